@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/example/linkedbot/internal/models"
+	"github.com/example/linkedbot/internal/store"
+)
+
+func runAudit(ctx context.Context, st *store.Store) (CommandResult, error) {
+	fs := flag.NewFlagSet("audit", flag.ContinueOnError)
+	var profileURL string
+	var limit int
+	var asJSON bool
+	fs.StringVar(&profileURL, "profile", "", "Only actions for this profile URL")
+	fs.IntVar(&limit, "limit", 50, "Number of most recent actions to show")
+	fs.BoolVar(&asJSON, "json", false, "Print the actions as JSON, one object per line")
+	if err := fs.Parse(flag.Args()[1:]); err != nil {
+		return CommandResult{}, err
+	}
+	if profileURL != "" {
+		profileURL = models.CanonicalProfileURL(profileURL)
+	}
+	actions, err := st.GetActionLogs(ctx, profileURL, limit)
+	if err != nil {
+		return CommandResult{}, err
+	}
+	if asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		for _, a := range actions {
+			if err := enc.Encode(map[string]any{
+				"time": a.CreatedAt, "profile_url": a.ProfileURL, "action": a.Action, "target": a.Target, "detail": a.Detail,
+				"before_screenshot": a.BeforePath, "after_screenshot": a.AfterPath, "error": a.Error,
+			}); err != nil {
+				return CommandResult{}, err
+			}
+		}
+		return CommandResult{Sent: len(actions)}, nil
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "time\tprofile\taction\ttarget\tdetail\terror\tscreenshots")
+	for _, a := range actions {
+		shots := strings.TrimSpace(a.BeforePath + " " + a.AfterPath)
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%q\t%s\t%s\n", a.CreatedAt.Local().Format("2006-01-02 15:04:05"), a.ProfileURL, a.Action,
+			a.Target, a.Detail, a.Error, shots)
+	}
+	return CommandResult{Sent: len(actions)}, tw.Flush()
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/example/linkedbot/internal/config"
+	"github.com/example/linkedbot/internal/exclusion"
+	"github.com/example/linkedbot/internal/store"
+)
+
+func runBlacklist(ctx context.Context, cfg *config.Config, st *store.Store) (CommandResult, error) {
+	args := flag.Args()[1:]
+	if len(args) == 0 {
+		return CommandResult{}, errors.New("usage: linkedbot blacklist add|remove|list")
+	}
+	sub := args[0]
+	fs := flag.NewFlagSet("blacklist "+sub, flag.ContinueOnError)
+	var company, name, url string
+	var asJSON bool
+	switch sub {
+	case "add", "remove":
+		fs.StringVar(&company, "company", "", "Company name (matches the company field or \"at Company\" in the headline)")
+		fs.StringVar(&name, "name", "", "Case-insensitive regular expression matched against the profile name")
+		fs.StringVar(&url, "url", "", "Profile URL")
+	case "list":
+		fs.BoolVar(&asJSON, "json", false, "Print the exclusions as JSON, one object per line")
+	default:
+		return CommandResult{}, fmt.Errorf("unknown blacklist subcommand %q (want add, remove or list)", sub)
+	}
+	if err := fs.Parse(args[1:]); err != nil {
+		return CommandResult{}, err
+	}
+
+	if sub == "list" {
+		list, err := exclusion.Load(ctx, cfg, st)
+		if err != nil {
+			return CommandResult{}, err
+		}
+		entries := list.Entries()
+		if asJSON {
+			enc := json.NewEncoder(os.Stdout)
+			for _, e := range entries {
+				if err := enc.Encode(map[string]any{"kind": e.Kind, "value": e.Value, "origin": e.Origin}); err != nil {
+					return CommandResult{}, err
+				}
+			}
+			return CommandResult{Sent: len(entries)}, nil
+		}
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "kind\tvalue\tfrom")
+		for _, e := range entries {
+			fmt.Fprintf(tw, "%s\t%s\t%s\n", e.Kind, e.Value, e.Origin)
+		}
+		return CommandResult{Sent: len(entries)}, tw.Flush()
+	}
+
+	var res CommandResult
+	given := 0
+	for _, e := range []struct{ kind, value string }{
+		{exclusion.KindCompany, company}, {exclusion.KindName, name}, {exclusion.KindURL, url},
+	} {
+		if e.value == "" {
+			continue
+		}
+		given++
+		value, err := exclusion.Normalize(e.kind, e.value)
+		if err != nil {
+			return res, err
+		}
+		var changed bool
+		if sub == "add" {
+			changed, err = st.AddExclusion(ctx, e.kind, value)
+		} else {
+			changed, err = st.RemoveExclusion(ctx, e.kind, value)
+		}
+		if err != nil {
+			return res, err
+		}
+		switch {
+		case changed && sub == "add":
+			fmt.Printf("excluded %s %q\n", e.kind, value)
+			res.Sent++
+		case changed:
+			fmt.Printf("removed %s %q\n", e.kind, value)
+			res.Sent++
+		case sub == "add":
+			fmt.Printf("%s %q is already excluded\n", e.kind, value)
+			res.Skipped++
+		default:
+			// Config exclusions can only be removed by editing the config
+			fmt.Printf("%s %q is not in the database blacklist\n", e.kind, value)
+			res.Skipped++
+		}
+	}
+	if given == 0 {
+		return res, fmt.Errorf("blacklist %s needs --company, --name or --url", sub)
+	}
+	return res, nil
+}
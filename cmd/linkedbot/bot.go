@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/example/linkedbot/internal/config"
+	"github.com/example/linkedbot/internal/ratelimit"
+	"github.com/example/linkedbot/internal/runstate"
+	"github.com/example/linkedbot/internal/store"
+	"github.com/example/linkedbot/internal/telegram"
+)
+
+// botCommands registers the daemon's Telegram commands. Pause and resume
+// act on this daemon only, like the dashboard buttons.
+func botCommands(bot *telegram.Bot, st *store.Store, rs *runstate.Manager) {
+	bot.Handle("pause", telegram.Command{Help: "skip scheduled jobs until /resume (the running one finishes)", Run: func(ctx context.Context, _ string) (string, error) {
+		rs.Pause()
+		return "Paused. Scheduled jobs are skipped until /resume.", nil
+	}})
+	bot.Handle("resume", telegram.Command{Help: "run scheduled jobs again", Run: func(ctx context.Context, _ string) (string, error) {
+		rs.Resume()
+		return "Resumed.", nil
+	}})
+	bot.Handle("stats", telegram.Command{Help: "today's quota, the running job and the last runs", Run: func(ctx context.Context, _ string) (string, error) {
+		return botStats(ctx, configs.Config(), st, rs)
+	}})
+	bot.Handle("pending", telegram.Command{Help: "the invite notes waiting for approval", Run: func(ctx context.Context, _ string) (string, error) {
+		return botPending(ctx, st)
+	}})
+	bot.Handle("approve", telegram.Command{Help: "approve the listed pending notes, or all of them without IDs", Run: func(ctx context.Context, args string) (string, error) {
+		var ids []int64
+		for _, f := range strings.Fields(args) {
+			id, err := strconv.ParseInt(strings.TrimPrefix(f, "#"), 10, 64)
+			if err != nil {
+				return "", fmt.Errorf("invalid approval id %q", f)
+			}
+			ids = append(ids, id)
+		}
+		n, err := st.DecideApprovals(ctx, ids, store.ApprovalApproved, "", "")
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Approved %d note(s); they go out with the next send-connections run.", n), nil
+	}})
+}
+
+// botPending lists the staged notes for /pending.
+func botPending(ctx context.Context, st *store.Store) (string, error) {
+	approvals, err := st.GetApprovals(ctx, store.ApprovalPending, 10)
+	if err != nil {
+		return "", err
+	}
+	if len(approvals) == 0 {
+		return "Nothing to review.", nil
+	}
+	var b strings.Builder
+	for _, a := range approvals {
+		fmt.Fprintf(&b, "#%d %s\n%s\n\n", a.ID, a.Name, a.Note)
+	}
+	counts, err := st.CountApprovals(ctx)
+	if err != nil {
+		return "", err
+	}
+	if more := counts[store.ApprovalPending] - len(approvals); more > 0 {
+		fmt.Fprintf(&b, "...and %d more\n\n", more)
+	}
+	b.WriteString("Approve with /approve ID... or /approve for all.")
+	return b.String(), nil
+}
+
+// botStats renders the daemon's state for /stats.
+func botStats(ctx context.Context, cfg *config.Config, st *store.Store, rs *runstate.Manager) (string, error) {
+	rl := ratelimit.New(cfg, st)
+	var b strings.Builder
+	state := rs.Snapshot()
+	if state.Paused {
+		b.WriteString("Paused\n")
+	}
+	for _, k := range []struct {
+		label string
+		kind  ratelimit.Kind
+	}{{"Invites", ratelimit.Connection}, {"Messages", ratelimit.Message}} {
+		budgets, err := rl.Usage(ctx, k.kind)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&b, "%s: %s\n", k.label, formatBudgets(budgets))
+	}
+	if err := checkCooldown(ctx, st); err != nil {
+		fmt.Fprintf(&b, "Cooldown: %v\n", err)
+	}
+	if r := state.Running; r != nil {
+		fmt.Fprintf(&b, "Running: %s for %s\n", r.Job, time.Since(r.Started).Round(time.Second))
+	}
+	for i, r := range state.Recent {
+		if i == 5 {
+			break
+		}
+		outcome := fmt.Sprintf("%d sent, %d skipped, %d failed", r.Sent, r.Skipped, r.Failed)
+		if r.Err != "" {
+			outcome += " (error: " + r.Err + ")"
+		}
+		fmt.Fprintf(&b, "%s %s: %s\n", r.Finished.Local().Format("15:04"), r.Job, outcome)
+	}
+	if len(state.Recent) == 0 && state.Running == nil {
+		b.WriteString("No jobs run yet\n")
+	}
+	return strings.TrimSuffix(b.String(), "\n"), nil
+}
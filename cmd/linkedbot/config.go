@@ -0,0 +1,45 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// runConfig prints the config file, or with --effective the configuration
+// in effect: defaults, file, --account, environment and flags merged, with
+// passwords in URLs masked.
+func runConfig() (CommandResult, error) {
+	args := flag.Args()[1:]
+	if len(args) == 0 || args[0] != "show" {
+		return CommandResult{}, errors.New("usage: config show [--effective]")
+	}
+	fs := flag.NewFlagSet("config show", flag.ContinueOnError)
+	var effective bool
+	fs.BoolVar(&effective, "effective", false, "Print the merged configuration instead of the file")
+	if err := fs.Parse(args[1:]); err != nil {
+		return CommandResult{}, err
+	}
+	if !effective {
+		b, err := os.ReadFile(configs.Path())
+		if err != nil {
+			return CommandResult{}, err
+		}
+		_, err = os.Stdout.Write(b)
+		return CommandResult{}, err
+	}
+	cfg := configs.Config().Redacted()
+	b, err := yaml.Marshal(&cfg)
+	if err != nil {
+		return CommandResult{}, err
+	}
+	fmt.Printf("# %s", configs.Path())
+	if cfg.Account != "" {
+		fmt.Printf(", account %s", cfg.Account)
+	}
+	fmt.Printf("\n# precedence: defaults < file < account < LINKEDBOT_* env < flags\n%s", b)
+	return CommandResult{}, nil
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"flag"
+
+	"github.com/example/linkedbot/internal/auth"
+	"github.com/example/linkedbot/internal/browser"
+	"github.com/example/linkedbot/internal/config"
+	"github.com/example/linkedbot/internal/connection"
+	"github.com/example/linkedbot/internal/logging"
+	"github.com/example/linkedbot/internal/store"
+)
+
+func runWithdrawConnections(ctx context.Context, cfg *config.Config, st *store.Store) (CommandResult, error) {
+	fs := flag.NewFlagSet("withdraw-connections", flag.ContinueOnError)
+	var days, limit int
+	fs.IntVar(&days, "days", cfg.Connection.WithdrawAfterDays, "Withdraw invites pending longer than this many days")
+	fs.IntVar(&limit, "limit", 20, "Max invites to withdraw in this run")
+	if err := fs.Parse(flag.Args()[1:]); err != nil {
+		return CommandResult{}, err
+	}
+
+	br, err := browser.New(ctx, cfg)
+	if err != nil {
+		return CommandResult{}, err
+	}
+	defer br.Close()
+	au := auth.New(br, cfg)
+	if err := au.EnsureLoggedIn(ctx); err != nil {
+		return CommandResult{}, err
+	}
+
+	svc := connection.New(br, cfg, st)
+	stats, err := svc.WithdrawStale(ctx, days, limit)
+	if err != nil {
+		return resultFromStats(stats), err
+	}
+	logging.New(cfg.Logging.Level).Info("invitations withdrawn", "count", stats.Sent, "failed", stats.Failed)
+	return resultFromStats(stats), nil
+}
+
+func runSyncConnections(ctx context.Context, cfg *config.Config, st *store.Store) (CommandResult, error) {
+	fs := flag.NewFlagSet("sync-connections", flag.ContinueOnError)
+	var limit int
+	fs.IntVar(&limit, "limit", 0, "Max connections to read (0 reads the whole list)")
+	if err := fs.Parse(flag.Args()[1:]); err != nil {
+		return CommandResult{}, err
+	}
+
+	br, err := browser.New(ctx, cfg)
+	if err != nil {
+		return CommandResult{}, err
+	}
+	defer br.Close()
+	au := auth.New(br, cfg)
+	if err := au.EnsureLoggedIn(ctx); err != nil {
+		return CommandResult{}, err
+	}
+
+	svc := connection.New(br, cfg, st)
+	stats, err := svc.SyncConnections(ctx, limit)
+	if err != nil {
+		return resultFromStats(stats), err
+	}
+	logging.New(cfg.Logging.Level).Info("connections synced", "changed", stats.Sent, "failed", stats.Failed)
+	return resultFromStats(stats), nil
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/example/linkedbot/internal/browser"
+	"github.com/example/linkedbot/internal/config"
+	"github.com/example/linkedbot/internal/logging"
+	"github.com/example/linkedbot/internal/notify"
+	"github.com/example/linkedbot/internal/store"
+)
+
+// browserCommands drive LinkedIn and are refused during a cooldown.
+var browserCommands = map[string]bool{
+	"login": true, "search": true, "enrich": true, "engage": true, "endorse": true, "nurture": true, "warm-view": true,
+	"send-connections": true, "send-messages": true, "withdraw-connections": true, "sync-connections": true,
+	"run-all": true, "daemon": true, "work": true, "retry-failed": true, "keep-alive": true,
+}
+
+// checkCooldown returns an error while a checkpoint cooldown is active.
+func checkCooldown(ctx context.Context, st *store.Store) error {
+	c, err := st.GetCooldown(ctx)
+	if err != nil {
+		return err
+	}
+	if !c.Active(time.Now()) {
+		return nil
+	}
+	until := "it is cleared"
+	if c.Until != nil {
+		until = c.Until.Local().Format("2006-01-02 15:04")
+	}
+	return fmt.Errorf("paused after a LinkedIn %s on %s until %s; resolve it in a normal browser, then run `linkedbot cooldown clear` to resume early",
+		c.Kind, c.DetectedAt.Local().Format("2006-01-02 15:04"), until)
+}
+
+// startCooldown records the checkpoint that aborted a run and notifies
+// webhooks. ctx is usually cancelled by then.
+func startCooldown(ctx context.Context, cfg *config.Config, st *store.Store, cp *browser.CheckpointError) {
+	ctx = context.WithoutCancel(ctx)
+	c := &store.Cooldown{Kind: cp.Kind, URL: cp.URL, DetectedAt: time.Now()}
+	if h := cfg.Checkpoint.CooldownHours; h > 0 {
+		until := c.DetectedAt.Add(time.Duration(h) * time.Hour)
+		c.Until = &until
+	}
+	log := logging.New(cfg.Logging.Level)
+	if err := st.SetCooldown(ctx, c); err != nil {
+		log.Error("failed to save cooldown", "err", err)
+	}
+	log.Warn("automation paused", "kind", cp.Kind, "url", cp.URL, "cooldown_hours", cfg.Checkpoint.CooldownHours)
+	notify.New(cfg).Notify(ctx, notify.EventCheckpoint, fmt.Sprintf("Run aborted: %s. Automation is paused.", cp.Error()), "")
+}
+
+// runCooldown shows the checkpoint cooldown and the invite cooldown, or
+// clears both.
+func runCooldown(ctx context.Context, st *store.Store) (CommandResult, error) {
+	args := flag.Args()[1:]
+	if len(args) > 0 && args[0] == "clear" {
+		cleared, err := st.ClearCooldown(ctx)
+		if err != nil {
+			return CommandResult{}, err
+		}
+		invites, err := st.ClearInviteCooldown(ctx)
+		if err != nil {
+			return CommandResult{}, err
+		}
+		if !cleared && !invites {
+			fmt.Println("no cooldown was set")
+			return CommandResult{}, nil
+		}
+		fmt.Println("cooldown cleared, automated actions are allowed again")
+		return CommandResult{Sent: 1}, nil
+	}
+	if len(args) > 0 {
+		return CommandResult{}, errors.New("usage: linkedbot cooldown [clear]")
+	}
+	invites, err := inviteCooldown(ctx, st)
+	if err != nil {
+		return CommandResult{}, err
+	}
+	if invites != "" {
+		fmt.Println(invites)
+	}
+	if err := checkCooldown(ctx, st); err != nil {
+		fmt.Println(err)
+		return CommandResult{}, nil
+	}
+	if invites == "" {
+		fmt.Println("no active cooldown")
+	}
+	return CommandResult{}, nil
+}
+
+// inviteCooldown describes an active invite cooldown, or returns "".
+func inviteCooldown(ctx context.Context, st *store.Store) (string, error) {
+	c, err := st.GetInviteCooldown(ctx)
+	if err != nil || !c.Active(time.Now()) {
+		return "", err
+	}
+	return fmt.Sprintf("invites paused after the LinkedIn %s on %s until %s; other actions go on",
+		c.Kind, c.DetectedAt.Local().Format("2006-01-02 15:04"), c.Until.Local().Format("2006-01-02 15:04")), nil
+}
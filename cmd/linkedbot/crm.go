@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+
+	"github.com/example/linkedbot/internal/config"
+	"github.com/example/linkedbot/internal/integrations"
+	"github.com/example/linkedbot/internal/logging"
+	"github.com/example/linkedbot/internal/store"
+)
+
+// runCRMSync pushes the profiles due to crm.provider now, or lists them with
+// --dry-run. The daemon runs it on crm.sync_cron.
+func runCRMSync(ctx context.Context, cfg *config.Config, st *store.Store) (CommandResult, error) {
+	fs := flag.NewFlagSet("crm-sync", flag.ContinueOnError)
+	var limit int
+	var dryRun bool
+	fs.IntVar(&limit, "limit", 100, "Max profiles to push")
+	fs.BoolVar(&dryRun, "dry-run", false, "List what would be pushed without calling the CRM")
+	if err := fs.Parse(flag.Args()[1:]); err != nil {
+		return CommandResult{}, err
+	}
+	if limit <= 0 {
+		return CommandResult{}, errors.New("--limit must be > 0")
+	}
+	if cfg.CRM.Provider == "" {
+		return CommandResult{}, errors.New("set crm.provider to hubspot or pipedrive")
+	}
+	syncer, err := integrations.New(cfg, st)
+	if err != nil {
+		return CommandResult{}, err
+	}
+	stats, err := syncer.Sync(ctx, limit, dryRun)
+	if err != nil {
+		return resultFromStats(stats), err
+	}
+	logging.New(cfg.Logging.Level).Info("crm synced", "provider", cfg.CRM.Provider, "count", stats.Sent, "failed", stats.Failed)
+	return resultFromStats(stats), nil
+}
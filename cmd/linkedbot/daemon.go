@@ -0,0 +1,306 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/example/linkedbot/internal/artifacts"
+	"github.com/example/linkedbot/internal/auth"
+	"github.com/example/linkedbot/internal/browser"
+	"github.com/example/linkedbot/internal/config"
+	"github.com/example/linkedbot/internal/connection"
+	"github.com/example/linkedbot/internal/dashboard"
+	"github.com/example/linkedbot/internal/engage"
+	"github.com/example/linkedbot/internal/integrations"
+	"github.com/example/linkedbot/internal/keepalive"
+	"github.com/example/linkedbot/internal/logging"
+	"github.com/example/linkedbot/internal/messaging"
+	"github.com/example/linkedbot/internal/models"
+	"github.com/example/linkedbot/internal/notify"
+	"github.com/example/linkedbot/internal/nurture"
+	"github.com/example/linkedbot/internal/runstate"
+	"github.com/example/linkedbot/internal/search"
+	"github.com/example/linkedbot/internal/store"
+	"github.com/example/linkedbot/internal/telegram"
+	"github.com/robfig/cron/v3"
+)
+
+type daemonJob struct {
+	name string
+	spec string
+	run  func(ctx context.Context, cfg *config.Config) (models.RunStats, error)
+}
+
+// runDaemon keeps one logged-in browser alive and runs the configured jobs on
+// their cron schedules until interrupted. Jobs never overlap: a tick that
+// fires while another job is still running is skipped, as is any tick
+// outside the stealth active window, or while paused from the dashboard.
+func runDaemon(ctx context.Context, cfg *config.Config, st *store.Store) (CommandResult, error) {
+	fs := flag.NewFlagSet("daemon", flag.ContinueOnError)
+	if err := fs.Parse(flag.Args()[1:]); err != nil {
+		return CommandResult{}, err
+	}
+	log := logging.New(cfg.Logging.Level).With("module", "daemon")
+
+	if cfg.Daemon.SearchCron == "" && cfg.Daemon.ConnectCron == "" && cfg.Daemon.MessageCron == "" && cfg.Daemon.NurtureCron == "" &&
+		cfg.Daemon.WorkerCron == "" && cfg.Daemon.KeepAliveCron == "" {
+		return CommandResult{}, errors.New("no jobs scheduled: set daemon.search_cron, connect_cron, message_cron, nurture_cron, worker_cron or keep_alive_cron")
+	}
+
+	br, err := browser.New(ctx, cfg)
+	if err != nil {
+		return CommandResult{}, err
+	}
+	defer br.Close()
+	au := auth.New(br, cfg)
+	if err := au.EnsureLoggedIn(ctx); err != nil {
+		return CommandResult{}, err
+	}
+
+	keepAliveSvc := keepalive.New(br, cfg)
+	// Services are built per run from the config current at the time, so
+	// reloaded limits and templates reach the next job
+	jobs := []daemonJob{
+		{"search", cfg.Daemon.SearchCron, func(ctx context.Context, cfg *config.Config) (models.RunStats, error) {
+			d := cfg.Search.Defaults
+			n, err := search.New(br, cfg, st).SearchAndStoreTargets(ctx, search.Criteria{Title: d.Title, Company: d.Company, Location: d.Location, Keywords: d.Keywords, Source: d.Source})
+			return models.RunStats{Sent: n}, err
+		}},
+		{"send-connections", cfg.Daemon.ConnectCron, func(ctx context.Context, cfg *config.Config) (models.RunStats, error) {
+			connSvc := connection.New(br, cfg, st)
+			stats, err := connSvc.SendConnections(ctx, 0)
+			if err != nil || cfg.Connection.WarmViewDays == 0 || ctx.Err() != nil {
+				return stats, err
+			}
+			// Visit the next batch now so it can be invited warm_view_days later
+			views, err := connSvc.WarmView(ctx, 0)
+			log.Info("warm-up views", "viewed", views.Sent, "failed", views.Failed)
+			stats.Failed += views.Failed
+			stats.Errors = append(stats.Errors, views.Errors...)
+			return stats, err
+		}},
+		{"send-messages", cfg.Daemon.MessageCron, func(ctx context.Context, cfg *config.Config) (models.RunStats, error) {
+			stats, err := messaging.New(br, cfg, st).SendFollowUps(ctx, 0, time.Time{})
+			if err != nil || !cfg.EndorseEnabled() || ctx.Err() != nil {
+				return stats, err
+			}
+			// Endorse the connections just found accepted
+			endorsed, err := engage.New(br, cfg, st).EndorseSkills(ctx, cfg.Engage.MaxPerDay)
+			log.Info("skill endorsements", "endorsed", endorsed.Sent, "failed", endorsed.Failed)
+			stats.Failed += endorsed.Failed
+			stats.Errors = append(stats.Errors, endorsed.Errors...)
+			return stats, err
+		}},
+		{"nurture", cfg.Daemon.NurtureCron, func(ctx context.Context, cfg *config.Config) (models.RunStats, error) {
+			return nurture.New(br, cfg, st).Run(ctx, cfg.Nurture.MaxPerDay)
+		}},
+		{"worker", cfg.Daemon.WorkerCron, func(ctx context.Context, cfg *config.Config) (models.RunStats, error) {
+			// Built per run so blacklist changes are picked up
+			wk, err := newWorker(ctx, br, cfg, st)
+			if err != nil {
+				return models.RunStats{}, err
+			}
+			return wk.Run(ctx, cfg.Limits.MaxConnectionsPerDay+cfg.Limits.MaxMessagesPerDay)
+		}},
+		{"keep-alive", cfg.Daemon.KeepAliveCron, func(ctx context.Context, _ *config.Config) (models.RunStats, error) {
+			stats, err := keepAliveSvc.Browse(ctx, keepAliveSvc.Duration())
+			if err != nil {
+				return stats, err
+			}
+			return stats, au.SaveSession(ctx)
+		}},
+	}
+
+	rs := runstate.New()
+	if cfg.Daemon.DashboardAddr != "" {
+		srv := dashboard.New(configs, st, rs)
+		go func() {
+			if err := srv.ListenAndServe(ctx, cfg.Daemon.DashboardAddr); err != nil {
+				log.Error("dashboard stopped", "err", err)
+			}
+		}()
+	}
+
+	if cfg.TelegramEnabled() && cfg.Notifications.Telegram.Commands {
+		bot := telegram.New(cfg)
+		botCommands(bot, st, rs)
+		go func() {
+			if err := bot.Listen(ctx); err != nil {
+				log.Error("telegram bot stopped", "err", err)
+			}
+		}()
+	}
+
+	var (
+		mu    sync.Mutex
+		total CommandResult
+	)
+	// Schedules are read in the account's zone, like the active window
+	c := cron.New(cron.WithLocation(cfg.Location()))
+	for _, j := range jobs {
+		if j.spec == "" {
+			continue
+		}
+		j := j
+		if _, err := c.AddFunc(j.spec, func() {
+			if !mu.TryLock() {
+				log.Warn("previous job still running, skipping", "job", j.name)
+				return
+			}
+			defer mu.Unlock()
+			if rs.Paused() {
+				log.Info("paused, skipping", "job", j.name)
+				return
+			}
+			if now := time.Now(); !cfg.InActiveWindow(now) {
+				log.Info("outside active window, skipping", "job", j.name, "active_hours", cfg.ActiveHours(now))
+				return
+			}
+			if err := checkCooldown(ctx, st); err != nil {
+				log.Info("skipping job", "job", j.name, "reason", err)
+				return
+			}
+			// A checkpoint aborts this job only; later jobs wait out the cooldown
+			jctx, cancel := browser.Abortable(ctx)
+			defer cancel()
+			rs.Start(j.name)
+			run := artifacts.Begin("daemon:" + j.name)
+			// The session can expire between runs; re-check before each job
+			if err := au.EnsureLoggedIn(jctx); err != nil {
+				rs.Finish(models.RunStats{}, err)
+				log.Error("login check failed", "job", j.name, "err", err)
+				total.Errors = append(total.Errors, j.name+": "+err.Error())
+				return
+			}
+			log.Info("job started", "job", j.name)
+			started := time.Now()
+			stats, err := j.run(jctx, configs.Config())
+			if cp := browser.CheckpointCause(jctx); cp != nil {
+				err = cp
+				startCooldown(ctx, cfg, st, cp)
+			}
+			rs.Finish(stats, err)
+			jr := resultFromStats(stats)
+			jr.Command, jr.OK, jr.Duration = "daemon:"+j.name, err == nil, time.Since(started)
+			if run.Len() > 0 {
+				jr.Artifacts = run.ID
+			}
+			if err != nil {
+				jr.Errors = append(jr.Errors, err.Error())
+			}
+			if err := recordRun(ctx, st, started, jr); err != nil {
+				log.Warn("failed to record run", "job", j.name, "err", err)
+			}
+			total.add(resultFromStats(stats))
+			if err != nil {
+				log.Error("job failed", "job", j.name, "err", err)
+				total.Errors = append(total.Errors, j.name+": "+err.Error())
+				if browser.CheckpointCause(jctx) == nil && ctx.Err() == nil {
+					notify.New(cfg).Notify(ctx, notify.EventFatalError, fmt.Sprintf("daemon job %s failed: %v", j.name, err), "")
+				}
+				return
+			}
+			log.Info("job finished", "job", j.name, "sent", stats.Sent, "failed", stats.Failed)
+			notify.New(cfg).Notify(ctx, notify.EventRunComplete, fmt.Sprintf("daemon job %s finished: %d sent, %d skipped, %d failed", j.name, stats.Sent, stats.Skipped, stats.Failed), "")
+		}); err != nil {
+			return CommandResult{}, err
+		}
+		log.Info("job scheduled", "job", j.name, "cron", j.spec)
+	}
+
+	// The digest reports on the account whatever its state, so it is not
+	// held back by pauses, the active window or cooldowns
+	if spec := cfg.Notifications.Email.DigestCron; spec != "" && cfg.EmailEnabled() {
+		if _, err := c.AddFunc(spec, func() {
+			subject, body, err := buildDigest(ctx, st, 24*time.Hour)
+			if err == nil {
+				err = notify.New(cfg).Email(ctx, subject, body)
+			}
+			if err != nil {
+				log.Warn("digest not sent", "err", err)
+				return
+			}
+			log.Info("digest sent")
+		}); err != nil {
+			return CommandResult{}, err
+		}
+		log.Info("job scheduled", "job", "digest", "cron", spec)
+	}
+	// Neither do the CRM and sheet syncs, which never touch LinkedIn
+	if spec := cfg.CRM.SyncCron; spec != "" && cfg.CRM.Provider != "" {
+		syncer, err := integrations.New(cfg, st)
+		if err != nil {
+			return CommandResult{}, err
+		}
+		if _, err := c.AddFunc(spec, func() {
+			stats, err := syncer.Sync(ctx, 100, false)
+			if err != nil {
+				log.Warn("crm sync failed", "err", err)
+				return
+			}
+			log.Info("crm synced", "count", stats.Sent, "failed", stats.Failed)
+		}); err != nil {
+			return CommandResult{}, err
+		}
+		log.Info("job scheduled", "job", "crm-sync", "cron", spec)
+	}
+	if spec := cfg.Sheets.SyncCron; spec != "" && cfg.SheetsEnabled() {
+		sh, err := integrations.NewSheets(cfg, st)
+		if err != nil {
+			return CommandResult{}, err
+		}
+		if _, err := c.AddFunc(spec, func() {
+			ch, rows, err := syncSheet(ctx, sh, false)
+			if err != nil {
+				log.Warn("sheets sync failed", "err", err)
+				return
+			}
+			log.Info("sheets synced", "rows", rows, "do_not_contact", ch.DoNotContact, "notes", ch.Notes)
+		}); err != nil {
+			return CommandResult{}, err
+		}
+		log.Info("job scheduled", "job", "sheets-sync", "cron", spec)
+	}
+
+	// Limits and templates edited in the config take effect between jobs
+	reload := func() {
+		mu.Lock()
+		defer mu.Unlock()
+		applied, restart, err := configs.Reload()
+		switch {
+		case err != nil:
+			log.Error("config reload failed, keeping the current config", "err", err)
+		case len(applied) > 0 || len(restart) > 0:
+			log.Info("config reloaded", "applied", applied, "needs_restart", restart)
+		}
+	}
+	if sec := cfg.Daemon.ConfigReloadSec; sec > 0 {
+		go configs.Watch(ctx, time.Duration(sec)*time.Second, reload)
+	}
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-hup:
+				reload()
+			}
+		}
+	}()
+
+	c.Start()
+	<-ctx.Done()
+	log.Info("shutting down, waiting for running job")
+	<-c.Stop().Done()
+	return total, nil
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+
+	"github.com/example/linkedbot/internal/artifacts"
+	"github.com/example/linkedbot/internal/config"
+	"github.com/example/linkedbot/internal/logging"
+)
+
+// runDebug lists the runs that saved debug artifacts, shows one of them, or
+// applies the cleanup policy.
+func runDebug(cfg *config.Config) (CommandResult, error) {
+	args := flag.Args()[1:]
+	sub := "list"
+	if len(args) > 0 {
+		sub, args = args[0], args[1:]
+	}
+	switch sub {
+	case "list":
+		ids, err := artifacts.List()
+		if err != nil {
+			return CommandResult{}, err
+		}
+		for _, id := range ids {
+			idx, err := artifacts.Load(id)
+			if err != nil {
+				fmt.Printf("%s  (%v)\n", id, err)
+				continue
+			}
+			fmt.Printf("%s  %-24s %d artifact(s)\n", id, idx.Command, len(idx.Entries))
+		}
+		if len(ids) == 0 {
+			fmt.Println("no debug artifacts")
+		}
+		return CommandResult{Sent: len(ids)}, nil
+	case "open":
+		if len(args) != 1 {
+			return CommandResult{}, errors.New("usage: debug open RUN-ID|latest")
+		}
+		id := args[0]
+		if id == "latest" {
+			ids, err := artifacts.List()
+			if err != nil {
+				return CommandResult{}, err
+			}
+			if len(ids) == 0 {
+				return CommandResult{}, errors.New("no debug artifacts")
+			}
+			id = ids[0]
+		}
+		idx, err := artifacts.Load(id)
+		if err != nil {
+			return CommandResult{}, err
+		}
+		dir, _ := filepath.Abs(filepath.Join(artifacts.Root, id))
+		fmt.Printf("run %s (%s, started %s)\n%s\n\n", idx.Run, idx.Command, idx.Started.Format("2006-01-02 15:04:05"), dir)
+		for _, e := range idx.Entries {
+			fmt.Printf("%s  %s\n", e.Time.Format("15:04:05"), e.Action)
+			if e.Profile != "" {
+				fmt.Printf("    profile:    %s\n", e.Profile)
+			} else if e.URL != "" {
+				fmt.Printf("    page:       %s\n", e.URL)
+			}
+			if e.Error != "" {
+				fmt.Printf("    error:      %s\n", e.Error)
+			}
+			if e.Screenshot != "" {
+				fmt.Printf("    screenshot: %s\n", filepath.Join(dir, e.Screenshot))
+			}
+			if e.HTML != "" {
+				fmt.Printf("    html:       %s\n", filepath.Join(dir, e.HTML))
+			}
+		}
+		if err := openFolder(dir); err != nil {
+			logging.New(cfg.Logging.Level).Debug("could not open the folder", "dir", dir, "err", err)
+		}
+		return CommandResult{Sent: len(idx.Entries)}, nil
+	case "clean":
+		removed, err := artifacts.Cleanup(cfg.Debug.KeepDays, cfg.Debug.MaxRuns)
+		if err != nil {
+			return CommandResult{}, err
+		}
+		fmt.Printf("removed %d run(s)\n", len(removed))
+		return CommandResult{Sent: len(removed)}, nil
+	}
+	return CommandResult{}, fmt.Errorf("unknown debug subcommand %q (want list, open or clean)", sub)
+}
+
+// openFolder shows dir in the desktop's file manager.
+func openFolder(dir string) error {
+	var c *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		c = exec.Command("open", dir)
+	case "windows":
+		c = exec.Command("explorer", dir)
+	default:
+		c = exec.Command("xdg-open", dir)
+	}
+	return c.Start()
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	"github.com/example/linkedbot/internal/store"
+)
+
+// runDedupe rewrites stored profile URLs to the canonical /in/<slug> form
+// and merges rows that are the same member. Slug changes seen while
+// visiting profiles are followed as they happen; this cleans up the rest.
+func runDedupe(ctx context.Context, st *store.Store) (CommandResult, error) {
+	fs := flag.NewFlagSet("dedupe", flag.ContinueOnError)
+	var dryRun bool
+	fs.BoolVar(&dryRun, "dry-run", false, "Only count what would change")
+	if err := fs.Parse(flag.Args()[1:]); err != nil {
+		return CommandResult{}, err
+	}
+	res, err := st.Dedupe(ctx, dryRun)
+	if err != nil {
+		return CommandResult{}, err
+	}
+	merge, normalize := "merged", "normalized"
+	if dryRun {
+		merge, normalize = "would merge", "would normalize"
+	}
+	fmt.Printf("%s %d duplicate profile(s), %s %d URL(s)\n", merge, res.Merged, normalize, res.Normalized)
+	return CommandResult{Sent: res.Merged}, nil
+}
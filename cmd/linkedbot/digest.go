@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/example/linkedbot/internal/config"
+	"github.com/example/linkedbot/internal/notify"
+	"github.com/example/linkedbot/internal/store"
+)
+
+// runDigest mails the digest of the last --since (24h) now, or prints it
+// with --dry-run. The daemon sends it on notifications.email.digest_cron.
+func runDigest(ctx context.Context, cfg *config.Config, st *store.Store) (CommandResult, error) {
+	fs := flag.NewFlagSet("digest", flag.ContinueOnError)
+	var since time.Duration
+	var dryRun bool
+	fs.DurationVar(&since, "since", 24*time.Hour, "Period the digest covers, ending now")
+	fs.BoolVar(&dryRun, "dry-run", false, "Print the digest instead of mailing it")
+	if err := fs.Parse(flag.Args()[1:]); err != nil {
+		return CommandResult{}, err
+	}
+	if since <= 0 {
+		return CommandResult{}, errors.New("--since must be > 0")
+	}
+	if !dryRun && !cfg.EmailEnabled() {
+		return CommandResult{}, errors.New("set notifications.email.host and to, or use --dry-run")
+	}
+	subject, body, err := buildDigest(ctx, st, since)
+	if err != nil {
+		return CommandResult{}, err
+	}
+	if dryRun {
+		fmt.Printf("Subject: %s\n\n%s", subject, body)
+		return CommandResult{}, nil
+	}
+	if err := notify.New(cfg).Email(ctx, subject, body); err != nil {
+		return CommandResult{}, fmt.Errorf("send digest: %w", err)
+	}
+	fmt.Printf("digest sent to %d recipient(s)\n", len(cfg.Notifications.Email.To))
+	return CommandResult{Sent: 1}, nil
+}
+
+// buildDigest renders the activity of the period ending now.
+func buildDigest(ctx context.Context, st *store.Store, period time.Duration) (subject, body string, err error) {
+	now := time.Now()
+	a, err := st.GetActivity(ctx, now.Add(-period), now)
+	if err != nil {
+		return "", "", err
+	}
+	cooldown, err := st.GetCooldown(ctx)
+	if err != nil {
+		return "", "", err
+	}
+	subject, body = notify.Digest(a, cooldown)
+	return subject, body, nil
+}
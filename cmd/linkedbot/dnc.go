@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/example/linkedbot/internal/config"
+	"github.com/example/linkedbot/internal/exclusion"
+	"github.com/example/linkedbot/internal/importer"
+	"github.com/example/linkedbot/internal/logging"
+	"github.com/example/linkedbot/internal/store"
+)
+
+// runDoNotContact manages the do-not-contact list. Unlike the blacklist it
+// is a compliance record: listed people are never stored, and a stored
+// profile moves to do_not_contact for good.
+func runDoNotContact(ctx context.Context, cfg *config.Config, st *store.Store) (CommandResult, error) {
+	args := flag.Args()[1:]
+	if len(args) == 0 {
+		return CommandResult{}, errors.New("usage: linkedbot dnc add|remove|import|list|log")
+	}
+	sub := args[0]
+	fs := flag.NewFlagSet("dnc "+sub, flag.ContinueOnError)
+	var profileURL, reason, file string
+	var limit int
+	var asJSON bool
+	switch sub {
+	case "add":
+		fs.StringVar(&profileURL, "url", "", "Profile URL")
+		fs.StringVar(&reason, "reason", "", "Why, e.g. \"unsubscribe request\"")
+	case "remove":
+		fs.StringVar(&profileURL, "url", "", "Profile URL")
+	case "import":
+		fs.StringVar(&file, "file", "", "CSV or plain list of profile URLs, optionally with a reason column")
+		fs.StringVar(&reason, "reason", "", "Reason for rows without one")
+	case "list":
+		fs.BoolVar(&asJSON, "json", false, "Print the list as JSON, one object per line")
+	case "log":
+		fs.IntVar(&limit, "limit", 50, "Number of most recent suppressions to show")
+		fs.BoolVar(&asJSON, "json", false, "Print the suppressions as JSON, one object per line")
+	default:
+		return CommandResult{}, fmt.Errorf("unknown dnc subcommand %q (want add, remove, import, list or log)", sub)
+	}
+	if err := fs.Parse(args[1:]); err != nil {
+		return CommandResult{}, err
+	}
+
+	switch sub {
+	case "add", "remove":
+		if profileURL == "" {
+			return CommandResult{}, fmt.Errorf("dnc %s needs --url", sub)
+		}
+		value, err := exclusion.Normalize(exclusion.KindURL, profileURL)
+		if err != nil {
+			return CommandResult{}, err
+		}
+		var changed bool
+		if sub == "add" {
+			changed, err = st.AddDoNotContact(ctx, value, reason)
+		} else {
+			changed, err = st.RemoveDoNotContact(ctx, value)
+		}
+		if err != nil {
+			return CommandResult{}, err
+		}
+		switch {
+		case changed && sub == "add":
+			fmt.Printf("%s is on the do-not-contact list\n", value)
+		case changed:
+			fmt.Printf("removed %s from the do-not-contact list; its stored profile stays do_not_contact\n", value)
+		case sub == "add":
+			fmt.Printf("%s is already on the do-not-contact list\n", value)
+			return CommandResult{Skipped: 1}, nil
+		default:
+			fmt.Printf("%s is not on the do-not-contact list\n", value)
+			return CommandResult{Skipped: 1}, nil
+		}
+		return CommandResult{Sent: 1}, nil
+	case "import":
+		if file == "" {
+			return CommandResult{}, errors.New("--file is required")
+		}
+		f, err := os.Open(file)
+		if err != nil {
+			return CommandResult{}, err
+		}
+		defer f.Close()
+		res, err := importer.DoNotContactCSV(ctx, st, f, reason)
+		out := CommandResult{Sent: res.Added, Skipped: res.Existing, Failed: res.Invalid, Errors: res.Malformed}
+		if err != nil {
+			return out, err
+		}
+		log := logging.New(cfg.Logging.Level)
+		for _, m := range res.Malformed {
+			log.Warn("skipped non-profile URL", "row", m)
+		}
+		log.Info("do-not-contact import complete", "added", res.Added, "already_listed", res.Existing, "invalid", res.Invalid)
+		return out, nil
+	case "list":
+		list, err := st.GetDoNotContact(ctx)
+		if err != nil {
+			return CommandResult{}, err
+		}
+		if asJSON {
+			enc := json.NewEncoder(os.Stdout)
+			for _, d := range list {
+				if err := enc.Encode(map[string]any{"linkedin_url": d.URL, "reason": d.Reason, "created_at": d.CreatedAt}); err != nil {
+					return CommandResult{}, err
+				}
+			}
+			return CommandResult{Sent: len(list)}, nil
+		}
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "added\tprofile\treason")
+		for _, d := range list {
+			fmt.Fprintf(tw, "%s\t%s\t%s\n", d.CreatedAt.Local().Format("2006-01-02 15:04"), d.URL, d.Reason)
+		}
+		return CommandResult{Sent: len(list)}, tw.Flush()
+	}
+	sups, err := st.GetSuppressions(ctx, limit)
+	if err != nil {
+		return CommandResult{}, err
+	}
+	if asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		for _, sp := range sups {
+			if err := enc.Encode(map[string]any{"linkedin_url": sp.URL, "action": sp.Action, "created_at": sp.CreatedAt}); err != nil {
+				return CommandResult{}, err
+			}
+		}
+		return CommandResult{Sent: len(sups)}, nil
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "time\taction\tprofile")
+	for _, sp := range sups {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", sp.CreatedAt.Local().Format("2006-01-02 15:04:05"), sp.Action, sp.URL)
+	}
+	return CommandResult{Sent: len(sups)}, tw.Flush()
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+
+	"github.com/example/linkedbot/internal/auth"
+	"github.com/example/linkedbot/internal/browser"
+	"github.com/example/linkedbot/internal/config"
+	"github.com/example/linkedbot/internal/engage"
+	"github.com/example/linkedbot/internal/logging"
+	"github.com/example/linkedbot/internal/store"
+)
+
+func runEngage(ctx context.Context, cfg *config.Config, st *store.Store) (CommandResult, error) {
+	fs := flag.NewFlagSet("engage", flag.ContinueOnError)
+	var limit int
+	var comment bool
+	fs.IntVar(&limit, "limit", 10, "Max profiles to visit in this run")
+	fs.BoolVar(&comment, "comment", cfg.Engage.CommentTemplate != "", "Also comment with engage.comment_template")
+	if err := fs.Parse(flag.Args()[1:]); err != nil {
+		return CommandResult{}, err
+	}
+
+	br, err := browser.New(ctx, cfg)
+	if err != nil {
+		return CommandResult{}, err
+	}
+	defer br.Close()
+	au := auth.New(br, cfg)
+	if err := au.EnsureLoggedIn(ctx); err != nil {
+		return CommandResult{}, err
+	}
+
+	svc := engage.New(br, cfg, st)
+	stats, err := svc.EngageProfiles(ctx, limit, comment)
+	if err != nil {
+		return resultFromStats(stats), err
+	}
+	logging.New(cfg.Logging.Level).Info("profiles engaged", "count", stats.Sent, "skipped", stats.Skipped, "failed", stats.Failed)
+	return resultFromStats(stats), nil
+}
+
+func runEndorse(ctx context.Context, cfg *config.Config, st *store.Store) (CommandResult, error) {
+	fs := flag.NewFlagSet("endorse", flag.ContinueOnError)
+	var limit int
+	fs.IntVar(&limit, "limit", 10, "Max profiles to visit in this run")
+	if err := fs.Parse(flag.Args()[1:]); err != nil {
+		return CommandResult{}, err
+	}
+	if !cfg.EndorseEnabled() {
+		return CommandResult{}, errors.New("nothing to endorse: set engage.endorse_skills or a campaign's endorse_skills")
+	}
+
+	br, err := browser.New(ctx, cfg)
+	if err != nil {
+		return CommandResult{}, err
+	}
+	defer br.Close()
+	au := auth.New(br, cfg)
+	if err := au.EnsureLoggedIn(ctx); err != nil {
+		return CommandResult{}, err
+	}
+
+	svc := engage.New(br, cfg, st)
+	stats, err := svc.EndorseSkills(ctx, limit)
+	if err != nil {
+		return resultFromStats(stats), err
+	}
+	logging.New(cfg.Logging.Level).Info("connections endorsed", "count", stats.Sent, "skipped", stats.Skipped, "failed", stats.Failed)
+	return resultFromStats(stats), nil
+}
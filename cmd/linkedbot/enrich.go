@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"time"
+
+	"github.com/example/linkedbot/internal/auth"
+	"github.com/example/linkedbot/internal/browser"
+	"github.com/example/linkedbot/internal/config"
+	"github.com/example/linkedbot/internal/enrich"
+	"github.com/example/linkedbot/internal/logging"
+	"github.com/example/linkedbot/internal/store"
+)
+
+func runEnrich(ctx context.Context, cfg *config.Config, st *store.Store) (CommandResult, error) {
+	fs := flag.NewFlagSet("enrich", flag.ContinueOnError)
+	var limit, refreshDays int
+	fs.IntVar(&limit, "limit", 20, "Max profiles to visit in this run")
+	fs.IntVar(&refreshDays, "refresh-days", 30, "Re-enrich profiles whose details are older than this many days (0 = never)")
+	if err := fs.Parse(flag.Args()[1:]); err != nil {
+		return CommandResult{}, err
+	}
+
+	br, err := browser.New(ctx, cfg)
+	if err != nil {
+		return CommandResult{}, err
+	}
+	defer br.Close()
+	au := auth.New(br, cfg)
+	if err := au.EnsureLoggedIn(ctx); err != nil {
+		return CommandResult{}, err
+	}
+
+	svc := enrich.New(br, cfg, st)
+	stats, err := svc.EnrichProfiles(ctx, limit, time.Duration(refreshDays)*24*time.Hour)
+	if err != nil {
+		return resultFromStats(stats), err
+	}
+	logging.New(cfg.Logging.Level).Info("profiles enriched", "count", stats.Sent, "failed", stats.Failed)
+	return resultFromStats(stats), nil
+}
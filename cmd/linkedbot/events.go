@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/example/linkedbot/internal/store"
+)
+
+// runEvents lists the newest events, or with poll prints the events after
+// --after as JSON lines, oldest first, for scripts that keep the last id.
+func runEvents(ctx context.Context, st *store.Store) (CommandResult, error) {
+	args := flag.Args()[1:]
+	poll := len(args) > 0 && args[0] == "poll"
+	if poll || (len(args) > 0 && args[0] == "list") {
+		args = args[1:]
+	}
+	fs := flag.NewFlagSet("events", flag.ContinueOnError)
+	var after int64
+	var typ string
+	var limit int
+	fs.Int64Var(&after, "after", 0, "Only events with a higher id")
+	fs.StringVar(&typ, "type", "", "Only events of this type ("+strings.Join(store.EventTypes, ", ")+")")
+	fs.IntVar(&limit, "limit", 50, "Number of events to show")
+	if err := fs.Parse(args); err != nil {
+		return CommandResult{}, err
+	}
+	if typ != "" && !slices.Contains(store.EventTypes, typ) {
+		return CommandResult{}, fmt.Errorf("--type must be one of %s", strings.Join(store.EventTypes, ", "))
+	}
+	if limit <= 0 {
+		return CommandResult{}, errors.New("--limit must be > 0")
+	}
+	events, err := st.GetEvents(ctx, after, typ, limit, poll)
+	if err != nil {
+		return CommandResult{}, err
+	}
+	if poll {
+		enc := json.NewEncoder(os.Stdout)
+		for _, e := range events {
+			if err := enc.Encode(e); err != nil {
+				return CommandResult{}, err
+			}
+		}
+		return CommandResult{Sent: len(events)}, nil
+	}
+	if len(events) == 0 {
+		fmt.Println("no events")
+		return CommandResult{}, nil
+	}
+	for _, e := range events {
+		fmt.Printf("%d\t%s\t%-18s\t%s\t%s\n", e.ID, e.CreatedAt.Local().Format("2006-01-02 15:04"), e.Type, e.Profile.Name, e.Profile.URL)
+	}
+	return CommandResult{Sent: len(events)}, nil
+}
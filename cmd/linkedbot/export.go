@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/example/linkedbot/internal/export"
+	"github.com/example/linkedbot/internal/store"
+)
+
+func runExport(ctx context.Context, st *store.Store) (CommandResult, error) {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	var view, format, filter, out string
+	var includePII bool
+	fs.StringVar(&view, "view", "profiles", "What to export: profiles or accepted (case-study report)")
+	fs.StringVar(&format, "format", "csv", "Output format: csv or json")
+	fs.StringVar(&filter, "filter", "", "Profiles to include: accepted, pending, messaged (default all)")
+	fs.StringVar(&out, "out", "", "Output file (default stdout)")
+	fs.BoolVar(&includePII, "include-pii", false, "Include names and profile URLs in the accepted report")
+	if err := fs.Parse(flag.Args()[1:]); err != nil {
+		return CommandResult{}, err
+	}
+
+	var table export.Table
+	switch view {
+	case "profiles":
+		profiles, err := st.ExportProfiles(ctx, filter)
+		if err != nil {
+			return CommandResult{}, err
+		}
+		table = export.Profiles(profiles)
+	case "accepted":
+		rows, err := st.GetAcceptedAndMessaged(ctx)
+		if err != nil {
+			return CommandResult{}, err
+		}
+		table = export.Accepted(rows, includePII)
+	default:
+		return CommandResult{}, fmt.Errorf("unknown export view: %s", view)
+	}
+
+	w := os.Stdout
+	if out != "" {
+		f, err := os.Create(out)
+		if err != nil {
+			return CommandResult{}, err
+		}
+		defer f.Close()
+		w = f
+	}
+	return CommandResult{Sent: len(table.Rows)}, export.Write(w, table, format)
+}
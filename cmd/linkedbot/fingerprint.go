@@ -0,0 +1,45 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/example/linkedbot/internal/browser"
+	"github.com/example/linkedbot/internal/config"
+)
+
+// runFingerprint prints the fingerprint the browser presents for this
+// account, or with "regenerate" replaces it so the next run looks like a new
+// device.
+func runFingerprint(cfg *config.Config) (CommandResult, error) {
+	args := flag.Args()[1:]
+	var fp browser.SessionFingerprint
+	var err error
+	switch {
+	case len(args) == 0 || args[0] == "show":
+		fp, err = browser.LoadFingerprint(cfg)
+	case args[0] == "regenerate":
+		fp, err = browser.RegenerateFingerprint(cfg)
+	default:
+		return CommandResult{}, fmt.Errorf("unknown fingerprint subcommand %q (want show or regenerate)", args[0])
+	}
+	if err != nil {
+		return CommandResult{}, err
+	}
+	fmt.Printf("account:    %s\n", cfg.AccountKey())
+	fmt.Printf("user agent: %s\n", fp.UserAgent)
+	fmt.Printf("platform:   %s\n", fp.Platform)
+	fmt.Printf("viewport:   %dx%d\n", fp.Width, fp.Height)
+	fmt.Printf("cpu cores:  %d\n", fp.HardwareConcurrency)
+	fmt.Printf("memory:     %d GB\n", fp.DeviceMemory)
+	fmt.Printf("webgl:      %s / %s\n", fp.WebGLVendor, fp.WebGLRenderer)
+	fmt.Printf("languages:  %s\n", strings.Join(fp.Languages, ", "))
+	tz := fp.Timezone
+	if tz == "" {
+		tz = "(machine's)"
+	}
+	fmt.Printf("timezone:   %s\n", tz)
+	fmt.Printf("created:    %s\n", fp.CreatedAt.Format("2006-01-02 15:04"))
+	return CommandResult{}, nil
+}
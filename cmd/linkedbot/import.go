@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+
+	"github.com/example/linkedbot/internal/config"
+	"github.com/example/linkedbot/internal/importer"
+	"github.com/example/linkedbot/internal/logging"
+	"github.com/example/linkedbot/internal/store"
+)
+
+func runImport(ctx context.Context, cfg *config.Config, st *store.Store) (CommandResult, error) {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	var file, source string
+	fs.StringVar(&file, "file", "", "CSV or plain list of LinkedIn profile URLs")
+	fs.StringVar(&source, "source", "", "Source label for the imported profiles (default import:<file name>)")
+	if err := fs.Parse(flag.Args()[1:]); err != nil {
+		return CommandResult{}, err
+	}
+	if file == "" {
+		return CommandResult{}, errors.New("--file is required")
+	}
+	if source == "" {
+		source = "import:" + filepath.Base(file)
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		return CommandResult{}, err
+	}
+	defer f.Close()
+	res, err := importer.CSV(ctx, st, f, source)
+	out := CommandResult{Sent: res.Added, Skipped: res.Existing + res.Suppressed, Failed: res.Invalid, Errors: res.Malformed}
+	if err != nil {
+		return out, err
+	}
+	log := logging.New(cfg.Logging.Level)
+	for _, m := range res.Malformed {
+		log.Warn("skipped non-profile URL", "row", m)
+	}
+	log.Info("import complete", "added", res.Added, "already_stored", res.Existing, "invalid", res.Invalid, "do_not_contact", res.Suppressed, "source", source)
+	return out, nil
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/example/linkedbot/internal/auth"
+	"github.com/example/linkedbot/internal/browser"
+	"github.com/example/linkedbot/internal/config"
+	"github.com/example/linkedbot/internal/keepalive"
+)
+
+// runKeepAlive browses the feed for keep_alive.min/max_minutes (or
+// --minutes) and saves the refreshed session cookies.
+func runKeepAlive(ctx context.Context, cfg *config.Config) (CommandResult, error) {
+	fs := flag.NewFlagSet("keep-alive", flag.ContinueOnError)
+	var minutes int
+	fs.IntVar(&minutes, "minutes", 0, "Minutes to read the feed (default: random between keep_alive.min_minutes and max_minutes)")
+	if err := fs.Parse(flag.Args()[1:]); err != nil {
+		return CommandResult{}, err
+	}
+	if minutes < 0 {
+		return CommandResult{}, fmt.Errorf("--minutes must be >= 0, got %d", minutes)
+	}
+
+	br, err := browser.New(ctx, cfg)
+	if err != nil {
+		return CommandResult{}, err
+	}
+	defer br.Close()
+	au := auth.New(br, cfg)
+	if err := au.EnsureLoggedIn(ctx); err != nil {
+		return CommandResult{}, err
+	}
+
+	svc := keepalive.New(br, cfg)
+	d := svc.Duration()
+	if minutes > 0 {
+		d = time.Duration(minutes) * time.Minute
+	}
+	stats, err := svc.Browse(ctx, d)
+	if err != nil {
+		return resultFromStats(stats), err
+	}
+	if err := au.SaveSession(context.WithoutCancel(ctx)); err != nil {
+		return resultFromStats(stats), fmt.Errorf("save session: %w", err)
+	}
+	return resultFromStats(stats), nil
+}
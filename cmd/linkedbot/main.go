@@ -5,38 +5,138 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 	"time"
 
+	"github.com/example/linkedbot/internal/artifacts"
 	"github.com/example/linkedbot/internal/auth"
 	"github.com/example/linkedbot/internal/browser"
 	"github.com/example/linkedbot/internal/config"
 	"github.com/example/linkedbot/internal/connection"
 	"github.com/example/linkedbot/internal/logging"
 	"github.com/example/linkedbot/internal/messaging"
+	"github.com/example/linkedbot/internal/notify"
 	"github.com/example/linkedbot/internal/search"
 	"github.com/example/linkedbot/internal/store"
+	"github.com/example/linkedbot/internal/tracing"
 )
 
+// configs holds the loaded config; the daemon reloads it and config show
+// prints it.
+var configs *config.Manager
+
 func main() {
-	ctx := context.Background()
+	// Ctrl+C or SIGTERM cancels ctx: batch commands finish the profile in
+	// flight, save their progress and resume from there next run. A second
+	// signal kills the process as usual.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
 
 	// Global flags
-	var cfgPath string
+	var cfgPath, account, remoteURL string
+	var jsonOut, headful, attach bool
 	flag.StringVar(&cfgPath, "config", "config.yaml", "Path to config file")
+	flag.StringVar(&account, "account", "", "Account from the accounts section of the config")
+	flag.BoolVar(&headful, "headful", false, "Show the browser window even if stealth.headless is set")
+	flag.BoolVar(&attach, "attach", false, "Drive your own Chrome, started with --remote-debugging-port (browser.debug_port), and its LinkedIn session")
+	flag.StringVar(&remoteURL, "remote-url", "", "Drive the Chrome or Browserless instance at this ws:// or http:// URL instead of launching one")
+	flag.BoolVar(&jsonOut, "json", false, "Print a JSON result line when the command finishes")
 
 	flag.Usage = func() {
 		fmt.Fprintf(os.Stderr, `linkedbot - LinkedIn automation CLI (PoC)
 
 Usage:
-  linkedbot [--config config.yaml] <command> [options]
+  linkedbot [--config config.yaml] [--account NAME] [--headful] [--remote-url URL | --attach] [--json] <command> [options]
 
 Commands:
   login                          Ensure logged in session (with cookie reuse)
-  search [--title T --company C --location L --keywords K --limit N]
+  login --manual [--timeout 10m] Log in by hand (2FA, CAPTCHA) in the browser window; the session and fingerprint are saved
+  search [--title T --company C --location L --keywords K --limit N --backend dom|voyager --source group:ID|event:ID]
                                   Search and store target profiles
-  send-connections [--limit N]   Send up to N connection requests
-  send-messages [--limit N]      Send follow-up messages to newly accepted connections
+  import --file targets.csv      Add profile URLs (optionally with name/company columns) to the queue
+  enrich [--limit N --refresh-days D]
+                                 Scrape about, experience, education, skills and mutual connections
+  engage [--limit N --comment]   Like (and comment on) a recent post of profiles not yet invited
+  warm-view [--limit N]          Visit the next profiles in the invite queue ahead of the invite
+  send-connections [--limit N --degree 2nd,3rd --tag T1,T2 --require-approval]
+                                 Send up to N connection requests (or stage their notes for review)
+  review [list --state S --json] Show the invite notes staged for approval
+  review approve|reject ID...|--all [--note TEXT --reason R]
+                                 Approve (optionally rewording) or reject staged notes; approved ones go out next run
+  send-messages [--limit N --tag T1,T2]
+                                 Send follow-up messages to newly accepted connections
+  endorse [--limit N]            Endorse top skills of accepted connections (engage.endorse_skills)
+  nurture [--limit N --scan-only]
+                                 Congratulate connections on job changes, anniversaries and birthdays
+  withdraw-connections [--days D --limit N]
+                                 Withdraw pending invites older than D days
+  sync-connections [--limit N]   Record existing 1st-degree connections so they are never invited
+  keep-alive [--minutes N]       Read the feed for a while to keep the session fresh (keep_alive.*)
+  work [--limit N --kind connect,message]
+                                 Run due jobs (retries, interrupted runs) without queueing new ones
+  jobs [--state S --kind K --limit N --json]
+                                 Show the job queue of send-connections and send-messages
+  jobs retry [--id N]            Re-queue failed jobs
+  retry-failed [--kind K --reason R,... --dry-run]
+                                 Re-queue and run failed jobs whose reason is retryable
   run-all                        Run login, search, send-connections, send-messages in order
+  daemon                         Stay running and execute jobs on the daemon.*_cron schedules
+  lint-templates [file ...]      Check configured templates (and template files) for problems
+  templates validate [file ...]  Render every template against sample profiles
+  profiles [--status S --limit N --json]
+                                 List profiles by pipeline status (discovered, queued, invited, ...)
+  profiles history --url URL     Show the logged status changes of a profile
+  profiles set-status --url URL --status S [--reason R]
+                                 Move a profile by hand, e.g. to closed or do_not_contact
+  tag add|remove URL TAG...      Tag a profile (e.g. hot-lead) for the --tag filters
+  tag list [URL]                 Show every tag with its profile count, or the tags of one profile
+  note add URL TEXT              Attach a free-text note to a profile
+  note list URL                  Show the notes of a profile
+  migrate [down --to N]          Show applied schema migrations, or revert those newer than N
+  purge [--older-than 180d --status closed,withdrawn --mode delete|anonymize --screenshots-older-than 30d --dry-run]
+                                 Delete or anonymize stale profiles and old screenshots (retention.*)
+  dedupe [--dry-run]             Normalize profile URLs and merge rows that are the same member
+  selectors                      Print the page selectors in effect (built-in plus overrides)
+  fingerprint [regenerate]       Show the account's stored browser fingerprint, or replace it with a new one
+  debug [list]                   List the runs that saved error screenshots and page HTML
+  debug open RUN-ID|latest       Show a run's artifacts with their profile and action, and open its folder
+  debug clean                    Apply the debug.keep_days/max_runs cleanup now
+  export [--format csv|json --filter accepted|pending|messaged --out F]
+                                 Export profiles with connection/message timestamps
+  export --view accepted [--include-pii]
+                                 Case-study report of accepted+messaged profiles
+  blacklist add|remove [--company C --name REGEX --url URL]
+                                 Exclude companies, names or profiles from search and send-connections
+  blacklist list [--json]        Show the exclusions from config and the database
+  dnc add|remove --url URL [--reason R]
+                                 Put a profile on (or take it off) the do-not-contact list
+  dnc import --file F [--reason R]
+                                 Add the profile URLs of a CSV to the do-not-contact list
+  dnc list [--json]              Show the do-not-contact list
+  dnc log [--limit N --json]     Show actions refused because of the do-not-contact list
+  cooldown [clear]               Show or clear the pauses after a checkpoint or the invite limit
+  status [--offline --json]      Probe the session and show quota, queues, last run and cooldown; exits 1 if blocked
+  digest [--since 24h --dry-run] Mail the activity digest (invites, acceptances, replies, errors, checkpoints) now
+  crm-sync [--limit N --dry-run] Push accepted connections and replies to the CRM (crm.provider)
+  sheets-sync [--dry-run]        Read do-not-contact marks and notes from the Google sheet, then rewrite it
+  events [--type T --limit N]    Show the newest events (profile_discovered, invite_sent, accepted, replied)
+  events poll [--after ID --type T --limit N]
+                                 Print the events after ID as JSON lines, oldest first
+  config show [--effective]      Print the config file, or the merged configuration in effect
+  secrets set NAME [VALUE] | get NAME | list | delete NAME
+                                 Keep passwords, tokens and API keys in the encrypted secrets file
+  audit [--profile URL --limit N --json]
+                                 Show the clicks, typing and navigation recorded in action_logs
+  runs [--limit N --type CMD --json]
+                                 List recent command runs and daemon jobs from run_logs
+  stats [--since 30d|YYYY-MM-DD --json]
+                                 Daily/weekly invites, acceptance, time-to-accept and reply rates
 
 Examples:
   linkedbot --config config.yaml login
@@ -50,18 +150,48 @@ Examples:
 		os.Exit(2)
 	}
 
-	// Load config
-	cfg, err := config.Load(cfgPath)
+	// Load config: defaults < file < account < env < flags
+	var err error
+	configs, err = config.NewManager(cfgPath, account, func(c *config.Config) {
+		if headful {
+			c.Stealth.Headless = false
+		}
+		if remoteURL != "" {
+			c.Browser.Engine, c.Browser.RemoteURL = config.EngineRemote, remoteURL
+		}
+		if attach {
+			c.Browser.Engine = config.EngineAttach
+		}
+	})
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "config load error: %v\n", err)
 		os.Exit(1)
 	}
+	cfg := configs.Config()
+	if err := logging.Setup(logging.Options{
+		Console: cfg.Logging.Console,
+		Modules: cfg.Logging.Modules,
+		File:    cfg.Logging.File.Path,
+		Rotate: logging.RotateOptions{
+			MaxSizeMB:  cfg.Logging.File.MaxSizeMB,
+			Daily:      cfg.Logging.File.RotateDaily,
+			MaxBackups: cfg.Logging.File.MaxBackups,
+		},
+	}); err != nil {
+		fmt.Fprintf(os.Stderr, "log setup error: %v\n", err)
+		os.Exit(1)
+	}
 	log := logging.New(cfg.Logging.Level)
 	log.Info("linkedbot starting", "version", "0.1.0")
-	log.Info("config loaded", "db_path", cfg.Database.Path, "log_level", cfg.Logging.Level)
+	log.Info("config loaded", "db_driver", cfg.Database.Driver, "db_path", cfg.Database.Path, "log_level", cfg.Logging.Level, "account", cfg.Account)
+	stopTracing, err := tracing.Setup(ctx, cfg)
+	if err != nil {
+		log.Error("tracing setup failed", "err", err)
+		os.Exit(1)
+	}
 
 	// init store
-	st, err := store.Open(cfg.Database.Path)
+	st, err := store.Open(cfg.Database.Driver, cfg.DatabaseSource())
 	if err != nil {
 		log.Error("db open failed", "err", err)
 		os.Exit(1)
@@ -74,148 +204,383 @@ Examples:
 
 	cmd := flag.Arg(0)
 	log.Info("executing command", "command", cmd)
+	// Failures of this run leave their screenshots and HTML in .cache/runs/<id>
+	run := artifacts.Begin(cmd)
+	if removed, err := artifacts.Cleanup(cfg.Debug.KeepDays, cfg.Debug.MaxRuns); err != nil {
+		log.Warn("failed to clean up debug artifacts", "err", err)
+	} else if len(removed) > 0 {
+		log.Debug("removed old debug artifacts", "runs", len(removed))
+	}
+	start := time.Now()
+	var res CommandResult
+	if browserCommands[cmd] {
+		err = checkCooldown(ctx, st)
+	}
+	// The checkpoint guard cancels runCtx when LinkedIn shows a checkpoint
+	runCtx, cancelRun := browser.Abortable(ctx)
+	defer cancelRun()
+	if err == nil {
+		res, err = runCommand(runCtx, cmd, cfg, st)
+	}
+	res.Command = cmd
+	res.Duration = time.Since(start)
+	if run.Len() > 0 {
+		res.Artifacts = run.ID
+		log.Info("debug artifacts saved", "run", run.ID, "dir", run.Dir)
+	}
+	if cp := browser.CheckpointCause(runCtx); cp != nil {
+		res.Interrupted = true
+		if err == nil {
+			err = cp
+		}
+		startCooldown(ctx, cfg, st, cp)
+	} else if ctx.Err() != nil && cmd != "daemon" {
+		// Stopping is the normal way out of the daemon
+		res.Interrupted = true
+		log.Info("interrupted, finished the current profile and saved progress", "cmd", cmd)
+	}
+	res.OK = err == nil
+	if err != nil {
+		res.Errors = append(res.Errors, err.Error())
+	}
+	if !notRuns[cmd] {
+		if err := recordRun(ctx, st, start, res); err != nil {
+			log.Warn("failed to record run", "err", err)
+		}
+	}
+	// Flush the spans now, the exits below skip deferred calls
+	flushCtx, cancelFlush := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	if err := stopTracing(flushCtx); err != nil {
+		log.Warn("failed to flush traces", "err", err)
+	}
+	cancelFlush()
+
+	if err != nil {
+		log.Error("command failed", "cmd", cmd, "err", err)
+		// Checkpoints were notified when the cooldown started; a failed
+		// status check is the monitor's to report
+		if !res.Interrupted && cmd != "status" {
+			notify.New(cfg).Notify(context.WithoutCancel(ctx), notify.EventFatalError, fmt.Sprintf("%s failed: %v", cmd, err), "")
+		}
+		if jsonOut {
+			printResult(res)
+		} else {
+			fmt.Fprintf(os.Stderr, "\n❌ Command failed: %v\n", err)
+			fmt.Fprintf(os.Stderr, "💡 Tip: Run with LINKEDBOT_LOG_LEVEL=debug for more details\n")
+		}
+		os.Exit(1)
+	}
+	log.Info("command completed successfully", "cmd", cmd)
+	if browserCommands[cmd] && cmd != "login" && cmd != "daemon" {
+		notify.New(cfg).Notify(ctx, notify.EventRunComplete, fmt.Sprintf("%s finished: %d sent, %d skipped, %d failed", cmd, res.Sent, res.Skipped, res.Failed), "")
+	}
+	if jsonOut {
+		printResult(res)
+		return
+	}
+	fmt.Printf("\n✅ %s completed successfully\n", cmd)
+}
+
+func runCommand(ctx context.Context, cmd string, cfg *config.Config, st *store.Store) (res CommandResult, err error) {
 	switch cmd {
 	case "login":
-		err = runLogin(ctx, cfg)
+		res, err = runLogin(ctx, cfg)
 	case "search":
-		err = runSearch(ctx, cfg, st)
+		res, err = runSearch(ctx, cfg, st)
+	case "import":
+		res, err = runImport(ctx, cfg, st)
+	case "enrich":
+		res, err = runEnrich(ctx, cfg, st)
+	case "engage":
+		res, err = runEngage(ctx, cfg, st)
+	case "warm-view":
+		res, err = runWarmView(ctx, cfg, st)
 	case "send-connections":
-		err = runSendConnections(ctx, cfg, st)
+		res, err = runSendConnections(ctx, cfg, st)
 	case "send-messages":
-		err = runSendMessages(ctx, cfg, st)
+		res, err = runSendMessages(ctx, cfg, st, time.Time{})
+	case "endorse":
+		res, err = runEndorse(ctx, cfg, st)
+	case "nurture":
+		res, err = runNurture(ctx, cfg, st)
+	case "withdraw-connections":
+		res, err = runWithdrawConnections(ctx, cfg, st)
+	case "sync-connections":
+		res, err = runSyncConnections(ctx, cfg, st)
+	case "keep-alive":
+		res, err = runKeepAlive(ctx, cfg)
 	case "run-all":
-		err = runAll(ctx, cfg, st)
+		res, err = runAll(ctx, cfg, st)
+	case "daemon":
+		res, err = runDaemon(ctx, cfg, st)
+	case "templates":
+		res, err = runTemplates(cfg)
+	case "lint-templates":
+		res, err = runLintTemplates(cfg)
+	case "migrate":
+		res, err = runMigrate(ctx, st)
+	case "purge":
+		res, err = runPurge(ctx, cfg, st)
+	case "dedupe":
+		res, err = runDedupe(ctx, st)
+	case "profiles":
+		res, err = runProfiles(ctx, st)
+	case "work":
+		res, err = runWork(ctx, cfg, st)
+	case "retry-failed":
+		res, err = runRetryFailed(ctx, cfg, st)
+	case "jobs":
+		res, err = runJobs(ctx, st)
+	case "tag":
+		res, err = runTag(ctx, st)
+	case "note":
+		res, err = runNote(ctx, st)
+	case "selectors":
+		res, err = runSelectors(ctx, cfg)
+	case "fingerprint":
+		res, err = runFingerprint(cfg)
+	case "debug":
+		res, err = runDebug(cfg)
+	case "export":
+		res, err = runExport(ctx, st)
+	case "stats":
+		res, err = runStats(ctx, st)
+	case "runs":
+		res, err = runRuns(ctx, st)
+	case "audit":
+		res, err = runAudit(ctx, st)
+	case "blacklist":
+		res, err = runBlacklist(ctx, cfg, st)
+	case "dnc":
+		res, err = runDoNotContact(ctx, cfg, st)
+	case "cooldown":
+		res, err = runCooldown(ctx, st)
+	case "status":
+		res, err = runStatus(ctx, cfg, st)
+	case "digest":
+		res, err = runDigest(ctx, cfg, st)
+	case "review":
+		res, err = runReview(ctx, st)
+	case "crm-sync":
+		res, err = runCRMSync(ctx, cfg, st)
+	case "sheets-sync":
+		res, err = runSheetsSync(ctx, cfg, st)
+	case "events":
+		res, err = runEvents(ctx, st)
+	case "config":
+		res, err = runConfig()
+	case "secrets":
+		res, err = runSecrets(cfg)
 	default:
 		err = fmt.Errorf("unknown command: %s", cmd)
 	}
-
-	if err != nil {
-		log.Error("command failed", "cmd", cmd, "err", err)
-		fmt.Fprintf(os.Stderr, "\n❌ Command failed: %v\n", err)
-		fmt.Fprintf(os.Stderr, "💡 Tip: Run with LINKEDBOT_LOG_LEVEL=debug for more details\n")
-		os.Exit(1)
-	}
-	log.Info("command completed successfully", "cmd", cmd)
-	fmt.Printf("\n✅ %s completed successfully\n", cmd)
+	return res, err
 }
 
-func runLogin(ctx context.Context, cfg *config.Config) error {
+func runLogin(ctx context.Context, cfg *config.Config) (CommandResult, error) {
+	fs := flag.NewFlagSet("login", flag.ContinueOnError)
+	var manual bool
+	var timeout time.Duration
+	fs.BoolVar(&manual, "manual", false, "Log in by hand in the browser window and save the session cookies")
+	fs.DurationVar(&timeout, "timeout", 10*time.Minute, "How long --manual waits for the login to finish")
+	if flag.NArg() > 0 && flag.Arg(0) == "login" {
+		if err := fs.Parse(flag.Args()[1:]); err != nil {
+			return CommandResult{}, err
+		}
+	}
+	if manual {
+		// The user needs a window to log in
+		cfg.Stealth.Headless = false
+		cfg.Browser.Background = false
+	}
 	br, err := browser.New(ctx, cfg)
 	if err != nil {
-		return err
+		return CommandResult{}, err
 	}
 	defer br.Close()
 	au := auth.New(br, cfg)
+	if manual {
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		return CommandResult{}, au.ManualLogin(ctx)
+	}
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Minute)
 	defer cancel()
-	return au.EnsureLoggedIn(ctx)
+	return CommandResult{}, au.EnsureLoggedIn(ctx)
 }
 
-func runSearch(ctx context.Context, cfg *config.Config, st *store.Store) error {
+func runSearch(ctx context.Context, cfg *config.Config, st *store.Store) (CommandResult, error) {
 	fs := flag.NewFlagSet("search", flag.ContinueOnError)
-	var title, company, location, keywords string
+	var title, company, location, keywords, backend, source string
 	var limit int
 	fs.StringVar(&title, "title", cfg.Search.Defaults.Title, "Job title filter")
 	fs.StringVar(&company, "company", cfg.Search.Defaults.Company, "Company filter")
 	fs.StringVar(&location, "location", cfg.Search.Defaults.Location, "Location filter")
 	fs.StringVar(&keywords, "keywords", cfg.Search.Defaults.Keywords, "Keywords filter")
 	fs.IntVar(&limit, "limit", cfg.Limits.MaxProfilesPerSearch, "Max profiles to collect in this run")
+	fs.StringVar(&backend, "backend", cfg.Search.Backend, "Search backend: dom or voyager")
+	fs.StringVar(&source, "source", cfg.Search.Defaults.Source, "List group:<id> members or event:<id> attendees instead of searching")
 	if err := fs.Parse(flag.Args()[1:]); err != nil {
-		return err
+		return CommandResult{}, err
+	}
+	if backend != "dom" && backend != "voyager" {
+		return CommandResult{}, fmt.Errorf("--backend must be dom or voyager, got %q", backend)
+	}
+	if _, _, ok := config.ParseAudience(source); source != "" && !ok {
+		return CommandResult{}, fmt.Errorf("--source must be group:<id> or event:<id>, got %q", source)
 	}
 
 	br, err := browser.New(ctx, cfg)
 	if err != nil {
-		return err
+		return CommandResult{}, err
 	}
 	defer br.Close()
 	au := auth.New(br, cfg)
 	if err := au.EnsureLoggedIn(ctx); err != nil {
-		return err
+		return CommandResult{}, err
 	}
 
 	svc := search.New(br, cfg, st)
-	crit := search.Criteria{Title: title, Company: company, Location: location, Keywords: keywords, Limit: limit}
+	crit := search.Criteria{Title: title, Company: company, Location: location, Keywords: keywords, Limit: limit, Backend: backend, Source: source}
 	newCount, err := svc.SearchAndStoreTargets(ctx, crit)
 	if err != nil {
-		return err
+		return CommandResult{}, err
 	}
 	logging.New(cfg.Logging.Level).Info("search complete", "new_profiles", newCount)
-	return nil
+	return CommandResult{Sent: newCount}, nil
 }
 
-func runSendConnections(ctx context.Context, cfg *config.Config, st *store.Store) error {
+func runSendConnections(ctx context.Context, cfg *config.Config, st *store.Store) (CommandResult, error) {
 	fs := flag.NewFlagSet("send-connections", flag.ContinueOnError)
 	var limit int
+	var degrees, tags string
 	fs.IntVar(&limit, "limit", cfg.Limits.MaxConnectionsPerDay, "Max connections to send in this run")
+	fs.StringVar(&degrees, "degree", strings.Join(cfg.Connection.Degrees, ","), "Only invite these connection degrees, e.g. 2nd or 2nd,3rd")
+	fs.StringVar(&tags, "tag", strings.Join(cfg.Connection.Tags, ","), "Only invite profiles with any of these tags")
+	fs.BoolVar(&cfg.Connection.RequireApproval, "require-approval", cfg.Connection.RequireApproval, "Stage notes for review and only send approved invites")
 	if err := fs.Parse(flag.Args()[1:]); err != nil {
-		return err
+		return CommandResult{}, err
+	}
+	var err error
+	if cfg.Connection.Tags, err = parseTagList(tags); err != nil {
+		return CommandResult{}, err
+	}
+	cfg.Connection.Degrees = nil
+	for _, d := range strings.Split(degrees, ",") {
+		switch d = strings.TrimSpace(d); d {
+		case "":
+		case "2nd", "3rd":
+			cfg.Connection.Degrees = append(cfg.Connection.Degrees, d)
+		default:
+			return CommandResult{}, fmt.Errorf("--degree: expected 2nd or 3rd, got %q", d)
+		}
 	}
 
 	br, err := browser.New(ctx, cfg)
 	if err != nil {
-		return err
+		return CommandResult{}, err
 	}
 	defer br.Close()
 	au := auth.New(br, cfg)
 	if err := au.EnsureLoggedIn(ctx); err != nil {
-		return err
+		return CommandResult{}, err
 	}
 
 	svc := connection.New(br, cfg, st)
-	sent, err := svc.SendConnections(ctx, limit)
+	stats, err := svc.SendConnections(ctx, limit)
 	if err != nil {
-		return err
+		return resultFromStats(stats), err
 	}
-	logging.New(cfg.Logging.Level).Info("connections sent", "count", sent)
-	return nil
+	logging.New(cfg.Logging.Level).Info("connections sent", "count", stats.Sent, "failed", stats.Failed)
+	return resultFromStats(stats), nil
 }
 
-func runSendMessages(ctx context.Context, cfg *config.Config, st *store.Store) error {
+func runSendMessages(ctx context.Context, cfg *config.Config, st *store.Store, sentBefore time.Time) (CommandResult, error) {
 	fs := flag.NewFlagSet("send-messages", flag.ContinueOnError)
 	var limit int
+	var tags string
 	fs.IntVar(&limit, "limit", cfg.Limits.MaxMessagesPerDay, "Max follow-up messages to send in this run")
+	fs.StringVar(&tags, "tag", strings.Join(cfg.Messaging.Tags, ","), "Only message profiles with any of these tags")
 	if err := fs.Parse(flag.Args()[1:]); err != nil {
-		return err
+		return CommandResult{}, err
+	}
+	var err error
+	if cfg.Messaging.Tags, err = parseTagList(tags); err != nil {
+		return CommandResult{}, err
 	}
 
 	br, err := browser.New(ctx, cfg)
 	if err != nil {
-		return err
+		return CommandResult{}, err
 	}
 	defer br.Close()
 	au := auth.New(br, cfg)
 	if err := au.EnsureLoggedIn(ctx); err != nil {
-		return err
+		return CommandResult{}, err
 	}
 
 	svc := messaging.New(br, cfg, st)
-	sent, err := svc.SendFollowUps(ctx, limit)
+	stats, err := svc.SendFollowUps(ctx, limit, sentBefore)
 	if err != nil {
-		return err
+		return resultFromStats(stats), err
 	}
-	logging.New(cfg.Logging.Level).Info("messages sent", "count", sent)
-	return nil
+	logging.New(cfg.Logging.Level).Info("messages sent", "count", stats.Sent, "failed", stats.Failed)
+	return resultFromStats(stats), nil
 }
 
-func runAll(ctx context.Context, cfg *config.Config, st *store.Store) error {
-	if err := runLogin(ctx, cfg); err != nil {
-		return err
+func runAll(ctx context.Context, cfg *config.Config, st *store.Store) (CommandResult, error) {
+	// Invites sent by this run cannot have been accepted yet, so the message
+	// stage only checks acceptance for connections sent in prior runs.
+	runStart := time.Now()
+	var total CommandResult
+	if _, err := runLogin(ctx, cfg); err != nil {
+		return total, err
 	}
 	if _, ok := os.LookupEnv("RUN_SEARCH"); ok {
-		if err := runSearch(ctx, cfg, st); err != nil {
-			return err
+		res, err := runSearch(ctx, cfg, st)
+		total.add(res)
+		if err != nil {
+			return total, err
 		}
 	}
-	if _, ok := os.LookupEnv("RUN_CONNECT"); ok {
-		if err := runSendConnections(ctx, cfg, st); err != nil {
-			return err
+	// Later stages are skipped once interrupted
+	if _, ok := os.LookupEnv("RUN_CONNECT"); ok && ctx.Err() == nil {
+		res, err := runSendConnections(ctx, cfg, st)
+		total.add(res)
+		if err != nil {
+			return total, err
+		}
+		// Visit the next batch now so it can be invited warm_view_days later
+		if cfg.Connection.WarmViewDays > 0 && ctx.Err() == nil {
+			res, err := runWarmView(ctx, cfg, st)
+			total.add(res)
+			if err != nil {
+				return total, err
+			}
 		}
 	}
-	if _, ok := os.LookupEnv("RUN_MESSAGE"); ok {
-		if err := runSendMessages(ctx, cfg, st); err != nil {
-			return err
+	if _, ok := os.LookupEnv("RUN_MESSAGE"); ok && ctx.Err() == nil {
+		if d := time.Duration(cfg.RunAll.AcceptanceCheckDelaySec) * time.Second; d > 0 {
+			logging.New(cfg.Logging.Level).Info("waiting before acceptance checks", "delay", d.String())
+			select {
+			case <-ctx.Done():
+				return total, nil
+			case <-time.After(d):
+			}
+		}
+		res, err := runSendMessages(ctx, cfg, st, runStart)
+		total.add(res)
+		if err != nil {
+			return total, err
+		}
+		// Endorse the connections just found accepted
+		if cfg.EndorseEnabled() && ctx.Err() == nil {
+			res, err := runEndorse(ctx, cfg, st)
+			total.add(res)
+			if err != nil {
+				return total, err
+			}
 		}
 	}
-	return nil
+	return total, nil
 }
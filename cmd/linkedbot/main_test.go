@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"path/filepath"
+	"testing"
+
+	"github.com/example/linkedbot/internal/auth"
+	"github.com/example/linkedbot/internal/config"
+	"github.com/example/linkedbot/internal/store"
+)
+
+// setArgs makes args the command line the commands parse their flags from.
+func setArgs(t *testing.T, args ...string) {
+	t.Helper()
+	if err := flag.CommandLine.Parse(args); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = flag.CommandLine.Parse(nil) })
+}
+
+// noCredentials loads the default config in a temporary directory with the
+// LinkedIn credentials unset, and opens a store there.
+func noCredentials(t *testing.T) (*config.Config, *store.Store) {
+	t.Helper()
+	dir := t.TempDir()
+	t.Chdir(dir)
+	t.Setenv("LINKEDIN_EMAIL", "")
+	t.Setenv("LINKEDIN_PASSWORD", "")
+	cfg, err := config.Load(filepath.Join(dir, "config.yaml"), "")
+	if err != nil {
+		t.Fatalf("config without credentials: %v", err)
+	}
+	if email, pass := cfg.Credentials(); email != "" || pass != "" {
+		t.Fatalf("credentials still set: %q", email)
+	}
+	st, err := store.Open("sqlite", filepath.Join(dir, "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(st.Close)
+	if err := st.Migrate(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return cfg, st
+}
+
+func TestReadOnlyCommandsNeedNoCredentials(t *testing.T) {
+	for _, args := range [][]string{
+		{"stats"},
+		{"export", "--out", "profiles.csv"},
+		{"export", "--view", "accepted", "--format", "json", "--out", "accepted.json"},
+	} {
+		t.Run(args[0], func(t *testing.T) {
+			cfg, st := noCredentials(t)
+			setArgs(t, args...)
+			if browserCommands[args[0]] {
+				t.Fatalf("%s is a browser command", args[0])
+			}
+			if _, err := runCommand(context.Background(), args[0], cfg, st); err != nil {
+				t.Errorf("%v without credentials: %v", args, err)
+			}
+		})
+	}
+}
+
+func TestLoginCommandsRequireCredentials(t *testing.T) {
+	cfg, _ := noCredentials(t)
+	// login and search open LinkedIn through auth.EnsureLoggedIn, which
+	// needs the credentials once the saved session has expired
+	for _, cmd := range []string{"login", "search"} {
+		if !browserCommands[cmd] {
+			t.Errorf("%s is not a browser command", cmd)
+		}
+	}
+	err := auth.RequireCredentials(cfg)
+	if err == nil {
+		t.Fatal("RequireCredentials passed without credentials")
+	}
+	if want := cfg.Auth.EmailEnv + " is required in env"; err.Error() != want {
+		t.Errorf("got %q, want %q", err, want)
+	}
+	t.Setenv(cfg.Auth.EmailEnv, "me@example.com")
+	if err := auth.RequireCredentials(cfg); err == nil {
+		t.Fatal("RequireCredentials passed without a password")
+	}
+	t.Setenv(cfg.Auth.PasswordEnv, "secret")
+	if err := auth.RequireCredentials(cfg); err != nil {
+		t.Errorf("RequireCredentials with both set: %v", err)
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/example/linkedbot/internal/store"
+)
+
+// runMigrate lists the schema migrations. Every command applies pending ones
+// on start, so "down" is only useful right before going back to an older
+// binary.
+func runMigrate(ctx context.Context, st *store.Store) (CommandResult, error) {
+	args := flag.Args()[1:]
+	if len(args) > 0 && args[0] == "down" {
+		fs := flag.NewFlagSet("migrate down", flag.ContinueOnError)
+		to := fs.Int("to", -1, "Revert every migration newer than this version")
+		if err := fs.Parse(args[1:]); err != nil {
+			return CommandResult{}, err
+		}
+		if *to < 0 {
+			return CommandResult{}, errors.New("usage: linkedbot migrate down --to N")
+		}
+		if err := st.MigrateDown(ctx, *to); err != nil {
+			return CommandResult{}, err
+		}
+	} else if len(args) > 0 {
+		return CommandResult{}, fmt.Errorf("unknown migrate subcommand %q (want down)", args[0])
+	}
+	migs, err := st.MigrationStatus(ctx)
+	if err != nil {
+		return CommandResult{}, err
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "version\tname\tapplied\treversible")
+	applied := 0
+	for _, m := range migs {
+		at := "pending"
+		if m.AppliedAt != nil {
+			at = m.AppliedAt.Local().Format("2006-01-02 15:04:05")
+			applied++
+		}
+		fmt.Fprintf(tw, "%04d\t%s\t%s\t%t\n", m.Version, m.Name, at, m.Reversible)
+	}
+	return CommandResult{Sent: applied}, tw.Flush()
+}
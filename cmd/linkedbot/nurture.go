@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"flag"
+
+	"github.com/example/linkedbot/internal/auth"
+	"github.com/example/linkedbot/internal/browser"
+	"github.com/example/linkedbot/internal/config"
+	"github.com/example/linkedbot/internal/logging"
+	"github.com/example/linkedbot/internal/nurture"
+	"github.com/example/linkedbot/internal/store"
+)
+
+func runNurture(ctx context.Context, cfg *config.Config, st *store.Store) (CommandResult, error) {
+	fs := flag.NewFlagSet("nurture", flag.ContinueOnError)
+	var limit int
+	var scanOnly bool
+	fs.IntVar(&limit, "limit", cfg.Nurture.MaxPerDay, "Max congratulations to send in this run")
+	fs.BoolVar(&scanOnly, "scan-only", false, "Only queue events from the notifications page, send nothing")
+	if err := fs.Parse(flag.Args()[1:]); err != nil {
+		return CommandResult{}, err
+	}
+
+	br, err := browser.New(ctx, cfg)
+	if err != nil {
+		return CommandResult{}, err
+	}
+	defer br.Close()
+	au := auth.New(br, cfg)
+	if err := au.EnsureLoggedIn(ctx); err != nil {
+		return CommandResult{}, err
+	}
+
+	svc := nurture.New(br, cfg, st)
+	log := logging.New(cfg.Logging.Level)
+	if scanOnly {
+		queued, err := svc.Scan(ctx)
+		if err != nil {
+			return CommandResult{}, err
+		}
+		log.Info("congratulations queued", "count", queued)
+		return CommandResult{Sent: queued}, nil
+	}
+	stats, err := svc.Run(ctx, limit)
+	if err != nil {
+		return resultFromStats(stats), err
+	}
+	log.Info("congratulations sent", "count", stats.Sent, "skipped", stats.Skipped, "failed", stats.Failed)
+	return resultFromStats(stats), nil
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/example/linkedbot/internal/models"
+	"github.com/example/linkedbot/internal/store"
+)
+
+func runProfiles(ctx context.Context, st *store.Store) (CommandResult, error) {
+	args := flag.Args()[1:]
+	sub := "list"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		sub, args = args[0], args[1:]
+	}
+	fs := flag.NewFlagSet("profiles "+sub, flag.ContinueOnError)
+	var status, profileURL, reason string
+	var limit int
+	var asJSON bool
+	switch sub {
+	case "list":
+		fs.StringVar(&status, "status", "", "Only profiles with this status")
+		fs.IntVar(&limit, "limit", 50, "Number of most recently updated profiles to list")
+		fs.BoolVar(&asJSON, "json", false, "Print the profiles as JSON, one object per line")
+	case "history":
+		fs.StringVar(&profileURL, "url", "", "Profile URL")
+	case "set-status":
+		fs.StringVar(&profileURL, "url", "", "Profile URL")
+		fs.StringVar(&status, "status", "", "New status, e.g. closed or do_not_contact")
+		fs.StringVar(&reason, "reason", "set by hand", "Why, recorded in the status history")
+	default:
+		return CommandResult{}, fmt.Errorf("unknown profiles subcommand %q (want list, history or set-status)", sub)
+	}
+	if err := fs.Parse(args); err != nil {
+		return CommandResult{}, err
+	}
+	var want models.ProfileStatus
+	if status != "" {
+		var ok bool
+		if want, ok = models.ParseStatus(status); !ok {
+			return CommandResult{}, fmt.Errorf("unknown status %q (want one of %v)", status, models.Statuses)
+		}
+	}
+
+	if sub == "list" {
+		profiles, err := st.GetProfilesByStatus(ctx, want, limit)
+		if err != nil {
+			return CommandResult{}, err
+		}
+		if asJSON {
+			enc := json.NewEncoder(os.Stdout)
+			for _, p := range profiles {
+				if err := enc.Encode(map[string]any{
+					"id": p.ID, "linkedin_url": p.LinkedInURL, "name": p.Name, "company": p.Company, "source": p.Source,
+					"status": p.Status, "updated_at": p.UpdatedAt,
+				}); err != nil {
+					return CommandResult{}, err
+				}
+			}
+			return CommandResult{Sent: len(profiles)}, nil
+		}
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "id\tstatus\tupdated\tname\tcompany\tprofile")
+		for _, p := range profiles {
+			fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%s\n", p.ID, p.Status, p.UpdatedAt.Local().Format("2006-01-02 15:04"), p.Name, p.Company, p.LinkedInURL)
+		}
+		return CommandResult{Sent: len(profiles)}, tw.Flush()
+	}
+
+	if profileURL == "" {
+		return CommandResult{}, fmt.Errorf("profiles %s needs --url", sub)
+	}
+	prof, err := lookupProfile(ctx, st, profileURL)
+	if err != nil {
+		return CommandResult{}, err
+	}
+	if sub == "set-status" {
+		if want == "" {
+			return CommandResult{}, errors.New("profiles set-status needs --status")
+		}
+		if err := st.SetStatus(ctx, prof.ID, want, reason); err != nil {
+			return CommandResult{}, err
+		}
+		fmt.Printf("%s: %s -> %s\n", prof.LinkedInURL, prof.Status, want)
+		return CommandResult{Sent: 1}, nil
+	}
+	history, err := st.GetStatusTransitions(ctx, prof.ID)
+	if err != nil {
+		return CommandResult{}, err
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "time\tfrom\tto\treason")
+	for _, t := range history {
+		from := string(t.From)
+		if from == "" {
+			from = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", t.CreatedAt.Local().Format("2006-01-02 15:04:05"), from, t.To, t.Reason)
+	}
+	return CommandResult{Sent: len(history)}, tw.Flush()
+}
+
+// lookupProfile finds the stored profile for a URL given on the command line.
+func lookupProfile(ctx context.Context, st *store.Store, profileURL string) (*models.Profile, error) {
+	prof, err := st.GetProfileByURL(ctx, profileURL)
+	if err != nil {
+		return nil, err
+	}
+	if prof == nil {
+		return nil, fmt.Errorf("no stored profile %s", models.CanonicalProfileURL(profileURL))
+	}
+	return prof, nil
+}
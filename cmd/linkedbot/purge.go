@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/example/linkedbot/internal/config"
+	"github.com/example/linkedbot/internal/models"
+	"github.com/example/linkedbot/internal/store"
+)
+
+// runPurge applies the retention policy: stale profiles are deleted or
+// anonymized together with their message and action logs, and old audit
+// screenshots are removed from disk.
+func runPurge(ctx context.Context, cfg *config.Config, st *store.Store) (CommandResult, error) {
+	fs := flag.NewFlagSet("purge", flag.ContinueOnError)
+	var olderThan, statuses, mode, shotsOlderThan string
+	var dryRun bool
+	fs.StringVar(&olderThan, "older-than", fmt.Sprintf("%dd", cfg.Retention.ProfileDays), "Purge profiles not updated for this many days, e.g. 180d (0d keeps them)")
+	fs.StringVar(&statuses, "status", strings.Join(cfg.Retention.Statuses, ","), "Only purge profiles with these statuses")
+	fs.StringVar(&mode, "mode", cfg.Retention.Mode, "delete or anonymize")
+	fs.StringVar(&shotsOlderThan, "screenshots-older-than", fmt.Sprintf("%dd", cfg.Retention.ScreenshotDays), "Delete audit screenshots older than this many days (0d keeps them)")
+	fs.BoolVar(&dryRun, "dry-run", false, "Only show what would be purged")
+	if err := fs.Parse(flag.Args()[1:]); err != nil {
+		return CommandResult{}, err
+	}
+	if mode != "delete" && mode != "anonymize" {
+		return CommandResult{}, fmt.Errorf("--mode: expected delete or anonymize, got %q", mode)
+	}
+	profileDays, err := parseDays("--older-than", olderThan)
+	if err != nil {
+		return CommandResult{}, err
+	}
+	shotDays, err := parseDays("--screenshots-older-than", shotsOlderThan)
+	if err != nil {
+		return CommandResult{}, err
+	}
+	var filter store.PurgeFilter
+	for _, s := range strings.Split(statuses, ",") {
+		if s = strings.TrimSpace(s); s == "" {
+			continue
+		}
+		status, ok := models.ParseStatus(s)
+		if !ok {
+			return CommandResult{}, fmt.Errorf("--status: unknown status %q (want one of %v)", s, models.Statuses)
+		}
+		filter.Statuses = append(filter.Statuses, status)
+	}
+
+	now := time.Now()
+	verb := "purged"
+	if dryRun {
+		verb = "would purge"
+	}
+	var res store.PurgeResult
+	if profileDays > 0 && len(filter.Statuses) > 0 {
+		filter.UpdatedBefore = now.AddDate(0, 0, -profileDays)
+		if res, err = st.Purge(ctx, filter, mode == "anonymize", dryRun); err != nil {
+			return CommandResult{}, err
+		}
+		fmt.Printf("%s (%s) %d profile(s) with status %s not updated for %d days: %d message log(s), %d action log(s)\n",
+			verb, mode, res.Profiles, statuses, profileDays, res.MessageLogs, res.ActionLogs)
+	}
+	shots := res.Screenshots
+	if shotDays > 0 {
+		old, err := st.ClearScreenshots(ctx, now.AddDate(0, 0, -shotDays), dryRun)
+		if err != nil {
+			return CommandResult{Sent: res.Profiles}, err
+		}
+		shots = append(shots, old...)
+	}
+	removed := len(shots)
+	if !dryRun {
+		removed = removeFiles(shots)
+	}
+	fmt.Printf("%s %d screenshot file(s)\n", verb, removed)
+	return CommandResult{Sent: res.Profiles}, nil
+}
+
+// parseDays reads a number of days given as "180d" or "180".
+func parseDays(name, s string) (int, error) {
+	n, err := strconv.Atoi(strings.TrimSuffix(strings.TrimSpace(s), "d"))
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid %s %q: want a number of days like 180d", name, s)
+	}
+	return n, nil
+}
+
+// removeFiles deletes paths, skipping those already gone, and returns how
+// many were deleted.
+func removeFiles(paths []string) int {
+	n := 0
+	for _, path := range paths {
+		err := os.Remove(path)
+		switch {
+		case err == nil:
+			n++
+		case !errors.Is(err, os.ErrNotExist):
+			fmt.Fprintf(os.Stderr, "remove %s: %v\n", path, err)
+		}
+	}
+	return n
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/example/linkedbot/internal/models"
+	"github.com/example/linkedbot/internal/store"
+)
+
+// CommandResult summarises a command for wrapper scripts. With --json it is
+// printed as the last line on stdout. Sent counts the command's main output:
+// profiles stored, invites or messages sent, rows exported or updated.
+type CommandResult struct {
+	Command string `json:"command"`
+	OK      bool   `json:"ok"`
+	Sent    int    `json:"sent"`
+	Skipped int    `json:"skipped"`
+	Failed  int    `json:"failed"`
+	// Interrupted is set when the command stopped early on SIGINT/SIGTERM
+	Interrupted bool          `json:"interrupted,omitempty"`
+	Duration    time.Duration `json:"-"`
+	Errors      []string      `json:"errors,omitempty"`
+	// Artifacts is the run id under .cache/runs holding the screenshots and
+	// page HTML of the failures, if there were any
+	Artifacts string `json:"artifacts,omitempty"`
+}
+
+func (r CommandResult) MarshalJSON() ([]byte, error) {
+	type plain CommandResult
+	return json.Marshal(struct {
+		plain
+		DurationMs int64 `json:"duration_ms"`
+	}{plain(r), r.Duration.Milliseconds()})
+}
+
+// add folds a stage's counts into r; run-all sums its stages this way.
+func (r *CommandResult) add(other CommandResult) {
+	r.Sent += other.Sent
+	r.Skipped += other.Skipped
+	r.Failed += other.Failed
+	r.Errors = append(r.Errors, other.Errors...)
+}
+
+func resultFromStats(st models.RunStats) CommandResult {
+	return CommandResult{Sent: st.Sent, Skipped: st.Skipped, Failed: st.Failed, Errors: st.Errors}
+}
+
+func printResult(r CommandResult) {
+	b, err := json.Marshal(r)
+	if err != nil {
+		b = []byte(fmt.Sprintf(`{"command":%q,"ok":false,"errors":[%q]}`, r.Command, err.Error()))
+	}
+	fmt.Println(string(b))
+}
+
+// notRuns are left out of run_logs: listing runs, actions, events,
+// selectors, migrations, profiles or the config, health checks, and editing
+// exclusions, statuses, approvals or the fingerprint are not runs themselves.
+var notRuns = map[string]bool{
+	"runs": true, "audit": true, "events": true, "selectors": true, "migrate": true, "profiles": true, "config": true,
+	"status": true, "digest": true, "debug": true, "jobs": true,
+	"blacklist": true, "dnc": true, "cooldown": true, "tag": true, "note": true, "review": true, "fingerprint": true, "secrets": true,
+}
+
+// recordRun stores r in run_logs with the JSON result as summary. It runs
+// after the command, so it must not depend on ctx still being live.
+func recordRun(ctx context.Context, st *store.Store, start time.Time, r CommandResult) error {
+	summary, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	_, err = st.InsertRunLog(context.WithoutCancel(ctx), &store.RunLog{
+		RunType:   r.Command,
+		StartedAt: start,
+		EndedAt:   start.Add(r.Duration),
+		OK:        r.OK,
+		Processed: r.Sent + r.Failed,
+		Succeeded: r.Sent,
+		Failed:    r.Failed,
+		Summary:   string(summary),
+	})
+	return err
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/example/linkedbot/internal/models"
+)
+
+func TestCommandResultJSON(t *testing.T) {
+	cases := []struct {
+		name string
+		res  CommandResult
+		want string
+	}{
+		{
+			"success",
+			CommandResult{Command: "send-connections", OK: true, Sent: 12, Skipped: 3, Failed: 1, Duration: 1500 * time.Millisecond},
+			`{"command":"send-connections","ok":true,"sent":12,"skipped":3,"failed":1,"duration_ms":1500}`,
+		},
+		{
+			"failure",
+			CommandResult{Command: "search", Errors: []string{"search page did not load"}, Interrupted: true, Artifacts: "20240301-093000", Duration: 2 * time.Second},
+			`{"command":"search","ok":false,"sent":0,"skipped":0,"failed":0,"interrupted":true,"errors":["search page did not load"],"artifacts":"20240301-093000","duration_ms":2000}`,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			b, err := json.Marshal(c.res)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(b) != c.want {
+				t.Errorf("got  %s\nwant %s", b, c.want)
+			}
+		})
+	}
+}
+
+func TestResultFromStats(t *testing.T) {
+	got := resultFromStats(models.RunStats{Sent: 4, Skipped: 2, Failed: 1, Errors: []string{"x"}})
+	got.add(CommandResult{Sent: 1, Failed: 1, Errors: []string{"y"}})
+	want := CommandResult{Sent: 5, Skipped: 2, Failed: 2, Errors: []string{"x", "y"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+// captureStdout returns what f prints on stdout.
+func captureStdout(t *testing.T, f func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	done := make(chan string)
+	go func() {
+		b, _ := io.ReadAll(r)
+		done <- string(b)
+	}()
+	defer func() { os.Stdout = stdout }()
+	f()
+	_ = w.Close()
+	return <-done
+}
+
+// TestJSONResultPerCommand runs the commands that need neither LinkedIn nor
+// a browser and checks the line --json prints last, the way main builds it.
+func TestJSONResultPerCommand(t *testing.T) {
+	for _, args := range [][]string{
+		{"stats"},
+		{"export", "--out", "profiles.csv"},
+		{"migrate"},
+		{"dedupe"},
+		{"profiles"},
+		{"jobs"},
+		{"runs"},
+		{"audit"},
+		{"events"},
+		{"cooldown"},
+		{"review"},
+		{"templates", "validate"},
+		{"lint-templates"},
+	} {
+		cmd := args[0]
+		t.Run(cmd, func(t *testing.T) {
+			cfg, st := noCredentials(t)
+			setArgs(t, args...)
+			out := captureStdout(t, func() {
+				start := time.Now()
+				res, err := runCommand(context.Background(), cmd, cfg, st)
+				res.Command = cmd
+				res.Duration = time.Since(start)
+				res.OK = err == nil
+				if err != nil {
+					res.Errors = append(res.Errors, err.Error())
+				}
+				printResult(res)
+			})
+
+			var last string
+			for sc := bufio.NewScanner(strings.NewReader(out)); sc.Scan(); {
+				last = sc.Text()
+			}
+			var got map[string]any
+			if err := json.Unmarshal([]byte(last), &got); err != nil {
+				t.Fatalf("last line %q is not JSON: %v", last, err)
+			}
+			for _, key := range []string{"command", "ok", "sent", "skipped", "failed", "duration_ms"} {
+				if _, ok := got[key]; !ok {
+					t.Errorf("%s missing from %s", key, last)
+				}
+			}
+			if got["command"] != cmd {
+				t.Errorf("command = %v, want %s", got["command"], cmd)
+			}
+			if got["ok"] != true {
+				t.Errorf("%s failed: %v", cmd, got["errors"])
+			}
+		})
+	}
+}
+
+func TestJSONResultOnFailure(t *testing.T) {
+	cfg, st := noCredentials(t)
+	setArgs(t, "export", "--view", "nope")
+	res, err := runCommand(context.Background(), "export", cfg, st)
+	if err == nil {
+		t.Fatal("export --view nope succeeded")
+	}
+	res.Command, res.OK, res.Errors = "export", false, append(res.Errors, err.Error())
+	out := captureStdout(t, func() { printResult(res) })
+	var got CommandResult
+	if err := json.Unmarshal([]byte(out), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.OK || len(got.Errors) != 1 || got.Errors[0] != "unknown export view: nope" {
+		t.Errorf("got %+v", got)
+	}
+}
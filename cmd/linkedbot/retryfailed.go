@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/example/linkedbot/internal/auth"
+	"github.com/example/linkedbot/internal/browser"
+	"github.com/example/linkedbot/internal/config"
+	"github.com/example/linkedbot/internal/logging"
+	"github.com/example/linkedbot/internal/store"
+	"github.com/example/linkedbot/internal/worker"
+)
+
+// runRetryFailed puts failed jobs whose reason is worth another try back in
+// the queue, each at most jobs.max_retries times, and works them off like
+// `work` does.
+func runRetryFailed(ctx context.Context, cfg *config.Config, st *store.Store) (CommandResult, error) {
+	fs := flag.NewFlagSet("retry-failed", flag.ContinueOnError)
+	var kind, reasons string
+	var dryRun bool
+	fs.StringVar(&kind, "kind", "", "Only retry jobs of this kind (connect, message)")
+	fs.StringVar(&reasons, "reason", strings.Join(worker.RetryableReasons, ","), "Retry failures with these reasons ("+strings.Join(worker.Reasons, ", ")+")")
+	fs.BoolVar(&dryRun, "dry-run", false, "Show the failures by reason and how many would be retried")
+	if err := fs.Parse(flag.Args()[1:]); err != nil {
+		return CommandResult{}, err
+	}
+	if kind != "" && kind != store.JobConnect && kind != store.JobMessage {
+		return CommandResult{}, fmt.Errorf("--kind: expected connect or message, got %q", kind)
+	}
+	var only []string
+	for _, r := range strings.Split(reasons, ",") {
+		if r = strings.TrimSpace(r); r == "" {
+			continue
+		}
+		if !slices.Contains(worker.Reasons, r) {
+			return CommandResult{}, fmt.Errorf("--reason: unknown reason %q, expected one of %s", r, strings.Join(worker.Reasons, ", "))
+		}
+		only = append(only, r)
+	}
+
+	if dryRun {
+		counts, err := st.CountFailedJobs(ctx)
+		if err != nil {
+			return CommandResult{}, err
+		}
+		for _, r := range worker.Reasons {
+			if counts[r] > 0 {
+				fmt.Printf("%-20s %d failed\n", r, counts[r])
+			}
+		}
+		if counts[""] > 0 {
+			fmt.Printf("%-20s %d failed\n", "(unclassified)", counts[""])
+		}
+		n, err := st.RetryFailedJobs(ctx, kind, only, cfg.Jobs.MaxRetries, true)
+		if err != nil {
+			return CommandResult{}, err
+		}
+		fmt.Printf("would retry %d job(s)\n", n)
+		return CommandResult{}, nil
+	}
+	n, err := st.RetryFailedJobs(ctx, kind, only, cfg.Jobs.MaxRetries, false)
+	if err != nil {
+		return CommandResult{}, err
+	}
+	log := logging.New(cfg.Logging.Level)
+	log.Info("re-queued failed jobs", "count", n, "reasons", only)
+	if n == 0 {
+		return CommandResult{}, nil
+	}
+
+	br, err := browser.New(ctx, cfg)
+	if err != nil {
+		return CommandResult{}, err
+	}
+	defer br.Close()
+	if err := auth.New(br, cfg).EnsureLoggedIn(ctx); err != nil {
+		return CommandResult{}, err
+	}
+	w, err := newWorker(ctx, br, cfg, st)
+	if err != nil {
+		return CommandResult{}, err
+	}
+	var kinds []string
+	if kind != "" {
+		kinds = append(kinds, kind)
+	}
+	stats, err := w.Run(ctx, n, kinds...)
+	if err != nil {
+		return resultFromStats(stats), err
+	}
+	log.Info("retries done", "sent", stats.Sent, "skipped", stats.Skipped, "failed", stats.Failed)
+	return resultFromStats(stats), nil
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/example/linkedbot/internal/store"
+)
+
+// runReview lists the invite notes staged by send-connections
+// --require-approval and approves or rejects them. Approved invites are sent
+// by the next send-connections, run-all or worker run.
+func runReview(ctx context.Context, st *store.Store) (CommandResult, error) {
+	args := flag.Args()[1:]
+	sub := "list"
+	if len(args) > 0 && (args[0] == "list" || args[0] == "approve" || args[0] == "reject") {
+		sub, args = args[0], args[1:]
+	}
+	fs := flag.NewFlagSet("review "+sub, flag.ContinueOnError)
+	var state, note, reason string
+	var limit int
+	var all, asJSON bool
+	switch sub {
+	case "list":
+		fs.StringVar(&state, "state", store.ApprovalPending, "Only approvals in this state (pending, approved, rejected, sent; empty for all)")
+		fs.IntVar(&limit, "limit", 50, "Number of approvals to show, oldest first")
+		fs.BoolVar(&asJSON, "json", false, "Print the approvals as JSON, one object per line")
+	case "approve":
+		fs.BoolVar(&all, "all", false, "Approve every pending note")
+		fs.StringVar(&note, "note", "", "Send this note instead of the staged one (one ID only)")
+	case "reject":
+		fs.BoolVar(&all, "all", false, "Reject every pending note")
+		fs.StringVar(&reason, "reason", "", "Why, kept with the approval")
+	}
+	if err := fs.Parse(args); err != nil {
+		return CommandResult{}, err
+	}
+
+	if sub == "list" {
+		approvals, err := st.GetApprovals(ctx, state, limit)
+		if err != nil {
+			return CommandResult{}, err
+		}
+		if asJSON {
+			enc := json.NewEncoder(os.Stdout)
+			for _, a := range approvals {
+				if err := enc.Encode(map[string]any{
+					"id": a.ID, "profile_url": a.ProfileURL, "name": a.Name, "headline": a.Headline, "note": a.Note,
+					"state": a.State, "reason": a.Reason, "created_at": a.CreatedAt, "decided_at": a.DecidedAt,
+				}); err != nil {
+					return CommandResult{}, err
+				}
+			}
+			return CommandResult{Sent: len(approvals)}, nil
+		}
+		if len(approvals) == 0 {
+			fmt.Println("nothing to review")
+			return CommandResult{}, nil
+		}
+		for _, a := range approvals {
+			fmt.Printf("#%d  %s  %s  [%s]\n", a.ID, a.Name, a.ProfileURL, a.State)
+			if a.Headline != "" {
+				fmt.Printf("    %s\n", a.Headline)
+			}
+			fmt.Printf("    > %s\n\n", a.Note)
+		}
+		fmt.Println("approve with `linkedbot review approve ID...` (or --all), reject with `linkedbot review reject ID...`")
+		return CommandResult{Sent: len(approvals)}, nil
+	}
+
+	var ids []int64
+	for _, arg := range fs.Args() {
+		id, err := strconv.ParseInt(arg, 10, 64)
+		if err != nil {
+			return CommandResult{}, fmt.Errorf("invalid approval id %q", arg)
+		}
+		ids = append(ids, id)
+	}
+	if all == (len(ids) > 0) {
+		return CommandResult{}, fmt.Errorf("usage: linkedbot review %s ID... | --all", sub)
+	}
+	if note != "" && len(ids) != 1 {
+		return CommandResult{}, errors.New("--note replaces the note of one approval; pass exactly one ID")
+	}
+	state = store.ApprovalApproved
+	if sub == "reject" {
+		state = store.ApprovalRejected
+	}
+	n, err := st.DecideApprovals(ctx, ids, state, note, reason)
+	if err != nil {
+		return CommandResult{}, err
+	}
+	fmt.Printf("%s %d pending note(s)\n", state, n)
+	if n < len(ids) {
+		fmt.Printf("%d id(s) were not pending\n", len(ids)-n)
+	}
+	return CommandResult{Sent: n, Skipped: max(len(ids)-n, 0)}, nil
+}
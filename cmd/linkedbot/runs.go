@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/example/linkedbot/internal/store"
+)
+
+func runRuns(ctx context.Context, st *store.Store) (CommandResult, error) {
+	fs := flag.NewFlagSet("runs", flag.ContinueOnError)
+	var limit int
+	var runType string
+	var asJSON bool
+	fs.IntVar(&limit, "limit", 20, "Number of runs to list")
+	fs.StringVar(&runType, "type", "", "Only runs of this command (e.g. send-connections, daemon:send-messages)")
+	fs.BoolVar(&asJSON, "json", false, "Print the runs as JSON, one object per line")
+	if err := fs.Parse(flag.Args()[1:]); err != nil {
+		return CommandResult{}, err
+	}
+	runs, err := st.GetRecentRuns(ctx, limit, runType)
+	if err != nil {
+		return CommandResult{}, err
+	}
+	if asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		for _, r := range runs {
+			if err := enc.Encode(map[string]any{
+				"id": r.ID, "run_type": r.RunType, "started_at": r.StartedAt, "ended_at": r.EndedAt, "ok": r.OK,
+				"processed": r.Processed, "succeeded": r.Succeeded, "failed": r.Failed, "summary": json.RawMessage(r.Summary),
+			}); err != nil {
+				return CommandResult{}, err
+			}
+		}
+		return CommandResult{Sent: len(runs)}, nil
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "id\tcommand\tstarted\tduration\tok\tprocessed\tsucceeded\tfailed")
+	for _, r := range runs {
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%t\t%d\t%d\t%d\n", r.ID, r.RunType, r.StartedAt.Local().Format("2006-01-02 15:04:05"),
+			r.EndedAt.Sub(r.StartedAt).Round(time.Second), r.OK, r.Processed, r.Succeeded, r.Failed)
+	}
+	return CommandResult{Sent: len(runs)}, tw.Flush()
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/example/linkedbot/internal/config"
+	"github.com/example/linkedbot/internal/secrets"
+	"golang.org/x/term"
+)
+
+const secretsUsage = "usage: secrets set NAME [VALUE] | get NAME | list | delete NAME"
+
+// runSecrets manages the encrypted secrets file (secrets.path). Names are
+// the env vars the secrets stand in for, e.g. LINKEDIN_PASSWORD. The
+// passphrase comes from secrets.passphrase_env or is asked for; a value left
+// off set is asked for too, or read from stdin when that isn't a terminal.
+func runSecrets(cfg *config.Config) (CommandResult, error) {
+	args := flag.Args()[1:]
+	if len(args) == 0 {
+		return CommandResult{}, errors.New(secretsUsage)
+	}
+	sub, args := args[0], args[1:]
+	switch {
+	case sub == "list" && len(args) == 0:
+	case (sub == "get" || sub == "delete") && len(args) == 1:
+	case sub == "set" && (len(args) == 1 || len(args) == 2):
+	default:
+		return CommandResult{}, errors.New(secretsUsage)
+	}
+	_, statErr := os.Stat(cfg.Secrets.Path)
+	pass, err := secretsPassphrase(cfg, sub == "set" && errors.Is(statErr, os.ErrNotExist))
+	if err != nil {
+		return CommandResult{}, err
+	}
+	st, err := secrets.Load(cfg.Secrets.Path, pass)
+	if err != nil {
+		return CommandResult{}, err
+	}
+	switch sub {
+	case "list":
+		for _, n := range st.Names() {
+			fmt.Println(n)
+		}
+	case "get":
+		v, ok := st.Get(args[0])
+		if !ok {
+			return CommandResult{}, fmt.Errorf("no secret %q in %s", args[0], cfg.Secrets.Path)
+		}
+		fmt.Println(v)
+	case "delete":
+		if !st.Delete(args[0]) {
+			return CommandResult{}, fmt.Errorf("no secret %q in %s", args[0], cfg.Secrets.Path)
+		}
+		if err := st.Save(); err != nil {
+			return CommandResult{}, err
+		}
+		fmt.Printf("Deleted %s\n", args[0])
+	case "set":
+		var value string
+		if len(args) == 2 {
+			value = args[1]
+		} else if value, err = readSecret(args[0] + ": "); err != nil {
+			return CommandResult{}, err
+		}
+		if value == "" {
+			return CommandResult{}, errors.New("empty value")
+		}
+		st.Set(args[0], value)
+		if err := st.Save(); err != nil {
+			return CommandResult{}, err
+		}
+		fmt.Printf("Stored %s in %s\n", args[0], cfg.Secrets.Path)
+	}
+	return CommandResult{}, nil
+}
+
+// secretsPassphrase reads the passphrase from secrets.passphrase_env or
+// asks for it, twice when it is about to create the file.
+func secretsPassphrase(cfg *config.Config, create bool) (string, error) {
+	if pass := cfg.SecretsPassphrase(); pass != "" {
+		return pass, nil
+	}
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return "", fmt.Errorf("set the secrets passphrase in %s", cfg.Secrets.PassphraseEnv)
+	}
+	pass, err := readSecret("Passphrase: ")
+	if err != nil {
+		return "", err
+	}
+	if pass == "" {
+		return "", errors.New("empty passphrase")
+	}
+	if create {
+		again, err := readSecret("Repeat passphrase: ")
+		if err != nil {
+			return "", err
+		}
+		if again != pass {
+			return "", errors.New("passphrases don't match")
+		}
+	}
+	return pass, nil
+}
+
+// readSecret asks for a value without echoing it, or reads the first line
+// of stdin when that isn't a terminal.
+func readSecret(prompt string) (string, error) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return "", err
+		}
+		return strings.TrimRight(line, "\r\n"), nil
+	}
+	fmt.Fprint(os.Stderr, prompt)
+	b, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/example/linkedbot/internal/config"
+	"github.com/example/linkedbot/internal/selectors"
+)
+
+// runSelectors prints the selectors in effect in the override file layout, so
+// a fix can start from a copy of the current ones, followed by the selectors
+// self-healing learned.
+func runSelectors(ctx context.Context, cfg *config.Config) (CommandResult, error) {
+	reg, err := selectors.Load(ctx, cfg)
+	if err != nil {
+		return CommandResult{}, err
+	}
+	out, err := reg.YAML()
+	if err != nil {
+		return CommandResult{}, err
+	}
+	fmt.Printf("# version %d from %s\n%s", reg.Version, strings.Join(reg.Sources, ", "), out)
+	// Heuristic fallbacks are worth promoting into an override file
+	if learned := reg.Learned(); len(learned) > 0 {
+		fmt.Printf("\n# learned by the heuristic fallback (%s):\n", cfg.Selectors.LearnedPath)
+		for _, l := range learned {
+			fmt.Printf("#   %s: %s (score %d, %d hits, last %s)\n", l.Key, selectors.Selector{CSS: l.CSS, Text: l.Text}, l.Score, l.Hits, l.LastUsed.Format("2006-01-02 15:04"))
+		}
+	}
+	return CommandResult{Sent: reg.Len()}, nil
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	"github.com/example/linkedbot/internal/config"
+	"github.com/example/linkedbot/internal/integrations"
+	"github.com/example/linkedbot/internal/store"
+)
+
+// runSheetsSync takes the edits made in the Google sheet over and then
+// rewrites it. With --dry-run the edits are only listed and the sheet is
+// left alone. The daemon runs it on sheets.sync_cron.
+func runSheetsSync(ctx context.Context, cfg *config.Config, st *store.Store) (CommandResult, error) {
+	fs := flag.NewFlagSet("sheets-sync", flag.ContinueOnError)
+	var dryRun bool
+	fs.BoolVar(&dryRun, "dry-run", false, "Show the edits that would be taken from the sheet without writing anything")
+	if err := fs.Parse(flag.Args()[1:]); err != nil {
+		return CommandResult{}, err
+	}
+	sh, err := integrations.NewSheets(cfg, st)
+	if err != nil {
+		return CommandResult{}, err
+	}
+	ch, rows, err := syncSheet(ctx, sh, dryRun)
+	if err != nil {
+		return CommandResult{}, err
+	}
+	fmt.Printf("from sheet: %d do-not-contact, %d notes, %d unknown rows\n", ch.DoNotContact, ch.Notes, ch.Unknown)
+	if !dryRun {
+		fmt.Printf("wrote %d rows\n", rows)
+	}
+	return CommandResult{Sent: rows, Skipped: ch.Unknown}, nil
+}
+
+// syncSheet pulls before pushing, so the push never overwrites edits that
+// weren't taken over yet.
+func syncSheet(ctx context.Context, sh *integrations.Sheets, dryRun bool) (integrations.SheetChanges, int, error) {
+	ch, err := sh.Pull(ctx, dryRun)
+	if err != nil || dryRun {
+		return ch, 0, err
+	}
+	rows, err := sh.Push(ctx)
+	return ch, rows, err
+}
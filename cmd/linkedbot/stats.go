@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/example/linkedbot/internal/models"
+	"github.com/example/linkedbot/internal/stats"
+	"github.com/example/linkedbot/internal/store"
+)
+
+func runStats(ctx context.Context, st *store.Store) (CommandResult, error) {
+	fs := flag.NewFlagSet("stats", flag.ContinueOnError)
+	var sinceArg string
+	var asJSON bool
+	fs.StringVar(&sinceArg, "since", "30d", "Start of the report: a date (YYYY-MM-DD) or a number of days back (e.g. 30d)")
+	fs.BoolVar(&asJSON, "json", false, "Print the report as JSON")
+	if err := fs.Parse(flag.Args()[1:]); err != nil {
+		return CommandResult{}, err
+	}
+	since, err := parseSince(sinceArg, time.Now())
+	if err != nil {
+		return CommandResult{}, err
+	}
+
+	profiles, err := st.ExportProfiles(ctx, "")
+	if err != nil {
+		return CommandResult{}, err
+	}
+	sent, err := st.GetMessageTimes(ctx, models.MessageTypeFollowUp, since)
+	if err != nil {
+		return CommandResult{}, err
+	}
+	report := stats.Build(profiles, sent, since)
+	replies, err := st.GetReplies(ctx, since, "")
+	if err != nil {
+		return CommandResult{}, err
+	}
+	stats.AddReplies(&report, replies)
+	if asJSON {
+		err = json.NewEncoder(os.Stdout).Encode(report)
+	} else {
+		err = stats.WriteText(os.Stdout, report)
+	}
+	return CommandResult{}, err
+}
+
+// parseSince accepts a date or "<N>d" counted back from now.
+func parseSince(s string, now time.Time) (time.Time, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return time.Time{}, fmt.Errorf("invalid --since %q", s)
+		}
+		d := now.AddDate(0, 0, -n)
+		return time.Date(d.Year(), d.Month(), d.Day(), 0, 0, 0, 0, time.Local), nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", s, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid --since %q: want YYYY-MM-DD or a number of days like 30d", s)
+	}
+	return t, nil
+}
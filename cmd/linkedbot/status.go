@@ -0,0 +1,192 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/example/linkedbot/internal/auth"
+	"github.com/example/linkedbot/internal/browser"
+	"github.com/example/linkedbot/internal/config"
+	"github.com/example/linkedbot/internal/models"
+	"github.com/example/linkedbot/internal/ratelimit"
+	"github.com/example/linkedbot/internal/store"
+)
+
+// runStatus prints the health of the account for monitoring: whether the
+// session still opens the feed, today's quota, the queues, the last run and
+// any cooldown. It fails when the account is blocked, by an active cooldown
+// or a checkpoint on the feed probe, so cron can alert on the exit code.
+func runStatus(ctx context.Context, cfg *config.Config, st *store.Store) (CommandResult, error) {
+	fs := flag.NewFlagSet("status", flag.ContinueOnError)
+	var offline, asJSON bool
+	fs.BoolVar(&offline, "offline", false, "Skip the browser probe of the session and report from the database only")
+	fs.BoolVar(&asJSON, "json", false, "Print the status as one JSON object")
+	if err := fs.Parse(flag.Args()[1:]); err != nil {
+		return CommandResult{}, err
+	}
+
+	var blocked []string
+	cooldown, err := st.GetCooldown(ctx)
+	if err != nil {
+		return CommandResult{}, err
+	}
+	cooling := cooldown.Active(time.Now())
+	if cooling {
+		blocked = append(blocked, checkCooldown(ctx, st).Error())
+	}
+
+	invites, err := st.GetInviteCooldown(ctx)
+	if err != nil {
+		return CommandResult{}, err
+	}
+	invitesPaused := invites.Active(time.Now())
+
+	session := "not checked"
+	if !offline {
+		if session, err = probeSession(ctx, cfg); err != nil {
+			if session != auth.SessionBlocked {
+				return CommandResult{}, fmt.Errorf("session probe: %w", err)
+			}
+			blocked = append(blocked, err.Error())
+		}
+	}
+
+	rl := ratelimit.New(cfg, st)
+	quota := map[ratelimit.Kind][]ratelimit.Budget{}
+	for _, kind := range []ratelimit.Kind{ratelimit.Connection, ratelimit.Message} {
+		if quota[kind], err = rl.Usage(ctx, kind); err != nil {
+			return CommandResult{}, err
+		}
+	}
+	profiles, err := st.CountProfilesByStatus(ctx)
+	if err != nil {
+		return CommandResult{}, err
+	}
+	jobs, err := st.CountJobs(ctx)
+	if err != nil {
+		return CommandResult{}, err
+	}
+	runs, err := st.GetRecentRuns(ctx, 1, "")
+	if err != nil {
+		return CommandResult{}, err
+	}
+
+	if asJSON {
+		out := map[string]any{
+			"account": cfg.AccountKey(), "session": session, "blocked": len(blocked) > 0, "reasons": blocked,
+			"to_invite": profiles[models.StatusDiscovered] + profiles[models.StatusQueued], "profiles": profiles,
+		}
+		if cooling {
+			out["cooldown"] = map[string]any{"kind": cooldown.Kind, "url": cooldown.URL, "detected_at": cooldown.DetectedAt, "until": cooldown.Until}
+		}
+		if invitesPaused {
+			out["invite_cooldown"] = map[string]any{"detected_at": invites.DetectedAt, "until": invites.Until}
+		}
+		q := map[string]any{}
+		for kind, budgets := range quota {
+			w := map[string]any{}
+			for _, b := range budgets {
+				w[b.Window] = map[string]int{"used": b.Used, "limit": b.Limit}
+			}
+			q[string(kind)] = w
+		}
+		out["quota"] = q
+		js := map[string]map[string]int{}
+		for _, c := range jobs {
+			if js[c.Kind] == nil {
+				js[c.Kind] = map[string]int{}
+			}
+			js[c.Kind][c.State] = c.Jobs
+		}
+		out["jobs"] = js
+		if len(runs) > 0 {
+			r := runs[0]
+			out["last_run"] = map[string]any{"run_type": r.RunType, "started_at": r.StartedAt, "ended_at": r.EndedAt, "ok": r.OK,
+				"processed": r.Processed, "succeeded": r.Succeeded, "failed": r.Failed}
+		}
+		if err := json.NewEncoder(os.Stdout).Encode(out); err != nil {
+			return CommandResult{}, err
+		}
+	} else {
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintf(tw, "account:\t%s\n", cfg.AccountKey())
+		fmt.Fprintf(tw, "session:\t%s\n", session)
+		if cooling {
+			until := "cleared"
+			if cooldown.Until != nil {
+				until = cooldown.Until.Local().Format("2006-01-02 15:04")
+			}
+			fmt.Fprintf(tw, "cooldown:\t%s since %s, until %s\n", cooldown.Kind, cooldown.DetectedAt.Local().Format("2006-01-02 15:04"), until)
+		} else {
+			fmt.Fprintln(tw, "cooldown:\tnone")
+		}
+		if invitesPaused {
+			fmt.Fprintf(tw, "invite cooldown:\t%s since %s, until %s\n", invites.Kind, invites.DetectedAt.Local().Format("2006-01-02 15:04"), invites.Until.Local().Format("2006-01-02 15:04"))
+		}
+		fmt.Fprintf(tw, "invites:\t%s\n", formatBudgets(quota[ratelimit.Connection]))
+		fmt.Fprintf(tw, "messages:\t%s\n", formatBudgets(quota[ratelimit.Message]))
+		fmt.Fprintf(tw, "to invite:\t%d profiles\n", profiles[models.StatusDiscovered]+profiles[models.StatusQueued])
+		var pending []string
+		for _, c := range jobs {
+			if c.State == "pending" || c.State == "running" || c.State == "failed" {
+				pending = append(pending, fmt.Sprintf("%s %s %d", c.Kind, c.State, c.Jobs))
+			}
+		}
+		if len(pending) == 0 {
+			pending = append(pending, "empty")
+		}
+		fmt.Fprintf(tw, "jobs:\t%s\n", strings.Join(pending, ", "))
+		if len(runs) > 0 {
+			r := runs[0]
+			outcome := "ok"
+			if !r.OK {
+				outcome = "failed"
+			}
+			fmt.Fprintf(tw, "last run:\t%s at %s, %s (%d processed, %d succeeded, %d failed)\n", r.RunType,
+				r.StartedAt.Local().Format("2006-01-02 15:04"), outcome, r.Processed, r.Succeeded, r.Failed)
+		} else {
+			fmt.Fprintln(tw, "last run:\tnone")
+		}
+		if err := tw.Flush(); err != nil {
+			return CommandResult{}, err
+		}
+	}
+	if len(blocked) > 0 {
+		return CommandResult{}, fmt.Errorf("account is blocked: %s", strings.Join(blocked, "; "))
+	}
+	return CommandResult{}, nil
+}
+
+// probeSession opens the feed once with the saved session. It logs nothing
+// in, so an expired session is reported rather than renewed.
+func probeSession(ctx context.Context, cfg *config.Config) (string, error) {
+	br, err := browser.New(ctx, cfg)
+	if err != nil {
+		return "", err
+	}
+	defer br.Close()
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Minute)
+	defer cancel()
+	return auth.New(br, cfg).CheckSession(ctx)
+}
+
+// formatBudgets prints budgets as "hourly 1/5, daily 3/20, ...", leaving
+// out uncapped windows.
+func formatBudgets(budgets []ratelimit.Budget) string {
+	var parts []string
+	for _, b := range budgets {
+		if b.Limit > 0 {
+			parts = append(parts, b.String())
+		}
+	}
+	if len(parts) == 0 {
+		return "uncapped"
+	}
+	return strings.Join(parts, ", ")
+}
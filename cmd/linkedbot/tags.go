@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/example/linkedbot/internal/models"
+	"github.com/example/linkedbot/internal/store"
+)
+
+// parseTagList splits a comma-separated --tag value into normalized tags.
+func parseTagList(s string) ([]string, error) {
+	var tags []string
+	for _, t := range strings.Split(s, ",") {
+		if strings.TrimSpace(t) == "" {
+			continue
+		}
+		tag, err := models.NormalizeTag(t)
+		if err != nil {
+			return nil, fmt.Errorf("--tag: %w", err)
+		}
+		tags = append(tags, tag)
+	}
+	return tags, nil
+}
+
+func runTag(ctx context.Context, st *store.Store) (CommandResult, error) {
+	args := flag.Args()[1:]
+	if len(args) == 0 {
+		return CommandResult{}, errors.New("usage: linkedbot tag add|remove URL TAG... | tag list [URL]")
+	}
+	sub, args := args[0], args[1:]
+	switch sub {
+	case "list":
+		if len(args) > 1 {
+			return CommandResult{}, errors.New("usage: linkedbot tag list [URL]")
+		}
+		if len(args) == 1 {
+			prof, err := lookupProfile(ctx, st, args[0])
+			if err != nil {
+				return CommandResult{}, err
+			}
+			tags, err := st.GetProfileTags(ctx, prof.ID)
+			if err != nil {
+				return CommandResult{}, err
+			}
+			for _, t := range tags {
+				fmt.Println(t)
+			}
+			return CommandResult{Sent: len(tags)}, nil
+		}
+		counts, err := st.ListTags(ctx)
+		if err != nil {
+			return CommandResult{}, err
+		}
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "tag	profiles")
+		for _, tc := range counts {
+			fmt.Fprintf(tw, "%s\t%d\n", tc.Name, tc.Profiles)
+		}
+		return CommandResult{Sent: len(counts)}, tw.Flush()
+	case "add", "remove":
+		if len(args) < 2 {
+			return CommandResult{}, fmt.Errorf("usage: linkedbot tag %s URL TAG...", sub)
+		}
+		prof, err := lookupProfile(ctx, st, args[0])
+		if err != nil {
+			return CommandResult{}, err
+		}
+		changed, verb := 0, "added"
+		if sub == "remove" {
+			verb = "removed"
+		}
+		for _, t := range args[1:] {
+			tag, err := models.NormalizeTag(t)
+			if err != nil {
+				return CommandResult{Sent: changed}, err
+			}
+			var ok bool
+			if sub == "add" {
+				ok, err = st.AddTag(ctx, prof.ID, tag)
+			} else {
+				ok, err = st.RemoveTag(ctx, prof.ID, tag)
+			}
+			if err != nil {
+				return CommandResult{Sent: changed}, err
+			}
+			if ok {
+				changed++
+			}
+		}
+		fmt.Printf("%s: %d tag(s) %s\n", prof.LinkedInURL, changed, verb)
+		return CommandResult{Sent: changed}, nil
+	default:
+		return CommandResult{}, fmt.Errorf("unknown tag subcommand %q (want add, remove or list)", sub)
+	}
+}
+
+func runNote(ctx context.Context, st *store.Store) (CommandResult, error) {
+	args := flag.Args()[1:]
+	if len(args) < 2 || (args[0] == "add" && len(args) < 3) {
+		return CommandResult{}, errors.New("usage: linkedbot note add URL TEXT | note list URL")
+	}
+	sub := args[0]
+	if sub != "add" && sub != "list" {
+		return CommandResult{}, fmt.Errorf("unknown note subcommand %q (want add or list)", sub)
+	}
+	prof, err := lookupProfile(ctx, st, args[1])
+	if err != nil {
+		return CommandResult{}, err
+	}
+	if sub == "add" {
+		body := strings.TrimSpace(strings.Join(args[2:], " "))
+		if body == "" {
+			return CommandResult{}, errors.New("note add needs some text")
+		}
+		if err := st.AddNote(ctx, prof.ID, body); err != nil {
+			return CommandResult{}, err
+		}
+		return CommandResult{Sent: 1}, nil
+	}
+	notes, err := st.GetNotes(ctx, prof.ID)
+	if err != nil {
+		return CommandResult{}, err
+	}
+	for _, n := range notes {
+		fmt.Printf("%s  %s\n", n.CreatedAt.Local().Format("2006-01-02 15:04"), n.Body)
+	}
+	return CommandResult{Sent: len(notes)}, nil
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"unicode/utf8"
+
+	"github.com/example/linkedbot/internal/config"
+	"github.com/example/linkedbot/internal/templates"
+)
+
+type templateTarget struct {
+	name, text string
+	maxLen     int
+}
+
+// templateTargets lists every configured template plus the given files.
+// Connection notes are cut to templates.NoteLimit before sending; follow-ups
+// only have LinkedIn's generous message limit.
+func templateTargets(cfg *config.Config, files []string) ([]templateTarget, error) {
+	targets := []templateTarget{
+		{"connection_note_template", cfg.Templates.ConnectionNote, templates.NoteLimit},
+		{"follow_up_message_template", cfg.Templates.FollowUp, 8000},
+	}
+	if cfg.Engage.CommentTemplate != "" {
+		targets = append(targets, templateTarget{"engage.comment_template", cfg.Engage.CommentTemplate, 1250})
+	}
+	for _, t := range []templateTarget{
+		{"nurture.templates.job_change", cfg.Nurture.Templates.JobChange, 8000},
+		{"nurture.templates.anniversary", cfg.Nurture.Templates.Anniversary, 8000},
+		{"nurture.templates.birthday", cfg.Nurture.Templates.Birthday, 8000},
+	} {
+		if t.text != "" {
+			targets = append(targets, t)
+		}
+	}
+	for i, step := range cfg.Messaging.Sequence {
+		targets = append(targets, templateTarget{fmt.Sprintf("messaging.sequence[%d]", i), step.Template, 8000})
+	}
+	for _, cp := range cfg.Templates.Campaigns {
+		if cp.ConnectionNoteFile != "" {
+			targets = append(targets, templateTarget{cp.ConnectionNoteFile, cp.ConnectionNote, templates.NoteLimit})
+		}
+		if cp.FollowUpFile != "" {
+			targets = append(targets, templateTarget{cp.FollowUpFile, cp.FollowUp, 8000})
+		}
+	}
+	for _, path := range files {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read template file: %w", err)
+		}
+		targets = append(targets, templateTarget{path, string(b), templates.NoteLimit})
+	}
+	return targets, nil
+}
+
+func runLintTemplates(cfg *config.Config) (CommandResult, error) {
+	fs := flag.NewFlagSet("lint-templates", flag.ContinueOnError)
+	if err := fs.Parse(flag.Args()[1:]); err != nil {
+		return CommandResult{}, err
+	}
+	targets, err := templateTargets(cfg, fs.Args())
+	if err != nil {
+		return CommandResult{}, err
+	}
+
+	errCount := 0
+	for _, t := range targets {
+		issues := templates.Lint(t.text, t.maxLen)
+		if len(issues) == 0 {
+			fmt.Printf("%s: ok\n", t.name)
+			continue
+		}
+		for _, is := range issues {
+			fmt.Printf("%s: %s\n", t.name, is)
+		}
+		if templates.HasErrors(issues) {
+			errCount++
+		}
+	}
+	res := CommandResult{Failed: errCount}
+	if errCount > 0 {
+		return res, fmt.Errorf("%d template(s) with errors", errCount)
+	}
+	return res, nil
+}
+
+// runTemplates handles `templates validate`, which renders every template
+// against the sample profiles so the actual output can be reviewed.
+func runTemplates(cfg *config.Config) (CommandResult, error) {
+	args := flag.Args()[1:]
+	if len(args) == 0 || args[0] != "validate" {
+		return CommandResult{}, errors.New("usage: linkedbot templates validate [file ...]")
+	}
+	fs := flag.NewFlagSet("templates validate", flag.ContinueOnError)
+	if err := fs.Parse(args[1:]); err != nil {
+		return CommandResult{}, err
+	}
+	targets, err := templateTargets(cfg, fs.Args())
+	if err != nil {
+		return CommandResult{}, err
+	}
+
+	var res CommandResult
+	for _, t := range targets {
+		failed := false
+		for _, sample := range templates.Samples {
+			out, err := templates.Render(t.text, &sample.Profile, cfg.Templates.TitleCleanup)
+			if err != nil {
+				fmt.Printf("%s [%s]: error: %v\n", t.name, sample.Name, err)
+				failed = true
+				continue
+			}
+			fmt.Printf("%s [%s]: %q\n", t.name, sample.Name, out)
+			if n := utf8.RuneCountInString(out); n > t.maxLen {
+				fmt.Printf("%s [%s]: warning: %d characters (limit %d)\n", t.name, sample.Name, n, t.maxLen)
+			}
+		}
+		if failed {
+			res.Failed++
+		} else {
+			res.Sent++
+		}
+	}
+	if res.Failed > 0 {
+		return res, fmt.Errorf("%d template(s) failed to render", res.Failed)
+	}
+	return res, nil
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"flag"
+
+	"github.com/example/linkedbot/internal/auth"
+	"github.com/example/linkedbot/internal/browser"
+	"github.com/example/linkedbot/internal/config"
+	"github.com/example/linkedbot/internal/connection"
+	"github.com/example/linkedbot/internal/logging"
+	"github.com/example/linkedbot/internal/store"
+)
+
+func runWarmView(ctx context.Context, cfg *config.Config, st *store.Store) (CommandResult, error) {
+	fs := flag.NewFlagSet("warm-view", flag.ContinueOnError)
+	var limit int
+	fs.IntVar(&limit, "limit", 0, "Max profiles to visit (defaults to limits.max_connections_per_day)")
+	if err := fs.Parse(flag.Args()[1:]); err != nil {
+		return CommandResult{}, err
+	}
+
+	br, err := browser.New(ctx, cfg)
+	if err != nil {
+		return CommandResult{}, err
+	}
+	defer br.Close()
+	au := auth.New(br, cfg)
+	if err := au.EnsureLoggedIn(ctx); err != nil {
+		return CommandResult{}, err
+	}
+
+	svc := connection.New(br, cfg, st)
+	stats, err := svc.WarmView(ctx, limit)
+	if err != nil {
+		return resultFromStats(stats), err
+	}
+	logging.New(cfg.Logging.Level).Info("profiles viewed", "count", stats.Sent, "failed", stats.Failed)
+	return resultFromStats(stats), nil
+}
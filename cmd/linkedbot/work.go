@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/example/linkedbot/internal/auth"
+	"github.com/example/linkedbot/internal/browser"
+	"github.com/example/linkedbot/internal/config"
+	"github.com/example/linkedbot/internal/connection"
+	"github.com/example/linkedbot/internal/logging"
+	"github.com/example/linkedbot/internal/messaging"
+	"github.com/example/linkedbot/internal/store"
+	"github.com/example/linkedbot/internal/worker"
+)
+
+// newWorker returns a job worker that handles every job kind.
+func newWorker(ctx context.Context, br *browser.Browser, cfg *config.Config, st *store.Store) (*worker.Worker, error) {
+	connect, err := connection.New(br, cfg, st).ConnectHandler(ctx)
+	if err != nil {
+		return nil, err
+	}
+	w := worker.New(br, cfg, st)
+	w.Handle(store.JobConnect, connect)
+	w.Handle(store.JobMessage, messaging.New(br, cfg, st).MessageHandler())
+	return w, nil
+}
+
+// runWork works through due jobs of every kind, interleaved, without
+// queueing new ones: retries, and jobs an interrupted run left behind.
+func runWork(ctx context.Context, cfg *config.Config, st *store.Store) (CommandResult, error) {
+	fs := flag.NewFlagSet("work", flag.ContinueOnError)
+	var limit int
+	var kinds string
+	fs.IntVar(&limit, "limit", cfg.Limits.MaxConnectionsPerDay+cfg.Limits.MaxMessagesPerDay, "Max jobs to run")
+	fs.StringVar(&kinds, "kind", "", "Only run jobs of these kinds (connect, message)")
+	if err := fs.Parse(flag.Args()[1:]); err != nil {
+		return CommandResult{}, err
+	}
+	var only []string
+	for _, k := range strings.Split(kinds, ",") {
+		switch k = strings.TrimSpace(k); k {
+		case "":
+		case store.JobConnect, store.JobMessage:
+			only = append(only, k)
+		default:
+			return CommandResult{}, fmt.Errorf("--kind: expected connect or message, got %q", k)
+		}
+	}
+
+	br, err := browser.New(ctx, cfg)
+	if err != nil {
+		return CommandResult{}, err
+	}
+	defer br.Close()
+	au := auth.New(br, cfg)
+	if err := au.EnsureLoggedIn(ctx); err != nil {
+		return CommandResult{}, err
+	}
+
+	w, err := newWorker(ctx, br, cfg, st)
+	if err != nil {
+		return CommandResult{}, err
+	}
+	stats, err := w.Run(ctx, limit, only...)
+	if err != nil {
+		return resultFromStats(stats), err
+	}
+	logging.New(cfg.Logging.Level).Info("jobs done", "sent", stats.Sent, "skipped", stats.Skipped, "failed", stats.Failed)
+	return resultFromStats(stats), nil
+}
+
+func runJobs(ctx context.Context, st *store.Store) (CommandResult, error) {
+	args := flag.Args()[1:]
+	if len(args) > 0 && args[0] == "retry" {
+		fs := flag.NewFlagSet("jobs retry", flag.ContinueOnError)
+		id := fs.Int64("id", 0, "Only re-queue this job (default: every failed job)")
+		if err := fs.Parse(args[1:]); err != nil {
+			return CommandResult{}, err
+		}
+		n, err := st.RequeueFailedJobs(ctx, *id)
+		if err != nil {
+			return CommandResult{}, err
+		}
+		fmt.Printf("re-queued %d failed job(s)\n", n)
+		return CommandResult{Sent: n}, nil
+	}
+	fs := flag.NewFlagSet("jobs", flag.ContinueOnError)
+	var state, kind string
+	var limit int
+	var asJSON bool
+	fs.StringVar(&state, "state", "", "Only jobs in this state (pending, running, done, skipped, failed)")
+	fs.StringVar(&kind, "kind", "", "Only jobs of this kind (connect, message)")
+	fs.IntVar(&limit, "limit", 20, "Number of most recently updated jobs to list")
+	fs.BoolVar(&asJSON, "json", false, "Print the jobs as JSON, one object per line")
+	if err := fs.Parse(args); err != nil {
+		return CommandResult{}, err
+	}
+	jobs, err := st.GetJobs(ctx, state, kind, limit)
+	if err != nil {
+		return CommandResult{}, err
+	}
+	if asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		for _, j := range jobs {
+			if err := enc.Encode(map[string]any{
+				"id": j.ID, "kind": j.Kind, "profile_id": j.ProfileID, "payload": j.Payload, "state": j.State, "attempts": j.Attempts,
+				"max_attempts": j.MaxAttempts, "next_run_at": j.NextRunAt, "last_error": j.LastError, "reason": j.Reason, "retries": j.Retries,
+				"updated_at": j.UpdatedAt,
+			}); err != nil {
+				return CommandResult{}, err
+			}
+		}
+		return CommandResult{Sent: len(jobs)}, nil
+	}
+	counts, err := st.CountJobs(ctx)
+	if err != nil {
+		return CommandResult{}, err
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "kind\tstate\tjobs")
+	for _, c := range counts {
+		fmt.Fprintf(tw, "%s\t%s\t%d\n", c.Kind, c.State, c.Jobs)
+	}
+	fmt.Fprintln(tw, "\nid\tkind\tprofile\tstate\tattempts\tnext run\treason\tlast error")
+	for _, j := range jobs {
+		fmt.Fprintf(tw, "%d\t%s\t%d\t%s\t%d/%d\t%s\t%s\t%s\n", j.ID, j.Kind, j.ProfileID, j.State, j.Attempts, j.MaxAttempts,
+			j.NextRunAt.Local().Format("2006-01-02 15:04"), j.Reason, j.LastError)
+	}
+	return CommandResult{Sent: len(jobs)}, tw.Flush()
+}
@@ -0,0 +1,218 @@
+package artifacts
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/example/linkedbot/internal/driver"
+)
+
+// Root is the directory every run's artifacts go under.
+var Root = filepath.Join(".cache", "runs")
+
+// IDPattern matches run ids, e.g. 20261017-093012-send-connections.
+var IDPattern = regexp.MustCompile(`^\d{8}-\d{6}-[A-Za-z0-9_-]+$`)
+
+// Entry is one captured failure in a run's index.json.
+type Entry struct {
+	Time time.Time `json:"time"`
+	// Action is the label of what failed, e.g. connect_button_fail
+	Action string `json:"action"`
+	// Profile is the profile the page showed, if it was one
+	Profile    string `json:"profile,omitempty"`
+	URL        string `json:"url,omitempty"`
+	Error      string `json:"error,omitempty"`
+	Screenshot string `json:"screenshot,omitempty"`
+	HTML       string `json:"html,omitempty"`
+}
+
+// Index is the index.json of a run.
+type Index struct {
+	Run     string    `json:"run"`
+	Command string    `json:"command"`
+	Started time.Time `json:"started"`
+	Entries []Entry   `json:"entries"`
+}
+
+// Run collects the debug artifacts (a screenshot and the page HTML per
+// failure) of one command or daemon job in Root/<id>/. The directory is only
+// created once something is captured.
+type Run struct {
+	ID  string
+	Dir string
+
+	mu  sync.Mutex
+	idx Index
+}
+
+var (
+	mu      sync.Mutex
+	current *Run
+)
+
+// Begin starts the run that Capture writes to until the next Begin.
+func Begin(command string) *Run {
+	now := time.Now()
+	id := now.Format("20060102-150405") + "-" + sanitize(command)
+	// Two daemon jobs can start within the same second
+	for n := 2; ; n++ {
+		if _, err := os.Stat(filepath.Join(Root, id)); os.IsNotExist(err) {
+			break
+		}
+		id = fmt.Sprintf("%s-%s-%d", now.Format("20060102-150405"), sanitize(command), n)
+	}
+	r := &Run{ID: id, Dir: filepath.Join(Root, id), idx: Index{Run: id, Command: command, Started: now}}
+	mu.Lock()
+	current = r
+	mu.Unlock()
+	return r
+}
+
+// Current returns the run artifacts go to, starting one when none was begun.
+func Current() *Run {
+	mu.Lock()
+	r := current
+	mu.Unlock()
+	if r == nil {
+		r = Begin("run")
+	}
+	return r
+}
+
+// Len returns the number of captured failures.
+func (r *Run) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.idx.Entries)
+}
+
+// Capture saves a screenshot and the HTML of p for the failed action and
+// adds them to the index. Failures to write are returned but callers
+// usually only log them: a missing artifact must not change the outcome.
+func (r *Run) Capture(p driver.Page, action string, cause error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := os.MkdirAll(r.Dir, 0o755); err != nil {
+		return err
+	}
+	now := time.Now()
+	base := fmt.Sprintf("%03d-%s", len(r.idx.Entries)+1, sanitize(action))
+	e := Entry{Time: now, Action: action}
+	if cause != nil {
+		e.Error = cause.Error()
+	}
+	if u, err := p.URL(); err == nil {
+		e.URL = u
+		if strings.Contains(u, "/in/") {
+			e.Profile = u
+		}
+	}
+	var errs []error
+	if b, err := p.Screenshot(true); err == nil {
+		if err := os.WriteFile(filepath.Join(r.Dir, base+".png"), b, 0o644); err != nil {
+			errs = append(errs, err)
+		} else {
+			e.Screenshot = base + ".png"
+		}
+	}
+	if html, err := p.HTML(); err == nil {
+		if err := os.WriteFile(filepath.Join(r.Dir, base+".html"), []byte(html), 0o644); err != nil {
+			errs = append(errs, err)
+		} else {
+			e.HTML = base + ".html"
+		}
+	}
+	r.idx.Entries = append(r.idx.Entries, e)
+	b, err := json.MarshalIndent(r.idx, "", "  ")
+	if err == nil {
+		err = os.WriteFile(filepath.Join(r.Dir, "index.json"), b, 0o644)
+	}
+	if err != nil {
+		errs = append(errs, err)
+	}
+	if len(errs) > 0 {
+		return errs[0]
+	}
+	return nil
+}
+
+// Load reads the index of run id.
+func Load(id string) (*Index, error) {
+	if !IDPattern.MatchString(id) {
+		return nil, fmt.Errorf("%q is not a run id", id)
+	}
+	b, err := os.ReadFile(filepath.Join(Root, id, "index.json"))
+	if err != nil {
+		return nil, err
+	}
+	var idx Index
+	if err := json.Unmarshal(b, &idx); err != nil {
+		return nil, fmt.Errorf("%s: %w", id, err)
+	}
+	return &idx, nil
+}
+
+// List returns the ids of the runs with artifacts, newest first.
+func List() ([]string, error) {
+	ents, err := os.ReadDir(Root)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for _, e := range ents {
+		if e.IsDir() && IDPattern.MatchString(e.Name()) {
+			ids = append(ids, e.Name())
+		}
+	}
+	// Ids start with the time, so they sort by it
+	sort.Sort(sort.Reverse(sort.StringSlice(ids)))
+	return ids, nil
+}
+
+// Cleanup deletes the runs started more than keepDays ago and all but the
+// newest maxRuns; 0 turns either rule off. It returns the deleted ids.
+func Cleanup(keepDays, maxRuns int) ([]string, error) {
+	ids, err := List()
+	if err != nil {
+		return nil, err
+	}
+	cutoff := time.Now().AddDate(0, 0, -keepDays)
+	var removed []string
+	for i, id := range ids {
+		old := false
+		if keepDays > 0 {
+			if t, err := time.ParseInLocation("20060102-150405", id[:15], time.Local); err == nil && t.Before(cutoff) {
+				old = true
+			}
+		}
+		if !old && (maxRuns <= 0 || i < maxRuns) {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(Root, id)); err != nil {
+			return removed, err
+		}
+		removed = append(removed, id)
+	}
+	return removed, nil
+}
+
+var unsafeChars = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+// sanitize makes s safe as part of a file name.
+func sanitize(s string) string {
+	s = unsafeChars.ReplaceAllString(s, "_")
+	if s == "" {
+		return "run"
+	}
+	return s
+}
@@ -0,0 +1,85 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/example/linkedbot/internal/config"
+	"github.com/example/linkedbot/internal/driver"
+	"github.com/example/linkedbot/internal/logging"
+	"github.com/example/linkedbot/internal/models"
+	"github.com/example/linkedbot/internal/store"
+	"github.com/example/linkedbot/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Actions recorded in action_logs.
+const (
+	ActionNavigate = "navigate"
+	ActionClick    = "click"
+	ActionType     = "type"
+)
+
+// Recorder performs browser actions against a profile and writes each one to
+// action_logs, optionally with screenshots taken just before and after.
+type Recorder struct {
+	cfg *config.Config
+	st  *store.Store
+	log *logging.Logger
+}
+
+func New(cfg *config.Config, st *store.Store) *Recorder {
+	return &Recorder{cfg: cfg, st: st, log: logging.New(cfg.Logging.Level).With("module", "audit")}
+}
+
+// Do runs fn as action on target (a URL, button or field description) for
+// prof and records the outcome, in action_logs and as a trace span. detail
+// is what was typed, if anything. Recording problems are logged; fn's error
+// is returned unchanged.
+func (r *Recorder) Do(ctx context.Context, p driver.Page, prof *models.Profile, action, target, detail string, fn func() error) (err error) {
+	ctx, span := tracing.Start(ctx, action+" "+target, append(tracing.Profile(prof.ID, prof.LinkedInURL), attribute.String("action.target", target))...)
+	defer func() { tracing.End(span, err) }()
+	if !r.cfg.Audit.Enabled {
+		return fn()
+	}
+	stamp := time.Now()
+	entry := store.ActionLog{ProfileID: prof.ID, ProfileURL: prof.LinkedInURL, Action: action, Target: target, Detail: detail, CreatedAt: stamp}
+	if r.cfg.Audit.Screenshots {
+		entry.BeforePath = r.screenshot(p, stamp, action, "before")
+	}
+	err = fn()
+	if r.cfg.Audit.Screenshots {
+		entry.AfterPath = r.screenshot(p, stamp, action, "after")
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	if _, rerr := r.st.InsertActionLog(ctx, &entry); rerr != nil {
+		r.log.Warn("failed to record action", "action", action, "target", target, "err", rerr)
+	}
+	return err
+}
+
+// screenshot saves the viewport under <dir>/<date>/ and returns the path, or
+// "" when it could not be taken.
+func (r *Recorder) screenshot(p driver.Page, stamp time.Time, action, when string) string {
+	dir := filepath.Join(r.cfg.AuditDir(), stamp.Format("2006-01-02"))
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		r.log.Warn("create audit dir failed", "err", err)
+		return ""
+	}
+	b, err := p.Screenshot(false)
+	if err != nil {
+		r.log.Debug("audit screenshot failed", "err", err)
+		return ""
+	}
+	path := filepath.Join(dir, fmt.Sprintf("%d-%s-%s.png", stamp.UnixNano(), action, when))
+	if err := os.WriteFile(path, b, 0o644); err != nil {
+		r.log.Warn("write audit screenshot failed", "err", err)
+		return ""
+	}
+	return path
+}
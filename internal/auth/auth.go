@@ -10,35 +10,88 @@ import (
 	"strings"
 	"time"
 
+	"github.com/example/linkedbot/internal/artifacts"
 	"github.com/example/linkedbot/internal/browser"
 	"github.com/example/linkedbot/internal/config"
+	"github.com/example/linkedbot/internal/driver"
 	"github.com/example/linkedbot/internal/logging"
-	"github.com/go-rod/rod"
-	"github.com/go-rod/rod/lib/proto"
+	"github.com/example/linkedbot/internal/notify"
+	"github.com/example/linkedbot/internal/retry"
+	"github.com/example/linkedbot/internal/secrets"
+	"github.com/example/linkedbot/internal/selectors"
+	"github.com/example/linkedbot/internal/stealth"
 )
 
 type Auth struct {
 	br  *browser.Browser
 	cfg *config.Config
+	nt  *notify.Notifier
+	rt  *retry.Retrier
+	sel *selectors.Registry
+	hu  *stealth.Human
 	log *logging.Logger
 }
 
 func New(br *browser.Browser, cfg *config.Config) *Auth {
-	return &Auth{br: br, cfg: cfg, log: logging.New(cfg.Logging.Level).With("module", "auth")}
+	return &Auth{br: br, cfg: cfg, nt: notify.New(cfg), rt: retry.New(cfg), sel: br.Selectors, hu: stealth.New(cfg), log: logging.New(cfg.Logging.Level).With("module", "auth")}
 }
 
+// ErrNoSession is returned when the saved session no longer works and no
+// login is possible: auth.mode is cookies or the credentials aren't set.
+var ErrNoSession = errors.New("no valid LinkedIn session; run `linkedbot login --manual` to log in by hand")
+
+// ErrNotLoggedIn is returned when the user's own Chrome (browser.engine
+// attach) has no LinkedIn session; the bot doesn't log in there for them.
+var ErrNotLoggedIn = errors.New("not logged in to LinkedIn in the attached Chrome; log in there and run again")
+
+// RequireCredentials checks the LinkedIn credentials in env or the secrets
+// file. Only a fresh login needs them; a valid saved session runs without.
+func RequireCredentials(cfg *config.Config) error {
+	email, pass := cfg.Credentials()
+	if email == "" {
+		return fmt.Errorf("%s is required in env", cfg.Auth.EmailEnv)
+	}
+	if pass == "" {
+		return fmt.Errorf("%s is required in env", cfg.Auth.PasswordEnv)
+	}
+	return nil
+}
+
+// EnsureLoggedIn reuses the saved session and, when it has expired, logs in
+// with the credentials unless auth.mode is cookies. In the user's own Chrome
+// only their session is used.
 func (a *Auth) EnsureLoggedIn(ctx context.Context) error {
+	// Login handles its own verification steps; the checkpoint guard watches
+	// everything after it
+	a.br.Disarm()
 	p, err := a.br.NewPage(ctx)
 	if err != nil {
 		return err
 	}
-	defer p.Close()
-	// Try cookies first
-	if err := a.loadCookies(p); err == nil {
-		if ok := a.validateSession(ctx, p); ok {
-			a.log.Info("session validated using cookies")
-			return nil
+	defer a.br.ClosePage(p)
+	if a.br.Attached() {
+		if !a.validateSession(ctx, p) {
+			return ErrNotLoggedIn
 		}
+		a.log.Info("session validated in the attached Chrome")
+		a.br.Guard(ctx)
+		return nil
+	}
+	// Try cookies first; the persistent browser profile may hold a session
+	// without the file
+	if err := a.loadCookies(p); err != nil {
+		a.log.Debug("no saved cookies", "err", err)
+	}
+	if a.validateSession(ctx, p) {
+		a.log.Info("session validated using cookies")
+		a.br.Guard(ctx)
+		return nil
+	}
+	if a.cfg.Auth.Mode == config.AuthCookies {
+		return ErrNoSession
+	}
+	if err := RequireCredentials(a.cfg); err != nil {
+		return fmt.Errorf("%w (%v)", ErrNoSession, err)
 	}
 	// Fresh login
 	if err := a.login(ctx, p); err != nil {
@@ -47,46 +100,59 @@ func (a *Auth) EnsureLoggedIn(ctx context.Context) error {
 	if err := a.saveCookies(p); err != nil {
 		a.log.Warn("save cookies failed", "err", err)
 	}
+	a.br.Guard(ctx)
 	return nil
 }
 
-func (a *Auth) login(ctx context.Context, p *rod.Page) error {
-	email := os.Getenv("LINKEDIN_EMAIL")
-	pass := os.Getenv("LINKEDIN_PASSWORD")
-	if email == "" || pass == "" {
-		return errors.New("missing LINKEDIN_EMAIL or LINKEDIN_PASSWORD env")
-	}
-
-	a.log.Info("attempting login", "email", email)
+// Session states reported by CheckSession.
+const (
+	SessionValid   = "valid"
+	SessionExpired = "expired"
+	SessionBlocked = "blocked"
+)
 
-	// Navigate to login page
-	url := a.cfg.LinkedIn.BaseURL + "login"
-	if err := p.Navigate(url); err != nil {
-		return fmt.Errorf("failed to navigate to login page: %w", err)
+// CheckSession opens the feed once with the saved cookies, without logging
+// in, to tell whether the session still works. A checkpoint or restriction
+// on the way reports SessionBlocked with the *browser.CheckpointError.
+func (a *Auth) CheckSession(ctx context.Context) (string, error) {
+	a.br.Disarm()
+	p, err := a.br.NewPage(ctx)
+	if err != nil {
+		return "", err
 	}
-
-	if err := p.WaitLoad(); err != nil {
-		return fmt.Errorf("login page load failed: %w", err)
+	defer a.br.ClosePage(p)
+	// The persistent browser profile may hold a session without the file;
+	// the user's own Chrome keeps its cookies
+	if !a.br.Attached() {
+		if err := a.loadCookies(p); err != nil {
+			a.log.Debug("no saved cookies", "err", err)
+		}
+	}
+	if a.validateSession(ctx, p) {
+		return SessionValid, nil
 	}
+	if cp := a.br.DetectCheckpoint(p); cp != nil {
+		return SessionBlocked, cp
+	}
+	return SessionExpired, nil
+}
 
-	time.Sleep(1 * time.Second)
+func (a *Auth) login(ctx context.Context, p driver.Page) error {
+	email, pass := a.cfg.Credentials()
+	a.log.Info("attempting login", "email", email)
 
-	// Check if we're on the right login page
-	usernameInput, err := p.Timeout(5 * time.Second).Element("input#username")
+	// Open the login page, falling back to the alternative login URL
+	loginURL := a.cfg.LinkedIn.BaseURL + "login"
+	usernameInput, err := retry.First(ctx, a.rt, "open login form",
+		func() (driver.Element, error) { return a.loginForm(p, loginURL) },
+		func() (driver.Element, error) {
+			a.log.Info("trying alternative login URL")
+			return a.loginForm(p, a.cfg.LinkedIn.BaseURL+"uas/login")
+		},
+	)
 	if err != nil {
-		// Try alternative login URL
-		a.log.Info("trying alternative login URL")
-		if err := p.Navigate(a.cfg.LinkedIn.BaseURL + "uas/login"); err != nil {
-			return fmt.Errorf("failed to navigate to alternative login: %w", err)
-		}
-		if err := p.WaitLoad(); err != nil {
-			return fmt.Errorf("alternative login page load failed: %w", err)
-		}
-		usernameInput, err = p.Timeout(5 * time.Second).Element("input#username")
-		if err != nil {
-			browser.ScreenshotOnError(p, "login_page_fail", err)
-			return fmt.Errorf("username input not found: %w", err)
-		}
+		browser.ScreenshotOnError(p, "login_page_fail", err)
+		return fmt.Errorf("username input not found: %w", err)
 	}
 
 	// Fill email
@@ -98,7 +164,9 @@ func (a *Auth) login(ctx context.Context, p *rod.Page) error {
 
 	// Fill password
 	a.log.Info("filling password")
-	passwordInput, err := p.Timeout(5 * time.Second).Element("input#password")
+	passwordInput, err := retry.Value(ctx, a.rt, "find password input", func() (driver.Element, error) {
+		return a.sel.Get("auth.password_input").Find(p, 5*time.Second)
+	})
 	if err != nil {
 		return fmt.Errorf("password input not found: %w", err)
 	}
@@ -109,11 +177,13 @@ func (a *Auth) login(ctx context.Context, p *rod.Page) error {
 
 	// Click submit button
 	a.log.Info("clicking submit button")
-	submitBtn, err := p.Timeout(5 * time.Second).Element("button[type='submit']")
+	submitBtn, err := retry.Value(ctx, a.rt, "find submit button", func() (driver.Element, error) {
+		return a.sel.Get("auth.submit_button").Find(p, 5*time.Second)
+	})
 	if err != nil {
 		return fmt.Errorf("submit button not found: %w", err)
 	}
-	if err := submitBtn.Click("left", 1); err != nil {
+	if err := submitBtn.Click(); err != nil {
 		return fmt.Errorf("failed to click submit: %w", err)
 	}
 
@@ -122,10 +192,21 @@ func (a *Auth) login(ctx context.Context, p *rod.Page) error {
 	time.Sleep(5 * time.Second)
 
 	// Check if login was successful
-	a.log.Info("checking login success", "current_url", p.MustInfo().URL)
+	a.log.Info("checking login success", "current_url", pageURL(p))
+
+	// A verification checkpoint is not a login: solve it before the
+	// "navigated away from the login page" check below counts it as success
+	currentURL := pageURL(p)
+	if a.onChallenge(p, currentURL) {
+		a.log.Info("verification checkpoint detected", "url", currentURL)
+		a.nt.Notify(ctx, notify.EventCheckpoint, "LinkedIn asked for verification during login", "")
+		if err := a.solveChallenge(ctx, p); err != nil {
+			return err
+		}
+		currentURL = pageURL(p)
+	}
 
 	// Strategy 1: Check current URL - successful login usually redirects to feed or home
-	currentURL := p.MustInfo().URL
 	if strings.Contains(currentURL, "/feed/") || strings.Contains(currentURL, "/feed") {
 		a.log.Info("login successful - detected feed URL")
 		return nil
@@ -136,46 +217,25 @@ func (a *Auth) login(ctx context.Context, p *rod.Page) error {
 	var successMethod string
 
 	// Check 1: Search box (only visible when logged in)
-	if el, err := p.Timeout(5 * time.Second).Element("input[placeholder*='Search'], input[aria-label*='Search']"); err == nil {
+	if el, err := a.sel.Get("auth.search_box").Find(p, 5*time.Second); err == nil {
 		if visible, _ := el.Visible(); visible {
 			success = true
 			successMethod = "search box"
 		}
 	}
 
-	// Check 2: Global navigation bar
+	// Check 2: Navigation elements only rendered for a member
 	if !success {
-		if _, err := p.Timeout(3 * time.Second).Element("nav.global-nav, header.global-alert-offset"); err == nil {
-			success = true
-			successMethod = "navigation bar"
+		for _, sel := range a.sel.Get("auth.logged_in").Set {
+			if _, err := sel.Find(p, 3*time.Second); err == nil {
+				success = true
+				successMethod = sel.String()
+				break
+			}
 		}
 	}
 
-	// Check 3: Feed link in navigation
-	if !success {
-		if _, err := p.Timeout(3 * time.Second).Element("a[href*='/feed']"); err == nil {
-			success = true
-			successMethod = "feed link"
-		}
-	}
-
-	// Check 4: Profile/Me menu
-	if !success {
-		if _, err := p.Timeout(3 * time.Second).Element("[data-control-name='identity_profile_photo'], .global-nav__me-photo"); err == nil {
-			success = true
-			successMethod = "profile menu"
-		}
-	}
-
-	// Check 5: Any element with class containing 'global-nav'
-	if !success {
-		if _, err := p.Timeout(3 * time.Second).Element("[class*='global-nav']"); err == nil {
-			success = true
-			successMethod = "global nav element"
-		}
-	}
-
-	// Check 6: Just check if we're NOT on login page anymore
+	// Check 3: Just check if we're NOT on login page anymore
 	if !success {
 		if !strings.Contains(currentURL, "/login") && !strings.Contains(currentURL, "/uas/login") {
 			// We navigated away from login page - likely successful
@@ -193,7 +253,7 @@ func (a *Auth) login(ctx context.Context, p *rod.Page) error {
 	a.log.Warn("login verification failed, checking for errors")
 
 	// Check for error messages
-	if errEl, err := p.Timeout(2 * time.Second).Element(".alert--error, .form__label--error, .error"); err == nil {
+	if errEl, err := a.sel.Get("auth.login_error").Find(p, 2*time.Second); err == nil {
 		if errText, _ := errEl.Text(); errText != "" {
 			a.log.Error("login error message found", "message", errText)
 			browser.ScreenshotOnError(p, "login_error", errors.New("login failed"))
@@ -202,10 +262,10 @@ func (a *Auth) login(ctx context.Context, p *rod.Page) error {
 	}
 
 	// Check for verification/checkpoint
-	if _, err := p.Timeout(2 * time.Second).Element("[data-test-id='checkpoint'], .challenge-dialog"); err == nil {
+	if a.onChallenge(p, currentURL) {
 		a.log.Error("checkpoint detected")
 		browser.ScreenshotOnError(p, "login_checkpoint", errors.New("checkpoint"))
-		return errors.New("login blocked by checkpoint/verification - please login manually in browser first")
+		return errors.New("login blocked by checkpoint/verification - run `linkedbot login --manual` to log in by hand")
 	}
 
 	// Still on login page?
@@ -215,7 +275,7 @@ func (a *Auth) login(ctx context.Context, p *rod.Page) error {
 
 		// Try to get page title for debugging
 		if title, err := p.Eval("() => document.title"); err == nil {
-			a.log.Info("page title", "title", title.Value.String())
+			a.log.Info("page title", "title", title.Str())
 		}
 
 		return errors.New("login failed: still on login page after submitting credentials")
@@ -224,59 +284,87 @@ func (a *Auth) login(ctx context.Context, p *rod.Page) error {
 	// Unknown state - save debug info
 	a.log.Error("login verification failed - unknown state", "url", currentURL)
 	browser.ScreenshotOnError(p, "login_unknown_fail", errors.New("unknown login failure"))
+	dir := artifacts.Current().Dir
+	a.log.Info("saved a screenshot and the page HTML for debugging", "dir", dir)
 
-	// Save HTML for debugging
-	if html, err := p.HTML(); err == nil {
-		_ = os.WriteFile("login_fail_page.html", []byte(html), 0644)
-		a.log.Info("saved page HTML to login_fail_page.html for debugging")
-	}
+	return fmt.Errorf("login failed: could not verify successful login - check the screenshot and page HTML in %s", dir)
+}
 
-	return errors.New("login failed: could not verify successful login - check screenshot and login_fail_page.html")
+// pageURL is the address p shows, or "" when it can't be read.
+func pageURL(p driver.Page) string {
+	u, _ := p.URL()
+	return u
 }
 
-func (a *Auth) validateSession(ctx context.Context, p *rod.Page) bool {
-	_ = p.Navigate(a.cfg.LinkedIn.BaseURL + "feed/")
+// loginForm loads a login page and returns its username input.
+func (a *Auth) loginForm(p driver.Page, u string) (driver.Element, error) {
+	if err := p.Navigate(u); err != nil {
+		return nil, fmt.Errorf("failed to navigate to %s: %w", u, err)
+	}
 	if err := p.WaitLoad(); err != nil {
+		return nil, fmt.Errorf("login page load failed: %w", err)
+	}
+	time.Sleep(1 * time.Second)
+	return a.sel.Get("auth.username_input").Find(p, 5*time.Second)
+}
+
+func (a *Auth) validateSession(ctx context.Context, p driver.Page) bool {
+	if err := browser.Navigate(ctx, a.rt, p, a.cfg.LinkedIn.BaseURL+"feed/"); err != nil {
 		return false
 	}
-	if _, err := p.Element("a[href*='/feed/']"); err == nil {
+	if _, err := a.sel.Get("auth.session_check").Find(p, 10*time.Second); err == nil {
 		return true
 	}
 	return false
 }
 
-func cookiesPath() string {
-	return filepath.Join(".cache", "cookies.json")
-}
-
-func (a *Auth) loadCookies(p *rod.Page) error {
-	b, err := os.ReadFile(cookiesPath())
+func (a *Auth) loadCookies(p driver.Page) error {
+	b, err := os.ReadFile(a.cfg.Auth.CookiePath)
 	if err != nil {
 		return err
 	}
-	var cookies []*proto.NetworkCookie
+	// A plaintext file from before secrets.encrypt_cookies still loads and
+	// is encrypted on the next save
+	if secrets.IsSealed(b) {
+		if b, err = secrets.Open(a.cfg.SecretsPassphrase(), b); err != nil {
+			return fmt.Errorf("%s: %w", a.cfg.Auth.CookiePath, err)
+		}
+	}
+	var cookies []driver.Cookie
 	if err := json.Unmarshal(b, &cookies); err != nil {
 		return err
 	}
-	for _, c := range cookies {
-		_, _ = proto.NetworkSetCookie{Domain: c.Domain, Name: c.Name, Value: c.Value, Path: c.Path, Expires: c.Expires, HTTPOnly: c.HTTPOnly, Secure: c.Secure}.Call(p)
-	}
+	_ = p.SetCookies(cookies)
 	return nil
 }
 
-func (a *Auth) saveCookies(p *rod.Page) error {
+// SaveSession writes the browser's current cookies to auth.cookie_path, so
+// the next start loads the session as LinkedIn last refreshed it.
+func (a *Auth) SaveSession(ctx context.Context) error {
+	p, err := a.br.NewPage(ctx)
+	if err != nil {
+		return err
+	}
+	defer a.br.ClosePage(p)
+	return a.saveCookies(p)
+}
+
+func (a *Auth) saveCookies(p driver.Page) error {
 	// Increase timeout and retry once to avoid deadline issues
 	pp := p.Timeout(20 * time.Second)
-	cookies, err := proto.StorageGetCookies{}.Call(pp)
+	cookies, err := pp.Cookies()
 	if err != nil {
 		// brief retry
 		time.Sleep(500 * time.Millisecond)
-		cookies, err = proto.StorageGetCookies{}.Call(pp)
+		cookies, err = pp.Cookies()
 		if err != nil {
 			return err
 		}
 	}
-	b, _ := json.MarshalIndent(cookies.Cookies, "", "  ")
-	_ = os.MkdirAll(filepath.Dir(cookiesPath()), 0o755)
-	return os.WriteFile(cookiesPath(), b, 0644)
+	b, _ := json.MarshalIndent(cookies, "", "  ")
+	if a.cfg.Secrets.EncryptCookies {
+		return secrets.WriteFile(a.cfg.Auth.CookiePath, a.cfg.SecretsPassphrase(), b)
+	}
+	_ = os.MkdirAll(filepath.Dir(a.cfg.Auth.CookiePath), 0o755)
+	return os.WriteFile(a.cfg.Auth.CookiePath, b, 0644)
 }
@@ -0,0 +1,125 @@
+package auth
+
+import (
+	"bufio"
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/example/linkedbot/internal/browser"
+	"github.com/example/linkedbot/internal/driver"
+	"github.com/example/linkedbot/internal/stealth"
+)
+
+// onChallenge reports whether login landed on a verification checkpoint.
+func (a *Auth) onChallenge(p driver.Page, currentURL string) bool {
+	if strings.Contains(currentURL, "/checkpoint") {
+		return true
+	}
+	return a.sel.Get("auth.challenge").Has(p)
+}
+
+// solveChallenge enters a verification code on the checkpoint page. The code
+// is generated from auth.totp_secret_env for authenticator challenges and
+// read from stdin otherwise. Challenges without a code input (captcha, app
+// approval) are left to the user.
+func (a *Auth) solveChallenge(ctx context.Context, p driver.Page) error {
+	input, err := a.sel.Get("auth.pin_input").Find(p, 5*time.Second)
+	if err != nil {
+		browser.ScreenshotOnError(p, "login_checkpoint", errors.New("checkpoint"))
+		return errors.New("login blocked by checkpoint/verification - run `linkedbot login --manual` to log in by hand")
+	}
+
+	authenticator := a.sel.Get("auth.authenticator_hint").Has(p)
+	var code string
+	if secret := a.cfg.Secret(a.cfg.Auth.TOTPSecretEnv); authenticator && a.cfg.Auth.TOTPSecretEnv != "" && secret != "" {
+		a.log.Info("authenticator challenge, generating code")
+		if code, err = totpCode(secret, time.Now()); err != nil {
+			return fmt.Errorf("%s: %w", a.cfg.Auth.TOTPSecretEnv, err)
+		}
+	} else {
+		kind := "email/SMS"
+		if authenticator {
+			kind = "authenticator app"
+		}
+		a.log.Info("verification code required, waiting for input", "kind", kind)
+		timeout := time.Duration(a.cfg.Auth.ChallengeTimeoutSec) * time.Second
+		if code, err = promptCode(ctx, kind, timeout); err != nil {
+			browser.ScreenshotOnError(p, "login_checkpoint", err)
+			return err
+		}
+	}
+
+	if err := a.hu.TypeHumanLike(input, code); err != nil {
+		return fmt.Errorf("failed to type verification code: %w", err)
+	}
+	stealth.SleepRandom(300, 700)
+	submit, err := a.sel.Get("auth.challenge_submit").Find(p, 5*time.Second)
+	if err != nil {
+		return fmt.Errorf("verification submit button not found: %w", err)
+	}
+	if err := a.hu.ClickHumanLike(p, submit); err != nil {
+		return fmt.Errorf("failed to submit verification code: %w", err)
+	}
+	time.Sleep(5 * time.Second)
+
+	if a.onChallenge(p, pageURL(p)) {
+		browser.ScreenshotOnError(p, "login_checkpoint_rejected", errors.New("code rejected"))
+		return errors.New("verification code was not accepted")
+	}
+	a.log.Info("verification challenge passed")
+	return nil
+}
+
+// promptCode asks for the verification code on the terminal.
+func promptCode(ctx context.Context, kind string, timeout time.Duration) (string, error) {
+	fmt.Fprintf(os.Stderr, "\nLinkedIn asks for a verification code (%s).\nEnter code: ", kind)
+	lines := make(chan string, 1)
+	errs := make(chan error, 1)
+	go func() {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			errs <- err
+			return
+		}
+		lines <- strings.TrimSpace(line)
+	}()
+	select {
+	case <-ctx.Done():
+		return "", ctx.Err()
+	case <-time.After(timeout):
+		return "", fmt.Errorf("no verification code entered within %s", timeout)
+	case err := <-errs:
+		return "", fmt.Errorf("read verification code: %w", err)
+	case code := <-lines:
+		if code == "" {
+			return "", errors.New("empty verification code")
+		}
+		return code, nil
+	}
+}
+
+// totpCode computes the current RFC 6238 code (SHA-1, 30s step, 6 digits),
+// the variant LinkedIn's authenticator setup uses.
+func totpCode(secret string, t time.Time) (string, error) {
+	secret = strings.ToUpper(strings.TrimRight(strings.ReplaceAll(secret, " ", ""), "="))
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(secret)
+	if err != nil {
+		return "", fmt.Errorf("invalid base32 TOTP secret: %w", err)
+	}
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(t.Unix()/30))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+	off := sum[len(sum)-1] & 0x0f
+	code := (binary.BigEndian.Uint32(sum[off:off+4]) & 0x7fffffff) % 1000000
+	return fmt.Sprintf("%06d", code), nil
+}
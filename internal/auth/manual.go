@@ -0,0 +1,95 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/example/linkedbot/internal/browser"
+	"github.com/example/linkedbot/internal/driver"
+)
+
+// manualPoll is how often ManualLogin checks whether the user has logged in
+const manualPoll = 2 * time.Second
+
+// Steps of a manual login, reported on the terminal as the user moves on.
+const (
+	manualLoginPage = "login"
+	manualChallenge = "challenge"
+	manualLoggedIn  = "logged_in"
+	manualOther     = "other"
+)
+
+// ManualLogin opens the login page in the browser window and waits, until
+// ctx ends, for the user to log in by hand, verification codes and CAPTCHAs
+// included. Nothing is typed or clicked for them. Once a logged-in page
+// shows, the session cookies are saved and the browser fingerprint pinned
+// to the session. The browser must be headful.
+func (a *Auth) ManualLogin(ctx context.Context) error {
+	a.br.Disarm()
+	p, err := a.br.NewPage(ctx)
+	if err != nil {
+		return err
+	}
+	defer a.br.ClosePage(p)
+	if err := browser.Navigate(ctx, a.rt, p, a.cfg.LinkedIn.BaseURL+"login"); err != nil {
+		return err
+	}
+	deadline, _ := ctx.Deadline()
+	fmt.Fprintf(os.Stderr, "\nLog in to LinkedIn in the browser window, with any verification it asks for.\n")
+	if !deadline.IsZero() {
+		fmt.Fprintf(os.Stderr, "Waiting until %s ...\n", deadline.Format("15:04"))
+	}
+	a.log.Info("waiting for manual login")
+	tick := time.NewTicker(manualPoll)
+	defer tick.Stop()
+	last := ""
+	for {
+		select {
+		case <-ctx.Done():
+			browser.ScreenshotOnError(p, "manual_login_timeout", ctx.Err())
+			return fmt.Errorf("manual login not finished: %w", ctx.Err())
+		case <-tick.C:
+		}
+		u, err := p.URL()
+		if err != nil {
+			return fmt.Errorf("browser window closed: %w", err)
+		}
+		step := a.manualStep(p, u)
+		if step != last {
+			a.log.Info("manual login", "step", step, "url", u)
+			if step == manualChallenge {
+				fmt.Fprintln(os.Stderr, "LinkedIn asks for verification; finish it in the browser window.")
+			}
+			last = step
+		}
+		if step == manualLoggedIn {
+			break
+		}
+	}
+	if err := a.saveCookies(p); err != nil {
+		return fmt.Errorf("save cookies: %w", err)
+	}
+	if err := a.br.PinFingerprint(); err != nil {
+		a.log.Warn("save fingerprint failed", "err", err)
+	}
+	a.log.Info("manual login captured", "cookie_path", a.cfg.Auth.CookiePath)
+	fmt.Fprintf(os.Stderr, "Logged in. Session saved to %s.\n", a.cfg.Auth.CookiePath)
+	return nil
+}
+
+// manualStep tells where the user is: on the login form, on a verification
+// page, logged in (a page with the member navigation), or elsewhere.
+func (a *Auth) manualStep(p driver.Page, currentURL string) string {
+	switch {
+	case a.onChallenge(p, currentURL):
+		return manualChallenge
+	case strings.Contains(currentURL, "/login") || strings.Contains(currentURL, "/uas/"):
+		return manualLoginPage
+	case a.sel.Get("auth.session_check").Has(p), a.sel.Get("auth.logged_in").Has(p):
+		return manualLoggedIn
+	}
+	return manualOther
+}
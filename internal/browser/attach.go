@@ -0,0 +1,70 @@
+package browser
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/example/linkedbot/internal/config"
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/launcher"
+	"github.com/go-rod/rod/lib/proto"
+)
+
+// attachEngine drives the user's own Chrome, started with
+// --remote-debugging-port=browser.debug_port. Its tabs already carry the
+// user's LinkedIn session and its fingerprint is a real one, so neither is
+// touched.
+type attachEngine struct {
+	port int
+}
+
+func (e attachEngine) Name() string { return config.EngineAttach }
+
+func (e attachEngine) ControlURL(ctx context.Context) (string, error) {
+	ws, err := launcher.ResolveURL(strconv.Itoa(e.port))
+	if err != nil {
+		return "", fmt.Errorf("no Chrome listening on port %d; start it with --remote-debugging-port=%d: %w", e.port, e.port, err)
+	}
+	return ws, nil
+}
+
+// Attached reports whether this is the user's own Chrome (browser.engine
+// attach): the session is theirs, and the browser stays open after the run.
+func (b *Browser) Attached() bool { return b.attached }
+
+// initAttached records what the user's Chrome presents instead of
+// overriding it.
+func (b *Browser) initAttached() error {
+	v, err := proto.BrowserGetVersion{}.Call(b.Rod)
+	if err != nil {
+		return fmt.Errorf("attach: %w", err)
+	}
+	b.fp = SessionFingerprint{UserAgent: v.UserAgent, Platform: platformFor(v.UserAgent)}
+	b.log.Info("attached to running Chrome", "product", v.Product, "ua", v.UserAgent)
+	return nil
+}
+
+// borrowTab returns a LinkedIn tab the user has open and the bot isn't
+// already using, or nil. ClosePage gives it back instead of closing it.
+func (b *Browser) borrowTab() *rod.Page {
+	pages, err := b.Rod.Pages()
+	if err != nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.borrowed != "" {
+		return nil
+	}
+	for _, p := range pages {
+		info, err := p.Info()
+		if err != nil || b.live[p.TargetID] || !strings.Contains(info.URL, "linkedin.com") {
+			continue
+		}
+		b.borrowed = p.TargetID
+		return p
+	}
+	return nil
+}
@@ -2,97 +2,217 @@ package browser
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"math/rand"
-	"os"
+	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/example/linkedbot/internal/artifacts"
 	"github.com/example/linkedbot/internal/config"
+	"github.com/example/linkedbot/internal/driver"
 	"github.com/example/linkedbot/internal/logging"
+	"github.com/example/linkedbot/internal/retry"
+	"github.com/example/linkedbot/internal/selectors"
+	"github.com/example/linkedbot/internal/stealth"
 	"github.com/go-rod/rod"
 	"github.com/go-rod/rod/lib/launcher"
+	"github.com/go-rod/rod/lib/launcher/flags"
 	"github.com/go-rod/rod/lib/proto"
 )
 
 type Browser struct {
 	Rod *rod.Browser
 	Cfg *config.Config
-	log *logging.Logger
+	// Selectors are the page selectors in effect, see selectors.Load
+	Selectors *selectors.Registry
+	log       *logging.Logger
+	fp        SessionFingerprint
+
+	// Page lifecycle bookkeeping, see NewPage/ClosePage
+	mu     sync.Mutex
+	live   map[proto.TargetTargetID]bool
+	opened int
+	closed int
+
+	// attached is set for the user's own Chrome, see Attached; borrowed is
+	// their LinkedIn tab while the bot uses it
+	attached bool
+	borrowed proto.TargetTargetID
+
+	// Checkpoint guard state, see Guard
+	guardMu  sync.Mutex
+	guarding bool
+	tripped  bool
+	abort    context.CancelCauseFunc
 }
 
 func New(ctx context.Context, cfg *config.Config) (*Browser, error) {
 	log := logging.New(cfg.Logging.Level).With("module", "browser")
-	// Use normal launcher but disable leakless to avoid AV false positives on Windows
-	l := launcher.New().Leakless(false)
-	// Force headful for visibility during runs
-	l = l.Headless(false)
-	url, err := l.Launch()
+	sel, err := selectors.Load(ctx, cfg)
 	if err != nil {
 		return nil, err
 	}
-	rb := rod.New().ControlURL(url).MustConnect()
-	br := &Browser{Rod: rb, Cfg: cfg, log: log}
+	proxy, err := pickProxy(cfg)
+	if err != nil {
+		log.Warn("failed to persist proxy assignment", "err", err)
+	}
+	var server, user, pass string
+	if proxy != "" && cfg.Browser.Engine != config.EngineLocal {
+		// Chrome only takes a proxy server at launch
+		log.Warn("browser.proxy is not applied to a remote browser; set it where the browser runs")
+	} else if proxy != "" {
+		if server, user, pass, err = splitProxy(proxy); err != nil {
+			return nil, fmt.Errorf("invalid proxy: %w", err)
+		}
+		log.Info("using proxy", "server", server, "authenticated", user != "")
+	}
+	engine := newEngine(cfg, server)
+	url, err := engine.ControlURL(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rb := rod.New().ControlURL(url)
+	if err := rb.Connect(); err != nil {
+		return nil, fmt.Errorf("connect to %s browser: %w", engine.Name(), err)
+	}
+	log.Info("browser connected", "engine", engine.Name())
+	br := &Browser{Rod: rb, Cfg: cfg, Selectors: sel, log: log, live: map[proto.TargetTargetID]bool{}, attached: cfg.Browser.Engine == config.EngineAttach}
+	if user != "" {
+		if err := br.handleProxyAuth(user, pass); err != nil {
+			return nil, fmt.Errorf("proxy auth: %w", err)
+		}
+	}
 	if err := br.init(ctx); err != nil {
 		return nil, err
 	}
 	return br, nil
 }
 
+// newLauncher builds the Chrome launcher. stealth.headless uses Chrome's new
+// headless mode, which shares the regular browser's code paths. With
+// browser.background a headful window starts minimized and off-screen, so
+// new tabs and navigations don't pull it in front of whatever the user is
+// doing. proxyServer is scheme://host:port without credentials, or "".
+func newLauncher(cfg *config.Config, proxyServer string) *launcher.Launcher {
+	// Use normal launcher but disable leakless to avoid AV false positives on Windows
+	l := launcher.New().Leakless(false)
+	if cfg.Stealth.Headless {
+		l = l.HeadlessNew(true)
+	} else {
+		l = l.Headless(false)
+	}
+	if cfg.Browser.Background && !cfg.Stealth.Headless {
+		l = l.Set(flags.Flag("window-position"), "-32000,-32000").
+			Set(flags.Flag("start-minimized"))
+	}
+	if proxyServer != "" {
+		l = l.Proxy(proxyServer)
+	}
+	if dir := profileDir(cfg); dir != "" {
+		l = l.UserDataDir(dir)
+	}
+	return l
+}
+
+// profileDir is the Chrome user-data-dir for the account, or "" to let the
+// launcher use a throwaway one. Chrome locks the directory, so two processes
+// can't drive the same account at once.
+func profileDir(cfg *config.Config) string {
+	if !cfg.Browser.PersistentProfile {
+		return ""
+	}
+	if cfg.Browser.ProfileDir != "" {
+		return cfg.Browser.ProfileDir
+	}
+	dir, err := filepath.Abs(filepath.Join(".cache", cfg.AccountKey(), "chrome-profile"))
+	if err != nil {
+		return filepath.Join(".cache", cfg.AccountKey(), "chrome-profile")
+	}
+	return dir
+}
+
 func (b *Browser) init(ctx context.Context) error {
+	if b.attached {
+		return b.initAttached()
+	}
 	b.Rod = b.Rod.MustIgnoreCertErrors(true)
 
 	// Create a default page for initial stealth setup
 	p := b.Rod.MustPage("about:blank")
 
-	// 1. User Agent Randomization
-	ua := b.Cfg.Stealth.UserAgent
-	if ua == "" {
-		// Latest realistic user agents
-		uas := []string{
-			"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/131.0.0.0 Safari/537.36",
-			"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/130.0.0.0 Safari/537.36",
-			"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/131.0.0.0 Safari/537.36",
-			"Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:133.0) Gecko/20100101 Firefox/133.0",
-			"Mozilla/5.0 (Macintosh; Intel Mac OS X 14_1) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.2 Safari/605.1.15",
-		}
-		ua = uas[rand.Intn(len(uas))]
-	}
-
-	// Extract platform info from UA for consistency
-	platform := "Win32"
-	if contains(ua, "Macintosh") {
-		platform = "MacIntel"
-	} else if contains(ua, "Linux") {
-		platform = "Linux x86_64"
+	// 1. Per-account fingerprint (user agent, platform, viewport)
+	fp, reused, err := loadOrCreateFingerprint(b.Cfg)
+	if err != nil {
+		b.log.Warn("failed to persist fingerprint", "err", err)
 	}
+	b.fp = fp
 
 	_ = proto.EmulationSetUserAgentOverride{
-		UserAgent: ua,
-		Platform:  platform,
+		UserAgent: fp.UserAgent,
+		Platform:  fp.Platform,
 	}.Call(p)
 
-	// 2. Viewport Randomization (realistic dimensions)
-	w := randRange(b.Cfg.Stealth.ViewportWidthMin, b.Cfg.Stealth.ViewportWidthMax)
-	h := randRange(b.Cfg.Stealth.ViewportHeightMin, b.Cfg.Stealth.ViewportHeightMax)
+	// 2. Viewport from the fingerprint
 	_ = p.SetViewport(&proto.EmulationSetDeviceMetricsOverride{
-		Width:             w,
-		Height:            h,
+		Width:             fp.Width,
+		Height:            fp.Height,
 		DeviceScaleFactor: 1,
 		Mobile:            false,
 	})
 
 	// 3. Comprehensive Fingerprint Masking
-	_, _ = p.Eval(getStealthScript(w, h, platform))
+	_, _ = p.Eval(`() => ` + getStealthScript(fp))
 
 	p.MustClose()
-	b.log.Info("browser fingerprint initialized", "ua", ua, "viewport", fmt.Sprintf("%dx%d", w, h))
+	b.log.Info("browser fingerprint initialized", "ua", fp.UserAgent, "viewport", fmt.Sprintf("%dx%d", fp.Width, fp.Height), "reused", reused, "headless", b.Cfg.Stealth.Headless, "timezone", b.Cfg.Location().String(), "webgl", fp.WebGLRenderer)
 	return nil
 }
 
-// getStealthScript returns comprehensive anti-detection JavaScript
-func getStealthScript(width, height int, platform string) string {
-	return `(width, height, platform) => {
+// headlessPatches hides the differences headless Chrome still shows next to
+// a headful one: zero outer window size, a HeadlessChrome brand in client
+// hints, a missing window.chrome and a notification permission that
+// contradicts the Permissions API.
+const headlessPatches = `(() => {
+	if (!window.chrome) {
+		window.chrome = { runtime: {}, app: {}, loadTimes: function() {}, csi: function() {} };
+	}
+	if (window.outerWidth === 0 || window.outerHeight === 0) {
+		Object.defineProperty(window, 'outerWidth', { get: () => window.innerWidth });
+		Object.defineProperty(window, 'outerHeight', { get: () => window.innerHeight + 85 });
+	}
+	if (navigator.userAgentData && navigator.userAgentData.brands) {
+		const brands = navigator.userAgentData.brands.map(b =>
+			b.brand === 'HeadlessChrome' ? { brand: 'Google Chrome', version: b.version } : b);
+		Object.defineProperty(navigator.userAgentData, 'brands', { get: () => brands });
+	}
+	if ('headless' in navigator) {
+		Object.defineProperty(navigator, 'headless', { get: () => undefined });
+	}
+	if (window.Notification && Notification.permission === 'denied') {
+		Object.defineProperty(Notification, 'permission', { get: () => 'default' });
+	}
+})()`
+
+// getStealthScript returns the anti-detection script for fp. It runs on
+// every new document, with the fingerprint's values baked in so each page
+// reports the same device.
+func getStealthScript(fp SessionFingerprint) string {
+	vals, _ := json.Marshal(map[string]any{
+		"width":    fp.Width,
+		"height":   fp.Height,
+		"platform": fp.Platform,
+		"cores":    fp.HardwareConcurrency,
+		"memory":   fp.DeviceMemory,
+		"vendor":   fp.WebGLVendor,
+		"renderer": fp.WebGLRenderer,
+		"langs":    fp.Languages,
+		"seed":     fp.NoiseSeed,
+	})
+	return `((fp) => {
 		// 1. Remove webdriver property
 		Object.defineProperty(navigator, 'webdriver', {
 			get: () => undefined
@@ -127,9 +247,9 @@ func getStealthScript(width, height int, platform string) string {
 			]
 		});
 		
-		// 4. Mock languages (realistic)
+		// 4. Languages from the fingerprint
 		Object.defineProperty(navigator, 'languages', {
-			get: () => ['en-US', 'en']
+			get: () => fp.langs
 		});
 		
 		// 5. Override permission API
@@ -140,63 +260,65 @@ func getStealthScript(width, height int, platform string) string {
 				: originalQuery(parameters)
 		);
 		
-		// 6. Mock hardware concurrency (realistic CPU count)
+		// 6. CPU count from the fingerprint
 		Object.defineProperty(navigator, 'hardwareConcurrency', {
-			get: () => 4 + Math.floor(Math.random() * 8) // 4-12 cores
+			get: () => fp.cores
 		});
 		
-		// 7. Mock device memory
+		// 7. Device memory from the fingerprint
 		Object.defineProperty(navigator, 'deviceMemory', {
-			get: () => 8
+			get: () => fp.memory
 		});
 		
-		// 8. Canvas fingerprint randomization (slight noise)
+		// 8. Canvas fingerprint noise, the same pixels on every run
 		const originalToDataURL = HTMLCanvasElement.prototype.toDataURL;
 		HTMLCanvasElement.prototype.toDataURL = function(type) {
 			const context = this.getContext('2d');
-			const imageData = context.getImageData(0, 0, this.width, this.height);
-			
-			// Add minimal noise to prevent fingerprinting
-			for (let i = 0; i < imageData.data.length; i += 4) {
-				if (Math.random() < 0.001) {
-					imageData.data[i] = imageData.data[i] + Math.floor(Math.random() * 2) - 1;
+			if (context && this.width > 0 && this.height > 0) {
+				const imageData = context.getImageData(0, 0, this.width, this.height);
+				for (let i = 0; i < imageData.data.length; i += 4) {
+					const h = Math.imul((i >>> 2) ^ fp.seed, 2654435761) >>> 0;
+					if (h % 1000 === 0) {
+						imageData.data[i] = imageData.data[i] ^ 1;
+					}
 				}
+				context.putImageData(imageData, 0, 0);
 			}
-			
-			context.putImageData(imageData, 0, 0);
 			return originalToDataURL.apply(this, arguments);
 		};
 		
-		// 9. WebGL fingerprint masking
-		const getParameter = WebGLRenderingContext.prototype.getParameter;
-		WebGLRenderingContext.prototype.getParameter = function(parameter) {
-			// Mask specific WebGL parameters
-			if (parameter === 37445) { // UNMASKED_VENDOR_WEBGL
-				return 'Intel Inc.';
-			}
-			if (parameter === 37446) { // UNMASKED_RENDERER_WEBGL
-				return 'Intel Iris OpenGL Engine';
-			}
-			return getParameter.apply(this, arguments);
-		};
+		// 9. WebGL vendor and renderer from the fingerprint
+		for (const ctx of [window.WebGLRenderingContext, window.WebGL2RenderingContext]) {
+			if (!ctx) continue;
+			const getParameter = ctx.prototype.getParameter;
+			ctx.prototype.getParameter = function(parameter) {
+				if (parameter === 37445) { // UNMASKED_VENDOR_WEBGL
+					return fp.vendor;
+				}
+				if (parameter === 37446) { // UNMASKED_RENDERER_WEBGL
+					return fp.renderer;
+				}
+				return getParameter.apply(this, arguments);
+			};
+		}
 		
 		// 10. Screen dimensions consistency
 		Object.defineProperty(window.screen, 'width', {
-			get: () => width + 100 // Slightly larger than viewport
+			get: () => fp.width + 100 // Slightly larger than viewport
 		});
 		Object.defineProperty(window.screen, 'height', {
-			get: () => height + 100
+			get: () => fp.height + 100
 		});
 		Object.defineProperty(window.screen, 'availWidth', {
-			get: () => width + 100
+			get: () => fp.width + 100
 		});
 		Object.defineProperty(window.screen, 'availHeight', {
-			get: () => height + 60 // Account for taskbar
+			get: () => fp.height + 60 // Account for taskbar
 		});
 		
 		// 11. Platform consistency
 		Object.defineProperty(navigator, 'platform', {
-			get: () => platform
+			get: () => fp.platform
 		});
 		
 		// 12. Battery API masking (avoid giving extra fingerprint data)
@@ -218,37 +340,140 @@ func getStealthScript(width, height int, platform string) string {
 				saveData: false
 			})
 		});
-		
-		// 14. Timezone consistency
-		Date.prototype.getTimezoneOffset = function() {
-			return -300; // EST/EDT
-		};
-	}`
+	})(` + string(vals) + `)`
 }
 
 func contains(s, substr string) bool {
 	return strings.Contains(s, substr)
 }
 
-func (b *Browser) NewPage(ctx context.Context) (*rod.Page, error) {
+// NewPage opens a tab with the session fingerprint applied. Callers must
+// release it with ClosePage so the live page count stays accurate. In the
+// user's own Chrome it hands out their open LinkedIn tab first and applies
+// nothing.
+func (b *Browser) NewPage(ctx context.Context) (driver.Page, error) {
+	if b.attached {
+		p := b.borrowTab()
+		if p == nil {
+			p = b.Rod.MustPage("")
+		}
+		b.watchLoads(p)
+		b.mu.Lock()
+		b.live[p.TargetID] = true
+		b.opened++
+		b.mu.Unlock()
+		return driver.Rod(p.Timeout(300 * time.Second)), nil
+	}
+	b.watchPages()
 	p := b.Rod.MustPage("")
+	b.watchLoads(p)
+	b.mu.Lock()
+	b.live[p.TargetID] = true
+	b.opened++
+	b.mu.Unlock()
 
 	// Set a very long default timeout to handle slow typing operations
 	p = p.Timeout(300 * time.Second) // 5 minutes
 
-	// Apply stealth scripts to each new page
-	w := randRange(b.Cfg.Stealth.ViewportWidthMin, b.Cfg.Stealth.ViewportWidthMax)
-	h := randRange(b.Cfg.Stealth.ViewportHeightMin, b.Cfg.Stealth.ViewportHeightMax)
-	platform := "Win32"
+	// Every page presents the same session fingerprint
+	_ = proto.EmulationSetUserAgentOverride{
+		UserAgent: b.fp.UserAgent,
+		Platform:  b.fp.Platform,
+	}.Call(p)
+	_ = p.SetViewport(&proto.EmulationSetDeviceMetricsOverride{
+		Width:             b.fp.Width,
+		Height:            b.fp.Height,
+		DeviceScaleFactor: 1,
+		Mobile:            false,
+	})
+	// Date and Intl report the fingerprint's timezone (stealth.timezone, the
+	// zone the active window and schedules follow) instead of the machine's
+	if tz := b.fp.Timezone; tz != "" {
+		if err := (proto.EmulationSetTimezoneOverride{TimezoneID: tz}).Call(p); err != nil {
+			b.log.Warn("timezone override failed", "timezone", tz, "err", err)
+		}
+	}
 
 	// Apply stealth on every page navigation
-	p.EvalOnNewDocument(getStealthScript(w, h, platform))
+	p.EvalOnNewDocument(getStealthScript(b.fp))
+	if b.Cfg.Stealth.Headless {
+		p.EvalOnNewDocument(headlessPatches)
+	}
 
-	return p, nil
+	return driver.Rod(p), nil
+}
+
+// ClosePage closes a page opened by NewPage and updates the live count.
+func (b *Browser) ClosePage(p driver.Page) {
+	if p == nil {
+		return
+	}
+	id := proto.TargetTargetID(p.ID())
+	b.mu.Lock()
+	lent := b.borrowed != "" && b.borrowed == id
+	if lent {
+		b.borrowed = ""
+	}
+	b.mu.Unlock()
+	// The user's own tab stays open
+	if !lent {
+		if err := p.Close(); err != nil {
+			b.log.Debug("page close failed", "err", err)
+		}
+	}
+	stealth.Forget(p)
+	b.mu.Lock()
+	if b.live[id] {
+		delete(b.live, id)
+		b.closed++
+	}
+	b.mu.Unlock()
+}
+
+// PageStats reports pages opened and closed through NewPage/ClosePage and
+// how many are still live.
+func (b *Browser) PageStats() (opened, closed, live int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.opened, b.closed, len(b.live)
+}
+
+// watchPages warns when more tabs are open than browser.max_open_pages and
+// recycles tabs that were not opened through NewPage (leaked or stray
+// windows). Tracked pages are never closed behind a caller's back.
+func (b *Browser) watchPages() {
+	limit := b.Cfg.Browser.MaxOpenPages
+	if limit <= 0 {
+		return
+	}
+	pages, err := b.Rod.Pages()
+	if err != nil || len(pages) < limit {
+		return
+	}
+	b.mu.Lock()
+	var stray []*rod.Page
+	for _, pg := range pages {
+		if !b.live[pg.TargetID] {
+			stray = append(stray, pg)
+		}
+	}
+	tracked := len(b.live)
+	b.mu.Unlock()
+	b.log.Warn("too many open pages", "open", len(pages), "tracked", tracked, "max", limit)
+	for _, pg := range stray {
+		if err := pg.Close(); err == nil {
+			b.log.Info("recycled untracked page", "target", pg.TargetID)
+		}
+	}
 }
 
 func (b *Browser) Close() {
-	if b.Rod != nil {
+	if opened, closed, live := b.PageStats(); live > 0 {
+		b.log.Warn("pages left open at shutdown", "opened", opened, "closed", closed, "live", live)
+	}
+	// The user's own Chrome keeps running; the connection goes with the
+	// process
+	if b.Rod != nil && !b.attached {
 		_ = b.Rod.Close()
 	}
 }
@@ -261,7 +486,7 @@ func randRange(min, max int) int {
 }
 
 // Helpers
-func WaitVisible(p *rod.Page, sel string, d time.Duration) error {
+func WaitVisible(p driver.Page, sel string, d time.Duration) error {
 	if err := p.Timeout(d).WaitLoad(); err != nil {
 		return err
 	}
@@ -272,7 +497,7 @@ func WaitVisible(p *rod.Page, sel string, d time.Duration) error {
 	return el.WaitVisible()
 }
 
-func Click(p *rod.Page, sel string) error {
+func Click(p driver.Page, sel string) error {
 	el, err := p.Timeout(10 * time.Second).Element(sel)
 	if err != nil {
 		return err
@@ -280,10 +505,10 @@ func Click(p *rod.Page, sel string) error {
 	if err := el.WaitVisible(); err != nil {
 		return err
 	}
-	return el.Click("left", 1)
+	return el.Click()
 }
 
-func Type(p *rod.Page, sel, text string) error {
+func Type(p driver.Page, sel, text string) error {
 	el, err := p.Timeout(10 * time.Second).Element(sel)
 	if err != nil {
 		return err
@@ -294,8 +519,57 @@ func Type(p *rod.Page, sel, text string) error {
 	return el.Input(text)
 }
 
+// WaitEnabled waits until el is visible and neither disabled nor
+// aria-disabled, e.g. a Send button that unlocks once text is entered.
+func WaitEnabled(el driver.Element, d time.Duration) error {
+	el = el.Timeout(d)
+	if err := el.WaitVisible(); err != nil {
+		return err
+	}
+	return el.Wait(`() => !this.disabled && this.getAttribute('aria-disabled') !== 'true'`)
+}
+
+// WaitFocusable waits until an input or contenteditable is visible and
+// enabled, then focuses it so typing lands in the right place.
+func WaitFocusable(el driver.Element, d time.Duration) error {
+	if err := WaitEnabled(el, d); err != nil {
+		return err
+	}
+	return el.Timeout(d).Focus()
+}
+
+// WaitGone waits until el is hidden or detached, e.g. a dialog closing.
+func WaitGone(el driver.Element, d time.Duration) error {
+	return el.Timeout(d).WaitInvisible()
+}
+
+// WaitEmpty waits until an input or contenteditable holds no text, which is
+// how the message composer signals that a message went out.
+func WaitEmpty(el driver.Element, d time.Duration) error {
+	return el.Timeout(d).Wait(`() => ((this.value ?? this.innerText) || '').trim() === ''`)
+}
+
+// ErrNavigation wraps the error of a page that could not be loaded.
+var ErrNavigation = errors.New("navigation failed")
+
+// Navigate loads u in p and waits for the load event, retrying failed
+// navigations with rt's backoff. Failures other than ctx ending wrap
+// ErrNavigation.
+func Navigate(ctx context.Context, rt *retry.Retrier, p driver.Page, u string) error {
+	err := rt.Do(ctx, "navigate to "+u, func() error {
+		if err := p.Navigate(u); err != nil {
+			return err
+		}
+		return p.WaitLoad()
+	})
+	if err != nil && ctx.Err() == nil {
+		return fmt.Errorf("%w: %w", ErrNavigation, err)
+	}
+	return err
+}
+
 // ClickByText clicks an element containing specific text
-func ClickByText(p *rod.Page, text string) error {
+func ClickByText(p driver.Page, text string) error {
 	// Try button first
 	el, err := p.Timeout(5*time.Second).ElementR("button", text)
 	if err != nil {
@@ -308,27 +582,27 @@ func ClickByText(p *rod.Page, text string) error {
 	if err := el.WaitVisible(); err != nil {
 		return err
 	}
-	return el.Click("left", 1)
+	return el.Click()
 }
 
 // HasElement checks if an element exists
-func HasElement(p *rod.Page, sel string) bool {
+func HasElement(p driver.Page, sel string) bool {
 	_, err := p.Timeout(2 * time.Second).Element(sel)
 	return err == nil
 }
 
 // HasElementWithText checks if an element with text exists
-func HasElementWithText(p *rod.Page, text string) bool {
+func HasElementWithText(p driver.Page, text string) bool {
 	_, err := p.Timeout(2*time.Second).ElementR("*", text)
 	return err == nil
 }
 
-func ScreenshotOnError(p *rod.Page, prefix string, err error) error {
+// ScreenshotOnError saves a screenshot and the HTML of p with the current
+// run's debug artifacts, labelled with prefix, and returns err.
+func ScreenshotOnError(p driver.Page, prefix string, err error) error {
 	if p == nil || err == nil {
 		return err
 	}
-	path := fmt.Sprintf("%s-%d.png", prefix, time.Now().Unix())
-	bts, _ := p.Screenshot(true, &proto.PageCaptureScreenshot{})
-	_ = os.WriteFile(path, bts, 0644)
+	_ = artifacts.Current().Capture(p, prefix, err)
 	return err
 }
@@ -0,0 +1,182 @@
+package browser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/example/linkedbot/internal/driver"
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+)
+
+// Checkpoint kinds reported in CheckpointError.Kind.
+const (
+	CheckpointVerification    = "checkpoint"
+	CheckpointCaptcha         = "captcha"
+	CheckpointUnusualActivity = "unusual_activity"
+	CheckpointRestricted      = "restricted"
+)
+
+// CheckpointError is the cause of a run aborted by the checkpoint guard.
+type CheckpointError struct {
+	Kind string
+	URL  string
+}
+
+func (e *CheckpointError) Error() string {
+	return fmt.Sprintf("LinkedIn %s detected at %s", strings.ReplaceAll(e.Kind, "_", " "), e.URL)
+}
+
+// Permanent tells the retry package a checkpoint is never worth retrying.
+func (e *CheckpointError) Permanent() bool { return true }
+
+var captchaSelectors = []string{
+	`iframe[src*="captcha"]`,
+	`#captcha-internal`,
+	`[id*="captcha-challenge"]`,
+}
+
+// bannerSelectors are where LinkedIn shows warnings; page text elsewhere
+// (posts, about sections) is not inspected to avoid false alarms.
+var bannerSelectors = []string{
+	`[role="alert"]`,
+	`[role="alertdialog"]`,
+	`.artdeco-global-alert`,
+	`.artdeco-modal`,
+	`main h1`,
+}
+
+// bannerPatterns maps the banner wording, a selector file pattern, to the
+// checkpoint it announces.
+var bannerPatterns = []struct {
+	kind    string
+	pattern string
+}{
+	{CheckpointUnusualActivity, "checkpoint.unusual_activity"},
+	{CheckpointRestricted, "checkpoint.restricted"},
+	{CheckpointVerification, "checkpoint.verification"},
+}
+
+type abortKey struct{}
+
+// Abortable returns a context the checkpoint guard cancels, with a
+// *CheckpointError as cause, when it trips. Pass it to Guard.
+func Abortable(parent context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancelCause(parent)
+	return context.WithValue(ctx, abortKey{}, cancel), func() { cancel(nil) }
+}
+
+// CheckpointCause returns the checkpoint that aborted ctx, or nil.
+func CheckpointCause(ctx context.Context) *CheckpointError {
+	var cp *CheckpointError
+	if errors.As(context.Cause(ctx), &cp) {
+		return cp
+	}
+	return nil
+}
+
+// Guard inspects every page loaded from now on for checkpoints, CAPTCHAs and
+// unusual-activity or restriction banners, and cancels ctx (made with
+// Abortable) on the first one. Login arms it once the session is valid, so
+// the verification steps of login itself don't trip it. Calling it again
+// re-arms it for a new ctx.
+func (b *Browser) Guard(ctx context.Context) {
+	abort, _ := ctx.Value(abortKey{}).(context.CancelCauseFunc)
+	b.guardMu.Lock()
+	b.abort = abort
+	b.guarding = true
+	b.tripped = false
+	b.guardMu.Unlock()
+}
+
+// Disarm stops the guard until the next Guard call.
+func (b *Browser) Disarm() {
+	b.guardMu.Lock()
+	b.guarding = false
+	b.guardMu.Unlock()
+}
+
+// watchLoads runs the checkpoint guard after each navigation of p.
+func (b *Browser) watchLoads(p *rod.Page) {
+	go p.EachEvent(func(*proto.PageLoadEventFired) {
+		go b.inspect(driver.Rod(p))
+	}, func(*proto.PageNavigatedWithinDocument) {
+		go b.inspect(driver.Rod(p))
+	})()
+}
+
+func (b *Browser) inspect(p driver.Page) {
+	b.guardMu.Lock()
+	armed := b.guarding && !b.tripped
+	b.guardMu.Unlock()
+	if !armed {
+		return
+	}
+	cp := b.DetectCheckpoint(p)
+	if cp == nil {
+		return
+	}
+
+	b.guardMu.Lock()
+	if b.tripped || !b.guarding {
+		b.guardMu.Unlock()
+		return
+	}
+	b.tripped = true
+	abort := b.abort
+	b.guardMu.Unlock()
+
+	b.log.Error("checkpoint detected, aborting run", "kind", cp.Kind, "url", cp.URL)
+	ScreenshotOnError(p, "checkpoint", cp)
+	if abort != nil {
+		abort(cp)
+	}
+}
+
+// DetectCheckpoint classifies the page p shows the way the guard does, and
+// returns nil when it looks normal.
+func (b *Browser) DetectCheckpoint(p driver.Page) *CheckpointError {
+	pg := p.Timeout(3 * time.Second)
+	u, err := pg.URL()
+	if err != nil {
+		return nil
+	}
+	if kind := b.checkpointKind(pg, u); kind != "" {
+		return &CheckpointError{Kind: kind, URL: u}
+	}
+	return nil
+}
+
+// checkpointKind classifies the loaded page, or returns "" when it looks
+// normal.
+func (b *Browser) checkpointKind(p driver.Page, url string) string {
+	if strings.Contains(url, "/checkpoint/") {
+		return CheckpointVerification
+	}
+	for _, sel := range captchaSelectors {
+		if els, err := p.Elements(sel); err == nil && len(els) > 0 {
+			return CheckpointCaptcha
+		}
+	}
+	for _, sel := range bannerSelectors {
+		els, err := p.Elements(sel)
+		if err != nil {
+			continue
+		}
+		for _, el := range els {
+			text, err := el.Text()
+			if err != nil {
+				continue
+			}
+			for _, bp := range bannerPatterns {
+				if b.Selectors.Pattern(bp.pattern).MatchString(text) {
+					return bp.kind
+				}
+			}
+		}
+	}
+	return ""
+}
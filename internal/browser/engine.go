@@ -0,0 +1,70 @@
+package browser
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/example/linkedbot/internal/config"
+	"github.com/go-rod/rod/lib/launcher"
+)
+
+// Engine provides the Chrome the bot drives, per browser.engine. It only
+// decides where the DevTools endpoint comes from; the flows drive the pages
+// NewPage hands out through driver.Page, which rod implements.
+type Engine interface {
+	// Name is the browser.engine value, for logs
+	Name() string
+	// ControlURL starts or reaches the browser and returns its DevTools
+	// websocket URL
+	ControlURL(ctx context.Context) (string, error)
+}
+
+// newEngine returns the engine browser.engine names. proxyServer is
+// scheme://host:port without credentials, or "".
+func newEngine(cfg *config.Config, proxyServer string) Engine {
+	switch cfg.Browser.Engine {
+	case config.EngineRemote:
+		return remoteEngine{url: cfg.Browser.RemoteURL}
+	case config.EngineAttach:
+		return attachEngine{port: cfg.Browser.DebugPort}
+	}
+	return localEngine{cfg: cfg, proxyServer: proxyServer}
+}
+
+// localEngine launches Chrome on this machine, downloading it if needed.
+type localEngine struct {
+	cfg         *config.Config
+	proxyServer string
+}
+
+func (e localEngine) Name() string { return config.EngineLocal }
+
+func (e localEngine) ControlURL(ctx context.Context) (string, error) {
+	return newLauncher(e.cfg, e.proxyServer).Context(ctx).Launch()
+}
+
+// remoteEngine connects to a Chrome that runs elsewhere, such as a
+// Browserless container: a ws:// URL is used as given, an http:// one is
+// resolved through the browser's /json/version endpoint.
+type remoteEngine struct {
+	url string
+}
+
+func (e remoteEngine) Name() string { return config.EngineRemote }
+
+func (e remoteEngine) ControlURL(ctx context.Context) (string, error) {
+	u, err := url.Parse(e.url)
+	if err != nil {
+		return "", fmt.Errorf("browser.remote_url: %w", err)
+	}
+	switch u.Scheme {
+	case "ws", "wss":
+		return e.url, nil
+	}
+	ws, err := launcher.ResolveURL(e.url)
+	if err != nil {
+		return "", fmt.Errorf("reach remote browser at %s: %w", u.Host, err)
+	}
+	return ws, nil
+}
@@ -0,0 +1,212 @@
+package browser
+
+import (
+	"encoding/json"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/example/linkedbot/internal/config"
+)
+
+// SessionFingerprint is the device identity presented to LinkedIn. It is
+// persisted per account so a returning user looks like the same machine
+// instead of a new browser on every run.
+type SessionFingerprint struct {
+	UserAgent           string    `json:"user_agent"`
+	Platform            string    `json:"platform"`
+	Width               int       `json:"width"`
+	Height              int       `json:"height"`
+	HardwareConcurrency int       `json:"hardware_concurrency"`
+	DeviceMemory        int       `json:"device_memory"`
+	WebGLVendor         string    `json:"webgl_vendor"`
+	WebGLRenderer       string    `json:"webgl_renderer"`
+	Languages           []string  `json:"languages"`
+	Timezone            string    `json:"timezone,omitempty"`
+	NoiseSeed           int       `json:"noise_seed"`
+	CreatedAt           time.Time `json:"created_at"`
+}
+
+// Latest realistic user agents
+var userAgents = []string{
+	"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/131.0.0.0 Safari/537.36",
+	"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/130.0.0.0 Safari/537.36",
+	"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/131.0.0.0 Safari/537.36",
+	"Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:133.0) Gecko/20100101 Firefox/133.0",
+	"Mozilla/5.0 (Macintosh; Intel Mac OS X 14_1) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.2 Safari/605.1.15",
+}
+
+// webGLByPlatform holds vendor/renderer pairs that real machines of each
+// platform report, so the GPU never contradicts the user agent.
+var webGLByPlatform = map[string][][2]string{
+	"Win32": {
+		{"Google Inc. (Intel)", "ANGLE (Intel, Intel(R) UHD Graphics 620 Direct3D11 vs_5_0 ps_5_0, D3D11)"},
+		{"Google Inc. (NVIDIA)", "ANGLE (NVIDIA, NVIDIA GeForce GTX 1650 Direct3D11 vs_5_0 ps_5_0, D3D11)"},
+		{"Google Inc. (AMD)", "ANGLE (AMD, AMD Radeon(TM) Graphics Direct3D11 vs_5_0 ps_5_0, D3D11)"},
+	},
+	"MacIntel": {
+		{"Google Inc. (Apple)", "ANGLE (Apple, Apple M1, OpenGL 4.1)"},
+		{"Google Inc. (Intel Inc.)", "ANGLE (Intel Inc., Intel(R) Iris(TM) Plus Graphics 655, OpenGL 4.1)"},
+	},
+	"Linux x86_64": {
+		{"Google Inc. (Intel)", "ANGLE (Intel, Mesa Intel(R) UHD Graphics 620 (KBL GT2), OpenGL 4.6)"},
+	},
+}
+
+func fingerprintPath(cfg *config.Config) string {
+	return filepath.Join(".cache", cfg.AccountKey(), "fingerprint.json")
+}
+
+// loadOrCreateFingerprint reuses the stored fingerprint unless it is older
+// than stealth.fingerprint_max_age_days, in which case a new one is generated
+// and saved.
+// A fingerprint saved before a field existed gets that field filled once and
+// written back, so it stays fixed from then on.
+func loadOrCreateFingerprint(cfg *config.Config) (SessionFingerprint, bool, error) {
+	maxAge := time.Duration(cfg.Stealth.FingerprintMaxAgeDays) * 24 * time.Hour
+	if fp, err := readFingerprint(cfg); err == nil && fp.UserAgent != "" {
+		if maxAge <= 0 || time.Since(fp.CreatedAt) < maxAge {
+			if fillFingerprint(&fp, cfg) {
+				if err := saveFingerprint(cfg, fp); err != nil {
+					return applyConfigOverrides(fp, cfg), true, err
+				}
+			}
+			return applyConfigOverrides(fp, cfg), true, nil
+		}
+	}
+	fp := newFingerprint(cfg)
+	if err := saveFingerprint(cfg, fp); err != nil {
+		return applyConfigOverrides(fp, cfg), false, err
+	}
+	return applyConfigOverrides(fp, cfg), false, nil
+}
+
+// LoadFingerprint returns the account's stored fingerprint, creating it on
+// first use, as the browser will apply it.
+func LoadFingerprint(cfg *config.Config) (SessionFingerprint, error) {
+	fp, _, err := loadOrCreateFingerprint(cfg)
+	return fp, err
+}
+
+// RegenerateFingerprint replaces the account's fingerprint with a new one.
+// LinkedIn sees the next run as a new device, so it is best kept for when
+// the old profile is suspected of being flagged.
+func RegenerateFingerprint(cfg *config.Config) (SessionFingerprint, error) {
+	fp := newFingerprint(cfg)
+	if err := saveFingerprint(cfg, fp); err != nil {
+		return SessionFingerprint{}, err
+	}
+	return applyConfigOverrides(fp, cfg), nil
+}
+
+// PinFingerprint stores the fingerprint this browser presents as new, so a
+// session logged in by hand keeps the device it was created on for the
+// next stealth.fingerprint_max_age_days. The user's own Chrome is left
+// alone.
+func (b *Browser) PinFingerprint() error {
+	if b.attached {
+		return nil
+	}
+	fp, err := readFingerprint(b.Cfg)
+	if err != nil || fp.UserAgent == "" {
+		// Saving failed at launch; keep what the page was shown
+		fp = b.fp
+	}
+	fp.CreatedAt = time.Now()
+	return saveFingerprint(b.Cfg, fp)
+}
+
+func readFingerprint(cfg *config.Config) (SessionFingerprint, error) {
+	var fp SessionFingerprint
+	b, err := os.ReadFile(fingerprintPath(cfg))
+	if err != nil {
+		return fp, err
+	}
+	err = json.Unmarshal(b, &fp)
+	return fp, err
+}
+
+func newFingerprint(cfg *config.Config) SessionFingerprint {
+	ua := userAgents[rand.Intn(len(userAgents))]
+	fp := SessionFingerprint{
+		UserAgent: ua,
+		Platform:  platformFor(ua),
+		Width:     randRange(cfg.Stealth.ViewportWidthMin, cfg.Stealth.ViewportWidthMax),
+		Height:    randRange(cfg.Stealth.ViewportHeightMin, cfg.Stealth.ViewportHeightMax),
+		CreatedAt: time.Now(),
+	}
+	fillFingerprint(&fp, cfg)
+	return fp
+}
+
+// fillFingerprint picks a value for every field of fp that has none and
+// reports whether it changed anything.
+func fillFingerprint(fp *SessionFingerprint, cfg *config.Config) bool {
+	changed := false
+	if fp.HardwareConcurrency == 0 {
+		fp.HardwareConcurrency = []int{4, 8, 8, 12, 16}[rand.Intn(5)]
+		changed = true
+	}
+	if fp.DeviceMemory == 0 {
+		fp.DeviceMemory = []int{4, 8, 8, 16}[rand.Intn(4)]
+		changed = true
+	}
+	if fp.WebGLVendor == "" || fp.WebGLRenderer == "" {
+		gpus := webGLByPlatform[fp.Platform]
+		if len(gpus) == 0 {
+			gpus = webGLByPlatform["Win32"]
+		}
+		gpu := gpus[rand.Intn(len(gpus))]
+		fp.WebGLVendor, fp.WebGLRenderer = gpu[0], gpu[1]
+		changed = true
+	}
+	if len(fp.Languages) == 0 {
+		fp.Languages = []string{"en-US", "en"}
+		changed = true
+	}
+	if fp.Timezone == "" && cfg.Stealth.Timezone != "" {
+		fp.Timezone = cfg.Stealth.Timezone
+		changed = true
+	}
+	if fp.NoiseSeed == 0 {
+		fp.NoiseSeed = 1 + rand.Intn(1<<30)
+		changed = true
+	}
+	return changed
+}
+
+// applyConfigOverrides lets an explicit stealth.user_agent or
+// stealth.timezone win over the stored one without rewriting the file. The
+// timezone has to follow the config, as the active window does.
+func applyConfigOverrides(fp SessionFingerprint, cfg *config.Config) SessionFingerprint {
+	if ua := cfg.Stealth.UserAgent; ua != "" {
+		fp.UserAgent = ua
+		fp.Platform = platformFor(ua)
+	}
+	if tz := cfg.Stealth.Timezone; tz != "" {
+		fp.Timezone = tz
+	}
+	return fp
+}
+
+func saveFingerprint(cfg *config.Config, fp SessionFingerprint) error {
+	b, err := json.MarshalIndent(fp, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(fingerprintPath(cfg)), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(fingerprintPath(cfg), b, 0644)
+}
+
+// Extract platform info from UA for consistency
+func platformFor(ua string) string {
+	if contains(ua, "Macintosh") {
+		return "MacIntel"
+	} else if contains(ua, "Linux") {
+		return "Linux x86_64"
+	}
+	return "Win32"
+}
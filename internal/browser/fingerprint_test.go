@@ -0,0 +1,80 @@
+package browser
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/example/linkedbot/internal/config"
+)
+
+func testFingerprintConfig(account string, maxAgeDays int) *config.Config {
+	cfg := &config.Config{Account: account}
+	cfg.Stealth.ViewportWidthMin, cfg.Stealth.ViewportWidthMax = 1280, 1920
+	cfg.Stealth.ViewportHeightMin, cfg.Stealth.ViewportHeightMax = 720, 1080
+	cfg.Stealth.FingerprintMaxAgeDays = maxAgeDays
+	return cfg
+}
+
+func TestFingerprintReusedOnSecondRun(t *testing.T) {
+	t.Chdir(t.TempDir())
+	cfg := testFingerprintConfig("work", 30)
+
+	first, reused, err := loadOrCreateFingerprint(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reused {
+		t.Fatal("first run reused a fingerprint that was never saved")
+	}
+	if _, err := os.Stat(filepath.Join(".cache", "work", "fingerprint.json")); err != nil {
+		t.Fatalf("fingerprint not saved: %v", err)
+	}
+
+	second, reused, err := loadOrCreateFingerprint(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reused {
+		t.Fatal("second run generated a new fingerprint")
+	}
+	if !second.CreatedAt.Equal(first.CreatedAt) {
+		t.Errorf("second run loaded a fingerprint created %s, want %s", second.CreatedAt, first.CreatedAt)
+	}
+	first.CreatedAt, second.CreatedAt = time.Time{}, time.Time{}
+	if !reflect.DeepEqual(first, second) {
+		t.Errorf("second run loaded %+v, want %+v", second, first)
+	}
+
+	// Another account gets its own device
+	if _, reused, err := loadOrCreateFingerprint(testFingerprintConfig("other", 30)); err != nil || reused {
+		t.Errorf("other account: reused %v, err %v; want a new fingerprint", reused, err)
+	}
+}
+
+func TestFingerprintRegeneratedAfterMaxAge(t *testing.T) {
+	t.Chdir(t.TempDir())
+	cfg := testFingerprintConfig("work", 30)
+
+	old := newFingerprint(cfg)
+	old.CreatedAt = time.Now().AddDate(0, 0, -31)
+	if err := saveFingerprint(cfg, old); err != nil {
+		t.Fatal(err)
+	}
+	fp, reused, err := loadOrCreateFingerprint(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reused || !fp.CreatedAt.After(old.CreatedAt) {
+		t.Errorf("fingerprint from %s was reused past stealth.fingerprint_max_age_days", old.CreatedAt)
+	}
+	stored, err := readFingerprint(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !stored.CreatedAt.Equal(fp.CreatedAt) {
+		t.Errorf("regenerated fingerprint not saved: stored %s, want %s", stored.CreatedAt, fp.CreatedAt)
+	}
+}
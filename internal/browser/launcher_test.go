@@ -0,0 +1,44 @@
+package browser
+
+import (
+	"testing"
+
+	"github.com/example/linkedbot/internal/config"
+	"github.com/go-rod/rod/lib/launcher/flags"
+)
+
+func TestNewLauncherBackground(t *testing.T) {
+	cases := []struct {
+		name                 string
+		background, headless bool
+		offScreen            bool
+	}{
+		{"background window", true, false, true},
+		{"foreground window", false, false, false},
+		// Headless has no window to move
+		{"background headless", true, true, false},
+		{"headless", false, true, false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			cfg := &config.Config{}
+			cfg.Browser.Background = c.background
+			cfg.Stealth.Headless = c.headless
+			l := newLauncher(cfg, "")
+
+			if got := l.Has(flags.Flag("start-minimized")); got != c.offScreen {
+				t.Errorf("start-minimized set %v, want %v", got, c.offScreen)
+			}
+			pos, ok := l.GetFlags(flags.Flag("window-position"))
+			if ok != c.offScreen {
+				t.Fatalf("window-position set %v, want %v", ok, c.offScreen)
+			}
+			if c.offScreen && (len(pos) != 1 || pos[0] != "-32000,-32000") {
+				t.Errorf("window-position = %q, want -32000,-32000", pos)
+			}
+			if got := l.Has(flags.Headless); got != c.headless {
+				t.Errorf("headless set %v, want %v", got, c.headless)
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package browser
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/example/linkedbot/internal/config"
+	"github.com/example/linkedbot/internal/logging"
+	"github.com/example/linkedbot/internal/rodtest"
+	"github.com/go-rod/rod/lib/proto"
+)
+
+// newTestBrowser wraps a headless Chrome in a Browser with maxOpen as
+// browser.max_open_pages.
+func newTestBrowser(t *testing.T, maxOpen int) *Browser {
+	t.Helper()
+	cfg := &config.Config{}
+	cfg.Browser.MaxOpenPages = maxOpen
+	cfg.Logging.Level = "error"
+	return &Browser{Rod: rodtest.Browser(t), Cfg: cfg, log: logging.New("error"), live: map[proto.TargetTargetID]bool{}}
+}
+
+func TestNoPageLeakAcrossIterations(t *testing.T) {
+	b := newTestBrowser(t, 5)
+	ctx := context.Background()
+	before, err := b.Rod.Pages()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const n = 20
+	for i := 0; i < n; i++ {
+		// Every other iteration fails half way, as the flows do when an
+		// element is missing
+		err := func() error {
+			p, err := b.NewPage(ctx)
+			if err != nil {
+				return err
+			}
+			defer b.ClosePage(p)
+			if i%2 == 1 {
+				return errors.New("element not found")
+			}
+			_, err = p.Eval(`() => document.body.innerHTML = '<p>ok</p>'`)
+			return err
+		}()
+		if err != nil && err.Error() != "element not found" {
+			t.Fatal(err)
+		}
+	}
+
+	if opened, closed, live := b.PageStats(); opened != n || closed != n || live != 0 {
+		t.Errorf("PageStats = %d opened, %d closed, %d live; want %d, %d, 0", opened, closed, live, n, n)
+	}
+	after, err := b.Rod.Pages()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(after) != len(before) {
+		t.Errorf("%d tabs open after %d iterations, %d before", len(after), n, len(before))
+	}
+}
+
+func TestWatchPagesRecyclesUntrackedPages(t *testing.T) {
+	b := newTestBrowser(t, 3)
+	ctx := context.Background()
+	kept, err := b.NewPage(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.ClosePage(kept)
+	// Tabs opened behind the Browser's back, e.g. by a leaked helper
+	for i := 0; i < 3; i++ {
+		if _, err := b.Rod.Page(proto.TargetCreateTarget{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	p, err := b.NewPage(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.ClosePage(p)
+
+	pages, err := b.Rod.Pages()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, pg := range pages {
+		if !b.live[pg.TargetID] {
+			t.Errorf("untracked page %s survived the watchdog", pg.TargetID)
+		}
+	}
+	if _, _, live := b.PageStats(); live != 2 {
+		t.Errorf("live = %d, want the 2 tracked pages", live)
+	}
+}
@@ -0,0 +1,87 @@
+package browser
+
+import (
+	"math/rand"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/example/linkedbot/internal/config"
+	"github.com/go-rod/rod/lib/proto"
+)
+
+func proxyPath(cfg *config.Config) string {
+	return filepath.Join(".cache", cfg.AccountKey(), "proxy")
+}
+
+// pickProxy returns the proxy for this session, or "" for a direct
+// connection. browser.proxy wins; otherwise one is drawn from
+// browser.proxy_pool. With sticky rotation the account keeps the proxy stored
+// under .cache as long as it is still in the pool, so LinkedIn keeps seeing
+// the same IP for the same login.
+func pickProxy(cfg *config.Config) (string, error) {
+	if cfg.Browser.Proxy != "" {
+		return cfg.Browser.Proxy, nil
+	}
+	pool := cfg.Browser.ProxyPool
+	if len(pool) == 0 {
+		return "", nil
+	}
+	if cfg.Browser.ProxyRotation != "sticky" {
+		return pool[rand.Intn(len(pool))], nil
+	}
+	if b, err := os.ReadFile(proxyPath(cfg)); err == nil {
+		stored := strings.TrimSpace(string(b))
+		for _, p := range pool {
+			if p == stored {
+				return p, nil
+			}
+		}
+	}
+	p := pool[rand.Intn(len(pool))]
+	if err := os.MkdirAll(filepath.Dir(proxyPath(cfg)), 0o755); err != nil {
+		return p, err
+	}
+	// The file may hold credentials
+	return p, os.WriteFile(proxyPath(cfg), []byte(p), 0o600)
+}
+
+// splitProxy separates the credentials from a proxy URL; Chrome's
+// --proxy-server flag only takes scheme://host:port.
+func splitProxy(raw string) (server, user, pass string, err error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", "", "", err
+	}
+	if u.User != nil {
+		user = u.User.Username()
+		pass, _ = u.User.Password()
+	}
+	return u.Scheme + "://" + u.Host, user, pass, nil
+}
+
+// handleProxyAuth answers proxy credential challenges for the whole session.
+// rod's HandleAuth only answers a single challenge, so this keeps the Fetch
+// domain enabled and resumes every paused request itself.
+func (b *Browser) handleProxyAuth(user, pass string) error {
+	if err := (proto.FetchEnable{HandleAuthRequests: true}).Call(b.Rod); err != nil {
+		return err
+	}
+	go b.Rod.EachEvent(
+		func(e *proto.FetchRequestPaused) {
+			_ = proto.FetchContinueRequest{RequestID: e.RequestID}.Call(b.Rod)
+		},
+		func(e *proto.FetchAuthRequired) {
+			_ = proto.FetchContinueWithAuth{
+				RequestID: e.RequestID,
+				AuthChallengeResponse: &proto.FetchAuthChallengeResponse{
+					Response: proto.FetchAuthChallengeResponseResponseProvideCredentials,
+					Username: user,
+					Password: pass,
+				},
+			}.Call(b.Rod)
+		},
+	)()
+	return nil
+}
@@ -0,0 +1,108 @@
+package browser
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/example/linkedbot/internal/driver"
+)
+
+// TabPool is a few tabs that read-only phases (search result pages,
+// acceptance checks) spread their page loads over. The tabs share one pacer:
+// a load on any tab starts no sooner than browser.tab_gap_ms after the
+// previous one, so extra tabs fill the time spent waiting for pages to render
+// instead of raising the request rate.
+type TabPool struct {
+	b     *Browser
+	pages []driver.Page
+	gap   time.Duration
+
+	mu   sync.Mutex
+	next time.Time
+}
+
+// NewTabPool opens n tabs, capped by browser.tabs; at least one. Callers
+// must release them with Close.
+func (b *Browser) NewTabPool(ctx context.Context, n int) (*TabPool, error) {
+	n = max(min(n, b.Cfg.Browser.Tabs), 1)
+	t := &TabPool{b: b, gap: time.Duration(b.Cfg.Browser.TabGapMs) * time.Millisecond}
+	for i := 0; i < n; i++ {
+		p, err := b.NewPage(ctx)
+		if err != nil {
+			t.Close()
+			return nil, err
+		}
+		t.pages = append(t.pages, p)
+	}
+	return t, nil
+}
+
+// Size returns the number of tabs.
+func (t *TabPool) Size() int { return len(t.pages) }
+
+// Close closes every tab of the pool.
+func (t *TabPool) Close() {
+	for _, p := range t.pages {
+		t.b.ClosePage(p)
+	}
+	t.pages = nil
+}
+
+// wait blocks until the pacer lets the next load start, the gap jittered by
+// +-30%.
+func (t *TabPool) wait(ctx context.Context) error {
+	t.mu.Lock()
+	now := time.Now()
+	start := now
+	if t.next.After(now) {
+		start = t.next
+	}
+	gap := t.gap
+	if gap > 0 {
+		gap += time.Duration((rand.Float64()*0.6 - 0.3) * float64(gap))
+	}
+	t.next = start.Add(gap)
+	t.mu.Unlock()
+
+	timer := time.NewTimer(start.Sub(now))
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// Map calls fn for each item, one item per tab at a time, and returns the
+// results and errors in item order. Items not started by the time ctx is done
+// get its error. fn runs on several goroutines, so it should only read the
+// page and leave store writes to the caller.
+func Map[In, Out any](ctx context.Context, t *TabPool, items []In, fn func(ctx context.Context, p driver.Page, item In) (Out, error)) ([]Out, []error) {
+	out := make([]Out, len(items))
+	errs := make([]error, len(items))
+	todo := make(chan int, len(items))
+	for i := range items {
+		todo <- i
+	}
+	close(todo)
+
+	var wg sync.WaitGroup
+	for _, p := range t.pages {
+		wg.Add(1)
+		go func(p driver.Page) {
+			defer wg.Done()
+			for i := range todo {
+				if err := t.wait(ctx); err != nil {
+					errs[i] = err
+					continue
+				}
+				out[i], errs[i] = fn(ctx, p, items[i])
+			}
+		}(p)
+	}
+	wg.Wait()
+	return out, errs
+}
@@ -0,0 +1,25 @@
+package browser
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+
+	"github.com/example/linkedbot/internal/driver"
+)
+
+// ErrProfileUnavailable is returned for a profile LinkedIn no longer shows:
+// the member deleted or hid it, or the link never existed.
+var ErrProfileUnavailable = errors.New("profile is not available")
+
+// ProfileUnavailable reports whether the loaded page p is LinkedIn's "This
+// profile is not available" or 404 page instead of a profile. It doesn't
+// wait, so call it once the page has loaded.
+func (b *Browser) ProfileUnavailable(p driver.Page) bool {
+	if raw, err := p.URL(); err == nil {
+		if u, err := url.Parse(raw); err == nil && (strings.HasPrefix(u.Path, "/in/unavailable") || strings.HasPrefix(u.Path, "/404")) {
+			return true
+		}
+	}
+	return len(b.Selectors.Get("profile.unavailable").All(p)) > 0
+}
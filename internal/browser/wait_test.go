@@ -0,0 +1,69 @@
+package browser
+
+import (
+	"testing"
+	"time"
+
+	"github.com/example/linkedbot/internal/rodtest"
+)
+
+// slowComposer mimics a composer that renders late: the box shows up after
+// 300ms, Send unlocks once text is typed, and sending clears the box a
+// moment later.
+const slowComposer = `<html><body>
+<div class="msg-form__contenteditable" contenteditable="true" style="display:none"></div>
+<button class="send" disabled>Send</button>
+<button class="never" aria-disabled="true">Never</button>
+<script>
+const box = document.querySelector('.msg-form__contenteditable');
+const send = document.querySelector('.send');
+setTimeout(() => { box.style.display = 'block'; }, 300);
+box.addEventListener('input', () => setTimeout(() => { send.disabled = false; }, 300));
+send.addEventListener('click', () => setTimeout(() => { box.innerText = ''; }, 300));
+</script>
+</body></html>`
+
+func TestSendSequenceWaits(t *testing.T) {
+	p := rodtest.Page(t, rodtest.Browser(t), slowComposer)
+	const d = 5 * time.Second
+
+	box, err := p.Element(".msg-form__contenteditable")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := WaitFocusable(box, d); err != nil {
+		t.Fatalf("compose box never became focusable: %v", err)
+	}
+	if err := box.Input("hello"); err != nil {
+		t.Fatal(err)
+	}
+
+	send, err := p.Element(".send")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := WaitEnabled(send, d); err != nil {
+		t.Fatalf("send button never enabled: %v", err)
+	}
+	if err := send.Click(); err != nil {
+		t.Fatal(err)
+	}
+	if err := WaitEmpty(box, d); err != nil {
+		t.Fatalf("compose box never cleared after sending: %v", err)
+	}
+}
+
+func TestWaitEnabledTimesOut(t *testing.T) {
+	p := rodtest.Page(t, rodtest.Browser(t), slowComposer)
+	never, err := p.Element(".never")
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	if err := WaitEnabled(never, 500*time.Millisecond); err == nil {
+		t.Fatal("aria-disabled button reported enabled")
+	}
+	if took := time.Since(start); took > 3*time.Second {
+		t.Errorf("timeout of 500ms took %s", took)
+	}
+}
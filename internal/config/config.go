@@ -3,59 +3,779 @@ package config
 import (
 	"errors"
 	"fmt"
+	"net"
+	"net/mail"
+	"net/url"
 	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
 
+	"github.com/example/linkedbot/internal/models"
+	"github.com/example/linkedbot/internal/secrets"
 	"github.com/joho/godotenv"
+	"github.com/robfig/cron/v3"
 	"gopkg.in/yaml.v3"
 )
 
 type Config struct {
 	LinkedIn struct {
 		BaseURL string `yaml:"base_url"`
+		// Locale is the language of the account's LinkedIn UI: en, de, fr
+		// or hi. Other languages add the translated button and dialog texts
+		// to the selectors
+		Locale string `yaml:"locale"`
 	} `yaml:"linkedin"`
-	Search struct {
+	// Auth names the env vars holding the credentials and where the session
+	// cookies live. --account replaces it with the account's settings.
+	Auth struct {
+		// Mode is password to log in with the credentials when the saved
+		// session has expired, or cookies to only ever use the session
+		// captured by login --manual. Credentials are checked when a login
+		// is needed, not on load
+		Mode        string `yaml:"mode"`
+		EmailEnv    string `yaml:"email_env"`
+		PasswordEnv string `yaml:"password_env"`
+		CookiePath  string `yaml:"cookie_path"`
+		// TOTPSecretEnv names the env var with the authenticator secret;
+		// other verification codes are read from stdin.
+		TOTPSecretEnv       string `yaml:"totp_secret_env"`
+		ChallengeTimeoutSec int    `yaml:"challenge_timeout_sec"`
+	} `yaml:"auth"`
+	Accounts map[string]Account `yaml:"accounts"`
+	// Account is the name selected with --account, "" for the default.
+	Account string `yaml:"-"`
+	// secrets are the contents of the secrets file
+	secrets map[string]string
+	Search  struct {
 		Defaults struct {
 			Title    string `yaml:"title"`
 			Company  string `yaml:"company"`
 			Location string `yaml:"location"`
 			Keywords string `yaml:"keywords"`
+			// Source searches a group's members ("group:<id>") or an
+			// event's attendees ("event:<id>") instead of the keywords
+			Source string `yaml:"source"`
 		} `yaml:"defaults"`
+		// Backend is "dom" (scrape the results pages) or "voyager" (call
+		// LinkedIn's internal search API with the session cookies)
+		Backend string `yaml:"backend"`
+		Voyager struct {
+			QueryID  string `yaml:"query_id"`
+			PageSize int    `yaml:"page_size"`
+		} `yaml:"voyager"`
 	} `yaml:"search"`
 	Limits struct {
-		MaxConnectionsPerDay int `yaml:"max_connections_per_day"`
-		MaxMessagesPerDay    int `yaml:"max_messages_per_day"`
-		MaxProfilesPerSearch int `yaml:"max_profiles_per_search"`
+		MaxConnectionsPerDay  int  `yaml:"max_connections_per_day"`
+		MaxMessagesPerDay     int  `yaml:"max_messages_per_day"`
+		MaxProfilesPerSearch  int  `yaml:"max_profiles_per_search"`
+		MaxConnectionsPerHour int  `yaml:"max_connections_per_hour"`
+		MaxConnectionsPerWeek int  `yaml:"max_connections_per_week"`
+		MaxMessagesPerHour    int  `yaml:"max_messages_per_hour"`
+		MaxMessagesPerWeek    int  `yaml:"max_messages_per_week"`
+		Pacing                bool `yaml:"pacing"`
+		// Schedule plans each day's invites and messages as bursts at random
+		// times in the active window; it replaces pacing for them
+		Schedule struct {
+			Enabled  bool `yaml:"enabled"`
+			MaxBurst int  `yaml:"max_burst"`
+		} `yaml:"schedule"`
+		WarmUp struct {
+			Enabled bool       `yaml:"enabled"`
+			Ramp    []RampStep `yaml:"ramp"`
+		} `yaml:"warm_up"`
 	} `yaml:"limits"`
 	Stealth struct {
-		Headless           bool   `yaml:"headless"`
-		EnableHumanMouse   bool   `yaml:"enable_human_mouse"`
-		EnableRandomScroll bool   `yaml:"enable_random_scroll"`
-		EnableTypeTypos    bool   `yaml:"enable_type_typos"`
-		EnableHoverWander  bool   `yaml:"enable_hover_wander"`
-		EnableBreaks       bool   `yaml:"enable_breaks"`
-		UserAgent          string `yaml:"user_agent"`
-		MinDelayMs         int    `yaml:"min_delay_ms"`
-		MaxDelayMs         int    `yaml:"max_delay_ms"`
-		ViewportWidthMin   int    `yaml:"viewport_width_min"`
-		ViewportWidthMax   int    `yaml:"viewport_width_max"`
-		ViewportHeightMin  int    `yaml:"viewport_height_min"`
-		ViewportHeightMax  int    `yaml:"viewport_height_max"`
-		ActiveStart        string `yaml:"active_start"`
-		ActiveEnd          string `yaml:"active_end"`
+		Headless           bool `yaml:"headless"`
+		EnableHumanMouse   bool `yaml:"enable_human_mouse"`
+		EnableRandomScroll bool `yaml:"enable_random_scroll"`
+		EnableTypeTypos    bool `yaml:"enable_type_typos"`
+		EnableHoverWander  bool `yaml:"enable_hover_wander"`
+		EnableBreaks       bool `yaml:"enable_breaks"`
+		// TypoRate is the chance per typed character of a typo that is
+		// then corrected, with enable_type_typos
+		TypoRate float64 `yaml:"typo_rate"`
+		// BreakProbability is the chance of a short break at each break
+		// point, with enable_breaks
+		BreakProbability float64 `yaml:"break_probability"`
+		// ScrollIntensity scales how many scrolls a page gets and how far
+		// each goes, with enable_random_scroll
+		ScrollIntensity float64 `yaml:"scroll_intensity"`
+		// TypingMode is how text is typed: "input" inserts each character,
+		// "keys" presses real keys (keydown/keyup with key codes, Shift for
+		// capitals and symbols, modeled key hold and gap times)
+		TypingMode string `yaml:"typing_mode"`
+		// ReadingWPM is the reading speed a profile's dwell time is
+		// estimated from, kept between ReadMinSeconds and ReadMaxSeconds
+		ReadingWPM            int    `yaml:"reading_wpm"`
+		ReadMinSeconds        int    `yaml:"read_min_seconds"`
+		ReadMaxSeconds        int    `yaml:"read_max_seconds"`
+		UserAgent             string `yaml:"user_agent"`
+		FingerprintMaxAgeDays int    `yaml:"fingerprint_max_age_days"`
+		MinDelayMs            int    `yaml:"min_delay_ms"`
+		MaxDelayMs            int    `yaml:"max_delay_ms"`
+		ViewportWidthMin      int    `yaml:"viewport_width_min"`
+		ViewportWidthMax      int    `yaml:"viewport_width_max"`
+		ViewportHeightMin     int    `yaml:"viewport_height_min"`
+		ViewportHeightMax     int    `yaml:"viewport_height_max"`
+		ActiveStart           string `yaml:"active_start"`
+		ActiveEnd             string `yaml:"active_end"`
+		// ActiveDays overrides the window per weekday (mon..sun) with
+		// "HH:MM-HH:MM", or "off" for no activity that day
+		ActiveDays   map[string]string `yaml:"active_days"`
+		SkipWeekends bool              `yaml:"skip_weekends"`
+		// Holidays are days off, as a date (2026-12-24) or every year (12-25)
+		Holidays []string `yaml:"holidays"`
+		// Timezone is the IANA zone (Europe/Berlin) the account owner lives
+		// in: the active window, day plans and daemon schedules follow it and
+		// the browser reports it. Empty uses the machine's zone.
+		Timezone string `yaml:"timezone"`
 	} `yaml:"stealth"`
+	Browser struct {
+		MaxOpenPages  int      `yaml:"max_open_pages"`
+		Background    bool     `yaml:"background"`
+		Proxy         string   `yaml:"proxy"`
+		ProxyPool     []string `yaml:"proxy_pool"`
+		ProxyRotation string   `yaml:"proxy_rotation"`
+		// PersistentProfile keeps a Chrome user-data-dir per account so
+		// localStorage, service workers and device trust survive restarts.
+		PersistentProfile bool   `yaml:"persistent_profile"`
+		ProfileDir        string `yaml:"profile_dir"`
+		// Tabs is how many tabs search result pages and acceptance checks
+		// are spread over; their page loads are spaced by TabGapMs in total
+		Tabs     int `yaml:"tabs"`
+		TabGapMs int `yaml:"tab_gap_ms"`
+		// Engine is local (launch Chrome here), remote (connect to the
+		// Chrome or Browserless instance at RemoteURL over CDP) or attach
+		// (drive the user's own Chrome, started with
+		// --remote-debugging-port=DebugPort, and its session)
+		Engine    string `yaml:"engine"`
+		RemoteURL string `yaml:"remote_url"`
+		DebugPort int    `yaml:"debug_port"`
+	} `yaml:"browser"`
+	Timeouts struct {
+		ComposeReadyMs int `yaml:"compose_ready_ms"`
+		SendEnabledMs  int `yaml:"send_enabled_ms"`
+		SendConfirmMs  int `yaml:"send_confirm_ms"`
+		SendRetries    int `yaml:"send_retries"`
+	} `yaml:"timeouts"`
+	// Retry is the backoff for navigations and element lookups that fail
+	// while LinkedIn is slow or still rendering.
+	Retry struct {
+		Attempts       int     `yaml:"attempts"`
+		InitialDelayMs int     `yaml:"initial_delay_ms"`
+		MaxDelayMs     int     `yaml:"max_delay_ms"`
+		Multiplier     float64 `yaml:"multiplier"`
+	} `yaml:"retry"`
+	// Selectors overrides the built-in page selectors without a rebuild:
+	// URL is fetched on every browser start (and cached for when it's
+	// unreachable), File is applied last. With SelfHeal, elements whose
+	// selectors all fail are found by heuristics and the selectors derived
+	// from them are kept in LearnedPath.
+	Selectors struct {
+		File        string `yaml:"file"`
+		URL         string `yaml:"url"`
+		CachePath   string `yaml:"cache_path"`
+		SelfHeal    bool   `yaml:"self_heal"`
+		LearnedPath string `yaml:"learned_path"`
+	} `yaml:"selectors"`
+	Connection struct {
+		QueueStrategy          string  `yaml:"queue_strategy"`
+		AutoPauseBadSources    bool    `yaml:"auto_pause_bad_sources"`
+		BadSourceMinSample     int     `yaml:"bad_source_min_sample"`
+		BadSourceMinAcceptRate float64 `yaml:"bad_source_min_accept_rate"`
+		WithdrawAfterDays      int     `yaml:"withdraw_after_days"`
+		// WithdrawExpired has withdraw-connections withdraw expired
+		// invites whatever their age
+		WithdrawExpired bool `yaml:"withdraw_expired"`
+		// WarmViewDays holds invites until the profile was visited by
+		// warm-view at least this many days earlier; 0 disables
+		WarmViewDays int `yaml:"warm_view_days"`
+		// Degrees limits invites to these distances ("2nd", "3rd"); empty
+		// invites any
+		Degrees []string `yaml:"degrees"`
+		// Tags limits invites (and warm-view) to profiles carrying any of
+		// these tags; empty invites any
+		Tags []string `yaml:"tags"`
+		// RequireApproval stages each invite's note for `review` instead of
+		// sending it; approved notes are sent by the next run as approved
+		RequireApproval bool `yaml:"require_approval"`
+		// LimitCooldownHours holds invites this long after LinkedIn showed
+		// its weekly invitation limit; other actions go on
+		LimitCooldownHours int `yaml:"limit_cooldown_hours"`
+		// EmailLookup finds the address LinkedIn asks for on some invites
+		EmailLookup struct {
+			// Provider is "" (off), "hunter" or "http"
+			Provider   string `yaml:"provider"`
+			APIKeyEnv  string `yaml:"api_key_env"`
+			URL        string `yaml:"url"`
+			TimeoutSec int    `yaml:"timeout_sec"`
+		} `yaml:"email_lookup"`
+	} `yaml:"connection"`
+	Messaging struct {
+		AcceptanceSignal string `yaml:"acceptance_signal"`
+		// AcceptanceDetection is "profile" (visit each pending invite) or
+		// "network" (scan the My Network lists)
+		AcceptanceDetection string         `yaml:"acceptance_detection"`
+		DeepLinkFallback    bool           `yaml:"deep_link_fallback"`
+		DetectReplies       bool           `yaml:"detect_replies"`
+		Sequence            []SequenceStep `yaml:"sequence"`
+		// InviteExpiryDays moves invites unanswered this long to expired
+		// and stops checking them for acceptance; 0 checks them forever
+		InviteExpiryDays int `yaml:"invite_expiry_days"`
+		// The first follow-up waits at least FirstFollowUpMinHours after
+		// acceptance plus up to FirstFollowUpJitterHours, fixed per profile
+		FirstFollowUpMinHours    int `yaml:"first_follow_up_min_hours"`
+		FirstFollowUpJitterHours int `yaml:"first_follow_up_jitter_hours"`
+		// Tags limits follow-ups to profiles carrying any of these tags;
+		// empty messages any
+		Tags []string `yaml:"tags"`
+		// ReplyClassifier labels detected replies: "rules" matches
+		// ReplyRules in order, "llm" asks the llm model and falls back to
+		// the rules
+		ReplyClassifier string      `yaml:"reply_classifier"`
+		ReplyRules      []ReplyRule `yaml:"reply_rules"`
+	} `yaml:"messaging"`
+	Extraction struct {
+		ExpandSeeMore bool `yaml:"expand_see_more"`
+	} `yaml:"extraction"`
+	Daemon struct {
+		SearchCron    string `yaml:"search_cron"`
+		ConnectCron   string `yaml:"connect_cron"`
+		MessageCron   string `yaml:"message_cron"`
+		NurtureCron   string `yaml:"nurture_cron"`
+		WorkerCron    string `yaml:"worker_cron"`
+		KeepAliveCron string `yaml:"keep_alive_cron"`
+		DashboardAddr string `yaml:"dashboard_addr"`
+		// EventsTokenEnv names the variable holding the bearer token the
+		// dashboard's page, buttons and /api endpoints require; unset
+		// leaves them open
+		EventsTokenEnv string `yaml:"events_token_env"`
+		// ConfigReloadSec is how often the config file is checked for
+		// changes to reload; 0 only reloads on SIGHUP
+		ConfigReloadSec int `yaml:"config_reload_sec"`
+	} `yaml:"daemon"`
+	// Jobs tunes the persistent queue send-connections and send-messages
+	// run on
+	Jobs struct {
+		MaxAttempts int `yaml:"max_attempts"`
+		// BackoffMinutes is the wait before the first retry, doubled after
+		// every further failure
+		BackoffMinutes int `yaml:"backoff_minutes"`
+		// StaleMinutes after which a job still marked running is taken
+		// as interrupted and queued again
+		StaleMinutes int `yaml:"stale_minutes"`
+		// MaxRetries caps how often retry-failed puts one failed job back
+		MaxRetries int `yaml:"max_retries"`
+	} `yaml:"jobs"`
+	// KeepAlive is how long a keep-alive visit reads the feed, picked at
+	// random between the two
+	KeepAlive struct {
+		MinMinutes int `yaml:"min_minutes"`
+		MaxMinutes int `yaml:"max_minutes"`
+	} `yaml:"keep_alive"`
+	// Session is the browsing around outreach runs: the chance to start on
+	// the feed, to check the notifications after it and to go back to the
+	// feed between two profiles, and the most scrolls per feed visit
+	Session struct {
+		Enabled                  bool    `yaml:"enabled"`
+		FeedProbability          float64 `yaml:"feed_probability"`
+		NotificationsProbability float64 `yaml:"notifications_probability"`
+		FeedReturnProbability    float64 `yaml:"feed_return_probability"`
+		FeedScrolls              int     `yaml:"feed_scrolls"`
+	} `yaml:"session"`
+	RunAll struct {
+		AcceptanceCheckDelaySec int `yaml:"acceptance_check_delay_sec"`
+	} `yaml:"run_all"`
 	Templates struct {
-		ConnectionNote string `yaml:"connection_note_template"`
-		FollowUp       string `yaml:"follow_up_message_template"`
+		ConnectionNote string     `yaml:"connection_note_template"`
+		FollowUp       string     `yaml:"follow_up_message_template"`
+		TitleCleanup   string     `yaml:"title_cleanup"`
+		Campaigns      []Campaign `yaml:"campaigns"`
 	} `yaml:"templates"`
+	// Engage warms prospects up before the invite by liking, and optionally
+	// commenting on, one of their recent posts.
+	Engage struct {
+		MaxPerDay      int `yaml:"max_per_day"`
+		MaxPostAgeDays int `yaml:"max_post_age_days"`
+		// CommentTemplate is rendered like the connection note; empty only
+		// likes
+		CommentTemplate string `yaml:"comment_template"`
+		// EndorseSkills is how many skills endorse endorses per accepted
+		// connection (0-2); campaigns can override it
+		EndorseSkills int `yaml:"endorse_skills"`
+	} `yaml:"engage"`
+	// Nurture congratulates existing connections on the job changes, work
+	// anniversaries and birthdays announced on the notifications page
+	Nurture struct {
+		MaxPerDay int `yaml:"max_per_day"`
+		// MaxAgeDays drops queued congratulations not sent by then
+		MaxAgeDays int `yaml:"max_age_days"`
+		// Templates per event; an empty one leaves the event alone
+		Templates struct {
+			JobChange   string `yaml:"job_change"`
+			Anniversary string `yaml:"anniversary"`
+			Birthday    string `yaml:"birthday"`
+		} `yaml:"templates"`
+	} `yaml:"nurture"`
+	// Checkpoint is what happens after LinkedIn shows a checkpoint, CAPTCHA
+	// or restriction banner mid-run.
+	Checkpoint struct {
+		CooldownHours int `yaml:"cooldown_hours"`
+	} `yaml:"checkpoint"`
+	Audit struct {
+		Enabled       bool   `yaml:"enabled"`
+		Screenshots   bool   `yaml:"screenshots"`
+		ScreenshotDir string `yaml:"screenshot_dir"`
+	} `yaml:"audit"`
+	// Retention is the policy `purge` applies unless overridden by flags
+	Retention struct {
+		// ProfileDays purges profiles in Statuses not updated for this
+		// many days; 0 keeps them
+		ProfileDays int      `yaml:"profile_days"`
+		Statuses    []string `yaml:"statuses"`
+		// Mode is "delete" or "anonymize" (keep the rows for stats, erase
+		// the personal data)
+		Mode string `yaml:"mode"`
+		// ScreenshotDays deletes audit screenshots older than this; 0
+		// keeps them
+		ScreenshotDays int `yaml:"screenshot_days"`
+	} `yaml:"retention"`
+	// Debug is how long the per-run debug artifacts in .cache/runs are
+	// kept: runs older than KeepDays and beyond the newest MaxRuns are
+	// deleted when a command starts; 0 turns a rule off
+	Debug struct {
+		KeepDays int `yaml:"keep_days"`
+		MaxRuns  int `yaml:"max_runs"`
+	} `yaml:"debug"`
+	// Exclusions are profiles search and send-connections never touch, on top
+	// of those added with `blacklist add`. Name patterns are case-insensitive
+	// regular expressions.
+	Exclusions struct {
+		Companies    []string `yaml:"companies"`
+		NamePatterns []string `yaml:"name_patterns"`
+		ProfileURLs  []string `yaml:"profile_urls"`
+	} `yaml:"exclusions"`
+	Notifications struct {
+		Webhooks   []Webhook `yaml:"webhooks"`
+		TimeoutSec int       `yaml:"timeout_sec"`
+		MaxRetries int       `yaml:"max_retries"`
+		// Email mails the subscribed events as they happen and a digest of
+		// the last 24 hours on DigestCron, over SMTP. Off while Host is empty.
+		// Port 465 uses implicit TLS, other ports STARTTLS when offered
+		Email struct {
+			Host        string   `yaml:"host"`
+			Port        int      `yaml:"port"`
+			Username    string   `yaml:"username"`
+			PasswordEnv string   `yaml:"password_env"`
+			From        string   `yaml:"from"`
+			To          []string `yaml:"to"`
+			Events      []string `yaml:"events"`
+			DigestCron  string   `yaml:"digest_cron"`
+		} `yaml:"email"`
+		// Telegram sends the subscribed events to ChatID through a bot, and
+		// with Commands the daemon answers /pause, /resume, /stats and the
+		// other bot commands from that chat. Off while ChatID is 0
+		Telegram struct {
+			TokenEnv string   `yaml:"token_env"`
+			ChatID   int64    `yaml:"chat_id"`
+			Events   []string `yaml:"events"`
+			Commands bool     `yaml:"commands"`
+		} `yaml:"telegram"`
+	} `yaml:"notifications"`
+	// LLM writes a personal connection note and/or follow-up per profile
+	// from its headline, about section and latest post, through an
+	// OpenAI-compatible (chat/completions) or Anthropic (messages) API. The
+	// configured template's text is the fallback whenever generation fails
+	// or a daily cap is hit. Off while Provider is empty
+	LLM struct {
+		// Provider is "" (off), "openai" or "anthropic"; BaseURL points
+		// either at a compatible server
+		Provider  string `yaml:"provider"`
+		BaseURL   string `yaml:"base_url"`
+		Model     string `yaml:"model"`
+		APIKeyEnv string `yaml:"api_key_env"`
+		// Notes and FollowUps choose what is generated
+		Notes     bool `yaml:"notes"`
+		FollowUps bool `yaml:"follow_ups"`
+		// The prompts are templates over the profile (the template fields
+		// plus .About, .Post, .Template and .MaxChars)
+		SystemPrompt   string `yaml:"system_prompt"`
+		NotePrompt     string `yaml:"note_prompt"`
+		FollowUpPrompt string `yaml:"follow_up_prompt"`
+		// ClassifyPrompt labels replies with messaging.reply_classifier
+		// llm; it also sees .Reply
+		ClassifyPrompt string `yaml:"classify_prompt"`
+		// MaxMessageChars bounds generated follow-ups; notes are held to
+		// the 280 characters templated notes are cut to
+		MaxMessageChars int     `yaml:"max_message_chars"`
+		MaxOutputTokens int     `yaml:"max_output_tokens"`
+		Temperature     float64 `yaml:"temperature"`
+		TimeoutSec      int     `yaml:"timeout_sec"`
+		// DailyTokenCap and DailyCostCap stop generating for the day once
+		// reached, 0 = uncapped. Cost is priced per million tokens
+		DailyTokenCap     int     `yaml:"daily_token_cap"`
+		DailyCostCap      float64 `yaml:"daily_cost_cap"`
+		InputCostPerMTok  float64 `yaml:"input_cost_per_mtok"`
+		OutputCostPerMTok float64 `yaml:"output_cost_per_mtok"`
+	} `yaml:"llm"`
+	// CRM pushes profiles in Statuses to HubSpot or Pipedrive as contacts
+	// (persons), updated whenever their status changes, and opens a deal
+	// once a reply is labelled with one of DealOn. Off while Provider is
+	// empty
+	CRM struct {
+		// Provider is "" (off), "hubspot" or "pipedrive"
+		Provider  string `yaml:"provider"`
+		APIKeyEnv string `yaml:"api_key_env"`
+		// BaseURL overrides the provider's API root, e.g. a Pipedrive
+		// company domain
+		BaseURL  string   `yaml:"base_url"`
+		Statuses []string `yaml:"statuses"`
+		// Fields maps CRM fields (HubSpot property names, Pipedrive field
+		// keys) to templates over the profile: the template fields plus
+		// .LastName, .URL, .Status, .ReplyLabel and .Reply. Empty uses a
+		// built-in mapping for the provider
+		Fields map[string]string `yaml:"fields"`
+		DealOn []string          `yaml:"deal_on"`
+		// DealName is a template like Fields. Pipeline and Stage place new
+		// deals (HubSpot pipeline/dealstage ids, Pipedrive pipeline_id and
+		// stage_id); empty uses the provider's default
+		DealName   string `yaml:"deal_name"`
+		Pipeline   string `yaml:"pipeline"`
+		Stage      string `yaml:"stage"`
+		SyncCron   string `yaml:"sync_cron"`
+		TimeoutSec int    `yaml:"timeout_sec"`
+	} `yaml:"crm"`
+	// Sheets mirrors profiles to a Google sheet for people without access
+	// to the bot, and reads back the "Do not contact" and "Note" columns
+	// they edit. Off while SpreadsheetID is empty
+	Sheets struct {
+		// CredentialsFile is a service account key (JSON). The spreadsheet
+		// must be shared with the account's client_email as an editor
+		CredentialsFile string `yaml:"credentials_file"`
+		SpreadsheetID   string `yaml:"spreadsheet_id"`
+		// Sheet is the tab written to; it must exist
+		Sheet string `yaml:"sheet"`
+		// Statuses limits the rows; empty writes every profile
+		Statuses   []string `yaml:"statuses"`
+		SyncCron   string   `yaml:"sync_cron"`
+		TimeoutSec int      `yaml:"timeout_sec"`
+	} `yaml:"sheets"`
+	// Secrets keeps passwords, tokens and API keys in a file encrypted with
+	// the passphrase in PassphraseEnv, each under the name of the env var
+	// it stands in for. A set env var still wins. Read only while the
+	// passphrase is set
+	Secrets struct {
+		Path          string `yaml:"path"`
+		PassphraseEnv string `yaml:"passphrase_env"`
+		// EncryptCookies keeps auth.cookie_path encrypted with the same
+		// passphrase
+		EncryptCookies bool `yaml:"encrypt_cookies"`
+	} `yaml:"secrets"`
 	Database struct {
-		Path string `yaml:"path"`
+		Driver string `yaml:"driver"` // sqlite or postgres
+		Path   string `yaml:"path"`   // sqlite file
+		DSN    string `yaml:"dsn"`    // postgres connection URL
 	} `yaml:"database"`
 	Logging struct {
 		Level string `yaml:"level"`
+		// Console is the stdout format: json, pretty or off
+		Console string `yaml:"console"`
+		// Modules overrides Level per module, e.g. search: debug
+		Modules map[string]string `yaml:"modules"`
+		// File is an optional JSON-lines log file, rotated by size and/or
+		// daily, keeping MaxBackups old files (0 keeps all)
+		File struct {
+			Path        string `yaml:"path"`
+			MaxSizeMB   int    `yaml:"max_size_mb"`
+			RotateDaily bool   `yaml:"rotate_daily"`
+			MaxBackups  int    `yaml:"max_backups"`
+		} `yaml:"file"`
 	} `yaml:"logging"`
+	// Tracing exports OpenTelemetry spans over OTLP/HTTP: one per job with
+	// the navigation, extraction, clicks and typing for the profile nested
+	// under it. An empty Endpoint turns it off.
+	Tracing struct {
+		// Endpoint is the collector's traces URL, e.g.
+		// http://localhost:4318/v1/traces
+		Endpoint    string            `yaml:"endpoint"`
+		Headers     map[string]string `yaml:"headers"`
+		ServiceName string            `yaml:"service_name"`
+		// SampleRatio is the share of jobs traced, 0-1
+		SampleRatio float64 `yaml:"sample_ratio"`
+	} `yaml:"tracing"`
+
+	// loc is stealth.timezone, loaded by validate
+	loc *time.Location
+}
+
+// Account overrides the global settings for one LinkedIn account. Empty
+// fields and zero limits keep the global value, except db_path and
+// cookie_path which default to per-account files so accounts never share
+// state by accident. A shared postgres database has no such default, so
+// db_dsn is required there.
+type Account struct {
+	AuthMode    string `yaml:"auth_mode"`
+	EmailEnv    string `yaml:"email_env"`
+	PasswordEnv string `yaml:"password_env"`
+	CookiePath  string `yaml:"cookie_path"`
+	TOTPEnv     string `yaml:"totp_secret_env"`
+	DBPath      string `yaml:"db_path"`
+	DBDSN       string `yaml:"db_dsn"`
+	Proxy       string `yaml:"proxy"`
+	Timezone    string `yaml:"timezone"`
+	Locale      string `yaml:"locale"`
+	Limits      struct {
+		MaxConnectionsPerDay  int `yaml:"max_connections_per_day"`
+		MaxMessagesPerDay     int `yaml:"max_messages_per_day"`
+		MaxProfilesPerSearch  int `yaml:"max_profiles_per_search"`
+		MaxConnectionsPerHour int `yaml:"max_connections_per_hour"`
+		MaxConnectionsPerWeek int `yaml:"max_connections_per_week"`
+		MaxMessagesPerHour    int `yaml:"max_messages_per_hour"`
+		MaxMessagesPerWeek    int `yaml:"max_messages_per_week"`
+	} `yaml:"limits"`
+}
+
+// SequenceStep is one follow-up in messaging.sequence. DelayDays counts from
+// acceptance for the first step and from the previous step after that.
+type SequenceStep struct {
+	DelayDays int    `yaml:"delay_days"`
+	Template  string `yaml:"template"`
+}
+
+// RampStep caps the daily limits during week Week of a new account's
+// warm-up, counting from 1.
+type RampStep struct {
+	Week                 int `yaml:"week"`
+	MaxConnectionsPerDay int `yaml:"max_connections_per_day"`
+	MaxMessagesPerDay    int `yaml:"max_messages_per_day"`
+}
+
+// weekdayKeys are the stealth.active_days keys, indexed by time.Weekday.
+var weekdayKeys = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// Location returns stealth.timezone, or the machine's zone when it is empty.
+func (c *Config) Location() *time.Location {
+	if c.loc == nil {
+		return time.Local
+	}
+	return c.loc
+}
+
+// ActiveWindow returns the stealth active window on t's day in
+// stealth.timezone. ok is false on a day off: a holiday, a weekend with
+// skip_weekends, or a weekday whose active_days entry is "off".
+func (c *Config) ActiveWindow(t time.Time) (start, end time.Time, ok bool) {
+	t = t.In(c.Location())
+	if c.isHoliday(t) {
+		return start, end, false
+	}
+	wd := t.Weekday()
+	if c.Stealth.SkipWeekends && (wd == time.Saturday || wd == time.Sunday) {
+		return start, end, false
+	}
+	from, to, err := parseWindow(c.Stealth.ActiveStart + "-" + c.Stealth.ActiveEnd)
+	if w, set := c.Stealth.ActiveDays[weekdayKeys[wd]]; set {
+		if w == "off" {
+			return start, end, false
+		}
+		from, to, err = parseWindow(w)
+	}
+	if err != nil {
+		return start, end, false
+	}
+	start = time.Date(t.Year(), t.Month(), t.Day(), from.Hour(), from.Minute(), 0, 0, t.Location())
+	end = time.Date(t.Year(), t.Month(), t.Day(), to.Hour(), to.Minute(), 0, 0, t.Location())
+	return start, end, true
+}
+
+// InActiveWindow reports whether t falls inside its day's active window.
+func (c *Config) InActiveWindow(t time.Time) bool {
+	start, end, ok := c.ActiveWindow(t)
+	return ok && t.After(start) && t.Before(end)
+}
+
+// ActiveHours describes t's day's window for logs, e.g. "09:00-18:00" or
+// "off".
+func (c *Config) ActiveHours(t time.Time) string {
+	start, end, ok := c.ActiveWindow(t)
+	if !ok {
+		return "off"
+	}
+	return start.Format("15:04") + "-" + end.Format("15:04")
+}
+
+func (c *Config) isHoliday(t time.Time) bool {
+	date, yearly := t.Format("2006-01-02"), t.Format("01-02")
+	for _, h := range c.Stealth.Holidays {
+		if h == date || h == yearly {
+			return true
+		}
+	}
+	return false
+}
+
+// parseWindow parses "HH:MM-HH:MM" with the start before the end.
+func parseWindow(s string) (from, to time.Time, err error) {
+	a, b, ok := strings.Cut(s, "-")
+	if !ok {
+		return from, to, fmt.Errorf("expected HH:MM-HH:MM, got %q", s)
+	}
+	if from, err = time.Parse("15:04", strings.TrimSpace(a)); err != nil {
+		return from, to, fmt.Errorf("expected HH:MM-HH:MM, got %q", s)
+	}
+	if to, err = time.Parse("15:04", strings.TrimSpace(b)); err != nil {
+		return from, to, fmt.Errorf("expected HH:MM-HH:MM, got %q", s)
+	}
+	if !from.Before(to) {
+		return from, to, fmt.Errorf("window %q must start before it ends", s)
+	}
+	return from, to, nil
+}
+
+// WarmUpStep returns the ramp step for an account of the given age: the last
+// step whose week has started. ok is false when warm-up is off or the age is
+// past the last step's week, and the regular daily limits apply.
+func (c *Config) WarmUpStep(age time.Duration) (step RampStep, ok bool) {
+	ramp := c.Limits.WarmUp.Ramp
+	if !c.Limits.WarmUp.Enabled || len(ramp) == 0 {
+		return RampStep{}, false
+	}
+	week := int(age/(7*24*time.Hour)) + 1
+	if week > ramp[len(ramp)-1].Week {
+		return RampStep{}, false
+	}
+	for _, st := range ramp {
+		if st.Week > week {
+			break
+		}
+		step, ok = st, true
+	}
+	return step, ok
 }
 
-func Load(path string) (*Config, error) {
+// AuditDir is where action screenshots go: audit.screenshot_dir, or a
+// per-account directory under .cache.
+func (c *Config) AuditDir() string {
+	if c.Audit.ScreenshotDir != "" {
+		return c.Audit.ScreenshotDir
+	}
+	return filepath.Join(".cache", c.AccountKey(), "audit")
+}
+
+// Webhook receives a JSON POST for each subscribed event; an empty Events
+// list subscribes to all of them.
+// notifyEvents are the events webhooks and email can subscribe to.
+var notifyEvents = []string{"connection_accepted", "reply_received", "interested_reply", "checkpoint_detected", "daily_cap_reached", "invite_limit_reached", "fatal_error", "run_complete"}
+
+// ReplyLabels are the classes a reply can be given besides "other".
+var ReplyLabels = []string{"interested", "not_interested", "stop", "out_of_office"}
+
+// ReplyRule gives a reply Label when any of Patterns, case-insensitive
+// regular expressions, matches its text.
+type ReplyRule struct {
+	Label    string   `yaml:"label"`
+	Patterns []string `yaml:"patterns"`
+}
+
+type Webhook struct {
+	URL    string   `yaml:"url"`
+	Events []string `yaml:"events"`
+}
+
+// Campaign overrides the templates for profiles whose source starts with
+// Source (e.g. "search:golang" or an import --source). The files are read
+// when the config is loaded.
+type Campaign struct {
+	Source             string `yaml:"source"`
+	ConnectionNoteFile string `yaml:"connection_note_file"`
+	FollowUpFile       string `yaml:"follow_up_file"`
+	// EndorseSkills overrides engage.endorse_skills when set
+	EndorseSkills *int `yaml:"endorse_skills"`
+
+	ConnectionNote string `yaml:"-"`
+	FollowUp       string `yaml:"-"`
+}
+
+// campaign returns the campaign with the longest source prefix matching
+// source, or nil.
+func (c *Config) campaign(source string) *Campaign {
+	var best *Campaign
+	for i := range c.Templates.Campaigns {
+		cp := &c.Templates.Campaigns[i]
+		if strings.HasPrefix(source, cp.Source) && (best == nil || len(cp.Source) > len(best.Source)) {
+			best = cp
+		}
+	}
+	return best
+}
+
+// ConnectionNoteFor returns the connection note template for a profile
+// source, preferring a matching campaign file.
+func (c *Config) ConnectionNoteFor(source string) string {
+	if cp := c.campaign(source); cp != nil && cp.ConnectionNote != "" {
+		return cp.ConnectionNote
+	}
+	return c.Templates.ConnectionNote
+}
+
+// FollowUpFor returns the template of a follow-up step for a profile source.
+// A campaign follow-up file replaces the first step only.
+func (c *Config) FollowUpFor(source string, step int) string {
+	if cp := c.campaign(source); step == 0 && cp != nil && cp.FollowUp != "" {
+		return cp.FollowUp
+	}
+	return c.FollowUpSteps()[step].Template
+}
+
+// EndorseSkillsFor returns how many skills to endorse for a profile source.
+func (c *Config) EndorseSkillsFor(source string) int {
+	if cp := c.campaign(source); cp != nil && cp.EndorseSkills != nil {
+		return *cp.EndorseSkills
+	}
+	return c.Engage.EndorseSkills
+}
+
+// EndorseEnabled reports whether any profile source gets endorsements.
+func (c *Config) EndorseEnabled() bool {
+	if c.Engage.EndorseSkills > 0 {
+		return true
+	}
+	for _, cp := range c.Templates.Campaigns {
+		if cp.EndorseSkills != nil && *cp.EndorseSkills > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// audienceRe matches the group:<id> and event:<id> search sources.
+var audienceRe = regexp.MustCompile(`^(group|event):(\d+)$`)
+
+// ParseAudience splits a group:<id> or event:<id> search source.
+func ParseAudience(source string) (kind, id string, ok bool) {
+	m := audienceRe.FindStringSubmatch(strings.TrimSpace(source))
+	if m == nil {
+		return "", "", false
+	}
+	return m[1], m[2], true
+}
+
+// FollowUpSteps returns messaging.sequence, or a single immediate step using
+// templates.follow_up_message_template when no sequence is configured.
+func (c *Config) FollowUpSteps() []SequenceStep {
+	if len(c.Messaging.Sequence) == 0 {
+		return []SequenceStep{{Template: c.Templates.FollowUp}}
+	}
+	return c.Messaging.Sequence
+}
+
+// Load reads the config file and, when account is non-empty, applies that
+// entry of accounts on top of the global settings, then the LINKEDBOT_*
+// environment variables. See Manager for the full precedence.
+func Load(path, account string) (*Config, error) {
 	_ = godotenv.Load() // optional
 	cfg := defaultConfig()
 	if b, err := os.ReadFile(path); err == nil {
@@ -63,25 +783,62 @@ func Load(path string) (*Config, error) {
 			return nil, fmt.Errorf("parse config: %w", err)
 		}
 	}
+	if account != "" {
+		if err := applyAccount(&cfg, account); err != nil {
+			return nil, err
+		}
+	}
 	applyEnvOverrides(&cfg)
+	if pass := cfg.SecretsPassphrase(); pass != "" {
+		st, err := secrets.Load(cfg.Secrets.Path, pass)
+		if err != nil {
+			return nil, fmt.Errorf("secrets: %w", err)
+		}
+		cfg.secrets = st.Values()
+	}
 	if err := validate(&cfg); err != nil {
 		return nil, err
 	}
+	if err := loadCampaigns(&cfg); err != nil {
+		return nil, err
+	}
 	return &cfg, nil
 }
 
 func defaultConfig() Config {
 	var cfg Config
 	cfg.LinkedIn.BaseURL = "https://www.linkedin.com/"
+	cfg.LinkedIn.Locale = "en"
+	cfg.Auth.Mode = AuthPassword
+	cfg.Auth.EmailEnv = "LINKEDIN_EMAIL"
+	cfg.Auth.PasswordEnv = "LINKEDIN_PASSWORD"
+	cfg.Auth.CookiePath = filepath.Join(".cache", "cookies.json")
+	cfg.Auth.TOTPSecretEnv = "LINKEDIN_TOTP_SECRET"
+	cfg.Auth.ChallengeTimeoutSec = 300
 	cfg.Limits.MaxConnectionsPerDay = 20
 	cfg.Limits.MaxMessagesPerDay = 50
 	cfg.Limits.MaxProfilesPerSearch = 200
+	cfg.Limits.MaxConnectionsPerWeek = 100
+	cfg.Limits.Schedule.MaxBurst = 3
+	cfg.Limits.WarmUp.Ramp = []RampStep{
+		{Week: 1, MaxConnectionsPerDay: 5, MaxMessagesPerDay: 10},
+		{Week: 2, MaxConnectionsPerDay: 10, MaxMessagesPerDay: 20},
+		{Week: 3, MaxConnectionsPerDay: 15, MaxMessagesPerDay: 35},
+	}
 	cfg.Stealth.Headless = false
 	cfg.Stealth.EnableHumanMouse = true
 	cfg.Stealth.EnableRandomScroll = true
 	cfg.Stealth.EnableTypeTypos = true
 	cfg.Stealth.EnableHoverWander = true
 	cfg.Stealth.EnableBreaks = true
+	cfg.Stealth.TypoRate = 0.02
+	cfg.Stealth.BreakProbability = 0.15
+	cfg.Stealth.ScrollIntensity = 1
+	cfg.Stealth.TypingMode = "input"
+	cfg.Stealth.ReadingWPM = 200
+	cfg.Stealth.ReadMinSeconds = 3
+	cfg.Stealth.ReadMaxSeconds = 40
+	cfg.Stealth.FingerprintMaxAgeDays = 30
 	cfg.Stealth.MinDelayMs = 120
 	cfg.Stealth.MaxDelayMs = 900
 	cfg.Stealth.ViewportWidthMin = 1280
@@ -90,22 +847,367 @@ func defaultConfig() Config {
 	cfg.Stealth.ViewportHeightMax = 1050
 	cfg.Stealth.ActiveStart = "09:00"
 	cfg.Stealth.ActiveEnd = "18:00"
+	cfg.Browser.MaxOpenPages = 5
+	cfg.Browser.ProxyRotation = "sticky"
+	cfg.Browser.PersistentProfile = true
+	cfg.Browser.Tabs = 1
+	cfg.Browser.Engine = EngineLocal
+	cfg.Browser.DebugPort = 9222
+	cfg.Browser.TabGapMs = 3000
+	cfg.Timeouts.ComposeReadyMs = 10000
+	cfg.Timeouts.SendEnabledMs = 10000
+	cfg.Timeouts.SendConfirmMs = 8000
+	cfg.Timeouts.SendRetries = 1
+	cfg.Retry.Attempts = 3
+	cfg.Retry.InitialDelayMs = 1000
+	cfg.Retry.MaxDelayMs = 8000
+	cfg.Retry.Multiplier = 2
+	cfg.Selectors.CachePath = filepath.Join(".cache", "selectors.yaml")
+	cfg.Selectors.SelfHeal = true
+	cfg.Selectors.LearnedPath = filepath.Join(".cache", "selectors.learned.yaml")
+	cfg.Search.Backend = "dom"
+	cfg.Search.Voyager.QueryID = "voyagerSearchDashClusters.b0928897b71bd00a5a7291755dcd64f0"
+	cfg.Search.Voyager.PageSize = 10
+	cfg.Connection.QueueStrategy = "fifo"
+	cfg.Connection.BadSourceMinSample = 30
+	cfg.Connection.BadSourceMinAcceptRate = 0.1
+	cfg.Connection.WithdrawAfterDays = 21
+	cfg.Connection.LimitCooldownHours = 7 * 24
+	cfg.Connection.EmailLookup.APIKeyEnv = "EMAIL_LOOKUP_API_KEY"
+	cfg.Connection.EmailLookup.TimeoutSec = 10
+	cfg.Engage.MaxPerDay = 30
+	cfg.Engage.MaxPostAgeDays = 30
+	cfg.Nurture.MaxPerDay = 10
+	cfg.Nurture.MaxAgeDays = 7
+	cfg.Messaging.AcceptanceSignal = "badge"
+	cfg.Messaging.AcceptanceDetection = "profile"
+	cfg.Messaging.DeepLinkFallback = true
+	cfg.Messaging.DetectReplies = true
+	cfg.Messaging.InviteExpiryDays = 30
+	cfg.Messaging.FirstFollowUpMinHours = 24
+	cfg.Messaging.FirstFollowUpJitterHours = 24
+	cfg.Messaging.ReplyClassifier = "rules"
+	// Checked in order: an opt-out wins over anything else, and "not
+	// interested" must be tried before "interested"
+	cfg.Messaging.ReplyRules = []ReplyRule{
+		{"stop", []string{`\bunsubscribe\b`, `\bstop (messaging|contacting|sending|spamming)\b`, `\bremove me\b`, `\b(don'?t|do not|please stop) (message|contact|spam)`, `\bleave me alone\b`}},
+		{"out_of_office", []string{`\bout of (the )?office\b`, `\b(on|currently on) (vacation|holiday|annual leave|leave|parental leave|maternity leave|paternity leave)\b`, `\baway (until|from)\b`, `\bauto(matic|mated)?[- ]?(reply|response)\b`, `\blimited access to\b`}},
+		{"not_interested", []string{`\bnot interested\b`, `\bno,? thanks?( you)?\b`, `\bnot (a (good )?fit|looking|the right time|at (this|the moment))\b`, `\bwe('re| are) (all )?(set|good)\b`, `\bpass on this\b`}},
+		{"interested", []string{`\binterested\b`, `\bsounds (good|great|interesting)\b`, `\blet'?s (talk|chat|connect|set up|schedule|meet)\b`, `\bhappy to (chat|talk|connect|hop on)\b`, `\btell me more\b`, `\bsend (me )?(more|details|info)`, `\b(book|schedule) a (call|time|meeting)\b`, `calendly\.com`, `\bwhen are you (free|available)\b`}},
+	}
+	cfg.Extraction.ExpandSeeMore = true
+	cfg.Audit.Enabled = true
+	cfg.Jobs.MaxAttempts = 3
+	cfg.Jobs.BackoffMinutes = 30
+	cfg.Jobs.StaleMinutes = 30
+	cfg.Jobs.MaxRetries = 2
+	cfg.KeepAlive.MinMinutes = 3
+	cfg.KeepAlive.MaxMinutes = 8
+	cfg.Session.Enabled = true
+	cfg.Session.FeedProbability = 0.8
+	cfg.Session.NotificationsProbability = 0.4
+	cfg.Session.FeedReturnProbability = 0.1
+	cfg.Session.FeedScrolls = 3
+	cfg.Retention.ProfileDays = 180
+	cfg.Retention.Statuses = []string{"closed", "withdrawn"}
+	cfg.Retention.Mode = "anonymize"
+	cfg.Retention.ScreenshotDays = 30
+	cfg.Debug.KeepDays = 14
+	cfg.Debug.MaxRuns = 50
+	cfg.Checkpoint.CooldownHours = 24
+	cfg.Notifications.TimeoutSec = 10
+	cfg.Notifications.MaxRetries = 3
+	cfg.Notifications.Email.Port = 587
+	cfg.Notifications.Email.PasswordEnv = "SMTP_PASSWORD"
+	cfg.Notifications.Email.Events = []string{"fatal_error", "checkpoint_detected"}
+	cfg.Notifications.Email.DigestCron = "0 18 * * *"
+	cfg.Notifications.Telegram.TokenEnv = "TELEGRAM_BOT_TOKEN"
+	cfg.Notifications.Telegram.Events = []string{"checkpoint_detected", "run_complete", "fatal_error", "daily_cap_reached", "invite_limit_reached"}
+	cfg.Notifications.Telegram.Commands = true
+	cfg.LLM.APIKeyEnv = "LLM_API_KEY"
+	cfg.LLM.Notes = true
+	cfg.LLM.SystemPrompt = "You write short, sincere LinkedIn messages in the first person for the account owner. Plain text only: no hashtags, emojis, links, placeholders or sign-off."
+	cfg.LLM.NotePrompt = `Write a connection request note to {{.FullName}}{{with .Headline}} ({{.}}){{end}}.
+{{with .About}}Their about section: {{.}}
+{{end}}{{with .Post}}Their latest post: {{.}}
+{{end}}Refer to one specific detail above, keep it friendly and low-key, and stay under {{.MaxChars}} characters. For tone, this is the note we'd send otherwise: {{.Template}}
+Reply with the note only.`
+	cfg.LLM.FollowUpPrompt = `{{.FullName}}{{with .Headline}} ({{.}}){{end}} accepted my connection request. Write a short first message to them.
+{{with .About}}Their about section: {{.}}
+{{end}}{{with .Post}}Their latest post: {{.}}
+{{end}}Build on one specific detail above, no pitch, end with an easy question, and stay under {{.MaxChars}} characters. This is the message we'd send otherwise, keep its intent: {{.Template}}
+Reply with the message only.`
+	cfg.LLM.ClassifyPrompt = `Classify this reply from {{.FullName}} to my LinkedIn outreach as exactly one of:
+interested (wants to talk or learn more), not_interested (declines), stop (asks not to be messaged again), out_of_office (automatic or away reply), other.
+Reply: {{.Reply}}
+Answer with the label only.`
+	cfg.LLM.MaxMessageChars = 600
+	cfg.LLM.MaxOutputTokens = 300
+	cfg.LLM.Temperature = 0.7
+	cfg.LLM.TimeoutSec = 30
+	cfg.CRM.APIKeyEnv = "CRM_API_KEY"
+	cfg.CRM.Statuses = []string{"accepted", "messaged", "replied"}
+	cfg.CRM.DealOn = []string{"interested"}
+	cfg.CRM.DealName = "{{.FullName}} (LinkedIn)"
+	cfg.CRM.SyncCron = "15 * * * *"
+	cfg.CRM.TimeoutSec = 15
+	cfg.Daemon.EventsTokenEnv = "LINKEDBOT_EVENTS_TOKEN"
+	cfg.Daemon.ConfigReloadSec = 5
+	cfg.Sheets.Sheet = "Profiles"
+	cfg.Secrets.Path = ".cache/secrets.enc"
+	cfg.Secrets.PassphraseEnv = "LINKEDBOT_SECRETS_PASSPHRASE"
+	cfg.Sheets.SyncCron = "45 * * * *"
+	cfg.Sheets.TimeoutSec = 30
+	cfg.Database.Driver = "sqlite"
 	cfg.Database.Path = "linkedbot.db"
 	cfg.Logging.Level = "info"
+	cfg.Logging.Console = "json"
+	cfg.Logging.File.MaxSizeMB = 50
+	cfg.Logging.File.RotateDaily = true
+	cfg.Logging.File.MaxBackups = 7
+	cfg.Tracing.ServiceName = "linkedbot"
+	cfg.Tracing.SampleRatio = 1
 	cfg.Templates.ConnectionNote = "Hi {{Name}}, noticed your work at {{Company}} as {{Title}}—would love to connect."
+	cfg.Templates.TitleCleanup = "aggressive"
 	cfg.Templates.FollowUp = "Thanks for connecting, {{Name}}! If helpful, happy to share ideas around {{Keywords}}."
 	return cfg
 }
 
+func applyAccount(cfg *Config, name string) error {
+	acc, ok := cfg.Accounts[name]
+	if !ok {
+		return fmt.Errorf("unknown account %q (not in accounts)", name)
+	}
+	cfg.Account = name
+	if acc.AuthMode != "" {
+		cfg.Auth.Mode = acc.AuthMode
+	}
+	if acc.EmailEnv != "" {
+		cfg.Auth.EmailEnv = acc.EmailEnv
+	}
+	if acc.PasswordEnv != "" {
+		cfg.Auth.PasswordEnv = acc.PasswordEnv
+	}
+	if acc.TOTPEnv != "" {
+		cfg.Auth.TOTPSecretEnv = acc.TOTPEnv
+	}
+	cfg.Auth.CookiePath = acc.CookiePath
+	if cfg.Auth.CookiePath == "" {
+		cfg.Auth.CookiePath = filepath.Join(".cache", name, "cookies.json")
+	}
+	if acc.DBPath != "" {
+		cfg.Database.Path = acc.DBPath
+	} else {
+		base := cfg.Database.Path
+		cfg.Database.Path = strings.TrimSuffix(base, filepath.Ext(base)) + "-" + name + filepath.Ext(base)
+	}
+	if acc.DBDSN != "" {
+		cfg.Database.DSN = acc.DBDSN
+	}
+	if acc.Proxy != "" {
+		cfg.Browser.Proxy = acc.Proxy
+	}
+	if acc.Locale != "" {
+		cfg.LinkedIn.Locale = acc.Locale
+	}
+	if acc.Timezone != "" {
+		cfg.Stealth.Timezone = acc.Timezone
+	}
+	if n := acc.Limits.MaxConnectionsPerDay; n > 0 {
+		cfg.Limits.MaxConnectionsPerDay = n
+	}
+	if n := acc.Limits.MaxMessagesPerDay; n > 0 {
+		cfg.Limits.MaxMessagesPerDay = n
+	}
+	if n := acc.Limits.MaxProfilesPerSearch; n > 0 {
+		cfg.Limits.MaxProfilesPerSearch = n
+	}
+	if n := acc.Limits.MaxConnectionsPerHour; n > 0 {
+		cfg.Limits.MaxConnectionsPerHour = n
+	}
+	if n := acc.Limits.MaxConnectionsPerWeek; n > 0 {
+		cfg.Limits.MaxConnectionsPerWeek = n
+	}
+	if n := acc.Limits.MaxMessagesPerHour; n > 0 {
+		cfg.Limits.MaxMessagesPerHour = n
+	}
+	if n := acc.Limits.MaxMessagesPerWeek; n > 0 {
+		cfg.Limits.MaxMessagesPerWeek = n
+	}
+	return nil
+}
+
+// DatabaseSource is what the store opens: the file path for sqlite, the
+// connection URL for postgres.
+func (c *Config) DatabaseSource() string {
+	if c.Database.Driver == "postgres" {
+		return c.Database.DSN
+	}
+	return c.Database.Path
+}
+
+// EmailEnabled reports whether SMTP notifications are configured.
+func (c *Config) EmailEnabled() bool {
+	return c.Notifications.Email.Host != "" && len(c.Notifications.Email.To) > 0
+}
+
+// SMTPPassword reads the SMTP password from notifications.email.password_env.
+func (c *Config) SMTPPassword() string {
+	return c.Secret(c.Notifications.Email.PasswordEnv)
+}
+
+// TelegramEnabled reports whether a Telegram chat and bot token are set.
+func (c *Config) TelegramEnabled() bool {
+	return c.Notifications.Telegram.ChatID != 0 && c.TelegramToken() != ""
+}
+
+// TelegramToken reads the bot token from notifications.telegram.token_env.
+func (c *Config) TelegramToken() string {
+	return c.Secret(c.Notifications.Telegram.TokenEnv)
+}
+
+// EventsToken reads the /api/events token from daemon.events_token_env.
+func (c *Config) EventsToken() string { return c.Secret(c.Daemon.EventsTokenEnv) }
+
+// CRMKey reads the CRM API token from crm.api_key_env.
+func (c *Config) CRMKey() string { return c.Secret(c.CRM.APIKeyEnv) }
+
+// SheetsEnabled reports whether a Google sheet is configured.
+func (c *Config) SheetsEnabled() bool { return c.Sheets.SpreadsheetID != "" }
+
+// LLMEnabled reports whether an LLM provider is configured.
+func (c *Config) LLMEnabled() bool { return c.LLM.Provider != "" }
+
+// LLMKey reads the LLM API key from llm.api_key_env.
+func (c *Config) LLMKey() string { return c.Secret(c.LLM.APIKeyEnv) }
+
+// Values of browser.engine.
+const (
+	EngineLocal  = "local"
+	EngineRemote = "remote"
+	EngineAttach = "attach"
+)
+
+// Values of auth.mode.
+const (
+	AuthPassword = "password"
+	AuthCookies  = "cookies"
+)
+
+// Credentials returns the LinkedIn email and password from the env vars
+// named in auth, or the secrets of the same names.
+func (c *Config) Credentials() (email, password string) {
+	return c.Secret(c.Auth.EmailEnv), c.Secret(c.Auth.PasswordEnv)
+}
+
+// Secret returns the env var called name or, when it is unset, the secret
+// stored under that name in the secrets file.
+func (c *Config) Secret(name string) string {
+	if name == "" {
+		return ""
+	}
+	if v := os.Getenv(name); v != "" {
+		return v
+	}
+	return c.secrets[name]
+}
+
+// SecretsPassphrase reads the passphrase of the secrets file and encrypted
+// cookies from secrets.passphrase_env.
+func (c *Config) SecretsPassphrase() string { return os.Getenv(c.Secrets.PassphraseEnv) }
+
+// AccountKey identifies the account whose state lives under .cache. It is
+// derived from the login email so each login keeps its own files; without
+// one (cookie-only) the --account name is used.
+func (c *Config) AccountKey() string {
+	email, _ := c.Credentials()
+	email = strings.ToLower(strings.TrimSpace(email))
+	if email == "" {
+		email = strings.ToLower(c.Account)
+	}
+	if email == "" {
+		return "default"
+	}
+	return strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '.' || r == '-' || r == '_' {
+			return r
+		}
+		return '_'
+	}, email)
+}
+
+// Redacted returns a copy of c with the passwords in database DSNs and
+// proxy URLs masked, for printing.
+func (c *Config) Redacted() Config {
+	r := *c
+	r.Database.DSN = redactURL(c.Database.DSN)
+	r.Browser.Proxy = redactURL(c.Browser.Proxy)
+	r.Browser.RemoteURL = redactURL(c.Browser.RemoteURL)
+	r.Browser.ProxyPool = make([]string, len(c.Browser.ProxyPool))
+	for i, p := range c.Browser.ProxyPool {
+		r.Browser.ProxyPool[i] = redactURL(p)
+	}
+	r.Accounts = make(map[string]Account, len(c.Accounts))
+	for name, acc := range c.Accounts {
+		acc.DBDSN = redactURL(acc.DBDSN)
+		acc.Proxy = redactURL(acc.Proxy)
+		r.Accounts[name] = acc
+	}
+	return r
+}
+
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme == "" {
+		return raw
+	}
+	if u.User != nil {
+		if _, ok := u.User.Password(); ok {
+			u.User = url.UserPassword(u.User.Username(), "xxxxx")
+		}
+	}
+	// Postgres also takes the password as a query parameter, Browserless
+	// a token
+	q := u.Query()
+	for _, k := range []string{"password", "token"} {
+		if q.Has(k) {
+			q.Set(k, "xxxxx")
+			u.RawQuery = q.Encode()
+		}
+	}
+	return u.String()
+}
+
 func applyEnvOverrides(cfg *Config) {
 	if v := os.Getenv("LINKEDBOT_DB_PATH"); v != "" {
 		cfg.Database.Path = v
 	}
+	if v := os.Getenv("LINKEDBOT_DB_DRIVER"); v != "" {
+		cfg.Database.Driver = v
+	}
+	if v := os.Getenv("LINKEDBOT_DB_DSN"); v != "" {
+		cfg.Database.DSN = v
+	}
 	if v := os.Getenv("LINKEDBOT_LOG_LEVEL"); v != "" {
 		cfg.Logging.Level = v
 	}
-	if v := os.Getenv("LINKEDBOT_HEADLESS"); v == "1" || v == "true" {
+	if v := os.Getenv("LINKEDBOT_LOG_FILE"); v != "" {
+		cfg.Logging.File.Path = v
+	}
+	if v := os.Getenv("LINKEDBOT_TRACING_ENDPOINT"); v != "" {
+		cfg.Tracing.Endpoint = v
+	}
+	if v := os.Getenv("LINKEDBOT_PROXY"); v != "" {
+		cfg.Browser.Proxy = v
+	}
+	if v := os.Getenv("LINKEDBOT_REMOTE_URL"); v != "" {
+		cfg.Browser.Engine, cfg.Browser.RemoteURL = EngineRemote, v
+	}
+	switch os.Getenv("LINKEDBOT_HEADLESS") {
+	case "1", "true":
 		cfg.Stealth.Headless = true
+	case "0", "false":
+		cfg.Stealth.Headless = false
 	}
 }
 
@@ -113,20 +1215,568 @@ func validate(cfg *Config) error {
 	if cfg.LinkedIn.BaseURL == "" {
 		return errors.New("linkedin.base_url is required")
 	}
+	if cfg.LinkedIn.Locale == "" {
+		return errors.New("linkedin.locale is required")
+	}
 	if cfg.Limits.MaxConnectionsPerDay <= 0 {
 		return errors.New("limits.max_connections_per_day must be > 0")
 	}
 	if cfg.Limits.MaxMessagesPerDay <= 0 {
 		return errors.New("limits.max_messages_per_day must be > 0")
 	}
+	if cfg.Timeouts.ComposeReadyMs <= 0 || cfg.Timeouts.SendEnabledMs <= 0 || cfg.Timeouts.SendConfirmMs <= 0 {
+		return errors.New("timeouts.compose_ready_ms, send_enabled_ms and send_confirm_ms must be > 0")
+	}
+	if cfg.Auth.Mode != AuthPassword && cfg.Auth.Mode != AuthCookies {
+		return fmt.Errorf("auth.mode must be %s or %s, got %q", AuthPassword, AuthCookies, cfg.Auth.Mode)
+	}
+	if cfg.Auth.ChallengeTimeoutSec <= 0 {
+		return errors.New("auth.challenge_timeout_sec must be > 0")
+	}
+	if cfg.Timeouts.SendRetries < 0 {
+		return errors.New("timeouts.send_retries must be >= 0")
+	}
+	if cfg.Retry.Attempts < 1 {
+		return errors.New("retry.attempts must be >= 1")
+	}
+	if cfg.Retry.InitialDelayMs < 0 || cfg.Retry.MaxDelayMs < cfg.Retry.InitialDelayMs {
+		return errors.New("retry.initial_delay_ms must be >= 0 and <= retry.max_delay_ms")
+	}
+	if cfg.Retry.Multiplier < 1 {
+		return errors.New("retry.multiplier must be >= 1")
+	}
+	if cfg.Selectors.URL != "" {
+		if u, err := url.Parse(cfg.Selectors.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("selectors.url %q must be an http(s) URL", cfg.Selectors.URL)
+		}
+	}
+	switch cfg.Database.Driver {
+	case "sqlite":
+	case "postgres":
+		if cfg.Database.DSN == "" {
+			return errors.New("database.dsn (or LINKEDBOT_DB_DSN) is required with database.driver postgres")
+		}
+		if cfg.Account != "" && cfg.Accounts[cfg.Account].DBDSN == "" {
+			return fmt.Errorf("accounts.%s.db_dsn is required with database.driver postgres so accounts don't share a database", cfg.Account)
+		}
+	default:
+		return fmt.Errorf("database.driver must be sqlite or postgres, got %q", cfg.Database.Driver)
+	}
+	switch cfg.Browser.ProxyRotation {
+	case "sticky", "per_session":
+	default:
+		return fmt.Errorf("browser.proxy_rotation must be sticky or per_session, got %q", cfg.Browser.ProxyRotation)
+	}
+	if cfg.Browser.Tabs < 1 || cfg.Browser.Tabs > 3 {
+		return fmt.Errorf("browser.tabs must be between 1 and 3, got %d", cfg.Browser.Tabs)
+	}
+	if cfg.Browser.TabGapMs < 0 {
+		return fmt.Errorf("browser.tab_gap_ms must not be negative, got %d", cfg.Browser.TabGapMs)
+	}
+	switch cfg.Browser.Engine {
+	case EngineLocal:
+	case EngineRemote:
+		u, err := url.Parse(cfg.Browser.RemoteURL)
+		if err != nil || u.Host == "" {
+			return fmt.Errorf("browser.remote_url is required with browser.engine remote: expected ws://host:port/... or http://host:port, got %q", cfg.Browser.RemoteURL)
+		}
+		switch u.Scheme {
+		case "ws", "wss", "http", "https":
+		default:
+			return fmt.Errorf("browser.remote_url: unsupported scheme %q (ws, wss, http, https)", u.Scheme)
+		}
+	case EngineAttach:
+		if cfg.Browser.DebugPort < 1 || cfg.Browser.DebugPort > 65535 {
+			return fmt.Errorf("browser.debug_port must be a TCP port, got %d", cfg.Browser.DebugPort)
+		}
+	default:
+		return fmt.Errorf("browser.engine must be local, remote or attach, got %q", cfg.Browser.Engine)
+	}
+	for _, p := range append([]string{cfg.Browser.Proxy}, cfg.Browser.ProxyPool...) {
+		if p == "" {
+			continue
+		}
+		if err := validateProxy(p); err != nil {
+			return err
+		}
+	}
+	switch cfg.Search.Backend {
+	case "dom", "voyager":
+	default:
+		return fmt.Errorf("search.backend must be dom or voyager, got %q", cfg.Search.Backend)
+	}
+	if cfg.Search.Backend == "voyager" && cfg.Search.Voyager.QueryID == "" {
+		return errors.New("search.voyager.query_id is required for the voyager backend")
+	}
+	if cfg.Search.Voyager.PageSize < 1 || cfg.Search.Voyager.PageSize > 50 {
+		return errors.New("search.voyager.page_size must be between 1 and 50")
+	}
+	if src := cfg.Search.Defaults.Source; src != "" {
+		if _, _, ok := ParseAudience(src); !ok {
+			return fmt.Errorf("search.defaults.source must be group:<id> or event:<id>, got %q", src)
+		}
+	}
+	switch cfg.Connection.QueueStrategy {
+	case "fifo", "round_robin":
+	default:
+		return fmt.Errorf("connection.queue_strategy must be fifo or round_robin, got %q", cfg.Connection.QueueStrategy)
+	}
+	if cfg.Connection.BadSourceMinSample <= 0 {
+		return errors.New("connection.bad_source_min_sample must be > 0")
+	}
+	if r := cfg.Connection.BadSourceMinAcceptRate; r < 0 || r > 1 {
+		return errors.New("connection.bad_source_min_accept_rate must be between 0 and 1")
+	}
+	for _, d := range cfg.Connection.Degrees {
+		if d != "2nd" && d != "3rd" {
+			return fmt.Errorf("connection.degrees: expected 2nd or 3rd, got %q", d)
+		}
+	}
+	for i, t := range cfg.Connection.Tags {
+		tag, err := models.NormalizeTag(t)
+		if err != nil {
+			return fmt.Errorf("connection.tags: %w", err)
+		}
+		cfg.Connection.Tags[i] = tag
+	}
+	switch el := cfg.Connection.EmailLookup; el.Provider {
+	case "":
+	case "hunter":
+		if el.APIKeyEnv == "" {
+			return errors.New("connection.email_lookup.api_key_env is required for the hunter provider")
+		}
+	case "http":
+		if u, err := url.Parse(el.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return errors.New("connection.email_lookup.url must be an http(s) URL for the http provider")
+		}
+	default:
+		return fmt.Errorf("connection.email_lookup.provider must be empty, hunter or http, got %q", el.Provider)
+	}
+	if cfg.Connection.EmailLookup.TimeoutSec <= 0 {
+		return errors.New("connection.email_lookup.timeout_sec must be > 0")
+	}
+	if cfg.Engage.MaxPerDay <= 0 {
+		return errors.New("engage.max_per_day must be > 0")
+	}
+	if cfg.Engage.MaxPostAgeDays <= 0 {
+		return errors.New("engage.max_post_age_days must be > 0")
+	}
+	if cfg.Engage.EndorseSkills < 0 || cfg.Engage.EndorseSkills > 2 {
+		return errors.New("engage.endorse_skills must be between 0 and 2")
+	}
+	if cfg.Nurture.MaxPerDay <= 0 {
+		return errors.New("nurture.max_per_day must be > 0")
+	}
+	if cfg.Nurture.MaxAgeDays <= 0 {
+		return errors.New("nurture.max_age_days must be > 0")
+	}
+	if cfg.Connection.WithdrawAfterDays <= 0 {
+		return errors.New("connection.withdraw_after_days must be > 0")
+	}
+	if cfg.Connection.WarmViewDays < 0 {
+		return errors.New("connection.warm_view_days must be >= 0")
+	}
+	if cfg.Connection.LimitCooldownHours <= 0 {
+		return errors.New("connection.limit_cooldown_hours must be > 0")
+	}
+	switch cfg.Templates.TitleCleanup {
+	case "aggressive", "minimal", "none":
+	default:
+		return fmt.Errorf("templates.title_cleanup must be aggressive, minimal or none, got %q", cfg.Templates.TitleCleanup)
+	}
+	switch cfg.Messaging.AcceptanceSignal {
+	case "badge", "message":
+	default:
+		return fmt.Errorf("messaging.acceptance_signal must be badge or message, got %q", cfg.Messaging.AcceptanceSignal)
+	}
+	switch cfg.Messaging.AcceptanceDetection {
+	case "profile", "network":
+	default:
+		return fmt.Errorf("messaging.acceptance_detection must be profile or network, got %q", cfg.Messaging.AcceptanceDetection)
+	}
+	if cfg.Messaging.InviteExpiryDays < 0 {
+		return fmt.Errorf("messaging.invite_expiry_days must be >= 0, got %d", cfg.Messaging.InviteExpiryDays)
+	}
+	if cfg.Messaging.FirstFollowUpMinHours < 0 || cfg.Messaging.FirstFollowUpJitterHours < 0 {
+		return fmt.Errorf("messaging.first_follow_up_min_hours and first_follow_up_jitter_hours must be >= 0")
+	}
+	for i, step := range cfg.Messaging.Sequence {
+		if step.DelayDays < 0 {
+			return fmt.Errorf("messaging.sequence[%d].delay_days must be >= 0", i)
+		}
+		if step.Template == "" {
+			return fmt.Errorf("messaging.sequence[%d].template is required", i)
+		}
+	}
+	switch cfg.Messaging.ReplyClassifier {
+	case "rules":
+	case "llm":
+		if cfg.LLM.Provider == "" {
+			return errors.New("messaging.reply_classifier llm needs llm.provider")
+		}
+	default:
+		return fmt.Errorf("messaging.reply_classifier must be rules or llm, got %q", cfg.Messaging.ReplyClassifier)
+	}
+	for i, r := range cfg.Messaging.ReplyRules {
+		if !slices.Contains(ReplyLabels, r.Label) {
+			return fmt.Errorf("messaging.reply_rules[%d]: label must be one of %s, got %q", i, strings.Join(ReplyLabels, ", "), r.Label)
+		}
+		for _, pat := range r.Patterns {
+			if _, err := regexp.Compile("(?i)" + pat); err != nil {
+				return fmt.Errorf("messaging.reply_rules[%d]: %w", i, err)
+			}
+		}
+	}
+	for i, t := range cfg.Messaging.Tags {
+		tag, err := models.NormalizeTag(t)
+		if err != nil {
+			return fmt.Errorf("messaging.tags: %w", err)
+		}
+		cfg.Messaging.Tags[i] = tag
+	}
+	levels := map[string]bool{"debug": true, "info": true, "warn": true, "error": true}
+	if !levels[strings.ToLower(cfg.Logging.Level)] {
+		return fmt.Errorf("logging.level must be debug, info, warn or error, got %q", cfg.Logging.Level)
+	}
+	for m, l := range cfg.Logging.Modules {
+		if !levels[strings.ToLower(l)] {
+			return fmt.Errorf("logging.modules.%s must be debug, info, warn or error, got %q", m, l)
+		}
+	}
+	if c := cfg.Logging.Console; c != "json" && c != "pretty" && c != "off" {
+		return fmt.Errorf("logging.console must be json, pretty or off, got %q", c)
+	}
+	if cfg.Logging.File.MaxSizeMB < 0 || cfg.Logging.File.MaxBackups < 0 {
+		return errors.New("logging.file.max_size_mb and max_backups must be >= 0 (0 = no limit)")
+	}
+	switch cfg.LLM.Provider {
+	case "":
+	case "openai", "anthropic":
+		if cfg.LLM.Model == "" {
+			return errors.New("llm.model is required when llm.provider is set")
+		}
+		if cfg.LLM.BaseURL != "" {
+			if u, err := url.Parse(cfg.LLM.BaseURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return fmt.Errorf("llm.base_url %q must be an http(s) URL", cfg.LLM.BaseURL)
+			}
+		}
+	default:
+		return fmt.Errorf("llm.provider must be empty, openai or anthropic, got %q", cfg.LLM.Provider)
+	}
+	if cfg.LLM.MaxMessageChars <= 0 || cfg.LLM.MaxOutputTokens <= 0 || cfg.LLM.TimeoutSec <= 0 {
+		return errors.New("llm.max_message_chars, max_output_tokens and timeout_sec must be > 0")
+	}
+	if cfg.LLM.Temperature < 0 || cfg.LLM.Temperature > 2 {
+		return errors.New("llm.temperature must be between 0 and 2")
+	}
+	if cfg.LLM.DailyTokenCap < 0 || cfg.LLM.DailyCostCap < 0 || cfg.LLM.InputCostPerMTok < 0 || cfg.LLM.OutputCostPerMTok < 0 {
+		return errors.New("llm caps and prices must be >= 0 (0 = uncapped)")
+	}
+	switch cfg.CRM.Provider {
+	case "":
+	case "hubspot", "pipedrive":
+		if cfg.CRM.APIKeyEnv == "" {
+			return errors.New("crm.api_key_env is required when crm.provider is set")
+		}
+		if cfg.CRM.BaseURL != "" {
+			if u, err := url.Parse(cfg.CRM.BaseURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return fmt.Errorf("crm.base_url %q must be an http(s) URL", cfg.CRM.BaseURL)
+			}
+		}
+	default:
+		return fmt.Errorf("crm.provider must be empty, hubspot or pipedrive, got %q", cfg.CRM.Provider)
+	}
+	for _, st := range cfg.CRM.Statuses {
+		if _, ok := models.ParseStatus(st); !ok {
+			return fmt.Errorf("crm.statuses: unknown status %q", st)
+		}
+	}
+	for _, l := range cfg.CRM.DealOn {
+		if !slices.Contains(ReplyLabels, l) && l != "other" {
+			return fmt.Errorf("crm.deal_on: unknown reply label %q", l)
+		}
+	}
+	if cfg.CRM.TimeoutSec <= 0 {
+		return errors.New("crm.timeout_sec must be > 0")
+	}
+	if cfg.SheetsEnabled() {
+		if cfg.Sheets.CredentialsFile == "" {
+			return errors.New("sheets.credentials_file is required when sheets.spreadsheet_id is set")
+		}
+		if cfg.Sheets.Sheet == "" {
+			return errors.New("sheets.sheet is required when sheets.spreadsheet_id is set")
+		}
+	}
+	for _, st := range cfg.Sheets.Statuses {
+		if _, ok := models.ParseStatus(st); !ok {
+			return fmt.Errorf("sheets.statuses: unknown status %q", st)
+		}
+	}
+	if cfg.Secrets.EncryptCookies && cfg.SecretsPassphrase() == "" {
+		return fmt.Errorf("secrets.encrypt_cookies needs the passphrase in %q", cfg.Secrets.PassphraseEnv)
+	}
+	if cfg.Sheets.TimeoutSec <= 0 {
+		return errors.New("sheets.timeout_sec must be > 0")
+	}
+	if cfg.Tracing.Endpoint != "" {
+		if u, err := url.Parse(cfg.Tracing.Endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("tracing.endpoint %q must be an http(s) URL", cfg.Tracing.Endpoint)
+		}
+		if cfg.Tracing.ServiceName == "" {
+			return errors.New("tracing.service_name is required when tracing is on")
+		}
+	}
+	if cfg.Tracing.SampleRatio < 0 || cfg.Tracing.SampleRatio > 1 {
+		return errors.New("tracing.sample_ratio must be between 0 and 1")
+	}
+	if cfg.Debug.KeepDays < 0 || cfg.Debug.MaxRuns < 0 {
+		return errors.New("debug.keep_days and debug.max_runs must be >= 0 (0 keeps everything)")
+	}
+	if cfg.Retention.ProfileDays < 0 || cfg.Retention.ScreenshotDays < 0 {
+		return errors.New("retention.profile_days and retention.screenshot_days must be >= 0")
+	}
+	for _, st := range cfg.Retention.Statuses {
+		if _, ok := models.ParseStatus(st); !ok {
+			return fmt.Errorf("retention.statuses: unknown status %q", st)
+		}
+	}
+	if m := cfg.Retention.Mode; m != "delete" && m != "anonymize" {
+		return fmt.Errorf("retention.mode must be delete or anonymize, got %q", m)
+	}
+	if cfg.Checkpoint.CooldownHours < 0 {
+		return errors.New("checkpoint.cooldown_hours must be >= 0 (0 waits for `cooldown clear`)")
+	}
+	for i, pat := range cfg.Exclusions.NamePatterns {
+		if _, err := regexp.Compile("(?i)" + pat); err != nil {
+			return fmt.Errorf("exclusions.name_patterns[%d]: %w", i, err)
+		}
+	}
+	for i, cp := range cfg.Templates.Campaigns {
+		if cp.Source == "" {
+			return fmt.Errorf("templates.campaigns[%d].source is required", i)
+		}
+		if cp.ConnectionNoteFile == "" && cp.FollowUpFile == "" && cp.EndorseSkills == nil {
+			return fmt.Errorf("templates.campaigns[%d] needs connection_note_file, follow_up_file or endorse_skills", i)
+		}
+		if n := cp.EndorseSkills; n != nil && (*n < 0 || *n > 2) {
+			return fmt.Errorf("templates.campaigns[%d].endorse_skills must be between 0 and 2", i)
+		}
+	}
+	if cfg.Jobs.MaxAttempts <= 0 || cfg.Jobs.BackoffMinutes <= 0 || cfg.Jobs.StaleMinutes <= 0 {
+		return errors.New("jobs.max_attempts, jobs.backoff_minutes and jobs.stale_minutes must be > 0")
+	}
+	if cfg.Jobs.MaxRetries < 0 {
+		return errors.New("jobs.max_retries must be >= 0")
+	}
+	if cfg.KeepAlive.MinMinutes <= 0 || cfg.KeepAlive.MaxMinutes < cfg.KeepAlive.MinMinutes {
+		return errors.New("keep_alive.min_minutes must be > 0 and max_minutes >= min_minutes")
+	}
+	for key, p := range map[string]float64{
+		"session.feed_probability":          cfg.Session.FeedProbability,
+		"session.notifications_probability": cfg.Session.NotificationsProbability,
+		"session.feed_return_probability":   cfg.Session.FeedReturnProbability,
+	} {
+		if p < 0 || p > 1 {
+			return fmt.Errorf("%s must be between 0 and 1", key)
+		}
+	}
+	if cfg.Session.FeedScrolls <= 0 {
+		return errors.New("session.feed_scrolls must be > 0")
+	}
+	if cfg.RunAll.AcceptanceCheckDelaySec < 0 {
+		return errors.New("run_all.acceptance_check_delay_sec must be >= 0")
+	}
+	for key, spec := range map[string]string{
+		"daemon.search_cron":              cfg.Daemon.SearchCron,
+		"daemon.connect_cron":             cfg.Daemon.ConnectCron,
+		"daemon.message_cron":             cfg.Daemon.MessageCron,
+		"daemon.nurture_cron":             cfg.Daemon.NurtureCron,
+		"daemon.worker_cron":              cfg.Daemon.WorkerCron,
+		"daemon.keep_alive_cron":          cfg.Daemon.KeepAliveCron,
+		"notifications.email.digest_cron": cfg.Notifications.Email.DigestCron,
+		"crm.sync_cron":                   cfg.CRM.SyncCron,
+		"sheets.sync_cron":                cfg.Sheets.SyncCron,
+	} {
+		if spec == "" {
+			continue
+		}
+		if _, err := cron.ParseStandard(spec); err != nil {
+			return fmt.Errorf("%s: invalid cron expression %q: %w", key, spec, err)
+		}
+	}
+	for i, wh := range cfg.Notifications.Webhooks {
+		if u, err := url.Parse(wh.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("notifications.webhooks[%d].url must be an http(s) URL", i)
+		}
+		for _, e := range wh.Events {
+			if !slices.Contains(notifyEvents, e) {
+				return fmt.Errorf("notifications.webhooks[%d]: unknown event %q", i, e)
+			}
+		}
+	}
+	if em := cfg.Notifications.Email; em.Host != "" {
+		if em.Port <= 0 {
+			return errors.New("notifications.email.port must be > 0")
+		}
+		if _, err := mail.ParseAddress(em.From); err != nil {
+			return fmt.Errorf("notifications.email.from: %w", err)
+		}
+		if len(em.To) == 0 {
+			return errors.New("notifications.email.to needs at least one address")
+		}
+		for _, to := range em.To {
+			if _, err := mail.ParseAddress(to); err != nil {
+				return fmt.Errorf("notifications.email.to %q: %w", to, err)
+			}
+		}
+		for _, e := range em.Events {
+			if !slices.Contains(notifyEvents, e) {
+				return fmt.Errorf("notifications.email: unknown event %q", e)
+			}
+		}
+	}
+	if tg := cfg.Notifications.Telegram; tg.ChatID != 0 {
+		if tg.TokenEnv == "" {
+			return errors.New("notifications.telegram.token_env is required")
+		}
+		for _, e := range tg.Events {
+			if !slices.Contains(notifyEvents, e) {
+				return fmt.Errorf("notifications.telegram: unknown event %q", e)
+			}
+		}
+	}
+	if cfg.Notifications.TimeoutSec <= 0 {
+		return errors.New("notifications.timeout_sec must be > 0")
+	}
+	if cfg.Notifications.MaxRetries < 0 {
+		return errors.New("notifications.max_retries must be >= 0")
+	}
+	if cfg.Daemon.ConfigReloadSec < 0 {
+		return errors.New("daemon.config_reload_sec must be >= 0")
+	}
+	if cfg.Daemon.DashboardAddr != "" {
+		if _, _, err := net.SplitHostPort(cfg.Daemon.DashboardAddr); err != nil {
+			return fmt.Errorf("daemon.dashboard_addr: %w", err)
+		}
+	}
+	for key, n := range map[string]int{
+		"limits.max_connections_per_hour": cfg.Limits.MaxConnectionsPerHour,
+		"limits.max_connections_per_week": cfg.Limits.MaxConnectionsPerWeek,
+		"limits.max_messages_per_hour":    cfg.Limits.MaxMessagesPerHour,
+		"limits.max_messages_per_week":    cfg.Limits.MaxMessagesPerWeek,
+	} {
+		if n < 0 {
+			return fmt.Errorf("%s must be >= 0 (0 disables)", key)
+		}
+	}
+	if r := cfg.Stealth.TypoRate; r < 0 || r > 0.2 {
+		return fmt.Errorf("stealth.typo_rate must be between 0 and 0.2, got %g", r)
+	}
+	if p := cfg.Stealth.BreakProbability; p < 0 || p > 1 {
+		return fmt.Errorf("stealth.break_probability must be between 0 and 1, got %g", p)
+	}
+	if si := cfg.Stealth.ScrollIntensity; si <= 0 || si > 3 {
+		return fmt.Errorf("stealth.scroll_intensity must be above 0 and at most 3, got %g", si)
+	}
+	if cfg.Stealth.ReadingWPM <= 0 {
+		return fmt.Errorf("stealth.reading_wpm must be > 0")
+	}
+	if cfg.Stealth.ReadMinSeconds < 0 || cfg.Stealth.ReadMaxSeconds < cfg.Stealth.ReadMinSeconds {
+		return fmt.Errorf("stealth.read_min_seconds must be >= 0 and <= read_max_seconds")
+	}
+	if m := cfg.Stealth.TypingMode; m != "input" && m != "keys" {
+		return fmt.Errorf("stealth.typing_mode must be input or keys, got %q", m)
+	}
+	if tz := cfg.Stealth.Timezone; tz != "" {
+		loc, err := time.LoadLocation(tz)
+		if err != nil {
+			return fmt.Errorf("stealth.timezone: %w", err)
+		}
+		cfg.loc = loc
+	}
+	if _, _, err := parseWindow(cfg.Stealth.ActiveStart + "-" + cfg.Stealth.ActiveEnd); err != nil {
+		return fmt.Errorf("stealth.active_start/active_end: %w", err)
+	}
+	days := make(map[string]string, len(cfg.Stealth.ActiveDays))
+	for day, w := range cfg.Stealth.ActiveDays {
+		day = strings.ToLower(strings.TrimSpace(day))
+		if !slices.Contains(weekdayKeys, day) {
+			return fmt.Errorf("stealth.active_days: unknown day %q, expected mon, tue, ... sun", day)
+		}
+		w = strings.ToLower(strings.TrimSpace(w))
+		if w != "off" {
+			if _, _, err := parseWindow(w); err != nil {
+				return fmt.Errorf("stealth.active_days.%s: %w", day, err)
+			}
+		}
+		days[day] = w
+	}
+	cfg.Stealth.ActiveDays = days
+	for _, h := range cfg.Stealth.Holidays {
+		if _, err := time.Parse("2006-01-02", h); err != nil {
+			if _, err := time.Parse("01-02", h); err != nil {
+				return fmt.Errorf("stealth.holidays: %q is not a date (2026-12-24) or a yearly day (12-25)", h)
+			}
+		}
+	}
+	if cfg.Limits.Schedule.MaxBurst < 1 {
+		return errors.New("limits.schedule.max_burst must be >= 1")
+	}
+	if cfg.Limits.WarmUp.Enabled {
+		prev := 0
+		for i, st := range cfg.Limits.WarmUp.Ramp {
+			if st.Week <= prev {
+				return fmt.Errorf("limits.warm_up.ramp[%d].week must be > 0 and increasing", i)
+			}
+			if st.MaxConnectionsPerDay <= 0 || st.MaxMessagesPerDay <= 0 {
+				return fmt.Errorf("limits.warm_up.ramp[%d] limits must be > 0", i)
+			}
+			prev = st.Week
+		}
+	}
 	if cfg.Limits.MaxProfilesPerSearch <= 0 {
 		return errors.New("limits.max_profiles_per_search must be > 0")
 	}
-	if os.Getenv("LINKEDIN_EMAIL") == "" {
-		return errors.New("LINKEDIN_EMAIL is required in env")
+	return nil
+}
+
+// loadCampaigns reads the template files campaigns name into their
+// ConnectionNote and FollowUp.
+func loadCampaigns(cfg *Config) error {
+	for i := range cfg.Templates.Campaigns {
+		cp := &cfg.Templates.Campaigns[i]
+		for _, f := range []struct {
+			path string
+			dst  *string
+		}{{cp.ConnectionNoteFile, &cp.ConnectionNote}, {cp.FollowUpFile, &cp.FollowUp}} {
+			if f.path == "" {
+				continue
+			}
+			b, err := os.ReadFile(f.path)
+			if err != nil {
+				return fmt.Errorf("templates.campaigns[%d]: %w", i, err)
+			}
+			*f.dst = strings.TrimRight(string(b), "\r\n")
+		}
 	}
-	if os.Getenv("LINKEDIN_PASSWORD") == "" {
-		return errors.New("LINKEDIN_PASSWORD is required in env")
+	return nil
+}
+
+// validateProxy checks a proxy URL. Chrome cannot authenticate to SOCKS
+// proxies, so credentials are only accepted for http(s).
+func validateProxy(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("invalid proxy %q: expected scheme://[user:pass@]host:port", raw)
+	}
+	switch u.Scheme {
+	case "http", "https":
+	case "socks5", "socks4":
+		if u.User != nil {
+			return fmt.Errorf("proxy %s: Chrome does not support SOCKS proxy authentication", u.Host)
+		}
+	default:
+		return fmt.Errorf("proxy %s: unsupported scheme %q (http, https, socks5, socks4)", u.Host, u.Scheme)
 	}
 	return nil
 }
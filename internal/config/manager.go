@@ -0,0 +1,149 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Manager loads the config the way every command sees it and keeps it
+// current while the daemon runs. Settings are layered, later ones winning:
+//
+//  1. the built-in defaults
+//  2. the config file, then the --account entry of accounts
+//  3. environment variables (LINKEDBOT_*)
+//  4. command-line flags, applied by the flags func
+//
+// Reload publishes a new Config with the limits and templates of a changed
+// file; other sections only change on restart. A published Config is never
+// written to again, so it can be read without locking.
+type Manager struct {
+	path    string
+	account string
+	flags   func(*Config)
+	cfg     atomic.Pointer[Config]
+	mu      sync.Mutex
+	// stamp is the modification times of the files last loaded
+	stamp string
+}
+
+// Reloadable lists the sections Reload applies to the running config.
+var Reloadable = []string{"limits", "templates"}
+
+// NewManager loads the config at path for account and applies flags on top.
+func NewManager(path, account string, flags func(*Config)) (*Manager, error) {
+	m := &Manager{path: path, account: account, flags: flags}
+	cfg, err := m.load()
+	if err != nil {
+		return nil, err
+	}
+	m.cfg.Store(cfg)
+	m.stamp = m.files(cfg)
+	return m, nil
+}
+
+// Path is the config file the manager reads.
+func (m *Manager) Path() string { return m.path }
+
+// Config returns the current config. Code that outlives a reload, such as a
+// daemon job or a dashboard request, should call it again rather than keep
+// the pointer.
+func (m *Manager) Config() *Config { return m.cfg.Load() }
+
+func (m *Manager) load() (*Config, error) {
+	cfg, err := Load(m.path, m.account)
+	if err != nil {
+		return nil, err
+	}
+	if m.flags != nil {
+		m.flags(cfg)
+	}
+	return cfg, nil
+}
+
+// Changed reports whether the config file or a campaign template file was
+// modified since the last load.
+func (m *Manager) Changed() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.files(m.cfg.Load()) != m.stamp
+}
+
+// files returns the modification times of the config file and the template
+// files it names; a missing file counts as time zero.
+func (m *Manager) files(cfg *Config) string {
+	paths := []string{m.path}
+	for _, cp := range cfg.Templates.Campaigns {
+		paths = append(paths, cp.ConnectionNoteFile, cp.FollowUpFile)
+	}
+	var b strings.Builder
+	for _, p := range paths {
+		if p == "" {
+			continue
+		}
+		var mod time.Time
+		if fi, err := os.Stat(p); err == nil {
+			mod = fi.ModTime()
+		}
+		fmt.Fprintf(&b, "%s=%d;", p, mod.UnixNano())
+	}
+	return b.String()
+}
+
+// Reload reads the files again and publishes a copy of the running config
+// with the Reloadable sections that changed, returning their names. Changes
+// to other sections are returned as restart and left alone. An invalid file
+// leaves the config as it was until the next change.
+func (m *Manager) Reload() (applied, restart []string, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	old := m.cfg.Load()
+	m.stamp = m.files(old)
+	next, err := m.load()
+	if err != nil {
+		return nil, nil, err
+	}
+	cfg := *old
+	cur := reflect.ValueOf(&cfg).Elem()
+	nv := reflect.ValueOf(next).Elem()
+	t := cur.Type()
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("yaml"), ",")
+		if name == "" || name == "-" || reflect.DeepEqual(cur.Field(i).Interface(), nv.Field(i).Interface()) {
+			continue
+		}
+		if !slices.Contains(Reloadable, name) {
+			restart = append(restart, name)
+			continue
+		}
+		cur.Field(i).Set(nv.Field(i))
+		applied = append(applied, name)
+	}
+	if len(applied) > 0 {
+		m.cfg.Store(&cfg)
+	}
+	return applied, restart, nil
+}
+
+// Watch calls reload whenever Changed turns true, checking every interval,
+// until ctx ends. reload is expected to call Reload.
+func (m *Manager) Watch(ctx context.Context, interval time.Duration, reload func()) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			if m.Changed() {
+				reload()
+			}
+		}
+	}
+}
@@ -2,325 +2,788 @@ package connection
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"slices"
 	"strings"
 	"time"
+	"unicode/utf8"
 
+	"github.com/example/linkedbot/internal/audit"
 	"github.com/example/linkedbot/internal/browser"
 	"github.com/example/linkedbot/internal/config"
+	"github.com/example/linkedbot/internal/driver"
+	"github.com/example/linkedbot/internal/emaillookup"
+	"github.com/example/linkedbot/internal/exclusion"
+	"github.com/example/linkedbot/internal/extract"
+	"github.com/example/linkedbot/internal/llm"
 	"github.com/example/linkedbot/internal/logging"
 	"github.com/example/linkedbot/internal/models"
+	"github.com/example/linkedbot/internal/notify"
+	"github.com/example/linkedbot/internal/ratelimit"
+	"github.com/example/linkedbot/internal/retry"
+	"github.com/example/linkedbot/internal/selectors"
 	"github.com/example/linkedbot/internal/stealth"
 	"github.com/example/linkedbot/internal/store"
-	"github.com/go-rod/rod"
+	"github.com/example/linkedbot/internal/templates"
+	"github.com/example/linkedbot/internal/tracing"
+	"github.com/example/linkedbot/internal/worker"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type Service struct {
 	br  *browser.Browser
 	cfg *config.Config
 	st  *store.Store
+	ex  *extract.Extractor
+	nt  *notify.Notifier
+	au  *audit.Recorder
+	rl  *ratelimit.Limiter
+	rt  *retry.Retrier
+	sel *selectors.Registry
+	xl  *exclusion.List
+	em  *emaillookup.Finder
+	ai  *llm.Client
+	hu  *stealth.Human
 	log *logging.Logger
 }
 
+// errExcluded is returned by sendOne for a profile that turned out to match
+// an exclusion, or to be outside connection.degrees, once its page was read.
+var errExcluded = errors.New("profile is excluded")
+
+// errAlreadyConnected is returned by sendOne for a profile that is already a
+// 1st-degree connection.
+var errAlreadyConnected = errors.New("already connected")
+
+// errRequiresEmail is returned by sendOne when LinkedIn asked for the
+// member's email and none could be looked up.
+var errRequiresEmail = errors.New("invite requires the member's email")
+
+// errInviteLimit is returned by sendOne when LinkedIn showed its weekly
+// invitation limit instead of sending the invite.
+var errInviteLimit = errors.New("LinkedIn weekly invitation limit reached")
+
+// errInviteRefused is returned by sendOne when LinkedIn answered Send with
+// an error.
+var errInviteRefused = errors.New("LinkedIn refused the invite")
+
+// errInviteUnconfirmed is returned by sendOne when neither a confirmation
+// nor an error showed after Send. The retry finds the invite pending if it
+// went out after all.
+var errInviteUnconfirmed = errors.New("invite not confirmed")
+
+// errMessageOnly ends the Connect button search on a profile that offers
+// Message but no way to connect.
+var errMessageOnly = errors.New("message button without connect option")
+
 func New(br *browser.Browser, cfg *config.Config, st *store.Store) *Service {
-	return &Service{br: br, cfg: cfg, st: st, log: logging.New(cfg.Logging.Level).With("module", "connection")}
+	return &Service{br: br, cfg: cfg, st: st, ex: extract.New(cfg, br.Selectors), nt: notify.New(cfg), au: audit.New(cfg, st), rl: ratelimit.New(cfg, st), rt: retry.New(cfg), sel: br.Selectors, em: emaillookup.New(cfg), ai: llm.New(cfg, st), hu: stealth.New(cfg), log: logging.New(cfg.Logging.Level).With("module", "connection")}
 }
 
-func (s *Service) SendConnections(ctx context.Context, limit int) (int, error) {
+// SendConnections queues invites for the next profiles in the queue and
+// works through the due connect jobs, including retries of earlier failures
+// and jobs left by an interrupted run.
+func (s *Service) SendConnections(ctx context.Context, limit int) (models.RunStats, error) {
+	var stats models.RunStats
 	if limit <= 0 {
 		limit = s.cfg.Limits.MaxConnectionsPerDay
 	}
-	// respect daily cap
-	today, err := s.st.CountActionsToday(ctx, "profiles", "")
-	if err == nil && today >= s.cfg.Limits.MaxConnectionsPerDay {
-		s.log.Info("daily connection cap reached", "count", today)
-		return 0, nil
+	// respect daily and weekly caps
+	budget, err := s.rl.Remaining(ctx, ratelimit.Connection)
+	if err != nil {
+		return stats, err
+	}
+	if budget.Left() == 0 {
+		s.log.Info("connection cap reached", "budget", budget.String())
+		return stats, nil
+	}
+	cooldown, err := s.st.GetInviteCooldown(ctx)
+	if err != nil {
+		return stats, err
+	}
+	if cooldown.Active(time.Now()) {
+		s.log.Info("invites paused after the weekly invitation limit", "detected_at", cooldown.DetectedAt.Local().Format("2006-01-02 15:04"), "until", cooldown.Until.Local().Format("2006-01-02 15:04"))
+		return stats, nil
 	}
 	toSend := limit
-	if capLeft := s.cfg.Limits.MaxConnectionsPerDay - today; toSend > capLeft {
-		toSend = capLeft
+	if left := budget.Left(); left >= 0 && toSend > left {
+		toSend = left
 	}
 
-	profiles, err := s.st.GetProfilesNeedingConnection(ctx, toSend)
+	h, err := s.ConnectHandler(ctx)
 	if err != nil {
-		return 0, err
+		return stats, err
 	}
-
-	s.log.Info("profiles to connect with", "count", len(profiles))
-	if len(profiles) == 0 {
-		return 0, nil
+	approval := s.cfg.Connection.RequireApproval
+	if approval {
+		// Approved invites are queued like any other
+		if _, err := s.queueApproved(ctx, toSend); err != nil {
+			return stats, err
+		}
+	}
+	// Jobs already due are worked off before new profiles are queued
+	due, err := s.st.CountDueJobs(ctx, store.JobConnect, time.Now())
+	if err != nil {
+		return stats, err
+	}
+	if due > 0 {
+		s.log.Info("connect jobs pending from earlier runs", "due", due)
+	}
+	want := toSend - due
+	if approval {
+		// Notes still waiting for review count against what is staged
+		counts, err := s.st.CountApprovals(ctx)
+		if err != nil {
+			return stats, err
+		}
+		want -= counts[store.ApprovalPending]
+	}
+	if want > 0 {
+		filter := store.QueueFilter{ExcludeSources: s.pausedSources(ctx), WithEmailRequired: s.em.Enabled(), Tags: s.cfg.Connection.Tags, WithoutJob: store.JobConnect, WithoutApproval: approval}
+		if days := s.cfg.Connection.WarmViewDays; days > 0 {
+			filter.ViewedBefore = time.Now().AddDate(0, 0, -days)
+		}
+		profiles, err := s.queue(ctx, want, filter)
+		if err != nil {
+			return stats, err
+		}
+		if approval {
+			staged, err := s.stage(ctx, profiles)
+			if err != nil {
+				return stats, err
+			}
+			if staged > 0 {
+				s.log.Info("invites staged for review, approve them with `linkedbot review`", "count", staged)
+			}
+			profiles = nil
+		}
+		if len(profiles) > 0 {
+			s.log.Info("profiles to connect with", "count", len(profiles))
+		}
+		for _, prof := range profiles {
+			if err := s.st.MarkQueued(ctx, prof.ID); err != nil {
+				s.log.Warn("failed to queue profile", "url", prof.LinkedInURL, "err", err)
+			}
+			added, err := s.st.EnqueueJob(ctx, store.JobConnect, prof.ID, "", s.cfg.Jobs.MaxAttempts)
+			if err != nil {
+				return stats, err
+			}
+			if added {
+				due++
+			}
+		}
+	}
+	if due == 0 {
+		if s.cfg.Connection.WarmViewDays > 0 {
+			s.log.Info("no profiles viewed long enough ago, run warm-view to queue more", "warm_view_days", s.cfg.Connection.WarmViewDays)
+		}
+		return stats, nil
 	}
 
 	// Check active window once at the start
-	if !stealth.InActiveWindow(s.cfg.Stealth.ActiveStart, s.cfg.Stealth.ActiveEnd) {
+	if now := time.Now(); !s.cfg.InActiveWindow(now) {
 		s.log.Warn("currently outside configured active window",
-			"active_hours", fmt.Sprintf("%s-%s", s.cfg.Stealth.ActiveStart, s.cfg.Stealth.ActiveEnd),
-			"current_time", time.Now().Format("15:04"))
+			"active_hours", s.cfg.ActiveHours(now),
+			"current_time", now.In(s.cfg.Location()).Format("Mon 15:04"))
 		s.log.Info("continuing anyway - to enforce active hours, run during the configured window or update config.yaml")
 	}
 
-	p, err := s.br.NewPage(ctx)
+	w := worker.New(s.br, s.cfg, s.st)
+	w.Handle(store.JobConnect, h)
+	stats, err = w.Run(ctx, toSend, store.JobConnect)
+	if err != nil {
+		return stats, err
+	}
+	// Only the run that hits the cap notifies, not every run after it
+	if stats.Sent > 0 && budget.Used+stats.Sent >= budget.Limit && budget.Limit > 0 {
+		s.nt.Notify(context.WithoutCancel(ctx), notify.EventDailyCapReached, fmt.Sprintf("Connection cap reached (%s limit of %d)", budget.Window, budget.Limit), "")
+	}
+	return stats, nil
+}
+
+// ConnectHandler returns the worker handler that sends the invite of a
+// connect job. Profiles that left the queue since, turned out to be
+// excluded or connected, or need an email nobody can supply are skipped.
+func (s *Service) ConnectHandler(ctx context.Context) (worker.Handler, error) {
+	xl, err := exclusion.Load(ctx, s.cfg, s.st)
+	if err != nil {
+		return worker.Handler{}, err
+	}
+	s.xl = xl
+	s.warnLongNotes()
+	return worker.Handler{Limit: ratelimit.Connection, Run: func(ctx context.Context, p driver.Page, job *store.Job, prof *models.Profile) error {
+		if prof.Status != models.StatusDiscovered && prof.Status != models.StatusQueued {
+			return fmt.Errorf("%w: profile is %s", worker.ErrSkip, prof.Status)
+		}
+		var approved *string
+		if s.cfg.Connection.RequireApproval {
+			note, ok, err := s.st.ApprovedNote(ctx, prof.ID)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				return fmt.Errorf("%w: invite not approved", worker.ErrSkip)
+			}
+			approved = &note
+		}
+		err := s.sendOne(ctx, p, prof, approved)
+		switch {
+		case errors.Is(err, errExcluded) || errors.Is(err, errAlreadyConnected) || errors.Is(err, errRequiresEmail):
+			return fmt.Errorf("%w: %w", worker.ErrSkip, err)
+		case errors.Is(err, errInviteLimit):
+			s.startInviteCooldown(ctx)
+			return fmt.Errorf("%w: %w", worker.ErrStop, worker.Fail(worker.ReasonRateLimited, err))
+		case errors.Is(err, errInviteRefused):
+			return fmt.Errorf("%w: %w", worker.ErrStop, worker.Fail(worker.ReasonRateLimited, err))
+		}
+		return err
+	}}, nil
+}
+
+// startInviteCooldown holds invites for connection.limit_cooldown_hours
+// after LinkedIn showed its weekly invitation limit, and notifies.
+func (s *Service) startInviteCooldown(ctx context.Context) {
+	ctx = context.WithoutCancel(ctx)
+	now := time.Now()
+	until := now.Add(time.Duration(s.cfg.Connection.LimitCooldownHours) * time.Hour)
+	if err := s.st.SetInviteCooldown(ctx, now, until); err != nil {
+		s.log.Error("failed to save invite cooldown", "err", err)
+	}
+	s.log.Warn("invites paused", "reason", errInviteLimit, "until", until.Local().Format("2006-01-02 15:04"))
+	s.nt.Notify(ctx, notify.EventInviteLimit, fmt.Sprintf("LinkedIn weekly invitation limit reached. Invites are paused until %s; other actions go on.", until.Local().Format("2006-01-02 15:04")), "")
+}
+
+// queueApproved queues a connect job for up to limit approved invites of
+// profiles still in the queue and returns how many were added.
+func (s *Service) queueApproved(ctx context.Context, limit int) (int, error) {
+	ids, err := s.st.ApprovedInQueue(ctx, limit)
 	if err != nil {
 		return 0, err
 	}
-	defer p.Close()
-	sent := 0
-	for _, prof := range profiles {
-		s.log.Info("processing profile", "url", prof.LinkedInURL)
-		if err := s.sendOne(ctx, p, &prof); err != nil {
-			s.log.Warn("send connection failed", "url", prof.LinkedInURL, "err", err)
-			continue
+	added := 0
+	for _, id := range ids {
+		if err := s.st.MarkQueued(ctx, id); err != nil {
+			s.log.Warn("failed to queue profile", "profile", id, "err", err)
+		}
+		ok, err := s.st.EnqueueJob(ctx, store.JobConnect, id, "", s.cfg.Jobs.MaxAttempts)
+		if err != nil {
+			return added, err
 		}
-		sent++
-		stealth.SleepRandom(s.cfg.Stealth.MinDelayMs+300, s.cfg.Stealth.MaxDelayMs+900)
+		if ok {
+			added++
+		}
+	}
+	if added > 0 {
+		s.log.Info("approved invites queued", "count", added)
 	}
-	return sent, nil
+	return added, nil
 }
 
-func (s *Service) sendOne(ctx context.Context, p *rod.Page, prof *models.Profile) error {
-	if err := p.Navigate(prof.LinkedInURL); err != nil {
-		return err
+// stage renders the note of each profile from what is stored about it and
+// keeps it for review instead of queueing the invite. It returns how many
+// were staged.
+func (s *Service) stage(ctx context.Context, profiles []models.Profile) (int, error) {
+	staged := 0
+	for i := range profiles {
+		prof := &profiles[i]
+		note, err := s.note(ctx, prof)
+		if err != nil {
+			return staged, err
+		}
+		added, err := s.st.StageApproval(ctx, prof.ID, note)
+		if err != nil {
+			return staged, err
+		}
+		if added {
+			staged++
+		}
+	}
+	return staged, nil
+}
+
+// note renders the connection note for prof, or has the LLM write it with
+// llm.notes on. A failed or capped generation falls back to the template.
+func (s *Service) note(ctx context.Context, prof *models.Profile) (string, error) {
+	note, err := templates.Render(s.cfg.ConnectionNoteFor(prof.Source), prof, s.cfg.Templates.TitleCleanup)
+	if err != nil {
+		return "", fmt.Errorf("render connection note: %w", err)
+	}
+	note = s.fit(prof, note)
+	if !s.ai.Enabled(llm.KindNote) {
+		return note, nil
+	}
+	text, err := s.ai.Generate(ctx, llm.KindNote, prof, note)
+	if err != nil {
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		s.log.Warn("llm note failed, using the template", "url", prof.LinkedInURL, "err", err)
+		return note, nil
+	}
+	return text, nil
+}
+
+// fit holds a note to templates.NoteLimit characters, cutting it at a word
+// boundary.
+func (s *Service) fit(prof *models.Profile, note string) string {
+	fitted, cut := templates.Fit(note, templates.NoteLimit)
+	if cut {
+		s.log.Warn("connection note too long, cut at a word", "url", prof.LinkedInURL, "chars", utf8.RuneCountInString(note), "max", templates.NoteLimit)
+	}
+	return fitted
+}
+
+// warnLongNotes warns about connection note templates that render past
+// templates.NoteLimit with long names, titles or companies, so the cut
+// doesn't come as a surprise.
+func (s *Service) warnLongNotes() {
+	check := func(name, text string) {
+		if n, err := templates.MaxLength(text); err == nil && n > templates.NoteLimit {
+			s.log.Warn("connection note template may run over the limit and be cut", "template", name, "chars", n, "max", templates.NoteLimit)
+		}
+	}
+	check("connection_note_template", s.cfg.Templates.ConnectionNote)
+	for _, cp := range s.cfg.Templates.Campaigns {
+		if cp.ConnectionNoteFile != "" {
+			check(cp.ConnectionNoteFile, cp.ConnectionNote)
+		}
+	}
+}
+
+// alreadyConnected records prof as an existing contact instead of inviting it.
+func (s *Service) alreadyConnected(ctx context.Context, prof *models.Profile, signal string) error {
+	s.log.Info("already connected, not inviting", "url", prof.LinkedInURL, "signal", signal)
+	if _, err := s.st.MarkAlreadyConnected(ctx, prof); err != nil {
+		s.log.Warn("failed to record existing connection", "url", prof.LinkedInURL, "err", err)
+	}
+	return errAlreadyConnected
+}
+
+// pausedSources returns the sources left out of the queue by
+// connection.auto_pause_bad_sources.
+func (s *Service) pausedSources(ctx context.Context) []string {
+	if !s.cfg.Connection.AutoPauseBadSources {
+		return nil
+	}
+	stats, err := s.st.AcceptanceRateBySource(ctx)
+	if err != nil {
+		s.log.Warn("failed to load source acceptance rates", "err", err)
+	}
+	var paused []string
+	for _, st := range badSources(stats, s.cfg.Connection.BadSourceMinSample, s.cfg.Connection.BadSourceMinAcceptRate) {
+		s.log.Warn("source paused for low acceptance rate", "source", st.Source, "sent", st.Sent, "accepted", st.Accepted, "rate", fmt.Sprintf("%.2f", st.Rate()))
+		paused = append(paused, st.Source)
+	}
+	return paused
+}
+
+// queue returns up to n profiles to invite, leaving out excluded ones and
+// those outside connection.degrees. The skipped stay in the queue, so the
+// query is widened until n are found or the queue runs out.
+func (s *Service) queue(ctx context.Context, n int, f store.QueueFilter) ([]models.Profile, error) {
+	for fetch := n; ; {
+		var profiles []models.Profile
+		var err error
+		if s.cfg.Connection.QueueStrategy == "round_robin" {
+			profiles, err = s.st.GetProfilesNeedingConnectionRoundRobin(ctx, fetch, f)
+		} else {
+			profiles, err = s.st.GetProfilesNeedingConnection(ctx, fetch, f)
+		}
+		if err != nil {
+			return nil, err
+		}
+		kept := profiles[:0]
+		for _, prof := range profiles {
+			if reason, ok := s.xl.Match(&prof); ok {
+				s.log.Debug("skipping excluded profile", "url", prof.LinkedInURL, "reason", reason)
+				continue
+			}
+			// Unknown degrees are checked on the profile page
+			if prof.Degree != "" && !s.degreeAllowed(prof.Degree) {
+				s.log.Debug("skipping profile outside target degrees", "url", prof.LinkedInURL, "degree", prof.Degree)
+				continue
+			}
+			kept = append(kept, prof)
+		}
+		if len(kept) >= n || len(profiles) < fetch {
+			return kept[:min(n, len(kept))], nil
+		}
+		fetch += len(profiles) - len(kept)
+	}
+}
+
+// followRedirect records a changed slug when the profile page opened under
+// another /in/ link. It reports false when that merged the profile into a
+// stored duplicate that is already past the invite.
+func (s *Service) followRedirect(ctx context.Context, p driver.Page, prof *models.Profile) bool {
+	u, err := p.URL()
+	if err != nil {
+		return true
+	}
+	old := prof.LinkedInURL
+	merged, err := s.st.FollowRedirect(ctx, prof, u)
+	if err != nil {
+		s.log.Warn("failed to record profile redirect", "url", old, "err", err)
+		return true
+	}
+	if prof.LinkedInURL != old {
+		s.log.Info("profile moved to a new URL", "old", old, "url", prof.LinkedInURL, "merged", merged)
 	}
-	if err := p.WaitLoad(); err != nil {
+	if merged && prof.Status != models.StatusDiscovered && prof.Status != models.StatusQueued {
+		s.log.Info("skipping profile merged into a duplicate", "url", prof.LinkedInURL, "status", prof.Status)
+		return false
+	}
+	return true
+}
+
+// unavailable marks a profile LinkedIn no longer shows as invalid and
+// returns browser.ErrProfileUnavailable.
+func (s *Service) unavailable(ctx context.Context, prof *models.Profile) error {
+	s.log.Info("profile not available, marking it invalid", "url", prof.LinkedInURL)
+	if err := s.st.MarkUnavailable(ctx, prof.ID); err != nil {
+		s.log.Warn("failed to mark profile invalid", "url", prof.LinkedInURL, "err", err)
+	}
+	return browser.ErrProfileUnavailable
+}
+
+// degreeAllowed reports whether connection.degrees allows inviting a profile
+// at distance degree.
+func (s *Service) degreeAllowed(degree string) bool {
+	return len(s.cfg.Connection.Degrees) == 0 || slices.Contains(s.cfg.Connection.Degrees, degree)
+}
+
+// badSources returns the sources whose acceptance rate is below minRate once
+// they have at least minSample sent invites. Smaller samples are too noisy to
+// judge and keep sending.
+func badSources(stats []store.SourceStats, minSample int, minRate float64) []store.SourceStats {
+	var out []store.SourceStats
+	for _, st := range stats {
+		if st.Sent >= minSample && st.Rate() < minRate {
+			out = append(out, st)
+		}
+	}
+	return out
+}
+
+func (s *Service) sendOne(ctx context.Context, p driver.Page, prof *models.Profile, approved *string) error {
+	if err := s.au.Do(ctx, p, prof, audit.ActionNavigate, prof.LinkedInURL, "", func() error { return browser.Navigate(ctx, s.rt, p, prof.LinkedInURL) }); err != nil {
 		return err
 	}
+	if s.br.ProfileUnavailable(p) {
+		return s.unavailable(ctx, prof)
+	}
+	if !s.followRedirect(ctx, p, prof) {
+		return errExcluded
+	}
 
 	// Wake up movement - visible mouse movement from edge to center
-	stealth.WakeUpMovement(p)
+	s.hu.WakeUpMovement(p)
 
 	// Additional idle movement for natural feel
-	stealth.MouseIdleMovement(p)
-	stealth.ThinkTime()
+	s.hu.MouseIdleMovement(p)
+	s.hu.Read(p)
 
-	stealth.ScrollHumanLike(p)
+	s.hu.ScrollHumanLike(p)
 	time.Sleep(1 * time.Second)
 
 	// Random hover over page elements to appear natural
-	stealth.RandomHover(p, []string{"h1", "div.pv-text-details__left-panel", "button"})
+	s.hu.RandomHover(p, s.sel.Get("profile.hover_targets").CSS())
 
 	// Extract profile information if not already present
 	if prof.Name == "" || prof.Headline == "" || prof.Company == "" {
 		s.log.Info("extracting profile information")
+		_, span := tracing.Start(ctx, "extract profile", tracing.Profile(prof.ID, prof.LinkedInURL)...)
 		s.extractProfileInfo(p, prof)
+		tracing.End(span, nil)
+	}
+	if reason, ok := s.xl.Match(prof); ok {
+		s.log.Info("skipping excluded profile", "url", prof.LinkedInURL, "reason", reason)
+		// Keep what was read so the queue filters it out next time
+		if _, err := s.st.UpsertProfile(ctx, prof); err != nil {
+			s.log.Warn("failed to save profile details", "url", prof.LinkedInURL, "err", err)
+		}
+		return errExcluded
+	}
+	// An existing contact shows the 1st-degree badge and has no Connect button
+	degree := s.ex.ConnectionDegree(p)
+	if degree == "1st" {
+		return s.alreadyConnected(ctx, prof, "badge")
+	}
+	if degree != "" && degree != prof.Degree {
+		prof.Degree = degree
+		if _, err := s.st.UpsertProfile(ctx, prof); err != nil {
+			s.log.Warn("failed to save connection degree", "url", prof.LinkedInURL, "err", err)
+		}
+	}
+	if degree != "" && !s.degreeAllowed(degree) {
+		s.log.Info("skipping profile outside target degrees", "url", prof.LinkedInURL, "degree", degree)
+		return errExcluded
+	}
+
+	// An invite that went out unconfirmed, or by hand, shows as Pending
+	if s.sel.Get("profile.pending_button").Has(p) {
+		s.log.Info("invite already pending, recording it as sent", "url", prof.LinkedInURL)
+		if err := s.st.MarkConnectionSent(ctx, prof.ID, ""); err != nil {
+			return fmt.Errorf("failed to mark connection sent: %w", err)
+		}
+		if approved != nil {
+			if err := s.st.MarkApprovalSent(ctx, prof.ID); err != nil {
+				s.log.Warn("failed to close approval", "url", prof.LinkedInURL, "err", err)
+			}
+		}
+		return nil
+	}
+
+	// Render before touching the invite dialog so a broken template costs
+	// no clicks; an approved note is sent as reviewed
+	var note string
+	if approved != nil {
+		note = *approved
+	} else {
+		rendered, err := s.note(ctx, prof)
+		if err != nil {
+			return err
+		}
+		note = rendered
 	}
 
 	// Visible mouse movement before looking for connect button
-	stealth.MouseIdleMovement(p)
+	s.hu.MouseIdleMovement(p)
 	stealth.SleepRandom(500, 1000)
 
-	// Find Connect button using multiple strategies
-	var connectBtn *rod.Element
-	var err error
-
-	// Strategy 1: Direct Connect button by aria-label
-	connectBtn, err = p.Timeout(5 * time.Second).Element(`button[aria-label*="Invite"][aria-label*="connect"]`)
-	if err != nil {
-		// Strategy 2: Connect button by text using ElementR
-		connectBtn, err = p.Timeout(5*time.Second).ElementR("button", "^Connect$")
-	}
-	if err != nil {
-		// Strategy 3: Try to find and click More button first
-		moreBtn, err2 := p.Timeout(3*time.Second).ElementR("button", "More")
-		if err2 == nil {
+	// Find the Connect button, retrying while the profile actions render
+	connectBtn, err := retry.First(ctx, s.rt, "find connect button",
+		func() (driver.Element, error) { return s.sel.Get("profile.connect_button").Find(p, 5*time.Second) },
+		func() (driver.Element, error) {
+			// Connect in the More dropdown, which may still be open from
+			// the previous attempt
+			menuConnect := s.sel.Get("profile.more_menu_connect")
+			if el, err := menuConnect.Find(p, time.Second); err == nil {
+				return el, nil
+			}
+			moreBtn, err := s.sel.Get("profile.more_button").Find(p, 3*time.Second)
+			if err != nil {
+				return nil, err
+			}
 			s.log.Info("clicking More button")
-			_ = stealth.ClickHumanLike(p, moreBtn)
+			_ = s.au.Do(ctx, p, prof, audit.ActionClick, "More button", "", func() error { return s.hu.ClickHumanLike(p, moreBtn) })
 			time.Sleep(800 * time.Millisecond)
-			// Now try to find Connect in dropdown
-			connectBtn, err = p.Timeout(5*time.Second).ElementR("div", "^Connect$")
-		}
+			return menuConnect.Find(p, 5*time.Second)
+		},
+		func() (driver.Element, error) {
+			// No badge, but Message without any Connect option means a
+			// contact: retrying won't make a Connect button appear
+			if s.sel.Get("profile.message_signal").Has(p) {
+				return nil, retry.Permanent(errMessageOnly)
+			}
+			return nil, errors.New("no Connect option on profile")
+		},
+	)
+	if errors.Is(err, errMessageOnly) {
+		return s.alreadyConnected(ctx, prof, "message_button")
 	}
-
 	if err != nil {
 		browser.ScreenshotOnError(p, "connect_button_fail", err)
 		return fmt.Errorf("connect button not found: %w", err)
 	}
 
 	s.log.Info("found connect button, clicking")
-	if err := stealth.ClickHumanLike(p, connectBtn); err != nil {
+	if err := s.au.Do(ctx, p, prof, audit.ActionClick, "Connect button", "", func() error { return s.hu.ClickHumanLike(p, connectBtn) }); err != nil {
 		return fmt.Errorf("failed to click connect: %w", err)
 	}
-	time.Sleep(1 * time.Second)
+	composeTimeout := time.Duration(s.cfg.Timeouts.ComposeReadyMs) * time.Millisecond
+
+	// Some invites must be vouched for with the member's email
+	if emailInput, err := s.sel.Get("connection.email_input").Find(p, 2*time.Second); err == nil {
+		if err := s.fillEmail(ctx, p, prof, emailInput); err != nil {
+			return err
+		}
+	}
 
-	// Try to add a note
-	addNoteBtn, err := p.Timeout(5*time.Second).ElementR("button", "Add a note")
+	// Try to add a note (the lookup itself waits for the invite dialog)
+	addNoteBtn, err := s.sel.Get("connection.add_note_button").Find(p, composeTimeout)
 	if err == nil {
 		s.log.Info("clicking Add a note")
-		_ = stealth.ClickHumanLike(p, addNoteBtn)
-		time.Sleep(800 * time.Millisecond)
+		_ = s.au.Do(ctx, p, prof, audit.ActionClick, "Add a note button", "", func() error { return s.hu.ClickHumanLike(p, addNoteBtn) })
 		// Visible movement after clicking
-		stealth.MouseIdleMovement(p)
+		s.hu.MouseIdleMovement(p)
 	} else {
 		s.log.Info("Add a note button not found, trying with default message")
 	}
 
-	// Type note if textarea available
-	note := renderTemplate(s.cfg.Templates.ConnectionNote, prof)
-	if len(note) > 280 {
-		note = note[:280]
-	}
+	// Approved notes may have been edited past the limit
+	note = s.fit(prof, note)
 
-	// Find textarea - use page default timeout for typing operations
-	// First check if it exists with a short timeout
-	_, err = p.Timeout(5 * time.Second).Element(`textarea[name="message"]`)
-	if err == nil {
-		// Re-acquire the element without custom timeout so it uses page's 180s default
-		textarea, err := p.Element(`textarea[name="message"]`)
-		if err == nil {
-			s.log.Info("typing note into textarea", "length", len(note))
-			if err := stealth.TypeHumanLike(textarea, note); err != nil {
-				return fmt.Errorf("failed to type note: %w", err)
-			}
-			s.log.Info("note typed successfully")
-		} else {
-			s.log.Warn("failed to re-acquire textarea", "err", err)
+	// Find textarea; it comes back without the lookup timeout so typing
+	// uses the page default
+	if textarea, err := s.sel.Get("connection.note_textarea").Find(p, composeTimeout); err == nil {
+		if err := browser.WaitFocusable(textarea, composeTimeout); err != nil {
+			return fmt.Errorf("note textarea not ready: %w", err)
 		}
+		s.log.Info("typing note into textarea", "chars", utf8.RuneCountInString(note))
+		if err := s.au.Do(ctx, p, prof, audit.ActionType, "connection note", note, func() error { return s.hu.TypeHumanLike(textarea, note) }); err != nil {
+			return fmt.Errorf("failed to type note: %w", err)
+		}
+		s.log.Info("note typed successfully")
 	} else {
 		s.log.Info("textarea not found, sending without custom note")
 	}
 
-	time.Sleep(1 * time.Second)
-
-	// Click Send button - use reasonable timeout
-	var sendBtn *rod.Element
-	sendBtn, err = p.Timeout(15*time.Second).ElementR("button", "Send")
-	if err != nil {
-		// Try alternative selector
-		sendBtn, err = p.Timeout(15 * time.Second).Element(`button[aria-label*="Send"]`)
-	}
+	// Click Send button
+	sendBtn, err := retry.Value(ctx, s.rt, "find send button", func() (driver.Element, error) {
+		return s.sel.Get("connection.send_button").Find(p, 15*time.Second)
+	})
 	if err != nil {
-		// Last resort - try finding Send button by inspecting all buttons
-		buttons, _ := p.Elements("button")
-		for _, btn := range buttons {
-			if text, _ := btn.Text(); text == "Send" || text == "Send invitation" {
-				sendBtn = btn
-				err = nil
-				break
-			}
-		}
-	}
-	if err != nil || sendBtn == nil {
 		browser.ScreenshotOnError(p, "send_button_fail", err)
 		return fmt.Errorf("send button not found: %w", err)
 	}
+	if err := browser.WaitEnabled(sendBtn, time.Duration(s.cfg.Timeouts.SendEnabledMs)*time.Millisecond); err != nil {
+		browser.ScreenshotOnError(p, "send_button_disabled", err)
+		return fmt.Errorf("send button never became enabled: %w", err)
+	}
 
 	// Visible movement before final send
-	stealth.MouseIdleMovement(p)
+	s.hu.MouseIdleMovement(p)
 	stealth.SleepRandom(300, 700)
 
-	s.log.Info("clicking send button")
-	if err := stealth.ClickHumanLike(p, sendBtn); err != nil {
-		return fmt.Errorf("failed to click send: %w", err)
+	sendCtx, span := tracing.Start(ctx, "send invite", append(tracing.Profile(prof.ID, prof.LinkedInURL), attribute.Int("note.length", utf8.RuneCountInString(note)))...)
+	err = s.submitInvite(sendCtx, p, prof, sendBtn)
+	if err == nil {
+		err = s.confirmInvite(sendCtx, p, prof)
+	}
+	tracing.End(span, err)
+	if err != nil {
+		return err
 	}
 
 	// Movement after sending
-	stealth.MouseIdleMovement(p)
-	time.Sleep(1 * time.Second)
+	s.hu.MouseIdleMovement(p)
 
 	// Mark as sent in database
 	if err := s.st.MarkConnectionSent(ctx, prof.ID, note); err != nil {
 		return fmt.Errorf("failed to mark connection sent: %w", err)
 	}
+	if approved != nil {
+		if err := s.st.MarkApprovalSent(ctx, prof.ID); err != nil {
+			s.log.Warn("failed to close approval", "url", prof.LinkedInURL, "err", err)
+		}
+	}
+	if prof.Email != "" {
+		if err := s.st.SaveEmail(ctx, prof.ID, prof.Email); err != nil {
+			s.log.Warn("failed to save email", "url", prof.LinkedInURL, "err", err)
+		}
+	}
 
 	s.log.Info("connection request sent successfully", "url", prof.LinkedInURL)
 	return nil
 }
 
-func (s *Service) extractProfileInfo(p *rod.Page, prof *models.Profile) {
-	// Extract name from h1 heading
-	if nameEl, err := p.Timeout(3 * time.Second).Element("h1"); err == nil {
-		if name, err := nameEl.Text(); err == nil {
-			prof.Name = strings.TrimSpace(name)
-			s.log.Info("extracted name", "name", prof.Name)
-		}
-	}
-
-	// Extract headline/title - usually in a div after the h1
-	// Try multiple selectors for headline
-	headlineSelectors := []string{
-		`div.text-body-medium`,
-		`div[class*="headline"]`,
-		`.pv-text-details__left-panel div:nth-child(2)`,
-	}
-
-	for _, sel := range headlineSelectors {
-		if headlineEl, err := p.Timeout(2 * time.Second).Element(sel); err == nil {
-			if headline, err := headlineEl.Text(); err == nil {
-				headline = strings.TrimSpace(headline)
-				// Make sure it's not the name
-				if headline != prof.Name && len(headline) > 0 {
-					prof.Headline = headline
-					s.log.Info("extracted headline", "headline", prof.Headline)
-					break
-				}
-			}
+// submitInvite clicks Send until the invite dialog closes, which happens
+// once LinkedIn accepted the request; it clicks again only while the Send
+// button is still on screen.
+func (s *Service) submitInvite(ctx context.Context, p driver.Page, prof *models.Profile, sendBtn driver.Element) error {
+	confirmTimeout := time.Duration(s.cfg.Timeouts.SendConfirmMs) * time.Millisecond
+	for attempt := 0; ; attempt++ {
+		s.log.Info("clicking send button", "attempt", attempt+1)
+		if err := s.au.Do(ctx, p, prof, audit.ActionClick, "Send invitation button", "", func() error { return s.hu.ClickHumanLike(p, sendBtn) }); err != nil {
+			return fmt.Errorf("failed to click send: %w", err)
+		}
+		if err := browser.WaitGone(sendBtn, confirmTimeout); err == nil {
+			return nil
 		}
+		// Clicking again won't get past a limit or an error
+		if err := s.inviteError(ctx, p, prof); err != nil {
+			return err
+		}
+		if attempt >= s.cfg.Timeouts.SendRetries {
+			browser.ScreenshotOnError(p, "send_not_confirmed", errors.New("invite dialog still open"))
+			return errors.New("invite dialog still open after clicking send")
+		}
+		s.log.Warn("send not confirmed, retrying", "attempt", attempt+1)
 	}
+}
 
-	// Extract company from the headline or experience section
-	// The company is often in the headline like "Software Engineer at Company"
-	if prof.Company == "" && prof.Headline != "" {
-		// Try to extract company from headline using "at" keyword
-		if idx := strings.Index(strings.ToLower(prof.Headline), " at "); idx >= 0 {
-			prof.Company = strings.TrimSpace(prof.Headline[idx+4:])
-			s.log.Info("extracted company from headline", "company", prof.Company)
+// confirmInvite waits up to timeouts.send_confirm_ms for LinkedIn's answer
+// to Send. The "Invitation sent" toast or a Pending button confirm the
+// invite; the weekly-limit modal or an error toast fail it.
+func (s *Service) confirmInvite(ctx context.Context, p driver.Page, prof *models.Profile) error {
+	deadline := time.Now().Add(time.Duration(s.cfg.Timeouts.SendConfirmMs) * time.Millisecond)
+	for {
+		if err := s.inviteError(ctx, p, prof); err != nil {
+			return err
 		}
+		if s.sel.Get("connection.sent_toast").Has(p) || s.sel.Get("profile.pending_button").Has(p) {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			browser.ScreenshotOnError(p, "invite_unconfirmed", errInviteUnconfirmed)
+			return errInviteUnconfirmed
+		}
+		time.Sleep(300 * time.Millisecond)
 	}
+}
 
-	// If we still don't have company, try the experience section
-	if prof.Company == "" {
-		if companyEl, err := p.Timeout(2 * time.Second).Element(`#experience ~ div span[aria-hidden="true"]`); err == nil {
-			if company, err := companyEl.Text(); err == nil {
-				prof.Company = strings.TrimSpace(company)
-				s.log.Info("extracted company from experience", "company", prof.Company)
-			}
+// inviteError returns errInviteLimit when the weekly-limit modal is up,
+// closing it, and errInviteRefused with the toast text on an error toast.
+func (s *Service) inviteError(ctx context.Context, p driver.Page, prof *models.Profile) error {
+	if s.sel.Get("connection.limit_modal").Has(p) {
+		browser.ScreenshotOnError(p, "invite_limit", errInviteLimit)
+		if btn, err := s.sel.Get("connection.dismiss_button").Find(p, 2*time.Second); err == nil {
+			_ = s.au.Do(ctx, p, prof, audit.ActionClick, "Dismiss limit dialog", "", func() error { return s.hu.ClickHumanLike(p, btn) })
+		} else {
+			_ = p.Press("Escape")
 		}
+		return errInviteLimit
 	}
-
-	// Update profile in database with extracted info
-	if prof.Name != "" || prof.Headline != "" || prof.Company != "" {
-		ctx := context.Background()
-		if _, err := s.st.UpsertProfile(ctx, prof); err != nil {
-			s.log.Warn("failed to update profile info", "err", err)
+	if s.sel.Get("connection.error_toast").Has(p) {
+		var text string
+		if el, err := s.sel.Get("connection.error_toast").Find(p, time.Second); err == nil {
+			text, _ = el.Text()
+		}
+		browser.ScreenshotOnError(p, "invite_refused", errInviteRefused)
+		if text = strings.TrimSpace(text); text != "" {
+			return fmt.Errorf("%w: %s", errInviteRefused, text)
 		}
+		return errInviteRefused
 	}
+	return nil
 }
 
-func renderTemplate(t string, p *models.Profile) string {
-	name := p.Name
-	company := p.Company
-	title := p.Headline
-
-	// Extract first name only for more personal touch
-	firstName := name
-	if idx := strings.Index(name, " "); idx > 0 {
-		firstName = name[:idx]
-	}
-
-	// Simplify long headlines - extract just the job title part
-	// Remove everything after @ or | symbols
-	if idx := strings.Index(title, "@"); idx > 0 {
-		title = strings.TrimSpace(title[:idx])
-	} else if idx := strings.Index(title, "|"); idx > 0 {
-		title = strings.TrimSpace(title[:idx])
-	} else if idx := strings.Index(title, " at "); idx > 0 {
-		// Handle "Software Engineer at Company" format
-		title = strings.TrimSpace(title[:idx])
+// fillEmail types the member's email into the invite dialog, looking it up
+// with connection.email_lookup. Without an address the dialog is dismissed,
+// the profile is marked requires_email and errRequiresEmail returned.
+func (s *Service) fillEmail(ctx context.Context, p driver.Page, prof *models.Profile, emailInput driver.Element) error {
+	s.log.Info("invite asks for the member's email", "url", prof.LinkedInURL)
+	email, err := "", errors.New("email lookup is not configured")
+	if s.em.Enabled() {
+		email, err = s.em.Find(ctx, prof)
 	}
-
-	// Limit title length to avoid exceeding message limits
-	if len(title) > 50 {
-		// Take first 50 chars and try to end at a word boundary
-		title = title[:50]
-		if idx := strings.LastIndex(title, " "); idx > 20 {
-			title = title[:idx]
+	if err != nil {
+		s.log.Info("skipping profile that requires an email", "url", prof.LinkedInURL, "reason", err)
+		if btn, err := s.sel.Get("connection.dismiss_button").Find(p, 2*time.Second); err == nil {
+			_ = s.au.Do(ctx, p, prof, audit.ActionClick, "Dismiss invite dialog", "", func() error { return s.hu.ClickHumanLike(p, btn) })
+		} else {
+			_ = p.Press("Escape")
+		}
+		if err := s.st.MarkRequiresEmail(ctx, prof.ID); err != nil {
+			s.log.Warn("failed to record email requirement", "url", prof.LinkedInURL, "err", err)
 		}
+		return errRequiresEmail
+	}
+	if err := s.au.Do(ctx, p, prof, audit.ActionType, "member email", email, func() error { return s.hu.TypeHumanLike(emailInput, email) }); err != nil {
+		return fmt.Errorf("failed to type email: %w", err)
 	}
+	prof.Email = email
+	return nil
+}
 
-	r := strings.NewReplacer(
-		"{{Name}}", firstName,
-		"{{Company}}", company,
-		"{{Title}}", title,
-		"{{Keywords}}", "",
-	)
-	return r.Replace(t)
+func (s *Service) extractProfileInfo(p driver.Page, prof *models.Profile) {
+	if !s.ex.ProfileInfo(p, prof) {
+		return
+	}
+	// Update profile in database with extracted info
+	if _, err := s.st.UpsertProfile(context.Background(), prof); err != nil {
+		s.log.Warn("failed to update profile info", "err", err)
+	}
 }
@@ -0,0 +1,105 @@
+package connection
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/example/linkedbot/internal/config"
+	"github.com/example/linkedbot/internal/logging"
+	"github.com/example/linkedbot/internal/models"
+	"github.com/example/linkedbot/internal/store"
+)
+
+func TestBadSources(t *testing.T) {
+	const minSample, minRate = 10, 0.2
+	cases := []struct {
+		stats  store.SourceStats
+		paused bool
+	}{
+		{store.SourceStats{Source: "too few sent", Sent: 9, Accepted: 0}, false},
+		{store.SourceStats{Source: "at sample, below rate", Sent: 10, Accepted: 1}, true},
+		{store.SourceStats{Source: "at sample, at rate", Sent: 10, Accepted: 2}, false},
+		{store.SourceStats{Source: "large, below rate", Sent: 200, Accepted: 39}, true},
+		{store.SourceStats{Source: "large, above rate", Sent: 200, Accepted: 90}, false},
+		{store.SourceStats{Source: "nothing sent", Sent: 0, Accepted: 0}, false},
+	}
+	for _, c := range cases {
+		got := badSources([]store.SourceStats{c.stats}, minSample, minRate)
+		if paused := len(got) == 1; paused != c.paused {
+			t.Errorf("%s (%d/%d): paused %v, want %v", c.stats.Source, c.stats.Accepted, c.stats.Sent, paused, c.paused)
+		}
+	}
+}
+
+func TestPausedSourcesLeaveQueue(t *testing.T) {
+	ctx := context.Background()
+	st, err := store.Open("sqlite", filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(st.Close)
+	if err := st.Migrate(ctx); err != nil {
+		t.Fatal(err)
+	}
+	add := func(source string, i int) int64 {
+		t.Helper()
+		url := fmt.Sprintf("https://www.linkedin.com/in/%s-%d", source, i)
+		id, err := st.UpsertProfile(ctx, &models.Profile{LinkedInURL: url, Name: url, Source: source})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return id
+	}
+	// sent invites from source, the first accepted of them, plus one
+	// profile still waiting in the queue
+	history := func(source string, sent, accepted int) {
+		t.Helper()
+		for i := 0; i < sent; i++ {
+			id := add(source, i)
+			if err := st.MarkQueued(ctx, id); err != nil {
+				t.Fatal(err)
+			}
+			if err := st.MarkConnectionSent(ctx, id, ""); err != nil {
+				t.Fatal(err)
+			}
+			if i < accepted {
+				if err := st.MarkAccepted(ctx, id); err != nil {
+					t.Fatal(err)
+				}
+			}
+		}
+		add(source, sent)
+	}
+	history("bad", 4, 0)
+	history("good", 4, 3)
+	history("small", 2, 0)
+
+	cfg := &config.Config{}
+	cfg.Connection.BadSourceMinSample = 4
+	cfg.Connection.BadSourceMinAcceptRate = 0.25
+	s := &Service{cfg: cfg, st: st, log: logging.New("error")}
+
+	if paused := s.pausedSources(ctx); paused != nil {
+		t.Fatalf("auto_pause_bad_sources off paused %v", paused)
+	}
+	cfg.Connection.AutoPauseBadSources = true
+	paused := s.pausedSources(ctx)
+	if !reflect.DeepEqual(paused, []string{"bad"}) {
+		t.Fatalf("paused %v, want [bad]", paused)
+	}
+
+	queue, err := st.GetProfilesNeedingConnection(ctx, 10, store.QueueFilter{ExcludeSources: paused})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sources []string
+	for _, p := range queue {
+		sources = append(sources, p.Source)
+	}
+	if want := []string{"good", "small"}; !reflect.DeepEqual(sources, want) {
+		t.Errorf("queue holds sources %v, want %v", sources, want)
+	}
+}
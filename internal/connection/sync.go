@@ -0,0 +1,61 @@
+package connection
+
+import (
+	"context"
+
+	"github.com/example/linkedbot/internal/browser"
+	"github.com/example/linkedbot/internal/extract"
+	"github.com/example/linkedbot/internal/models"
+)
+
+// SyncConnections reads My Network > Connections and records every contact
+// in the store, so existing connections are never invited. Invites the bot
+// sent that show up there are marked accepted. limit caps how many cards are
+// read (0 reads the whole list); stats.Sent counts profiles that changed.
+func (s *Service) SyncConnections(ctx context.Context, limit int) (models.RunStats, error) {
+	var stats models.RunStats
+	p, err := s.br.NewPage(ctx)
+	if err != nil {
+		return stats, err
+	}
+	defer s.br.ClosePage(p)
+	if err := browser.Navigate(ctx, s.rt, p, s.cfg.LinkedIn.BaseURL+"mynetwork/invite-connect/connections/"); err != nil {
+		return stats, err
+	}
+	s.hu.WakeUpMovement(p)
+
+	work := context.WithoutCancel(ctx)
+	seen := map[string]bool{}
+	for ctx.Err() == nil && (limit <= 0 || len(seen) < limit) {
+		found := 0
+		cards, _ := p.Elements(extract.ConnectionCardSelector)
+		for _, card := range cards {
+			if limit > 0 && len(seen) >= limit {
+				break
+			}
+			prof, ok := extract.CardProfile(card)
+			if !ok || seen[prof.LinkedInURL] {
+				continue
+			}
+			prof.Source = "sync-connections"
+			seen[prof.LinkedInURL] = true
+			found++
+			changed, err := s.st.MarkAlreadyConnected(work, &prof)
+			if err != nil {
+				s.log.Warn("failed to record connection", "url", prof.LinkedInURL, "err", err)
+				stats.Fail(prof.LinkedInURL, err)
+				continue
+			}
+			if changed {
+				s.log.Info("connection recorded", "url", prof.LinkedInURL, "name", prof.Name)
+				stats.Sent++
+			}
+		}
+		// Stop once a scroll neither loaded nor revealed new cards
+		if !s.hu.LoadMore(p, extract.ConnectionCardSelector, s.sel.Get("page.load_more").Set) && found == 0 {
+			break
+		}
+	}
+	s.log.Info("connections synced", "read", len(seen), "changed", stats.Sent)
+	return stats, nil
+}
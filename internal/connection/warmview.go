@@ -0,0 +1,79 @@
+package connection
+
+import (
+	"context"
+
+	"github.com/example/linkedbot/internal/audit"
+	"github.com/example/linkedbot/internal/browser"
+	"github.com/example/linkedbot/internal/exclusion"
+	"github.com/example/linkedbot/internal/models"
+	"github.com/example/linkedbot/internal/stealth"
+	"github.com/example/linkedbot/internal/store"
+)
+
+// WarmView visits up to limit profiles next in the invite queue without
+// clicking anything, so they get a "viewed your profile" notification ahead
+// of the invite. Visits are stored as viewed_at; with
+// connection.warm_view_days set, SendConnections only invites profiles
+// viewed at least that many days earlier.
+func (s *Service) WarmView(ctx context.Context, limit int) (models.RunStats, error) {
+	var stats models.RunStats
+	if limit <= 0 {
+		limit = s.cfg.Limits.MaxConnectionsPerDay
+	}
+	var err error
+	if s.xl, err = exclusion.Load(ctx, s.cfg, s.st); err != nil {
+		return stats, err
+	}
+	profiles, err := s.queue(ctx, limit, store.QueueFilter{ExcludeSources: s.pausedSources(ctx), WithEmailRequired: s.em.Enabled(), Unviewed: true,
+		Tags: s.cfg.Connection.Tags})
+	if err != nil {
+		return stats, err
+	}
+	s.log.Info("profiles to view", "count", len(profiles))
+	if len(profiles) == 0 {
+		return stats, nil
+	}
+
+	p, err := s.br.NewPage(ctx)
+	if err != nil {
+		return stats, err
+	}
+	defer s.br.ClosePage(p)
+	work := context.WithoutCancel(ctx)
+	for i, prof := range profiles {
+		if ctx.Err() != nil {
+			stats.Skipped = len(profiles) - i
+			break
+		}
+		if err := s.st.CheckDoNotContact(work, prof.LinkedInURL, "view"); err != nil {
+			s.log.Info("skipping profile", "url", prof.LinkedInURL, "reason", err)
+			stats.Skipped++
+			continue
+		}
+		if err := s.au.Do(work, p, &prof, audit.ActionNavigate, prof.LinkedInURL, "", func() error { return browser.Navigate(work, s.rt, p, prof.LinkedInURL) }); err != nil {
+			s.log.Warn("profile visit failed", "url", prof.LinkedInURL, "err", err)
+			stats.Fail(prof.LinkedInURL, err)
+			continue
+		}
+		if s.br.ProfileUnavailable(p) {
+			stats.Fail(prof.LinkedInURL, s.unavailable(work, &prof))
+			continue
+		}
+		if !s.followRedirect(work, p, &prof) {
+			stats.Skipped++
+			continue
+		}
+		// Read the page the way a person would before moving on
+		s.hu.WakeUpMovement(p)
+		s.hu.ScrollHumanLike(p)
+		s.hu.Read(p)
+		if err := s.st.MarkViewed(work, prof.ID); err != nil {
+			s.log.Warn("failed to record profile view", "url", prof.LinkedInURL, "err", err)
+		}
+		s.log.Info("profile viewed", "url", prof.LinkedInURL)
+		stats.Sent++
+		stealth.SleepRandom(s.cfg.Stealth.MinDelayMs+300, s.cfg.Stealth.MaxDelayMs+900)
+	}
+	return stats, nil
+}
@@ -0,0 +1,171 @@
+package connection
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/example/linkedbot/internal/audit"
+	"github.com/example/linkedbot/internal/browser"
+	"github.com/example/linkedbot/internal/driver"
+	"github.com/example/linkedbot/internal/extract"
+	"github.com/example/linkedbot/internal/models"
+	"github.com/example/linkedbot/internal/stealth"
+)
+
+// WithdrawStale withdraws pending invitations older than days from My
+// Network > Sent invitations, at most limit per run. Invites the bot sent are
+// aged by their stored send time; others by the "Sent 3 weeks ago" label.
+// With connection.withdraw_expired, expired invites go whatever their age.
+func (s *Service) WithdrawStale(ctx context.Context, days, limit int) (models.RunStats, error) {
+	var stats models.RunStats
+	pending, err := s.st.GetPendingInvites(ctx)
+	if err != nil {
+		return stats, err
+	}
+	tracked := make(map[string]models.Profile, len(pending))
+	for _, prof := range pending {
+		tracked[prof.LinkedInURL] = prof
+	}
+
+	p, err := s.br.NewPage(ctx)
+	if err != nil {
+		return stats, err
+	}
+	defer s.br.ClosePage(p)
+	if err := browser.Navigate(ctx, s.rt, p, s.cfg.LinkedIn.BaseURL+"mynetwork/invitation-manager/sent/"); err != nil {
+		return stats, err
+	}
+	s.hu.WakeUpMovement(p)
+
+	maxAge := time.Duration(days) * 24 * time.Hour
+	seen := map[string]bool{}
+	// Withdrawing re-renders the list, so the cards are queried afresh after
+	// every withdrawal
+	for pass := 0; stats.Sent < limit && pass < limit+10; pass++ {
+		if ctx.Err() != nil {
+			break
+		}
+		card, url, prof := s.nextStaleInvite(p, tracked, seen, maxAge)
+		if card == nil {
+			if !s.hu.LoadMore(p, extract.InvitationCardSelector, s.sel.Get("page.load_more").Set) {
+				break
+			}
+			continue
+		}
+		if err := s.withdrawOne(ctx, p, card, &models.Profile{ID: prof.ID, LinkedInURL: url}); err != nil {
+			s.log.Warn("withdraw failed", "url", url, "err", err)
+			stats.Fail(url, err)
+			continue
+		}
+		if prof.ID != 0 {
+			// Record it even if ctx was cancelled while withdrawing
+			if err := s.st.MarkWithdrawn(context.WithoutCancel(ctx), prof.ID); err != nil {
+				s.log.Warn("failed to record withdrawal", "url", url, "err", err)
+			}
+		}
+		s.log.Info("invitation withdrawn", "url", url, "tracked", prof.ID != 0)
+		stats.Sent++
+		stealth.SleepRandom(s.cfg.Stealth.MinDelayMs+500, s.cfg.Stealth.MaxDelayMs+1500)
+	}
+	return stats, nil
+}
+
+// nextStaleInvite returns the first unseen card older than maxAge on the
+// current page, or nil.
+func (s *Service) nextStaleInvite(p driver.Page, tracked map[string]models.Profile, seen map[string]bool, maxAge time.Duration) (driver.Element, string, models.Profile) {
+	cards, _ := p.Elements(extract.InvitationCardSelector)
+	for _, card := range cards {
+		link, err := s.sel.Get("connection.invite_card_link").In(card, 2*time.Second)
+		if err != nil {
+			continue
+		}
+		href, err := link.Attribute("href")
+		if err != nil || href == nil {
+			continue
+		}
+		url := models.CanonicalProfileURL(*href)
+		if seen[url] {
+			continue
+		}
+		seen[url] = true
+
+		prof, ok := tracked[url]
+		if ok && prof.Status == models.StatusExpired && s.cfg.Connection.WithdrawExpired {
+			return card, url, prof
+		}
+		var age time.Duration
+		if ok && prof.ConnectionSentAt != nil {
+			age = time.Since(*prof.ConnectionSentAt)
+		} else {
+			text, _ := card.Text()
+			if age, ok = s.parseSentAgo(text); !ok {
+				s.log.Debug("invitation age unknown, keeping", "url", url)
+				continue
+			}
+		}
+		if age >= maxAge {
+			return card, url, prof
+		}
+	}
+	return nil, "", models.Profile{}
+}
+
+func (s *Service) withdrawOne(ctx context.Context, p driver.Page, card driver.Element, prof *models.Profile) error {
+	btn, err := s.sel.Get("connection.withdraw_button").In(card, 5*time.Second)
+	if err != nil {
+		return fmt.Errorf("withdraw button not found: %w", err)
+	}
+	s.hu.MouseIdleMovement(p)
+	if err := s.au.Do(ctx, p, prof, audit.ActionClick, "Withdraw button", "", func() error { return s.hu.ClickHumanLike(p, btn) }); err != nil {
+		return fmt.Errorf("failed to click withdraw: %w", err)
+	}
+	confirm, err := s.sel.Get("connection.withdraw_confirm").Find(p, time.Duration(s.cfg.Timeouts.ComposeReadyMs)*time.Millisecond)
+	if err != nil {
+		browser.ScreenshotOnError(p, "withdraw_confirm_fail", err)
+		return fmt.Errorf("withdraw confirmation not found: %w", err)
+	}
+	if err := s.au.Do(ctx, p, prof, audit.ActionClick, "Withdraw confirmation", "", func() error { return s.hu.ClickHumanLike(p, confirm) }); err != nil {
+		return fmt.Errorf("failed to confirm withdraw: %w", err)
+	}
+	return browser.WaitGone(confirm, time.Duration(s.cfg.Timeouts.SendConfirmMs)*time.Millisecond)
+}
+
+// sentAgoUnits are the connection.sent_* patterns of an invitation's age
+// with the unit each counts in.
+var sentAgoUnits = []struct {
+	pattern string
+	unit    time.Duration
+}{
+	{"connection.sent_minutes", time.Minute},
+	{"connection.sent_hours", time.Hour},
+	{"connection.sent_days", 24 * time.Hour},
+	{"connection.sent_weeks", 7 * 24 * time.Hour},
+	{"connection.sent_months", 30 * 24 * time.Hour},
+	{"connection.sent_years", 365 * 24 * time.Hour},
+}
+
+// parseSentAgo reads the age from a "Sent 3 weeks ago" label. Months and
+// years are approximated as 30 and 365 days.
+func (s *Service) parseSentAgo(text string) (time.Duration, bool) {
+	if s.sel.Pattern("connection.sent_today").MatchString(text) {
+		return 0, true
+	}
+	if s.sel.Pattern("connection.sent_yesterday").MatchString(text) {
+		return 24 * time.Hour, true
+	}
+	for _, u := range sentAgoUnits {
+		m := s.sel.Pattern(u.pattern).FindStringSubmatch(text)
+		if m == nil {
+			continue
+		}
+		// "a week ago", "vor einer Woche"
+		n := 1
+		if v, err := strconv.Atoi(m[1]); err == nil {
+			n = v
+		}
+		return time.Duration(n) * u.unit, true
+	}
+	return 0, false
+}
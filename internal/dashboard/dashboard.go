@@ -0,0 +1,294 @@
+package dashboard
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"html/template"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/example/linkedbot/internal/artifacts"
+	"github.com/example/linkedbot/internal/config"
+	"github.com/example/linkedbot/internal/logging"
+	"github.com/example/linkedbot/internal/models"
+	"github.com/example/linkedbot/internal/ratelimit"
+	"github.com/example/linkedbot/internal/runstate"
+	"github.com/example/linkedbot/internal/store"
+)
+
+//go:embed dashboard.html
+var pageHTML string
+
+var page = template.Must(template.New("dashboard").Funcs(template.FuncMap{
+	"ago": func(t time.Time) string { return time.Since(t).Truncate(time.Second).String() },
+	"pct": func(n, max int) int {
+		if max <= 0 {
+			return 0
+		}
+		return min(100, 100*n/max)
+	},
+}).Parse(pageHTML))
+
+// screenshotRe matches the screenshot files written by
+// browser.ScreenshotOnError into a run's artifact directory.
+var screenshotRe = regexp.MustCompile(`^\d{3}-[A-Za-z0-9_-]+\.png$`)
+
+const maxScreenshots = 12
+
+// maxApprovals caps the pending notes listed on the page.
+const maxApprovals = 50
+
+type Quota struct {
+	Used  int `json:"used"`
+	Limit int `json:"limit"`
+}
+
+// View is everything the page and /api/state show.
+type View struct {
+	runstate.State
+	Account     string       `json:"account"`
+	Connections Quota        `json:"connections"`
+	Messages    Quota        `json:"messages"`
+	Screenshots []Screenshot `json:"screenshots"`
+	Approvals   []Approval   `json:"approvals"`
+	// Token is the dashboard token the page was opened with, sent back
+	// with its buttons
+	Token string `json:"-"`
+}
+
+// Approval is an invite note waiting for review.
+type Approval struct {
+	ID        int64     `json:"id"`
+	URL       string    `json:"profile_url"`
+	Name      string    `json:"name"`
+	Headline  string    `json:"headline"`
+	Note      string    `json:"note"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Screenshot struct {
+	// Name is <run-id>/<file>
+	Name    string    `json:"name"`
+	ModTime time.Time `json:"time"`
+}
+
+// Server serves the monitoring page. It reads the store for today's quota
+// usage and the run-state manager for live status; pause and resume only
+// affect the process the manager belongs to. Each request reads the config
+// configs currently holds, so reloaded limits show up without a restart.
+type Server struct {
+	configs *config.Manager
+	st      *store.Store
+	rs      *runstate.Manager
+	log     *logging.Logger
+}
+
+func New(configs *config.Manager, st *store.Store, rs *runstate.Manager) *Server {
+	return &Server{configs: configs, st: st, rs: rs, log: logging.New(configs.Config().Logging.Level).With("module", "dashboard")}
+}
+
+// ListenAndServe serves on addr until ctx is cancelled.
+func (s *Server) ListenAndServe(ctx context.Context, addr string) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", s.handlePage)
+	mux.HandleFunc("GET /api/state", s.handleState)
+	mux.HandleFunc("POST /pause", s.guard(s.handlePause(true)))
+	mux.HandleFunc("POST /resume", s.guard(s.handlePause(false)))
+	mux.HandleFunc("GET /screenshots/{run}/{name}", s.handleScreenshot)
+	mux.HandleFunc("POST /approvals/{id}/approve", s.guard(s.handleDecide(store.ApprovalApproved)))
+	mux.HandleFunc("POST /approvals/{id}/reject", s.guard(s.handleDecide(store.ApprovalRejected)))
+	mux.HandleFunc("POST /approvals/approve-all", s.guard(s.handleDecide(store.ApprovalApproved)))
+	mux.HandleFunc("GET /api/events", s.handleEvents)
+	mux.HandleFunc("GET /api/events/stream", s.handleEventStream)
+
+	// Requests share ctx, so open event streams end on shutdown
+	srv := &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: 10 * time.Second, BaseContext: func(net.Listener) context.Context { return ctx }}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+	s.log.Info("dashboard listening", "addr", addr)
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+func (s *Server) view(ctx context.Context) (View, error) {
+	cfg := s.configs.Config()
+	rl := ratelimit.New(cfg, s.st)
+	v := View{State: s.rs.Snapshot(), Account: cfg.Account}
+	var err error
+	if v.Connections.Used, err = s.st.CountActionsToday(ctx, "profiles", ""); err != nil {
+		return v, err
+	}
+	if v.Messages.Used, err = s.st.CountActionsToday(ctx, "message_logs", string(models.MessageTypeFollowUp)); err != nil {
+		return v, err
+	}
+	if v.Connections.Limit, err = rl.DailyLimit(ctx, ratelimit.Connection); err != nil {
+		return v, err
+	}
+	if v.Messages.Limit, err = rl.DailyLimit(ctx, ratelimit.Message); err != nil {
+		return v, err
+	}
+	v.Screenshots = screenshots()
+	pending, err := s.st.GetApprovals(ctx, store.ApprovalPending, maxApprovals)
+	if err != nil {
+		return v, err
+	}
+	for _, a := range pending {
+		v.Approvals = append(v.Approvals, Approval{ID: a.ID, URL: a.ProfileURL, Name: a.Name, Headline: a.Headline, Note: a.Note, CreatedAt: a.CreatedAt})
+	}
+	return v, nil
+}
+
+func (s *Server) handlePage(w http.ResponseWriter, r *http.Request) {
+	if !s.authorized(w, r) {
+		return
+	}
+	v, err := s.view(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	v.Token = r.FormValue("token")
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := page.Execute(w, v); err != nil {
+		s.log.Warn("render dashboard failed", "err", err)
+	}
+}
+
+func (s *Server) handleState(w http.ResponseWriter, r *http.Request) {
+	if !s.authorized(w, r) {
+		return
+	}
+	v, err := s.view(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func (s *Server) handlePause(pause bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if pause {
+			s.rs.Pause()
+		} else {
+			s.rs.Resume()
+		}
+		s.log.Info("pause toggled from dashboard", "paused", pause)
+		http.Redirect(w, r, home(r), http.StatusSeeOther)
+	}
+}
+
+// guard wraps a handler that changes state: requests another site's page
+// makes the browser send are refused, and the rest need the dashboard token
+// like /api/events.
+func (s *Server) guard(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !sameOrigin(r) {
+			s.log.Warn("cross-origin dashboard request refused", "path", r.URL.Path, "origin", r.Header.Get("Origin"))
+			http.Error(w, "cross-origin request refused", http.StatusForbidden)
+			return
+		}
+		if !s.authorized(w, r) {
+			return
+		}
+		h(w, r)
+	}
+}
+
+// sameOrigin reports whether r comes from the dashboard itself or from a
+// client that isn't a browser. Browsers mark requests a page on another
+// origin makes with Sec-Fetch-Site, and with an Origin naming that page.
+func sameOrigin(r *http.Request) bool {
+	switch r.Header.Get("Sec-Fetch-Site") {
+	case "", "same-origin", "none":
+	default:
+		return false
+	}
+	if o := r.Header.Get("Origin"); o != "" {
+		u, err := url.Parse(o)
+		if err != nil || u.Host != r.Host {
+			return false
+		}
+	}
+	return true
+}
+
+// home is the page to go back to after a button, with the token it was
+// pressed with.
+func home(r *http.Request) string {
+	if token := r.FormValue("token"); token != "" {
+		return "/?" + url.Values{"token": {token}}.Encode()
+	}
+	return "/"
+}
+
+// handleDecide approves or rejects the pending note {id}, or every pending
+// note on /approvals/approve-all.
+func (s *Server) handleDecide(state string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var ids []int64
+		if raw := r.PathValue("id"); raw != "" {
+			id, err := strconv.ParseInt(raw, 10, 64)
+			if err != nil {
+				http.NotFound(w, r)
+				return
+			}
+			ids = append(ids, id)
+		}
+		n, err := s.st.DecideApprovals(r.Context(), ids, state, "", r.FormValue("reason"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		s.log.Info("approvals decided from dashboard", "state", state, "ids", ids, "count", n)
+		http.Redirect(w, r, home(r), http.StatusSeeOther)
+	}
+}
+
+func (s *Server) handleScreenshot(w http.ResponseWriter, r *http.Request) {
+	if !s.authorized(w, r) {
+		return
+	}
+	run, name := r.PathValue("run"), r.PathValue("name")
+	if !artifacts.IDPattern.MatchString(run) || !screenshotRe.MatchString(name) {
+		http.NotFound(w, r)
+		return
+	}
+	http.ServeFile(w, r, filepath.Join(artifacts.Root, run, name))
+}
+
+// screenshots lists the newest error screenshots of the recent runs.
+func screenshots() []Screenshot {
+	matches, _ := filepath.Glob(filepath.Join(artifacts.Root, "*", "*.png"))
+	var out []Screenshot
+	for _, m := range matches {
+		run, name := filepath.Base(filepath.Dir(m)), filepath.Base(m)
+		if !artifacts.IDPattern.MatchString(run) || !screenshotRe.MatchString(name) {
+			continue
+		}
+		if fi, err := os.Stat(m); err == nil {
+			out = append(out, Screenshot{Name: run + "/" + name, ModTime: fi.ModTime()})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ModTime.After(out[j].ModTime) })
+	if len(out) > maxScreenshots {
+		out = out[:maxScreenshots]
+	}
+	return out
+}
@@ -0,0 +1,159 @@
+package dashboard
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/example/linkedbot/internal/store"
+)
+
+const (
+	defaultEvents = 50
+	maxEvents     = 500
+	// streamPoll is how often /api/events/stream looks for new events
+	streamPoll = 2 * time.Second
+	// streamKeepAlive keeps proxies from closing an idle stream
+	streamKeepAlive = 30 * time.Second
+)
+
+// handleEvents answers GET /api/events with a JSON array of events, newest
+// first as polling triggers (Zapier, n8n) expect. ?after=ID returns only
+// newer events, ?type= one type and ?limit= up to 500 (50).
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	if !s.authorized(w, r) {
+		return
+	}
+	after, typ, limit, err := eventsQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	events, err := s.st.GetEvents(r.Context(), after, typ, limit, false)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if events == nil {
+		events = []store.Event{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(events)
+}
+
+// handleEventStream serves events as server-sent events, oldest first, each
+// with its id so a reconnecting client resumes after Last-Event-ID. Without
+// that header or ?after=ID only events from now on are sent.
+func (s *Server) handleEventStream(w http.ResponseWriter, r *http.Request) {
+	if !s.authorized(w, r) {
+		return
+	}
+	if id := r.Header.Get("Last-Event-ID"); id != "" && r.URL.Query().Get("after") == "" {
+		q := r.URL.Query()
+		q.Set("after", id)
+		r.URL.RawQuery = q.Encode()
+	}
+	after, typ, _, err := eventsQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	ctx := r.Context()
+	if !r.URL.Query().Has("after") {
+		latest, err := s.st.GetEvents(ctx, 0, "", 1, false)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if len(latest) > 0 {
+			after = latest[0].ID
+		}
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	poll := time.NewTicker(streamPoll)
+	defer poll.Stop()
+	idle := time.Now()
+	for {
+		events, err := s.st.GetEvents(ctx, after, typ, maxEvents, true)
+		if err != nil {
+			s.log.Warn("event stream query failed", "err", err)
+		}
+		for _, e := range events {
+			data, err := json.Marshal(e)
+			if err != nil {
+				return
+			}
+			if _, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.Type, data); err != nil {
+				return
+			}
+			after = e.ID
+		}
+		if len(events) > 0 || time.Since(idle) >= streamKeepAlive {
+			if len(events) == 0 {
+				if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+					return
+				}
+			}
+			flusher.Flush()
+			idle = time.Now()
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-poll.C:
+		}
+	}
+}
+
+// authorized checks the bearer token (or a token query or form value)
+// against daemon.events_token_env and answers 401 when it doesn't match.
+func (s *Server) authorized(w http.ResponseWriter, r *http.Request) bool {
+	want := s.configs.Config().EventsToken()
+	if want == "" {
+		return true
+	}
+	got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		got = r.FormValue("token")
+	}
+	if subtle.ConstantTimeCompare([]byte(got), []byte(want)) == 1 {
+		return true
+	}
+	w.Header().Set("WWW-Authenticate", "Bearer")
+	http.Error(w, "unauthorized", http.StatusUnauthorized)
+	return false
+}
+
+func eventsQuery(r *http.Request) (after int64, typ string, limit int, err error) {
+	q := r.URL.Query()
+	if v := q.Get("after"); v != "" {
+		if after, err = strconv.ParseInt(v, 10, 64); err != nil || after < 0 {
+			return 0, "", 0, fmt.Errorf("after must be an event id, got %q", v)
+		}
+	}
+	typ = q.Get("type")
+	if typ != "" && !slices.Contains(store.EventTypes, typ) {
+		return 0, "", 0, fmt.Errorf("type must be one of %s, got %q", strings.Join(store.EventTypes, ", "), typ)
+	}
+	limit = defaultEvents
+	if v := q.Get("limit"); v != "" {
+		if limit, err = strconv.Atoi(v); err != nil || limit <= 0 || limit > maxEvents {
+			return 0, "", 0, fmt.Errorf("limit must be between 1 and %d, got %q", maxEvents, v)
+		}
+	}
+	return after, typ, limit, nil
+}
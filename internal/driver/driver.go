@@ -0,0 +1,196 @@
+// Package driver is what the flows need from a browser: navigation, element
+// queries and input dispatch on a tab. Rod adapts a rod page to it, which
+// is what the browser package hands out; another driver, such as chromedp,
+// plugs in by implementing Page and Element.
+package driver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// ErrNotFound is wrapped by lookups that found no element.
+var ErrNotFound = errors.New("element not found")
+
+// Page is one browser tab. Calls wait as long as the page's context allows;
+// Timeout and Context return copies with a different one, so
+// p.Timeout(d).Element(css) waits up to d.
+type Page interface {
+	// ID identifies the tab; copies of a page share it
+	ID() string
+	Timeout(d time.Duration) Page
+	Context(ctx context.Context) Page
+	// CancelTimeout drops the deadline Timeout set
+	CancelTimeout() Page
+
+	// Navigate loads url without waiting for the load event; WaitLoad
+	// waits for it
+	Navigate(url string) error
+	WaitLoad() error
+	URL() (string, error)
+	HTML() (string, error)
+	// Screenshot captures the viewport as PNG, or the whole page with
+	// fullPage
+	Screenshot(fullPage bool) ([]byte, error)
+	Close() error
+
+	// Element waits for the first element matching css
+	Element(css string) (Element, error)
+	// ElementR waits for the first element matching css whose text matches
+	// the JavaScript regular expression re, e.g. /connect/i
+	ElementR(css, re string) (Element, error)
+	// Elements returns the elements matching css without waiting
+	Elements(css string) ([]Element, error)
+	// Eval calls the JavaScript function js with args and returns its
+	// result
+	Eval(js string, args ...any) (Value, error)
+
+	MouseMove(x, y float64) error
+	// MouseDown and MouseUp press and release the left button at x, y
+	MouseDown(x, y float64) error
+	MouseUp(x, y float64) error
+	// Key dispatches one keyboard event to the focused element
+	Key(ev KeyEvent) error
+	// Press presses and releases a named key, e.g. Escape
+	Press(key string) error
+	// InsertText types text as an IME would, without key events
+	InsertText(text string) error
+
+	Cookies() ([]Cookie, error)
+	SetCookies(cookies []Cookie) error
+}
+
+// Element is a node of a page.
+type Element interface {
+	Page() Page
+	Timeout(d time.Duration) Element
+	CancelTimeout() Element
+
+	Element(css string) (Element, error)
+	ElementR(css, re string) (Element, error)
+	Elements(css string) ([]Element, error)
+
+	Text() (string, error)
+	HTML() (string, error)
+	// Attribute returns nil when the element lacks name
+	Attribute(name string) (*string, error)
+	Visible() (bool, error)
+	// Box is the element's border box in viewport coordinates
+	Box() (Box, error)
+	// Eval calls js with this bound to the element
+	Eval(js string, args ...any) (Value, error)
+
+	WaitVisible() error
+	WaitInvisible() error
+	// Wait waits until the JavaScript predicate js, called with this bound
+	// to the element, returns true
+	Wait(js string) error
+
+	ScrollIntoView() error
+	Focus() error
+	// Click clicks the element's center with the left button
+	Click() error
+	// Input focuses the element and replaces its text with text
+	Input(text string) error
+}
+
+// Box is a rectangle in viewport coordinates.
+type Box struct {
+	X, Y, Width, Height float64
+}
+
+// Center returns the middle of b.
+func (b Box) Center() (x, y float64) {
+	return b.X + b.Width/2, b.Y + b.Height/2
+}
+
+// KeyEventType is the kind of a KeyEvent, as the DevTools protocol names it.
+type KeyEventType string
+
+const (
+	// KeyDown is a keydown that also types the event's Text
+	KeyDown KeyEventType = "keyDown"
+	// RawKeyDown is a keydown without text, for keys like Shift
+	RawKeyDown KeyEventType = "rawKeyDown"
+	KeyUp      KeyEventType = "keyUp"
+)
+
+// ModShift is the Modifiers bit of a held Shift key.
+const ModShift = 8
+
+// KeyEvent is one physical key event.
+type KeyEvent struct {
+	Type KeyEventType
+	// Key and Code are KeyboardEvent.key and .code, KeyCode the Windows
+	// virtual key code
+	Key, Code string
+	KeyCode   int
+	Modifiers int
+	// Text is what a KeyDown types, if anything
+	Text string
+}
+
+// Cookie is a browser cookie. The JSON names match the DevTools protocol,
+// which is how auth.cookie_path stores them.
+type Cookie struct {
+	Name     string  `json:"name"`
+	Value    string  `json:"value"`
+	Domain   string  `json:"domain"`
+	Path     string  `json:"path"`
+	Expires  float64 `json:"expires"`
+	HTTPOnly bool    `json:"httpOnly"`
+	Secure   bool    `json:"secure"`
+}
+
+// Value is the result of a script, as JSON.
+type Value json.RawMessage
+
+// Nil reports whether the script returned null or undefined.
+func (v Value) Nil() bool {
+	return len(v) == 0 || string(v) == "null"
+}
+
+// Unmarshal decodes v into out.
+func (v Value) Unmarshal(out any) error {
+	if v.Nil() {
+		return nil
+	}
+	return json.Unmarshal(v, out)
+}
+
+// Get returns the field key of an object, or a nil Value.
+func (v Value) Get(key string) Value {
+	var m map[string]json.RawMessage
+	if json.Unmarshal(v, &m) != nil {
+		return nil
+	}
+	return Value(m[key])
+}
+
+// Str returns a string result as is and anything else as JSON.
+func (v Value) Str() string {
+	var s string
+	if json.Unmarshal(v, &s) == nil {
+		return s
+	}
+	return string(v)
+}
+
+// Num returns a number result, or 0.
+func (v Value) Num() float64 {
+	var n float64
+	_ = json.Unmarshal(v, &n)
+	return n
+}
+
+// Int returns a number result truncated to an int, or 0.
+func (v Value) Int() int { return int(v.Num()) }
+
+// Bool returns a boolean result, or false.
+func (v Value) Bool() bool {
+	var b bool
+	_ = json.Unmarshal(v, &b)
+	return b
+}
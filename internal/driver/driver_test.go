@@ -0,0 +1,40 @@
+package driver
+
+import "testing"
+
+func TestValue(t *testing.T) {
+	v := Value(`{"words":120,"screens":2.5,"title":"Feed","ok":true,"none":null}`)
+	if got := v.Get("words").Int(); got != 120 {
+		t.Errorf("words = %d, want 120", got)
+	}
+	if got := v.Get("screens").Num(); got != 2.5 {
+		t.Errorf("screens = %v, want 2.5", got)
+	}
+	if got := v.Get("title").Str(); got != "Feed" {
+		t.Errorf("title = %q, want Feed", got)
+	}
+	if !v.Get("ok").Bool() {
+		t.Error("ok = false, want true")
+	}
+	for _, key := range []string{"none", "missing"} {
+		if !v.Get(key).Nil() {
+			t.Errorf("%s is not nil", key)
+		}
+	}
+	if v.Nil() {
+		t.Error("object reported nil")
+	}
+	if got := v.Get("title").Get("x"); !got.Nil() {
+		t.Errorf("field of a string = %s, want nil", got)
+	}
+
+	var out struct {
+		Words int `json:"words"`
+	}
+	if err := v.Unmarshal(&out); err != nil || out.Words != 120 {
+		t.Errorf("Unmarshal = %+v, %v", out, err)
+	}
+	if err := Value("null").Unmarshal(&out); err != nil {
+		t.Errorf("Unmarshal of null: %v", err)
+	}
+}
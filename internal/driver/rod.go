@@ -0,0 +1,219 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/input"
+	"github.com/go-rod/rod/lib/proto"
+)
+
+// rodKeys are the keys Press knows by name.
+var rodKeys = map[string]input.Key{
+	"Escape":    input.Escape,
+	"Enter":     input.Enter,
+	"Tab":       input.Tab,
+	"Backspace": input.Backspace,
+}
+
+// Rod returns p as a Page.
+func Rod(p *rod.Page) Page { return rodPage{p} }
+
+type rodPage struct {
+	p *rod.Page
+}
+
+func (r rodPage) ID() string                       { return string(r.p.TargetID) }
+func (r rodPage) Timeout(d time.Duration) Page     { return rodPage{r.p.Timeout(d)} }
+func (r rodPage) Context(ctx context.Context) Page { return rodPage{r.p.Context(ctx)} }
+func (r rodPage) CancelTimeout() Page              { return rodPage{r.p.CancelTimeout()} }
+func (r rodPage) Navigate(url string) error        { return r.p.Navigate(url) }
+func (r rodPage) WaitLoad() error                  { return r.p.WaitLoad() }
+func (r rodPage) HTML() (string, error)            { return r.p.HTML() }
+func (r rodPage) Close() error                     { return r.p.Close() }
+
+func (r rodPage) URL() (string, error) {
+	info, err := r.p.Info()
+	if err != nil {
+		return "", err
+	}
+	return info.URL, nil
+}
+
+func (r rodPage) Screenshot(fullPage bool) ([]byte, error) {
+	return r.p.Screenshot(fullPage, &proto.PageCaptureScreenshot{})
+}
+
+func (r rodPage) Element(css string) (Element, error) {
+	return rodOne(r.p.Element(css))
+}
+
+func (r rodPage) ElementR(css, re string) (Element, error) {
+	return rodOne(r.p.ElementR(css, re))
+}
+
+func (r rodPage) Elements(css string) ([]Element, error) {
+	return rodElements(r.p.Elements(css))
+}
+
+func (r rodPage) Eval(js string, args ...any) (Value, error) {
+	return rodValue(r.p.Eval(js, args...))
+}
+
+func (r rodPage) MouseMove(x, y float64) error {
+	return proto.InputDispatchMouseEvent{Type: proto.InputDispatchMouseEventTypeMouseMoved, X: x, Y: y}.Call(r.p)
+}
+
+func (r rodPage) MouseDown(x, y float64) error {
+	return proto.InputDispatchMouseEvent{Type: proto.InputDispatchMouseEventTypeMousePressed, X: x, Y: y, Button: proto.InputMouseButtonLeft, ClickCount: 1}.Call(r.p)
+}
+
+func (r rodPage) MouseUp(x, y float64) error {
+	return proto.InputDispatchMouseEvent{Type: proto.InputDispatchMouseEventTypeMouseReleased, X: x, Y: y, Button: proto.InputMouseButtonLeft, ClickCount: 1}.Call(r.p)
+}
+
+func (r rodPage) Key(ev KeyEvent) error {
+	return proto.InputDispatchKeyEvent{
+		Type:                  proto.InputDispatchKeyEventType(ev.Type),
+		Modifiers:             ev.Modifiers,
+		Text:                  ev.Text,
+		UnmodifiedText:        ev.Text,
+		Key:                   ev.Key,
+		Code:                  ev.Code,
+		WindowsVirtualKeyCode: ev.KeyCode,
+		NativeVirtualKeyCode:  ev.KeyCode,
+	}.Call(r.p)
+}
+
+func (r rodPage) Press(key string) error {
+	k, ok := rodKeys[key]
+	if !ok {
+		return fmt.Errorf("unknown key %q", key)
+	}
+	return r.p.Keyboard.Press(k)
+}
+
+func (r rodPage) InsertText(text string) error {
+	return proto.InputInsertText{Text: text}.Call(r.p)
+}
+
+func (r rodPage) Cookies() ([]Cookie, error) {
+	res, err := proto.StorageGetCookies{}.Call(r.p)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Cookie, len(res.Cookies))
+	for i, c := range res.Cookies {
+		out[i] = Cookie{Name: c.Name, Value: c.Value, Domain: c.Domain, Path: c.Path, Expires: float64(c.Expires), HTTPOnly: c.HTTPOnly, Secure: c.Secure}
+	}
+	return out, nil
+}
+
+func (r rodPage) SetCookies(cookies []Cookie) error {
+	var first error
+	for _, c := range cookies {
+		_, err := proto.NetworkSetCookie{Name: c.Name, Value: c.Value, Domain: c.Domain, Path: c.Path, Expires: proto.TimeSinceEpoch(c.Expires), HTTPOnly: c.HTTPOnly, Secure: c.Secure}.Call(r.p)
+		if err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+type rodElement struct {
+	el *rod.Element
+}
+
+func (r rodElement) Page() Page                      { return rodPage{r.el.Page()} }
+func (r rodElement) Timeout(d time.Duration) Element { return rodElement{r.el.Timeout(d)} }
+func (r rodElement) CancelTimeout() Element          { return rodElement{r.el.CancelTimeout()} }
+func (r rodElement) Text() (string, error)           { return r.el.Text() }
+func (r rodElement) HTML() (string, error)           { return r.el.HTML() }
+func (r rodElement) Visible() (bool, error)          { return r.el.Visible() }
+func (r rodElement) WaitVisible() error              { return r.el.WaitVisible() }
+func (r rodElement) WaitInvisible() error            { return r.el.WaitInvisible() }
+func (r rodElement) Wait(js string) error            { return r.el.Wait(rod.Eval(js)) }
+func (r rodElement) ScrollIntoView() error           { return r.el.ScrollIntoView() }
+func (r rodElement) Focus() error                    { return r.el.Focus() }
+func (r rodElement) Click() error                    { return r.el.Click(proto.InputMouseButtonLeft, 1) }
+func (r rodElement) Input(text string) error         { return r.el.Input(text) }
+
+func (r rodElement) Attribute(name string) (*string, error) {
+	return r.el.Attribute(name)
+}
+
+func (r rodElement) Element(css string) (Element, error) {
+	return rodOne(r.el.Element(css))
+}
+
+func (r rodElement) ElementR(css, re string) (Element, error) {
+	return rodOne(r.el.ElementR(css, re))
+}
+
+func (r rodElement) Elements(css string) ([]Element, error) {
+	return rodElements(r.el.Elements(css))
+}
+
+func (r rodElement) Eval(js string, args ...any) (Value, error) {
+	return rodValue(r.el.Eval(js, args...))
+}
+
+// Box is the bounding box of the element's first content quad.
+func (r rodElement) Box() (Box, error) {
+	shape, err := r.el.Shape()
+	if err != nil {
+		return Box{}, err
+	}
+	if len(shape.Quads) == 0 || len(shape.Quads[0]) != 8 {
+		return Box{}, errors.New("element has no box")
+	}
+	q := shape.Quads[0]
+	minX, maxX, minY, maxY := q[0], q[0], q[1], q[1]
+	for i := 2; i < len(q); i += 2 {
+		minX, maxX = min(minX, q[i]), max(maxX, q[i])
+		minY, maxY = min(minY, q[i+1]), max(maxY, q[i+1])
+	}
+	return Box{X: minX, Y: minY, Width: maxX - minX, Height: maxY - minY}, nil
+}
+
+// rodOne wraps the result of a single-element lookup, turning rod's
+// not-found error into ErrNotFound.
+func rodOne(el *rod.Element, err error) (Element, error) {
+	if err != nil {
+		return nil, rodErr(err)
+	}
+	return rodElement{el}, nil
+}
+
+func rodElements(els rod.Elements, err error) ([]Element, error) {
+	if err != nil {
+		return nil, rodErr(err)
+	}
+	out := make([]Element, len(els))
+	for i, el := range els {
+		out[i] = rodElement{el}
+	}
+	return out, nil
+}
+
+func rodValue(obj *proto.RuntimeRemoteObject, err error) (Value, error) {
+	if err != nil {
+		return nil, err
+	}
+	b, err := obj.Value.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	return Value(b), nil
+}
+
+func rodErr(err error) error {
+	var nf *rod.ElementNotFoundError
+	if errors.As(err, &nf) {
+		return fmt.Errorf("%w: %w", ErrNotFound, err)
+	}
+	return err
+}
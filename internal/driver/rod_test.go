@@ -0,0 +1,79 @@
+package driver_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/example/linkedbot/internal/driver"
+	"github.com/example/linkedbot/internal/rodtest"
+)
+
+const form = `<html><body style="margin:0">
+<button id="go" style="position:absolute;left:100px;top:50px;width:80px;height:30px"
+  onclick="this.textContent = 'Clicked'">Go</button>
+<input id="name">
+</body></html>`
+
+func TestRodPage(t *testing.T) {
+	p := rodtest.Page(t, rodtest.Browser(t), form)
+
+	btn, err := p.Timeout(2*time.Second).ElementR("button", "/^go$/i")
+	if err != nil {
+		t.Fatal(err)
+	}
+	box, err := btn.Box()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (driver.Box{X: 100, Y: 50, Width: 80, Height: 30}); box != want {
+		t.Errorf("Box = %+v, want %+v", box, want)
+	}
+
+	// A click dispatched at the box's center lands on the button
+	x, y := box.Center()
+	if err := p.MouseMove(x, y); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.MouseDown(x, y); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.MouseUp(x, y); err != nil {
+		t.Fatal(err)
+	}
+	if text, _ := btn.Text(); text != "Clicked" {
+		t.Errorf("button text = %q after clicking its center", text)
+	}
+
+	name, err := p.Element("#name")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := name.Focus(); err != nil {
+		t.Fatal(err)
+	}
+	for _, ev := range []driver.KeyEvent{
+		{Type: driver.KeyDown, Key: "a", Code: "KeyA", KeyCode: 65, Text: "a"},
+		{Type: driver.KeyUp, Key: "a", Code: "KeyA", KeyCode: 65},
+	} {
+		if err := p.Key(ev); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := p.InsertText("é"); err != nil {
+		t.Fatal(err)
+	}
+	v, err := name.Eval(`() => this.value`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := v.Str(); got != "aé" {
+		t.Errorf("input value = %q, want aé", got)
+	}
+
+	if _, err := p.Timeout(300 * time.Millisecond).Element("#missing"); err == nil {
+		t.Error("lookup of a missing element succeeded")
+	}
+	if els, err := p.Elements("#missing"); err != nil || len(els) != 0 {
+		t.Errorf("Elements of a missing selector = %d, %v", len(els), err)
+	}
+}
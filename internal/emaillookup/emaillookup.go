@@ -0,0 +1,151 @@
+package emaillookup
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/example/linkedbot/internal/config"
+	"github.com/example/linkedbot/internal/logging"
+	"github.com/example/linkedbot/internal/models"
+	"github.com/example/linkedbot/internal/retry"
+)
+
+// hunterURL is Hunter's email-finder endpoint.
+const hunterURL = "https://api.hunter.io/v2/email-finder"
+
+// ErrNotFound is returned when the provider has no address for the profile.
+var ErrNotFound = errors.New("no email found")
+
+// Finder looks up a member's email address with the provider configured in
+// connection.email_lookup, for invites LinkedIn only sends with the email.
+type Finder struct {
+	cfg    *config.Config
+	client *http.Client
+	rt     *retry.Retrier
+	log    *logging.Logger
+}
+
+func New(cfg *config.Config) *Finder {
+	return &Finder{
+		cfg:    cfg,
+		client: &http.Client{Timeout: time.Duration(cfg.Connection.EmailLookup.TimeoutSec) * time.Second},
+		rt:     retry.New(cfg),
+		log:    logging.New(cfg.Logging.Level).With("module", "emaillookup"),
+	}
+}
+
+// Enabled reports whether a provider is configured.
+func (f *Finder) Enabled() bool { return f.cfg.Connection.EmailLookup.Provider != "" }
+
+// Find returns the email of prof, or ErrNotFound. Network errors, 5xx and
+// 429 responses are retried; other failures are returned as is.
+func (f *Finder) Find(ctx context.Context, prof *models.Profile) (string, error) {
+	el := f.cfg.Connection.EmailLookup
+	var req *http.Request
+	var err error
+	switch el.Provider {
+	case "hunter":
+		req, err = f.hunterRequest(ctx, prof)
+	case "http":
+		req, err = f.httpRequest(ctx, prof)
+	default:
+		return "", errors.New("email lookup is not configured")
+	}
+	if err != nil {
+		return "", err
+	}
+	email, err := retry.Value(ctx, f.rt, "email lookup", func() (string, error) { return f.do(req, el.Provider) })
+	if err != nil {
+		return "", err
+	}
+	f.log.Info("email found", "url", prof.LinkedInURL, "provider", el.Provider)
+	return email, nil
+}
+
+// hunterRequest asks Hunter for the address from the name and company, the
+// only inputs its finder takes.
+func (f *Finder) hunterRequest(ctx context.Context, prof *models.Profile) (*http.Request, error) {
+	key := f.cfg.Secret(f.cfg.Connection.EmailLookup.APIKeyEnv)
+	if key == "" {
+		return nil, fmt.Errorf("%s is not set", f.cfg.Connection.EmailLookup.APIKeyEnv)
+	}
+	first, last, _ := strings.Cut(strings.TrimSpace(prof.Name), " ")
+	if first == "" || last == "" || prof.Company == "" {
+		return nil, ErrNotFound
+	}
+	q := url.Values{"company": {prof.Company}, "first_name": {first}, "last_name": {last}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, hunterURL+"?"+q.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	// A header keeps the key out of the URLs in error messages
+	req.Header.Set("X-API-KEY", key)
+	return req, nil
+}
+
+// httpRequest calls a custom endpoint with the profile as query parameters.
+// It answers {"email": "..."}, or 404 when it has none.
+func (f *Finder) httpRequest(ctx context.Context, prof *models.Profile) (*http.Request, error) {
+	u, err := url.Parse(f.cfg.Connection.EmailLookup.URL)
+	if err != nil {
+		return nil, err
+	}
+	q := u.Query()
+	q.Set("linkedin_url", prof.LinkedInURL)
+	q.Set("name", prof.Name)
+	q.Set("company", prof.Company)
+	u.RawQuery = q.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if key := f.cfg.Secret(f.cfg.Connection.EmailLookup.APIKeyEnv); key != "" {
+		req.Header.Set("Authorization", "Bearer "+key)
+	}
+	return req, nil
+}
+
+func (f *Finder) do(req *http.Request, provider string) (string, error) {
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return "", retry.Permanent(ErrNotFound)
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return "", fmt.Errorf("email lookup: %s", resp.Status)
+	case resp.StatusCode != http.StatusOK:
+		return "", retry.Permanent(fmt.Errorf("email lookup: %s", resp.Status))
+	}
+	// Hunter wraps the result in "data"
+	var out struct {
+		Email string `json:"email"`
+		Data  struct {
+			Email string `json:"email"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &out); err != nil {
+		return "", retry.Permanent(fmt.Errorf("email lookup: unexpected response: %w", err))
+	}
+	email := out.Email
+	if provider == "hunter" {
+		email = out.Data.Email
+	}
+	if !strings.Contains(email, "@") {
+		return "", retry.Permanent(ErrNotFound)
+	}
+	return email, nil
+}
@@ -0,0 +1,138 @@
+package engage
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/example/linkedbot/internal/audit"
+	"github.com/example/linkedbot/internal/browser"
+	"github.com/example/linkedbot/internal/driver"
+	"github.com/example/linkedbot/internal/models"
+	"github.com/example/linkedbot/internal/ratelimit"
+	"github.com/example/linkedbot/internal/stealth"
+)
+
+// EndorseSkills visits up to limit accepted connections and endorses as many
+// of their top skills as engage.endorse_skills (or their campaign) asks for.
+// Each endorsed profile counts against engage.max_per_day. Profiles are
+// visited once, also when they list no skills to endorse.
+func (s *Service) EndorseSkills(ctx context.Context, limit int) (models.RunStats, error) {
+	var stats models.RunStats
+	budget, err := s.rl.Remaining(ctx, ratelimit.Engagement)
+	if err != nil {
+		return stats, err
+	}
+	if budget.Left() == 0 {
+		s.log.Info("engagement cap reached", "budget", budget.String())
+		return stats, nil
+	}
+	if left := budget.Left(); left >= 0 && limit > left {
+		limit = left
+	}
+	accepted, err := s.st.GetProfilesToEndorse(ctx)
+	if err != nil {
+		return stats, err
+	}
+	var profiles []models.Profile
+	for _, prof := range accepted {
+		if len(profiles) < limit && s.cfg.EndorseSkillsFor(prof.Source) > 0 {
+			profiles = append(profiles, prof)
+		}
+	}
+	s.log.Info("profiles to endorse", "count", len(profiles))
+	if len(profiles) == 0 {
+		return stats, nil
+	}
+
+	p, err := s.br.NewPage(ctx)
+	if err != nil {
+		return stats, err
+	}
+	defer s.br.ClosePage(p)
+	work := context.WithoutCancel(ctx)
+	for i, prof := range profiles {
+		if ctx.Err() != nil {
+			stats.Skipped = len(profiles) - i
+			break
+		}
+		if err := s.st.CheckDoNotContact(work, prof.LinkedInURL, "endorse"); err != nil {
+			s.log.Info("skipping profile", "url", prof.LinkedInURL, "reason", err)
+			stats.Skipped++
+			continue
+		}
+		if err := s.rl.Wait(ctx, ratelimit.Engagement); err != nil {
+			if errors.Is(err, ratelimit.ErrExhausted) {
+				s.log.Info("stopping batch", "reason", err)
+			}
+			stats.Skipped = len(profiles) - i
+			break
+		}
+		skills, err := s.endorseOne(work, p, &prof, s.cfg.EndorseSkillsFor(prof.Source))
+		// A failed page load is retried next run
+		if err == nil || len(skills) > 0 {
+			if err := s.st.MarkEndorsed(work, prof.ID, skills); err != nil {
+				s.log.Warn("failed to record endorsement", "url", prof.LinkedInURL, "err", err)
+			}
+		}
+		if err != nil {
+			s.log.Warn("endorsement failed", "url", prof.LinkedInURL, "err", err)
+			stats.Fail(prof.LinkedInURL, err)
+			continue
+		}
+		if len(skills) == 0 {
+			stats.Skipped++
+			continue
+		}
+		s.rl.Record(work, ratelimit.Engagement)
+		stats.Sent++
+		stealth.SleepRandom(s.cfg.Stealth.MinDelayMs+300, s.cfg.Stealth.MaxDelayMs+900)
+	}
+	return stats, nil
+}
+
+// endorseOne endorses up to n skills shown on prof's page, top skills first,
+// and returns their names.
+func (s *Service) endorseOne(ctx context.Context, p driver.Page, prof *models.Profile, n int) ([]string, error) {
+	if err := s.au.Do(ctx, p, prof, audit.ActionNavigate, prof.LinkedInURL, "", func() error { return browser.Navigate(ctx, s.rt, p, prof.LinkedInURL) }); err != nil {
+		return nil, err
+	}
+	s.hu.WakeUpMovement(p)
+	section, err := s.sel.Get("engage.skills_section").Find(p, 5*time.Second)
+	if err != nil {
+		s.log.Info("no skills section", "url", prof.LinkedInURL)
+		return nil, nil
+	}
+	_ = section.ScrollIntoView()
+	s.hu.MouseIdleMovement(p)
+	stealth.ThinkTime()
+
+	var skills []string
+	for _, btn := range s.sel.Get("engage.endorse_button").All(p) {
+		if len(skills) == n {
+			break
+		}
+		skill := endorseSkillName(btn)
+		if err := s.au.Do(ctx, p, prof, audit.ActionClick, "Endorse button", skill, func() error { return s.hu.ClickHumanLike(p, btn) }); err != nil {
+			return skills, err
+		}
+		s.log.Info("skill endorsed", "url", prof.LinkedInURL, "skill", skill)
+		skills = append(skills, skill)
+		stealth.SleepRandom(800, 2000)
+	}
+	if len(skills) == 0 {
+		s.log.Info("no skills left to endorse", "url", prof.LinkedInURL)
+	}
+	return skills, nil
+}
+
+// endorseSkillName reads the skill off an Endorse button's aria-label,
+// "Endorse Go (Programming Language)".
+func endorseSkillName(btn driver.Element) string {
+	label, err := btn.Attribute("aria-label")
+	if err != nil || label == nil {
+		return ""
+	}
+	return strings.TrimSpace(strings.TrimPrefix(*label, "Endorse "))
+}
@@ -0,0 +1,282 @@
+package engage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/example/linkedbot/internal/audit"
+	"github.com/example/linkedbot/internal/browser"
+	"github.com/example/linkedbot/internal/config"
+	"github.com/example/linkedbot/internal/driver"
+	"github.com/example/linkedbot/internal/exclusion"
+	"github.com/example/linkedbot/internal/logging"
+	"github.com/example/linkedbot/internal/models"
+	"github.com/example/linkedbot/internal/ratelimit"
+	"github.com/example/linkedbot/internal/retry"
+	"github.com/example/linkedbot/internal/selectors"
+	"github.com/example/linkedbot/internal/stealth"
+	"github.com/example/linkedbot/internal/store"
+	"github.com/example/linkedbot/internal/templates"
+)
+
+// maxCommentLen is LinkedIn's comment length limit.
+const maxCommentLen = 1250
+
+// maxPostText is how much of a post is kept for the llm prompts.
+const maxPostText = 1000
+
+// postAgeRe reads the age label of a post: "3d", "2w", "5mo", "1yr", "45m".
+var postAgeRe = regexp.MustCompile(`^\s*(\d+)\s*(m|h|d|w|mo|yr)\b`)
+
+type Service struct {
+	br  *browser.Browser
+	cfg *config.Config
+	st  *store.Store
+	au  *audit.Recorder
+	rl  *ratelimit.Limiter
+	rt  *retry.Retrier
+	sel *selectors.Registry
+	xl  *exclusion.List
+	hu  *stealth.Human
+	log *logging.Logger
+}
+
+func New(br *browser.Browser, cfg *config.Config, st *store.Store) *Service {
+	return &Service{br: br, cfg: cfg, st: st, au: audit.New(cfg, st), rl: ratelimit.New(cfg, st), rt: retry.New(cfg), sel: br.Selectors, hu: stealth.New(cfg), log: logging.New(cfg.Logging.Level).With("module", "engage")}
+}
+
+// EngageProfiles visits the recent activity of up to limit profiles that
+// haven't been invited yet, likes their newest post of at most
+// engage.max_post_age_days and, with comment set, comments on it. Every
+// visited profile is recorded, so profiles without a recent post are not
+// visited again.
+func (s *Service) EngageProfiles(ctx context.Context, limit int, comment bool) (models.RunStats, error) {
+	var stats models.RunStats
+	if comment && s.cfg.Engage.CommentTemplate == "" {
+		return stats, errors.New("engage.comment_template is empty")
+	}
+	budget, err := s.rl.Remaining(ctx, ratelimit.Engagement)
+	if err != nil {
+		return stats, err
+	}
+	if budget.Left() == 0 {
+		s.log.Info("engagement cap reached", "budget", budget.String())
+		return stats, nil
+	}
+	if left := budget.Left(); left >= 0 && limit > left {
+		limit = left
+	}
+	if s.xl, err = exclusion.Load(ctx, s.cfg, s.st); err != nil {
+		return stats, err
+	}
+	profiles, err := s.st.GetProfilesToEngage(ctx, limit)
+	if err != nil {
+		return stats, err
+	}
+	s.log.Info("profiles to engage", "count", len(profiles))
+	if len(profiles) == 0 {
+		return stats, nil
+	}
+
+	p, err := s.br.NewPage(ctx)
+	if err != nil {
+		return stats, err
+	}
+	defer s.br.ClosePage(p)
+	// The profile in flight is finished on work even after ctx is cancelled
+	work := context.WithoutCancel(ctx)
+	for i, prof := range profiles {
+		if ctx.Err() != nil {
+			stats.Skipped = len(profiles) - i
+			break
+		}
+		if reason, ok := s.xl.Match(&prof); ok {
+			s.log.Info("skipping excluded profile", "url", prof.LinkedInURL, "reason", reason)
+			stats.Skipped++
+			continue
+		}
+		if err := s.st.CheckDoNotContact(work, prof.LinkedInURL, "engage"); err != nil {
+			s.log.Info("skipping profile", "url", prof.LinkedInURL, "reason", err)
+			stats.Skipped++
+			continue
+		}
+		if err := s.rl.Wait(ctx, ratelimit.Engagement); err != nil {
+			if errors.Is(err, ratelimit.ErrExhausted) {
+				s.log.Info("stopping batch", "reason", err)
+			}
+			stats.Skipped = len(profiles) - i
+			break
+		}
+		e, err := s.engageOne(work, p, &prof, comment)
+		// A failed visit is retried next run unless the like went through
+		if e != nil && (err == nil || e.Engaged()) {
+			if err := s.st.InsertEngagement(work, e); err != nil {
+				s.log.Warn("failed to record engagement", "url", prof.LinkedInURL, "err", err)
+			}
+		}
+		if err != nil {
+			s.log.Warn("engagement failed", "url", prof.LinkedInURL, "err", err)
+			stats.Fail(prof.LinkedInURL, err)
+			continue
+		}
+		if !e.Engaged() {
+			stats.Skipped++
+			continue
+		}
+		s.rl.Record(work, ratelimit.Engagement)
+		stats.Sent++
+		stealth.SleepRandom(s.cfg.Stealth.MinDelayMs+300, s.cfg.Stealth.MaxDelayMs+900)
+	}
+	return stats, nil
+}
+
+// engageOne likes and comments on the newest recent post of prof. The
+// returned engagement is nil when the page failed to load.
+func (s *Service) engageOne(ctx context.Context, p driver.Page, prof *models.Profile, comment bool) (*store.Engagement, error) {
+	activityURL := prof.LinkedInURL + "/recent-activity/all/"
+	if err := s.au.Do(ctx, p, prof, audit.ActionNavigate, activityURL, "", func() error { return browser.Navigate(ctx, s.rt, p, activityURL) }); err != nil {
+		return nil, err
+	}
+	s.hu.WakeUpMovement(p)
+	s.hu.ScrollHumanLike(p)
+
+	e := &store.Engagement{ProfileID: prof.ID}
+	post, err := s.sel.Get("engage.post").Find(p, 10*time.Second)
+	if err != nil {
+		s.log.Info("no posts in recent activity", "url", prof.LinkedInURL)
+		return e, nil
+	}
+	if age, ok := s.postAge(post); ok && age > time.Duration(s.cfg.Engage.MaxPostAgeDays)*24*time.Hour {
+		s.log.Info("newest post too old, not engaging", "url", prof.LinkedInURL, "age", age.Round(time.Hour).String())
+		return e, nil
+	}
+	if urn, err := post.Attribute("data-urn"); err == nil && urn != nil {
+		e.PostURL = s.cfg.LinkedIn.BaseURL + "feed/update/" + *urn + "/"
+	}
+	e.PostText = s.postText(post)
+	_ = post.ScrollIntoView()
+	s.hu.MouseIdleMovement(p)
+	stealth.ThinkTime()
+
+	like, err := s.sel.Get("engage.like_button").In(post, 3*time.Second)
+	if err != nil {
+		browser.ScreenshotOnError(p, "like_button_fail", err)
+		return e, fmt.Errorf("like button not found: %w", err)
+	}
+	if pressed, _ := like.Attribute("aria-pressed"); pressed != nil && *pressed == "true" {
+		s.log.Info("post already liked", "url", prof.LinkedInURL)
+	} else if err := s.au.Do(ctx, p, prof, audit.ActionClick, "Like button", e.PostURL, func() error { return s.hu.ClickHumanLike(p, like) }); err != nil {
+		return e, fmt.Errorf("failed to like: %w", err)
+	}
+	e.Liked = true
+	s.log.Info("post liked", "url", prof.LinkedInURL, "post", e.PostURL)
+
+	if comment {
+		text, err := templates.Render(s.cfg.Engage.CommentTemplate, prof, s.cfg.Templates.TitleCleanup)
+		if err != nil {
+			return e, fmt.Errorf("render comment: %w", err)
+		}
+		if err := s.comment(ctx, p, prof, post, text); err != nil {
+			return e, err
+		}
+		e.Comment = text
+		s.log.Info("comment posted", "url", prof.LinkedInURL, "post", e.PostURL)
+	}
+	return e, nil
+}
+
+func (s *Service) comment(ctx context.Context, p driver.Page, prof *models.Profile, post driver.Element, text string) error {
+	if len(text) > maxCommentLen {
+		text = text[:maxCommentLen]
+	}
+	composeTimeout := time.Duration(s.cfg.Timeouts.ComposeReadyMs) * time.Millisecond
+	btn, err := s.sel.Get("engage.comment_button").In(post, 3*time.Second)
+	if err != nil {
+		return fmt.Errorf("comment button not found: %w", err)
+	}
+	if err := s.au.Do(ctx, p, prof, audit.ActionClick, "Comment button", "", func() error { return s.hu.ClickHumanLike(p, btn) }); err != nil {
+		return fmt.Errorf("failed to open comment box: %w", err)
+	}
+	box, err := s.sel.Get("engage.comment_box").In(post, composeTimeout)
+	if err != nil {
+		browser.ScreenshotOnError(p, "comment_box_fail", err)
+		return fmt.Errorf("comment box not found: %w", err)
+	}
+	if err := browser.WaitFocusable(box, composeTimeout); err != nil {
+		return fmt.Errorf("comment box not ready: %w", err)
+	}
+	if err := s.au.Do(ctx, p, prof, audit.ActionType, "comment", text, func() error { return s.hu.TypeHumanLike(box, text) }); err != nil {
+		return fmt.Errorf("failed to type comment: %w", err)
+	}
+	submit, err := s.sel.Get("engage.comment_submit").In(post, composeTimeout)
+	if err != nil {
+		browser.ScreenshotOnError(p, "comment_submit_fail", err)
+		return fmt.Errorf("comment submit button not found: %w", err)
+	}
+	if err := browser.WaitEnabled(submit, time.Duration(s.cfg.Timeouts.SendEnabledMs)*time.Millisecond); err != nil {
+		return fmt.Errorf("comment submit never became enabled: %w", err)
+	}
+	stealth.SleepRandom(300, 700)
+	if err := s.au.Do(ctx, p, prof, audit.ActionClick, "Post comment button", "", func() error { return s.hu.ClickHumanLike(p, submit) }); err != nil {
+		return fmt.Errorf("failed to post comment: %w", err)
+	}
+	// The button goes away with the text once the comment is posted
+	if err := browser.WaitGone(submit, time.Duration(s.cfg.Timeouts.SendConfirmMs)*time.Millisecond); err != nil {
+		s.log.Warn("comment box still filled, the comment may not have been posted", "url", prof.LinkedInURL)
+	}
+	return nil
+}
+
+// postText reads the start of the text of post, whitespace collapsed.
+func (s *Service) postText(post driver.Element) string {
+	body, err := s.sel.Get("engage.post_text").In(post, time.Second)
+	if err != nil {
+		return ""
+	}
+	text, err := body.Text()
+	if err != nil {
+		return ""
+	}
+	r := []rune(strings.Join(strings.Fields(text), " "))
+	if len(r) > maxPostText {
+		r = r[:maxPostText]
+	}
+	return string(r)
+}
+
+// postAge reads the age label of post. Months and years are approximated as
+// 30 and 365 days.
+func (s *Service) postAge(post driver.Element) (time.Duration, bool) {
+	label, err := s.sel.Get("engage.post_age").In(post, time.Second)
+	if err != nil {
+		return 0, false
+	}
+	text, err := label.Text()
+	if err != nil {
+		return 0, false
+	}
+	return parsePostAge(text)
+}
+
+func parsePostAge(text string) (time.Duration, bool) {
+	m := postAgeRe.FindStringSubmatch(strings.ToLower(text))
+	if m == nil {
+		return 0, false
+	}
+	n, _ := strconv.Atoi(m[1])
+	day := 24 * time.Hour
+	unit := map[string]time.Duration{
+		"m":  time.Minute,
+		"h":  time.Hour,
+		"d":  day,
+		"w":  7 * day,
+		"mo": 30 * day,
+		"yr": 365 * day,
+	}[m[2]]
+	return time.Duration(n) * unit, true
+}
@@ -0,0 +1,129 @@
+package enrich
+
+import (
+	"context"
+	"time"
+
+	"github.com/example/linkedbot/internal/browser"
+	"github.com/example/linkedbot/internal/config"
+	"github.com/example/linkedbot/internal/driver"
+	"github.com/example/linkedbot/internal/extract"
+	"github.com/example/linkedbot/internal/logging"
+	"github.com/example/linkedbot/internal/models"
+	"github.com/example/linkedbot/internal/stealth"
+	"github.com/example/linkedbot/internal/store"
+)
+
+type Service struct {
+	br  *browser.Browser
+	cfg *config.Config
+	st  *store.Store
+	ex  *extract.Extractor
+	hu  *stealth.Human
+	log *logging.Logger
+}
+
+func New(br *browser.Browser, cfg *config.Config, st *store.Store) *Service {
+	return &Service{br: br, cfg: cfg, st: st, ex: extract.New(cfg, br.Selectors), hu: stealth.New(cfg), log: logging.New(cfg.Logging.Level).With("module", "enrich")}
+}
+
+// EnrichProfiles visits up to limit profiles that were never enriched or
+// were enriched more than refreshAfter ago, and stores their full details.
+// A zero refreshAfter only picks up profiles that were never enriched.
+func (s *Service) EnrichProfiles(ctx context.Context, limit int, refreshAfter time.Duration) (models.RunStats, error) {
+	var stats models.RunStats
+	var staleBefore time.Time
+	if refreshAfter > 0 {
+		staleBefore = time.Now().Add(-refreshAfter)
+	}
+	prog, resumed, err := s.st.ResumeBatch(ctx, "enrich", limit)
+	if err != nil {
+		return stats, err
+	}
+	if resumed {
+		s.log.Info("resuming interrupted run", "done", prog.Processed, "batch", prog.Batch, "last_profile_id", prog.LastProfileID)
+		limit = min(limit, prog.Remaining())
+	}
+	profiles, err := s.st.GetProfilesNeedingEnrichment(ctx, limit, staleBefore)
+	if err != nil {
+		return stats, err
+	}
+	s.log.Info("profiles to enrich", "count", len(profiles))
+	if len(profiles) == 0 {
+		return stats, s.st.FinishBatch(ctx, prog, false)
+	}
+
+	p, err := s.br.NewPage(ctx)
+	if err != nil {
+		return stats, err
+	}
+	defer s.br.ClosePage(p)
+	// The profile in flight is finished on work even after ctx is cancelled
+	work := context.WithoutCancel(ctx)
+	for i, prof := range profiles {
+		if ctx.Err() != nil {
+			stats.Skipped = len(profiles) - i
+			break
+		}
+		if err := s.st.CheckDoNotContact(work, prof.LinkedInURL, "enrich"); err != nil {
+			s.log.Info("skipping profile", "url", prof.LinkedInURL, "reason", err)
+			stats.Skipped++
+			continue
+		}
+		err := s.enrichOne(work, p, &prof)
+		prog.Step(prof.ID, err == nil)
+		if err := s.st.SaveRunProgress(work, prog); err != nil {
+			s.log.Warn("failed to save progress", "err", err)
+		}
+		if err != nil {
+			stats.Fail(prof.LinkedInURL, err)
+			continue
+		}
+		stats.Sent++
+		stealth.SleepRandom(s.cfg.Stealth.MinDelayMs+300, s.cfg.Stealth.MaxDelayMs+900)
+	}
+	if err := s.st.FinishBatch(work, prog, ctx.Err() != nil); err != nil {
+		s.log.Warn("failed to save progress", "err", err)
+	}
+	return stats, nil
+}
+
+func (s *Service) enrichOne(ctx context.Context, p driver.Page, prof *models.Profile) error {
+	if err := p.Navigate(prof.LinkedInURL); err != nil {
+		return err
+	}
+	if err := p.WaitLoad(); err != nil {
+		return err
+	}
+	if s.br.ProfileUnavailable(p) {
+		s.log.Info("profile not available, marking it invalid", "url", prof.LinkedInURL)
+		if err := s.st.MarkUnavailable(ctx, prof.ID); err != nil {
+			s.log.Warn("failed to mark profile invalid", "url", prof.LinkedInURL, "err", err)
+		}
+		return browser.ErrProfileUnavailable
+	}
+	// A changed slug redirects; keep the row on the member's current link
+	if u, err := p.URL(); err == nil {
+		if _, err := s.st.FollowRedirect(ctx, prof, u); err != nil {
+			s.log.Warn("failed to record profile redirect", "url", prof.LinkedInURL, "err", err)
+		}
+	}
+	s.hu.MouseIdleMovement(p)
+	s.hu.ScrollHumanLike(p)
+	s.hu.Read(p)
+
+	// Refresh the basics on the way; they may be empty for imported rows
+	if s.ex.ProfileInfo(p, prof) {
+		if _, err := s.st.UpsertProfile(ctx, prof); err != nil {
+			s.log.Warn("failed to update profile info", "err", err)
+		}
+	}
+	d := s.ex.Details(p, prof.LinkedInURL)
+	d.ProfileID = prof.ID
+	d.EnrichedAt = time.Now()
+	if err := s.st.SaveProfileDetails(ctx, &d); err != nil {
+		return err
+	}
+	s.log.Info("profile enriched", "url", prof.LinkedInURL, "experience", len(d.Experience), "education", len(d.Education), "skills", len(d.Skills), "mutual", d.MutualConnections)
+	return nil
+}
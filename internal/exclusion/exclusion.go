@@ -0,0 +1,138 @@
+package exclusion
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/example/linkedbot/internal/config"
+	"github.com/example/linkedbot/internal/models"
+	"github.com/example/linkedbot/internal/store"
+)
+
+// Kinds of exclusion, as stored in the exclusions table.
+const (
+	KindCompany = "company"
+	KindName    = "name"
+	KindURL     = "url"
+)
+
+// Entry is one exclusion and where it comes from ("config" or "db").
+type Entry struct {
+	Kind   string
+	Value  string
+	Origin string
+}
+
+type company struct {
+	name string
+	// headlineRe finds the company in headlines like "Engineer at Acme" or
+	// "SRE @ Acme" when the company field is empty
+	headlineRe *regexp.Regexp
+}
+
+type namePattern struct {
+	pattern string
+	re      *regexp.Regexp
+}
+
+// List matches profiles against the config exclusions and those stored with
+// `blacklist add`.
+type List struct {
+	entries   []Entry
+	companies []company
+	names     []namePattern
+	urls      map[string]bool
+}
+
+// Load builds the list from cfg.Exclusions and the exclusions table.
+func Load(ctx context.Context, cfg *config.Config, st *store.Store) (*List, error) {
+	var entries []Entry
+	for _, c := range cfg.Exclusions.Companies {
+		entries = append(entries, Entry{Kind: KindCompany, Value: c, Origin: "config"})
+	}
+	for _, n := range cfg.Exclusions.NamePatterns {
+		entries = append(entries, Entry{Kind: KindName, Value: n, Origin: "config"})
+	}
+	for _, u := range cfg.Exclusions.ProfileURLs {
+		entries = append(entries, Entry{Kind: KindURL, Value: u, Origin: "config"})
+	}
+	stored, err := st.GetExclusions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range stored {
+		entries = append(entries, Entry{Kind: e.Kind, Value: e.Value, Origin: "db"})
+	}
+
+	l := &List{urls: map[string]bool{}}
+	for _, e := range entries {
+		value, err := Normalize(e.Kind, e.Value)
+		if err != nil {
+			return nil, fmt.Errorf("%s exclusion: %w", e.Origin, err)
+		}
+		switch e.Kind {
+		case KindCompany:
+			l.companies = append(l.companies, company{
+				name:       value,
+				headlineRe: regexp.MustCompile(`(?i)(^|\s)(at|@)\s*` + regexp.QuoteMeta(value) + `(\W|$)`),
+			})
+		case KindName:
+			l.names = append(l.names, namePattern{pattern: value, re: regexp.MustCompile("(?i)" + value)})
+		case KindURL:
+			l.urls[value] = true
+		}
+	}
+	l.entries = entries
+	return l, nil
+}
+
+// Normalize checks an exclusion value and returns the form it is stored and
+// matched in: canonical profile URLs and trimmed company names.
+func Normalize(kind, value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "", fmt.Errorf("empty %s", kind)
+	}
+	switch kind {
+	case KindCompany:
+		return value, nil
+	case KindName:
+		if _, err := regexp.Compile("(?i)" + value); err != nil {
+			return "", fmt.Errorf("name pattern %q: %w", value, err)
+		}
+		return value, nil
+	case KindURL:
+		if !strings.Contains(value, "/in/") {
+			return "", fmt.Errorf("%q is not a profile URL", value)
+		}
+		return models.CanonicalProfileURL(value), nil
+	}
+	return "", fmt.Errorf("unknown exclusion kind %q", kind)
+}
+
+// Entries returns every exclusion, config ones first.
+func (l *List) Entries() []Entry { return l.entries }
+
+// Match reports whether p is excluded and why. Only the fields known so far
+// are checked, so a profile found by URL alone can still be excluded later by
+// company or name once its page has been read.
+func (l *List) Match(p *models.Profile) (string, bool) {
+	if l.urls[models.CanonicalProfileURL(p.LinkedInURL)] {
+		return "profile URL", true
+	}
+	for _, c := range l.companies {
+		if strings.EqualFold(strings.TrimSpace(p.Company), c.name) || c.headlineRe.MatchString(p.Headline) {
+			return fmt.Sprintf("company %q", c.name), true
+		}
+	}
+	if p.Name != "" {
+		for _, n := range l.names {
+			if n.re.MatchString(p.Name) {
+				return fmt.Sprintf("name pattern %q", n.pattern), true
+			}
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,127 @@
+package export
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/example/linkedbot/internal/models"
+	"github.com/example/linkedbot/internal/store"
+)
+
+// Table is an export before formatting. Cells keep their Go types so JSON
+// gets real booleans, numbers and nulls while CSV gets plain text.
+type Table struct {
+	Header []string
+	Rows   [][]any
+}
+
+// Write formats t as "csv" or "json" (an array of objects keyed by header).
+func Write(w io.Writer, t Table, format string) error {
+	switch format {
+	case "csv":
+		return writeCSV(w, t)
+	case "json":
+		return writeJSON(w, t)
+	default:
+		return fmt.Errorf("unknown export format: %s", format)
+	}
+}
+
+// Profiles lists profiles with their pipeline timestamps. The yes/no columns
+// are derived from them for spreadsheets that filter on them.
+func Profiles(profiles []models.Profile) Table {
+	t := Table{Header: []string{"id", "linkedin_url", "name", "headline", "company", "location", "source", "degree", "open_profile", "requires_email", "email", "status",
+		"connection_sent", "connection_sent_at", "connection_accepted", "connection_checked_at", "message_sent", "message_sent_at",
+		"replied", "replied_at", "withdrawn", "withdrawn_at", "engaged_at", "viewed_at", "endorsed_skills", "created_at"}}
+	for _, p := range profiles {
+		t.Rows = append(t.Rows, []any{p.ID, p.LinkedInURL, p.Name, p.Headline, p.Company, p.Location, p.Source, p.Degree, p.OpenProfile, p.RequiresEmail, p.Email, string(p.Status),
+			p.ConnectionSentAt != nil, p.ConnectionSentAt, p.ConnectionCheckedAt != nil, p.ConnectionCheckedAt, p.MessageSentAt != nil, p.MessageSentAt,
+			p.RepliedAt != nil, p.RepliedAt, p.WithdrawnAt != nil, p.WithdrawnAt, p.EngagedAt, p.ViewedAt, p.EndorsedSkills, p.CreatedAt})
+	}
+	return t
+}
+
+// Accepted lists accepted and messaged profiles with the note and follow-up
+// used. Name and profile URL identify a person and are only included when
+// includePII is set.
+func Accepted(rows []store.AcceptedRow, includePII bool) Table {
+	t := Table{Header: []string{"headline", "company", "location", "source", "connection_sent_at", "accepted_at", "message_sent_at", "note", "follow_up"}}
+	if includePII {
+		t.Header = append([]string{"name", "linkedin_url"}, t.Header...)
+	}
+	for _, r := range rows {
+		rec := []any{r.Headline, r.Company, r.Location, r.Source, nullTime(r.ConnectionSentAt), nullTime(r.AcceptedAt), nullTime(r.MessageSentAt), r.Note, r.FollowUp}
+		if includePII {
+			rec = append([]any{r.Name, r.LinkedInURL}, rec...)
+		}
+		t.Rows = append(t.Rows, rec)
+	}
+	return t
+}
+
+func writeCSV(w io.Writer, t Table) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(t.Header); err != nil {
+		return err
+	}
+	rec := make([]string, len(t.Header))
+	for _, row := range t.Rows {
+		for i, v := range row {
+			rec[i] = cell(v)
+		}
+		if err := cw.Write(rec); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func writeJSON(w io.Writer, t Table) error {
+	objs := make([]map[string]any, 0, len(t.Rows))
+	for _, row := range t.Rows {
+		obj := make(map[string]any, len(t.Header))
+		for i, v := range row {
+			obj[t.Header[i]] = v
+		}
+		objs = append(objs, obj)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(objs)
+}
+
+func cell(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case time.Time:
+		if v.IsZero() {
+			return ""
+		}
+		return v.Format(time.RFC3339)
+	case *time.Time:
+		if v == nil {
+			return ""
+		}
+		return v.Format(time.RFC3339)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func nullTime(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
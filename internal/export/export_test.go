@@ -0,0 +1,61 @@
+package export
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/example/linkedbot/internal/store"
+)
+
+func TestAcceptedCSV(t *testing.T) {
+	at := time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC)
+	rows := []store.AcceptedRow{{
+		LinkedInURL:      "https://www.linkedin.com/in/jane-doe",
+		Name:             "Jane Doe",
+		Headline:         "Engineer at Acme",
+		Company:          "Acme",
+		Location:         "Berlin",
+		Source:           "search:go",
+		ConnectionSentAt: sql.NullTime{Time: at, Valid: true},
+		AcceptedAt:       sql.NullTime{Time: at.Add(24 * time.Hour), Valid: true},
+		MessageSentAt:    sql.NullTime{Time: at.Add(48 * time.Hour), Valid: true},
+		Note:             "Hi Jane, let's connect",
+		FollowUp:         "Thanks for connecting",
+	}}
+	shared := []string{"Engineer at Acme", "Acme", "Berlin", "search:go", "2024-03-01T09:30:00Z", "2024-03-02T09:30:00Z", "2024-03-03T09:30:00Z", "Hi Jane, let's connect", "Thanks for connecting"}
+	header := []string{"headline", "company", "location", "source", "connection_sent_at", "accepted_at", "message_sent_at", "note", "follow_up"}
+
+	cases := []struct {
+		name       string
+		includePII bool
+		want       [][]string
+	}{
+		{"without PII", false, [][]string{header, shared}},
+		{"with PII", true, [][]string{
+			append([]string{"name", "linkedin_url"}, header...),
+			append([]string{"Jane Doe", "https://www.linkedin.com/in/jane-doe"}, shared...),
+		}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var b strings.Builder
+			if err := Write(&b, Accepted(rows, c.includePII), "csv"); err != nil {
+				t.Fatal(err)
+			}
+			got, err := csv.NewReader(strings.NewReader(b.String())).ReadAll()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, c.want) {
+				t.Errorf("got\n%q\nwant\n%q", got, c.want)
+			}
+			if !c.includePII && (strings.Contains(b.String(), "Jane Doe") || strings.Contains(b.String(), "linkedin.com")) {
+				t.Error("PII exported without --include-pii")
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package extract
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/example/linkedbot/internal/driver"
+	"github.com/example/linkedbot/internal/models"
+)
+
+// Sections on the profile page are anchored by an empty div with the
+// section id; the list items hold the visible text in aria-hidden spans.
+const (
+	aboutSelector      = `section:has(#about) .inline-show-more-text span[aria-hidden="true"], section:has(#about) div.display-flex span[aria-hidden="true"]`
+	experienceSelector = `section:has(#experience) li.artdeco-list__item`
+	educationSelector  = `section:has(#education) li.artdeco-list__item`
+	skillItemSelector  = `li.artdeco-list__item`
+	itemTextSelector   = `span[aria-hidden="true"]`
+)
+
+// Details reads the about section, experience, education and mutual
+// connection count from the open profile page, then loads the skills page.
+// Missing sections are left empty.
+func (e *Extractor) Details(p driver.Page, profileURL string) models.ProfileDetails {
+	var d models.ProfileDetails
+	if e.cfg.Extraction.ExpandSeeMore {
+		e.expandSeeMore(p)
+	}
+	if el, err := p.Timeout(2 * time.Second).Element(aboutSelector); err == nil {
+		if text, err := el.Text(); err == nil {
+			d.About = e.cleanSeeMore(text)
+		}
+	}
+
+	for _, item := range listItems(p, experienceSelector) {
+		// title, "Company · Full-time", dates, ...
+		pos := models.Position{Title: at(item, 0), Company: at(item, 1), Dates: at(item, 2)}
+		if i := strings.Index(pos.Company, " · "); i >= 0 {
+			pos.Company = pos.Company[:i]
+		}
+		if pos.Title != "" {
+			d.Experience = append(d.Experience, pos)
+		}
+	}
+	for _, item := range listItems(p, educationSelector) {
+		sch := models.School{School: at(item, 0), Degree: at(item, 1), Dates: at(item, 2)}
+		if sch.School != "" {
+			d.Education = append(d.Education, sch)
+		}
+	}
+
+	if el, err := e.sel.Get("profile.mutual_connections").Find(p, 2*time.Second); err == nil {
+		if text, err := el.Text(); err == nil {
+			d.MutualConnections = e.mutualCount(text)
+		}
+	}
+
+	d.Skills = e.skills(p, profileURL)
+	return d
+}
+
+// skills loads /details/skills/, which lists every skill rather than the
+// top few shown on the profile.
+func (e *Extractor) skills(p driver.Page, profileURL string) []string {
+	if err := p.Navigate(strings.TrimRight(profileURL, "/") + "/details/skills/"); err != nil {
+		e.log.Debug("skills page navigation failed", "err", err)
+		return nil
+	}
+	if err := p.WaitLoad(); err != nil {
+		return nil
+	}
+	time.Sleep(1500 * time.Millisecond)
+	var out []string
+	seen := map[string]bool{}
+	for _, item := range listItems(p, skillItemSelector) {
+		if skill := at(item, 0); skill != "" && !seen[skill] {
+			seen[skill] = true
+			out = append(out, skill)
+		}
+	}
+	return out
+}
+
+// listItems returns the visible texts of each list item, one slice per item.
+func listItems(p driver.Page, sel string) [][]string {
+	items, err := p.Timeout(3 * time.Second).Elements(sel)
+	if err != nil {
+		return nil
+	}
+	var out [][]string
+	for _, item := range items {
+		spans, err := item.Elements(itemTextSelector)
+		if err != nil {
+			continue
+		}
+		var texts []string
+		for _, s := range spans {
+			if t, err := s.Text(); err == nil && strings.TrimSpace(t) != "" {
+				texts = append(texts, strings.TrimSpace(t))
+			}
+		}
+		out = append(out, texts)
+	}
+	return out
+}
+
+func at(texts []string, i int) string {
+	if i < len(texts) {
+		return texts[i]
+	}
+	return ""
+}
+
+// mutualCount reads "Ann and Bob are mutual connections", "Ann, Bob and 12
+// other mutual connections" or "34 mutual connections", in the wording of
+// the profile.mutual_* patterns.
+func (e *Extractor) mutualCount(text string) int {
+	if m := e.sel.Pattern("profile.mutual_others").FindStringSubmatchIndex(text); m != nil {
+		n, _ := strconv.Atoi(text[m[2]:m[3]])
+		return n + len(strings.Split(text[:m[0]], ","))
+	}
+	if m := e.sel.Pattern("profile.mutual_count").FindStringSubmatch(text); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		return n
+	}
+	switch {
+	case e.sel.Pattern("profile.mutual_names").MatchString(text):
+		return len(strings.Split(text, ",")) + 1
+	case e.sel.Pattern("profile.mutual_one").MatchString(text):
+		return 1
+	}
+	return 0
+}
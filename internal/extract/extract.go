@@ -0,0 +1,276 @@
+package extract
+
+import (
+	"html"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/example/linkedbot/internal/config"
+	"github.com/example/linkedbot/internal/driver"
+	"github.com/example/linkedbot/internal/logging"
+	"github.com/example/linkedbot/internal/models"
+	"github.com/example/linkedbot/internal/selectors"
+)
+
+// Extractor reads profile details from an open profile page. It is shared by
+// the connection and messaging flows so both see the same data.
+type Extractor struct {
+	cfg *config.Config
+	sel *selectors.Registry
+	log *logging.Logger
+}
+
+func New(cfg *config.Config, sel *selectors.Registry) *Extractor {
+	return &Extractor{cfg: cfg, sel: sel, log: logging.New(cfg.Logging.Level).With("module", "extract")}
+}
+
+var headlineSelectors = []string{
+	`div.text-body-medium`,
+	`div[class*="headline"]`,
+	`.pv-text-details__left-panel div:nth-child(2)`,
+}
+
+// seeMoreScopes are the containers whose text LinkedIn truncates behind a
+// "…see more" toggle.
+var seeMoreScopes = []string{
+	`.pv-text-details__left-panel`,
+	`section.artdeco-card`,
+	`.inline-show-more-text`,
+}
+
+// degreeSelectors locate the "· 1st/2nd/3rd" distance badge in the top card.
+var degreeSelectors = []string{
+	`.pv-top-card .dist-value`,
+	`span.dist-value`,
+	`.distance-badge`,
+	`[class*="distance-badge"]`,
+}
+
+// ProfileInfo fills in name, headline and company on prof from the current
+// page. It returns true when any field was extracted.
+func (e *Extractor) ProfileInfo(p driver.Page, prof *models.Profile) bool {
+	// Extract name from h1 heading
+	if nameEl, err := p.Timeout(3 * time.Second).Element("h1"); err == nil {
+		if name, err := nameEl.Text(); err == nil {
+			prof.Name = strings.TrimSpace(name)
+			e.log.Info("extracted name", "name", prof.Name)
+		}
+	}
+
+	// Truncated headlines lose the " at Company" suffix, so expand first
+	if e.cfg.Extraction.ExpandSeeMore {
+		e.expandSeeMore(p)
+	}
+
+	for _, sel := range headlineSelectors {
+		if headlineEl, err := p.Timeout(2 * time.Second).Element(sel); err == nil {
+			if headline, err := headlineEl.Text(); err == nil {
+				headline = e.cleanSeeMore(headline)
+				// Make sure it's not the name
+				if headline != prof.Name && len(headline) > 0 {
+					prof.Headline = headline
+					e.log.Info("extracted headline", "headline", prof.Headline)
+					break
+				}
+			}
+		}
+	}
+
+	if prof.Company == "" {
+		if prof.Company = CompanyFromHeadline(e.sel.Pattern("profile.headline_company"), prof.Headline); prof.Company != "" {
+			e.log.Info("extracted company from headline", "company", prof.Company)
+		}
+	}
+
+	// If we still don't have company, try the experience section
+	if prof.Company == "" {
+		if companyEl, err := p.Timeout(2 * time.Second).Element(`#experience ~ div span[aria-hidden="true"]`); err == nil {
+			if company, err := companyEl.Text(); err == nil {
+				prof.Company = strings.TrimSpace(company)
+				e.log.Info("extracted company from experience", "company", prof.Company)
+			}
+		}
+	}
+
+	if prof.MemberURN == "" {
+		if urn := e.MemberURN(p, prof.LinkedInURL); urn != "" {
+			prof.MemberURN = urn
+			e.log.Info("extracted member urn", "urn", urn)
+		}
+	}
+
+	return prof.Name != "" || prof.Headline != "" || prof.Company != "" || prof.MemberURN != ""
+}
+
+// CompanyFromHeadline returns the company of a headline like "Software
+// Engineer at Company", or "". at is the profile.headline_company pattern.
+func CompanyFromHeadline(at selectors.Pattern, headline string) string {
+	if m := at.FindStringSubmatch(headline); m != nil {
+		return strings.TrimSpace(m[1])
+	}
+	return ""
+}
+
+// expandSeeMore clicks any "see more" toggle in the top card or about
+// section. Pages without a toggle are left untouched.
+func (e *Extractor) expandSeeMore(p driver.Page) {
+	for _, scope := range seeMoreScopes {
+		container, err := p.Timeout(2 * time.Second).Element(scope)
+		if err != nil {
+			continue
+		}
+		toggle, err := e.sel.Get("profile.see_more_toggle").In(container, time.Second)
+		if err != nil {
+			continue
+		}
+		if visible, _ := toggle.Visible(); !visible {
+			continue
+		}
+		if err := toggle.Click(); err != nil {
+			e.log.Debug("see more click failed", "scope", scope, "err", err)
+			continue
+		}
+		e.log.Debug("expanded truncated text", "scope", scope)
+		time.Sleep(400 * time.Millisecond)
+		return
+	}
+}
+
+// cleanSeeMore strips a trailing "…see more" label left in collapsed text.
+func (e *Extractor) cleanSeeMore(s string) string {
+	s = strings.TrimSpace(s)
+	if m := e.sel.Pattern("profile.see_more_suffix").FindStringSubmatchIndex(s); m != nil {
+		s = strings.TrimSpace(s[:m[0]])
+		s = strings.TrimRight(s, "…. ")
+	}
+	return s
+}
+
+// ConnectionDegree returns the distance badge shown on the profile ("1st",
+// "2nd", "3rd") or "" when no badge is found. Only real connections carry
+// the 1st-degree badge, unlike the Message button which open profiles and
+// premium members also show to strangers.
+func (e *Extractor) ConnectionDegree(p driver.Page) string {
+	for _, sel := range degreeSelectors {
+		el, err := p.Timeout(2 * time.Second).Element(sel)
+		if err != nil {
+			continue
+		}
+		text, err := el.Text()
+		if err != nil {
+			continue
+		}
+		if d := ParseDegree(e.sel.Pattern("profile.degree"), text); d != "" {
+			return d
+		}
+	}
+	// Fall back to the badge text itself, e.g. "· 1st" next to the name
+	if el, err := e.sel.Get("profile.degree_badge").Find(p, 2*time.Second); err == nil {
+		if text, err := el.Text(); err == nil {
+			return ParseDegree(e.sel.Pattern("profile.degree"), text)
+		}
+	}
+	return ""
+}
+
+// Accepted decides whether the open profile is a 1st-degree connection and
+// reports which signal decided it, per messaging.acceptance_signal. With
+// "badge" a 2nd/3rd badge outweighs a Message button, which open profiles
+// show to strangers too; the button only decides when no badge renders.
+func (e *Extractor) Accepted(p driver.Page) (bool, string) {
+	if e.cfg.Messaging.AcceptanceSignal == "badge" {
+		switch e.ConnectionDegree(p) {
+		case "1st":
+			return true, "badge"
+		case "":
+			// No badge rendered, fall back to the Message button
+		default:
+			return false, "badge"
+		}
+	}
+	if e.sel.Get("profile.message_signal").Has(p) {
+		return true, "message_button"
+	}
+	return false, "message_button"
+}
+
+// ParseDegree returns the first distance ("1st", "2nd" or "3rd") deg finds in
+// text, e.g. a badge or a search result card, or "" when there is none. deg
+// captures the digit, so the result is the same whatever the UI language.
+func ParseDegree(deg selectors.Pattern, text string) string {
+	m := deg.FindStringSubmatch(text)
+	if m == nil {
+		return ""
+	}
+	switch m[1] {
+	case "1":
+		return "1st"
+	case "2":
+		return "2nd"
+	case "3":
+		return "3rd"
+	}
+	return ""
+}
+
+var urnRe = regexp.MustCompile(`urn:li:fsd_profile:[A-Za-z0-9_-]+`)
+
+// MemberURN finds the member URN (urn:li:fsd_profile:ACoAA...) of the
+// profile at profileURL. LinkedIn does not render it visibly: it lives in the
+// JSON payloads embedded in <code> blocks, next to the member's
+// "publicIdentifier" (their /in/<slug>), and in hrefs such as
+// "...?profileUrn=urn%3Ali%3Afsd_profile%3A...". Other people's URNs appear
+// on the same page (sidebar suggestions), so the match closest to the slug
+// wins. Returns "" when nothing is found.
+func (e *Extractor) MemberURN(p driver.Page, profileURL string) string {
+	raw, err := p.HTML()
+	if err != nil {
+		return ""
+	}
+	return findMemberURN(html.UnescapeString(raw), slugOf(profileURL))
+}
+
+func findMemberURN(doc, slug string) string {
+	doc = strings.ReplaceAll(doc, "%3A", ":")
+	if slug != "" {
+		marker := `"publicIdentifier":"` + slug + `"`
+		if i := strings.Index(doc, marker); i >= 0 {
+			// Prefer the URN inside the same JSON object as the identifier
+			start := strings.LastIndex(doc[:i], "{")
+			end := strings.Index(doc[i:], "}")
+			if start >= 0 && end >= 0 {
+				if urn := urnRe.FindString(doc[start : i+end]); urn != "" {
+					return urn
+				}
+			}
+			best, bestDist := "", -1
+			for _, loc := range urnRe.FindAllStringIndex(doc, -1) {
+				dist := loc[0] - i
+				if dist < 0 {
+					dist = -dist
+				}
+				if bestDist < 0 || dist < bestDist {
+					best, bestDist = doc[loc[0]:loc[1]], dist
+				}
+			}
+			if best != "" {
+				return best
+			}
+		}
+	}
+	// Without an identifier to anchor on, take the first URN on the page
+	return urnRe.FindString(doc)
+}
+
+func slugOf(profileURL string) string {
+	i := strings.Index(profileURL, "/in/")
+	if i < 0 {
+		return ""
+	}
+	slug := profileURL[i+len("/in/"):]
+	if j := strings.IndexAny(slug, "/?#"); j >= 0 {
+		slug = slug[:j]
+	}
+	return slug
+}
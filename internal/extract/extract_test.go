@@ -0,0 +1,200 @@
+package extract
+
+import (
+	"context"
+	"html"
+	"testing"
+
+	"github.com/example/linkedbot/internal/config"
+	"github.com/example/linkedbot/internal/models"
+	"github.com/example/linkedbot/internal/rodtest"
+	"github.com/example/linkedbot/internal/selectors"
+)
+
+// newTestExtractor returns an Extractor on the built-in English selectors.
+func newTestExtractor(t *testing.T, expandSeeMore bool) *Extractor {
+	t.Helper()
+	cfg := &config.Config{}
+	cfg.LinkedIn.Locale = "en"
+	cfg.Logging.Level = "error"
+	cfg.Extraction.ExpandSeeMore = expandSeeMore
+	cfg.Messaging.AcceptanceSignal = "badge"
+	sel, err := selectors.Load(context.Background(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return New(cfg, sel)
+}
+
+// seeMoreFixture is a top card whose headline is cut off behind a toggle
+// that, like LinkedIn's, swaps in the full text when clicked.
+const seeMoreFixture = `<html><body>
+<div class="pv-text-details__left-panel">
+  <h1>Jane Doe</h1>
+  <div class="text-body-medium">Senior Engineer at Acme Cor…
+    <button class="inline-show-more-text__button"
+      onclick="this.parentElement.textContent = 'Senior Engineer at Acme Corporation'">see more</button>
+  </div>
+</div>
+</body></html>`
+
+const noToggleFixture = `<html><body>
+<div class="pv-text-details__left-panel">
+  <h1>Jane Doe</h1>
+  <div class="text-body-medium">Senior Engineer at Acme Corporation</div>
+</div>
+</body></html>`
+
+// topCard is a profile top card with the distance badge badge (none when
+// empty) and, with message set, a Message button.
+func topCard(badge string, message bool) string {
+	doc := `<html><body><div class="pv-top-card"><h1>Jane Doe</h1>`
+	if badge != "" {
+		doc += `<span class="dist-value">` + badge + `</span>`
+	}
+	if message {
+		doc += `<button aria-label="Message Jane Doe">Message</button>`
+	} else {
+		doc += `<button aria-label="Invite Jane Doe to connect">Connect</button>`
+	}
+	return doc + `</div></body></html>`
+}
+
+func TestAcceptedBadgeVersusMessageButton(t *testing.T) {
+	cases := []struct {
+		name     string
+		doc      string
+		signal   string
+		accepted bool
+		by       string
+	}{
+		{"1st-degree connection", topCard("1st", true), "badge", true, "badge"},
+		// Open profiles and premium members offer Message to strangers
+		{"open profile 2nd", topCard("2nd", true), "badge", false, "badge"},
+		{"open profile 3rd", topCard("3rd+", true), "badge", false, "badge"},
+		{"pending invite", topCard("2nd", false), "badge", false, "badge"},
+		{"bare badge text", `<html><body><h1>Jane Doe</h1><span>· 1st</span></body></html>`, "badge", true, "badge"},
+		{"no badge, Message", topCard("", true), "badge", true, "message_button"},
+		{"no badge, Connect", topCard("", false), "badge", false, "message_button"},
+		// acceptance_signal: message trusts the button alone
+		{"message signal, open profile", topCard("2nd", true), "message", true, "message_button"},
+	}
+	b := rodtest.Browser(t)
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			e := newTestExtractor(t, false)
+			e.cfg.Messaging.AcceptanceSignal = c.signal
+			accepted, by := e.Accepted(rodtest.Page(t, b, c.doc))
+			if accepted != c.accepted || by != c.by {
+				t.Errorf("Accepted = %v by %s, want %v by %s", accepted, by, c.accepted, c.by)
+			}
+		})
+	}
+}
+
+func TestParseDegree(t *testing.T) {
+	deg := newTestExtractor(t, false).sel.Pattern("profile.degree")
+	cases := map[string]string{
+		"1st":                     "1st",
+		"· 2nd":                   "2nd",
+		"3rd+ degree connection":  "3rd",
+		"Jane Doe · 1st · Berlin": "1st",
+		"Message":                 "",
+		"21st Century Fox":        "",
+	}
+	for text, want := range cases {
+		if got := ParseDegree(deg, text); got != want {
+			t.Errorf("ParseDegree(%q) = %q, want %q", text, got, want)
+		}
+	}
+}
+
+func TestConnectionDegree(t *testing.T) {
+	b := rodtest.Browser(t)
+	for badge, want := range map[string]string{"1st": "1st", "· 2nd": "2nd", "3rd+": "3rd", "": ""} {
+		if got := newTestExtractor(t, false).ConnectionDegree(rodtest.Page(t, b, topCard(badge, true))); got != want {
+			t.Errorf("badge %q: got %q, want %q", badge, got, want)
+		}
+	}
+}
+
+func TestCleanSeeMore(t *testing.T) {
+	e := newTestExtractor(t, true)
+	cases := map[string]string{
+		"Senior Engineer at Acme Cor… see more":   "Senior Engineer at Acme Cor",
+		"Senior Engineer at Acme Cor... See more": "Senior Engineer at Acme Cor",
+		"Senior Engineer at Acme Corporation":     "Senior Engineer at Acme Corporation",
+	}
+	for in, want := range cases {
+		if got := e.cleanSeeMore(in); got != want {
+			t.Errorf("cleanSeeMore(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestProfileInfoSeeMore(t *testing.T) {
+	cases := []struct {
+		name         string
+		doc          string
+		expand       bool
+		wantHeadline string
+		wantCompany  string
+	}{
+		{"expanded", seeMoreFixture, true, "Senior Engineer at Acme Corporation", "Acme Corporation"},
+		{"left truncated", seeMoreFixture, false, "Senior Engineer at Acme Cor", "Acme Cor"},
+		{"no toggle", noToggleFixture, true, "Senior Engineer at Acme Corporation", "Acme Corporation"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			p := rodtest.Page(t, rodtest.Browser(t), c.doc)
+			var prof models.Profile
+			if !newTestExtractor(t, c.expand).ProfileInfo(p, &prof) {
+				t.Fatal("nothing extracted")
+			}
+			if prof.Headline != c.wantHeadline || prof.Company != c.wantCompany {
+				t.Errorf("got headline %q, company %q; want %q, %q", prof.Headline, prof.Company, c.wantHeadline, c.wantCompany)
+			}
+		})
+	}
+}
+
+// urnFixture is trimmed from a profile page: the payloads LinkedIn embeds
+// in <code> blocks are HTML-escaped JSON, and the sidebar suggestions carry
+// other members' URNs ahead of the profile's own.
+const urnFixture = `<html><body>
+<code style="display: none" id="bpr-guid-1">{&quot;included&quot;:[{&quot;entityUrn&quot;:&quot;urn:li:fsd_profile:ACoAAOtherMember1&quot;,&quot;publicIdentifier&quot;:&quot;someone-else&quot;}]}</code>
+<code style="display: none" id="bpr-guid-2">{&quot;included&quot;:[{&quot;firstName&quot;:&quot;Jane&quot;,&quot;entityUrn&quot;:&quot;urn:li:fsd_profile:ACoAAJaneDoe42&quot;,&quot;publicIdentifier&quot;:&quot;jane-doe&quot;}]}</code>
+<a href="/in/someone-else/?profileUrn=urn%3Ali%3Afsd_profile%3AACoAAOtherMember1">Someone Else</a>
+</body></html>`
+
+// hrefFixture has no embedded payload, only a link carrying the URN
+// percent-encoded.
+const hrefFixture = `<html><body>
+<a href="/messaging/compose/?profileUrn=urn%3Ali%3Afsd_profile%3AACoAAFromHref_9&amp;recipient=x">Message</a>
+</body></html>`
+
+func TestFindMemberURN(t *testing.T) {
+	cases := []struct {
+		name, doc, url, want string
+	}{
+		{"payload next to slug", urnFixture, "https://www.linkedin.com/in/jane-doe/", "urn:li:fsd_profile:ACoAAJaneDoe42"},
+		{"other member's payload", urnFixture, "https://www.linkedin.com/in/someone-else", "urn:li:fsd_profile:ACoAAOtherMember1"},
+		{"encoded href", hrefFixture, "https://www.linkedin.com/in/jane-doe/", "urn:li:fsd_profile:ACoAAFromHref_9"},
+		{"no urn", seeMoreFixture, "https://www.linkedin.com/in/jane-doe/", ""},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			// MemberURN unescapes the page HTML the same way
+			if got := findMemberURN(html.UnescapeString(c.doc), slugOf(c.url)); got != c.want {
+				t.Errorf("got %q, want %q", got, c.want)
+			}
+		})
+	}
+}
+
+func TestMemberURNFromPage(t *testing.T) {
+	p := rodtest.Page(t, rodtest.Browser(t), urnFixture)
+	if got, want := newTestExtractor(t, false).MemberURN(p, "https://www.linkedin.com/in/jane-doe/"), "urn:li:fsd_profile:ACoAAJaneDoe42"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,39 @@
+package extract
+
+import (
+	"strings"
+
+	"github.com/example/linkedbot/internal/driver"
+	"github.com/example/linkedbot/internal/models"
+)
+
+// Card selectors for the My Network lists.
+const (
+	ConnectionCardSelector = `li.mn-connection-card, div.mn-connection-card, [data-view-name="connections-list"] li, [componentkey*="connection"]`
+	InvitationCardSelector = `li.invitation-card, div.invitation-card, [data-view-name="pending-invitation"]`
+)
+
+// CardProfile reads the profile link, name and occupation from a connection
+// or invitation card. ok is false for cards without a profile link.
+func CardProfile(card driver.Element) (prof models.Profile, ok bool) {
+	link, err := card.Element(`a[href*="/in/"]`)
+	if err != nil {
+		return prof, false
+	}
+	href, err := link.Attribute("href")
+	if err != nil || href == nil {
+		return prof, false
+	}
+	prof.LinkedInURL = models.CanonicalProfileURL(*href)
+	if el, err := card.Element(`.mn-connection-card__name, .invitation-card__title, span[aria-hidden="true"]`); err == nil {
+		if name, err := el.Text(); err == nil {
+			prof.Name = strings.TrimSpace(name)
+		}
+	}
+	if el, err := card.Element(`.mn-connection-card__occupation, .invitation-card__subtitle`); err == nil {
+		if headline, err := el.Text(); err == nil {
+			prof.Headline = strings.TrimSpace(headline)
+		}
+	}
+	return prof, true
+}
@@ -0,0 +1,157 @@
+package importer
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/example/linkedbot/internal/models"
+	"github.com/example/linkedbot/internal/store"
+)
+
+// Result counts what an import did with each row.
+type Result struct {
+	Added      int
+	Existing   int
+	Invalid    int
+	Suppressed int // on the do-not-contact list
+	Malformed  []string
+}
+
+// columns maps header names found in hand-made lists and Sales Navigator
+// exports to profile fields.
+var columns = map[string]string{
+	"url":          "url",
+	"linkedin_url": "url",
+	"linkedin url": "url",
+	"profile_url":  "url",
+	"profile url":  "url",
+	"name":         "name",
+	"full name":    "name",
+	"company":      "company",
+	"headline":     "headline",
+	"title":        "headline",
+	"location":     "location",
+	"reason":       "reason",
+}
+
+// CSV imports profile URLs from r. A header row is optional: without one the
+// first column is taken as the URL. Only /in/ profile links can be stored;
+// other URLs (e.g. Sales Navigator leads) are counted as invalid, and
+// profiles on the do-not-contact list as suppressed.
+func CSV(ctx context.Context, st *store.Store, r io.Reader, source string) (Result, error) {
+	var res Result
+	err := eachRow(r, &res, func(line int, u string, get func(string) string) error {
+		p := &models.Profile{
+			LinkedInURL: u,
+			Name:        get("name"),
+			Headline:    get("headline"),
+			Company:     get("company"),
+			Location:    get("location"),
+			Source:      source,
+		}
+		added, err := st.ImportProfile(ctx, p)
+		switch {
+		case errors.Is(err, store.ErrDoNotContact):
+			res.Suppressed++
+		case err != nil:
+			return fmt.Errorf("line %d: %w", line, err)
+		case added:
+			res.Added++
+		default:
+			res.Existing++
+		}
+		return nil
+	})
+	return res, err
+}
+
+// DoNotContactCSV adds the profile URLs in r to the do-not-contact list, in
+// the same formats CSV accepts. A reason column overrides reason per row.
+// Added counts newly listed URLs, Existing those listed already.
+func DoNotContactCSV(ctx context.Context, st *store.Store, r io.Reader, reason string) (Result, error) {
+	var res Result
+	err := eachRow(r, &res, func(line int, u string, get func(string) string) error {
+		why := get("reason")
+		if why == "" {
+			why = reason
+		}
+		added, err := st.AddDoNotContact(ctx, u, why)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+		if added {
+			res.Added++
+		} else {
+			res.Existing++
+		}
+		return nil
+	})
+	return res, err
+}
+
+// eachRow calls fn with every profile URL row of r, counting rows whose URL
+// is not a profile link as invalid in res.
+func eachRow(r io.Reader, res *Result, fn func(line int, u string, get func(string) string) error) error {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	idx := map[string]int{"url": 0}
+	first := true
+	for line := 1; ; line++ {
+		rec, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+		if first {
+			first = false
+			if h := headerIndex(rec); h != nil {
+				idx = h
+				continue
+			}
+		}
+
+		get := func(field string) string {
+			if i, ok := idx[field]; ok && i < len(rec) {
+				return strings.TrimSpace(rec[i])
+			}
+			return ""
+		}
+		u := get("url")
+		if u == "" {
+			continue
+		}
+		if !strings.Contains(u, "/in/") {
+			res.Invalid++
+			res.Malformed = append(res.Malformed, fmt.Sprintf("line %d: %s", line, u))
+			continue
+		}
+		if err := fn(line, u, get); err != nil {
+			return err
+		}
+	}
+}
+
+// headerIndex returns the column positions when rec is a header row, nil
+// otherwise.
+func headerIndex(rec []string) map[string]int {
+	idx := map[string]int{}
+	for i, h := range rec {
+		if field, ok := columns[strings.ToLower(strings.TrimSpace(h))]; ok {
+			if _, dup := idx[field]; !dup {
+				idx[field] = i
+			}
+		}
+	}
+	if _, ok := idx["url"]; !ok {
+		return nil
+	}
+	return idx
+}
@@ -0,0 +1,42 @@
+package importer
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/example/linkedbot/internal/store"
+)
+
+func TestCSVStoresCanonicalURL(t *testing.T) {
+	ctx := context.Background()
+	st, err := store.Open("sqlite", filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.Close()
+	if err := st.Migrate(ctx); err != nil {
+		t.Fatal(err)
+	}
+	csv := "url,name\n" +
+		"https://www.linkedin.com/in/jane-doe/?trk=public_profile,Jane Doe\n" +
+		"linkedin.com/in/jane-doe,Jane\n" +
+		"/in/john-roe/overlay/contact-info/,John Roe\n"
+	res, err := CSV(ctx, st, strings.NewReader(csv), "csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Added != 2 || res.Existing != 1 {
+		t.Errorf("added %d, existing %d; want 2 and 1", res.Added, res.Existing)
+	}
+	for _, u := range []string{"https://www.linkedin.com/in/jane-doe", "https://www.linkedin.com/in/john-roe"} {
+		p, err := st.GetProfileByURL(ctx, u)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p == nil || p.LinkedInURL != u {
+			t.Errorf("profile %s not stored in canonical form: %+v", u, p)
+		}
+	}
+}
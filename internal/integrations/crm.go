@@ -0,0 +1,287 @@
+package integrations
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/example/linkedbot/internal/config"
+	"github.com/example/linkedbot/internal/logging"
+	"github.com/example/linkedbot/internal/models"
+	"github.com/example/linkedbot/internal/retry"
+	"github.com/example/linkedbot/internal/store"
+	"github.com/example/linkedbot/internal/templates"
+)
+
+// CRM is where crm-sync writes contacts and deals.
+type CRM interface {
+	// UpsertContact updates contact id, or creates one when id is empty or
+	// no longer exists, and returns its id.
+	UpsertContact(ctx context.Context, id string, fields map[string]string) (string, error)
+	// CreateDeal opens a deal named name for the contact and returns its id.
+	CreateDeal(ctx context.Context, contactID, name string) (string, error)
+}
+
+// baseURLs are the API roots used when crm.base_url is empty.
+var baseURLs = map[string]string{
+	"hubspot":   "https://api.hubapi.com",
+	"pipedrive": "https://api.pipedrive.com/v1",
+}
+
+// defaultFields are the mappings used when crm.fields is empty.
+var defaultFields = map[string]map[string]string{
+	"hubspot": {
+		"firstname": "{{.Name}}",
+		"lastname":  "{{.LastName}}",
+		"company":   "{{.Company}}",
+		"jobtitle":  "{{.Title}}",
+		"city":      "{{.Location}}",
+	},
+	"pipedrive": {
+		"name": "{{.FullName}}",
+	},
+}
+
+// fieldData is what the field and deal name templates see.
+type fieldData struct {
+	templates.Data
+	LastName   string
+	URL        string
+	Status     string
+	ReplyLabel string
+	Reply      string
+}
+
+// Syncer pushes the profiles in crm.statuses to the configured CRM. What was
+// pushed is kept per profile, so a profile is only sent again when its
+// status changes or a new reply comes in, and a contact or deal is never
+// created twice.
+type Syncer struct {
+	cfg      *config.Config
+	st       *store.Store
+	crm      CRM
+	fields   map[string]*template.Template
+	dealName *template.Template
+	// texts are the field templates joined, for templates.NewData
+	texts string
+	log   *logging.Logger
+}
+
+func New(cfg *config.Config, st *store.Store) (*Syncer, error) {
+	key := cfg.CRMKey()
+	if key == "" {
+		return nil, fmt.Errorf("%s is not set", cfg.CRM.APIKeyEnv)
+	}
+	c := &client{
+		http: &http.Client{Timeout: time.Duration(cfg.CRM.TimeoutSec) * time.Second},
+		rt:   retry.New(cfg),
+		base: strings.TrimSuffix(cfg.CRM.BaseURL, "/"),
+	}
+	if c.base == "" {
+		c.base = baseURLs[cfg.CRM.Provider]
+	}
+	var crm CRM
+	switch cfg.CRM.Provider {
+	case "hubspot":
+		c.header = http.Header{"Authorization": {"Bearer " + key}}
+		crm = &hubSpot{client: c, pipeline: cfg.CRM.Pipeline, stage: cfg.CRM.Stage}
+	case "pipedrive":
+		c.header = http.Header{"X-Api-Token": {key}}
+		crm = &pipedrive{client: c, pipeline: cfg.CRM.Pipeline, stage: cfg.CRM.Stage}
+	default:
+		return nil, errors.New("crm.provider is not set")
+	}
+	s := &Syncer{cfg: cfg, st: st, crm: crm, fields: map[string]*template.Template{}, log: logging.New(cfg.Logging.Level).With("module", "crm")}
+	mapping := cfg.CRM.Fields
+	if len(mapping) == 0 {
+		mapping = defaultFields[cfg.CRM.Provider]
+	}
+	var texts []string
+	for field, text := range mapping {
+		t, err := templates.Parse(field, text)
+		if err != nil {
+			return nil, fmt.Errorf("crm.fields.%s: %w", field, err)
+		}
+		s.fields[field] = t
+		texts = append(texts, text)
+	}
+	sort.Strings(texts)
+	s.texts = strings.Join(texts, " ")
+	t, err := templates.Parse("deal_name", cfg.CRM.DealName)
+	if err != nil {
+		return nil, fmt.Errorf("crm.deal_name: %w", err)
+	}
+	s.dealName = t
+	return s, nil
+}
+
+// Sync pushes up to limit pending profiles. A failed profile is recorded
+// and counted, and the rest are still pushed.
+func (s *Syncer) Sync(ctx context.Context, limit int, dryRun bool) (models.RunStats, error) {
+	var stats models.RunStats
+	items, err := s.st.GetCRMPending(ctx, s.cfg.CRM.Provider, s.cfg.CRM.Statuses, limit)
+	if err != nil {
+		return stats, err
+	}
+	s.log.Info("profiles to sync", "provider", s.cfg.CRM.Provider, "count", len(items))
+	for i := range items {
+		if ctx.Err() != nil {
+			return stats, ctx.Err()
+		}
+		it := &items[i]
+		if dryRun {
+			action := "update"
+			if it.ContactID == "" {
+				action = "create"
+			}
+			if s.wantsDeal(it) {
+				action += " + deal"
+			}
+			fmt.Printf("%s\t%s\t%s\t%s\n", action, it.Profile.Status, it.Profile.Name, it.Profile.LinkedInURL)
+			stats.Sent++
+			continue
+		}
+		if err := s.push(ctx, it); err != nil {
+			s.log.Warn("crm sync failed", "url", it.Profile.LinkedInURL, "err", err)
+			if err := s.st.SetCRMError(ctx, s.cfg.CRM.Provider, it, err.Error()); err != nil {
+				return stats, err
+			}
+			stats.Failed++
+			stats.Errors = append(stats.Errors, it.Profile.LinkedInURL+": "+err.Error())
+			continue
+		}
+		if err := s.st.SaveCRMSync(ctx, s.cfg.CRM.Provider, it); err != nil {
+			return stats, err
+		}
+		stats.Sent++
+	}
+	return stats, nil
+}
+
+// push writes the contact of it and, when its reply calls for one and it
+// has none yet, a deal. The ids created are set on it.
+func (s *Syncer) push(ctx context.Context, it *store.CRMItem) error {
+	data := s.data(it)
+	fields := map[string]string{}
+	for name, t := range s.fields {
+		v, err := execute(t, data)
+		if err != nil {
+			return fmt.Errorf("crm.fields.%s: %w", name, err)
+		}
+		// Blanks would erase what the CRM already knows
+		if v != "" {
+			fields[name] = v
+		}
+	}
+	id, err := s.crm.UpsertContact(ctx, it.ContactID, fields)
+	if err != nil {
+		return fmt.Errorf("contact: %w", err)
+	}
+	if id != it.ContactID {
+		s.log.Info("crm contact created", "url", it.Profile.LinkedInURL, "id", id)
+		it.ContactID = id
+	}
+	if !s.wantsDeal(it) {
+		return nil
+	}
+	name, err := execute(s.dealName, data)
+	if err != nil {
+		return fmt.Errorf("crm.deal_name: %w", err)
+	}
+	if it.DealID, err = s.crm.CreateDeal(ctx, it.ContactID, name); err != nil {
+		return fmt.Errorf("deal: %w", err)
+	}
+	s.log.Info("crm deal created", "url", it.Profile.LinkedInURL, "id", it.DealID, "reply", it.ReplyLabel)
+	return nil
+}
+
+func (s *Syncer) wantsDeal(it *store.CRMItem) bool {
+	return it.DealID == "" && it.ReplyLabel != "" && slices.Contains(s.cfg.CRM.DealOn, it.ReplyLabel)
+}
+
+func (s *Syncer) data(it *store.CRMItem) fieldData {
+	p := &it.Profile
+	_, last, _ := strings.Cut(strings.TrimSpace(p.Name), " ")
+	return fieldData{
+		Data:       templates.NewData(s.texts, p, s.cfg.Templates.TitleCleanup),
+		LastName:   strings.TrimSpace(last),
+		URL:        p.LinkedInURL,
+		Status:     string(p.Status),
+		ReplyLabel: it.ReplyLabel,
+		Reply:      it.Reply,
+	}
+}
+
+func execute(t *template.Template, data fieldData) (string, error) {
+	var b strings.Builder
+	if err := t.Execute(&b, data); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(b.String()), nil
+}
+
+// errNotFound is a 404 from the CRM, e.g. for a contact deleted there.
+var errNotFound = errors.New("not found")
+
+// client is the JSON-over-HTTP plumbing the CRMs and Sheets share.
+type client struct {
+	http   *http.Client
+	rt     *retry.Retrier
+	base   string
+	header http.Header
+}
+
+// do sends body, if not nil, to path and decodes the answer into out.
+// Network errors, 429 and 5xx are retried; other failures are permanent.
+func (c *client) do(ctx context.Context, method, path string, body, out any) error {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return err
+		}
+	}
+	return c.rt.Do(ctx, method+" "+path, func() error {
+		req, err := http.NewRequestWithContext(ctx, method, c.base+path, bytes.NewReader(payload))
+		if err != nil {
+			return retry.Permanent(err)
+		}
+		req.Header = c.header.Clone()
+		if body != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		resp, err := c.http.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		raw, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+		if err != nil {
+			return err
+		}
+		switch {
+		case resp.StatusCode == http.StatusNotFound:
+			return retry.Permanent(errNotFound)
+		case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+			return fmt.Errorf("%s", resp.Status)
+		case resp.StatusCode >= 300:
+			return retry.Permanent(fmt.Errorf("%s: %s", resp.Status, templates.Truncate(strings.TrimSpace(string(raw)), 300)))
+		}
+		if out == nil {
+			return nil
+		}
+		if err := json.Unmarshal(raw, out); err != nil {
+			return retry.Permanent(fmt.Errorf("unexpected response: %w", err))
+		}
+		return nil
+	})
+}
@@ -0,0 +1,58 @@
+package integrations
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+)
+
+// hubSpot talks to the CRM v3 objects API with a private app token.
+type hubSpot struct {
+	*client
+	pipeline string
+	stage    string
+}
+
+type hubSpotObject struct {
+	ID string `json:"id"`
+}
+
+func (h *hubSpot) UpsertContact(ctx context.Context, id string, fields map[string]string) (string, error) {
+	body := map[string]any{"properties": fields}
+	var obj hubSpotObject
+	if id != "" {
+		err := h.do(ctx, http.MethodPatch, "/crm/v3/objects/contacts/"+url.PathEscape(id), body, &obj)
+		if !errors.Is(err, errNotFound) {
+			return id, err
+		}
+		// Deleted in HubSpot, create it again
+	}
+	if err := h.do(ctx, http.MethodPost, "/crm/v3/objects/contacts", body, &obj); err != nil {
+		return "", err
+	}
+	return obj.ID, nil
+}
+
+func (h *hubSpot) CreateDeal(ctx context.Context, contactID, name string) (string, error) {
+	props := map[string]string{"dealname": name}
+	if h.pipeline != "" {
+		props["pipeline"] = h.pipeline
+	}
+	if h.stage != "" {
+		props["dealstage"] = h.stage
+	}
+	body := map[string]any{
+		"properties": props,
+		"associations": []any{map[string]any{
+			"to": map[string]string{"id": contactID},
+			// 3 is HubSpot's deal to contact association
+			"types": []any{map[string]any{"associationCategory": "HUBSPOT_DEFINED", "associationTypeId": 3}},
+		}},
+	}
+	var obj hubSpotObject
+	if err := h.do(ctx, http.MethodPost, "/crm/v3/objects/deals", body, &obj); err != nil {
+		return "", err
+	}
+	return obj.ID, nil
+}
@@ -0,0 +1,66 @@
+package integrations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// pipedrive talks to the v1 API with a personal API token. Custom person
+// fields are addressed by their 40 character keys in crm.fields.
+type pipedrive struct {
+	*client
+	pipeline string
+	stage    string
+}
+
+type pipedriveResponse struct {
+	Success bool `json:"success"`
+	Data    struct {
+		ID int64 `json:"id"`
+	} `json:"data"`
+}
+
+func (p *pipedrive) UpsertContact(ctx context.Context, id string, fields map[string]string) (string, error) {
+	if id != "" {
+		_, err := p.send(ctx, http.MethodPut, "/persons/"+id, fields)
+		if !errors.Is(err, errNotFound) {
+			return id, err
+		}
+		// Deleted in Pipedrive, create it again
+	}
+	return p.send(ctx, http.MethodPost, "/persons", fields)
+}
+
+func (p *pipedrive) CreateDeal(ctx context.Context, contactID, name string) (string, error) {
+	person, err := strconv.ParseInt(contactID, 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("person id %q: %w", contactID, err)
+	}
+	body := map[string]any{"title": name, "person_id": person}
+	for key, v := range map[string]string{"pipeline_id": p.pipeline, "stage_id": p.stage} {
+		if v == "" {
+			continue
+		}
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return "", fmt.Errorf("%s %q: %w", key, v, err)
+		}
+		body[key] = n
+	}
+	return p.send(ctx, http.MethodPost, "/deals", body)
+}
+
+// send returns the id of the created or updated record.
+func (p *pipedrive) send(ctx context.Context, method, path string, body any) (string, error) {
+	var resp pipedriveResponse
+	if err := p.do(ctx, method, path, body, &resp); err != nil {
+		return "", err
+	}
+	if !resp.Success || resp.Data.ID == 0 {
+		return "", errors.New("unexpected response")
+	}
+	return strconv.FormatInt(resp.Data.ID, 10), nil
+}
@@ -0,0 +1,306 @@
+package integrations
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/example/linkedbot/internal/config"
+	"github.com/example/linkedbot/internal/logging"
+	"github.com/example/linkedbot/internal/models"
+	"github.com/example/linkedbot/internal/retry"
+	"github.com/example/linkedbot/internal/store"
+)
+
+const (
+	sheetsAPI   = "https://sheets.googleapis.com/v4/spreadsheets/"
+	sheetsScope = "https://www.googleapis.com/auth/spreadsheets"
+)
+
+// Columns of the sheet. ColDoNotContact and ColNote are the ones read back;
+// the rest are overwritten on every push.
+const (
+	ColDoNotContact = "Do not contact"
+	ColNote         = "Note"
+)
+
+var sheetHeader = []string{"LinkedIn URL", "Name", "Headline", "Company", "Location", "Source", "Status",
+	"Invited", "Accepted", "Messaged", "Replied", "Reply", "Updated", ColDoNotContact, ColNote}
+
+// SheetChanges counts what Pull took over from the sheet.
+type SheetChanges struct {
+	DoNotContact int
+	Notes        int
+	// Unknown counts rows whose URL isn't a stored profile
+	Unknown int
+}
+
+// Sheets syncs profiles with a Google sheet as a service account.
+type Sheets struct {
+	cfg     *config.Config
+	st      *store.Store
+	client  *client
+	account serviceAccount
+	key     *rsa.PrivateKey
+	token   string
+	expiry  time.Time
+	log     *logging.Logger
+}
+
+// serviceAccount is the part of a service account key file used here.
+type serviceAccount struct {
+	ClientEmail string `json:"client_email"`
+	PrivateKey  string `json:"private_key"`
+	TokenURI    string `json:"token_uri"`
+}
+
+func NewSheets(cfg *config.Config, st *store.Store) (*Sheets, error) {
+	if !cfg.SheetsEnabled() {
+		return nil, errors.New("sheets.spreadsheet_id is not set")
+	}
+	raw, err := os.ReadFile(cfg.Sheets.CredentialsFile)
+	if err != nil {
+		return nil, fmt.Errorf("sheets.credentials_file: %w", err)
+	}
+	s := &Sheets{
+		cfg: cfg,
+		st:  st,
+		client: &client{
+			http: &http.Client{Timeout: time.Duration(cfg.Sheets.TimeoutSec) * time.Second},
+			rt:   retry.New(cfg),
+			base: sheetsAPI + url.PathEscape(cfg.Sheets.SpreadsheetID),
+		},
+		log: logging.New(cfg.Logging.Level).With("module", "sheets"),
+	}
+	if err := json.Unmarshal(raw, &s.account); err != nil {
+		return nil, fmt.Errorf("sheets.credentials_file: %w", err)
+	}
+	if s.account.ClientEmail == "" || s.account.PrivateKey == "" {
+		return nil, errors.New("sheets.credentials_file is not a service account key")
+	}
+	if s.account.TokenURI == "" {
+		s.account.TokenURI = "https://oauth2.googleapis.com/token"
+	}
+	block, _ := pem.Decode([]byte(s.account.PrivateKey))
+	if block == nil {
+		return nil, errors.New("sheets.credentials_file: private_key is not PEM")
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("sheets.credentials_file: private_key: %w", err)
+	}
+	var ok bool
+	if s.key, ok = key.(*rsa.PrivateKey); !ok {
+		return nil, errors.New("sheets.credentials_file: private_key is not an RSA key")
+	}
+	return s, nil
+}
+
+// Pull takes the edits made in the sheet over: a row marked in "Do not
+// contact" puts its profile on the do-not-contact list, and a "Note" that
+// differs from the profile's newest note is added as a new note. With
+// dryRun the changes are only logged.
+func (s *Sheets) Pull(ctx context.Context, dryRun bool) (SheetChanges, error) {
+	var ch SheetChanges
+	if err := s.authorize(ctx); err != nil {
+		return ch, err
+	}
+	var resp struct {
+		Values [][]string `json:"values"`
+	}
+	if err := s.client.do(ctx, http.MethodGet, "/values/"+s.rangeOf("A:Z"), nil, &resp); err != nil {
+		return ch, fmt.Errorf("read sheet: %w", err)
+	}
+	if len(resp.Values) < 2 {
+		return ch, nil
+	}
+	// Columns are found by header, so reordering them in the sheet is fine
+	col := map[string]int{}
+	for i, h := range resp.Values[0] {
+		col[strings.TrimSpace(h)] = i
+	}
+	urlCol, ok := col[sheetHeader[0]]
+	if !ok {
+		return ch, fmt.Errorf("sheet %q has no %q column", s.cfg.Sheets.Sheet, sheetHeader[0])
+	}
+	rows, err := s.st.GetSheetRows(ctx, nil)
+	if err != nil {
+		return ch, err
+	}
+	stored := make(map[string]*store.SheetRow, len(rows))
+	for i := range rows {
+		stored[rows[i].Profile.LinkedInURL] = &rows[i]
+	}
+	for _, rec := range resp.Values[1:] {
+		profileURL := cellAt(rec, urlCol)
+		if profileURL == "" {
+			continue
+		}
+		r := stored[models.CanonicalProfileURL(profileURL)]
+		if r == nil {
+			ch.Unknown++
+			continue
+		}
+		if i, ok := col[ColDoNotContact]; ok && truthy(cellAt(rec, i)) && r.Profile.Status != models.StatusDoNotContact {
+			s.log.Info("do not contact from sheet", "url", r.Profile.LinkedInURL, "dry_run", dryRun)
+			if !dryRun {
+				if _, err := s.st.AddDoNotContact(ctx, r.Profile.LinkedInURL, "google sheet"); err != nil {
+					return ch, err
+				}
+			}
+			ch.DoNotContact++
+		}
+		if i, ok := col[ColNote]; ok {
+			if note := cellAt(rec, i); note != "" && note != r.Note {
+				s.log.Info("note from sheet", "url", r.Profile.LinkedInURL, "dry_run", dryRun)
+				if !dryRun {
+					if err := s.st.AddNote(ctx, r.Profile.ID, note); err != nil {
+						return ch, err
+					}
+				}
+				ch.Notes++
+			}
+		}
+	}
+	return ch, nil
+}
+
+// Push overwrites the sheet with the profiles in sheets.statuses, one row
+// each, and clears the rows below. It returns the number of rows written.
+func (s *Sheets) Push(ctx context.Context) (int, error) {
+	if err := s.authorize(ctx); err != nil {
+		return 0, err
+	}
+	rows, err := s.st.GetSheetRows(ctx, s.cfg.Sheets.Statuses)
+	if err != nil {
+		return 0, err
+	}
+	values := [][]string{sheetHeader}
+	for _, r := range rows {
+		p := &r.Profile
+		dnc := ""
+		if p.Status == models.StatusDoNotContact {
+			dnc = "yes"
+		}
+		values = append(values, []string{p.LinkedInURL, p.Name, p.Headline, p.Company, p.Location, p.Source, string(p.Status),
+			sheetTime(p.ConnectionSentAt), sheetTime(p.ConnectionCheckedAt), sheetTime(p.MessageSentAt), sheetTime(p.RepliedAt), r.ReplyLabel,
+			sheetTime(&p.UpdatedAt), dnc, r.Note})
+	}
+	last := columnName(len(sheetHeader))
+	body := map[string]any{"majorDimension": "ROWS", "values": values}
+	if err := s.client.do(ctx, http.MethodPut, "/values/"+s.rangeOf("A1:"+last)+"?valueInputOption=RAW", body, nil); err != nil {
+		return 0, fmt.Errorf("write sheet: %w", err)
+	}
+	below := fmt.Sprintf("A%d:%s", len(values)+1, last)
+	if err := s.client.do(ctx, http.MethodPost, "/values/"+s.rangeOf(below)+":clear", map[string]any{}, nil); err != nil {
+		return 0, fmt.Errorf("clear sheet: %w", err)
+	}
+	return len(rows), nil
+}
+
+// rangeOf returns cells of the configured tab in A1 notation, path escaped.
+func (s *Sheets) rangeOf(cells string) string {
+	return url.PathEscape("'" + strings.ReplaceAll(s.cfg.Sheets.Sheet, "'", "''") + "'!" + cells)
+}
+
+// authorize sets an access token on the client, exchanging a signed JWT
+// for a new one when the last is about to expire.
+func (s *Sheets) authorize(ctx context.Context) error {
+	if s.token != "" && time.Until(s.expiry) > time.Minute {
+		return nil
+	}
+	now := time.Now()
+	enc := base64.RawURLEncoding
+	header := enc.EncodeToString([]byte(`{"alg":"RS256","typ":"JWT"}`))
+	claims, err := json.Marshal(map[string]any{
+		"iss":   s.account.ClientEmail,
+		"scope": sheetsScope,
+		"aud":   s.account.TokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	})
+	if err != nil {
+		return err
+	}
+	unsigned := header + "." + enc.EncodeToString(claims)
+	sum := sha256.Sum256([]byte(unsigned))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, s.key, crypto.SHA256, sum[:])
+	if err != nil {
+		return err
+	}
+	form := url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {unsigned + "." + enc.EncodeToString(sig)},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.account.TokenURI, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := s.client.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("google token: %w", err)
+	}
+	defer resp.Body.Close()
+	raw, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("google token: %s: %s", resp.Status, strings.TrimSpace(string(raw)))
+	}
+	var tok struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.Unmarshal(raw, &tok); err != nil || tok.AccessToken == "" {
+		return fmt.Errorf("google token: unexpected response")
+	}
+	s.token = tok.AccessToken
+	s.expiry = now.Add(time.Duration(tok.ExpiresIn) * time.Second)
+	s.client.header = http.Header{"Authorization": {"Bearer " + s.token}}
+	return nil
+}
+
+func cellAt(rec []string, i int) string {
+	if i >= len(rec) {
+		return ""
+	}
+	return strings.TrimSpace(rec[i])
+}
+
+// truthy reports whether a cell reads as a tick: yes, y, true, x or 1.
+func truthy(v string) bool {
+	switch strings.ToLower(v) {
+	case "yes", "y", "true", "x", "1", "✓", "✔":
+		return true
+	}
+	return false
+}
+
+func sheetTime(t *time.Time) string {
+	if t == nil || t.IsZero() {
+		return ""
+	}
+	return t.Local().Format("2006-01-02 15:04")
+}
+
+// columnName returns the A1 letter of the nth (1-based) column.
+func columnName(n int) string {
+	var b []byte
+	for ; n > 0; n = (n - 1) / 26 {
+		b = append([]byte{byte('A' + (n-1)%26)}, b...)
+	}
+	return string(b)
+}
@@ -0,0 +1,98 @@
+package keepalive
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	"github.com/example/linkedbot/internal/browser"
+	"github.com/example/linkedbot/internal/config"
+	"github.com/example/linkedbot/internal/logging"
+	"github.com/example/linkedbot/internal/models"
+	"github.com/example/linkedbot/internal/retry"
+	"github.com/example/linkedbot/internal/selectors"
+	"github.com/example/linkedbot/internal/stealth"
+)
+
+// Service browses the feed between outreach runs: it scrolls, pauses on
+// posts and moves the mouse like someone reading, and never clicks, likes or
+// comments. The visit keeps the session cookie fresh and gives the account
+// ordinary activity between the runs that only visit profiles.
+type Service struct {
+	br  *browser.Browser
+	cfg *config.Config
+	rt  *retry.Retrier
+	sel *selectors.Registry
+	hu  *stealth.Human
+	log *logging.Logger
+}
+
+func New(br *browser.Browser, cfg *config.Config) *Service {
+	return &Service{br: br, cfg: cfg, rt: retry.New(cfg), sel: br.Selectors, hu: stealth.New(cfg), log: logging.New(cfg.Logging.Level).With("module", "keepalive")}
+}
+
+// Duration picks how long a visit lasts, between keep_alive.min_minutes and
+// max_minutes.
+func (s *Service) Duration() time.Duration {
+	lo := time.Duration(s.cfg.KeepAlive.MinMinutes) * time.Minute
+	hi := time.Duration(s.cfg.KeepAlive.MaxMinutes) * time.Minute
+	if hi <= lo {
+		return lo
+	}
+	return lo + time.Duration(rand.Int63n(int64(hi-lo)))
+}
+
+// Browse reads the feed for d, or until ctx is done, and counts the distinct
+// posts that scrolled past as Sent. Now and then it reloads the feed, as
+// people do when they reach older posts.
+func (s *Service) Browse(ctx context.Context, d time.Duration) (models.RunStats, error) {
+	p, err := s.br.NewPage(ctx)
+	if err != nil {
+		return models.RunStats{}, err
+	}
+	defer s.br.ClosePage(p)
+
+	feedURL := s.cfg.LinkedIn.BaseURL + "feed/"
+	s.log.Info("browsing the feed", "for", d.Round(time.Second))
+	if err := browser.Navigate(ctx, s.rt, p, feedURL); err != nil {
+		return models.RunStats{}, err
+	}
+	s.hu.WakeUpMovement(p)
+
+	seen := map[string]bool{}
+	deadline := time.Now().Add(d)
+	for time.Now().Before(deadline) {
+		if ctx.Err() != nil {
+			break
+		}
+		s.hu.ScrollHumanLike(p)
+		for _, post := range s.sel.Get("engage.post").All(p) {
+			if urn, err := post.Attribute("data-urn"); err == nil && urn != nil {
+				seen[*urn] = true
+			}
+		}
+
+		switch r := rand.Float64(); {
+		case r < 0.3:
+			// Stop on a post for a while
+			s.hu.RandomHover(p, s.sel.Get("engage.post").CSS())
+			stealth.SleepGaussian(6000, 2500)
+		case r < 0.5:
+			s.hu.MouseIdleMovement(p)
+		case r < 0.55:
+			s.log.Debug("reloading the feed", "posts_seen", len(seen))
+			if err := browser.Navigate(ctx, s.rt, p, feedURL); err != nil {
+				return s.finish(seen), err
+			}
+			s.hu.WakeUpMovement(p)
+		}
+		stealth.ThinkTime()
+		s.hu.TakeBreak()
+	}
+	return s.finish(seen), nil
+}
+
+func (s *Service) finish(seen map[string]bool) models.RunStats {
+	s.log.Info("feed visit finished", "posts_seen", len(seen))
+	return models.RunStats{Sent: len(seen)}
+}
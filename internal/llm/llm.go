@@ -0,0 +1,358 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+	"unicode/utf8"
+
+	"github.com/example/linkedbot/internal/config"
+	"github.com/example/linkedbot/internal/logging"
+	"github.com/example/linkedbot/internal/models"
+	"github.com/example/linkedbot/internal/retry"
+	"github.com/example/linkedbot/internal/store"
+	"github.com/example/linkedbot/internal/templates"
+)
+
+// Kinds of generated text.
+const (
+	KindNote     = "note"
+	KindFollowUp = "follow_up"
+	// KindReply is the label of a reply, for messaging.reply_classifier llm
+	KindReply = "classify"
+)
+
+// NoteLimit is the length of a generated note: what templated notes are
+// cut to before sending, inside LinkedIn's 300 characters.
+const NoteLimit = templates.NoteLimit
+
+// maxAbout is how much of the about section goes into a prompt.
+const maxAbout = 1500
+
+const (
+	openAIURL        = "https://api.openai.com/v1"
+	anthropicURL     = "https://api.anthropic.com/v1"
+	anthropicVersion = "2023-06-01"
+)
+
+// ErrCapReached is returned once today's generations used up
+// llm.daily_token_cap or llm.daily_cost_cap.
+var ErrCapReached = errors.New("llm daily cap reached")
+
+// Client writes personal notes and follow-ups with the model configured in
+// llm, caching each text in the store so a profile is only paid for once per
+// prompt.
+type Client struct {
+	cfg    *config.Config
+	st     *store.Store
+	client *http.Client
+	rt     *retry.Retrier
+	log    *logging.Logger
+}
+
+func New(cfg *config.Config, st *store.Store) *Client {
+	return &Client{
+		cfg:    cfg,
+		st:     st,
+		client: &http.Client{Timeout: time.Duration(cfg.LLM.TimeoutSec) * time.Second},
+		rt:     retry.New(cfg),
+		log:    logging.New(cfg.Logging.Level).With("module", "llm"),
+	}
+}
+
+// Enabled reports whether texts of kind are generated.
+func (c *Client) Enabled(kind string) bool {
+	if !c.cfg.LLMEnabled() {
+		return false
+	}
+	switch kind {
+	case KindNote:
+		return c.cfg.LLM.Notes
+	case KindFollowUp:
+		return c.cfg.LLM.FollowUps
+	case KindReply:
+		return c.cfg.Messaging.ReplyClassifier == "llm"
+	}
+	return false
+}
+
+// promptData is what the prompts see: the template fields plus the profile's
+// about section and latest post, the text the template renders to and the
+// length budget.
+type promptData struct {
+	templates.Data
+	About    string
+	Post     string
+	Template string
+	MaxChars int
+	Reply    string
+}
+
+// completion is one answer of the model.
+type completion struct {
+	Text         string
+	InputTokens  int
+	OutputTokens int
+}
+
+// Generate writes a text of kind for prof. fallback is what the configured
+// template renders to, shown to the prompt as .Template; callers send it
+// instead when Generate fails. A text generated earlier from the same
+// prompt is reused without calling the model.
+func (c *Client) Generate(ctx context.Context, kind string, prof *models.Profile, fallback string) (string, error) {
+	prompt, maxChars := c.cfg.LLM.NotePrompt, NoteLimit
+	if kind == KindFollowUp {
+		prompt, maxChars = c.cfg.LLM.FollowUpPrompt, c.cfg.LLM.MaxMessageChars
+	}
+	data, err := c.data(ctx, prof, prompt)
+	if err != nil {
+		return "", err
+	}
+	data.Template, data.MaxChars = fallback, maxChars
+	text, err := c.complete(ctx, kind, prof, prompt, data)
+	if err != nil {
+		return "", err
+	}
+	if n := utf8.RuneCountInString(text); n > maxChars {
+		c.log.Warn("generated text over budget, cutting", "kind", kind, "url", prof.LinkedInURL, "chars", n, "max", maxChars)
+		text = templates.Truncate(text, maxChars)
+	}
+	return text, nil
+}
+
+// Classify labels reply, the text prof sent, as one of labels.
+func (c *Client) Classify(ctx context.Context, prof *models.Profile, reply string, labels []string) (string, error) {
+	data, err := c.data(ctx, prof, c.cfg.LLM.ClassifyPrompt)
+	if err != nil {
+		return "", err
+	}
+	data.Reply = reply
+	answer, err := c.complete(ctx, KindReply, prof, c.cfg.LLM.ClassifyPrompt, data)
+	if err != nil {
+		return "", err
+	}
+	answer = strings.ToLower(answer)
+	// Longest first, so not_interested isn't read as interested
+	sorted := slices.Clone(labels)
+	slices.SortFunc(sorted, func(a, b string) int { return len(b) - len(a) })
+	for _, l := range sorted {
+		if strings.Contains(answer, l) {
+			return l, nil
+		}
+	}
+	return "", fmt.Errorf("llm answered %q, not a label", templates.Truncate(answer, 40))
+}
+
+// data collects what the prompts know about prof.
+func (c *Client) data(ctx context.Context, prof *models.Profile, prompt string) (promptData, error) {
+	about, post, err := c.st.ProfileContext(ctx, prof.ID)
+	if err != nil {
+		return promptData{}, err
+	}
+	return promptData{
+		Data:  templates.NewData(prompt, prof, c.cfg.Templates.TitleCleanup),
+		About: templates.Truncate(strings.Join(strings.Fields(about), " "), maxAbout),
+		Post:  post,
+	}, nil
+}
+
+// complete renders the system prompt and prompt with data and returns the
+// model's answer, from the store when the same prompt was answered for prof
+// before. A new answer is stored with its token counts and cost.
+func (c *Client) complete(ctx context.Context, kind string, prof *models.Profile, prompt string, data promptData) (string, error) {
+	system, err := render("llm.system_prompt", c.cfg.LLM.SystemPrompt, data)
+	if err != nil {
+		return "", err
+	}
+	user, err := render("llm."+kind+"_prompt", prompt, data)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256([]byte(c.cfg.LLM.Model + "\x00" + system + "\x00" + user))
+	hash := hex.EncodeToString(sum[:8])
+	if text, ok, err := c.st.GetGeneratedText(ctx, prof.ID, kind, hash); err != nil {
+		return "", err
+	} else if ok {
+		c.log.Debug("reusing generated text", "kind", kind, "url", prof.LinkedInURL)
+		return text, nil
+	}
+	if err := c.checkCaps(ctx); err != nil {
+		return "", err
+	}
+
+	res, err := retry.Value(ctx, c.rt, "llm "+kind, func() (completion, error) { return c.call(ctx, system, user) })
+	if err != nil {
+		return "", err
+	}
+	text := clean(res.Text)
+	if text == "" {
+		return "", errors.New("llm returned no text")
+	}
+	g := &store.GeneratedText{
+		ProfileID: prof.ID, Kind: kind, PromptHash: hash, Model: c.cfg.LLM.Model, Content: text,
+		InputTokens: res.InputTokens, OutputTokens: res.OutputTokens, Cost: c.cost(res),
+	}
+	if err := c.st.SaveGeneratedText(ctx, g); err != nil {
+		return "", err
+	}
+	c.log.Info("text generated", "kind", kind, "url", prof.LinkedInURL, "chars", utf8.RuneCountInString(text),
+		"tokens", res.InputTokens+res.OutputTokens, "cost", g.Cost)
+	return text, nil
+}
+
+// checkCaps fails with ErrCapReached once today's usage hits a daily cap.
+func (c *Client) checkCaps(ctx context.Context) error {
+	l := c.cfg.LLM
+	if l.DailyTokenCap == 0 && l.DailyCostCap == 0 {
+		return nil
+	}
+	now := time.Now().In(c.cfg.Location())
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	u, err := c.st.LLMUsageSince(ctx, day)
+	if err != nil {
+		return err
+	}
+	if l.DailyTokenCap > 0 && u.Tokens >= l.DailyTokenCap {
+		return fmt.Errorf("%w: %d of %d tokens used today", ErrCapReached, u.Tokens, l.DailyTokenCap)
+	}
+	if l.DailyCostCap > 0 && u.Cost >= l.DailyCostCap {
+		return fmt.Errorf("%w: %.4f of %.2f spent today", ErrCapReached, u.Cost, l.DailyCostCap)
+	}
+	return nil
+}
+
+func (c *Client) cost(res completion) float64 {
+	return (float64(res.InputTokens)*c.cfg.LLM.InputCostPerMTok + float64(res.OutputTokens)*c.cfg.LLM.OutputCostPerMTok) / 1e6
+}
+
+// call sends one system and user prompt to the provider. Network
+// errors, 429 and 5xx responses are retried; other failures are permanent.
+func (c *Client) call(ctx context.Context, system, user string) (completion, error) {
+	l := c.cfg.LLM
+	var url string
+	var body map[string]any
+	header := http.Header{"Content-Type": {"application/json"}}
+	key := c.cfg.LLMKey()
+	switch l.Provider {
+	case "anthropic":
+		url = strings.TrimSuffix(or(l.BaseURL, anthropicURL), "/") + "/messages"
+		body = map[string]any{
+			"model": l.Model, "system": system, "max_tokens": l.MaxOutputTokens, "temperature": l.Temperature,
+			"messages": []map[string]string{{"role": "user", "content": user}},
+		}
+		header.Set("anthropic-version", anthropicVersion)
+		if key != "" {
+			header.Set("x-api-key", key)
+		}
+	default:
+		url = strings.TrimSuffix(or(l.BaseURL, openAIURL), "/") + "/chat/completions"
+		body = map[string]any{
+			"model": l.Model, "max_tokens": l.MaxOutputTokens, "temperature": l.Temperature,
+			"messages": []map[string]string{{"role": "system", "content": system}, {"role": "user", "content": user}},
+		}
+		if key != "" {
+			header.Set("Authorization", "Bearer "+key)
+		}
+	}
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return completion{}, retry.Permanent(err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return completion{}, retry.Permanent(err)
+	}
+	req.Header = header
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return completion{}, err
+	}
+	defer resp.Body.Close()
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return completion{}, err
+	}
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return completion{}, fmt.Errorf("llm: %s", resp.Status)
+	case resp.StatusCode != http.StatusOK:
+		return completion{}, retry.Permanent(fmt.Errorf("llm: %s: %s", resp.Status, templates.Truncate(strings.TrimSpace(string(raw)), 200)))
+	}
+
+	var out struct {
+		// OpenAI
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+		// Anthropic
+		Content []struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		} `json:"content"`
+		Usage struct {
+			PromptTokens     int `json:"prompt_tokens"`
+			CompletionTokens int `json:"completion_tokens"`
+			InputTokens      int `json:"input_tokens"`
+			OutputTokens     int `json:"output_tokens"`
+		} `json:"usage"`
+	}
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return completion{}, retry.Permanent(fmt.Errorf("llm: unexpected response: %w", err))
+	}
+	res := completion{
+		InputTokens:  out.Usage.PromptTokens + out.Usage.InputTokens,
+		OutputTokens: out.Usage.CompletionTokens + out.Usage.OutputTokens,
+	}
+	if len(out.Choices) > 0 {
+		res.Text = out.Choices[0].Message.Content
+	}
+	for _, part := range out.Content {
+		if part.Type == "text" {
+			res.Text += part.Text
+		}
+	}
+	return res, nil
+}
+
+func render(name, text string, data promptData) (string, error) {
+	t, err := template.New(name).Funcs(templates.Funcs).Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	if err := t.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("%s: %w", name, err)
+	}
+	return strings.TrimSpace(b.String()), nil
+}
+
+// clean trims the answer and the quotes models like to wrap it in.
+func clean(text string) string {
+	text = strings.TrimSpace(text)
+	for _, q := range [][2]string{{`"`, `"`}, {"“", "”"}, {"'", "'"}} {
+		if len(text) > len(q[0])+len(q[1]) && strings.HasPrefix(text, q[0]) && strings.HasSuffix(text, q[1]) {
+			text = strings.TrimSpace(text[len(q[0]) : len(text)-len(q[1])])
+		}
+	}
+	return text
+}
+
+func or(s, def string) string {
+	if s == "" {
+		return def
+	}
+	return s
+}
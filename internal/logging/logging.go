@@ -1,25 +1,143 @@
 package logging
 
 import (
+	"context"
+	"fmt"
+	"io"
 	"log/slog"
 	"os"
 	"strings"
+	"sync"
 )
 
 type Logger = slog.Logger
 
-func New(level string) *slog.Logger {
-	var lvl slog.Level
+// Options configure where logs go, set once at startup with Setup.
+type Options struct {
+	// Console is the stdout format: "json", "pretty" or "off"
+	Console string
+	// Modules overrides the level per module, the value of the "module"
+	// attribute, e.g. {"search": "debug"}
+	Modules map[string]string
+	// File is written as JSON lines when set, rotated by Rotate
+	File   string
+	Rotate RotateOptions
+}
+
+var (
+	mu      sync.RWMutex
+	sinks   = []slog.Handler{slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug})}
+	modules = map[string]slog.Level{}
+)
+
+// Setup replaces the default JSON-on-stdout output with opts. Loggers made
+// by New before Setup keep the old output, so call it right after loading
+// the config.
+func Setup(opts Options) error {
+	var hs []slog.Handler
+	switch strings.ToLower(opts.Console) {
+	case "", "json":
+		hs = append(hs, slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	case "pretty":
+		hs = append(hs, newPrettyHandler(os.Stdout))
+	case "off":
+	default:
+		return fmt.Errorf("unknown console format %q (want json, pretty or off)", opts.Console)
+	}
+	if opts.File != "" {
+		w, err := openRotating(opts.File, opts.Rotate)
+		if err != nil {
+			return err
+		}
+		hs = append(hs, slog.NewJSONHandler(w, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	}
+	if len(hs) == 0 {
+		hs = append(hs, slog.NewJSONHandler(io.Discard, nil))
+	}
+	mods := map[string]slog.Level{}
+	for m, l := range opts.Modules {
+		lvl, err := ParseLevel(l)
+		if err != nil {
+			return fmt.Errorf("module %s: %w", m, err)
+		}
+		mods[m] = lvl
+	}
+	mu.Lock()
+	sinks, modules = hs, mods
+	mu.Unlock()
+	return nil
+}
+
+// ParseLevel parses debug, info, warn or error.
+func ParseLevel(level string) (slog.Level, error) {
 	switch strings.ToLower(level) {
 	case "debug":
-		lvl = slog.LevelDebug
+		return slog.LevelDebug, nil
+	case "info", "":
+		return slog.LevelInfo, nil
 	case "warn":
-		lvl = slog.LevelWarn
+		return slog.LevelWarn, nil
 	case "error":
-		lvl = slog.LevelError
-	default:
-		lvl = slog.LevelInfo
+		return slog.LevelError, nil
 	}
-	h := slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: lvl})
+	return slog.LevelInfo, fmt.Errorf("unknown log level %q (want debug, info, warn or error)", level)
+}
+
+// New returns a logger at level, unless the module it is tagged with by
+// With("module", ...) has a level of its own.
+func New(level string) *slog.Logger {
+	lvl, _ := ParseLevel(level)
+	mu.RLock()
+	h := &handler{sinks: sinks, modules: modules, level: lvl}
+	mu.RUnlock()
 	return slog.New(h).With("app", "linkedbot")
 }
+
+// handler fans records out to every sink, filtering by the level of its
+// module.
+type handler struct {
+	sinks   []slog.Handler
+	modules map[string]slog.Level
+	level   slog.Level
+	module  string
+}
+
+func (h *handler) Enabled(_ context.Context, l slog.Level) bool {
+	if lvl, ok := h.modules[h.module]; ok && h.module != "" {
+		return l >= lvl
+	}
+	return l >= h.level
+}
+
+func (h *handler) Handle(ctx context.Context, r slog.Record) error {
+	var first error
+	for _, s := range h.sinks {
+		if err := s.Handle(ctx, r.Clone()); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+func (h *handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	c := *h
+	for _, a := range attrs {
+		if a.Key == "module" {
+			c.module = a.Value.String()
+		}
+	}
+	c.sinks = make([]slog.Handler, len(h.sinks))
+	for i, s := range h.sinks {
+		c.sinks[i] = s.WithAttrs(attrs)
+	}
+	return &c
+}
+
+func (h *handler) WithGroup(name string) slog.Handler {
+	c := *h
+	c.sinks = make([]slog.Handler, len(h.sinks))
+	for i, s := range h.sinks {
+		c.sinks[i] = s.WithGroup(name)
+	}
+	return &c
+}
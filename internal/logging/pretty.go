@@ -0,0 +1,91 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+)
+
+// prettyHandler writes one readable line per record for a terminal:
+//
+//	15:04:05 INFO  [search] page fetched page=2 found=10
+type prettyHandler struct {
+	mu     *sync.Mutex
+	w      io.Writer
+	module string
+	attrs  string // preformatted attributes from WithAttrs
+	group  string
+}
+
+func newPrettyHandler(w io.Writer) *prettyHandler {
+	return &prettyHandler{mu: &sync.Mutex{}, w: w}
+}
+
+func (h *prettyHandler) Enabled(context.Context, slog.Level) bool { return true }
+
+func (h *prettyHandler) Handle(_ context.Context, r slog.Record) error {
+	var b bytes.Buffer
+	b.WriteString(r.Time.Format(time.TimeOnly))
+	fmt.Fprintf(&b, " %-5s ", r.Level.String())
+	if h.module != "" {
+		fmt.Fprintf(&b, "[%s] ", h.module)
+	}
+	b.WriteString(r.Message)
+	b.WriteString(h.attrs)
+	r.Attrs(func(a slog.Attr) bool {
+		writeAttr(&b, h.group, a)
+		return true
+	})
+	b.WriteByte('\n')
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := h.w.Write(b.Bytes())
+	return err
+}
+
+func (h *prettyHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	c := *h
+	var b bytes.Buffer
+	b.WriteString(h.attrs)
+	for _, a := range attrs {
+		switch {
+		case a.Key == "module" && h.group == "":
+			c.module = a.Value.String()
+		case a.Key == "app" && h.group == "":
+			// The same on every line
+		default:
+			writeAttr(&b, h.group, a)
+		}
+	}
+	c.attrs = b.String()
+	return &c
+}
+
+func (h *prettyHandler) WithGroup(name string) slog.Handler {
+	c := *h
+	c.group = h.group + name + "."
+	return &c
+}
+
+func writeAttr(b *bytes.Buffer, prefix string, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		for _, g := range a.Value.Group() {
+			writeAttr(b, prefix+a.Key+".", g)
+		}
+		return
+	}
+	v := a.Value.String()
+	if strings.ContainsAny(v, " \t\n\"") {
+		v = fmt.Sprintf("%q", v)
+	}
+	fmt.Fprintf(b, " %s%s=%s", prefix, a.Key, v)
+}
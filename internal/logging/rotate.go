@@ -0,0 +1,124 @@
+package logging
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RotateOptions decide when the log file is rotated: once it would grow
+// past MaxSizeMB (0 = no limit) or, with Daily, on the first write of a new
+// day. MaxBackups rotated files are kept (0 = all).
+type RotateOptions struct {
+	MaxSizeMB  int
+	Daily      bool
+	MaxBackups int
+}
+
+// rotatingFile is an append-only log file that moves itself aside to
+// <name>-<time><ext> when RotateOptions say so.
+type rotatingFile struct {
+	mu   sync.Mutex
+	path string
+	opts RotateOptions
+	f    *os.File
+	size int64
+	day  string
+}
+
+func openRotating(path string, opts RotateOptions) (*rotatingFile, error) {
+	r := &rotatingFile{path: path, opts: opts}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *rotatingFile) open() error {
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(r.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	r.f, r.size, r.day = f, fi.Size(), fi.ModTime().Format(time.DateOnly)
+	if fi.Size() == 0 {
+		r.day = time.Now().Format(time.DateOnly)
+	}
+	return nil
+}
+
+func (r *rotatingFile) Write(b []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	tooBig := r.opts.MaxSizeMB > 0 && r.size > 0 && r.size+int64(len(b)) > int64(r.opts.MaxSizeMB)<<20
+	newDay := r.opts.Daily && r.size > 0 && now.Format(time.DateOnly) != r.day
+	if tooBig || newDay {
+		if err := r.rotate(now); err != nil {
+			// Keep logging to the current file rather than losing lines
+			fmt.Fprintf(os.Stderr, "log rotation failed: %v\n", err)
+		}
+	}
+	n, err := r.f.Write(b)
+	r.size += int64(n)
+	return n, err
+}
+
+func (r *rotatingFile) rotate(now time.Time) error {
+	if err := r.f.Close(); err != nil {
+		return err
+	}
+	ext := filepath.Ext(r.path)
+	base := strings.TrimSuffix(r.path, ext)
+	name := base + "-" + now.Format("20060102-150405") + ext
+	for n := 2; ; n++ {
+		if _, err := os.Stat(name); os.IsNotExist(err) {
+			break
+		}
+		// Rotated twice within a second
+		name = fmt.Sprintf("%s-%s.%d%s", base, now.Format("20060102-150405"), n, ext)
+	}
+	if err := os.Rename(r.path, name); err != nil {
+		// Reopen the old file so writes continue
+		if oerr := r.open(); oerr != nil {
+			return oerr
+		}
+		return err
+	}
+	if err := r.open(); err != nil {
+		return err
+	}
+	r.day = now.Format(time.DateOnly)
+	return r.prune(base, ext)
+}
+
+// prune deletes the oldest rotated files beyond MaxBackups.
+func (r *rotatingFile) prune(base, ext string) error {
+	if r.opts.MaxBackups <= 0 {
+		return nil
+	}
+	old, err := filepath.Glob(base + "-*" + ext)
+	if err != nil {
+		return err
+	}
+	// The timestamp suffix sorts by age
+	sort.Strings(old)
+	for len(old) > r.opts.MaxBackups {
+		if err := os.Remove(old[0]); err != nil {
+			return err
+		}
+		old = old[1:]
+	}
+	return nil
+}
@@ -2,298 +2,542 @@ package messaging
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/example/linkedbot/internal/audit"
 	"github.com/example/linkedbot/internal/browser"
 	"github.com/example/linkedbot/internal/config"
+	"github.com/example/linkedbot/internal/driver"
+	"github.com/example/linkedbot/internal/extract"
+	"github.com/example/linkedbot/internal/llm"
 	"github.com/example/linkedbot/internal/logging"
 	"github.com/example/linkedbot/internal/models"
+	"github.com/example/linkedbot/internal/notify"
+	"github.com/example/linkedbot/internal/ratelimit"
+	"github.com/example/linkedbot/internal/replies"
+	"github.com/example/linkedbot/internal/retry"
+	"github.com/example/linkedbot/internal/selectors"
 	"github.com/example/linkedbot/internal/stealth"
 	"github.com/example/linkedbot/internal/store"
-	"github.com/go-rod/rod"
+	"github.com/example/linkedbot/internal/templates"
+	"github.com/example/linkedbot/internal/worker"
 )
 
 type Service struct {
 	br  *browser.Browser
 	cfg *config.Config
 	st  *store.Store
+	ex  *extract.Extractor
+	nt  *notify.Notifier
+	au  *audit.Recorder
+	rl  *ratelimit.Limiter
+	rt  *retry.Retrier
+	sel *selectors.Registry
+	ai  *llm.Client
+	rc  *replies.Classifier
+	hu  *stealth.Human
 	log *logging.Logger
 }
 
 func New(br *browser.Browser, cfg *config.Config, st *store.Store) *Service {
-	return &Service{br: br, cfg: cfg, st: st, log: logging.New(cfg.Logging.Level).With("module", "messaging")}
+	return &Service{br: br, cfg: cfg, st: st, ex: extract.New(cfg, br.Selectors), nt: notify.New(cfg), au: audit.New(cfg, st), rl: ratelimit.New(cfg, st), rt: retry.New(cfg), sel: br.Selectors, ai: llm.New(cfg, st), rc: replies.New(cfg, st), hu: stealth.New(cfg), log: logging.New(cfg.Logging.Level).With("module", "messaging")}
 }
 
-func (s *Service) SendFollowUps(ctx context.Context, limit int) (int, error) {
+// SendFollowUps advances accepted connections through the follow-up sequence,
+// queueing a message job for each profile's next due step and working
+// through the due ones, retries included. Acceptance is only checked for
+// invites sent before sentBefore (zero means no cutoff), so connections sent
+// earlier in the same run-all are left for a later run.
+func (s *Service) SendFollowUps(ctx context.Context, limit int, sentBefore time.Time) (models.RunStats, error) {
+	var stats models.RunStats
 	if limit <= 0 {
 		limit = s.cfg.Limits.MaxMessagesPerDay
 	}
-	// respect daily cap
-	today, err := s.st.CountActionsToday(ctx, "message_logs", string(models.MessageTypeFollowUp))
-	if err == nil && today >= s.cfg.Limits.MaxMessagesPerDay {
-		return 0, fmt.Errorf("daily message cap reached: %d", today)
+	// respect daily and weekly caps
+	budget, err := s.rl.Remaining(ctx, ratelimit.Message)
+	if err != nil {
+		return stats, err
+	}
+	if budget.Left() == 0 {
+		return stats, fmt.Errorf("%s message cap reached: %d", budget.Window, budget.Used)
 	}
 	toSend := limit
-	if capLeft := s.cfg.Limits.MaxMessagesPerDay - today; toSend > capLeft {
-		toSend = capLeft
+	if left := budget.Left(); left >= 0 && toSend > left {
+		toSend = left
 	}
 
 	// First detect acceptances
-	if err := s.detectAcceptances(ctx, 30); err != nil {
+	if err := s.detectAcceptances(ctx, 30, sentBefore); err != nil {
 		s.log.Warn("acceptance detection partial", "err", err)
 	}
+	// Anyone who replied drops out of the sequence before the next step
+	if s.cfg.Messaging.DetectReplies {
+		if err := s.detectReplies(ctx, 30); err != nil {
+			s.log.Warn("reply detection partial", "err", err)
+		}
+	}
 
-	profiles, err := s.st.GetProfilesNeedingFollowUp(ctx, toSend)
+	// Jobs already due are worked off before new follow-ups are queued
+	queued, err := s.st.CountDueJobs(ctx, store.JobMessage, time.Now())
 	if err != nil {
-		return 0, err
+		return stats, err
 	}
-	p, err := s.br.NewPage(ctx)
+	if want := toSend - queued; want > 0 {
+		steps := s.cfg.FollowUpSteps()
+		delays := make([]time.Duration, len(steps))
+		for i, step := range steps {
+			delays[i] = time.Duration(step.DelayDays) * 24 * time.Hour
+		}
+		accepted := store.AcceptanceDelay{
+			Min:    time.Duration(s.cfg.Messaging.FirstFollowUpMinHours) * time.Hour,
+			Jitter: time.Duration(s.cfg.Messaging.FirstFollowUpJitterHours) * time.Hour,
+		}
+		due, err := s.st.GetFollowUpsDue(ctx, want, delays, accepted, s.cfg.Messaging.Tags)
+		if err != nil {
+			return stats, err
+		}
+		for _, d := range due {
+			added, err := s.st.EnqueueJob(ctx, store.JobMessage, d.Profile.ID, strconv.Itoa(d.Step), s.cfg.Jobs.MaxAttempts)
+			if err != nil {
+				return stats, err
+			}
+			if added {
+				queued++
+			}
+		}
+	}
+	if queued == 0 {
+		return stats, nil
+	}
+
+	w := worker.New(s.br, s.cfg, s.st)
+	w.Handle(store.JobMessage, s.MessageHandler())
+	stats, err = w.Run(ctx, toSend, store.JobMessage)
 	if err != nil {
-		return 0, err
+		return stats, err
 	}
-	defer p.Close()
-	sent := 0
-	for _, prof := range profiles {
-		if err := s.messageOne(ctx, p, &prof); err != nil {
-			s.log.Warn("send message failed", "url", prof.LinkedInURL, "err", err)
-			continue
+	if stats.Sent > 0 && budget.Used+stats.Sent >= budget.Limit && budget.Limit > 0 {
+		s.nt.Notify(context.WithoutCancel(ctx), notify.EventDailyCapReached, fmt.Sprintf("Message cap reached (%s limit of %d)", budget.Window, budget.Limit), "")
+	}
+	return stats, nil
+}
+
+// MessageHandler returns the worker handler that sends the follow-up step
+// in a message job's payload. Profiles that replied, were closed or already
+// got the step since it was queued are skipped.
+func (s *Service) MessageHandler() worker.Handler {
+	return worker.Handler{Limit: ratelimit.Message, Run: func(ctx context.Context, p driver.Page, job *store.Job, prof *models.Profile) error {
+		step, err := strconv.Atoi(job.Payload)
+		if err != nil {
+			return retry.Permanent(fmt.Errorf("bad follow-up step %q", job.Payload))
+		}
+		if prof.Status != models.StatusAccepted && prof.Status != models.StatusMessaged {
+			return fmt.Errorf("%w: profile is %s", worker.ErrSkip, prof.Status)
 		}
-		sent++
-		stealth.SleepRandom(s.cfg.Stealth.MinDelayMs+300, s.cfg.Stealth.MaxDelayMs+1200)
+		sent, err := s.st.SequenceStep(ctx, prof.ID)
+		if err != nil {
+			return err
+		}
+		if sent != step {
+			return fmt.Errorf("%w: %d of the sequence already sent", worker.ErrSkip, sent)
+		}
+		s.log.Info("sending follow-up", "url", prof.LinkedInURL, "step", step+1, "of", len(s.cfg.FollowUpSteps()))
+		return s.messageOne(ctx, p, prof, step, s.cfg.FollowUpFor(prof.Source, step))
+	}}
+}
+
+// displayName is the profile name for notifications, or its URL when the
+// name was never extracted.
+func displayName(p *models.Profile) string {
+	if p.Name != "" {
+		return p.Name
 	}
-	return sent, nil
+	return p.LinkedInURL
 }
 
-func (s *Service) detectAcceptances(ctx context.Context, batch int) error {
-	p, err := s.br.NewPage(ctx)
+func (s *Service) detectAcceptances(ctx context.Context, batch int, sentBefore time.Time) error {
+	s.expireInvites(ctx)
+	if s.cfg.Messaging.AcceptanceDetection == "network" {
+		return s.detectAcceptancesFromNetwork(ctx, batch, sentBefore)
+	}
+	cands, err := s.st.GetPendingAcceptanceChecks(ctx, batch, sentBefore)
 	if err != nil {
 		return err
 	}
-	defer p.Close()
-	cands, err := s.st.GetPendingAcceptanceChecks(ctx, batch)
+	return s.checkAcceptedProfiles(ctx, cands)
+}
+
+// expireInvites stops acceptance checks for invites left unanswered for
+// messaging.invite_expiry_days.
+func (s *Service) expireInvites(ctx context.Context) {
+	days := s.cfg.Messaging.InviteExpiryDays
+	if days <= 0 {
+		return
+	}
+	n, err := s.st.ExpireInvites(ctx, time.Now().AddDate(0, 0, -days))
 	if err != nil {
-		return err
+		s.log.Warn("failed to expire old invites", "err", err)
+		return
+	}
+	if n > 0 {
+		s.log.Info("invites expired, no longer checked for acceptance", "count", n, "days", days, "withdraw", s.cfg.Connection.WithdrawExpired)
 	}
+}
 
+// checkAcceptedProfiles visits each candidate's profile, on up to
+// browser.tabs tabs, and marks the ones that are now 1st-degree connections
+// as accepted.
+func (s *Service) checkAcceptedProfiles(ctx context.Context, cands []models.Profile) error {
+	if len(cands) == 0 {
+		return nil
+	}
 	s.log.Info("checking for accepted connections", "count", len(cands))
+	pool, err := s.br.NewTabPool(ctx, len(cands))
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
 
-	for _, cand := range cands {
-		if err := p.Navigate(cand.LinkedInURL); err != nil {
+	signals, errs := browser.Map(ctx, pool, cands, func(ctx context.Context, p driver.Page, cand models.Profile) (string, error) {
+		if err := browser.Navigate(ctx, s.rt, p, cand.LinkedInURL); err != nil {
 			s.log.Warn("failed to navigate", "url", cand.LinkedInURL, "err", err)
-			continue
+			return "", err
+		}
+		if s.br.ProfileUnavailable(p) {
+			return "", s.unavailable(ctx, &cand)
 		}
-		_ = p.WaitLoad()
 		time.Sleep(1 * time.Second)
+		accepted, signal := s.ex.Accepted(p)
+		stealth.SleepRandom(300, 900)
+		if !accepted {
+			return "", nil
+		}
+		return signal, nil
+	})
+	// The checks that finished still count when the run was interrupted
+	work := context.WithoutCancel(ctx)
+	for i := range cands {
+		if errs[i] == nil && signals[i] != "" {
+			s.markAccepted(work, &cands[i], signals[i])
+		}
+	}
+	return ctx.Err()
+}
+
+// unavailable marks a profile LinkedIn no longer shows as invalid and
+// returns browser.ErrProfileUnavailable.
+func (s *Service) unavailable(ctx context.Context, prof *models.Profile) error {
+	s.log.Info("profile not available, marking it invalid", "url", prof.LinkedInURL)
+	if err := s.st.MarkUnavailable(context.WithoutCancel(ctx), prof.ID); err != nil {
+		s.log.Warn("failed to mark profile invalid", "url", prof.LinkedInURL, "err", err)
+	}
+	return browser.ErrProfileUnavailable
+}
+
+func (s *Service) markAccepted(ctx context.Context, cand *models.Profile, signal string) {
+	s.log.Info("connection accepted", "url", cand.LinkedInURL, "signal", signal)
+	_ = s.st.MarkAccepted(ctx, cand.ID)
+	s.nt.Notify(ctx, notify.EventConnectionAccepted, fmt.Sprintf("%s accepted your connection request", displayName(cand)), cand.LinkedInURL)
+}
+
+func (s *Service) detectReplies(ctx context.Context, batch int) error {
+	cands, err := s.st.GetProfilesAwaitingReply(ctx, batch, len(s.cfg.FollowUpSteps()))
+	if err != nil {
+		return err
+	}
+	if len(cands) == 0 {
+		return nil
+	}
+	p, err := s.br.NewPage(ctx)
+	if err != nil {
+		return err
+	}
+	defer s.br.ClosePage(p)
+
+	s.log.Info("checking conversations for replies", "count", len(cands))
 
-		// Check if Message button exists (indicates connection accepted)
-		if browser.HasElementWithText(p, "Message") || browser.HasElement(p, `button[aria-label*="Message"]`) {
-			s.log.Info("connection accepted", "url", cand.LinkedInURL)
-			_ = s.st.MarkAccepted(ctx, cand.ID)
+	for _, cand := range cands {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err := s.openThread(ctx, p, &cand); err != nil {
+			s.log.Warn("failed to open conversation", "url", cand.LinkedInURL, "err", err)
+			continue
+		}
+		if text, ok := s.reply(p, &cand); ok {
+			s.handleReply(ctx, &cand, text)
 		}
 		stealth.SleepRandom(300, 900)
 	}
 	return nil
 }
 
-func (s *Service) messageOne(ctx context.Context, p *rod.Page, prof *models.Profile) error {
-	if err := p.Navigate(prof.LinkedInURL); err != nil {
+// openThread shows the conversation with prof. The deep link resolves to the
+// existing thread when the URN is known; otherwise the profile's Message
+// overlay is used.
+func (s *Service) openThread(ctx context.Context, p driver.Page, prof *models.Profile) error {
+	if prof.MemberURN != "" {
+		_, err := s.openDeepLinkCompose(ctx, p, prof)
+		return err
+	}
+	if err := s.au.Do(ctx, p, prof, audit.ActionNavigate, prof.LinkedInURL, "", func() error { return browser.Navigate(ctx, s.rt, p, prof.LinkedInURL) }); err != nil {
 		return err
 	}
-	if err := p.WaitLoad(); err != nil {
+	_, err := s.openCompose(ctx, p, prof)
+	return err
+}
+
+// maxReplyText is how much of a reply is kept and classified.
+const maxReplyText = 2000
+
+// reply looks for messages from the other participant in the open thread
+// and returns their text. LinkedIn tags those with
+// msg-s-event-listitem--other; message group sender names are compared with
+// the profile name as a fallback, which finds a reply but not its text.
+func (s *Service) reply(p driver.Page, prof *models.Profile) (string, bool) {
+	if _, err := s.sel.Get("messaging.message_list").Find(p, 5*time.Second); err != nil {
+		return "", false
+	}
+	if items := s.sel.Get("messaging.reply_from_other").All(p); len(items) > 0 {
+		var parts []string
+		for _, item := range items {
+			el := item
+			if body, err := s.sel.Get("messaging.reply_body").In(item, 200*time.Millisecond); err == nil {
+				el = body
+			}
+			if text, err := el.Text(); err == nil && strings.TrimSpace(text) != "" {
+				parts = append(parts, strings.Join(strings.Fields(text), " "))
+			}
+		}
+		return templates.Truncate(strings.Join(parts, "\n"), maxReplyText), true
+	}
+	if prof.Name == "" {
+		return "", false
+	}
+	names := s.sel.Get("messaging.sender_name").All(p)
+	for _, n := range names {
+		if text, err := n.Text(); err == nil && strings.EqualFold(strings.TrimSpace(text), prof.Name) {
+			return "", true
+		}
+	}
+	return "", false
+}
+
+// handleReply classifies what prof wrote back and routes it: an opt-out
+// puts the profile on the do-not-contact list, a refusal closes it, an
+// out-of-office leaves the sequence running and anything else ends the
+// sequence as replied. A text handled before is skipped.
+func (s *Service) handleReply(ctx context.Context, prof *models.Profile, text string) {
+	label, by := s.rc.Classify(ctx, prof, text)
+	added, err := s.st.SaveReply(ctx, &store.Reply{ProfileID: prof.ID, Content: text, Label: label, Classifier: by})
+	if err != nil {
+		s.log.Warn("failed to record reply", "url", prof.LinkedInURL, "err", err)
+	} else if !added {
+		return
+	}
+	s.log.Info("reply detected", "url", prof.LinkedInURL, "label", label, "classifier", by)
+	if label == replies.OutOfOffice {
+		s.log.Info("out-of-office reply, sequence continues", "url", prof.LinkedInURL)
+		return
+	}
+	if err := s.st.MarkReplied(ctx, prof.ID); err != nil {
+		s.log.Warn("failed to mark replied", "url", prof.LinkedInURL, "err", err)
+	}
+	switch label {
+	case replies.Stop:
+		if _, err := s.st.AddDoNotContact(ctx, prof.LinkedInURL, "asked to stop in a reply"); err != nil {
+			s.log.Warn("failed to add to do-not-contact list", "url", prof.LinkedInURL, "err", err)
+		}
+	case replies.NotInterested:
+		if err := s.st.SetStatus(ctx, prof.ID, models.StatusClosed, "not interested"); err != nil {
+			s.log.Warn("failed to close profile", "url", prof.LinkedInURL, "err", err)
+		}
+	}
+	s.nt.Notify(ctx, notify.EventReplyReceived, fmt.Sprintf("%s replied to your message (%s)", displayName(prof), strings.ReplaceAll(label, "_", " ")), prof.LinkedInURL)
+	if label == replies.Interested {
+		s.nt.Notify(ctx, notify.EventInterestedReply, fmt.Sprintf("%s is interested: %q", displayName(prof), templates.Truncate(text, 300)), prof.LinkedInURL)
+	}
+}
+
+func (s *Service) messageOne(ctx context.Context, p driver.Page, prof *models.Profile, step int, tmpl string) error {
+	msg, err := s.send(ctx, p, prof, tmpl, llm.KindFollowUp)
+	if err != nil {
 		return err
 	}
+	if err := s.st.MarkMessageSent(ctx, prof.ID, step, msg); err != nil {
+		return fmt.Errorf("failed to mark message sent: %w", err)
+	}
+	s.log.Info("message sent successfully", "url", prof.LinkedInURL)
+	return nil
+}
+
+// Send opens prof's profile, renders tmpl for it and sends the result as a
+// message, returning the text sent. Recording the message is left to the
+// caller.
+func (s *Service) Send(ctx context.Context, p driver.Page, prof *models.Profile, tmpl string) (string, error) {
+	return s.send(ctx, p, prof, tmpl, "")
+}
+
+// send is Send, with the LLM writing the message instead when texts of kind
+// are generated.
+func (s *Service) send(ctx context.Context, p driver.Page, prof *models.Profile, tmpl, kind string) (string, error) {
+	if err := s.au.Do(ctx, p, prof, audit.ActionNavigate, prof.LinkedInURL, "", func() error { return browser.Navigate(ctx, s.rt, p, prof.LinkedInURL) }); err != nil {
+		return "", err
+	}
+	if s.br.ProfileUnavailable(p) {
+		return "", s.unavailable(ctx, prof)
+	}
 
 	// Wake up movement - visible mouse movement from edge to center
-	stealth.WakeUpMovement(p)
+	s.hu.WakeUpMovement(p)
 
 	// Additional idle movement for natural feel
-	stealth.MouseIdleMovement(p)
-	stealth.ThinkTime()
+	s.hu.MouseIdleMovement(p)
+	s.hu.Read(p)
 
 	// Random hover to appear natural
-	stealth.RandomHover(p, []string{"h1", "div", "section"})
+	s.hu.RandomHover(p, s.sel.Get("messaging.hover_targets").CSS())
 	time.Sleep(1 * time.Second)
 
-	// Ensure we have profile information
-	if prof.Name == "" || prof.Headline == "" || prof.Company == "" {
+	// Ensure we have profile information (the URN enables the deep-link fallback)
+	if prof.Name == "" || prof.Headline == "" || prof.Company == "" || prof.MemberURN == "" {
 		s.log.Info("extracting profile information for messaging")
 		s.extractProfileInfo(p, prof)
 	}
-
-	// Find and click Message button
-	msgBtn, err := p.Timeout(5*time.Second).ElementR("button", "^Message$")
+	msg, err := templates.Render(tmpl, prof, s.cfg.Templates.TitleCleanup)
 	if err != nil {
-		msgBtn, err = p.Timeout(5 * time.Second).Element(`button[aria-label*="Message"]`)
-	}
-	if err != nil {
-		return fmt.Errorf("message button not found: %w", err)
-	}
-
-	// Visible movement before clicking message
-	stealth.MouseIdleMovement(p)
-
-	s.log.Info("clicking message button")
-	if err := stealth.ClickHumanLike(p, msgBtn); err != nil {
-		return fmt.Errorf("failed to click message button: %w", err)
+		return "", fmt.Errorf("render message: %w", err)
+	}
+	if kind != "" && s.ai.Enabled(kind) {
+		if text, err := s.ai.Generate(ctx, kind, prof, msg); err == nil {
+			msg = text
+		} else if ctx.Err() != nil {
+			return "", ctx.Err()
+		} else {
+			s.log.Warn("llm message failed, using the template", "url", prof.LinkedInURL, "err", err)
+		}
 	}
 
-	// Movement after message box opens
-	stealth.MouseIdleMovement(p)
-	time.Sleep(1500 * time.Millisecond)
-
-	// Type message
-	msg := renderTemplate(s.cfg.Templates.FollowUp, prof)
-
-	// Try to find the message input field
-	var msgInput *rod.Element
-	_, err = p.Timeout(8 * time.Second).Element(`div.msg-form__contenteditable`)
-	if err == nil {
-		// Re-acquire without timeout to use page's default 180s timeout
-		msgInput, err = p.Element(`div.msg-form__contenteditable`)
-	} else {
-		// Try alternative selectors
-		_, err = p.Timeout(5 * time.Second).Element(`div[contenteditable="true"]`)
-		if err == nil {
-			msgInput, err = p.Element(`div[contenteditable="true"]`)
-		}
+	msgInput, err := s.openCompose(ctx, p, prof)
+	if err != nil && s.cfg.Messaging.DeepLinkFallback && prof.MemberURN != "" {
+		s.log.Warn("profile compose box unavailable, falling back to deep link", "err", err)
+		msgInput, err = s.openDeepLinkCompose(ctx, p, prof)
 	}
-	if err != nil || msgInput == nil {
+	if err != nil {
 		browser.ScreenshotOnError(p, "message_input_fail", err)
-		return fmt.Errorf("message input not found: %w", err)
+		return "", err
+	}
+	composeTimeout := time.Duration(s.cfg.Timeouts.ComposeReadyMs) * time.Millisecond
+	if err := browser.WaitFocusable(msgInput, composeTimeout); err != nil {
+		browser.ScreenshotOnError(p, "message_input_not_ready", err)
+		return "", fmt.Errorf("message input not ready: %w", err)
 	}
 
+	// Type message
 	s.log.Info("typing message", "length", len(msg))
-	if err := stealth.TypeHumanLike(msgInput, msg); err != nil {
-		return fmt.Errorf("failed to type message: %w", err)
+	if err := s.au.Do(ctx, p, prof, audit.ActionType, "message composer", msg, func() error { return s.hu.TypeHumanLike(msgInput, msg) }); err != nil {
+		return "", fmt.Errorf("failed to type message: %w", err)
 	}
 	s.log.Info("message typed successfully")
 
-	time.Sleep(1 * time.Second)
-
 	// Click Send button
-	var sendBtn *rod.Element
-	sendBtn, err = p.Timeout(15 * time.Second).Element(`button.msg-form__send-button`)
-	if err != nil {
-		sendBtn, err = p.Timeout(15*time.Second).ElementR("button", "Send")
-	}
+	sendBtn, err := retry.Value(ctx, s.rt, "find send button", func() (driver.Element, error) {
+		return s.sel.Get("messaging.send_button").Find(p, 15*time.Second)
+	})
 	if err != nil {
-		// Fallback - find any button with Send text
-		buttons, _ := p.Elements("button")
-		for _, btn := range buttons {
-			if text, _ := btn.Text(); text == "Send" {
-				sendBtn = btn
-				err = nil
-				break
-			}
-		}
-	}
-	if err != nil || sendBtn == nil {
 		browser.ScreenshotOnError(p, "send_message_fail", err)
-		return fmt.Errorf("send button not found: %w", err)
+		return "", fmt.Errorf("send button not found: %w", err)
+	}
+	if err := browser.WaitEnabled(sendBtn, time.Duration(s.cfg.Timeouts.SendEnabledMs)*time.Millisecond); err != nil {
+		browser.ScreenshotOnError(p, "send_message_disabled", err)
+		return "", fmt.Errorf("send button never became enabled: %w", err)
 	}
 
 	// Visible movement before final send
-	stealth.MouseIdleMovement(p)
+	s.hu.MouseIdleMovement(p)
 	stealth.SleepRandom(400, 800)
 
-	s.log.Info("clicking send button")
-	if err := stealth.ClickHumanLike(p, sendBtn); err != nil {
-		return fmt.Errorf("failed to click send: %w", err)
+	// The composer clears once the message is sent; only click again while
+	// the text is still there so a message is never sent twice
+	confirmTimeout := time.Duration(s.cfg.Timeouts.SendConfirmMs) * time.Millisecond
+	for attempt := 0; ; attempt++ {
+		s.log.Info("clicking send button", "attempt", attempt+1)
+		if err := s.au.Do(ctx, p, prof, audit.ActionClick, "Send message button", "", func() error { return s.hu.ClickHumanLike(p, sendBtn) }); err != nil {
+			return "", fmt.Errorf("failed to click send: %w", err)
+		}
+		if err := browser.WaitEmpty(msgInput, confirmTimeout); err == nil {
+			break
+		}
+		if attempt >= s.cfg.Timeouts.SendRetries {
+			browser.ScreenshotOnError(p, "send_message_not_confirmed", errors.New("composer not cleared"))
+			return "", errors.New("message still in composer after clicking send")
+		}
+		s.log.Warn("send not confirmed, retrying", "attempt", attempt+1)
 	}
 
 	// Movement after sending
-	stealth.MouseIdleMovement(p)
-	time.Sleep(1 * time.Second)
-
-	if err := s.st.MarkMessageSent(ctx, prof.ID, msg); err != nil {
-		return fmt.Errorf("failed to mark message sent: %w", err)
-	}
-
-	s.log.Info("message sent successfully", "url", prof.LinkedInURL)
-	return nil
+	s.hu.MouseIdleMovement(p)
+	return msg, nil
 }
 
-func (s *Service) extractProfileInfo(p *rod.Page, prof *models.Profile) {
-	// Extract name from h1 heading
-	if nameEl, err := p.Timeout(3 * time.Second).Element("h1"); err == nil {
-		if name, err := nameEl.Text(); err == nil {
-			prof.Name = strings.TrimSpace(name)
-			s.log.Info("extracted name", "name", prof.Name)
-		}
-	}
-
-	// Extract headline/title
-	headlineSelectors := []string{
-		`div.text-body-medium`,
-		`div[class*="headline"]`,
-		`.pv-text-details__left-panel div:nth-child(2)`,
+// openCompose clicks the profile's Message button and returns the compose
+// box of the chat overlay.
+func (s *Service) openCompose(ctx context.Context, p driver.Page, prof *models.Profile) (driver.Element, error) {
+	msgBtn, err := retry.Value(ctx, s.rt, "find message button", func() (driver.Element, error) {
+		return s.sel.Get("profile.message_button").Find(p, 5*time.Second)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("message button not found: %w", err)
 	}
 
-	for _, sel := range headlineSelectors {
-		if headlineEl, err := p.Timeout(2 * time.Second).Element(sel); err == nil {
-			if headline, err := headlineEl.Text(); err == nil {
-				headline = strings.TrimSpace(headline)
-				if headline != prof.Name && len(headline) > 0 {
-					prof.Headline = headline
-					s.log.Info("extracted headline", "headline", prof.Headline)
-					break
-				}
-			}
-		}
-	}
+	// Visible movement before clicking message
+	s.hu.MouseIdleMovement(p)
 
-	// Extract company from headline
-	if prof.Company == "" && prof.Headline != "" {
-		if idx := strings.Index(strings.ToLower(prof.Headline), " at "); idx >= 0 {
-			prof.Company = strings.TrimSpace(prof.Headline[idx+4:])
-			s.log.Info("extracted company from headline", "company", prof.Company)
-		}
+	s.log.Info("clicking message button")
+	if err := s.au.Do(ctx, p, prof, audit.ActionClick, "Message button", "", func() error { return s.hu.ClickHumanLike(p, msgBtn) }); err != nil {
+		return nil, fmt.Errorf("failed to click message button: %w", err)
 	}
 
-	// Update profile in database
-	if prof.Name != "" || prof.Headline != "" || prof.Company != "" {
-		ctx := context.Background()
-		if _, err := s.st.UpsertProfile(ctx, prof); err != nil {
-			s.log.Warn("failed to update profile info", "err", err)
-		}
-	}
+	// Movement after message box opens
+	s.hu.MouseIdleMovement(p)
+	return s.findComposeBox(ctx, p)
 }
 
-func renderTemplate(t string, p *models.Profile) string {
-	name := p.Name
-	company := p.Company
-	title := p.Headline
+// openDeepLinkCompose opens messaging/thread/new/?recipient=<id>, a simpler
+// and more stable compose page addressed by the member URN.
+func (s *Service) openDeepLinkCompose(ctx context.Context, p driver.Page, prof *models.Profile) (driver.Element, error) {
+	id := prof.MemberURN[strings.LastIndex(prof.MemberURN, ":")+1:]
+	u := s.cfg.LinkedIn.BaseURL + "messaging/thread/new/?recipient=" + url.QueryEscape(id)
+	s.log.Info("opening deep-link compose", "url", u)
+	if err := s.au.Do(ctx, p, prof, audit.ActionNavigate, u, "", func() error { return browser.Navigate(ctx, s.rt, p, u) }); err != nil {
+		return nil, fmt.Errorf("deep-link navigation failed: %w", err)
+	}
+	s.hu.MouseIdleMovement(p)
+	return s.findComposeBox(ctx, p)
+}
 
-	// Extract first name only
-	firstName := name
-	if idx := strings.Index(name, " "); idx > 0 {
-		firstName = name[:idx]
+func (s *Service) findComposeBox(ctx context.Context, p driver.Page) (driver.Element, error) {
+	composeTimeout := time.Duration(s.cfg.Timeouts.ComposeReadyMs) * time.Millisecond
+	msgInput, err := retry.Value(ctx, s.rt, "find message input", func() (driver.Element, error) {
+		return s.sel.Get("messaging.compose_box").Find(p, composeTimeout)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("message input not found: %w", err)
 	}
+	return msgInput, nil
+}
 
-	// Simplify long headlines - extract just the job title part
-	if idx := strings.Index(title, "@"); idx > 0 {
-		title = strings.TrimSpace(title[:idx])
-	} else if idx := strings.Index(title, "|"); idx > 0 {
-		title = strings.TrimSpace(title[:idx])
-	} else if idx := strings.Index(title, " at "); idx > 0 {
-		title = strings.TrimSpace(title[:idx])
+func (s *Service) extractProfileInfo(p driver.Page, prof *models.Profile) {
+	if !s.ex.ProfileInfo(p, prof) {
+		return
 	}
-
-	// Limit title length
-	if len(title) > 50 {
-		title = title[:50]
-		if idx := strings.LastIndex(title, " "); idx > 20 {
-			title = title[:idx]
-		}
+	// Update profile in database with extracted info
+	if _, err := s.st.UpsertProfile(context.Background(), prof); err != nil {
+		s.log.Warn("failed to update profile info", "err", err)
 	}
-
-	r := strings.NewReplacer(
-		"{{Name}}", firstName,
-		"{{Company}}", company,
-		"{{Title}}", title,
-		"{{Keywords}}", "",
-	)
-	return r.Replace(t)
 }
@@ -0,0 +1,97 @@
+package messaging
+
+import (
+	"context"
+	"time"
+
+	"github.com/example/linkedbot/internal/browser"
+	"github.com/example/linkedbot/internal/driver"
+	"github.com/example/linkedbot/internal/extract"
+	"github.com/example/linkedbot/internal/models"
+)
+
+// maxListLoads bounds how far each My Network list is scrolled. Both lists
+// put the most recent entries first, where pending invites and fresh
+// acceptances are.
+const maxListLoads = 15
+
+// detectAcceptancesFromNetwork matches pending invites against the recent
+// connections list and the sent invitations list instead of visiting every
+// profile. Invites on neither list (declined, withdrawn by hand or further
+// down than was scrolled) fall back to profile visits, at most batch of them.
+func (s *Service) detectAcceptancesFromNetwork(ctx context.Context, batch int, sentBefore time.Time) error {
+	// LIMIT -1 is unlimited in SQLite: the lists cover every pending invite
+	cands, err := s.st.GetPendingAcceptanceChecks(ctx, -1, sentBefore)
+	if err != nil || len(cands) == 0 {
+		return err
+	}
+	want := make(map[string]bool, len(cands))
+	for _, cand := range cands {
+		want[models.ProfileSlug(cand.LinkedInURL)] = true
+	}
+
+	p, err := s.br.NewPage(ctx)
+	if err != nil {
+		return err
+	}
+	defer s.br.ClosePage(p)
+	connected, err := s.scanNetworkList(ctx, p, "mynetwork/invite-connect/connections/", extract.ConnectionCardSelector, want)
+	if err != nil {
+		return err
+	}
+	pending, err := s.scanNetworkList(ctx, p, "mynetwork/invitation-manager/sent/", extract.InvitationCardSelector, want)
+	if err != nil {
+		return err
+	}
+
+	var unknown []models.Profile
+	accepted := 0
+	for _, cand := range cands {
+		slug := models.ProfileSlug(cand.LinkedInURL)
+		switch {
+		case connected[slug]:
+			s.markAccepted(ctx, &cand, "connections_list")
+			accepted++
+		case pending[slug]:
+		default:
+			unknown = append(unknown, cand)
+		}
+	}
+	s.log.Info("pending invites matched against My Network", "pending", len(cands), "accepted", accepted,
+		"still_pending", len(pending), "unmatched", len(unknown))
+	if len(unknown) > batch {
+		unknown = unknown[:batch]
+	}
+	if len(unknown) == 0 {
+		return nil
+	}
+	return s.checkAcceptedProfiles(ctx, unknown)
+}
+
+// scanNetworkList opens a My Network list and returns the slugs in want that
+// appear on it, loading more of the list until all are found or it ends.
+func (s *Service) scanNetworkList(ctx context.Context, p driver.Page, path, cardSelector string, want map[string]bool) (map[string]bool, error) {
+	if err := browser.Navigate(ctx, s.rt, p, s.cfg.LinkedIn.BaseURL+path); err != nil {
+		return nil, err
+	}
+	s.hu.WakeUpMovement(p)
+	found := map[string]bool{}
+	for i := 0; i < maxListLoads && len(found) < len(want); i++ {
+		if ctx.Err() != nil {
+			return found, ctx.Err()
+		}
+		cards, _ := p.Elements(cardSelector)
+		for _, card := range cards {
+			if prof, ok := extract.CardProfile(card); ok {
+				if slug := models.ProfileSlug(prof.LinkedInURL); want[slug] {
+					found[slug] = true
+				}
+			}
+		}
+		if !s.hu.LoadMore(p, cardSelector, s.sel.Get("page.load_more").Set) {
+			break
+		}
+	}
+	s.log.Debug("scanned network list", "path", path, "matched", len(found))
+	return found, nil
+}
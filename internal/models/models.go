@@ -1,6 +1,12 @@
 package models
 
-import "time"
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
 
 type Profile struct {
 	ID                  int64
@@ -9,21 +15,161 @@ type Profile struct {
 	Headline            string
 	Company             string
 	Location            string
-	ConnectionSent      bool
+	Source              string
+	MemberURN           string
+	Degree              string // "1st", "2nd", "3rd" or "" when unknown
+	OpenProfile         bool   // Open Profile or Premium member
+	RequiresEmail       bool   // invite asked for the member's email
+	Email               string
 	ConnectionSentAt    *time.Time
-	ConnectionAccepted  bool
-	ConnectionCheckedAt *time.Time
-	MessageSent         bool
+	ConnectionCheckedAt *time.Time // when the invite was seen accepted
 	MessageSentAt       *time.Time
+	RepliedAt           *time.Time
+	WithdrawnAt         *time.Time
+	EngagedAt           *time.Time // first like or comment left by engage
+	ViewedAt            *time.Time // warm-up visit before the invite
+	EndorsedSkills      string     // skills endorsed after acceptance, comma-separated
+	Status              ProfileStatus
 	CreatedAt           time.Time
 	UpdatedAt           time.Time
 }
 
+// ProfileDetails is the full-profile data collected by enrich.
+type ProfileDetails struct {
+	ProfileID         int64
+	About             string
+	Skills            []string
+	MutualConnections int
+	Experience        []Position
+	Education         []School
+	EnrichedAt        time.Time
+}
+
+type Position struct {
+	Title   string
+	Company string
+	Dates   string
+}
+
+type School struct {
+	School string
+	Degree string
+	Dates  string
+}
+
+// ProfileStatus is where a profile is in the outreach pipeline. It only
+// moves along the transitions CanTransition allows; the store logs every
+// move.
+type ProfileStatus string
+
+const (
+	StatusDiscovered ProfileStatus = "discovered"
+	// StatusQueued is picked for an invite: warm-viewed, or taken by a
+	// send-connections batch
+	StatusQueued    ProfileStatus = "queued"
+	StatusInvited   ProfileStatus = "invited"
+	StatusAccepted  ProfileStatus = "accepted"
+	StatusMessaged  ProfileStatus = "messaged"
+	StatusReplied   ProfileStatus = "replied"
+	StatusWithdrawn ProfileStatus = "withdrawn"
+	// StatusExpired is an invite left unanswered past
+	// messaging.invite_expiry_days; acceptance is no longer checked
+	StatusExpired ProfileStatus = "expired"
+	// StatusConnected is a contact the account was already connected to
+	// without the bot inviting them
+	StatusConnected ProfileStatus = "connected"
+	// StatusClosed ends outreach to a profile, e.g. once the conversation
+	// is done
+	StatusClosed ProfileStatus = "closed"
+	// StatusDoNotContact is final: the profile is never contacted again
+	StatusDoNotContact ProfileStatus = "do_not_contact"
+	// StatusInvalid is a profile LinkedIn no longer shows (deleted, hidden
+	// or a dead link); it is left out of every queue
+	StatusInvalid ProfileStatus = "invalid"
+)
+
+// Statuses lists every status in pipeline order.
+var Statuses = []ProfileStatus{
+	StatusDiscovered, StatusQueued, StatusInvited, StatusAccepted, StatusMessaged, StatusReplied,
+	StatusExpired, StatusWithdrawn, StatusConnected, StatusClosed, StatusDoNotContact, StatusInvalid,
+}
+
+// transitions are the moves along the pipeline. Closed, do-not-contact and
+// invalid are reachable from every status and are not listed.
+var transitions = map[ProfileStatus][]ProfileStatus{
+	// An invite may go out without the profile being queued first
+	StatusDiscovered: {StatusQueued, StatusInvited, StatusConnected},
+	StatusQueued:     {StatusInvited, StatusConnected},
+	StatusInvited:    {StatusAccepted, StatusWithdrawn, StatusExpired},
+	// An expired invite may still be found accepted, or be withdrawn
+	StatusExpired:  {StatusAccepted, StatusWithdrawn},
+	StatusAccepted: {StatusMessaged},
+	StatusMessaged: {StatusReplied},
+	// A profile that shows again starts over
+	StatusInvalid: {StatusDiscovered},
+}
+
+// CanTransition reports whether a profile may move from one status to
+// another.
+func CanTransition(from, to ProfileStatus) bool {
+	if from == to || from == StatusDoNotContact {
+		return false
+	}
+	if to == StatusClosed || to == StatusDoNotContact || to == StatusInvalid {
+		return true
+	}
+	for _, s := range transitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseStatus returns the status named s.
+func ParseStatus(s string) (ProfileStatus, bool) {
+	for _, st := range Statuses {
+		if string(st) == s {
+			return st, true
+		}
+	}
+	return "", false
+}
+
+var tagRe = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// NormalizeTag lower-cases and trims a tag name, turning inner spaces into
+// dashes ("Hot Lead" becomes "hot-lead").
+func NormalizeTag(s string) (string, error) {
+	tag := strings.Join(strings.Fields(strings.ToLower(s)), "-")
+	if len(tag) > 64 || !tagRe.MatchString(tag) {
+		return "", fmt.Errorf("invalid tag %q: use letters, digits, dashes and underscores", s)
+	}
+	return tag, nil
+}
+
+// RunStats tallies what a batch command did with the profiles it picked up.
+// Skipped profiles were queued but never attempted (e.g. the run was
+// cancelled); failed ones were attempted and errored.
+type RunStats struct {
+	Sent    int
+	Skipped int
+	Failed  int
+	Errors  []string
+}
+
+// Fail records a failed attempt for profileURL.
+func (r *RunStats) Fail(profileURL string, err error) {
+	r.Failed++
+	r.Errors = append(r.Errors, profileURL+": "+err.Error())
+}
+
 type MessageType string
 
 const (
 	MessageTypeConnectionNote MessageType = "connection_note"
 	MessageTypeFollowUp       MessageType = "follow_up"
+	MessageTypeNurture        MessageType = "nurture"
 )
 
 type MessageLog struct {
@@ -41,3 +187,47 @@ type RunLog struct {
 	EndedAt   time.Time
 	Summary   string
 }
+
+// CanonicalProfileURL reduces a profile link to the canonical
+// https://www.linkedin.com/in/<slug> form, the slug lower-cased and escaped
+// one way, as LinkedIn ignores its case. Every path that writes a profile
+// goes through it so the same person is never stored twice.
+func CanonicalProfileURL(u string) string {
+	u = strings.TrimSpace(u)
+	if i := strings.IndexAny(u, "?#"); i >= 0 {
+		u = u[:i]
+	}
+	u = strings.TrimRight(u, "/")
+	if slug, ok := rawSlug(u); ok {
+		return "https://www.linkedin.com/in/" + url.PathEscape(slug)
+	}
+	if !strings.HasPrefix(u, "http") {
+		u = "https://www.linkedin.com" + u
+	}
+	return u
+}
+
+// ProfileSlug returns the lower-cased, unescaped /in/<slug> part of a profile
+// link, which identifies the member whatever form the link takes ("" if u is
+// not a profile link).
+func ProfileSlug(u string) string {
+	slug, _ := rawSlug(CanonicalProfileURL(u))
+	return slug
+}
+
+// rawSlug returns the lower-cased, unescaped slug after /in/ in u.
+func rawSlug(u string) (string, bool) {
+	i := strings.Index(u, "/in/")
+	if i < 0 {
+		return "", false
+	}
+	slug := u[i+len("/in/"):]
+	if j := strings.IndexAny(slug, "/?#"); j >= 0 {
+		slug = slug[:j] // drop /overlay/..., /details/... sub-pages
+	}
+	if s, err := url.PathUnescape(slug); err == nil {
+		slug = s
+	}
+	slug = strings.ToLower(slug)
+	return slug, slug != ""
+}
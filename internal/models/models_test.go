@@ -0,0 +1,51 @@
+package models
+
+import "testing"
+
+func TestCanonicalProfileURL(t *testing.T) {
+	const want = "https://www.linkedin.com/in/jane-doe"
+	tests := []struct {
+		name, in, want string
+	}{
+		{"canonical", want, want},
+		{"trailing slash", want + "/", want},
+		{"tracking query", want + "?miniProfileUrn=urn%3Ali%3Afs_miniProfile%3AACoAA&trk=public", want},
+		{"fragment", want + "#experience", want},
+		{"no www", "https://linkedin.com/in/jane-doe", want},
+		{"country host", "https://in.linkedin.com/in/jane-doe/", want},
+		{"http", "http://www.linkedin.com/in/jane-doe", want},
+		{"relative", "/in/jane-doe/", want},
+		{"sub-page", want + "/details/experience/", want},
+		{"overlay", want + "/overlay/contact-info/", want},
+		{"surrounding space", "  " + want + " \n", want},
+		{"not a profile", "https://www.linkedin.com/company/acme/", "https://www.linkedin.com/company/acme"},
+		{"relative non-profile", "/company/acme", "https://www.linkedin.com/company/acme"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CanonicalProfileURL(tt.in); got != tt.want {
+				t.Errorf("CanonicalProfileURL(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+// Slugs are matched case-insensitively and stored percent-encoded, so
+// every spelling of a profile dedupes to one row.
+func TestCanonicalProfileURLSlug(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+	}{
+		{"mixed case", "https://www.linkedin.com/in/Jane-Doe/", "https://www.linkedin.com/in/jane-doe"},
+		{"escaped slug", "https://www.linkedin.com/in/j%C3%BCrgen-m", "https://www.linkedin.com/in/j%C3%BCrgen-m"},
+		{"escaped upper case", "https://www.linkedin.com/in/J%C3%9Crgen-M", "https://www.linkedin.com/in/j%C3%BCrgen-m"},
+		{"unescaped slug", "https://www.linkedin.com/in/Jürgen-M", "https://www.linkedin.com/in/j%C3%BCrgen-m"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CanonicalProfileURL(tt.in); got != tt.want {
+				t.Errorf("CanonicalProfileURL(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
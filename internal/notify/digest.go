@@ -0,0 +1,44 @@
+package notify
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/example/linkedbot/internal/store"
+)
+
+// maxDigestErrors caps the errors listed in a digest; the rest are counted.
+const maxDigestErrors = 20
+
+// Digest renders the activity in a as an email subject and body. cooldown,
+// if detected in the period, is reported as the checkpoint that paused the
+// account.
+func Digest(a *store.Activity, cooldown *store.Cooldown) (subject, body string) {
+	subject = fmt.Sprintf("linkedbot digest: %d invites, %d accepted, %d replies", a.Invited, a.Accepted, a.Replied)
+	var b strings.Builder
+	fmt.Fprintf(&b, "Activity from %s to %s\n\n", a.Since.Local().Format("2006-01-02 15:04"), a.Until.Local().Format("2006-01-02 15:04"))
+	fmt.Fprintf(&b, "Invites sent:     %d\n", a.Invited)
+	fmt.Fprintf(&b, "New acceptances:  %d\n", a.Accepted)
+	fmt.Fprintf(&b, "Replies:          %d\n", a.Replied)
+	fmt.Fprintf(&b, "Runs:             %d (%d failed)\n", a.Runs, a.FailedRuns)
+	if cooldown != nil && !cooldown.DetectedAt.Before(a.Since) {
+		subject += ", checkpoint"
+		until := "it is cleared"
+		if cooldown.Until != nil {
+			until = cooldown.Until.Local().Format("2006-01-02 15:04")
+		}
+		fmt.Fprintf(&b, "\nCheckpoint: LinkedIn %s at %s on %s; automation is paused until %s.\n",
+			strings.ReplaceAll(cooldown.Kind, "_", " "), cooldown.URL, cooldown.DetectedAt.Local().Format("2006-01-02 15:04"), until)
+	}
+	if len(a.Errors) > 0 {
+		fmt.Fprintf(&b, "\nErrors (%d):\n", len(a.Errors))
+		for i, e := range a.Errors {
+			if i == maxDigestErrors {
+				fmt.Fprintf(&b, "... and %d more\n", len(a.Errors)-maxDigestErrors)
+				break
+			}
+			fmt.Fprintf(&b, "- %s\n", e)
+		}
+	}
+	return subject, b.String()
+}
@@ -0,0 +1,98 @@
+package notify
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Email sends subject and body to notifications.email.to as a plain-text
+// mail. Port 465 talks TLS from the start; other ports upgrade with
+// STARTTLS when the server offers it.
+func (n *Notifier) Email(ctx context.Context, subject, body string) error {
+	em := n.cfg.Notifications.Email
+	if !n.cfg.EmailEnabled() {
+		return errors.New("email notifications are not configured")
+	}
+	from, err := mail.ParseAddress(em.From)
+	if err != nil {
+		return fmt.Errorf("notifications.email.from: %w", err)
+	}
+	var rcpts []string
+	for _, to := range em.To {
+		a, err := mail.ParseAddress(to)
+		if err != nil {
+			return fmt.Errorf("notifications.email.to %q: %w", to, err)
+		}
+		rcpts = append(rcpts, a.Address)
+	}
+	if n.cfg.Account != "" {
+		subject = fmt.Sprintf("[%s] %s", n.cfg.Account, subject)
+	}
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "From: %s\r\n", from.String())
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(em.To, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\nContent-Type: text/plain; charset=utf-8\r\n\r\n")
+	msg.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(n.cfg.Notifications.TimeoutSec)*time.Second)
+	defer cancel()
+	addr := net.JoinHostPort(em.Host, strconv.Itoa(em.Port))
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	tlsConfig := &tls.Config{ServerName: em.Host}
+	if em.Port == 465 {
+		conn = tls.Client(conn, tlsConfig)
+	}
+	c, err := smtp.NewClient(conn, em.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+	if ok, _ := c.Extension("STARTTLS"); ok && em.Port != 465 {
+		if err := c.StartTLS(tlsConfig); err != nil {
+			return err
+		}
+	}
+	if em.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", em.Username, n.cfg.SMTPPassword(), em.Host)); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(from.Address); err != nil {
+		return err
+	}
+	for _, r := range rcpts {
+		if err := c.Rcpt(r); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write([]byte(msg.String())); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
@@ -0,0 +1,152 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/example/linkedbot/internal/config"
+	"github.com/example/linkedbot/internal/logging"
+	"github.com/example/linkedbot/internal/telegram"
+)
+
+// Event names accepted in notifications.webhooks[].events.
+const (
+	EventConnectionAccepted = "connection_accepted"
+	EventReplyReceived      = "reply_received"
+	// EventInterestedReply is a reply classified as interested
+	EventInterestedReply = "interested_reply"
+	EventCheckpoint      = "checkpoint_detected"
+	EventDailyCapReached = "daily_cap_reached"
+	// EventInviteLimit is LinkedIn's weekly invitation limit, which starts
+	// an invite cooldown
+	EventInviteLimit = "invite_limit_reached"
+	// EventFatalError is a command or daemon job that ended in an error
+	EventFatalError = "fatal_error"
+	// EventRunComplete is an outreach command or daemon job that finished
+	EventRunComplete = "run_complete"
+)
+
+// Event is the JSON body posted to webhooks. Text makes it usable as a Slack
+// incoming-webhook message as is; the other fields are for custom receivers.
+type Event struct {
+	Text       string    `json:"text"`
+	Event      string    `json:"event"`
+	Account    string    `json:"account,omitempty"`
+	ProfileURL string    `json:"profile_url,omitempty"`
+	Time       time.Time `json:"time"`
+}
+
+// Notifier posts events to the configured webhooks and sends them by email
+// and Telegram when those are set up. With none configured Notify does
+// nothing.
+type Notifier struct {
+	cfg    *config.Config
+	client *http.Client
+	log    *logging.Logger
+}
+
+func New(cfg *config.Config) *Notifier {
+	return &Notifier{
+		cfg:    cfg,
+		client: &http.Client{Timeout: time.Duration(cfg.Notifications.TimeoutSec) * time.Second},
+		log:    logging.New(cfg.Logging.Level).With("module", "notify"),
+	}
+}
+
+// Notify sends event to every webhook subscribed to it, and by email or
+// Telegram when their events list it. Delivery failures are logged, never
+// returned: a dead webhook must not stop a run.
+func (n *Notifier) Notify(ctx context.Context, event, text, profileURL string) {
+	ev := Event{Text: text, Event: event, Account: n.cfg.Account, ProfileURL: profileURL, Time: time.Now()}
+	if n.cfg.Account != "" {
+		ev.Text = fmt.Sprintf("[%s] %s", n.cfg.Account, text)
+	}
+	body, err := json.Marshal(ev)
+	if err != nil {
+		n.log.Warn("encode notification failed", "err", err)
+		return
+	}
+	for _, wh := range n.cfg.Notifications.Webhooks {
+		if !subscribed(wh.Events, event) {
+			continue
+		}
+		if err := n.post(ctx, wh.URL, body); err != nil {
+			n.log.Warn("webhook delivery failed", "event", event, "url", wh.URL, "err", err)
+		}
+	}
+	if n.cfg.EmailEnabled() && subscribed(n.cfg.Notifications.Email.Events, event) {
+		body := text
+		if profileURL != "" {
+			body += "\n\n" + profileURL
+		}
+		subject, _, _ := strings.Cut(text, "\n")
+		if err := n.Email(ctx, subject, body); err != nil {
+			n.log.Warn("email delivery failed", "event", event, "err", err)
+		}
+	}
+	if n.cfg.TelegramEnabled() && subscribed(n.cfg.Notifications.Telegram.Events, event) {
+		msg := ev.Text
+		if profileURL != "" {
+			msg += "\n" + profileURL
+		}
+		if err := telegram.New(n.cfg).Send(ctx, msg); err != nil {
+			n.log.Warn("telegram delivery failed", "event", event, "err", err)
+		}
+	}
+}
+
+// post retries failed deliveries with exponential backoff (1s, 2s, 4s, ...).
+// Client errors other than 429 are not retried.
+func (n *Notifier) post(ctx context.Context, url string, body []byte) error {
+	backoff := time.Second
+	var err error
+	for attempt := 0; ; attempt++ {
+		var retry bool
+		retry, err = n.postOnce(ctx, url, body)
+		if err == nil || !retry || attempt >= n.cfg.Notifications.MaxRetries {
+			return err
+		}
+		n.log.Debug("webhook delivery failed, retrying", "url", url, "attempt", attempt+1, "err", err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+func (n *Notifier) postOnce(ctx context.Context, url string, body []byte) (retry bool, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		retry = resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+		return retry, fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return false, nil
+}
+
+func subscribed(events []string, event string) bool {
+	if len(events) == 0 {
+		return true
+	}
+	for _, e := range events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,228 @@
+package nurture
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/example/linkedbot/internal/browser"
+	"github.com/example/linkedbot/internal/config"
+	"github.com/example/linkedbot/internal/extract"
+	"github.com/example/linkedbot/internal/logging"
+	"github.com/example/linkedbot/internal/messaging"
+	"github.com/example/linkedbot/internal/models"
+	"github.com/example/linkedbot/internal/ratelimit"
+	"github.com/example/linkedbot/internal/retry"
+	"github.com/example/linkedbot/internal/selectors"
+	"github.com/example/linkedbot/internal/stealth"
+	"github.com/example/linkedbot/internal/store"
+)
+
+// Event kinds, as stored in nurture_events.
+const (
+	KindJobChange   = "job_change"
+	KindAnniversary = "anniversary"
+	KindBirthday    = "birthday"
+)
+
+// maxNotificationLoads bounds how far the notifications page is scrolled;
+// it lists the newest first.
+const maxNotificationLoads = 5
+
+// eventKinds are the events told apart by notification wording, checked in
+// this order against the nurture.<kind> patterns.
+var eventKinds = []string{KindBirthday, KindAnniversary, KindJobChange}
+
+type Service struct {
+	br  *browser.Browser
+	cfg *config.Config
+	st  *store.Store
+	msg *messaging.Service
+	rl  *ratelimit.Limiter
+	rt  *retry.Retrier
+	sel *selectors.Registry
+	hu  *stealth.Human
+	log *logging.Logger
+}
+
+func New(br *browser.Browser, cfg *config.Config, st *store.Store) *Service {
+	return &Service{br: br, cfg: cfg, st: st, msg: messaging.New(br, cfg, st), rl: ratelimit.New(cfg, st), rt: retry.New(cfg), sel: br.Selectors, hu: stealth.New(cfg), log: logging.New(cfg.Logging.Level).With("module", "nurture")}
+}
+
+// template returns the nurture template of kind, "" when it is not set.
+func (s *Service) template(kind string) string {
+	t := s.cfg.Nurture.Templates
+	switch kind {
+	case KindJobChange:
+		return t.JobChange
+	case KindAnniversary:
+		return t.Anniversary
+	case KindBirthday:
+		return t.Birthday
+	}
+	return ""
+}
+
+// Scan reads the notifications page and queues an event for every job
+// change, work anniversary or birthday of a stored connection that has a
+// template. It returns how many new events were queued.
+func (s *Service) Scan(ctx context.Context) (int, error) {
+	t := s.cfg.Nurture.Templates
+	if t.JobChange == "" && t.Anniversary == "" && t.Birthday == "" {
+		return 0, errors.New("no nurture templates configured")
+	}
+	conns, err := s.st.GetConnections(ctx)
+	if err != nil {
+		return 0, err
+	}
+	bySlug := make(map[string]models.Profile, len(conns))
+	for _, c := range conns {
+		bySlug[models.ProfileSlug(c.LinkedInURL)] = c
+	}
+
+	p, err := s.br.NewPage(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer s.br.ClosePage(p)
+	if err := browser.Navigate(ctx, s.rt, p, s.cfg.LinkedIn.BaseURL+"notifications/"); err != nil {
+		return 0, err
+	}
+	s.hu.WakeUpMovement(p)
+
+	cards := s.sel.Get("nurture.notification_card")
+	queued := 0
+	for i := 0; i < maxNotificationLoads; i++ {
+		if ctx.Err() != nil {
+			return queued, ctx.Err()
+		}
+		for _, card := range cards.All(p) {
+			text, err := card.Text()
+			if err != nil {
+				continue
+			}
+			kind := s.eventKind(text)
+			if kind == "" || s.template(kind) == "" {
+				continue
+			}
+			cp, ok := extract.CardProfile(card)
+			if !ok {
+				continue
+			}
+			prof, ok := bySlug[models.ProfileSlug(cp.LinkedInURL)]
+			if !ok {
+				continue
+			}
+			added, err := s.st.QueueNurture(ctx, prof.ID, kind, strings.Join(strings.Fields(text), " "))
+			if err != nil {
+				return queued, err
+			}
+			if added {
+				s.log.Info("congratulation queued", "url", prof.LinkedInURL, "event", kind)
+				queued++
+			}
+		}
+		if !s.hu.LoadMore(p, strings.Join(cards.CSS(), ", "), s.sel.Get("page.load_more").Set) {
+			break
+		}
+	}
+	return queued, nil
+}
+
+// SendQueued messages up to limit queued events with their template, oldest
+// first. Events older than nurture.max_age_days are left unsent: a late
+// congratulation reads as automated.
+func (s *Service) SendQueued(ctx context.Context, limit int) (models.RunStats, error) {
+	var stats models.RunStats
+	budget, err := s.rl.Remaining(ctx, ratelimit.Nurture)
+	if err != nil {
+		return stats, err
+	}
+	if budget.Left() == 0 {
+		s.log.Info("nurture cap reached", "budget", budget.String())
+		return stats, nil
+	}
+	if left := budget.Left(); left >= 0 && limit > left {
+		limit = left
+	}
+	due, err := s.st.GetNurtureDue(ctx, limit, time.Now().AddDate(0, 0, -s.cfg.Nurture.MaxAgeDays))
+	if err != nil {
+		return stats, err
+	}
+	s.log.Info("congratulations to send", "count", len(due))
+	if len(due) == 0 {
+		return stats, nil
+	}
+
+	p, err := s.br.NewPage(ctx)
+	if err != nil {
+		return stats, err
+	}
+	defer s.br.ClosePage(p)
+	work := context.WithoutCancel(ctx)
+	for i, e := range due {
+		if ctx.Err() != nil {
+			stats.Skipped = len(due) - i
+			break
+		}
+		if err := s.st.CheckDoNotContact(work, e.Profile.LinkedInURL, "nurture"); err != nil {
+			s.log.Info("skipping profile", "url", e.Profile.LinkedInURL, "reason", err)
+			stats.Skipped++
+			continue
+		}
+		tmpl := s.template(e.Kind)
+		if tmpl == "" {
+			stats.Skipped++
+			continue
+		}
+		if err := s.rl.Wait(ctx, ratelimit.Nurture); err != nil {
+			if errors.Is(err, ratelimit.ErrExhausted) {
+				s.log.Info("stopping batch", "reason", err)
+			}
+			stats.Skipped = len(due) - i
+			break
+		}
+		// Render {{.Company}} as the new employer, read off "... started a
+		// new position as Engineer at Acme"
+		if e.Kind == KindJobChange {
+			if m := s.sel.Pattern("nurture.new_company").FindStringSubmatch(e.Detail); m != nil {
+				e.Profile.Company = strings.TrimSpace(m[1])
+			}
+		}
+		text, err := s.msg.Send(work, p, &e.Profile, tmpl)
+		if err != nil {
+			s.log.Warn("congratulation failed", "url", e.Profile.LinkedInURL, "err", err)
+			stats.Fail(e.Profile.LinkedInURL, err)
+			continue
+		}
+		if err := s.st.MarkNurtureSent(work, &e, text); err != nil {
+			s.log.Warn("failed to record congratulation", "url", e.Profile.LinkedInURL, "err", err)
+		}
+		s.rl.Record(work, ratelimit.Nurture)
+		s.log.Info("congratulation sent", "url", e.Profile.LinkedInURL, "event", e.Kind)
+		stats.Sent++
+		stealth.SleepRandom(s.cfg.Stealth.MinDelayMs+300, s.cfg.Stealth.MaxDelayMs+900)
+	}
+	return stats, nil
+}
+
+// Run scans the notifications, then sends what is due.
+func (s *Service) Run(ctx context.Context, limit int) (models.RunStats, error) {
+	queued, err := s.Scan(ctx)
+	if err != nil {
+		return models.RunStats{}, fmt.Errorf("scan notifications: %w", err)
+	}
+	s.log.Info("notifications scanned", "queued", queued)
+	return s.SendQueued(ctx, limit)
+}
+
+func (s *Service) eventKind(text string) string {
+	for _, kind := range eventKinds {
+		if s.sel.Pattern("nurture." + kind).MatchString(text) {
+			return kind
+		}
+	}
+	return ""
+}
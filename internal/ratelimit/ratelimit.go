@@ -0,0 +1,263 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/example/linkedbot/internal/config"
+	"github.com/example/linkedbot/internal/logging"
+	"github.com/example/linkedbot/internal/store"
+)
+
+// Kind is the action a budget applies to.
+type Kind string
+
+const (
+	Connection Kind = "connection"
+	Message    Kind = "message"
+	// Engagement is capped by engage.max_per_day only
+	Engagement Kind = "engagement"
+	// Nurture is capped by nurture.max_per_day only
+	Nurture Kind = "nurture"
+)
+
+// ErrExhausted is returned by Wait when a budget is used up and pacing can't
+// (or may not) wait for it to free up.
+var ErrExhausted = errors.New("rate limit reached")
+
+// pacingJitter is the +- fraction applied to each paced gap.
+const pacingJitter = 0.3
+
+// Budget is the usage of one window. A Limit of 0 means the window is not
+// capped.
+type Budget struct {
+	Window string
+	Used   int
+	Limit  int
+}
+
+// Left returns the actions still allowed in the window, or -1 if uncapped.
+func (b Budget) Left() int {
+	if b.Limit <= 0 {
+		return -1
+	}
+	return max(b.Limit-b.Used, 0)
+}
+
+func (b Budget) String() string {
+	return fmt.Sprintf("%s %d/%d", b.Window, b.Used, b.Limit)
+}
+
+// Limiter enforces the hourly, daily and weekly budgets from the limits
+// config. Hours and weeks are rolling; the day is the calendar day in
+// stealth.timezone, and its cap is lowered by limits.warm_up for new
+// accounts. Counts live in the store, so every command and the daemon share
+// them.
+type Limiter struct {
+	cfg *config.Config
+	st  *store.Store
+	log *logging.Logger
+}
+
+func New(cfg *config.Config, st *store.Store) *Limiter {
+	return &Limiter{cfg: cfg, st: st, log: logging.New(cfg.Logging.Level).With("module", "ratelimit")}
+}
+
+// now is the current time in stealth.timezone, whose calendar day the daily
+// budgets and the day plan follow.
+func (l *Limiter) now() time.Time {
+	return time.Now().In(l.cfg.Location())
+}
+
+func (l *Limiter) limits(kind Kind) (hour, day, week int) {
+	lim := l.cfg.Limits
+	switch kind {
+	case Message:
+		return lim.MaxMessagesPerHour, lim.MaxMessagesPerDay, lim.MaxMessagesPerWeek
+	case Engagement:
+		return 0, l.cfg.Engage.MaxPerDay, 0
+	case Nurture:
+		return 0, l.cfg.Nurture.MaxPerDay, 0
+	}
+	return lim.MaxConnectionsPerHour, lim.MaxConnectionsPerDay, lim.MaxConnectionsPerWeek
+}
+
+// DailyLimit returns the daily cap for kind: the configured limit, lowered by
+// the warm-up ramp while the account is new.
+func (l *Limiter) DailyLimit(ctx context.Context, kind Kind) (int, error) {
+	_, day, _ := l.limits(kind)
+	if !l.cfg.Limits.WarmUp.Enabled || kind == Engagement || kind == Nurture {
+		return day, nil
+	}
+	started, err := l.st.AccountStartedAt(ctx)
+	if err != nil {
+		return 0, err
+	}
+	step, ok := l.cfg.WarmUpStep(time.Since(started))
+	if !ok {
+		return day, nil
+	}
+	ramp := step.MaxConnectionsPerDay
+	if kind == Message {
+		ramp = step.MaxMessagesPerDay
+	}
+	return min(day, ramp), nil
+}
+
+// usage returns the hour, day and week budgets and the times of the actions
+// in the last week, oldest first.
+func (l *Limiter) usage(ctx context.Context, kind Kind, now time.Time) (hour, day, week Budget, events []time.Time, err error) {
+	events, err = l.st.GetRateEvents(ctx, string(kind), now.AddDate(0, 0, -7))
+	if err != nil {
+		return hour, day, week, nil, err
+	}
+	hourLimit, _, weekLimit := l.limits(kind)
+	dayLimit, err := l.DailyLimit(ctx, kind)
+	if err != nil {
+		return hour, day, week, nil, err
+	}
+	hour = Budget{Window: "hourly", Limit: hourLimit}
+	day = Budget{Window: "daily", Limit: dayLimit}
+	week = Budget{Window: "weekly", Used: len(events), Limit: weekLimit}
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	for _, t := range events {
+		if !t.Before(midnight) {
+			day.Used++
+		}
+		if t.After(now.Add(-time.Hour)) {
+			hour.Used++
+		}
+	}
+	return hour, day, week, events, nil
+}
+
+// Remaining returns the tightest of the daily and weekly budgets, which
+// bounds how many actions a run can do. The hourly budget is left to Wait so
+// a paced run can outlast it.
+func (l *Limiter) Remaining(ctx context.Context, kind Kind) (Budget, error) {
+	_, day, week, _, err := l.usage(ctx, kind, l.now())
+	if err != nil {
+		return Budget{}, err
+	}
+	return tightest(day, week), nil
+}
+
+// Usage returns the hourly, daily and weekly budgets of kind as they stand.
+func (l *Limiter) Usage(ctx context.Context, kind Kind) ([]Budget, error) {
+	hour, day, week, _, err := l.usage(ctx, kind, l.now())
+	if err != nil {
+		return nil, err
+	}
+	return []Budget{hour, day, week}, nil
+}
+
+func tightest(budgets ...Budget) Budget {
+	best := budgets[0]
+	for _, b := range budgets[1:] {
+		if b.Left() >= 0 && (best.Left() < 0 || b.Left() < best.Left()) {
+			best = b
+		}
+	}
+	return best
+}
+
+// Wait blocks until the next action of kind is allowed. Without pacing it
+// returns at once, or ErrExhausted if a budget is used up. With pacing it
+// waits out a full hourly budget and spaces actions so the rest of the daily
+// or weekly budget is spread over what is left of the active window. With
+// limits.schedule, invites and messages instead wait for their slot in the
+// day's plan, and ErrExhausted means the plan is used up. Invites are also
+// exhausted while an invite cooldown is active.
+func (l *Limiter) Wait(ctx context.Context, kind Kind) error {
+	now := l.now()
+	if kind == Connection {
+		c, err := l.st.GetInviteCooldown(ctx)
+		if err != nil {
+			return err
+		}
+		if c.Active(now) {
+			return fmt.Errorf("%w: %s until %s", ErrExhausted, c.Kind, c.Until.In(l.cfg.Location()).Format("2006-01-02 15:04"))
+		}
+	}
+	hour, day, week, events, err := l.usage(ctx, kind, now)
+	if err != nil {
+		return err
+	}
+	run := tightest(day, week)
+	if run.Left() == 0 {
+		return fmt.Errorf("%w: %s", ErrExhausted, run)
+	}
+	var until time.Time
+	if hour.Left() == 0 {
+		if !l.cfg.Limits.Pacing && !l.scheduled(kind) {
+			return fmt.Errorf("%w: %s", ErrExhausted, hour)
+		}
+		// A slot frees up when the action hour.Limit places from the end
+		// drops out of the rolling hour
+		until = events[len(events)-hour.Limit].Add(time.Hour)
+	}
+	switch {
+	case l.scheduled(kind):
+		slot, err := l.nextSlot(ctx, kind, now, run.Left())
+		if err != nil {
+			return err
+		}
+		if slot == nil {
+			return fmt.Errorf("%w: today's schedule is done", ErrExhausted)
+		}
+		if slot.DueAt.After(until) {
+			until = slot.DueAt
+		}
+	case l.cfg.Limits.Pacing && len(events) > 0:
+		if next := events[len(events)-1].Add(l.gap(now, run.Left())); next.After(until) {
+			until = next
+		}
+	}
+	d := time.Until(until)
+	if d <= 0 {
+		return nil
+	}
+	msg := "pacing: waiting before next action"
+	if l.scheduled(kind) {
+		msg = "schedule: waiting for the next slot"
+	}
+	l.log.Info(msg, "kind", kind, "wait", d.Round(time.Second).String(), "until", until.In(l.cfg.Location()).Format("15:04"), "hourly", hour.String(), "budget", run.String())
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(d):
+		return nil
+	}
+}
+
+// gap is the jittered spacing that spreads left actions over the rest of the
+// active window. Outside the window, or with no cap, actions aren't spaced.
+func (l *Limiter) gap(now time.Time, left int) time.Duration {
+	_, end, ok := l.cfg.ActiveWindow(now)
+	if !ok || left <= 0 {
+		return 0
+	}
+	rest := end.Sub(now)
+	if rest <= 0 {
+		return 0
+	}
+	gap := float64(rest) / float64(left)
+	return time.Duration(gap * (1 + pacingJitter*(2*rand.Float64()-1)))
+}
+
+// Record counts one done action against the budgets, and against its slot
+// in the day's plan.
+func (l *Limiter) Record(ctx context.Context, kind Kind) {
+	if err := l.st.RecordRateEvent(ctx, string(kind)); err != nil {
+		l.log.Warn("failed to record rate limit event", "kind", kind, "err", err)
+	}
+	if l.scheduled(kind) {
+		now := l.now()
+		if _, err := l.st.UseScheduleSlot(ctx, string(kind), dayKey(now), now); err != nil {
+			l.log.Warn("failed to record schedule slot", "kind", kind, "err", err)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/example/linkedbot/internal/store"
+)
+
+// dayKey is the calendar day a plan belongs to, of a time from l.now.
+func dayKey(t time.Time) string { return t.Format("2006-01-02") }
+
+// scheduled reports whether actions of kind follow the day plan of
+// limits.schedule rather than pacing.
+func (l *Limiter) scheduled(kind Kind) bool {
+	return l.cfg.Limits.Schedule.Enabled && (kind == Connection || kind == Message)
+}
+
+// nextSlot returns the slot the next action of kind belongs to, planning the
+// day for left actions when there is no plan yet. It returns nil once today's
+// plan is used up.
+func (l *Limiter) nextSlot(ctx context.Context, kind Kind, now time.Time, left int) (*store.ScheduleSlot, error) {
+	day := dayKey(now)
+	slots, err := l.st.GetSchedule(ctx, string(kind), day)
+	if err != nil {
+		return nil, err
+	}
+	if len(slots) == 0 {
+		slots = l.plan(now, left)
+		if err := l.st.SaveSchedule(ctx, string(kind), day, slots); err != nil {
+			return nil, err
+		}
+		l.log.Info("planned today's actions", "kind", kind, "actions", left, "slots", formatSlots(slots))
+	}
+	for i := range slots {
+		if slots[i].Used < slots[i].Size {
+			return &slots[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// plan splits total actions into bursts of 1 to limits.schedule.max_burst,
+// each at a random time between now (or the window start) and the end of
+// today's active window. A day off gets no slots.
+func (l *Limiter) plan(now time.Time, total int) []store.ScheduleSlot {
+	from, to, ok := l.cfg.ActiveWindow(now)
+	if !ok {
+		return nil
+	}
+	if from.Before(now) {
+		from = now
+	}
+	span := to.Sub(from)
+	if span <= 0 {
+		return nil
+	}
+	var slots []store.ScheduleSlot
+	for total > 0 {
+		n := min(1+rand.Intn(l.cfg.Limits.Schedule.MaxBurst), total)
+		total -= n
+		slots = append(slots, store.ScheduleSlot{DueAt: from.Add(time.Duration(rand.Int63n(int64(span)))), Size: n})
+	}
+	slices.SortFunc(slots, func(a, b store.ScheduleSlot) int { return a.DueAt.Compare(b.DueAt) })
+	return slots
+}
+
+// formatSlots renders a plan for the log, e.g. "09:40x3 11:15x2".
+func formatSlots(slots []store.ScheduleSlot) string {
+	parts := make([]string, len(slots))
+	for i, sl := range slots {
+		parts[i] = fmt.Sprintf("%sx%d", sl.DueAt.Format("15:04"), sl.Size)
+	}
+	return strings.Join(parts, " ")
+}
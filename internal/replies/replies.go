@@ -0,0 +1,86 @@
+package replies
+
+import (
+	"context"
+	"regexp"
+
+	"github.com/example/linkedbot/internal/config"
+	"github.com/example/linkedbot/internal/llm"
+	"github.com/example/linkedbot/internal/logging"
+	"github.com/example/linkedbot/internal/models"
+	"github.com/example/linkedbot/internal/store"
+)
+
+// Labels a reply is classified as.
+const (
+	Interested    = "interested"
+	NotInterested = "not_interested"
+	// Stop asks not to be messaged again
+	Stop        = "stop"
+	OutOfOffice = "out_of_office"
+	// Other is anything no rule or the model could place
+	Other = "other"
+)
+
+// Labels lists every label.
+var Labels = []string{Interested, NotInterested, Stop, OutOfOffice, Other}
+
+// Classifiers, as recorded with each reply.
+const (
+	ByRules = "rules"
+	ByLLM   = "llm"
+)
+
+type rule struct {
+	label    string
+	patterns []*regexp.Regexp
+}
+
+// Classifier labels replies with messaging.reply_rules, or with the llm
+// model when messaging.reply_classifier is llm.
+type Classifier struct {
+	ai    *llm.Client
+	rules []rule
+	log   *logging.Logger
+}
+
+func New(cfg *config.Config, st *store.Store) *Classifier {
+	c := &Classifier{ai: llm.New(cfg, st), log: logging.New(cfg.Logging.Level).With("module", "replies")}
+	for _, r := range cfg.Messaging.ReplyRules {
+		cr := rule{label: r.Label}
+		for _, p := range r.Patterns {
+			// Validated with the config
+			cr.patterns = append(cr.patterns, regexp.MustCompile("(?i)"+p))
+		}
+		c.rules = append(c.rules, cr)
+	}
+	return c
+}
+
+// Classify labels text, what prof wrote back, and reports which classifier
+// decided. A failed model call falls back to the rules.
+func (c *Classifier) Classify(ctx context.Context, prof *models.Profile, text string) (label, by string) {
+	if text == "" {
+		return Other, ByRules
+	}
+	if c.ai.Enabled(llm.KindReply) {
+		label, err := c.ai.Classify(ctx, prof, text, Labels)
+		if err == nil {
+			return label, ByLLM
+		}
+		c.log.Warn("llm classification failed, using the rules", "url", prof.LinkedInURL, "err", err)
+	}
+	return c.match(text), ByRules
+}
+
+// match returns the label of the first rule with a matching pattern.
+func (c *Classifier) match(text string) string {
+	for _, r := range c.rules {
+		for _, p := range r.patterns {
+			if p.MatchString(text) {
+				return r.label
+			}
+		}
+	}
+	return Other
+}
@@ -0,0 +1,128 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/example/linkedbot/internal/config"
+	"github.com/example/linkedbot/internal/logging"
+)
+
+// backoffJitter is the +- fraction applied to each backoff delay.
+const backoffJitter = 0.2
+
+type permanentError struct{ err error }
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent marks err as not worth retrying: Do returns it (unwrapped) at
+// once.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// IsPermanent classifies err. Errors marked with Permanent, errors that
+// report Permanent() true (e.g. a tripped checkpoint guard) and cancellation
+// are permanent; everything else, such as element lookup timeouts and failed
+// navigations, is transient.
+func IsPermanent(err error) bool {
+	var pe *permanentError
+	if errors.As(err, &pe) || errors.Is(err, context.Canceled) {
+		return true
+	}
+	var p interface{ Permanent() bool }
+	return errors.As(err, &p) && p.Permanent()
+}
+
+func unwrapPermanent(err error) error {
+	var pe *permanentError
+	if errors.As(err, &pe) {
+		return pe.err
+	}
+	return err
+}
+
+// Retrier retries page operations with exponential backoff, as configured
+// in the retry section.
+type Retrier struct {
+	attempts   int
+	initial    time.Duration
+	max        time.Duration
+	multiplier float64
+	log        *logging.Logger
+}
+
+func New(cfg *config.Config) *Retrier {
+	return &Retrier{
+		attempts:   cfg.Retry.Attempts,
+		initial:    time.Duration(cfg.Retry.InitialDelayMs) * time.Millisecond,
+		max:        time.Duration(cfg.Retry.MaxDelayMs) * time.Millisecond,
+		multiplier: cfg.Retry.Multiplier,
+		log:        logging.New(cfg.Logging.Level).With("module", "retry"),
+	}
+}
+
+// Do runs fn until it succeeds, fails permanently, ctx ends or the attempts
+// run out. op names the operation in logs and the final error.
+func (r *Retrier) Do(ctx context.Context, op string, fn func() error) error {
+	_, err := Value(ctx, r, op, func() (struct{}, error) { return struct{}{}, fn() })
+	return err
+}
+
+// Value is Do for operations that return a result.
+func Value[T any](ctx context.Context, r *Retrier, op string, fn func() (T, error)) (T, error) {
+	var zero T
+	delay := r.initial
+	for attempt := 1; ; attempt++ {
+		v, err := fn()
+		if err == nil {
+			return v, nil
+		}
+		if IsPermanent(err) {
+			return zero, unwrapPermanent(err)
+		}
+		if attempt >= r.attempts {
+			if r.attempts > 1 {
+				return zero, fmt.Errorf("%s: gave up after %d attempts: %w", op, attempt, err)
+			}
+			return zero, err
+		}
+		wait := jitter(delay)
+		r.log.Warn("operation failed, retrying", "op", op, "attempt", attempt, "wait", wait.Round(time.Millisecond).String(), "err", err)
+		select {
+		case <-ctx.Done():
+			return zero, err
+		case <-time.After(wait):
+		}
+		delay = min(time.Duration(float64(delay)*r.multiplier), r.max)
+	}
+}
+
+// First tries the strategies in order on each attempt and returns the result
+// of the first that succeeds. This replaces chains of fallback selectors: the
+// whole chain is retried with backoff while the page is still rendering. A
+// strategy returning a Permanent error ends the search at once.
+func First[T any](ctx context.Context, r *Retrier, op string, strategies ...func() (T, error)) (T, error) {
+	return Value(ctx, r, op, func() (T, error) {
+		var zero T
+		var err error
+		for _, try := range strategies {
+			var v T
+			if v, err = try(); err == nil || IsPermanent(err) {
+				return v, err
+			}
+		}
+		return zero, err
+	})
+}
+
+func jitter(d time.Duration) time.Duration {
+	return time.Duration(float64(d) * (1 + backoffJitter*(2*rand.Float64()-1)))
+}
@@ -0,0 +1,46 @@
+// Package rodtest gives tests a headless Chrome to run page fixtures in.
+// Only the Chrome already installed on the machine is used: without one the
+// calling test is skipped, as CI and sandboxes usually have none and a
+// download would make the tests depend on the network.
+package rodtest
+
+import (
+	"testing"
+
+	"github.com/example/linkedbot/internal/driver"
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/launcher"
+	"github.com/go-rod/rod/lib/proto"
+)
+
+// Browser starts a headless Chrome that is closed when the test ends.
+func Browser(t testing.TB) *rod.Browser {
+	t.Helper()
+	bin, ok := launcher.LookPath()
+	if !ok {
+		t.Skip("no Chrome on this machine")
+	}
+	u, err := launcher.New().Bin(bin).Headless(true).Launch()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := rod.New().ControlURL(u)
+	if err := b.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = b.Close() })
+	return b
+}
+
+// Page opens doc in a new tab of b.
+func Page(t testing.TB, b *rod.Browser, doc string) driver.Page {
+	t.Helper()
+	p, err := b.Page(proto.TargetCreateTarget{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.SetDocumentContent(doc); err != nil {
+		t.Fatal(err)
+	}
+	return driver.Rod(p)
+}
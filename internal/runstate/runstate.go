@@ -0,0 +1,121 @@
+package runstate
+
+import (
+	"sync"
+	"time"
+
+	"github.com/example/linkedbot/internal/models"
+)
+
+// How many finished runs and errors are kept for display.
+const (
+	maxRecent = 20
+	maxErrors = 50
+)
+
+// JobRun is one execution of a daemon job.
+type JobRun struct {
+	Job      string    `json:"job"`
+	Started  time.Time `json:"started"`
+	Finished time.Time `json:"finished"`
+	Sent     int       `json:"sent"`
+	Skipped  int       `json:"skipped"`
+	Failed   int       `json:"failed"`
+	Err      string    `json:"error,omitempty"`
+}
+
+// ErrorEntry is a job-level or per-profile error.
+type ErrorEntry struct {
+	Time    time.Time `json:"time"`
+	Job     string    `json:"job"`
+	Message string    `json:"message"`
+}
+
+// State is a point-in-time copy of the manager, safe to render.
+type State struct {
+	Paused  bool         `json:"paused"`
+	Running *JobRun      `json:"running,omitempty"`
+	Recent  []JobRun     `json:"recent"`
+	Errors  []ErrorEntry `json:"errors"`
+}
+
+// Manager tracks what the daemon is doing and whether it is paused. It is
+// shared between the scheduler and the dashboard.
+type Manager struct {
+	mu      sync.Mutex
+	paused  bool
+	running *JobRun
+	recent  []JobRun
+	errors  []ErrorEntry
+}
+
+func New() *Manager { return &Manager{} }
+
+func (m *Manager) Pause()  { m.setPaused(true) }
+func (m *Manager) Resume() { m.setPaused(false) }
+
+func (m *Manager) setPaused(v bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.paused = v
+}
+
+func (m *Manager) Paused() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.paused
+}
+
+// Start records that job began running.
+func (m *Manager) Start(job string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.running = &JobRun{Job: job, Started: time.Now()}
+}
+
+// Finish closes the running job with its stats and error, if any.
+func (m *Manager) Finish(stats models.RunStats, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.running == nil {
+		return
+	}
+	run := *m.running
+	m.running = nil
+	run.Finished = time.Now()
+	run.Sent, run.Skipped, run.Failed = stats.Sent, stats.Skipped, stats.Failed
+	for _, e := range stats.Errors {
+		m.addError(run.Finished, run.Job, e)
+	}
+	if err != nil {
+		run.Err = err.Error()
+		m.addError(run.Finished, run.Job, run.Err)
+	}
+	m.recent = append([]JobRun{run}, m.recent...)
+	if len(m.recent) > maxRecent {
+		m.recent = m.recent[:maxRecent]
+	}
+}
+
+func (m *Manager) addError(t time.Time, job, msg string) {
+	m.errors = append([]ErrorEntry{{Time: t, Job: job, Message: msg}}, m.errors...)
+	if len(m.errors) > maxErrors {
+		m.errors = m.errors[:maxErrors]
+	}
+}
+
+// Snapshot returns a copy of the current state, newest runs and errors first.
+func (m *Manager) Snapshot() State {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	st := State{
+		Paused: m.paused,
+		Recent: append([]JobRun(nil), m.recent...),
+		Errors: append([]ErrorEntry(nil), m.errors...),
+	}
+	if m.running != nil {
+		r := *m.running
+		st.Running = &r
+	}
+	return st
+}
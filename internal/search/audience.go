@@ -0,0 +1,83 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/example/linkedbot/internal/browser"
+	"github.com/example/linkedbot/internal/driver"
+	"github.com/example/linkedbot/internal/extract"
+	"github.com/example/linkedbot/internal/models"
+)
+
+// audiencePaths are the member lists behind the group:<id> and event:<id>
+// sources.
+var audiencePaths = map[string]string{
+	"group": "groups/%s/members/",
+	"event": "events/%s/attendees/",
+}
+
+// audiencePages returns a page fetcher for a group's members or an event's
+// attendees. The lists load more on scroll instead of paging, so page n is
+// what the n-th load adds; earlier pages of a resumed search are scrolled
+// past without being returned again.
+func (s *Service) audiencePages(kind, id string) func(context.Context, driver.Page, string, int) ([]models.Profile, error) {
+	cards := s.sel.Get("search.group_member_card")
+	if kind == "event" {
+		cards = s.sel.Get("search.event_attendee_card")
+	}
+	cardCSS := strings.Join(cards.CSS(), ", ")
+	seen := map[string]bool{}
+	loaded := 0
+	return func(ctx context.Context, p driver.Page, _ string, pageNum int) ([]models.Profile, error) {
+		if loaded == 0 {
+			listURL := s.cfg.LinkedIn.BaseURL + fmt.Sprintf(audiencePaths[kind], id)
+			s.log.Info("opening member list", "url", listURL)
+			if err := browser.Navigate(ctx, s.rt, p, listURL); err != nil {
+				return nil, fmt.Errorf("failed to open %s %s: %w", kind, id, err)
+			}
+			s.hu.WakeUpMovement(p)
+			if _, err := cards.Find(p, 10*time.Second); err != nil {
+				browser.ScreenshotOnError(p, kind+"_members_fail", err)
+				return nil, fmt.Errorf("no %s members visible, check the id and that you are a member: %w", kind, err)
+			}
+			loaded = 1
+		}
+		for ; loaded < pageNum; loaded++ {
+			s.audienceCards(cards.All(p), seen)
+			if !s.hu.LoadMore(p, cardCSS, s.sel.Get("page.load_more").Set) {
+				return nil, nil
+			}
+		}
+		return s.audienceCards(cards.All(p), seen), nil
+	}
+}
+
+// audienceCards reads the member cards not in seen and adds them to it.
+func (s *Service) audienceCards(cards []driver.Element, seen map[string]bool) []models.Profile {
+	var out []models.Profile
+	for _, card := range cards {
+		prof, ok := extract.CardProfile(card)
+		if !ok || !strings.Contains(prof.LinkedInURL, "/in/") || seen[prof.LinkedInURL] {
+			continue
+		}
+		seen[prof.LinkedInURL] = true
+		if el, err := s.sel.Get("search.card_name").In(card, 200*time.Millisecond); err == nil {
+			if name, err := el.Text(); err == nil && strings.TrimSpace(name) != "" {
+				prof.Name = strings.TrimSpace(name)
+			}
+		}
+		if el, err := s.sel.Get("search.card_headline").In(card, 200*time.Millisecond); err == nil {
+			if headline, err := el.Text(); err == nil && strings.TrimSpace(headline) != "" {
+				prof.Headline = strings.TrimSpace(headline)
+			}
+		}
+		prof.Company = extract.CompanyFromHeadline(s.sel.Pattern("profile.headline_company"), prof.Headline)
+		text, _ := card.Text()
+		prof.Degree = extract.ParseDegree(s.sel.Pattern("search.degree_label"), text)
+		out = append(out, prof)
+	}
+	return out
+}
@@ -2,25 +2,33 @@ package search
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/url"
-	"os"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/example/linkedbot/internal/browser"
 	"github.com/example/linkedbot/internal/config"
+	"github.com/example/linkedbot/internal/driver"
+	"github.com/example/linkedbot/internal/exclusion"
+	"github.com/example/linkedbot/internal/extract"
 	"github.com/example/linkedbot/internal/logging"
 	"github.com/example/linkedbot/internal/models"
+	"github.com/example/linkedbot/internal/retry"
+	"github.com/example/linkedbot/internal/selectors"
 	"github.com/example/linkedbot/internal/stealth"
 	"github.com/example/linkedbot/internal/store"
-	"github.com/go-rod/rod"
 )
 
 type Service struct {
 	br  *browser.Browser
 	cfg *config.Config
 	st  *store.Store
+	rt  *retry.Retrier
+	sel *selectors.Registry
+	hu  *stealth.Human
 	log *logging.Logger
 }
 
@@ -30,22 +38,55 @@ type Criteria struct {
 	Location string
 	Keywords string
 	Limit    int
+	// Backend overrides search.backend for this search
+	Backend string
+	// Source lists a group's members ("group:<id>") or an event's attendees
+	// ("event:<id>") instead of searching the other criteria
+	Source string
 }
 
 func New(br *browser.Browser, cfg *config.Config, st *store.Store) *Service {
-	return &Service{br: br, cfg: cfg, st: st, log: logging.New(cfg.Logging.Level).With("module", "search")}
+	return &Service{br: br, cfg: cfg, st: st, rt: retry.New(cfg), sel: br.Selectors, hu: stealth.New(cfg), log: logging.New(cfg.Logging.Level).With("module", "search")}
+}
+
+// errNoLinks is returned by the link lookup when nothing matches, which after
+// the last retry means the results ran out.
+var errNoLinks = errors.New("no profile links found")
+
+// profileLinks finds the result links: search.profile_links page-wide, then
+// the first search.profile_link of each search.result_item, then any
+// search.profile_link on the page.
+func (s *Service) profileLinks(p driver.Page) ([]driver.Element, error) {
+	if links := s.sel.Get("search.profile_links").All(p); len(links) > 0 {
+		s.log.Info("found profile links", "strategy", "profile_links", "count", len(links))
+		return links, nil
+	}
+	var links []driver.Element
+	for _, item := range s.sel.Get("search.result_item").All(p) {
+		if link, err := s.sel.Get("search.profile_link").In(item, 500*time.Millisecond); err == nil {
+			links = append(links, link)
+		}
+	}
+	if len(links) == 0 {
+		links = s.sel.Get("search.profile_link").All(p)
+		s.log.Info("found profile links", "strategy", "fallback", "count", len(links))
+	} else {
+		s.log.Info("found profile links", "strategy", "result items", "count", len(links))
+	}
+	if len(links) == 0 {
+		return nil, errNoLinks
+	}
+	return links, nil
 }
 
 func (s *Service) SearchAndStoreTargets(ctx context.Context, c Criteria) (int, error) {
 	if c.Limit <= 0 {
 		c.Limit = s.cfg.Limits.MaxProfilesPerSearch
 	}
-	p, err := s.br.NewPage(ctx)
+	excluded, err := exclusion.Load(ctx, s.cfg, s.st)
 	if err != nil {
 		return 0, err
 	}
-	defer p.Close()
-
 	// 1. Build a single, effective keyword string.
 	parts := []string{}
 	if strings.TrimSpace(c.Title) != "" {
@@ -62,179 +103,300 @@ func (s *Service) SearchAndStoreTargets(ctx context.Context, c Criteria) (int, e
 	}
 	kw := strings.Join(parts, " ")
 
-	// 2. Construct the base URL for search.
-	baseSearchURL := fmt.Sprintf(
-		"%ssearch/results/people/?keywords=%s&origin=GLOBAL_SEARCH_HEADER",
-		s.cfg.LinkedIn.BaseURL,
-		url.QueryEscape(kw),
-	)
+	// Profiles remember which search found them so the connection queue can
+	// balance between campaigns
+	source := "search:" + kw
 
-	collected := 0
+	collected, collectedBefore := 0, 0
 	pageNum := 1
-	s.log.Info("starting search", "keywords", kw, "limit", c.Limit)
-
-	// 3. Loop through pages by URL parameter.
-	for ; collected < c.Limit; pageNum++ {
-		pageURL := fmt.Sprintf("%s&page=%d", baseSearchURL, pageNum)
-		s.log.Info("navigating to search page", "url", pageURL)
-
-		if err := p.Navigate(pageURL); err != nil {
-			s.log.Warn("failed to navigate to page", "page", pageNum, "err", err)
-			break // Stop if navigation fails
-		}
-
-		// Wait for the main container of search results to appear.
-		if err := p.WaitLoad(); err != nil {
-			s.log.Warn("page load failed", "page", pageNum, "err", err)
-			break
+	backend := c.Backend
+	if backend == "" {
+		backend = s.cfg.Search.Backend
+	}
+	fetch := s.domPage
+	if backend == "voyager" {
+		fetch = s.voyagerPage
+	}
+	tabs := s.cfg.Browser.Tabs
+	if c.Source != "" {
+		kind, id, ok := config.ParseAudience(c.Source)
+		if !ok {
+			return 0, fmt.Errorf("invalid source %q: expected group:<id> or event:<id>", c.Source)
 		}
+		kw, source, backend = c.Source, c.Source, kind
+		fetch = s.audiencePages(kind, id)
+		// A member list is one page that loads more on scroll
+		tabs = 1
+	}
+	pool, err := s.br.NewTabPool(ctx, tabs)
+	if err != nil {
+		return 0, err
+	}
+	defer pool.Close()
+	s.log.Info("starting search", "keywords", kw, "limit", c.Limit, "backend", backend, "tabs", pool.Size())
 
-		// Wake up movement on each search page for visibility
-		if pageNum == 1 {
-			stealth.WakeUpMovement(p)
+	// An interrupted search for the same keywords continues at its next page
+	prog, resumed, err := s.st.ResumeBatch(ctx, "search", c.Limit)
+	if err != nil {
+		return 0, err
+	}
+	if page, ok := searchCursor(prog.Cursor, kw); resumed && ok {
+		s.log.Info("resuming interrupted search", "page", page, "collected", prog.Sent)
+		pageNum, collected = page, prog.Sent
+		collectedBefore = collected
+		c.Limit = prog.Batch
+	} else {
+		resumed = false
+		prog = &store.RunProgress{Command: "search", Batch: c.Limit}
+	}
+	work := context.WithoutCancel(ctx)
+	defer func() {
+		prog.Sent = collected
+		prog.Cursor = fmt.Sprintf("%d|%s", pageNum, kw)
+		if err := s.st.FinishBatch(work, prog, ctx.Err() != nil); err != nil {
+			s.log.Warn("failed to save progress", "err", err)
 		}
-
-		// Wait for the results container to be visible
-		_, err = p.Element(".search-results-container")
-		if err != nil {
-			s.log.Warn("search results container not found", "page", pageNum, "err", err)
-			browser.ScreenshotOnError(p, "search_fail", err)
+	}()
+
+	// 3. Loop through result pages, one per tab at a time. Results are
+	// stored in page order so the progress cursor stays a single page.
+	done := false
+	for !done && collected < c.Limit {
+		// Pages are the unit of work: stop between them when interrupted
+		if ctx.Err() != nil {
+			s.log.Info("search interrupted, progress saved", "page", pageNum, "collected", collected)
 			break
 		}
+		nums := make([]int, pool.Size())
+		for i := range nums {
+			nums[i] = pageNum + i
+		}
+		results, errs := browser.Map(ctx, pool, nums, func(ctx context.Context, p driver.Page, n int) ([]models.Profile, error) {
+			return fetch(ctx, p, kw, n)
+		})
 
-		// Visible mouse movement and hover over search results
-		stealth.MouseIdleMovement(p)
-		stealth.RandomHover(p, []string{"h3", "div.entity-result__title-text", "a[href*='/in/']"})
-
-		// Scroll to trigger lazy loading.
-		stealth.ScrollHumanLike(p)
-
-		// More visible movement during waiting period
-		stealth.MouseIdleMovement(p)
-		time.Sleep(2500 * time.Millisecond) // Longer pause for JS to render
-
-		// 4. Extract profile links using multiple selector strategies
-		var links rod.Elements
-
-		// Strategy 1: Try modern structure with specific attributes
-		links, err = p.Elements(`a[href*="/in/"][data-test-app-aware-link]`)
-		if err == nil && len(links) > 0 {
-			s.log.Info("found links using strategy 1 (data-test-app-aware-link)", "count", len(links))
-		} else {
-			// Strategy 2: Any link in search results container pointing to /in/
-			links, err = p.Elements(`.search-results-container a[href*="/in/"]`)
-			if err == nil && len(links) > 0 {
-				s.log.Info("found links using strategy 2 (search-results-container)", "count", len(links))
-			} else {
-				// Strategy 3: Look for list items and then find profile links within
-				listItems, _ := p.Elements(`ul[role="list"] li`)
-				if len(listItems) > 0 {
-					s.log.Info("found list items", "count", len(listItems))
-					links = nil
-					for _, item := range listItems {
-						// Find all links within this list item
-						itemLinks, _ := item.Elements(`a[href*="/in/"]`)
-						if len(itemLinks) > 0 {
-							// Take only the first link (profile link) from each item
-							links = append(links, itemLinks[0])
-						}
-					}
-					s.log.Info("found links using strategy 3 (list items)", "count", len(links))
+		for i, found := range results {
+			if collected >= c.Limit {
+				break
+			}
+			if err := errs[i]; err != nil {
+				if ctx.Err() != nil {
+					s.log.Info("search interrupted, progress saved", "page", pageNum, "collected", collected)
 				} else {
-					// Strategy 4: Fallback - any anchor with /in/ in the href
-					links, _ = p.Elements(`a[href*="/in/"]`)
-					s.log.Info("found links using strategy 4 (fallback)", "count", len(links))
+					s.log.Warn("search page failed", "page", pageNum, "backend", backend, "err", err)
 				}
+				done = true
+				break
+			}
+			s.log.Info("profiles found on page", "page", pageNum, "count", len(found))
+			if len(found) == 0 {
+				s.log.Info("no more results, ending search")
+				done = true
+				break
+			}
+
+			// If we didn't collect anything on this page, likely end of results
+			if s.storeResults(work, found, source, excluded, &collected, c.Limit) == 0 {
+				s.log.Info("no unique profiles on this page, ending search")
+				done = true
+				break
+			}
+
+			pageNum++
+			prog.Sent, prog.Cursor = collected, fmt.Sprintf("%d|%s", pageNum, kw)
+			if err := s.st.SaveRunProgress(work, prog); err != nil {
+				s.log.Warn("failed to save progress", "err", err)
 			}
 		}
 
-		if err != nil {
-			s.log.Warn("all selectors failed to find profile links", "page", pageNum, "err", err)
+		// Small delay between pages to be respectful
+		if !done && pageNum < 10 && collected < c.Limit {
+			stealth.SleepRandom(2000, 4000)
+		}
+	}
+
+	s.log.Info("search completed", "total_collected", collected, "pages_visited", pageNum-1)
+	if resumed {
+		// Profiles stored before the interruption were counted by that run
+		return collected - collectedBefore, nil
+	}
+	return collected, nil
+}
+
+// storeResults stores the profiles found on one results page, skipping
+// duplicates on the page, excluded and do-not-contact profiles, until limit
+// is collected. It returns the number of distinct profiles seen.
+func (s *Service) storeResults(ctx context.Context, found []models.Profile, source string, excluded *exclusion.List, collected *int, limit int) int {
+	seenOnPage := map[string]bool{}
+	for _, pmodel := range found {
+		if *collected >= limit {
+			s.log.Info("reached collection limit", "collected", *collected, "limit", limit)
 			break
 		}
+		profileURL := pmodel.LinkedInURL
 
-		s.log.Info("links found on page", "page", pageNum, "count", len(links))
-		if len(links) == 0 {
-			if pageNum > 1 {
-				s.log.Info("no more links found, ending search")
-			} else {
-				s.log.Warn("no links found on first page, search may have failed. Saving debug files.")
-				browser.ScreenshotOnError(p, "search_fail", fmt.Errorf("no results"))
-				// Save full page HTML for debugging
-				html, _ := p.HTML()
-				_ = os.WriteFile("search_fail_full.html", []byte(html), 0644)
-				// Also save just the container if it exists
-				if container, err := p.Element(".search-results-container"); err == nil {
-					containerHTML, _ := container.HTML()
-					_ = os.WriteFile("search_fail_container.html", []byte(containerHTML), 0644)
-				}
-			}
-			break // End of results
+		// Skip duplicates on same page
+		if seenOnPage[profileURL] {
+			s.log.Debug("skipping duplicate on page", "url", profileURL)
+			continue
 		}
+		seenOnPage[profileURL] = true
 
-		seenOnPage := map[string]bool{}
-		for i, linkEl := range links {
-			if collected >= c.Limit {
-				s.log.Info("reached collection limit", "collected", collected, "limit", c.Limit)
-				break
-			}
-			href, err := linkEl.Attribute("href")
-			if err != nil || href == nil {
-				s.log.Debug("skipping link with no href", "index", i)
-				continue
-			}
+		pmodel.Source = source
+		if reason, ok := excluded.Match(&pmodel); ok {
+			s.log.Info("skipping excluded profile", "url", profileURL, "reason", reason)
+			continue
+		}
 
-			profileURL := normalizeProfileURL(*href)
+		// Store in database
+		_, err := s.st.UpsertProfile(ctx, &pmodel)
+		if errors.Is(err, store.ErrDoNotContact) {
+			s.log.Info("skipping profile", "url", profileURL, "reason", err)
+			continue
+		}
+		if err != nil {
+			s.log.Warn("failed to store profile", "url", profileURL, "err", err)
+			continue
+		}
 
-			// Filter out non-profile links
-			if !strings.Contains(profileURL, "/in/") {
-				s.log.Debug("skipping non-profile link", "url", profileURL)
-				continue
-			}
+		*collected++
+		s.log.Info("profile stored", "url", profileURL, "total_collected", *collected)
+	}
+	return len(seenOnPage)
+}
 
-			// Skip duplicates on same page
-			if seenOnPage[profileURL] {
-				s.log.Debug("skipping duplicate on page", "url", profileURL)
-				continue
-			}
-			seenOnPage[profileURL] = true
+// domPage opens results page pageNum for kw and reads the profile links off
+// it, with what each result card shows of the member.
+func (s *Service) domPage(ctx context.Context, p driver.Page, kw string, pageNum int) ([]models.Profile, error) {
+	pageURL := fmt.Sprintf("%ssearch/results/people/?keywords=%s&origin=GLOBAL_SEARCH_HEADER&page=%d",
+		s.cfg.LinkedIn.BaseURL, url.QueryEscape(kw), pageNum)
+	s.log.Info("navigating to search page", "url", pageURL)
+	if err := browser.Navigate(ctx, s.rt, p, pageURL); err != nil {
+		return nil, fmt.Errorf("failed to navigate to page: %w", err)
+	}
 
-			// Try to extract name/headline if available (for better tracking)
-			pmodel := models.Profile{LinkedInURL: profileURL}
+	// Wake up movement on each search page for visibility
+	if pageNum == 1 {
+		s.hu.WakeUpMovement(p)
+	}
 
-			// Store in database
-			_, err = s.st.UpsertProfile(ctx, &pmodel)
-			if err != nil {
-				s.log.Warn("failed to store profile", "url", profileURL, "err", err)
-				continue
-			}
+	// Wait for the results container to be visible
+	_, err := retry.Value(ctx, s.rt, "find search results", func() (driver.Element, error) {
+		return s.sel.Get("search.results_container").Find(p, 10*time.Second)
+	})
+	if err != nil {
+		browser.ScreenshotOnError(p, "search_fail", err)
+		return nil, fmt.Errorf("search results container not found: %w", err)
+	}
 
-			collected++
-			s.log.Info("profile stored", "url", profileURL, "total_collected", collected)
-		}
+	// Visible mouse movement and hover over search results
+	s.hu.MouseIdleMovement(p)
+	s.hu.RandomHover(p, s.sel.Get("search.hover_targets").CSS())
 
-		// If we didn't collect anything on this page, likely end of results
-		if len(seenOnPage) == 0 {
-			s.log.Info("no unique profiles on this page, ending search")
-			break
-		}
+	// Scroll to trigger lazy loading.
+	s.hu.ScrollHumanLike(p)
 
-		// Small delay between pages to be respectful
-		if pageNum < 10 && collected < c.Limit {
-			stealth.SleepRandom(2000, 4000)
+	// More visible movement during waiting period
+	s.hu.MouseIdleMovement(p)
+	time.Sleep(2500 * time.Millisecond) // Longer pause for JS to render
+
+	// Extract profile links, from the most to the least specific selector
+	links, err := retry.Value(ctx, s.rt, "find profile links", func() ([]driver.Element, error) { return s.profileLinks(p) })
+	if err != nil && !errors.Is(err, errNoLinks) {
+		return nil, fmt.Errorf("all selectors failed to find profile links: %w", err)
+	}
+	if len(links) == 0 && pageNum == 1 {
+		s.log.Warn("no links found on first page, search may have failed. Saving debug files.")
+		browser.ScreenshotOnError(p, "search_fail", fmt.Errorf("no results"))
+	}
+
+	cards := s.resultCards(p)
+	var out []models.Profile
+	for i, linkEl := range links {
+		href, err := linkEl.Attribute("href")
+		if err != nil || href == nil {
+			s.log.Debug("skipping link with no href", "index", i)
+			continue
+		}
+		profileURL := models.CanonicalProfileURL(*href)
+		// Filter out non-profile links
+		if !strings.Contains(profileURL, "/in/") {
+			s.log.Debug("skipping non-profile link", "url", profileURL)
+			continue
 		}
+		prof := cards[profileURL]
+		prof.LinkedInURL = profileURL
+		out = append(out, prof)
 	}
+	return out, nil
+}
 
-	s.log.Info("search completed", "total_collected", collected, "pages_visited", pageNum-1)
-	return collected, nil
+// resultCards reads the name, headline, location, current company,
+// connection degree and Open Profile/Premium status of each result card,
+// keyed by profile URL.
+func (s *Service) resultCards(p driver.Page) map[string]models.Profile {
+	out := map[string]models.Profile{}
+	for _, item := range s.sel.Get("search.result_item").All(p) {
+		link, err := s.sel.Get("search.profile_link").In(item, 200*time.Millisecond)
+		if err != nil {
+			continue
+		}
+		href, err := link.Attribute("href")
+		if err != nil || href == nil {
+			continue
+		}
+		prof := models.Profile{
+			Name:     s.cardText(item, "search.result_name"),
+			Headline: s.cardText(item, "search.result_headline"),
+			Location: s.cardText(item, "search.result_location"),
+		}
+		// "Current: Title at Company" names the company even when the
+		// headline doesn't; the label before the colon is localized
+		summary := s.cardText(item, "search.result_summary")
+		if i := strings.Index(summary, ":"); i >= 0 {
+			prof.Company = extract.CompanyFromHeadline(s.sel.Pattern("profile.headline_company"), summary[i+1:])
+		}
+		if prof.Company == "" {
+			prof.Company = extract.CompanyFromHeadline(s.sel.Pattern("profile.headline_company"), prof.Headline)
+		}
+		if badge, err := s.sel.Get("search.result_degree").In(item, 200*time.Millisecond); err == nil {
+			text, _ := badge.Text()
+			prof.Degree = extract.ParseDegree(s.sel.Pattern("profile.degree"), text)
+		}
+		// The screen-reader label of the badge, for cards where
+		// result_degree matches nothing
+		if prof.Degree == "" {
+			text, _ := item.Text()
+			prof.Degree = extract.ParseDegree(s.sel.Pattern("search.degree_label"), text)
+		}
+		prof.OpenProfile = s.sel.Get("search.result_premium").HasIn(item) ||
+			(prof.Degree != "" && prof.Degree != "1st" && s.sel.Get("search.result_message_button").HasIn(item))
+		out[models.CanonicalProfileURL(*href)] = prof
+	}
+	return out
 }
 
-func normalizeProfileURL(u string) string {
-	if i := strings.Index(u, "?"); i >= 0 {
-		u = u[:i]
+// cardText returns the trimmed text of the element key finds in a result
+// card, or "".
+func (s *Service) cardText(item driver.Element, key string) string {
+	el, err := s.sel.Get(key).In(item, 200*time.Millisecond)
+	if err != nil {
+		return ""
+	}
+	text, err := el.Text()
+	if err != nil {
+		return ""
 	}
-	if !strings.HasPrefix(u, "http") {
-		u = "https://www.linkedin.com" + u
+	return strings.TrimSpace(text)
+}
+
+// searchCursor parses the "page|keywords" cursor saved by an interrupted
+// search and returns the page when the keywords match.
+func searchCursor(cursor, kw string) (int, bool) {
+	page, rest, ok := strings.Cut(cursor, "|")
+	if !ok || rest != kw {
+		return 0, false
 	}
-	return u
+	n, err := strconv.Atoi(page)
+	return n, err == nil && n > 0
 }
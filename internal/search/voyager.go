@@ -0,0 +1,165 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/example/linkedbot/internal/browser"
+	"github.com/example/linkedbot/internal/driver"
+	"github.com/example/linkedbot/internal/extract"
+	"github.com/example/linkedbot/internal/models"
+	"github.com/example/linkedbot/internal/retry"
+)
+
+// voyagerResultType is the $type of a people result in a search response.
+const voyagerResultType = "com.linkedin.voyager.dash.search.EntityResultViewModel"
+
+// voyagerFetchJS calls the API from inside the page so the request carries the
+// session cookies and looks like LinkedIn's own. The csrf token is the
+// JSESSIONID cookie without its quotes.
+const voyagerFetchJS = `async (path) => {
+	const m = document.cookie.match(/JSESSIONID="?([^";]+)"?/);
+	if (!m) return {status: 0, body: 'no JSESSIONID cookie'};
+	const resp = await fetch(path, {
+		credentials: 'include',
+		headers: {
+			'csrf-token': m[1],
+			'accept': 'application/vnd.linkedin.normalized+json+2.1',
+			'x-restli-protocol-version': '2.0.0',
+			'x-li-lang': 'en_US',
+		},
+	});
+	return {status: resp.status, body: await resp.text()};
+}`
+
+// voyagerPage fetches results page pageNum for kw from the people-search API
+// and parses name, headline, location and member URN out of the JSON, so no
+// results page is rendered or scraped.
+func (s *Service) voyagerPage(ctx context.Context, p driver.Page, kw string, pageNum int) ([]models.Profile, error) {
+	// The API is same-origin only: fetch from a LinkedIn page
+	if u, err := p.URL(); err != nil || !strings.HasPrefix(u, s.cfg.LinkedIn.BaseURL) {
+		if err := browser.Navigate(ctx, s.rt, p, s.cfg.LinkedIn.BaseURL+"feed/"); err != nil {
+			return nil, fmt.Errorf("failed to open feed for the api session: %w", err)
+		}
+	}
+	path := voyagerSearchPath(s.cfg.Search.Voyager.QueryID, kw, (pageNum-1)*s.cfg.Search.Voyager.PageSize, s.cfg.Search.Voyager.PageSize)
+	s.log.Info("querying search api", "page", pageNum, "keywords", kw)
+
+	body, err := retry.Value(ctx, s.rt, "search api", func() ([]byte, error) {
+		res, err := p.Eval(voyagerFetchJS, path)
+		if err != nil {
+			return nil, err
+		}
+		var out struct {
+			Status int    `json:"status"`
+			Body   string `json:"body"`
+		}
+		if err := res.Unmarshal(&out); err != nil {
+			return nil, err
+		}
+		switch {
+		case out.Status == 0:
+			return nil, retry.Permanent(fmt.Errorf("search api: %s", out.Body))
+		case out.Status == 401 || out.Status == 403:
+			return nil, retry.Permanent(fmt.Errorf("search api refused the session (HTTP %d), try search.backend: dom", out.Status))
+		case out.Status != 200:
+			return nil, fmt.Errorf("search api: HTTP %d", out.Status)
+		}
+		return []byte(out.Body), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.parseVoyagerPeople(body)
+}
+
+// voyagerSearchPath builds the graphql people-search request. Values inside
+// the rest.li variables are percent-encoded, spaces included.
+func voyagerSearchPath(queryID, kw string, start, count int) string {
+	keywords := strings.ReplaceAll(url.QueryEscape(kw), "+", "%20")
+	return fmt.Sprintf("/voyager/api/graphql?variables=(start:%d,count:%d,origin:GLOBAL_SEARCH_HEADER,"+
+		"query:(keywords:%s,flagshipSearchIntent:SEARCH_SRP,queryParameters:List((key:resultType,value:List(PEOPLE))),"+
+		"includeFiltersInResponse:false))&queryId=%s", start, count, keywords, url.QueryEscape(queryID))
+}
+
+// voyagerDistance maps a result's memberDistance to the badge degree.
+// OUT_OF_NETWORK members are left unknown.
+var voyagerDistance = map[string]string{
+	"DISTANCE_1": "1st",
+	"DISTANCE_2": "2nd",
+	"DISTANCE_3": "3rd",
+}
+
+// voyagerText is the {"text": ...} wrapper the API puts around strings.
+type voyagerText struct {
+	Text string `json:"text"`
+}
+
+// parseVoyagerPeople reads the people results of a normalized search
+// response. A premium badge icon marks Open Profile/Premium members. Results without a public profile link ("LinkedIn Member") are
+// skipped.
+func (s *Service) parseVoyagerPeople(body []byte) ([]models.Profile, error) {
+	var resp struct {
+		Included []struct {
+			Type              string          `json:"$type"`
+			EntityURN         string          `json:"entityUrn"`
+			NavigationURL     string          `json:"navigationUrl"`
+			Title             *voyagerText    `json:"title"`
+			PrimarySubtitle   *voyagerText    `json:"primarySubtitle"`
+			SecondarySubtitle *voyagerText    `json:"secondarySubtitle"`
+			BadgeText         *voyagerText    `json:"badgeText"`
+			BadgeIcon         json.RawMessage `json:"badgeIcon"`
+			Tracking          struct {
+				MemberDistance string `json:"memberDistance"`
+			} `json:"entityCustomTrackingInfo"`
+		} `json:"included"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("search api: unexpected response: %w", err)
+	}
+	var out []models.Profile
+	for _, item := range resp.Included {
+		if item.Type != voyagerResultType || item.NavigationURL == "" {
+			continue
+		}
+		profileURL := models.CanonicalProfileURL(item.NavigationURL)
+		if !strings.Contains(profileURL, "/in/") {
+			continue
+		}
+		prof := models.Profile{LinkedInURL: profileURL, MemberURN: voyagerMemberURN(item.EntityURN)}
+		if item.Title != nil {
+			prof.Name = item.Title.Text
+		}
+		if item.PrimarySubtitle != nil {
+			prof.Headline = item.PrimarySubtitle.Text
+			prof.Company = extract.CompanyFromHeadline(s.sel.Pattern("profile.headline_company"), prof.Headline)
+		}
+		if item.SecondarySubtitle != nil {
+			prof.Location = item.SecondarySubtitle.Text
+		}
+		prof.Degree = voyagerDistance[item.Tracking.MemberDistance]
+		if prof.Degree == "" && item.BadgeText != nil {
+			prof.Degree = extract.ParseDegree(s.sel.Pattern("profile.degree"), item.BadgeText.Text)
+		}
+		prof.OpenProfile = strings.Contains(string(item.BadgeIcon), "PREMIUM")
+		out = append(out, prof)
+	}
+	return out, nil
+}
+
+// voyagerMemberURN extracts the urn:li:fsd_profile:... embedded in a result's
+// entityUrn, e.g. urn:li:fsd_entityResultViewModel:(urn:li:fsd_profile:ACoAA...,SEARCH_SRP,DEFAULT).
+func voyagerMemberURN(entityURN string) string {
+	i := strings.Index(entityURN, "urn:li:fsd_profile:")
+	if i < 0 {
+		return ""
+	}
+	urn := entityURN[i:]
+	if j := strings.IndexAny(urn, ",)"); j >= 0 {
+		urn = urn[:j]
+	}
+	return urn
+}
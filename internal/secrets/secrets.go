@@ -0,0 +1,173 @@
+package secrets
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"golang.org/x/crypto/nacl/secretbox"
+	"golang.org/x/crypto/scrypt"
+)
+
+// magic starts every sealed file, so plaintext files are told apart.
+var magic = []byte("linkedbot-secretbox-v1\n")
+
+const (
+	saltLen  = 16
+	nonceLen = 24
+)
+
+// ErrPassphrase is returned when sealed data doesn't open with the
+// passphrase, or was tampered with.
+var ErrPassphrase = errors.New("wrong passphrase or corrupted data")
+
+// Seal encrypts data with a key derived from passphrase (scrypt) using NaCl
+// secretbox. Every call uses a new salt and nonce.
+func Seal(passphrase string, data []byte) ([]byte, error) {
+	if passphrase == "" {
+		return nil, errors.New("empty passphrase")
+	}
+	var salt [saltLen]byte
+	var nonce [nonceLen]byte
+	if _, err := rand.Read(salt[:]); err != nil {
+		return nil, err
+	}
+	if _, err := rand.Read(nonce[:]); err != nil {
+		return nil, err
+	}
+	key, err := deriveKey(passphrase, salt[:])
+	if err != nil {
+		return nil, err
+	}
+	out := append(append(append([]byte{}, magic...), salt[:]...), nonce[:]...)
+	return secretbox.Seal(out, data, &nonce, key), nil
+}
+
+// Open decrypts data sealed by Seal.
+func Open(passphrase string, data []byte) ([]byte, error) {
+	if !IsSealed(data) {
+		return nil, errors.New("not sealed by linkedbot")
+	}
+	data = data[len(magic):]
+	if len(data) < saltLen+nonceLen+secretbox.Overhead {
+		return nil, ErrPassphrase
+	}
+	var nonce [nonceLen]byte
+	copy(nonce[:], data[saltLen:saltLen+nonceLen])
+	key, err := deriveKey(passphrase, data[:saltLen])
+	if err != nil {
+		return nil, err
+	}
+	plain, ok := secretbox.Open(nil, data[saltLen+nonceLen:], &nonce, key)
+	if !ok {
+		return nil, ErrPassphrase
+	}
+	return plain, nil
+}
+
+// IsSealed reports whether data was written by Seal.
+func IsSealed(data []byte) bool { return bytes.HasPrefix(data, magic) }
+
+func deriveKey(passphrase string, salt []byte) (*[32]byte, error) {
+	k, err := scrypt.Key([]byte(passphrase), salt, 1<<15, 8, 1, 32)
+	if err != nil {
+		return nil, err
+	}
+	var key [32]byte
+	copy(key[:], k)
+	return &key, nil
+}
+
+// WriteFile seals data and writes it to path readable by the owner only,
+// replacing the file in one step.
+func WriteFile(path, passphrase string, data []byte) error {
+	sealed, err := Seal(passphrase, data)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, sealed, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Store is a set of named secrets kept sealed in one file. Names are the
+// env vars the secrets stand in for, e.g. LINKEDIN_PASSWORD.
+type Store struct {
+	path       string
+	passphrase string
+	values     map[string]string
+}
+
+// Load opens the store at path; a missing file is an empty store.
+func Load(path, passphrase string) (*Store, error) {
+	s := &Store{path: path, passphrase: passphrase, values: map[string]string{}}
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	plain, err := Open(passphrase, b)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if err := json.Unmarshal(plain, &s.values); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return s, nil
+}
+
+// Get returns the secret called name.
+func (s *Store) Get(name string) (string, bool) {
+	v, ok := s.values[name]
+	return v, ok
+}
+
+// Set stores value under name; call Save to write it.
+func (s *Store) Set(name, value string) { s.values[name] = value }
+
+// Delete removes name and reports whether it was stored.
+func (s *Store) Delete(name string) bool {
+	_, ok := s.values[name]
+	delete(s.values, name)
+	return ok
+}
+
+// Names lists the stored names in order.
+func (s *Store) Names() []string {
+	names := make([]string, 0, len(s.values))
+	for n := range s.values {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Values returns a copy of every secret by name.
+func (s *Store) Values() map[string]string {
+	out := make(map[string]string, len(s.values))
+	for n, v := range s.values {
+		out[n] = v
+	}
+	return out
+}
+
+// Save seals the store and writes it back.
+func (s *Store) Save() error {
+	b, err := json.Marshal(s.values)
+	if err != nil {
+		return err
+	}
+	return WriteFile(s.path, s.passphrase, b)
+}
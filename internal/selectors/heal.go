@@ -0,0 +1,265 @@
+package selectors
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/example/linkedbot/internal/driver"
+	"gopkg.in/yaml.v3"
+)
+
+// healMinScore is the score a heuristic candidate needs: a text or attribute
+// match in the expected region, or both matches anywhere. A lone "Connect"
+// in a sidebar must not be mistaken for the profile's own button.
+const healMinScore = 5
+
+// maxLearned caps the fallbacks remembered per key, most recent first.
+const maxLearned = 3
+
+// Heuristic describes an element well enough to find it when every selector
+// of its key fails, e.g. after a LinkedIn UI experiment renamed its classes.
+type Heuristic struct {
+	// Candidates is the CSS of the elements to score, e.g. "button".
+	Candidates string `yaml:"candidates"`
+	// Text is matched against the visible text (+3).
+	Text string `yaml:"text,omitempty"`
+	// Aria is matched against aria-label, title, placeholder and name (+3).
+	Aria string `yaml:"aria,omitempty"`
+	// Near is the CSS of the region the element belongs to: +2 inside it,
+	// +1 just below it.
+	Near string `yaml:"near,omitempty"`
+}
+
+// Learned is a selector the heuristic fallback derived from an element it
+// found, tried before scoring again on later lookups.
+type Learned struct {
+	Key      string    `yaml:"key"`
+	CSS      string    `yaml:"css"`
+	Text     string    `yaml:"text,omitempty"`
+	Score    int       `yaml:"score"`
+	Hits     int       `yaml:"hits"`
+	LastUsed time.Time `yaml:"last_used"`
+}
+
+func (l Learned) selector() Selector { return Selector{CSS: l.CSS, Text: l.Text} }
+
+// learnedStore keeps the learned selectors in selectors.learned_path. It is
+// shared by every account so a fix found by one run helps the others.
+type learnedStore struct {
+	path    string
+	mu      sync.Mutex
+	entries []Learned
+}
+
+func loadLearned(path string) (*learnedStore, error) {
+	ls := &learnedStore{path: path}
+	b, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return ls, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(b, &ls.entries); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return ls, nil
+}
+
+func (ls *learnedStore) forKey(key string) []Learned {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	var out []Learned
+	for _, l := range ls.entries {
+		if l.Key == key {
+			out = append(out, l)
+		}
+	}
+	return out
+}
+
+// record counts a hit for l, adding it if new, and saves the file.
+func (ls *learnedStore) record(l Learned) error {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	l.LastUsed = time.Now()
+	found := false
+	for i, e := range ls.entries {
+		if e.Key == l.Key && e.CSS == l.CSS && e.Text == l.Text {
+			ls.entries[i].Hits++
+			ls.entries[i].LastUsed = l.LastUsed
+			found = true
+		}
+	}
+	if !found {
+		l.Hits = 1
+		ls.entries = append(ls.entries, l)
+	}
+	sort.SliceStable(ls.entries, func(i, j int) bool { return ls.entries[i].LastUsed.After(ls.entries[j].LastUsed) })
+	kept := ls.entries[:0]
+	perKey := map[string]int{}
+	for _, e := range ls.entries {
+		if perKey[e.Key]++; perKey[e.Key] <= maxLearned {
+			kept = append(kept, e)
+		}
+	}
+	ls.entries = kept
+	b, err := yaml.Marshal(ls.entries)
+	if err != nil {
+		return err
+	}
+	return writeCache(ls.path, b)
+}
+
+// Lookup is a registry key ready to query. Find falls back to learned
+// selectors and the key's heuristic when the registered ones fail; Has, All
+// and In use the registered selectors only.
+type Lookup struct {
+	Set
+	key string
+	r   *Registry
+}
+
+// Find returns the first element matched by the key's selectors, waiting up
+// to d for each, then tries what earlier heuristic lookups learned and
+// finally scores the page with the key's heuristic.
+func (l Lookup) Find(p driver.Page, d time.Duration) (driver.Element, error) {
+	el, err := l.Set.Find(p, d)
+	if err == nil {
+		return el, nil
+	}
+	h, ok := l.r.heuristics[l.key]
+	if !ok || l.r.learned == nil {
+		return nil, err
+	}
+	for _, known := range l.r.learned.forKey(l.key) {
+		if el, lerr := known.selector().Find(p, time.Second); lerr == nil {
+			l.r.log.Info("selector failed, using learned fallback", "key", l.key, "css", known.CSS, "text", known.Text)
+			l.r.remember(known)
+			return el, nil
+		}
+	}
+	el, found, herr := heal(p, h, d)
+	if herr != nil || el == nil {
+		return nil, err
+	}
+	l.r.log.Warn("selector failed, healed with heuristic", "key", l.key, "css", found.CSS, "text", found.Text, "score", found.Score)
+	found.Key = l.key
+	l.r.remember(found)
+	return el, nil
+}
+
+func (r *Registry) remember(l Learned) {
+	if err := r.learned.record(l); err != nil {
+		r.log.Warn("failed to save learned selector", "key", l.Key, "err", err)
+	}
+}
+
+// healJS scores the candidates and tags the best one with the token so it can
+// be fetched as an element. It returns null when nothing scores enough, or
+// the score and a selector derived from the winner's tag, stable classes and
+// short text.
+const healJS = `(spec) => {
+	const toRe = (s) => !s ? null : s.startsWith('(?i)') ? new RegExp(s.slice(4), 'i') : new RegExp(s);
+	const text = toRe(spec.text), aria = toRe(spec.aria);
+	const regions = spec.near ? Array.from(document.querySelectorAll(spec.near)) : [];
+	let best = null, bestScore = 0;
+	for (const el of document.querySelectorAll(spec.candidates)) {
+		const r = el.getBoundingClientRect();
+		if (r.width === 0 || r.height === 0 || el.disabled) continue;
+		let score = 0;
+		const t = (el.innerText || el.value || '').trim();
+		if (text && text.test(t)) score += 3;
+		const attrs = ['aria-label', 'title', 'placeholder', 'name'].map((a) => el.getAttribute(a) || '').join(' ');
+		if (aria && aria.test(attrs)) score += 3;
+		if (regions.some((g) => g.contains(el))) score += 2;
+		else if (regions.some((g) => { const b = g.getBoundingClientRect(); return r.top >= b.bottom && r.top - b.bottom < 200; })) score += 1;
+		if (/primary/.test(el.getAttribute('class') || '')) score += 1;
+		if (score > bestScore) { best = el; bestScore = score; }
+	}
+	if (!best || bestScore < spec.min) return null;
+	best.setAttribute('data-linkedbot-heal', spec.token);
+	const classes = Array.from(best.classList).filter((c) => !/\d|^ember/.test(c)).slice(0, 3);
+	let css = best.tagName.toLowerCase() + classes.map((c) => '.' + CSS.escape(c)).join('');
+	if (spec.near && regions.some((g) => g.contains(best))) css = ':is(' + spec.near + ') ' + css;
+	const t = (best.innerText || '').trim();
+	return {score: bestScore, css: css, text: t.length > 0 && t.length <= 40 ? t : ''};
+}`
+
+var healSeq struct {
+	sync.Mutex
+	n int
+}
+
+// heal runs the heuristic on p. el is nil when no candidate scores enough.
+func heal(p driver.Page, h Heuristic, d time.Duration) (driver.Element, Learned, error) {
+	healSeq.Lock()
+	healSeq.n++
+	token := strconv.Itoa(healSeq.n)
+	healSeq.Unlock()
+
+	res, err := p.Timeout(d).Eval(healJS, map[string]any{
+		"candidates": h.Candidates,
+		"text":       h.Text,
+		"aria":       h.Aria,
+		"near":       h.Near,
+		"min":        healMinScore,
+		"token":      token,
+	})
+	if err != nil {
+		return nil, Learned{}, err
+	}
+	if res.Nil() {
+		return nil, Learned{}, nil
+	}
+	var found struct {
+		Score int    `json:"score"`
+		CSS   string `json:"css"`
+		Text  string `json:"text"`
+	}
+	if err := res.Unmarshal(&found); err != nil {
+		return nil, Learned{}, err
+	}
+	el, err := Selector{CSS: `[data-linkedbot-heal="` + token + `"]`}.Find(p, d)
+	if err != nil {
+		return nil, Learned{}, err
+	}
+	l := Learned{CSS: found.CSS, Score: found.Score}
+	if found.Text != "" {
+		l.Text = `^\s*` + regexp.QuoteMeta(found.Text) + `\s*$`
+	}
+	return el, l, nil
+}
+
+// Learned returns the fallbacks the heuristics found, most recent first.
+func (r *Registry) Learned() []Learned {
+	if r.learned == nil {
+		return nil
+	}
+	r.learned.mu.Lock()
+	defer r.learned.mu.Unlock()
+	return append([]Learned(nil), r.learned.entries...)
+}
+
+// checkHeuristics validates the heuristics of a selector file against keys.
+func checkHeuristics(hs map[string]Heuristic, keys map[string]Set) error {
+	for key, h := range hs {
+		if _, ok := keys[key]; !ok {
+			return fmt.Errorf("heuristics: unknown selector %q", key)
+		}
+		if h.Candidates == "" || (h.Text == "" && h.Aria == "") {
+			return fmt.Errorf("heuristics.%s: candidates and text or aria are required", key)
+		}
+		for _, re := range []string{h.Text, h.Aria} {
+			if _, err := regexp.Compile(re); err != nil {
+				return fmt.Errorf("heuristics.%s: %w", key, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,526 @@
+package selectors
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/example/linkedbot/internal/config"
+	"github.com/example/linkedbot/internal/driver"
+	"github.com/example/linkedbot/internal/logging"
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed selectors.yaml
+var builtin []byte
+
+// locales holds the texts of LinkedIn's other UI languages, one file per
+// linkedin.locale.
+//
+//go:embed locales/*.yaml
+var locales embed.FS
+
+// fetchTimeout bounds the download of selectors.url.
+const fetchTimeout = 15 * time.Second
+
+// Selector is one way to find an element: a CSS selector, optionally narrowed
+// to elements whose text matches the Text regular expression. In YAML it is a
+// plain CSS string or {css, text}.
+type Selector struct {
+	CSS  string `yaml:"css"`
+	Text string `yaml:"text,omitempty"`
+}
+
+func (s *Selector) UnmarshalYAML(n *yaml.Node) error {
+	if n.Kind == yaml.ScalarNode {
+		s.CSS = n.Value
+		return nil
+	}
+	type plain Selector
+	return n.Decode((*plain)(s))
+}
+
+func (s Selector) MarshalYAML() (any, error) {
+	if s.Text == "" {
+		return s.CSS, nil
+	}
+	type plain Selector
+	return plain(s), nil
+}
+
+func (s Selector) String() string {
+	if s.Text == "" {
+		return s.CSS
+	}
+	return fmt.Sprintf("%s /%s/", s.CSS, s.Text)
+}
+
+// Find waits up to d for an element matching s. The element is returned
+// without the lookup deadline, so actions on it use the page's own timeout.
+func (s Selector) Find(p driver.Page, d time.Duration) (driver.Element, error) {
+	pg := p.Timeout(d)
+	var el driver.Element
+	var err error
+	if s.Text != "" {
+		el, err = pg.ElementR(s.CSS, jsRegex(s.Text))
+	} else {
+		el, err = pg.Element(s.CSS)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return el.CancelTimeout(), nil
+}
+
+// jsRegex converts a Go regexp to the form ElementR evaluates in the page: a
+// leading (?i) becomes the /.../i flag, which JavaScript has no inline syntax
+// for.
+func jsRegex(re string) string {
+	if rest, ok := strings.CutPrefix(re, "(?i)"); ok {
+		return "/" + rest + "/i"
+	}
+	return re
+}
+
+// Set is the alternatives of one registry key, tried in order.
+type Set []Selector
+
+// Find returns the first element matched by an alternative, waiting up to d
+// for each.
+func (s Set) Find(p driver.Page, d time.Duration) (driver.Element, error) {
+	err := errors.New("no selectors")
+	for _, sel := range s {
+		el, e := sel.Find(p, d)
+		if e == nil {
+			return el, nil
+		}
+		err = e
+	}
+	return nil, err
+}
+
+// Has reports whether any alternative matches within a short wait.
+func (s Set) Has(p driver.Page) bool {
+	_, err := s.Find(p, 2*time.Second)
+	return err == nil
+}
+
+// All returns the elements of the first alternative that matches any,
+// without waiting.
+func (s Set) All(p driver.Page) []driver.Element {
+	for _, sel := range s {
+		els, err := p.Elements(sel.CSS)
+		if err != nil || len(els) == 0 {
+			continue
+		}
+		if sel.Text != "" {
+			els = filterText(els, regexp.MustCompile(sel.Text))
+		}
+		if len(els) > 0 {
+			return els
+		}
+	}
+	return nil
+}
+
+// HasIn reports whether any alternative matches inside el, without waiting.
+func (s Set) HasIn(el driver.Element) bool {
+	for _, sel := range s {
+		els, err := el.Elements(sel.CSS)
+		if err != nil || len(els) == 0 {
+			continue
+		}
+		if sel.Text == "" || len(filterText(els, regexp.MustCompile(sel.Text))) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// In finds the first match inside el, waiting up to d for each alternative.
+func (s Set) In(el driver.Element, d time.Duration) (driver.Element, error) {
+	err := errors.New("no selectors")
+	for _, sel := range s {
+		var found driver.Element
+		if sel.Text != "" {
+			found, err = el.Timeout(d).ElementR(sel.CSS, jsRegex(sel.Text))
+		} else {
+			found, err = el.Timeout(d).Element(sel.CSS)
+		}
+		if err == nil {
+			return found.CancelTimeout(), nil
+		}
+	}
+	return nil, err
+}
+
+// CSS returns the CSS part of every alternative, e.g. for hover targets.
+func (s Set) CSS() []string {
+	out := make([]string, len(s))
+	for i, sel := range s {
+		out[i] = sel.CSS
+	}
+	return out
+}
+
+// Pattern is the alternatives of one text pattern, tried in order. Unlike a
+// selector's text it is matched against text already read from the page, to
+// tell what a banner, label or notification says.
+type Pattern []*regexp.Regexp
+
+// MatchString reports whether any alternative matches s.
+func (p Pattern) MatchString(s string) bool {
+	return p.FindStringSubmatchIndex(s) != nil
+}
+
+// FindStringSubmatch returns the submatches of the first alternative that
+// matches s, or nil.
+func (p Pattern) FindStringSubmatch(s string) []string {
+	for _, re := range p {
+		if m := re.FindStringSubmatch(s); m != nil {
+			return m
+		}
+	}
+	return nil
+}
+
+// FindStringSubmatchIndex is FindStringSubmatch returning index pairs.
+func (p Pattern) FindStringSubmatchIndex(s string) []int {
+	for _, re := range p {
+		if m := re.FindStringSubmatchIndex(s); m != nil {
+			return m
+		}
+	}
+	return nil
+}
+
+func compile(res []string) Pattern {
+	out := make(Pattern, len(res))
+	for i, re := range res {
+		out[i] = regexp.MustCompile(re)
+	}
+	return out
+}
+
+func filterText(els []driver.Element, re *regexp.Regexp) []driver.Element {
+	var out []driver.Element
+	for _, el := range els {
+		if text, err := el.Text(); err == nil && re.MatchString(text) {
+			out = append(out, el)
+		}
+	}
+	return out
+}
+
+// file is the YAML layout of the built-in, remote and override selectors:
+// a version, the heuristics by key, the text patterns by key and group ->
+// name -> alternatives.
+type file struct {
+	Version    int                       `yaml:"version"`
+	Heuristics map[string]Heuristic      `yaml:"heuristics,omitempty"`
+	Patterns   map[string][]string       `yaml:"patterns,omitempty"`
+	Groups     map[string]map[string]Set `yaml:",inline"`
+}
+
+// Registry holds the selectors in effect: the built-in ones with the remote
+// (selectors.url) overrides, the linkedin.locale translations and the local
+// (selectors.file) overrides applied on top, in that order. Keys are
+// "group.name", e.g. "profile.connect_button".
+type Registry struct {
+	Version int
+	// Sources lists where the selectors came from, built-in first.
+	Sources    []string
+	sets       map[string]Set
+	heuristics map[string]Heuristic
+	patterns   map[string][]string
+	compiled   map[string]Pattern
+	// learned is nil when selectors.self_heal is off
+	learned *learnedStore
+	log     *logging.Logger
+}
+
+// Load builds the registry for cfg. A remote file that can't be fetched is
+// replaced by the last cached copy, or skipped with a warning; invalid local
+// overrides are an error.
+func Load(ctx context.Context, cfg *config.Config) (*Registry, error) {
+	log := logging.New(cfg.Logging.Level).With("module", "selectors")
+	base, err := parse(builtin)
+	if err != nil {
+		return nil, fmt.Errorf("built-in selectors: %w", err)
+	}
+	r := &Registry{Version: base.Version, Sources: []string{"built-in"}, sets: flatten(base), heuristics: map[string]Heuristic{}, patterns: base.Patterns, log: log}
+	if err := checkHeuristics(base.Heuristics, r.sets); err != nil {
+		return nil, fmt.Errorf("built-in selectors: %w", err)
+	}
+	for key, h := range base.Heuristics {
+		r.heuristics[key] = h
+	}
+	if cfg.Selectors.SelfHeal {
+		if r.learned, err = loadLearned(cfg.Selectors.LearnedPath); err != nil {
+			log.Warn("learned selectors unreadable, starting over", "err", err)
+			r.learned = &learnedStore{path: cfg.Selectors.LearnedPath}
+		}
+	}
+
+	if cfg.Selectors.URL != "" {
+		remote, src, err := loadRemote(ctx, cfg, log)
+		switch {
+		case err != nil:
+			log.Warn("remote selectors unavailable, using built-in", "url", cfg.Selectors.URL, "err", err)
+		case remote.Version < base.Version:
+			log.Warn("remote selectors are older than the built-in ones, ignoring", "url", cfg.Selectors.URL, "version", remote.Version, "builtin_version", base.Version)
+		default:
+			if err := r.apply(src, remote); err != nil {
+				log.Warn("remote selectors rejected, using built-in", "err", err)
+			}
+		}
+	}
+
+	if loc := cfg.LinkedIn.Locale; loc != "en" {
+		b, err := locales.ReadFile("locales/" + loc + ".yaml")
+		if err != nil {
+			return nil, fmt.Errorf("linkedin.locale: no translations for %q, want one of %s", loc, strings.Join(Locales(), ", "))
+		}
+		tr, err := parse(b)
+		if err != nil {
+			return nil, fmt.Errorf("locale %s: %w", loc, err)
+		}
+		if err := r.extend("locale "+loc, tr); err != nil {
+			return nil, err
+		}
+	}
+
+	if cfg.Selectors.File != "" {
+		b, err := os.ReadFile(cfg.Selectors.File)
+		if err != nil {
+			return nil, fmt.Errorf("selectors.file: %w", err)
+		}
+		local, err := parse(b)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", cfg.Selectors.File, err)
+		}
+		if err := r.apply(cfg.Selectors.File, local); err != nil {
+			return nil, err
+		}
+	}
+	r.compiled = make(map[string]Pattern, len(r.patterns))
+	for key, res := range r.patterns {
+		r.compiled[key] = compile(res)
+	}
+	log.Debug("selectors loaded", "version", r.Version, "sources", strings.Join(r.Sources, ", "))
+	return r, nil
+}
+
+// loadRemote downloads selectors.url and caches it, falling back to the cache
+// when the download fails.
+func loadRemote(ctx context.Context, cfg *config.Config, log *logging.Logger) (*file, string, error) {
+	b, err := fetch(ctx, cfg.Selectors.URL)
+	if err == nil {
+		var f *file
+		if f, err = parse(b); err == nil {
+			if werr := writeCache(cfg.Selectors.CachePath, b); werr != nil {
+				log.Warn("failed to cache remote selectors", "err", werr)
+			}
+			return f, cfg.Selectors.URL, nil
+		}
+	}
+	cached, cerr := os.ReadFile(cfg.Selectors.CachePath)
+	if cerr != nil {
+		return nil, "", err
+	}
+	f, cerr := parse(cached)
+	if cerr != nil {
+		return nil, "", err
+	}
+	return f, cfg.Selectors.CachePath + " (cached)", nil
+}
+
+func fetch(ctx context.Context, url string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, fetchTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+func writeCache(path string, b []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, b, 0o644)
+}
+
+// parse decodes and checks a selector file.
+func parse(b []byte) (*file, error) {
+	var f file
+	if err := yaml.Unmarshal(b, &f); err != nil {
+		return nil, err
+	}
+	for key, set := range flatten(&f) {
+		if len(set) == 0 {
+			return nil, fmt.Errorf("%s: no selectors", key)
+		}
+		for _, sel := range set {
+			if strings.TrimSpace(sel.CSS) == "" {
+				return nil, fmt.Errorf("%s: empty css", key)
+			}
+			if _, err := regexp.Compile(sel.Text); err != nil {
+				return nil, fmt.Errorf("%s: text %q: %w", key, sel.Text, err)
+			}
+		}
+	}
+	for key, res := range f.Patterns {
+		if len(res) == 0 {
+			return nil, fmt.Errorf("patterns.%s: no patterns", key)
+		}
+		for _, re := range res {
+			if _, err := regexp.Compile(re); err != nil {
+				return nil, fmt.Errorf("patterns.%s: %q: %w", key, re, err)
+			}
+		}
+	}
+	return &f, nil
+}
+
+func flatten(f *file) map[string]Set {
+	out := map[string]Set{}
+	for group, sets := range f.Groups {
+		for name, set := range sets {
+			out[group+"."+name] = set
+		}
+	}
+	return out
+}
+
+// apply replaces the keys f sets. Keys the built-in file doesn't know are
+// rejected, so a typo can't silently leave the old selector in place.
+func (r *Registry) apply(src string, f *file) error {
+	sets := flatten(f)
+	for key := range sets {
+		if _, ok := r.sets[key]; !ok {
+			return fmt.Errorf("%s: unknown selector %q", src, key)
+		}
+	}
+	if err := checkHeuristics(f.Heuristics, r.sets); err != nil {
+		return fmt.Errorf("%s: %w", src, err)
+	}
+	if err := r.checkPatterns(f.Patterns); err != nil {
+		return fmt.Errorf("%s: %w", src, err)
+	}
+	for key, set := range sets {
+		r.sets[key] = set
+	}
+	for key, res := range f.Patterns {
+		r.patterns[key] = res
+	}
+	for key, h := range f.Heuristics {
+		r.heuristics[key] = h
+	}
+	r.Version = max(r.Version, f.Version)
+	r.Sources = append(r.Sources, src)
+	return nil
+}
+
+// extend adds the alternatives and patterns f lists after those of the same
+// keys, and takes over its heuristics, which replace the ones in effect.
+func (r *Registry) extend(src string, f *file) error {
+	sets := flatten(f)
+	for key := range sets {
+		if _, ok := r.sets[key]; !ok {
+			return fmt.Errorf("%s: unknown selector %q", src, key)
+		}
+	}
+	if err := checkHeuristics(f.Heuristics, r.sets); err != nil {
+		return fmt.Errorf("%s: %w", src, err)
+	}
+	if err := r.checkPatterns(f.Patterns); err != nil {
+		return fmt.Errorf("%s: %w", src, err)
+	}
+	for key, set := range sets {
+		r.sets[key] = append(slices.Clip(r.sets[key]), set...)
+	}
+	for key, res := range f.Patterns {
+		r.patterns[key] = append(slices.Clip(r.patterns[key]), res...)
+	}
+	for key, h := range f.Heuristics {
+		r.heuristics[key] = h
+	}
+	r.Sources = append(r.Sources, src)
+	return nil
+}
+
+// checkPatterns rejects patterns the built-in file doesn't know.
+func (r *Registry) checkPatterns(patterns map[string][]string) error {
+	for key := range patterns {
+		if _, ok := r.patterns[key]; !ok {
+			return fmt.Errorf("unknown pattern %q", key)
+		}
+	}
+	return nil
+}
+
+// Locales lists the values linkedin.locale accepts.
+func Locales() []string {
+	out := []string{"en"}
+	entries, _ := locales.ReadDir("locales")
+	for _, e := range entries {
+		out = append(out, strings.TrimSuffix(e.Name(), ".yaml"))
+	}
+	return out
+}
+
+// Get returns the lookup for key. Keys come from the built-in file, so an
+// unknown one is a programming error.
+func (r *Registry) Get(key string) Lookup {
+	set, ok := r.sets[key]
+	if !ok {
+		panic("selectors: unknown key " + key)
+	}
+	return Lookup{Set: set, key: key, r: r}
+}
+
+// Pattern returns the text pattern of key. Like Get, it panics on a key the
+// built-in file doesn't have.
+func (r *Registry) Pattern(key string) Pattern {
+	p, ok := r.compiled[key]
+	if !ok {
+		panic("selectors: unknown pattern " + key)
+	}
+	return p
+}
+
+// Len returns the number of keys.
+func (r *Registry) Len() int { return len(r.sets) }
+
+// YAML renders the selectors in effect in the file layout, ready to be
+// edited into an override file.
+func (r *Registry) YAML() ([]byte, error) {
+	f := file{Version: r.Version, Heuristics: r.heuristics, Patterns: r.patterns, Groups: map[string]map[string]Set{}}
+	for key, set := range r.sets {
+		group, name, _ := strings.Cut(key, ".")
+		if f.Groups[group] == nil {
+			f.Groups[group] = map[string]Set{}
+		}
+		f.Groups[group][name] = set
+	}
+	return yaml.Marshal(f)
+}
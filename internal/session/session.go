@@ -0,0 +1,88 @@
+package session
+
+import (
+	"context"
+	"math/rand"
+
+	"github.com/example/linkedbot/internal/browser"
+	"github.com/example/linkedbot/internal/config"
+	"github.com/example/linkedbot/internal/driver"
+	"github.com/example/linkedbot/internal/logging"
+	"github.com/example/linkedbot/internal/retry"
+	"github.com/example/linkedbot/internal/stealth"
+)
+
+// Session wraps an outreach run in the browsing a person does around it:
+// before the first profile it opens the feed, scrolls a bit and may check
+// the notifications, and between profiles it now and then goes back to the
+// feed. Every step happens with a probability from the session config, so no
+// two runs open the same way.
+type Session struct {
+	cfg *config.Config
+	rt  *retry.Retrier
+	hu  *stealth.Human
+	log *logging.Logger
+}
+
+func New(cfg *config.Config) *Session {
+	return &Session{cfg: cfg, rt: retry.New(cfg), hu: stealth.New(cfg), log: logging.New(cfg.Logging.Level).With("module", "session")}
+}
+
+// Open runs the start of a session on p: the feed with
+// session.feed_probability, then the notifications with
+// session.notifications_probability. A page that fails to load is logged and
+// skipped; it never stops the outreach.
+func (s *Session) Open(ctx context.Context, p driver.Page) {
+	if !s.cfg.Session.Enabled {
+		return
+	}
+	if chance(s.cfg.Session.FeedProbability) {
+		s.feed(ctx, p)
+	}
+	if ctx.Err() == nil && chance(s.cfg.Session.NotificationsProbability) {
+		s.notifications(ctx, p)
+	}
+}
+
+// Between goes back to the feed for a moment with
+// session.feed_return_probability. Call it between two profiles.
+func (s *Session) Between(ctx context.Context, p driver.Page) {
+	if !s.cfg.Session.Enabled || ctx.Err() != nil || !chance(s.cfg.Session.FeedReturnProbability) {
+		return
+	}
+	s.feed(ctx, p)
+}
+
+// feed opens the feed and scrolls it one to session.feed_scrolls times,
+// pausing on a post now and then.
+func (s *Session) feed(ctx context.Context, p driver.Page) {
+	s.log.Debug("visiting the feed")
+	if err := browser.Navigate(ctx, s.rt, p, s.cfg.LinkedIn.BaseURL+"feed/"); err != nil {
+		s.log.Debug("feed visit failed", "err", err)
+		return
+	}
+	s.hu.WakeUpMovement(p)
+	for i := 1 + rand.Intn(s.cfg.Session.FeedScrolls); i > 0 && ctx.Err() == nil; i-- {
+		s.hu.ScrollHumanLike(p)
+		if rand.Float64() < 0.3 {
+			s.hu.MouseIdleMovement(p)
+			stealth.SleepGaussian(4000, 1500)
+		}
+		stealth.ThinkTime()
+	}
+}
+
+// notifications opens the notifications and reads them.
+func (s *Session) notifications(ctx context.Context, p driver.Page) {
+	s.log.Debug("checking notifications")
+	if err := browser.Navigate(ctx, s.rt, p, s.cfg.LinkedIn.BaseURL+"notifications/"); err != nil {
+		s.log.Debug("notifications visit failed", "err", err)
+		return
+	}
+	s.hu.WakeUpMovement(p)
+	stealth.ThinkTime()
+	s.hu.ScrollHumanLike(p)
+	stealth.SleepGaussian(3000, 1000)
+}
+
+func chance(p float64) bool { return p > 0 && rand.Float64() < p }
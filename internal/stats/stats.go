@@ -0,0 +1,270 @@
+package stats
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/example/linkedbot/internal/models"
+	"github.com/example/linkedbot/internal/store"
+	"github.com/example/linkedbot/internal/templates"
+)
+
+// Period holds the counts for one day or week. Acceptance and reply rates
+// are per cohort: of the invites sent (profiles first messaged) in the
+// period, how many were accepted (replied) by now.
+type Period struct {
+	Start          time.Time `json:"start"`
+	InvitesSent    int       `json:"invites_sent"`
+	Accepted       int       `json:"accepted"`
+	AcceptanceRate float64   `json:"acceptance_rate"`
+	MessagesSent   int       `json:"messages_sent"`
+	Messaged       int       `json:"messaged"`
+	Replied        int       `json:"replied"`
+	ReplyRate      float64   `json:"reply_rate"`
+}
+
+// Bucket is one bar of the time-to-accept distribution.
+type Bucket struct {
+	Label string `json:"label"`
+	Count int    `json:"count"`
+}
+
+// Distribution summarises how long accepted invites took to be accepted.
+// The acceptance time is when it was detected, so values are upper bounds.
+type Distribution struct {
+	Count       int      `json:"count"`
+	MedianHours float64  `json:"median_hours"`
+	P90Hours    float64  `json:"p90_hours"`
+	MaxHours    float64  `json:"max_hours"`
+	Buckets     []Bucket `json:"buckets"`
+}
+
+// Lead is a profile whose reply was classified as interested.
+type Lead struct {
+	Name  string    `json:"name"`
+	URL   string    `json:"profile_url"`
+	Reply string    `json:"reply"`
+	Time  time.Time `json:"time"`
+}
+
+type Report struct {
+	Since        time.Time    `json:"since"`
+	Totals       Period       `json:"totals"`
+	Engaged      Period       `json:"engaged"` // invites after engage liked or commented on a post
+	NotEngaged   Period       `json:"not_engaged"`
+	Daily        []Period     `json:"daily"`
+	Weekly       []Period     `json:"weekly"`
+	TimeToAccept Distribution `json:"time_to_accept"`
+	// Replies counts the classified replies by label; Leads are the
+	// interested ones, newest first
+	Replies map[string]int `json:"replies"`
+	Leads   []Lead         `json:"interested_leads"`
+}
+
+var bucketBounds = []struct {
+	label string
+	max   time.Duration
+}{
+	{"<1d", 24 * time.Hour},
+	{"1-3d", 3 * 24 * time.Hour},
+	{"3-7d", 7 * 24 * time.Hour},
+	{"7-14d", 14 * 24 * time.Hour},
+	{">=14d", 1<<63 - 1},
+}
+
+// Build computes the report from stored profiles and the send times of
+// follow-up messages. Only activity at or after since is counted.
+func Build(profiles []models.Profile, messageTimes []time.Time, since time.Time) Report {
+	r := Report{Since: since, Totals: Period{Start: since}}
+	daily := map[time.Time]*Period{}
+	weekly := map[time.Time]*Period{}
+	periods := func(t time.Time) []*Period {
+		return []*Period{&r.Totals, period(daily, dayStart(t)), period(weekly, weekStart(t))}
+	}
+
+	var accepts []time.Duration
+	for _, p := range profiles {
+		accepted := p.ConnectionCheckedAt != nil
+		if p.ConnectionSentAt != nil && !p.ConnectionSentAt.Before(since) {
+			for _, per := range periods(*p.ConnectionSentAt) {
+				per.InvitesSent++
+				if accepted {
+					per.Accepted++
+				}
+			}
+			cohort := &r.NotEngaged
+			if p.EngagedAt != nil && p.EngagedAt.Before(*p.ConnectionSentAt) {
+				cohort = &r.Engaged
+			}
+			cohort.InvitesSent++
+			if accepted {
+				cohort.Accepted++
+				accepts = append(accepts, p.ConnectionCheckedAt.Sub(*p.ConnectionSentAt))
+			}
+		}
+		if p.MessageSentAt != nil && !p.MessageSentAt.Before(since) {
+			for _, per := range periods(*p.MessageSentAt) {
+				per.Messaged++
+				if p.RepliedAt != nil {
+					per.Replied++
+				}
+			}
+		}
+	}
+	for _, t := range messageTimes {
+		if t.Before(since) {
+			continue
+		}
+		for _, per := range periods(t) {
+			per.MessagesSent++
+		}
+	}
+
+	r.Daily = sorted(daily)
+	r.Weekly = sorted(weekly)
+	r.Totals.rates()
+	r.Engaged.rates()
+	r.NotEngaged.rates()
+	r.TimeToAccept = distribution(accepts)
+	return r
+}
+
+func period(m map[time.Time]*Period, start time.Time) *Period {
+	if p, ok := m[start]; ok {
+		return p
+	}
+	p := &Period{Start: start}
+	m[start] = p
+	return p
+}
+
+func sorted(m map[time.Time]*Period) []Period {
+	out := make([]Period, 0, len(m))
+	for _, p := range m {
+		p.rates()
+		out = append(out, *p)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Start.Before(out[j].Start) })
+	return out
+}
+
+func (p *Period) rates() {
+	if p.InvitesSent > 0 {
+		p.AcceptanceRate = float64(p.Accepted) / float64(p.InvitesSent)
+	}
+	if p.Messaged > 0 {
+		p.ReplyRate = float64(p.Replied) / float64(p.Messaged)
+	}
+}
+
+func dayStart(t time.Time) time.Time {
+	t = t.Local()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+}
+
+// weekStart returns the Monday of t's week.
+func weekStart(t time.Time) time.Time {
+	d := dayStart(t)
+	return d.AddDate(0, 0, -((int(d.Weekday()) + 6) % 7))
+}
+
+func distribution(ds []time.Duration) Distribution {
+	dist := Distribution{Count: len(ds)}
+	for _, b := range bucketBounds {
+		dist.Buckets = append(dist.Buckets, Bucket{Label: b.label})
+	}
+	if len(ds) == 0 {
+		return dist
+	}
+	sort.Slice(ds, func(i, j int) bool { return ds[i] < ds[j] })
+	for _, d := range ds {
+		for i, b := range bucketBounds {
+			if d < b.max {
+				dist.Buckets[i].Count++
+				break
+			}
+		}
+	}
+	dist.MedianHours = ds[len(ds)/2].Hours()
+	dist.P90Hours = ds[(len(ds)*9)/10].Hours()
+	dist.MaxHours = ds[len(ds)-1].Hours()
+	return dist
+}
+
+// AddReplies tallies the classified replies into r.
+func AddReplies(r *Report, replies []store.Reply) {
+	r.Replies = map[string]int{}
+	for _, rp := range replies {
+		r.Replies[rp.Label]++
+		if rp.Label == "interested" {
+			r.Leads = append(r.Leads, Lead{Name: rp.Name, URL: rp.ProfileURL, Reply: rp.Content, Time: rp.CreatedAt})
+		}
+	}
+}
+
+// WriteText prints the report as aligned tables.
+func WriteText(w io.Writer, r Report) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "Since %s\n\n", r.Since.Format("2006-01-02"))
+	writePeriods(tw, "Daily", "2006-01-02", r.Daily)
+	writePeriods(tw, "Weekly (from Monday)", "2006-01-02", r.Weekly)
+	writePeriods(tw, "Total", "", []Period{r.Totals})
+	if r.Engaged.InvitesSent > 0 {
+		fmt.Fprintln(tw, "Acceptance by engagement before the invite")
+		fmt.Fprintln(tw, "cohort\tinvites\taccepted\taccept rate")
+		for _, c := range []struct {
+			label string
+			p     Period
+		}{{"engaged", r.Engaged}, {"not engaged", r.NotEngaged}} {
+			fmt.Fprintf(tw, "%s\t%d\t%d\t%.0f%%\n", c.label, c.p.InvitesSent, c.p.Accepted, 100*c.p.AcceptanceRate)
+		}
+		fmt.Fprintln(tw)
+	}
+
+	if len(r.Replies) > 0 {
+		fmt.Fprintln(tw, "Replies by class")
+		labels := make([]string, 0, len(r.Replies))
+		for l := range r.Replies {
+			labels = append(labels, l)
+		}
+		sort.Strings(labels)
+		for _, l := range labels {
+			fmt.Fprintf(tw, "%s\t%d\n", l, r.Replies[l])
+		}
+		fmt.Fprintln(tw)
+	}
+	if len(r.Leads) > 0 {
+		fmt.Fprintf(tw, "Interested leads (%d)\n", len(r.Leads))
+		for _, l := range r.Leads {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", l.Time.Local().Format("2006-01-02"), l.Name, l.URL, templates.Truncate(l.Reply, 60))
+		}
+		fmt.Fprintln(tw)
+	}
+
+	d := r.TimeToAccept
+	fmt.Fprintf(tw, "Time to accept (%d accepted)\n", d.Count)
+	if d.Count > 0 {
+		fmt.Fprintf(tw, "median\t%.1fh\tp90\t%.1fh\tmax\t%.1fh\n", d.MedianHours, d.P90Hours, d.MaxHours)
+		for _, b := range d.Buckets {
+			fmt.Fprintf(tw, "%s\t%d\n", b.Label, b.Count)
+		}
+	}
+	return tw.Flush()
+}
+
+func writePeriods(w io.Writer, title, layout string, ps []Period) {
+	fmt.Fprintln(w, title)
+	fmt.Fprintln(w, "period\tinvites\taccepted\taccept rate\tmessages\treplied\treply rate")
+	for _, p := range ps {
+		label := "all"
+		if layout != "" {
+			label = p.Start.Format(layout)
+		}
+		fmt.Fprintf(w, "%s\t%d\t%d\t%.0f%%\t%d\t%d/%d\t%.0f%%\n", label, p.InvitesSent, p.Accepted, 100*p.AcceptanceRate,
+			p.MessagesSent, p.Replied, p.Messaged, 100*p.ReplyRate)
+	}
+	fmt.Fprintln(w)
+}
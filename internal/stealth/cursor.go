@@ -0,0 +1,77 @@
+package stealth
+
+import (
+	"sync"
+
+	"github.com/example/linkedbot/internal/driver"
+)
+
+// cursor is where the mouse was last moved to on a page. Chrome doesn't
+// report it, so every movement records its end point and the next one starts
+// there instead of jumping.
+type cursor struct {
+	x, y int
+}
+
+// Cursors are kept by page ID, since p.Timeout and friends return copies of
+// the same page.
+var (
+	cursorsMu sync.Mutex
+	cursors   = map[string]cursor{}
+)
+
+// cursorAt returns the last cursor position on p. A page the mouse hasn't
+// moved on yet starts at the viewport center.
+func cursorAt(p driver.Page) (x, y int) {
+	cursorsMu.Lock()
+	c, ok := cursors[p.ID()]
+	cursorsMu.Unlock()
+	if ok {
+		return c.x, c.y
+	}
+	w, h := viewport(p)
+	return w / 2, h / 2
+}
+
+// hasCursor reports whether the mouse has moved on p.
+func hasCursor(p driver.Page) bool {
+	cursorsMu.Lock()
+	defer cursorsMu.Unlock()
+	_, ok := cursors[p.ID()]
+	return ok
+}
+
+func setCursor(p driver.Page, x, y int) {
+	cursorsMu.Lock()
+	cursors[p.ID()] = cursor{x: x, y: y}
+	cursorsMu.Unlock()
+}
+
+// Forget drops the cursor position of p. The browser calls it when a page
+// is closed.
+func Forget(p driver.Page) {
+	cursorsMu.Lock()
+	delete(cursors, p.ID())
+	cursorsMu.Unlock()
+}
+
+// moveTo dispatches a single mouse move and records it.
+func moveTo(p driver.Page, x, y int) error {
+	setCursor(p, x, y)
+	return p.MouseMove(float64(x), float64(y))
+}
+
+// viewport returns the inner window size of p, or 1400x900 when it can't be
+// read.
+func viewport(p driver.Page) (width, height int) {
+	width, height = 1400, 900
+	if dims, err := p.Eval(`() => ({width: window.innerWidth, height: window.innerHeight})`); err == nil {
+		if w := dims.Get("width").Int(); w > 0 {
+			width = w
+		}
+		if h := dims.Get("height").Int(); h > 0 {
+			height = h
+		}
+	}
+	return width, height
+}
@@ -0,0 +1,132 @@
+package stealth
+
+import "github.com/example/linkedbot/internal/driver"
+
+// keyDef is a physical key as a US keyboard reports it.
+type keyDef struct {
+	key     string // KeyboardEvent.key
+	code    string // KeyboardEvent.code
+	keyCode int    // Windows virtual key code
+	shift   bool
+}
+
+var (
+	shiftKey     = keyDef{key: "Shift", code: "ShiftLeft", keyCode: 16}
+	backspaceKey = keyDef{key: "Backspace", code: "Backspace", keyCode: 8}
+)
+
+// symbolKeys are the punctuation keys, unshifted and shifted.
+var symbolKeys = []struct {
+	plain, shifted rune
+	code           string
+	keyCode        int
+}{
+	{'`', '~', "Backquote", 192},
+	{'-', '_', "Minus", 189},
+	{'=', '+', "Equal", 187},
+	{'[', '{', "BracketLeft", 219},
+	{']', '}', "BracketRight", 221},
+	{'\\', '|', "Backslash", 220},
+	{';', ':', "Semicolon", 186},
+	{'\'', '"', "Quote", 222},
+	{',', '<', "Comma", 188},
+	{'.', '>', "Period", 190},
+	{'/', '?', "Slash", 191},
+}
+
+// shiftedDigits are the symbols on the digit row, from 1 to 0.
+const shiftedDigits = "!@#$%^&*()"
+
+// keyFor returns the key that types r. ok is false for characters a US
+// keyboard has no key for (accents, emoji), which are inserted as text.
+func keyFor(r rune) (k keyDef, ok bool) {
+	switch {
+	case r >= 'a' && r <= 'z':
+		return keyDef{key: string(r), code: "Key" + string(r-'a'+'A'), keyCode: int(r - 'a' + 'A')}, true
+	case r >= 'A' && r <= 'Z':
+		return keyDef{key: string(r), code: "Key" + string(r), keyCode: int(r), shift: true}, true
+	case r >= '0' && r <= '9':
+		return keyDef{key: string(r), code: "Digit" + string(r), keyCode: int(r)}, true
+	case r == ' ':
+		return keyDef{key: " ", code: "Space", keyCode: 32}, true
+	case r == '\n':
+		// Shift+Enter is a line break; a plain Enter may send a message
+		return keyDef{key: "Enter", code: "Enter", keyCode: 13, shift: true}, true
+	}
+	for i, d := range shiftedDigits {
+		if r == d {
+			digit := rune('0' + (i+1)%10)
+			return keyDef{key: string(r), code: "Digit" + string(digit), keyCode: int(digit), shift: true}, true
+		}
+	}
+	for _, s := range symbolKeys {
+		if r == s.plain {
+			return keyDef{key: string(r), code: s.code, keyCode: s.keyCode}, true
+		}
+		if r == s.shifted {
+			return keyDef{key: string(r), code: s.code, keyCode: s.keyCode, shift: true}, true
+		}
+	}
+	return keyDef{}, false
+}
+
+// typeRune types r into the focused element: with keydown/keyup events when
+// typing_mode is keys, otherwise through el.Input.
+func (h *Human) typeRune(el driver.Element, r rune) error {
+	if !h.keys {
+		return el.Input(string(r))
+	}
+	p := el.Page()
+	k, ok := keyFor(r)
+	if !ok {
+		return p.InsertText(string(r))
+	}
+	if k.shift {
+		if err := keyEvent(p, driver.RawKeyDown, shiftKey, driver.ModShift, ""); err != nil {
+			return err
+		}
+		SleepGaussian(60, 20)
+	}
+	if err := pressKey(p, k); err != nil {
+		return err
+	}
+	if k.shift {
+		SleepGaussian(40, 15)
+		return keyEvent(p, driver.KeyUp, shiftKey, 0, "")
+	}
+	return nil
+}
+
+// backspace deletes the character before the caret.
+func backspace(el driver.Element) error {
+	return pressKey(el.Page(), backspaceKey)
+}
+
+// pressKey presses and releases k, holding it for a dwell time of about
+// 90ms. Keys with a character insert it on keydown, as a real keyboard does.
+func pressKey(p driver.Page, k keyDef) error {
+	mods := 0
+	if k.shift {
+		mods = driver.ModShift
+	}
+	text := ""
+	switch {
+	case k.code == "Enter":
+		text = "\r"
+	case len([]rune(k.key)) == 1:
+		text = k.key
+	}
+	down := driver.KeyDown
+	if text == "" {
+		down = driver.RawKeyDown
+	}
+	if err := keyEvent(p, down, k, mods, text); err != nil {
+		return err
+	}
+	SleepGaussian(90, 25)
+	return keyEvent(p, driver.KeyUp, k, mods, "")
+}
+
+func keyEvent(p driver.Page, typ driver.KeyEventType, k keyDef, mods int, text string) error {
+	return p.Key(driver.KeyEvent{Type: typ, Key: k.key, Code: k.code, KeyCode: k.keyCode, Modifiers: mods, Text: text})
+}
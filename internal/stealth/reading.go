@@ -0,0 +1,51 @@
+package stealth
+
+import (
+	"math/rand"
+	"time"
+
+	"github.com/example/linkedbot/internal/driver"
+)
+
+// skimShare is the part of a page's text people actually read; the rest
+// they skim past.
+const skimShare = 0.25
+
+// wordsPerScreen stands in for the text of a page whose text can't be read.
+const wordsPerScreen = 120
+
+// ReadingTime estimates how long a person would stay on p: the words of its
+// main content at stealth.reading_wpm, of which they read a quarter, with
+// +-20% noise, kept between read_min_seconds and read_max_seconds. A short
+// profile gets a glance and a long one a proper look.
+func (h *Human) ReadingTime(p driver.Page) time.Duration {
+	secs := float64(pageWords(p)) * skimShare / float64(h.wpm) * 60
+	secs *= 1 + rand.NormFloat64()*0.2
+	d := time.Duration(secs * float64(time.Second))
+	return min(max(d, h.readMin), h.readMax)
+}
+
+// Read pauses on p for its ReadingTime.
+func (h *Human) Read(p driver.Page) {
+	time.Sleep(h.ReadingTime(p))
+}
+
+// pageWords counts the words of the page's main content, or estimates them
+// from its scroll height.
+func pageWords(p driver.Page) int {
+	res, err := p.Eval(`() => {
+		const root = document.querySelector('main') || document.body;
+		const text = root ? root.innerText || '' : '';
+		return {
+			words: text.split(/\s+/).filter(Boolean).length,
+			screens: document.documentElement.scrollHeight / Math.max(window.innerHeight, 1),
+		};
+	}`)
+	if err != nil {
+		return wordsPerScreen
+	}
+	if n := res.Get("words").Int(); n > 0 {
+		return n
+	}
+	return max(1, int(res.Get("screens").Num()*wordsPerScreen))
+}
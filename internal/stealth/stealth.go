@@ -3,12 +3,46 @@ package stealth
 import (
 	"math"
 	"math/rand"
+	"strconv"
 	"time"
 
-	"github.com/go-rod/rod"
-	"github.com/go-rod/rod/lib/proto"
+	"github.com/example/linkedbot/internal/config"
+	"github.com/example/linkedbot/internal/driver"
+	"github.com/example/linkedbot/internal/selectors"
 )
 
+// Human performs page interactions the way the stealth.* settings ask: each
+// enable_* flag switches a behavior to its plain form (a direct click, no
+// typos, no idle wandering, no breaks) and typo_rate, break_probability and
+// scroll_intensity tune the ones left on.
+type Human struct {
+	mouse, scroll, typos, hover, breaks bool
+	typoRate, breakProb, scrollScale    float64
+	// keys types with key events instead of el.Input (typing_mode: keys)
+	keys bool
+	// wpm, readMin and readMax shape ReadingTime
+	wpm              int
+	readMin, readMax time.Duration
+}
+
+func New(cfg *config.Config) *Human {
+	st := cfg.Stealth
+	return &Human{
+		mouse:       st.EnableHumanMouse,
+		scroll:      st.EnableRandomScroll,
+		typos:       st.EnableTypeTypos,
+		hover:       st.EnableHoverWander,
+		breaks:      st.EnableBreaks,
+		typoRate:    st.TypoRate,
+		breakProb:   st.BreakProbability,
+		scrollScale: st.ScrollIntensity,
+		keys:        st.TypingMode == "keys",
+		wpm:         st.ReadingWPM,
+		readMin:     time.Duration(st.ReadMinSeconds) * time.Second,
+		readMax:     time.Duration(st.ReadMaxSeconds) * time.Second,
+	}
+}
+
 // SleepRandom sleeps for a random duration between min and max milliseconds
 func SleepRandom(minMs, maxMs int) {
 	if maxMs < minMs {
@@ -43,9 +77,15 @@ func SleepGaussian(meanMs, stdDevMs int) {
 
 func ThinkTime() { SleepGaussian(1400, 600) } // Mean 1.4s, StdDev 600ms
 
-// MoveMouseHumanLike moves the mouse along a bezier curve with variable speed,
-// natural overshoot, and micro-corrections
-func MoveMouseHumanLike(p *rod.Page, fromX, fromY, toX, toY int) error {
+// MoveMouseHumanLike moves the mouse from where it last was on p along a
+// bezier curve with variable speed, natural overshoot, and micro-corrections.
+// Without enable_human_mouse it jumps straight to the target.
+func (h *Human) MoveMouseHumanLike(p driver.Page, toX, toY int) error {
+	if !h.mouse {
+		return moveTo(p, toX, toY)
+	}
+	fromX, fromY := cursorAt(p)
+
 	// Calculate distance for speed variance
 	dist := math.Sqrt(math.Pow(float64(toX-fromX), 2) + math.Pow(float64(toY-fromY), 2))
 
@@ -91,11 +131,7 @@ func MoveMouseHumanLike(p *rod.Page, fromX, fromY, toX, toY int) error {
 		x += rand.Intn(3) - 1
 		y += rand.Intn(3) - 1
 
-		_ = proto.InputDispatchMouseEvent{
-			Type: proto.InputDispatchMouseEventTypeMouseMoved,
-			X:    float64(x),
-			Y:    float64(y),
-		}.Call(p)
+		_ = moveTo(p, x, y)
 
 		// Variable speed - faster in middle, slower at start/end
 		delay := 8 + rand.Intn(10)
@@ -110,11 +146,7 @@ func MoveMouseHumanLike(p *rod.Page, fromX, fromY, toX, toY int) error {
 		for j := 0; j < 2; j++ {
 			dx := rand.Intn(3) - 1
 			dy := rand.Intn(3) - 1
-			_ = proto.InputDispatchMouseEvent{
-				Type: proto.InputDispatchMouseEventTypeMouseMoved,
-				X:    float64(toX + dx),
-				Y:    float64(toY + dy),
-			}.Call(p)
+			_ = moveTo(p, toX+dx, toY+dy)
 			time.Sleep(time.Duration(20+rand.Intn(30)) * time.Millisecond)
 		}
 	}
@@ -143,147 +175,91 @@ func bezier(p0, p1, p2, t float64) float64 {
 }
 
 // MouseIdleMovement simulates natural mouse movements when not clicking
-// Humans don't keep mouse perfectly still
-func MouseIdleMovement(p *rod.Page) error {
-	// Always do some movement to make it more visible (changed from 30% to 100%)
-	if true { // Always execute for visibility
-		// Get window dimensions
-		width := 1400 // Default viewport width
-		height := 900 // Default viewport height
-
-		if dims, err := p.Eval(`() => ({width: window.innerWidth, height: window.innerHeight})`); err == nil {
-			if w := dims.Value.Get("width").Int(); w > 0 {
-				width = w
-			}
-			if h := dims.Value.Get("height").Int(); h > 0 {
-				height = h
-			}
-		}
+// Humans don't keep mouse perfectly still. It is part of enable_hover_wander.
+func (h *Human) MouseIdleMovement(p driver.Page) error {
+	if h.hover {
+		width, height := viewport(p)
 
 		// Random point in safe area (not edges)
 		margin := 100
 		x := margin + rand.Intn(width-2*margin)
 		y := margin + rand.Intn(height-2*margin)
 
-		// Get current mouse position (estimate from center)
-		fromX := width / 2
-		fromY := height / 2
-
 		// First move to a random point with visible bezier movement
-		MoveMouseHumanLike(p, fromX, fromY, x, y)
+		h.MoveMouseHumanLike(p, x, y)
 		SleepRandom(200, 500)
 
 		// Small wandering movement (increased count for more visibility)
 		for i := 0; i < 3+rand.Intn(4); i++ {
 			dx := rand.Intn(40) - 20
 			dy := rand.Intn(40) - 20
-			_ = proto.InputDispatchMouseEvent{
-				Type: proto.InputDispatchMouseEventTypeMouseMoved,
-				X:    float64(x + dx),
-				Y:    float64(y + dy),
-			}.Call(p)
+			_ = moveTo(p, x+dx, y+dy)
 			SleepRandom(100, 400)
 		}
 	}
 	return nil
 }
 
-// ClickHumanLike performs a scroll-into-view and a click with realistic mouse movement
-func ClickHumanLike(p *rod.Page, el *rod.Element) error {
+// ClickHumanLike performs a scroll-into-view and a click with realistic mouse
+// movement, or a plain click without enable_human_mouse
+func (h *Human) ClickHumanLike(p driver.Page, el driver.Element) error {
 	_ = el.ScrollIntoView()
 	SleepGaussian(300, 150)
-
-	// Get element position
-	shape, err := el.Shape()
-	if err != nil || len(shape.Quads) == 0 {
-		return el.Click("left", 1) // Fallback to simple click
-	}
-
-	// Use first quad to get bounding box
-	quad := shape.Quads[0]
-	minX, maxX := quad[0], quad[0]
-	minY, maxY := quad[1], quad[1]
-	for i := 0; i < len(quad); i += 2 {
-		if quad[i] < minX {
-			minX = quad[i]
-		}
-		if quad[i] > maxX {
-			maxX = quad[i]
-		}
-		if quad[i+1] < minY {
-			minY = quad[i+1]
-		}
-		if quad[i+1] > maxY {
-			maxY = quad[i+1]
+	box, err := el.Box()
+	if !h.mouse {
+		// A plain click lands on the center, which is where the mouse stays
+		if err == nil {
+			x, y := box.Center()
+			setCursor(p, int(x), int(y))
 		}
+		return el.Click()
+	}
+	if err != nil {
+		return el.Click() // Fallback to simple click
 	}
-
-	width := maxX - minX
-	height := maxY - minY
 
 	// Random point within element (more natural than center)
-	targetX := int(minX + width*0.3 + rand.Float64()*width*0.4)
-	targetY := int(minY + height*0.3 + rand.Float64()*height*0.4)
+	targetX := int(box.X + box.Width*0.3 + rand.Float64()*box.Width*0.4)
+	targetY := int(box.Y + box.Height*0.3 + rand.Float64()*box.Height*0.4)
 
-	// Get current viewport dimensions
-	fromX := 700 // Default center
-	fromY := 450
-
-	if dims, err := p.Eval(`() => ({width: window.innerWidth, height: window.innerHeight})`); err == nil {
-		if w := dims.Value.Get("width").Int(); w > 0 {
-			fromX = w / 2
-		}
-		if h := dims.Value.Get("height").Int(); h > 0 {
-			fromY = h / 2
-		}
-	}
-
-	// Move mouse to element
-	_ = MoveMouseHumanLike(p, fromX, fromY, targetX, targetY)
+	// Move mouse to element, from wherever the last movement left it
+	_ = h.MoveMouseHumanLike(p, targetX, targetY)
 
 	SleepRandom(50, 150)
 
 	// Mouse down
-	_ = proto.InputDispatchMouseEvent{
-		Type:       proto.InputDispatchMouseEventTypeMousePressed,
-		X:          float64(targetX),
-		Y:          float64(targetY),
-		Button:     proto.InputMouseButtonLeft,
-		ClickCount: 1,
-	}.Call(p)
+	_ = p.MouseDown(float64(targetX), float64(targetY))
 
 	// Human reaction time for click
 	SleepRandom(30, 90)
 
 	// Mouse up
-	_ = proto.InputDispatchMouseEvent{
-		Type:       proto.InputDispatchMouseEventTypeMouseReleased,
-		X:          float64(targetX),
-		Y:          float64(targetY),
-		Button:     proto.InputMouseButtonLeft,
-		ClickCount: 1,
-	}.Call(p)
+	_ = p.MouseUp(float64(targetX), float64(targetY))
 
 	return nil
 }
 
-// TypeHumanLike simulates realistic typing with variable delays, occasional typos, and corrections
-func TypeHumanLike(el *rod.Element, text string) error {
+// TypeHumanLike simulates realistic typing with variable delays, occasional
+// typos (at typo_rate, with enable_type_typos), and corrections. With
+// typing_mode keys every character is a key press with real key codes.
+func (h *Human) TypeHumanLike(el driver.Element, text string) error {
+	if h.keys {
+		if err := el.Focus(); err != nil {
+			return err
+		}
+	}
 	for i, r := range text {
-		ch := string(r)
-
-		// 2% chance of typo (then correction)
-		if rand.Float64() < 0.02 && i > 3 {
-			wrongChar := randomNearbyRune(r)
-			_ = el.Input(wrongChar)
+		// Typo, then correction
+		if h.typos && rand.Float64() < h.typoRate && i > 3 {
+			_ = h.typeRune(el, randomNearbyRune(r))
 			SleepRandom(80, 180)
 
 			// Realize mistake and backspace
-			_ = el.Input("\b")
+			_ = backspace(el)
 			SleepRandom(100, 250)
 		}
 
-		if err := el.Input(ch); err != nil {
+		if err := h.typeRune(el, r); err != nil {
 			return err
 		}
 
@@ -308,7 +284,7 @@ func TypeHumanLike(el *rod.Element, text string) error {
 	return nil
 }
 
-func randomNearbyRune(r rune) string {
+func randomNearbyRune(r rune) rune {
 	// Keyboard-proximity based typos
 	nearby := map[rune][]rune{
 		'a': {'s', 'q', 'w', 'z'},
@@ -320,22 +296,30 @@ func randomNearbyRune(r rune) string {
 	}
 
 	if opts, ok := nearby[r]; ok && len(opts) > 0 {
-		return string(opts[rand.Intn(len(opts))])
+		return opts[rand.Intn(len(opts))]
 	}
 
 	// Generic fallback
 	opts := []rune{'a', 'e', 'i', 'o', 'u', 's', 'n', 't', 'r', 'l'}
-	return string(opts[rand.Intn(len(opts))])
+	return opts[rand.Intn(len(opts))]
 }
 
-// ScrollHumanLike scrolls with realistic human patterns
-func ScrollHumanLike(p *rod.Page) {
+// ScrollHumanLike scrolls with realistic human patterns, scroll_intensity
+// scaling how many scrolls and how far. Without enable_random_scroll it makes
+// one plain scroll, enough to trigger lazy loading.
+func (h *Human) ScrollHumanLike(p driver.Page) {
+	if !h.scroll {
+		_, _ = p.Eval(`(dy) => window.scrollBy({top: dy, behavior: 'smooth'})`, 600)
+		SleepRandom(300, 600)
+		return
+	}
+
 	// Variable number of scroll actions
-	steps := 3 + rand.Intn(5)
+	steps := max(1, int(float64(3+rand.Intn(5))*h.scrollScale))
 
 	for i := 0; i < steps; i++ {
 		// Variable scroll distance
-		px := 300 + rand.Intn(500)
+		px := int(float64(300+rand.Intn(500)) * h.scrollScale)
 
 		// Sometimes scroll in chunks
 		if rand.Float64() < 0.3 {
@@ -363,9 +347,10 @@ func ScrollHumanLike(p *rod.Page) {
 	}
 }
 
-// RandomHover moves mouse over arbitrary elements (simulates browsing)
-func RandomHover(p *rod.Page, selectors []string) {
-	if len(selectors) == 0 {
+// RandomHover moves mouse over arbitrary elements (simulates browsing), with
+// enable_hover_wander
+func (h *Human) RandomHover(p driver.Page, selectors []string) {
+	if !h.hover || len(selectors) == 0 {
 		return
 	}
 
@@ -374,26 +359,9 @@ func RandomHover(p *rod.Page, selectors []string) {
 	for i := 0; i < attempts && i < len(selectors); i++ {
 		sel := selectors[rand.Intn(len(selectors))]
 		if el, err := p.Timeout(2 * time.Second).Element(sel); err == nil {
-			shape, err := el.Shape()
-			if err == nil && len(shape.Quads) > 0 {
-				quad := shape.Quads[0]
-				// Calculate center of element
-				centerX := (quad[0] + quad[2] + quad[4] + quad[6]) / 4
-				centerY := (quad[1] + quad[3] + quad[5] + quad[7]) / 4
-
-				// Get viewport center
-				fromX := 700
-				fromY := 450
-				if dims, err := p.Eval(`() => ({width: window.innerWidth, height: window.innerHeight})`); err == nil {
-					if w := dims.Value.Get("width").Int(); w > 0 {
-						fromX = w / 2
-					}
-					if h := dims.Value.Get("height").Int(); h > 0 {
-						fromY = h / 2
-					}
-				}
-
-				_ = MoveMouseHumanLike(p, fromX, fromY, int(centerX), int(centerY))
+			if box, err := el.Box(); err == nil {
+				centerX, centerY := box.Center()
+				_ = h.MoveMouseHumanLike(p, int(centerX), int(centerY))
 				SleepRandom(300, 800)
 			}
 		}
@@ -402,53 +370,75 @@ func RandomHover(p *rod.Page, selectors []string) {
 
 // WakeUpMovement creates a visible "wake up" mouse movement at the start of page interactions
 // Simulates a human moving their mouse when they start engaging with a page
-func WakeUpMovement(p *rod.Page) error {
-	// Get window dimensions
-	width := 1400
-	height := 900
-
-	if dims, err := p.Eval(`() => ({width: window.innerWidth, height: window.innerHeight})`); err == nil {
-		if w := dims.Value.Get("width").Int(); w > 0 {
-			width = w
-		}
-		if h := dims.Value.Get("height").Int(); h > 0 {
-			height = h
-		}
+func (h *Human) WakeUpMovement(p driver.Page) error {
+	if !h.mouse {
+		return nil
 	}
 
-	// Start from a corner or edge (like user just came to the window)
-	startPositions := []struct{ x, y int }{
-		{100, 100},         // Top left
-		{width - 100, 100}, // Top right
-		{width / 2, 100},   // Top center
-		{100, height / 2},  // Left center
+	width, height := viewport(p)
+
+	// On a fresh page start from a corner or edge (like user just came to
+	// the window); after a navigation the mouse is still where it was
+	if !hasCursor(p) {
+		startPositions := []struct{ x, y int }{
+			{100, 100},         // Top left
+			{width - 100, 100}, // Top right
+			{width / 2, 100},   // Top center
+			{100, height / 2},  // Left center
+		}
+		start := startPositions[rand.Intn(len(startPositions))]
+		setCursor(p, start.x, start.y)
 	}
-	start := startPositions[rand.Intn(len(startPositions))]
 
 	// Move to center-ish area with visible movement
 	targetX := width/2 + rand.Intn(200) - 100
 	targetY := height/2 + rand.Intn(200) - 100
 
-	MoveMouseHumanLike(p, start.x, start.y, targetX, targetY)
+	h.MoveMouseHumanLike(p, targetX, targetY)
 	SleepRandom(300, 600)
 
 	return nil
 }
 
-// TakeBreak simulates a human taking a break (checking other tabs, etc.)
-func TakeBreak() {
-	if rand.Float64() < 0.15 { // 15% chance of taking a break
+// TakeBreak simulates a human taking a break (checking other tabs, etc.) with
+// break_probability, when enable_breaks is on
+func (h *Human) TakeBreak() {
+	if h.breaks && rand.Float64() < h.breakProb {
 		breakDuration := 3000 + rand.Intn(5000) // 3-8 seconds
 		time.Sleep(time.Duration(breakDuration) * time.Millisecond)
 	}
 }
 
-// InActiveWindow enforces time window
-func InActiveWindow(start, end string) bool {
-	now := time.Now()
-	s, _ := time.Parse("15:04", start)
-	e, _ := time.Parse("15:04", end)
-	startToday := time.Date(now.Year(), now.Month(), now.Day(), s.Hour(), s.Minute(), 0, 0, now.Location())
-	endToday := time.Date(now.Year(), now.Month(), now.Day(), e.Hour(), e.Minute(), 0, 0, now.Location())
-	return now.After(startToday) && now.Before(endToday)
+// LoadMore reveals more of a paginated card list: it scrolls for lazily
+// loaded cards, then clicks the Next or "Show more results" button that more
+// (the page.load_more selectors) finds. It reports whether the list changed.
+func (h *Human) LoadMore(p driver.Page, cardSelector string, more selectors.Set) bool {
+	before := listState(p, cardSelector)
+	h.ScrollHumanLike(p)
+	time.Sleep(1500 * time.Millisecond)
+	if listState(p, cardSelector) != before {
+		return true
+	}
+	btn, err := more.Find(p, 2*time.Second)
+	if err != nil {
+		return false
+	}
+	if err := h.ClickHumanLike(p, btn); err != nil {
+		return false
+	}
+	_ = p.WaitLoad()
+	time.Sleep(1500 * time.Millisecond)
+	return listState(p, cardSelector) != before
+}
+
+// listState summarizes a card list by its length and first and last cards,
+// so a replaced page of the same size still counts as a change.
+func listState(p driver.Page, cardSelector string) string {
+	cards, _ := p.Elements(cardSelector)
+	if len(cards) == 0 {
+		return ""
+	}
+	first, _ := cards[0].Text()
+	last, _ := cards[len(cards)-1].Text()
+	return strconv.Itoa(len(cards)) + "\x00" + first + "\x00" + last
 }
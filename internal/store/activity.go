@@ -0,0 +1,81 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// Activity is what happened between Since and Until, for the notification
+// digest.
+type Activity struct {
+	Since, Until time.Time
+	// Invited, Accepted and Replied count the profiles that moved to
+	// those statuses
+	Invited  int
+	Accepted int
+	Replied  int
+	// Runs counts the recorded command runs and daemon jobs, FailedRuns
+	// those that ended in an error
+	Runs       int
+	FailedRuns int
+	// Errors are the errors of the runs, newest first
+	Errors []string
+}
+
+// GetActivity tallies the status changes and runs in [since, until).
+func (s *Store) GetActivity(ctx context.Context, since, until time.Time) (*Activity, error) {
+	a := &Activity{Since: since, Until: until}
+	rows, err := s.db.QueryContext(ctx, `SELECT to_status, COUNT(*) FROM status_transitions
+		WHERE created_at >= ? AND created_at < ? AND to_status IN ('invited', 'accepted', 'replied') GROUP BY to_status`, since, until)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var status string
+		var n int
+		if err := rows.Scan(&status, &n); err != nil {
+			return nil, err
+		}
+		switch status {
+		case "invited":
+			a.Invited = n
+		case "accepted":
+			a.Accepted = n
+		case "replied":
+			a.Replied = n
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	runs, err := s.db.QueryContext(ctx, `SELECT run_type, ok, COALESCE(summary, '') FROM run_logs
+		WHERE started_at >= ? AND started_at < ? ORDER BY started_at DESC, id DESC`, since, until)
+	if err != nil {
+		return nil, err
+	}
+	defer runs.Close()
+	for runs.Next() {
+		var runType, summary string
+		var ok bool
+		if err := runs.Scan(&runType, &ok, &summary); err != nil {
+			return nil, err
+		}
+		a.Runs++
+		if !ok {
+			a.FailedRuns++
+		}
+		// The summary is the command's JSON result
+		var res struct {
+			Errors []string `json:"errors"`
+		}
+		if json.Unmarshal([]byte(summary), &res) == nil {
+			for _, e := range res.Errors {
+				a.Errors = append(a.Errors, runType+": "+e)
+			}
+		}
+	}
+	return a, runs.Err()
+}
@@ -0,0 +1,157 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+)
+
+// Approval states. Pending notes wait for review; approved ones are sent by
+// the next run and then marked sent.
+const (
+	ApprovalPending  = "pending"
+	ApprovalApproved = "approved"
+	ApprovalRejected = "rejected"
+	ApprovalSent     = "sent"
+)
+
+// Approval is an invite note staged for review.
+type Approval struct {
+	ID         int64
+	ProfileID  int64
+	ProfileURL string
+	Name       string
+	Headline   string
+	Note       string
+	State      string
+	Reason     string
+	CreatedAt  time.Time
+	DecidedAt  *time.Time
+}
+
+// StageApproval stores note for review. It reports false when the profile
+// already has an approval, whatever its state.
+func (s *Store) StageApproval(ctx context.Context, profileID int64, note string) (bool, error) {
+	res, err := s.db.ExecContext(ctx, `INSERT INTO approvals (profile_id, note, state, created_at) VALUES (?, ?, ?, ?) ON CONFLICT DO NOTHING`,
+		profileID, note, ApprovalPending, time.Now())
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// GetApprovals returns the approvals in state (all when empty), oldest
+// first.
+func (s *Store) GetApprovals(ctx context.Context, state string, limit int) ([]Approval, error) {
+	q := `SELECT a.id, a.profile_id, p.linkedin_url, COALESCE(p.name, ''), COALESCE(p.headline, ''), a.note, a.state, a.reason, a.created_at, a.decided_at
+		FROM approvals a JOIN profiles p ON p.id = a.profile_id`
+	var args []any
+	if state != "" {
+		q += ` WHERE a.state = ?`
+		args = append(args, state)
+	}
+	q += ` ORDER BY a.id LIMIT ?`
+	rows, err := s.db.QueryContext(ctx, q, append(args, limit)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Approval
+	for rows.Next() {
+		var a Approval
+		var decided sql.NullTime
+		if err := rows.Scan(&a.ID, &a.ProfileID, &a.ProfileURL, &a.Name, &a.Headline, &a.Note, &a.State, &a.Reason, &a.CreatedAt, &decided); err != nil {
+			return nil, err
+		}
+		a.DecidedAt = timePtr(decided)
+		out = append(out, a)
+	}
+	return out, rows.Err()
+}
+
+// DecideApprovals moves the pending approvals with ids (every pending one
+// when ids is empty) to approved or rejected, recording reason. A non-empty
+// note replaces the staged one. It returns how many were changed.
+func (s *Store) DecideApprovals(ctx context.Context, ids []int64, state, note, reason string) (int, error) {
+	if state != ApprovalApproved && state != ApprovalRejected {
+		return 0, errors.New("approvals can only be approved or rejected")
+	}
+	q := `UPDATE approvals SET state = ?, reason = ?, decided_at = ?`
+	args := []any{state, reason, time.Now()}
+	if note != "" {
+		q += `, note = ?`
+		args = append(args, note)
+	}
+	q += ` WHERE state = ?`
+	args = append(args, ApprovalPending)
+	if len(ids) > 0 {
+		q += ` AND id IN (?` + strings.Repeat(", ?", len(ids)-1) + `)`
+		for _, id := range ids {
+			args = append(args, id)
+		}
+	}
+	res, err := s.db.ExecContext(ctx, q, args...)
+	if err != nil {
+		return 0, err
+	}
+	n, _ := res.RowsAffected()
+	return int(n), nil
+}
+
+// ApprovedNote returns the approved note for a profile; ok is false when
+// the profile has no approved invite.
+func (s *Store) ApprovedNote(ctx context.Context, profileID int64) (note string, ok bool, err error) {
+	err = s.db.QueryRowContext(ctx, `SELECT note FROM approvals WHERE profile_id = ? AND state = ?`, profileID, ApprovalApproved).Scan(&note)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", false, nil
+	}
+	return note, err == nil, err
+}
+
+// ApprovedInQueue returns up to limit profiles whose invite is approved and
+// that are still in the connection queue, in the order they were approved.
+func (s *Store) ApprovedInQueue(ctx context.Context, limit int) ([]int64, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT a.profile_id FROM approvals a JOIN profiles ON profiles.id = a.profile_id
+		WHERE a.state = ? AND profiles.`+inQueue+` ORDER BY a.decided_at, a.id LIMIT ?`, ApprovalApproved, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		out = append(out, id)
+	}
+	return out, rows.Err()
+}
+
+// MarkApprovalSent closes the approved invite of a profile once sent.
+func (s *Store) MarkApprovalSent(ctx context.Context, profileID int64) error {
+	_, err := s.db.ExecContext(ctx, `UPDATE approvals SET state = ? WHERE profile_id = ? AND state = ?`, ApprovalSent, profileID, ApprovalApproved)
+	return err
+}
+
+// CountApprovals tallies the approvals by state.
+func (s *Store) CountApprovals(ctx context.Context) (map[string]int, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT state, COUNT(*) FROM approvals GROUP BY state`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := map[string]int{}
+	for rows.Next() {
+		var state string
+		var n int
+		if err := rows.Scan(&state, &n); err != nil {
+			return nil, err
+		}
+		out[state] = n
+	}
+	return out, rows.Err()
+}
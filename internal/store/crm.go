@@ -0,0 +1,79 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/example/linkedbot/internal/models"
+)
+
+// CRMItem is a profile due for a CRM push, with what was pushed before and
+// its newest reply.
+type CRMItem struct {
+	Profile models.Profile
+	// ContactID and DealID are empty until created
+	ContactID  string
+	DealID     string
+	ReplyID    int64
+	ReplyLabel string
+	Reply      string
+}
+
+// GetCRMPending returns up to limit profiles in statuses whose status or
+// newest reply changed since they were last pushed to provider, never
+// pushed ones included. Profiles whose last push failed come last.
+func (s *Store) GetCRMPending(ctx context.Context, provider string, statuses []string, limit int) ([]CRMItem, error) {
+	if len(statuses) == 0 {
+		return nil, nil
+	}
+	args := []any{provider}
+	for _, st := range statuses {
+		args = append(args, st)
+	}
+	rows, err := s.db.QueryContext(ctx, `SELECT p.id, p.linkedin_url, p.name, p.headline, p.company, p.location, p.source, p.status,
+		COALESCE(c.contact_id, ''), COALESCE(c.deal_id, ''), COALESCE(r.id, 0), COALESCE(r.label, ''), COALESCE(r.content, '')
+	FROM profiles p
+	LEFT JOIN crm_sync c ON c.profile_id = p.id AND c.provider = ?
+	LEFT JOIN replies r ON r.id = (SELECT id FROM replies WHERE profile_id = p.id ORDER BY created_at DESC, id DESC LIMIT 1)
+	WHERE p.status IN (?`+strings.Repeat(", ?", len(statuses)-1)+`)
+		AND (c.profile_id IS NULL OR c.synced_status <> p.status OR COALESCE(r.id, 0) > c.synced_reply_id)
+	ORDER BY COALESCE(c.error, '') <> '', p.updated_at, p.id LIMIT ?`, append(args, limit)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []CRMItem
+	for rows.Next() {
+		var it CRMItem
+		p := &it.Profile
+		var name, headline, company, location sql.NullString
+		if err := rows.Scan(&p.ID, &p.LinkedInURL, &name, &headline, &company, &location, &p.Source, &p.Status,
+			&it.ContactID, &it.DealID, &it.ReplyID, &it.ReplyLabel, &it.Reply); err != nil {
+			return nil, err
+		}
+		p.Name, p.Headline, p.Company, p.Location = name.String, headline.String, company.String, location.String
+		out = append(out, it)
+	}
+	return out, rows.Err()
+}
+
+// SaveCRMSync records a successful push of it to provider.
+func (s *Store) SaveCRMSync(ctx context.Context, provider string, it *CRMItem) error {
+	_, err := s.db.ExecContext(ctx, `INSERT INTO crm_sync (profile_id, provider, contact_id, deal_id, synced_status, synced_reply_id, synced_at, error)
+		VALUES (?, ?, ?, ?, ?, ?, ?, '')
+		ON CONFLICT(profile_id) DO UPDATE SET provider = excluded.provider, contact_id = excluded.contact_id, deal_id = excluded.deal_id,
+		synced_status = excluded.synced_status, synced_reply_id = excluded.synced_reply_id, synced_at = excluded.synced_at, error = ''`,
+		it.Profile.ID, provider, it.ContactID, it.DealID, string(it.Profile.Status), it.ReplyID, time.Now())
+	return err
+}
+
+// SetCRMError records a failed push of it, keeping what earlier pushes
+// created so the next attempt updates rather than duplicates.
+func (s *Store) SetCRMError(ctx context.Context, provider string, it *CRMItem, msg string) error {
+	_, err := s.db.ExecContext(ctx, `INSERT INTO crm_sync (profile_id, provider, contact_id, deal_id, error) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(profile_id) DO UPDATE SET provider = excluded.provider, contact_id = excluded.contact_id, deal_id = excluded.deal_id, error = excluded.error`,
+		it.Profile.ID, provider, it.ContactID, it.DealID, msg)
+	return err
+}
@@ -0,0 +1,279 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/example/linkedbot/internal/models"
+)
+
+// mergeConflicts lists, per table, the columns that together with
+// profile_id must stay unique. A merged row that would clash with one the
+// surviving profile already has is dropped instead of moved.
+var mergeConflicts = map[string][]string{
+	"message_sequences": nil,
+	"profile_details":   nil,
+	"approvals":         nil,
+	"crm_sync":          nil,
+	"profile_tags":      {"tag_id"},
+	"nurture_events":    {"kind", "year"},
+	"generated_texts":   {"kind", "prompt_hash"},
+	"replies":           {"content_hash"},
+}
+
+// DedupeResult counts what Dedupe changed.
+type DedupeResult struct {
+	// Normalized counts URLs rewritten to the canonical form
+	Normalized int
+	// Merged counts duplicate rows folded into another profile
+	Merged int
+}
+
+// Dedupe rewrites stored profile URLs to the canonical /in/<slug> form and
+// merges rows that are the same member: the slug in another case or
+// escaping, or the same member URN under an old and a new slug. The row
+// furthest along the pipeline survives and takes over the history of the
+// others; their URLs become aliases. dryRun only counts.
+func (s *Store) Dedupe(ctx context.Context, dryRun bool) (DedupeResult, error) {
+	var res DedupeResult
+	rows, err := s.db.QueryContext(ctx, `SELECT id, linkedin_url, member_urn, status FROM profiles ORDER BY id`)
+	if err != nil {
+		return res, err
+	}
+	var profiles []models.Profile
+	for rows.Next() {
+		var p models.Profile
+		if err := rows.Scan(&p.ID, &p.LinkedInURL, &p.MemberURN, &p.Status); err != nil {
+			rows.Close()
+			return res, err
+		}
+		profiles = append(profiles, p)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return res, err
+	}
+
+	// Group rows sharing a slug or a member URN, transitively
+	parent := make([]int, len(profiles))
+	for i := range parent {
+		parent[i] = i
+	}
+	var root func(int) int
+	root = func(i int) int {
+		for parent[i] != i {
+			parent[i] = parent[parent[i]]
+			i = parent[i]
+		}
+		return i
+	}
+	seen := map[string]int{}
+	for i, p := range profiles {
+		for _, key := range []string{"slug:" + models.ProfileSlug(p.LinkedInURL), "urn:" + p.MemberURN} {
+			if key == "slug:" || key == "urn:" {
+				continue
+			}
+			if j, ok := seen[key]; ok {
+				parent[root(i)] = root(j)
+			} else {
+				seen[key] = i
+			}
+		}
+	}
+	groups := map[int][]models.Profile{}
+	for i, p := range profiles {
+		groups[root(i)] = append(groups[root(i)], p)
+	}
+
+	now := time.Now()
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return res, err
+	}
+	defer func() { _ = tx.Rollback() }()
+	for i := range profiles {
+		group := groups[i]
+		if len(group) == 0 {
+			continue
+		}
+		keep := survivor(group)
+		for _, p := range group {
+			if p.ID == keep.ID {
+				continue
+			}
+			res.Merged++
+			if !dryRun {
+				if err := mergeProfiles(ctx, tx, keep.ID, p, now); err != nil {
+					return res, err
+				}
+			}
+		}
+		if canon := models.CanonicalProfileURL(keep.LinkedInURL); canon != keep.LinkedInURL {
+			res.Normalized++
+			if !dryRun {
+				if _, err := tx.ExecContext(ctx, `UPDATE profiles SET linkedin_url = ? WHERE id = ?`, canon, keep.ID); err != nil {
+					return res, err
+				}
+			}
+		}
+	}
+	if dryRun {
+		return res, nil
+	}
+	return res, tx.Commit()
+}
+
+// FollowRedirect records that prof's link led to pageURL, which names
+// another slug when the member has changed theirs. The profile takes the
+// new URL and keeps the old one as an alias; when the new URL is already
+// stored as another row, the two are merged as Dedupe does. prof is updated
+// to the surviving row, and merged reports that a merge happened.
+func (s *Store) FollowRedirect(ctx context.Context, prof *models.Profile, pageURL string) (merged bool, err error) {
+	slug := models.ProfileSlug(pageURL)
+	if slug == "" || slug == models.ProfileSlug(prof.LinkedInURL) {
+		return false, nil
+	}
+	newURL := models.CanonicalProfileURL(pageURL)
+	now := time.Now()
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = tx.Rollback() }()
+	cur := models.Profile{ID: prof.ID, LinkedInURL: prof.LinkedInURL}
+	if err := tx.QueryRowContext(ctx, `SELECT status FROM profiles WHERE id = ?`, prof.ID).Scan(&cur.Status); err != nil {
+		return false, err
+	}
+	other := models.Profile{LinkedInURL: newURL}
+	err = tx.QueryRowContext(ctx, `SELECT id, status FROM profiles WHERE linkedin_url = ?`, newURL).Scan(&other.ID, &other.Status)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+	case err != nil:
+		return false, err
+	default:
+		merged = true
+		keep := survivor([]models.Profile{cur, other})
+		drop := cur
+		if keep.ID == cur.ID {
+			drop = other
+		}
+		if err := mergeProfiles(ctx, tx, keep.ID, drop, now); err != nil {
+			return false, err
+		}
+		cur = keep
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE profiles SET linkedin_url = ?, updated_at = ? WHERE id = ?`, newURL, now, cur.ID); err != nil {
+		return false, err
+	}
+	if err := addAlias(ctx, tx, prof.LinkedInURL, cur.ID, now); err != nil {
+		return false, err
+	}
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+	prof.ID, prof.LinkedInURL, prof.Status = cur.ID, newURL, cur.Status
+	return merged, nil
+}
+
+// survivor picks the row a group of duplicates is merged into: the one
+// furthest along the pipeline, the oldest among equals. An invalid row, a
+// link that no longer opens, only survives if all are.
+func survivor(group []models.Profile) models.Profile {
+	rank := func(st models.ProfileStatus) int {
+		if st == models.StatusInvalid {
+			return -1
+		}
+		return slices.Index(models.Statuses, st)
+	}
+	keep := group[0]
+	for _, p := range group[1:] {
+		pi, ki := rank(p.Status), rank(keep.Status)
+		if pi > ki || (pi == ki && p.ID < keep.ID) {
+			keep = p
+		}
+	}
+	return keep
+}
+
+// mergeProfiles folds drop into keep: every row recorded about drop moves
+// over, empty fields of keep are filled from it, and its URL becomes an
+// alias of keep.
+func mergeProfiles(ctx context.Context, tx *txn, keep int64, drop models.Profile, now time.Time) error {
+	for table, cols := range mergeConflicts {
+		q := `DELETE FROM ` + table + ` WHERE profile_id = ? AND EXISTS (SELECT 1 FROM ` + table + ` k WHERE k.profile_id = ?`
+		for _, c := range cols {
+			q += ` AND k.` + c + ` = ` + table + `.` + c
+		}
+		if _, err := tx.ExecContext(ctx, q+`)`, drop.ID, keep); err != nil {
+			return err
+		}
+	}
+	// Only one job of a kind may be active per profile
+	if _, err := tx.ExecContext(ctx, `DELETE FROM jobs WHERE profile_id = ? AND state IN ('pending', 'running')
+		AND EXISTS (SELECT 1 FROM jobs k WHERE k.profile_id = ? AND k.kind = jobs.kind AND k.state IN ('pending', 'running'))`, drop.ID, keep); err != nil {
+		return err
+	}
+	for _, table := range profileTables {
+		if _, err := tx.ExecContext(ctx, `UPDATE `+table+` SET profile_id = ? WHERE profile_id = ?`, keep, drop.ID); err != nil {
+			return err
+		}
+	}
+	q := `UPDATE profiles SET `
+	for _, c := range []string{"name", "headline", "company", "location", "source", "member_urn", "degree", "email", "endorsed_skills"} {
+		q += c + ` = COALESCE(NULLIF(` + c + `, ''), (SELECT d.` + c + ` FROM profiles d WHERE d.id = ?)), `
+	}
+	for _, c := range []string{"connection_sent_at", "connection_checked_at", "message_sent_at", "replied_at", "withdrawn_at",
+		"already_connected_at", "requires_email_at", "engaged_at", "viewed_at", "endorsed_at"} {
+		q += c + ` = COALESCE(` + c + `, (SELECT d.` + c + ` FROM profiles d WHERE d.id = ?)), `
+	}
+	for _, c := range []string{"open_profile", "requires_email"} {
+		q += c + ` = ` + c + ` | (SELECT d.` + c + ` FROM profiles d WHERE d.id = ?), `
+	}
+	q += `created_at = (SELECT MIN(d.created_at) FROM profiles d WHERE d.id IN (?, ?)), updated_at = ? WHERE id = ?`
+	args := make([]any, 0, 25)
+	for i := 0; i < 21; i++ {
+		args = append(args, drop.ID)
+	}
+	if _, err := tx.ExecContext(ctx, q, append(args, drop.ID, keep, now, keep)...); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM profiles WHERE id = ?`, drop.ID); err != nil {
+		return err
+	}
+	return addAlias(ctx, tx, drop.LinkedInURL, keep, now)
+}
+
+// addAlias points the canonical form of u at a profile, unless u is that
+// profile's own URL.
+func addAlias(ctx context.Context, tx *txn, u string, profileID int64, now time.Time) error {
+	u = models.CanonicalProfileURL(u)
+	if _, err := tx.ExecContext(ctx, `DELETE FROM profile_aliases WHERE linkedin_url IN (SELECT linkedin_url FROM profiles WHERE id = ?)`, profileID); err != nil {
+		return err
+	}
+	var own string
+	if err := tx.QueryRowContext(ctx, `SELECT linkedin_url FROM profiles WHERE id = ?`, profileID).Scan(&own); err != nil {
+		return err
+	}
+	if u == models.CanonicalProfileURL(own) {
+		return nil
+	}
+	_, err := tx.ExecContext(ctx, `INSERT INTO profile_aliases (linkedin_url, profile_id, created_at) VALUES (?, ?, ?)
+		ON CONFLICT (linkedin_url) DO UPDATE SET profile_id = excluded.profile_id`, u, profileID, now)
+	return err
+}
+
+// resolveAlias returns the URL of the profile u is an alias of, or the
+// canonical u itself.
+func (s *Store) resolveAlias(ctx context.Context, u string) (string, error) {
+	u = models.CanonicalProfileURL(u)
+	var cur string
+	err := s.db.QueryRowContext(ctx, `SELECT p.linkedin_url FROM profile_aliases a JOIN profiles p ON p.id = a.profile_id
+	WHERE a.linkedin_url = ?`, u).Scan(&cur)
+	if errors.Is(err, sql.ErrNoRows) {
+		return u, nil
+	}
+	return cur, err
+}
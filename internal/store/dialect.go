@@ -0,0 +1,155 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+	_ "modernc.org/sqlite"
+)
+
+// dialect is what differs between the databases the store runs on. Queries
+// are written once, with ? placeholders and SQL both SQLite and Postgres
+// accept; each dialect has its own migrations directory for the DDL. The
+// backends differ only here, so Store stays a single concrete type rather
+// than an interface with one implementation per database.
+type dialect interface {
+	// driverName is the database/sql driver to open.
+	driverName() string
+	// rebind rewrites the ? placeholders of query for the driver.
+	rebind(query string) string
+	// migrationsDir is the directory under migrations/ with the driver's
+	// schema files.
+	migrationsDir() string
+	// migrationsTable creates schema_migrations.
+	migrationsTable() string
+	// tableExistsQuery counts the tables named by its one argument.
+	tableExistsQuery() string
+}
+
+func dialectFor(driver string) (dialect, error) {
+	switch driver {
+	case "", "sqlite":
+		return sqliteDialect{}, nil
+	case "postgres":
+		return postgresDialect{}, nil
+	}
+	return nil, fmt.Errorf("unsupported database driver %q", driver)
+}
+
+type sqliteDialect struct{}
+
+func (sqliteDialect) driverName() string         { return "sqlite" }
+func (sqliteDialect) rebind(query string) string { return query }
+func (sqliteDialect) migrationsDir() string      { return "sqlite" }
+
+func (sqliteDialect) migrationsTable() string {
+	return `CREATE TABLE IF NOT EXISTS schema_migrations (
+	version INTEGER PRIMARY KEY,
+	name TEXT NOT NULL,
+	applied_at DATETIME NOT NULL
+)`
+}
+
+func (sqliteDialect) tableExistsQuery() string {
+	return `SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?`
+}
+
+type postgresDialect struct{}
+
+func (postgresDialect) driverName() string    { return "pgx" }
+func (postgresDialect) migrationsDir() string { return "postgres" }
+
+// rebind numbers the placeholders ($1, $2, ...), leaving quoted strings
+// alone.
+func (postgresDialect) rebind(query string) string {
+	var b strings.Builder
+	n := 0
+	quoted := false
+	for _, r := range query {
+		switch {
+		case r == '\'':
+			quoted = !quoted
+		case r == '?' && !quoted:
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func (postgresDialect) migrationsTable() string {
+	return `CREATE TABLE IF NOT EXISTS schema_migrations (
+	version INTEGER PRIMARY KEY,
+	name TEXT NOT NULL,
+	applied_at TIMESTAMPTZ NOT NULL
+)`
+}
+
+func (postgresDialect) tableExistsQuery() string {
+	return `SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = current_schema() AND table_name = ?`
+}
+
+// conn is the database handle the store queries through. It rebinds every
+// query for the dialect and stores bools as the 0/1 the flag columns hold,
+// which Postgres does not convert by itself.
+type conn struct {
+	*sql.DB
+	d dialect
+}
+
+func (c *conn) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return c.DB.ExecContext(ctx, c.d.rebind(query), bindArgs(args)...)
+}
+
+func (c *conn) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	return c.DB.QueryContext(ctx, c.d.rebind(query), bindArgs(args)...)
+}
+
+func (c *conn) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	return c.DB.QueryRowContext(ctx, c.d.rebind(query), bindArgs(args)...)
+}
+
+func (c *conn) BeginTx(ctx context.Context, opts *sql.TxOptions) (*txn, error) {
+	tx, err := c.DB.BeginTx(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &txn{Tx: tx, d: c.d}, nil
+}
+
+// txn is a transaction on conn.
+type txn struct {
+	*sql.Tx
+	d dialect
+}
+
+func (t *txn) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return t.Tx.ExecContext(ctx, t.d.rebind(query), bindArgs(args)...)
+}
+
+func (t *txn) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	return t.Tx.QueryContext(ctx, t.d.rebind(query), bindArgs(args)...)
+}
+
+func (t *txn) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	return t.Tx.QueryRowContext(ctx, t.d.rebind(query), bindArgs(args)...)
+}
+
+func bindArgs(args []any) []any {
+	for i, a := range args {
+		if b, ok := a.(bool); ok {
+			if b {
+				args[i] = 1
+			} else {
+				args[i] = 0
+			}
+		}
+	}
+	return args
+}
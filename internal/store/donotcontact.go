@@ -0,0 +1,131 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/example/linkedbot/internal/models"
+)
+
+// ErrDoNotContact is returned when storing a profile that is on the
+// do-not-contact list.
+var ErrDoNotContact = errors.New("profile is on the do-not-contact list")
+
+// DoNotContact is one entry of the do-not-contact list.
+type DoNotContact struct {
+	URL       string
+	Reason    string
+	CreatedAt time.Time
+}
+
+// AddDoNotContact puts a profile URL on the do-not-contact list and moves
+// the profile, if stored, to do_not_contact. It reports whether the URL was
+// not listed yet.
+func (s *Store) AddDoNotContact(ctx context.Context, profileURL, reason string) (bool, error) {
+	profileURL = models.CanonicalProfileURL(profileURL)
+	now := time.Now()
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = tx.Rollback() }()
+	res, err := tx.ExecContext(ctx, `INSERT INTO do_not_contact (linkedin_url, reason, created_at) VALUES (?, ?, ?) ON CONFLICT (linkedin_url) DO NOTHING`,
+		profileURL, reason, now)
+	if err != nil {
+		return false, err
+	}
+	var id int64
+	err = tx.QueryRowContext(ctx, `SELECT id FROM profiles WHERE linkedin_url = ?`, profileURL).Scan(&id)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+	case err != nil:
+		return false, err
+	default:
+		why := "do-not-contact list"
+		if reason != "" {
+			why += ": " + reason
+		}
+		if err := transition(ctx, tx, id, models.StatusDoNotContact, why, now); err != nil {
+			return false, err
+		}
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, tx.Commit()
+}
+
+// RemoveDoNotContact takes a URL off the do-not-contact list. A stored
+// profile stays do_not_contact, which is final.
+func (s *Store) RemoveDoNotContact(ctx context.Context, profileURL string) (bool, error) {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM do_not_contact WHERE linkedin_url = ?`, models.CanonicalProfileURL(profileURL))
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// GetDoNotContact returns the do-not-contact list, oldest first.
+func (s *Store) GetDoNotContact(ctx context.Context) ([]DoNotContact, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT linkedin_url, reason, created_at FROM do_not_contact ORDER BY created_at, id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []DoNotContact
+	for rows.Next() {
+		var d DoNotContact
+		if err := rows.Scan(&d.URL, &d.Reason, &d.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, d)
+	}
+	return out, rows.Err()
+}
+
+// CheckDoNotContact returns ErrDoNotContact, and records that action was
+// suppressed, when profileURL is on the do-not-contact list. Callers skip the
+// profile on any error, so a failed lookup never lets a contact through.
+func (s *Store) CheckDoNotContact(ctx context.Context, profileURL, action string) error {
+	profileURL = models.CanonicalProfileURL(profileURL)
+	var n int
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM do_not_contact WHERE linkedin_url = ?`, profileURL).Scan(&n); err != nil {
+		return err
+	}
+	if n == 0 {
+		return nil
+	}
+	if _, err := s.db.ExecContext(ctx, `INSERT INTO suppressions (linkedin_url, action, created_at) VALUES (?, ?, ?)`,
+		profileURL, action, time.Now()); err != nil {
+		return err
+	}
+	return ErrDoNotContact
+}
+
+// Suppression is one action refused because of the do-not-contact list.
+type Suppression struct {
+	ID        int64
+	URL       string
+	Action    string
+	CreatedAt time.Time
+}
+
+// GetSuppressions returns the most recent suppressions, newest first.
+func (s *Store) GetSuppressions(ctx context.Context, limit int) ([]Suppression, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT id, linkedin_url, action, created_at FROM suppressions
+	ORDER BY created_at DESC, id DESC LIMIT ?`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Suppression
+	for rows.Next() {
+		var sp Suppression
+		if err := rows.Scan(&sp.ID, &sp.URL, &sp.Action, &sp.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, sp)
+	}
+	return out, rows.Err()
+}
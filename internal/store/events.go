@@ -0,0 +1,94 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+// Event types of the outbound event stream.
+const (
+	EventProfileDiscovered = "profile_discovered"
+	EventInviteSent        = "invite_sent"
+	EventAccepted          = "accepted"
+	EventReplied           = "replied"
+)
+
+// EventTypes lists every event type.
+var EventTypes = []string{EventProfileDiscovered, EventInviteSent, EventAccepted, EventReplied}
+
+// Event is one entry of the event stream, shaped as the JSON automation
+// tools receive. Profile is read when the event is, so purged profiles show
+// as anonymized.
+type Event struct {
+	ID        int64           `json:"id"`
+	Type      string          `json:"type"`
+	CreatedAt time.Time       `json:"created_at"`
+	Profile   EventProfile    `json:"profile"`
+	Data      json.RawMessage `json:"data"`
+}
+
+type EventProfile struct {
+	ID       int64  `json:"id"`
+	URL      string `json:"linkedin_url"`
+	Name     string `json:"name"`
+	Headline string `json:"headline"`
+	Company  string `json:"company"`
+	Location string `json:"location"`
+	Source   string `json:"source"`
+	Status   string `json:"status"`
+}
+
+// execer is a connection or a transaction.
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// addEvent appends an event of typ for a profile; data holds its details.
+func addEvent(ctx context.Context, db execer, typ string, profileID int64, data map[string]any, now time.Time) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	if data == nil {
+		raw = []byte("{}")
+	}
+	_, err = db.ExecContext(ctx, `INSERT INTO events (type, profile_id, data, created_at) VALUES (?, ?, ?, ?)`, typ, profileID, string(raw), now)
+	return err
+}
+
+// GetEvents returns up to limit events with an id above after, newest
+// first or, with oldestFirst, the next ones in order for paging by id; typ
+// filters them when not empty.
+func (s *Store) GetEvents(ctx context.Context, after int64, typ string, limit int, oldestFirst bool) ([]Event, error) {
+	q := `SELECT e.id, e.type, e.created_at, e.data, p.id, p.linkedin_url, COALESCE(p.name, ''), COALESCE(p.headline, ''), COALESCE(p.company, ''),
+		COALESCE(p.location, ''), p.source, p.status
+	FROM events e JOIN profiles p ON p.id = e.profile_id WHERE e.id > ?`
+	args := []any{after}
+	if typ != "" {
+		q += ` AND e.type = ?`
+		args = append(args, typ)
+	}
+	order := ` ORDER BY e.id DESC`
+	if oldestFirst {
+		order = ` ORDER BY e.id`
+	}
+	rows, err := s.db.QueryContext(ctx, q+order+` LIMIT ?`, append(args, limit)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Event
+	for rows.Next() {
+		var e Event
+		var data string
+		p := &e.Profile
+		if err := rows.Scan(&e.ID, &e.Type, &e.CreatedAt, &data, &p.ID, &p.URL, &p.Name, &p.Headline, &p.Company, &p.Location, &p.Source, &p.Status); err != nil {
+			return nil, err
+		}
+		e.Data = json.RawMessage(data)
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,75 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// GeneratedText is a note or message written by the LLM for a profile.
+type GeneratedText struct {
+	ProfileID    int64
+	Kind         string
+	PromptHash   string
+	Model        string
+	Content      string
+	InputTokens  int
+	OutputTokens int
+	Cost         float64
+	CreatedAt    time.Time
+}
+
+// LLMUsage is what the LLM calls since some time cost.
+type LLMUsage struct {
+	Calls  int
+	Tokens int
+	Cost   float64
+}
+
+// GetGeneratedText returns the cached text for the profile, kind and
+// prompt; ok is false when there is none.
+func (s *Store) GetGeneratedText(ctx context.Context, profileID int64, kind, promptHash string) (text string, ok bool, err error) {
+	err = s.db.QueryRowContext(ctx, `SELECT content FROM generated_texts WHERE profile_id = ? AND kind = ? AND prompt_hash = ?`,
+		profileID, kind, promptHash).Scan(&text)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", false, nil
+	}
+	return text, err == nil, err
+}
+
+// SaveGeneratedText caches g, replacing an earlier text for the same
+// profile, kind and prompt.
+func (s *Store) SaveGeneratedText(ctx context.Context, g *GeneratedText) error {
+	g.CreatedAt = time.Now()
+	_, err := s.db.ExecContext(ctx, `INSERT INTO generated_texts (profile_id, kind, prompt_hash, model, content, input_tokens, output_tokens, cost, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(profile_id, kind, prompt_hash) DO UPDATE SET model = excluded.model, content = excluded.content,
+		input_tokens = excluded.input_tokens, output_tokens = excluded.output_tokens, cost = excluded.cost, created_at = excluded.created_at`,
+		g.ProfileID, g.Kind, g.PromptHash, g.Model, g.Content, g.InputTokens, g.OutputTokens, g.Cost, g.CreatedAt)
+	return err
+}
+
+// LLMUsageSince sums the generations cached since since.
+func (s *Store) LLMUsageSince(ctx context.Context, since time.Time) (LLMUsage, error) {
+	var u LLMUsage
+	err := s.db.QueryRowContext(ctx, `SELECT COUNT(*), COALESCE(SUM(input_tokens + output_tokens), 0), COALESCE(SUM(cost), 0)
+		FROM generated_texts WHERE created_at >= ?`, since).Scan(&u.Calls, &u.Tokens, &u.Cost)
+	return u, err
+}
+
+// ProfileContext returns what the LLM prompts know about a profile beyond
+// its search card: the about section saved by enrich and the text of the
+// newest post engage looked at. Either may be empty.
+func (s *Store) ProfileContext(ctx context.Context, profileID int64) (about, post string, err error) {
+	err = s.db.QueryRowContext(ctx, `SELECT COALESCE(about, '') FROM profile_details WHERE profile_id = ?`, profileID).Scan(&about)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return "", "", err
+	}
+	err = s.db.QueryRowContext(ctx, `SELECT post_text FROM engagements WHERE profile_id = ? AND post_text <> '' ORDER BY created_at DESC, id DESC LIMIT 1`,
+		profileID).Scan(&post)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return "", "", err
+	}
+	return about, post, nil
+}
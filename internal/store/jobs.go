@@ -0,0 +1,339 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/example/linkedbot/internal/models"
+)
+
+// Job kinds.
+const (
+	JobConnect = "connect" // send an invite
+	JobMessage = "message" // send the follow-up step in the payload
+)
+
+// Job states. Pending jobs run once next_run_at has passed; done, skipped
+// and failed are final.
+const (
+	JobPending = "pending"
+	JobRunning = "running"
+	JobDone    = "done"
+	JobSkipped = "skipped"
+	JobFailed  = "failed"
+)
+
+// Job is one queued action on a profile, e.g. an invite or a follow-up step.
+type Job struct {
+	ID          int64
+	Kind        string
+	ProfileID   int64
+	Payload     string
+	State       string
+	Attempts    int
+	MaxAttempts int
+	NextRunAt   time.Time
+	LastError   string
+	// Reason classifies the last failure, see worker.Classify
+	Reason string
+	// Retries counts the times retry-failed put the job back
+	Retries   int
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+const jobColumns = `id, kind, profile_id, payload, state, attempts, max_attempts, next_run_at, last_error, reason, retries, created_at, updated_at`
+
+func scanJobs(rows *sql.Rows) ([]Job, error) {
+	defer rows.Close()
+	var out []Job
+	for rows.Next() {
+		var j Job
+		if err := rows.Scan(&j.ID, &j.Kind, &j.ProfileID, &j.Payload, &j.State, &j.Attempts, &j.MaxAttempts, &j.NextRunAt, &j.LastError, &j.Reason, &j.Retries, &j.CreatedAt, &j.UpdatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, j)
+	}
+	return out, rows.Err()
+}
+
+// EnqueueJob queues a job of kind for a profile, due at once. It reports
+// false when the profile already has a pending or running job of that kind.
+func (s *Store) EnqueueJob(ctx context.Context, kind string, profileID int64, payload string, maxAttempts int) (bool, error) {
+	now := time.Now()
+	res, err := s.db.ExecContext(ctx, `INSERT INTO jobs (kind, profile_id, payload, state, max_attempts, next_run_at, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?) ON CONFLICT DO NOTHING`, kind, profileID, payload, JobPending, maxAttempts, now, now, now)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// ClaimJob marks the oldest due pending job of one of kinds as running and
+// counts the attempt. It returns nil when no job is due.
+func (s *Store) ClaimJob(ctx context.Context, kinds []string, now time.Time) (*Job, error) {
+	if len(kinds) == 0 {
+		return nil, nil
+	}
+	args := []any{JobPending, now}
+	for _, k := range kinds {
+		args = append(args, k)
+	}
+	for {
+		rows, err := s.db.QueryContext(ctx, `SELECT `+jobColumns+` FROM jobs
+		WHERE state = ? AND next_run_at <= ? AND kind IN (?`+strings.Repeat(", ?", len(kinds)-1)+`)
+		ORDER BY next_run_at, id LIMIT 1`, args...)
+		if err != nil {
+			return nil, err
+		}
+		jobs, err := scanJobs(rows)
+		if err != nil || len(jobs) == 0 {
+			return nil, err
+		}
+		j := jobs[0]
+		// Another worker may have claimed it in between
+		res, err := s.db.ExecContext(ctx, `UPDATE jobs SET state = ?, attempts = attempts + 1, updated_at = ? WHERE id = ? AND state = ?`,
+			JobRunning, now, j.ID, JobPending)
+		if err != nil {
+			return nil, err
+		}
+		if n, _ := res.RowsAffected(); n == 1 {
+			j.State = JobRunning
+			j.Attempts++
+			j.UpdatedAt = now
+			return &j, nil
+		}
+	}
+}
+
+// NextJobKind returns the kind of the job ClaimJob would claim next, "" when
+// none is due.
+func (s *Store) NextJobKind(ctx context.Context, kinds []string, now time.Time) (string, error) {
+	if len(kinds) == 0 {
+		return "", nil
+	}
+	args := []any{JobPending, now}
+	for _, k := range kinds {
+		args = append(args, k)
+	}
+	var kind string
+	err := s.db.QueryRowContext(ctx, `SELECT kind FROM jobs
+		WHERE state = ? AND next_run_at <= ? AND kind IN (?`+strings.Repeat(", ?", len(kinds)-1)+`)
+		ORDER BY next_run_at, id LIMIT 1`, args...).Scan(&kind)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	return kind, err
+}
+
+// CountDueJobs returns how many pending jobs of kind are due at now.
+func (s *Store) CountDueJobs(ctx context.Context, kind string, now time.Time) (int, error) {
+	var n int
+	err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM jobs WHERE kind = ? AND state = ? AND next_run_at <= ?`, kind, JobPending, now).Scan(&n)
+	return n, err
+}
+
+// ReleaseJob puts a claimed job back without counting the attempt, e.g.
+// when its rate limit is used up.
+func (s *Store) ReleaseJob(ctx context.Context, id int64) error {
+	_, err := s.db.ExecContext(ctx, `UPDATE jobs SET state = ?, attempts = attempts - 1, updated_at = ? WHERE id = ? AND state = ?`,
+		JobPending, time.Now(), id, JobRunning)
+	return err
+}
+
+// CompleteJob marks a job done.
+func (s *Store) CompleteJob(ctx context.Context, id int64) error {
+	return s.finishJob(ctx, id, JobDone, "")
+}
+
+// SkipJob ends a job that turned out not to be needed, with the reason.
+func (s *Store) SkipJob(ctx context.Context, id int64, reason string) error {
+	return s.finishJob(ctx, id, JobSkipped, reason)
+}
+
+func (s *Store) finishJob(ctx context.Context, id int64, state, reason string) error {
+	_, err := s.db.ExecContext(ctx, `UPDATE jobs SET state = ?, last_error = ?, updated_at = ? WHERE id = ?`, state, reason, time.Now(), id)
+	return err
+}
+
+// RetryJob records a failed attempt and the reason it failed. The job runs
+// again at next, or fails for good once it used up its attempts or next is
+// zero. It reports whether the job will run again.
+func (s *Store) RetryJob(ctx context.Context, j *Job, cause error, reason string, next time.Time) (bool, error) {
+	state := JobPending
+	if next.IsZero() || j.Attempts >= j.MaxAttempts {
+		state, next = JobFailed, j.NextRunAt
+	}
+	_, err := s.db.ExecContext(ctx, `UPDATE jobs SET state = ?, next_run_at = ?, last_error = ?, reason = ?, updated_at = ? WHERE id = ?`,
+		state, next, cause.Error(), reason, time.Now(), j.ID)
+	return state == JobPending, err
+}
+
+// RecoverJobs puts jobs left running since before by a crashed or killed
+// process back in the queue. The interrupted attempt still counts.
+func (s *Store) RecoverJobs(ctx context.Context, before time.Time) (int, error) {
+	res, err := s.db.ExecContext(ctx, `UPDATE jobs SET state = ?, last_error = ?, updated_at = ? WHERE state = ? AND updated_at < ?`,
+		JobPending, "interrupted", time.Now(), JobRunning, before)
+	if err != nil {
+		return 0, err
+	}
+	n, _ := res.RowsAffected()
+	return int(n), nil
+}
+
+// RequeueFailedJobs gives failed jobs (only job id, if not 0) a fresh set of
+// attempts, due at once. Profiles that got a new job of the same kind in the
+// meantime are left alone.
+func (s *Store) RequeueFailedJobs(ctx context.Context, id int64) (int, error) {
+	now := time.Now()
+	query := `UPDATE jobs SET state = ?, attempts = 0, next_run_at = ?, updated_at = ? WHERE state = ?
+	AND NOT EXISTS (SELECT 1 FROM jobs a WHERE a.kind = jobs.kind AND a.profile_id = jobs.profile_id AND a.state IN (?, ?))`
+	args := []any{JobPending, now, now, JobFailed, JobPending, JobRunning}
+	if id != 0 {
+		query += ` AND id = ?`
+		args = append(args, id)
+	}
+	res, err := s.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	n, _ := res.RowsAffected()
+	return int(n), nil
+}
+
+// RetryFailedJobs gives failed jobs that failed for one of reasons (of kind,
+// if set) a fresh set of attempts, due at once, and counts a retry against
+// each; jobs already retried maxRetries times stay failed. Profiles that got
+// a new job of the same kind in the meantime are left alone. dryRun only
+// counts.
+func (s *Store) RetryFailedJobs(ctx context.Context, kind string, reasons []string, maxRetries int, dryRun bool) (int, error) {
+	if len(reasons) == 0 {
+		return 0, nil
+	}
+	where := `WHERE state = ? AND retries < ? AND reason IN (?` + strings.Repeat(", ?", len(reasons)-1) + `)
+	AND NOT EXISTS (SELECT 1 FROM jobs a WHERE a.kind = jobs.kind AND a.profile_id = jobs.profile_id AND a.state IN (?, ?))`
+	args := []any{JobFailed, maxRetries}
+	for _, r := range reasons {
+		args = append(args, r)
+	}
+	args = append(args, JobPending, JobRunning)
+	if kind != "" {
+		where += ` AND kind = ?`
+		args = append(args, kind)
+	}
+	if dryRun {
+		var n int
+		err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM jobs `+where, args...).Scan(&n)
+		return n, err
+	}
+	now := time.Now()
+	res, err := s.db.ExecContext(ctx, `UPDATE jobs SET state = ?, attempts = 0, retries = retries + 1, next_run_at = ?, updated_at = ? `+where,
+		append([]any{JobPending, now, now}, args...)...)
+	if err != nil {
+		return 0, err
+	}
+	n, _ := res.RowsAffected()
+	return int(n), nil
+}
+
+// CountFailedJobs tallies failed jobs by reason.
+func (s *Store) CountFailedJobs(ctx context.Context) (map[string]int, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT reason, COUNT(*) FROM jobs WHERE state = ? GROUP BY reason`, JobFailed)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := map[string]int{}
+	for rows.Next() {
+		var reason string
+		var n int
+		if err := rows.Scan(&reason, &n); err != nil {
+			return nil, err
+		}
+		out[reason] = n
+	}
+	return out, rows.Err()
+}
+
+// GetJobs returns the most recently updated jobs, optionally only those in
+// state and of kind.
+func (s *Store) GetJobs(ctx context.Context, state, kind string, limit int) ([]Job, error) {
+	query := `SELECT ` + jobColumns + ` FROM jobs WHERE 1 = 1`
+	var args []any
+	if state != "" {
+		query += ` AND state = ?`
+		args = append(args, state)
+	}
+	if kind != "" {
+		query += ` AND kind = ?`
+		args = append(args, kind)
+	}
+	rows, err := s.db.QueryContext(ctx, query+` ORDER BY updated_at DESC, id DESC LIMIT ?`, append(args, limit)...)
+	if err != nil {
+		return nil, err
+	}
+	return scanJobs(rows)
+}
+
+// JobCount is the number of jobs of one kind in one state.
+type JobCount struct {
+	Kind  string
+	State string
+	Jobs  int
+}
+
+// CountJobs tallies the queue by kind and state.
+func (s *Store) CountJobs(ctx context.Context) ([]JobCount, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT kind, state, COUNT(*) FROM jobs GROUP BY kind, state ORDER BY kind, state`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []JobCount
+	for rows.Next() {
+		var c JobCount
+		if err := rows.Scan(&c.Kind, &c.State, &c.Jobs); err != nil {
+			return nil, err
+		}
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
+
+// GetProfile returns the profile with id in the same shape as the queue
+// queries, plus its status, or nil if it is gone.
+func (s *Store) GetProfile(ctx context.Context, id int64) (*models.Profile, error) {
+	var p models.Profile
+	var name, headline, company, location sql.NullString
+	err := s.db.QueryRowContext(ctx, `SELECT id, linkedin_url, name, headline, company, location, source, member_urn, degree, open_profile, status
+	FROM profiles WHERE id = ?`, id).Scan(&p.ID, &p.LinkedInURL, &name, &headline, &company, &location, &p.Source, &p.MemberURN, &p.Degree, &p.OpenProfile, &p.Status)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	p.Name, p.Headline, p.Company, p.Location = name.String, headline.String, company.String, location.String
+	return &p, nil
+}
+
+// SequenceStep returns how many follow-up steps were sent to a profile.
+func (s *Store) SequenceStep(ctx context.Context, profileID int64) (int, error) {
+	var n int
+	err := s.db.QueryRowContext(ctx, `SELECT COALESCE(MAX(steps_sent), 0) FROM message_sequences WHERE profile_id = ?`, profileID).Scan(&n)
+	return n, err
+}
+
+// withoutJob leaves out profiles that have a pending, running or failed job
+// of kind; idCol names the profile id column of the outer query.
+func withoutJob(idCol, kind string) (string, []any) {
+	if kind == "" {
+		return "", nil
+	}
+	return ` AND NOT EXISTS (SELECT 1 FROM jobs j WHERE j.profile_id = ` + idCol + ` AND j.kind = ? AND j.state IN (?, ?, ?))`,
+		[]any{kind, JobPending, JobRunning, JobFailed}
+}
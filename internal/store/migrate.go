@@ -0,0 +1,334 @@
+package store
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/example/linkedbot/internal/logging"
+	"github.com/example/linkedbot/internal/models"
+)
+
+// Schema changes are numbered SQL files in migrations/<driver>/:
+// NNNN_name.up.sql applies one, NNNN_name.down.sql (optional) reverts it.
+// Every driver gets the same versions. Applied versions are recorded in
+// schema_migrations. Never edit a released migration; add the next number
+// instead.
+//
+//go:embed migrations/*/*.sql
+var migrationFiles embed.FS
+
+var migrationNameRe = regexp.MustCompile(`^(\d{4})_(\w+)\.(up|down)\.sql$`)
+
+type migration struct {
+	Version  int
+	Name     string
+	up, down string
+}
+
+// MigrationState is a known migration and when it was applied, if it was.
+type MigrationState struct {
+	Version    int
+	Name       string
+	AppliedAt  *time.Time
+	Reversible bool
+}
+
+func loadMigrations(d dialect) ([]migration, error) {
+	dir := "migrations/" + d.migrationsDir()
+	entries, err := fs.ReadDir(migrationFiles, dir)
+	if err != nil {
+		return nil, err
+	}
+	byVersion := map[int]*migration{}
+	for _, e := range entries {
+		m := migrationNameRe.FindStringSubmatch(e.Name())
+		if m == nil {
+			return nil, fmt.Errorf("unexpected migration file %s", e.Name())
+		}
+		b, err := migrationFiles.ReadFile(dir + "/" + e.Name())
+		if err != nil {
+			return nil, err
+		}
+		v, _ := strconv.Atoi(m[1])
+		mig, ok := byVersion[v]
+		if !ok {
+			mig = &migration{Version: v, Name: m[2]}
+			byVersion[v] = mig
+		} else if mig.Name != m[2] {
+			return nil, fmt.Errorf("migration %04d has two names: %s and %s", v, mig.Name, m[2])
+		}
+		if m[3] == "up" {
+			mig.up = string(b)
+		} else {
+			mig.down = string(b)
+		}
+	}
+	out := make([]migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.up == "" {
+			return nil, fmt.Errorf("migration %04d_%s has no up file", m.Version, m.Name)
+		}
+		out = append(out, *m)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Version < out[j].Version })
+	return out, nil
+}
+
+// Migrate applies every migration newer than the database, each in its own
+// transaction. A database from before versioning is first brought up to the
+// baseline by upgradeLegacy.
+func (s *Store) Migrate(ctx context.Context) error {
+	migs, err := loadMigrations(s.db.d)
+	if err != nil {
+		return err
+	}
+	if _, err := s.db.ExecContext(ctx, s.db.d.migrationsTable()); err != nil {
+		return err
+	}
+	current, err := s.schemaVersion(ctx)
+	if err != nil {
+		return err
+	}
+	// Only SQLite databases predate versioning
+	if _, ok := s.db.d.(sqliteDialect); ok && current == 0 {
+		legacy, err := s.tableExists(ctx, "profiles")
+		if err != nil {
+			return err
+		}
+		if legacy {
+			if err := s.upgradeLegacy(ctx, migs[0]); err != nil {
+				return fmt.Errorf("upgrade pre-migration database: %w", err)
+			}
+			current = migs[0].Version
+		}
+	}
+	for _, m := range migs {
+		if m.Version <= current {
+			continue
+		}
+		if err := s.apply(ctx, m.Version, m.Name, m.up, true); err != nil {
+			return fmt.Errorf("migration %04d_%s: %w", m.Version, m.Name, err)
+		}
+		if m.Version == statusMachineVersion {
+			s.reportContradictoryFlags(ctx)
+		}
+	}
+	// The account's age for warm-up counts from its first stored profile, or
+	// from now for a new database
+	if _, err := s.db.ExecContext(ctx, `INSERT INTO account (id, started_at)
+		SELECT 1, COALESCE((SELECT MIN(created_at) FROM profiles), ?) WHERE true ON CONFLICT DO NOTHING`, time.Now()); err != nil {
+		return fmt.Errorf("record account start: %w", err)
+	}
+	return nil
+}
+
+// statusMachineVersion is 0002_status_machine, which derives each profile's
+// status from the legacy flags.
+const statusMachineVersion = 2
+
+// contradictoryReason prefixes the reason 0002_status_machine gives a
+// derived transition when the profile's flags contradicted each other.
+const contradictoryReason = "derived from contradictory flags: "
+
+// ContradictoryFlags counts the profiles whose legacy flags contradicted
+// each other when 0002_status_machine derived their status, by
+// contradiction. Each was moved to the furthest step its flags claimed.
+func (s *Store) ContradictoryFlags(ctx context.Context) (map[string]int, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT reason, COUNT(*) FROM status_transitions WHERE from_status = '' AND reason LIKE ? GROUP BY reason`, contradictoryReason+"%")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := map[string]int{}
+	for rows.Next() {
+		var reason string
+		var n int
+		if err := rows.Scan(&reason, &n); err != nil {
+			return nil, err
+		}
+		out[strings.TrimPrefix(reason, contradictoryReason)] = n
+	}
+	return out, rows.Err()
+}
+
+// reportContradictoryFlags warns about the rows 0002_status_machine had to
+// resolve, right after it ran, so they aren't rewritten silently.
+func (s *Store) reportContradictoryFlags(ctx context.Context) {
+	log := logging.New("info").With("module", "store")
+	counts, err := s.ContradictoryFlags(ctx)
+	if err != nil {
+		log.Warn("failed to count contradictory legacy flags", "err", err)
+		return
+	}
+	for reason, n := range counts {
+		log.Warn("profiles with contradictory flags moved to their furthest step", "contradiction", reason, "profiles", n,
+			"hint", "linkedbot profiles history --url URL shows the derived transition")
+	}
+}
+
+// MigrateDown reverts applied migrations newer than version, newest first.
+// It stops at the first one without a down file.
+func (s *Store) MigrateDown(ctx context.Context, version int) error {
+	migs, err := loadMigrations(s.db.d)
+	if err != nil {
+		return err
+	}
+	current, err := s.schemaVersion(ctx)
+	if err != nil {
+		return err
+	}
+	for i := len(migs) - 1; i >= 0; i-- {
+		m := migs[i]
+		if m.Version <= version || m.Version > current {
+			continue
+		}
+		if m.down == "" {
+			return fmt.Errorf("migration %04d_%s cannot be reverted", m.Version, m.Name)
+		}
+		if err := s.apply(ctx, m.Version, m.Name, m.down, false); err != nil {
+			return fmt.Errorf("revert %04d_%s: %w", m.Version, m.Name, err)
+		}
+	}
+	return nil
+}
+
+// MigrationStatus lists every known migration with its applied time.
+func (s *Store) MigrationStatus(ctx context.Context) ([]MigrationState, error) {
+	migs, err := loadMigrations(s.db.d)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := s.db.QueryContext(ctx, `SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	applied := map[int]time.Time{}
+	for rows.Next() {
+		var v int
+		var at time.Time
+		if err := rows.Scan(&v, &at); err != nil {
+			return nil, err
+		}
+		applied[v] = at
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	out := make([]MigrationState, 0, len(migs))
+	for _, m := range migs {
+		st := MigrationState{Version: m.Version, Name: m.Name, Reversible: m.down != ""}
+		if at, ok := applied[m.Version]; ok {
+			st.AppliedAt = &at
+		}
+		out = append(out, st)
+	}
+	return out, nil
+}
+
+func (s *Store) schemaVersion(ctx context.Context) (int, error) {
+	var v int
+	err := s.db.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&v)
+	return v, err
+}
+
+// apply runs a migration script and records (up) or forgets (down) its
+// version in one transaction.
+func (s *Store) apply(ctx context.Context, version int, name, script string, up bool) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		return err
+	}
+	if up {
+		_, err = tx.ExecContext(ctx, `INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)`, version, name, time.Now())
+	} else {
+		_, err = tx.ExecContext(ctx, `DELETE FROM schema_migrations WHERE version = ?`, version)
+	}
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *Store) tableExists(ctx context.Context, table string) (bool, error) {
+	var n int
+	err := s.db.QueryRowContext(ctx, s.db.d.tableExistsQuery(), table).Scan(&n)
+	return n > 0, err
+}
+
+// legacyColumns were added to existing tables by earlier versions as
+// features came in; the baseline creates them directly.
+var legacyColumns = []struct{ table, column, decl string }{
+	{"profiles", "source", `TEXT NOT NULL DEFAULT ''`},
+	{"profiles", "member_urn", `TEXT NOT NULL DEFAULT ''`},
+	{"profiles", "replied", `INTEGER DEFAULT 0`},
+	{"profiles", "replied_at", `DATETIME`},
+	{"profiles", "withdrawn", `INTEGER DEFAULT 0`},
+	{"profiles", "withdrawn_at", `DATETIME`},
+	{"profiles", "already_connected", `INTEGER DEFAULT 0`},
+	{"profiles", "already_connected_at", `DATETIME`},
+	{"profiles", "degree", `TEXT NOT NULL DEFAULT ''`},
+	{"profiles", "open_profile", `INTEGER NOT NULL DEFAULT 0`},
+	{"profiles", "requires_email", `INTEGER NOT NULL DEFAULT 0`},
+	{"profiles", "requires_email_at", `DATETIME`},
+	{"profiles", "email", `TEXT NOT NULL DEFAULT ''`},
+	{"profiles", "engaged_at", `DATETIME`},
+	{"profiles", "viewed_at", `DATETIME`},
+	{"profiles", "endorsed_at", `DATETIME`},
+	{"profiles", "endorsed_skills", `TEXT NOT NULL DEFAULT ''`},
+	// Filled in from the flags by 0002_status_machine
+	{"profiles", "status", `TEXT NOT NULL DEFAULT 'discovered'`},
+	{"run_logs", "ok", `INTEGER NOT NULL DEFAULT 0`},
+	{"run_logs", "processed", `INTEGER NOT NULL DEFAULT 0`},
+	{"run_logs", "succeeded", `INTEGER NOT NULL DEFAULT 0`},
+	{"run_logs", "failed", `INTEGER NOT NULL DEFAULT 0`},
+}
+
+// upgradeLegacy brings a database created before versioned migrations to
+// the baseline schema: missing tables and columns are added and the data
+// older versions backfilled on every start is filled in once. It then
+// records the baseline as applied.
+func (s *Store) upgradeLegacy(ctx context.Context, baseline migration) error {
+	if _, err := s.db.ExecContext(ctx, baseline.up); err != nil {
+		return err
+	}
+	for _, c := range legacyColumns {
+		if _, err := s.addColumnIfMissing(ctx, c.table, c.column, c.decl); err != nil {
+			return err
+		}
+	}
+	// Follow-ups sent before sequences existed count as the first step
+	if _, err := s.db.ExecContext(ctx, `INSERT OR IGNORE INTO message_sequences (profile_id, steps_sent, last_sent_at)
+		SELECT id, 1, message_sent_at FROM profiles WHERE message_sent = 1`); err != nil {
+		return fmt.Errorf("backfill message_sequences: %w", err)
+	}
+	// The rate limiter's weekly budget starts from the last week of history
+	// rather than from zero
+	var events int
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM rate_events`).Scan(&events); err != nil {
+		return err
+	}
+	if events == 0 {
+		weekAgo := time.Now().AddDate(0, 0, -7)
+		if _, err := s.db.ExecContext(ctx, `INSERT INTO rate_events (kind, created_at)
+			SELECT 'connection', connection_sent_at FROM profiles WHERE connection_sent = 1 AND connection_sent_at >= ?
+			UNION ALL
+			SELECT 'message', created_at FROM message_logs WHERE type = ? AND created_at >= ?`,
+			weekAgo, string(models.MessageTypeFollowUp), weekAgo); err != nil {
+			return fmt.Errorf("backfill rate_events: %w", err)
+		}
+	}
+	_, err := s.db.ExecContext(ctx, `INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)`, baseline.Version, baseline.Name, time.Now())
+	return err
+}
@@ -0,0 +1,139 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// legacyProfiles is the profiles table of the last version before
+// versioned migrations: pipeline flags, no status.
+const legacyProfiles = `CREATE TABLE profiles (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	linkedin_url TEXT NOT NULL UNIQUE,
+	name TEXT,
+	headline TEXT,
+	company TEXT,
+	location TEXT,
+	connection_sent INTEGER DEFAULT 0,
+	connection_sent_at DATETIME,
+	connection_accepted INTEGER DEFAULT 0,
+	connection_checked_at DATETIME,
+	message_sent INTEGER DEFAULT 0,
+	message_sent_at DATETIME,
+	replied INTEGER DEFAULT 0,
+	replied_at DATETIME,
+	withdrawn INTEGER DEFAULT 0,
+	withdrawn_at DATETIME,
+	already_connected INTEGER DEFAULT 0,
+	already_connected_at DATETIME,
+	created_at DATETIME NOT NULL,
+	updated_at DATETIME NOT NULL
+)`
+
+type legacyFlags struct {
+	sent, accepted, messaged, replied, withdrawn, connected bool
+}
+
+func TestStatusMachineMigrationFromFlags(t *testing.T) {
+	cases := []struct {
+		name   string
+		flags  legacyFlags
+		status string
+		// contradiction is the reason suffix for inconsistent flags, "" when
+		// they agree
+		contradiction string
+	}{
+		// Every sent/accepted/messaged combination
+		{"nothing", legacyFlags{}, "discovered", ""},
+		{"sent", legacyFlags{sent: true}, "invited", ""},
+		{"accepted", legacyFlags{sent: true, accepted: true}, "accepted", ""},
+		{"messaged", legacyFlags{sent: true, accepted: true, messaged: true}, "messaged", ""},
+		{"accepted never sent", legacyFlags{accepted: true}, "accepted", "accepted but never sent"},
+		{"messaged never sent", legacyFlags{accepted: true, messaged: true}, "messaged", "accepted but never sent"},
+		{"messaged never accepted", legacyFlags{sent: true, messaged: true}, "messaged", "messaged but never accepted"},
+		{"messaged only", legacyFlags{messaged: true}, "messaged", "messaged but never accepted"},
+		// The later flags
+		{"replied", legacyFlags{sent: true, accepted: true, messaged: true, replied: true}, "replied", ""},
+		{"replied never messaged", legacyFlags{sent: true, accepted: true, replied: true}, "replied", "replied but never messaged"},
+		{"withdrawn", legacyFlags{sent: true, withdrawn: true}, "withdrawn", ""},
+		{"withdrawn but accepted", legacyFlags{sent: true, accepted: true, withdrawn: true}, "accepted", "withdrawn but accepted"},
+		{"already connected", legacyFlags{connected: true}, "connected", ""},
+		{"already connected and messaged", legacyFlags{connected: true, messaged: true}, "connected", ""},
+	}
+
+	ctx := context.Background()
+	st, err := Open("sqlite", filepath.Join(t.TempDir(), "legacy.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(st.Close)
+	if _, err := st.db.ExecContext(ctx, legacyProfiles); err != nil {
+		t.Fatal(err)
+	}
+	b := func(v bool) int {
+		if v {
+			return 1
+		}
+		return 0
+	}
+	now := time.Now()
+	for i, c := range cases {
+		f := c.flags
+		if _, err := st.db.ExecContext(ctx, `INSERT INTO profiles (linkedin_url, name, connection_sent, connection_accepted, message_sent, replied, withdrawn, already_connected, created_at, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`, fmt.Sprintf("https://www.linkedin.com/in/p%d", i), c.name,
+			b(f.sent), b(f.accepted), b(f.messaged), b(f.replied), b(f.withdrawn), b(f.connected), now, now); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// upgradeLegacy brings the table to the baseline, 0002 derives status
+	if err := st.Migrate(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	wantCounts := map[string]int{}
+	for i, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var id int64
+			var status string
+			if err := st.db.QueryRowContext(ctx, `SELECT id, status FROM profiles WHERE linkedin_url = ?`, fmt.Sprintf("https://www.linkedin.com/in/p%d", i)).Scan(&id, &status); err != nil {
+				t.Fatal(err)
+			}
+			if status != c.status {
+				t.Errorf("status %q, want %q", status, c.status)
+			}
+			history, err := st.GetStatusTransitions(ctx, id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if c.status == "discovered" {
+				if len(history) != 0 {
+					t.Errorf("discovered profile got transitions %+v", history)
+				}
+				return
+			}
+			want := "derived from flags"
+			if c.contradiction != "" {
+				want = contradictoryReason + c.contradiction
+			}
+			if len(history) != 1 || history[0].Reason != want {
+				t.Errorf("transitions %+v, want one with reason %q", history, want)
+			}
+		})
+		if c.contradiction != "" {
+			wantCounts[c.contradiction]++
+		}
+	}
+
+	counts, err := st.ContradictoryFlags(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(counts, wantCounts) {
+		t.Errorf("ContradictoryFlags = %v, want %v", counts, wantCounts)
+	}
+}
@@ -0,0 +1,16 @@
+-- Drops everything: only useful to start over from an empty database.
+DROP TABLE IF EXISTS nurture_events;
+DROP TABLE IF EXISTS engagements;
+DROP TABLE IF EXISTS cooldown;
+DROP TABLE IF EXISTS account;
+DROP TABLE IF EXISTS exclusions;
+DROP TABLE IF EXISTS rate_events;
+DROP TABLE IF EXISTS run_logs;
+DROP TABLE IF EXISTS action_logs;
+DROP TABLE IF EXISTS run_progress;
+DROP TABLE IF EXISTS profile_education;
+DROP TABLE IF EXISTS profile_experience;
+DROP TABLE IF EXISTS profile_details;
+DROP TABLE IF EXISTS message_sequences;
+DROP TABLE IF EXISTS message_logs;
+DROP TABLE IF EXISTS profiles;
@@ -0,0 +1,154 @@
+-- Postgres version of the sqlite baseline: ids are BIGSERIAL and times
+-- TIMESTAMPTZ. Flags stay INTEGER 0/1 so queries are shared between drivers.
+CREATE TABLE IF NOT EXISTS profiles (
+	id BIGSERIAL PRIMARY KEY,
+	linkedin_url TEXT NOT NULL UNIQUE,
+	name TEXT,
+	headline TEXT,
+	company TEXT,
+	location TEXT,
+	source TEXT NOT NULL DEFAULT '',
+	member_urn TEXT NOT NULL DEFAULT '',
+	connection_sent INTEGER DEFAULT 0,
+	connection_sent_at TIMESTAMPTZ,
+	connection_accepted INTEGER DEFAULT 0,
+	connection_checked_at TIMESTAMPTZ,
+	message_sent INTEGER DEFAULT 0,
+	message_sent_at TIMESTAMPTZ,
+	replied INTEGER DEFAULT 0,
+	replied_at TIMESTAMPTZ,
+	withdrawn INTEGER DEFAULT 0,
+	withdrawn_at TIMESTAMPTZ,
+	already_connected INTEGER DEFAULT 0,
+	already_connected_at TIMESTAMPTZ,
+	degree TEXT NOT NULL DEFAULT '',
+	open_profile INTEGER NOT NULL DEFAULT 0,
+	requires_email INTEGER NOT NULL DEFAULT 0,
+	requires_email_at TIMESTAMPTZ,
+	email TEXT NOT NULL DEFAULT '',
+	engaged_at TIMESTAMPTZ,
+	viewed_at TIMESTAMPTZ,
+	endorsed_at TIMESTAMPTZ,
+	endorsed_skills TEXT NOT NULL DEFAULT '',
+	status TEXT NOT NULL DEFAULT 'discovered',
+	created_at TIMESTAMPTZ NOT NULL,
+	updated_at TIMESTAMPTZ NOT NULL
+);
+CREATE TABLE IF NOT EXISTS message_logs (
+	id BIGSERIAL PRIMARY KEY,
+	profile_id BIGINT NOT NULL,
+	type TEXT NOT NULL,
+	content TEXT NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL,
+	FOREIGN KEY(profile_id) REFERENCES profiles(id)
+);
+CREATE TABLE IF NOT EXISTS message_sequences (
+	profile_id BIGINT PRIMARY KEY,
+	steps_sent INTEGER NOT NULL DEFAULT 0,
+	last_sent_at TIMESTAMPTZ,
+	FOREIGN KEY(profile_id) REFERENCES profiles(id)
+);
+CREATE TABLE IF NOT EXISTS profile_details (
+	profile_id BIGINT PRIMARY KEY,
+	about TEXT NOT NULL DEFAULT '',
+	skills TEXT NOT NULL DEFAULT '',
+	mutual_connections INTEGER NOT NULL DEFAULT 0,
+	enriched_at TIMESTAMPTZ NOT NULL,
+	FOREIGN KEY(profile_id) REFERENCES profiles(id)
+);
+CREATE TABLE IF NOT EXISTS profile_experience (
+	id BIGSERIAL PRIMARY KEY,
+	profile_id BIGINT NOT NULL,
+	position INTEGER NOT NULL,
+	title TEXT NOT NULL,
+	company TEXT NOT NULL,
+	dates TEXT NOT NULL,
+	FOREIGN KEY(profile_id) REFERENCES profiles(id)
+);
+CREATE TABLE IF NOT EXISTS profile_education (
+	id BIGSERIAL PRIMARY KEY,
+	profile_id BIGINT NOT NULL,
+	position INTEGER NOT NULL,
+	school TEXT NOT NULL,
+	degree TEXT NOT NULL,
+	dates TEXT NOT NULL,
+	FOREIGN KEY(profile_id) REFERENCES profiles(id)
+);
+CREATE TABLE IF NOT EXISTS run_progress (
+	command TEXT PRIMARY KEY,
+	batch INTEGER NOT NULL,
+	processed INTEGER NOT NULL DEFAULT 0,
+	sent INTEGER NOT NULL DEFAULT 0,
+	failed INTEGER NOT NULL DEFAULT 0,
+	last_profile_id BIGINT NOT NULL DEFAULT 0,
+	cursor TEXT NOT NULL DEFAULT '',
+	updated_at TIMESTAMPTZ NOT NULL
+);
+CREATE TABLE IF NOT EXISTS action_logs (
+	id BIGSERIAL PRIMARY KEY,
+	profile_id BIGINT,
+	profile_url TEXT NOT NULL DEFAULT '',
+	action TEXT NOT NULL,
+	target TEXT NOT NULL DEFAULT '',
+	detail TEXT NOT NULL DEFAULT '',
+	before_screenshot TEXT NOT NULL DEFAULT '',
+	after_screenshot TEXT NOT NULL DEFAULT '',
+	error TEXT NOT NULL DEFAULT '',
+	created_at TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_action_logs_profile_url ON action_logs(profile_url);
+CREATE TABLE IF NOT EXISTS run_logs (
+	id BIGSERIAL PRIMARY KEY,
+	run_type TEXT NOT NULL,
+	started_at TIMESTAMPTZ NOT NULL,
+	ended_at TIMESTAMPTZ NOT NULL,
+	ok INTEGER NOT NULL DEFAULT 0,
+	processed INTEGER NOT NULL DEFAULT 0,
+	succeeded INTEGER NOT NULL DEFAULT 0,
+	failed INTEGER NOT NULL DEFAULT 0,
+	summary TEXT
+);
+CREATE TABLE IF NOT EXISTS rate_events (
+	id BIGSERIAL PRIMARY KEY,
+	kind TEXT NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_rate_events_kind_created ON rate_events(kind, created_at);
+CREATE TABLE IF NOT EXISTS exclusions (
+	id BIGSERIAL PRIMARY KEY,
+	kind TEXT NOT NULL,
+	value TEXT NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL,
+	UNIQUE(kind, value)
+);
+CREATE TABLE IF NOT EXISTS account (
+	id INTEGER PRIMARY KEY CHECK (id = 1),
+	started_at TIMESTAMPTZ NOT NULL
+);
+CREATE TABLE IF NOT EXISTS cooldown (
+	id INTEGER PRIMARY KEY CHECK (id = 1),
+	kind TEXT NOT NULL,
+	url TEXT NOT NULL DEFAULT '',
+	detected_at TIMESTAMPTZ NOT NULL,
+	until TIMESTAMPTZ
+);
+CREATE TABLE IF NOT EXISTS engagements (
+	id BIGSERIAL PRIMARY KEY,
+	profile_id BIGINT NOT NULL,
+	post_url TEXT NOT NULL DEFAULT '',
+	liked INTEGER NOT NULL DEFAULT 0,
+	comment TEXT NOT NULL DEFAULT '',
+	created_at TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_engagements_profile ON engagements(profile_id);
+CREATE TABLE IF NOT EXISTS nurture_events (
+	id BIGSERIAL PRIMARY KEY,
+	profile_id BIGINT NOT NULL,
+	kind TEXT NOT NULL,
+	detail TEXT NOT NULL DEFAULT '',
+	year INTEGER NOT NULL,
+	message TEXT NOT NULL DEFAULT '',
+	sent_at TIMESTAMPTZ,
+	created_at TIMESTAMPTZ NOT NULL,
+	UNIQUE(profile_id, kind, year)
+);
@@ -0,0 +1,26 @@
+-- Restores the boolean flags from the timestamps. Closed and do-not-contact
+-- profiles fall back to the status their flags give; the transitions log is
+-- lost.
+ALTER TABLE profiles ADD COLUMN connection_sent INTEGER DEFAULT 0;
+ALTER TABLE profiles ADD COLUMN connection_accepted INTEGER DEFAULT 0;
+ALTER TABLE profiles ADD COLUMN message_sent INTEGER DEFAULT 0;
+ALTER TABLE profiles ADD COLUMN replied INTEGER DEFAULT 0;
+ALTER TABLE profiles ADD COLUMN withdrawn INTEGER DEFAULT 0;
+ALTER TABLE profiles ADD COLUMN already_connected INTEGER DEFAULT 0;
+UPDATE profiles SET
+	connection_sent = CASE WHEN connection_sent_at IS NULL THEN 0 ELSE 1 END,
+	connection_accepted = CASE WHEN connection_checked_at IS NULL THEN 0 ELSE 1 END,
+	message_sent = CASE WHEN message_sent_at IS NULL THEN 0 ELSE 1 END,
+	replied = CASE WHEN replied_at IS NULL THEN 0 ELSE 1 END,
+	withdrawn = CASE WHEN withdrawn_at IS NULL THEN 0 ELSE 1 END,
+	already_connected = CASE WHEN already_connected_at IS NULL THEN 0 ELSE 1 END;
+UPDATE profiles SET status = CASE
+	WHEN already_connected = 1 THEN 'connected'
+	WHEN replied = 1 THEN 'responded'
+	WHEN message_sent = 1 THEN 'messaged'
+	WHEN connection_accepted = 1 THEN 'accepted'
+	WHEN withdrawn = 1 THEN 'withdrawn'
+	WHEN connection_sent = 1 THEN 'invited'
+	ELSE 'discovered'
+END;
+DROP TABLE status_transitions;
@@ -0,0 +1,60 @@
+-- status becomes the only record of where a profile is in the pipeline and
+-- the boolean flags it was derived from are dropped. The *_at timestamps
+-- stay and record when each step happened; every status change is logged in
+-- status_transitions.
+--
+-- This conversion replaces the recompute-status command: once the flags are
+-- gone there is nothing left to re-derive status from, so it is done here,
+-- once, with the same precedence. Contradictory flags resolve to the
+-- furthest step, e.g. accepted but never sent becomes accepted, and the
+-- contradiction is kept as the reason of the derived transition.
+CREATE TABLE status_transitions (
+	id BIGSERIAL PRIMARY KEY,
+	profile_id BIGINT NOT NULL,
+	from_status TEXT NOT NULL,
+	to_status TEXT NOT NULL,
+	reason TEXT NOT NULL DEFAULT '',
+	created_at TIMESTAMPTZ NOT NULL,
+	FOREIGN KEY(profile_id) REFERENCES profiles(id)
+);
+CREATE INDEX idx_status_transitions_profile ON status_transitions(profile_id);
+
+-- Queries read the timestamps from now on, so every set flag needs one
+UPDATE profiles SET connection_sent_at = COALESCE(connection_sent_at, updated_at) WHERE connection_sent = 1;
+UPDATE profiles SET connection_checked_at = NULL WHERE COALESCE(connection_accepted, 0) = 0;
+UPDATE profiles SET connection_checked_at = COALESCE(connection_checked_at, updated_at) WHERE connection_accepted = 1;
+UPDATE profiles SET message_sent_at = COALESCE(message_sent_at, updated_at) WHERE message_sent = 1;
+UPDATE profiles SET replied_at = COALESCE(replied_at, updated_at) WHERE replied = 1;
+UPDATE profiles SET withdrawn_at = COALESCE(withdrawn_at, updated_at) WHERE withdrawn = 1;
+UPDATE profiles SET already_connected_at = COALESCE(already_connected_at, updated_at) WHERE already_connected = 1;
+
+UPDATE profiles SET status = CASE
+	WHEN already_connected = 1 THEN 'connected'
+	WHEN replied = 1 THEN 'replied'
+	WHEN message_sent = 1 THEN 'messaged'
+	WHEN connection_accepted = 1 THEN 'accepted'
+	WHEN withdrawn = 1 THEN 'withdrawn'
+	WHEN connection_sent = 1 THEN 'invited'
+	ELSE 'discovered'
+END;
+-- Rows whose flags contradict each other keep the reason in their
+-- transition, so they can be found and counted afterwards
+INSERT INTO status_transitions (profile_id, from_status, to_status, reason, created_at)
+	SELECT id, '', status, CASE
+		WHEN COALESCE(connection_accepted, 0) = 1 AND COALESCE(connection_sent, 0) = 0 AND COALESCE(already_connected, 0) = 0
+			THEN 'derived from contradictory flags: accepted but never sent'
+		WHEN COALESCE(message_sent, 0) = 1 AND COALESCE(connection_accepted, 0) = 0 AND COALESCE(already_connected, 0) = 0
+			THEN 'derived from contradictory flags: messaged but never accepted'
+		WHEN COALESCE(replied, 0) = 1 AND COALESCE(message_sent, 0) = 0
+			THEN 'derived from contradictory flags: replied but never messaged'
+		WHEN COALESCE(withdrawn, 0) = 1 AND COALESCE(connection_accepted, 0) = 1
+			THEN 'derived from contradictory flags: withdrawn but accepted'
+		ELSE 'derived from flags'
+	END, updated_at FROM profiles WHERE status <> 'discovered';
+
+ALTER TABLE profiles DROP COLUMN connection_sent;
+ALTER TABLE profiles DROP COLUMN connection_accepted;
+ALTER TABLE profiles DROP COLUMN message_sent;
+ALTER TABLE profiles DROP COLUMN replied;
+ALTER TABLE profiles DROP COLUMN withdrawn;
+ALTER TABLE profiles DROP COLUMN already_connected;
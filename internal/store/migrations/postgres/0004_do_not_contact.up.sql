@@ -0,0 +1,17 @@
+-- Compliance list of people who must never be contacted (unsubscribe and
+-- GDPR requests), keyed by canonical profile URL so it also covers profiles
+-- that are not stored yet. suppressions records every action refused
+-- because of it.
+CREATE TABLE do_not_contact (
+	id BIGSERIAL PRIMARY KEY,
+	linkedin_url TEXT NOT NULL UNIQUE,
+	reason TEXT NOT NULL DEFAULT '',
+	created_at TIMESTAMPTZ NOT NULL
+);
+CREATE TABLE suppressions (
+	id BIGSERIAL PRIMARY KEY,
+	linkedin_url TEXT NOT NULL,
+	action TEXT NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX idx_suppressions_created ON suppressions(created_at);
@@ -0,0 +1,23 @@
+-- Persistent job queue for the send loops: one row per action to take on a
+-- profile, picked up by the worker once next_run_at has passed and retried
+-- with backoff until max_attempts. At most one job per kind and profile is
+-- pending or running at a time.
+CREATE TABLE jobs (
+	id BIGSERIAL PRIMARY KEY,
+	kind TEXT NOT NULL,
+	profile_id BIGINT NOT NULL,
+	payload TEXT NOT NULL DEFAULT '',
+	state TEXT NOT NULL DEFAULT 'pending',
+	attempts INTEGER NOT NULL DEFAULT 0,
+	max_attempts INTEGER NOT NULL,
+	next_run_at TIMESTAMPTZ NOT NULL,
+	last_error TEXT NOT NULL DEFAULT '',
+	created_at TIMESTAMPTZ NOT NULL,
+	updated_at TIMESTAMPTZ NOT NULL,
+	FOREIGN KEY(profile_id) REFERENCES profiles(id)
+);
+CREATE INDEX idx_jobs_due ON jobs(state, next_run_at);
+CREATE INDEX idx_jobs_profile ON jobs(profile_id);
+CREATE UNIQUE INDEX idx_jobs_active ON jobs(kind, profile_id) WHERE state IN ('pending', 'running');
+-- The send loops no longer keep batch progress; jobs replace it
+DELETE FROM run_progress WHERE command IN ('send-connections', 'send-messages');
@@ -0,0 +1,13 @@
+-- Outbound event stream for automation tools (events poll, /api/events):
+-- one row per profile discovered, invite sent, invite accepted and reply
+-- received. data is a JSON object with the details of the type. Ids only
+-- grow, so consumers poll with the last id they saw.
+CREATE TABLE events (
+	id BIGSERIAL PRIMARY KEY,
+	type TEXT NOT NULL,
+	profile_id BIGINT NOT NULL,
+	data TEXT NOT NULL DEFAULT '{}',
+	created_at TIMESTAMPTZ NOT NULL,
+	FOREIGN KEY(profile_id) REFERENCES profiles(id)
+);
+CREATE INDEX idx_events_profile ON events(profile_id);
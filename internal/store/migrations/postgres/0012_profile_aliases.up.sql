@@ -0,0 +1,10 @@
+-- Former links of profiles: the slug a member had before changing it, or
+-- the URL of a duplicate row merged by dedupe. Profiles written under an
+-- alias go to the row it points at.
+CREATE TABLE profile_aliases (
+	linkedin_url TEXT PRIMARY KEY,
+	profile_id BIGINT NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL,
+	FOREIGN KEY(profile_id) REFERENCES profiles(id)
+);
+CREATE INDEX idx_profile_aliases_profile ON profile_aliases(profile_id);
@@ -0,0 +1,2 @@
+ALTER TABLE jobs DROP COLUMN retries;
+ALTER TABLE jobs DROP COLUMN reason;
@@ -0,0 +1,155 @@
+-- Schema as of the introduction of versioned migrations. Databases created
+-- before that are brought up to it in Go (see upgradeLegacy) and marked as
+-- being at this version.
+CREATE TABLE IF NOT EXISTS profiles (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	linkedin_url TEXT NOT NULL UNIQUE,
+	name TEXT,
+	headline TEXT,
+	company TEXT,
+	location TEXT,
+	source TEXT NOT NULL DEFAULT '',
+	member_urn TEXT NOT NULL DEFAULT '',
+	connection_sent INTEGER DEFAULT 0,
+	connection_sent_at DATETIME,
+	connection_accepted INTEGER DEFAULT 0,
+	connection_checked_at DATETIME,
+	message_sent INTEGER DEFAULT 0,
+	message_sent_at DATETIME,
+	replied INTEGER DEFAULT 0,
+	replied_at DATETIME,
+	withdrawn INTEGER DEFAULT 0,
+	withdrawn_at DATETIME,
+	already_connected INTEGER DEFAULT 0,
+	already_connected_at DATETIME,
+	degree TEXT NOT NULL DEFAULT '',
+	open_profile INTEGER NOT NULL DEFAULT 0,
+	requires_email INTEGER NOT NULL DEFAULT 0,
+	requires_email_at DATETIME,
+	email TEXT NOT NULL DEFAULT '',
+	engaged_at DATETIME,
+	viewed_at DATETIME,
+	endorsed_at DATETIME,
+	endorsed_skills TEXT NOT NULL DEFAULT '',
+	status TEXT NOT NULL DEFAULT 'discovered',
+	created_at DATETIME NOT NULL,
+	updated_at DATETIME NOT NULL
+);
+CREATE TABLE IF NOT EXISTS message_logs (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	profile_id INTEGER NOT NULL,
+	type TEXT NOT NULL,
+	content TEXT NOT NULL,
+	created_at DATETIME NOT NULL,
+	FOREIGN KEY(profile_id) REFERENCES profiles(id)
+);
+CREATE TABLE IF NOT EXISTS message_sequences (
+	profile_id INTEGER PRIMARY KEY,
+	steps_sent INTEGER NOT NULL DEFAULT 0,
+	last_sent_at DATETIME,
+	FOREIGN KEY(profile_id) REFERENCES profiles(id)
+);
+CREATE TABLE IF NOT EXISTS profile_details (
+	profile_id INTEGER PRIMARY KEY,
+	about TEXT NOT NULL DEFAULT '',
+	skills TEXT NOT NULL DEFAULT '',
+	mutual_connections INTEGER NOT NULL DEFAULT 0,
+	enriched_at DATETIME NOT NULL,
+	FOREIGN KEY(profile_id) REFERENCES profiles(id)
+);
+CREATE TABLE IF NOT EXISTS profile_experience (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	profile_id INTEGER NOT NULL,
+	position INTEGER NOT NULL,
+	title TEXT NOT NULL,
+	company TEXT NOT NULL,
+	dates TEXT NOT NULL,
+	FOREIGN KEY(profile_id) REFERENCES profiles(id)
+);
+CREATE TABLE IF NOT EXISTS profile_education (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	profile_id INTEGER NOT NULL,
+	position INTEGER NOT NULL,
+	school TEXT NOT NULL,
+	degree TEXT NOT NULL,
+	dates TEXT NOT NULL,
+	FOREIGN KEY(profile_id) REFERENCES profiles(id)
+);
+CREATE TABLE IF NOT EXISTS run_progress (
+	command TEXT PRIMARY KEY,
+	batch INTEGER NOT NULL,
+	processed INTEGER NOT NULL DEFAULT 0,
+	sent INTEGER NOT NULL DEFAULT 0,
+	failed INTEGER NOT NULL DEFAULT 0,
+	last_profile_id INTEGER NOT NULL DEFAULT 0,
+	cursor TEXT NOT NULL DEFAULT '',
+	updated_at DATETIME NOT NULL
+);
+CREATE TABLE IF NOT EXISTS action_logs (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	profile_id INTEGER,
+	profile_url TEXT NOT NULL DEFAULT '',
+	action TEXT NOT NULL,
+	target TEXT NOT NULL DEFAULT '',
+	detail TEXT NOT NULL DEFAULT '',
+	before_screenshot TEXT NOT NULL DEFAULT '',
+	after_screenshot TEXT NOT NULL DEFAULT '',
+	error TEXT NOT NULL DEFAULT '',
+	created_at DATETIME NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_action_logs_profile_url ON action_logs(profile_url);
+CREATE TABLE IF NOT EXISTS run_logs (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	run_type TEXT NOT NULL,
+	started_at DATETIME NOT NULL,
+	ended_at DATETIME NOT NULL,
+	ok INTEGER NOT NULL DEFAULT 0,
+	processed INTEGER NOT NULL DEFAULT 0,
+	succeeded INTEGER NOT NULL DEFAULT 0,
+	failed INTEGER NOT NULL DEFAULT 0,
+	summary TEXT
+);
+CREATE TABLE IF NOT EXISTS rate_events (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	kind TEXT NOT NULL,
+	created_at DATETIME NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_rate_events_kind_created ON rate_events(kind, created_at);
+CREATE TABLE IF NOT EXISTS exclusions (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	kind TEXT NOT NULL,
+	value TEXT NOT NULL,
+	created_at DATETIME NOT NULL,
+	UNIQUE(kind, value)
+);
+CREATE TABLE IF NOT EXISTS account (
+	id INTEGER PRIMARY KEY CHECK (id = 1),
+	started_at DATETIME NOT NULL
+);
+CREATE TABLE IF NOT EXISTS cooldown (
+	id INTEGER PRIMARY KEY CHECK (id = 1),
+	kind TEXT NOT NULL,
+	url TEXT NOT NULL DEFAULT '',
+	detected_at DATETIME NOT NULL,
+	until DATETIME
+);
+CREATE TABLE IF NOT EXISTS engagements (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	profile_id INTEGER NOT NULL,
+	post_url TEXT NOT NULL DEFAULT '',
+	liked INTEGER NOT NULL DEFAULT 0,
+	comment TEXT NOT NULL DEFAULT '',
+	created_at DATETIME NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_engagements_profile ON engagements(profile_id);
+CREATE TABLE IF NOT EXISTS nurture_events (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	profile_id INTEGER NOT NULL,
+	kind TEXT NOT NULL,
+	detail TEXT NOT NULL DEFAULT '',
+	year INTEGER NOT NULL,
+	message TEXT NOT NULL DEFAULT '',
+	sent_at DATETIME,
+	created_at DATETIME NOT NULL,
+	UNIQUE(profile_id, kind, year)
+);
@@ -0,0 +1,3 @@
+DROP TABLE profile_notes;
+DROP TABLE profile_tags;
+DROP TABLE tags;
@@ -0,0 +1,24 @@
+-- Free-form tags (many-to-many with profiles) and notes for segmenting
+-- targets. Tag names are normalized in Go before they get here.
+CREATE TABLE tags (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	name TEXT NOT NULL UNIQUE,
+	created_at DATETIME NOT NULL
+);
+CREATE TABLE profile_tags (
+	profile_id INTEGER NOT NULL,
+	tag_id INTEGER NOT NULL,
+	created_at DATETIME NOT NULL,
+	PRIMARY KEY(profile_id, tag_id),
+	FOREIGN KEY(profile_id) REFERENCES profiles(id),
+	FOREIGN KEY(tag_id) REFERENCES tags(id)
+);
+CREATE INDEX idx_profile_tags_tag ON profile_tags(tag_id);
+CREATE TABLE profile_notes (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	profile_id INTEGER NOT NULL,
+	body TEXT NOT NULL,
+	created_at DATETIME NOT NULL,
+	FOREIGN KEY(profile_id) REFERENCES profiles(id)
+);
+CREATE INDEX idx_profile_notes_profile ON profile_notes(profile_id);
@@ -0,0 +1,2 @@
+DROP TABLE suppressions;
+DROP TABLE do_not_contact;
@@ -0,0 +1,12 @@
+-- The day's plan for invites and follow-ups with limits.schedule: bursts of
+-- size actions due from due_at on, with used counting the ones done, so a
+-- restarted run keeps to the same plan. Only the current day is kept.
+CREATE TABLE schedule_slots (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	kind TEXT NOT NULL,
+	day TEXT NOT NULL,
+	due_at DATETIME NOT NULL,
+	size INTEGER NOT NULL,
+	used INTEGER NOT NULL DEFAULT 0
+);
+CREATE INDEX idx_schedule_slots_day ON schedule_slots(kind, day, due_at);
@@ -0,0 +1,15 @@
+-- Approval queue for send-connections --require-approval: the note an
+-- invite would carry is staged here and only sent once approved, verbatim
+-- as stored. One row per profile; state is pending, approved, rejected or
+-- sent.
+CREATE TABLE approvals (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	profile_id INTEGER NOT NULL UNIQUE,
+	note TEXT NOT NULL DEFAULT '',
+	state TEXT NOT NULL DEFAULT 'pending',
+	reason TEXT NOT NULL DEFAULT '',
+	created_at DATETIME NOT NULL,
+	decided_at DATETIME,
+	FOREIGN KEY(profile_id) REFERENCES profiles(id)
+);
+CREATE INDEX idx_approvals_state ON approvals(state);
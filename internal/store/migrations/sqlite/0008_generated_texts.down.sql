@@ -0,0 +1,2 @@
+ALTER TABLE engagements DROP COLUMN post_text;
+DROP TABLE generated_texts;
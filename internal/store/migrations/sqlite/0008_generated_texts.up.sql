@@ -0,0 +1,21 @@
+-- Text written by the LLM (llm.*), one row per profile, kind (note or
+-- follow_up) and prompt: a retried or staged invite reuses its text instead
+-- of paying for a new one, and a changed prompt writes a fresh one. The
+-- token counts and cost feed the daily caps.
+CREATE TABLE generated_texts (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	profile_id INTEGER NOT NULL,
+	kind TEXT NOT NULL,
+	prompt_hash TEXT NOT NULL,
+	model TEXT NOT NULL DEFAULT '',
+	content TEXT NOT NULL,
+	input_tokens INTEGER NOT NULL DEFAULT 0,
+	output_tokens INTEGER NOT NULL DEFAULT 0,
+	cost REAL NOT NULL DEFAULT 0,
+	created_at DATETIME NOT NULL,
+	UNIQUE(profile_id, kind, prompt_hash),
+	FOREIGN KEY(profile_id) REFERENCES profiles(id)
+);
+CREATE INDEX idx_generated_texts_created ON generated_texts(created_at);
+-- The text of the post engage looked at, for the prompts
+ALTER TABLE engagements ADD COLUMN post_text TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,16 @@
+-- Replies found by send-messages, with the class they were given
+-- (interested, not_interested, stop, out_of_office or other) and by what
+-- (rules or llm). The hash of the text keeps a reply from being handled
+-- twice, e.g. an out-of-office that leaves the sequence running.
+CREATE TABLE replies (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	profile_id INTEGER NOT NULL,
+	content TEXT NOT NULL DEFAULT '',
+	content_hash TEXT NOT NULL,
+	label TEXT NOT NULL,
+	classifier TEXT NOT NULL,
+	created_at DATETIME NOT NULL,
+	UNIQUE(profile_id, content_hash),
+	FOREIGN KEY(profile_id) REFERENCES profiles(id)
+);
+CREATE INDEX idx_replies_label ON replies(label, created_at);
@@ -0,0 +1 @@
+DROP TABLE crm_sync;
@@ -0,0 +1,15 @@
+-- What crm-sync last pushed for a profile: the contact (person) and deal it
+-- created in provider, and the status and reply they reflect, so a profile
+-- is only sent again once one of those changes. error keeps the last
+-- failure.
+CREATE TABLE crm_sync (
+	profile_id INTEGER PRIMARY KEY,
+	provider TEXT NOT NULL,
+	contact_id TEXT NOT NULL DEFAULT '',
+	deal_id TEXT NOT NULL DEFAULT '',
+	synced_status TEXT NOT NULL DEFAULT '',
+	synced_reply_id INTEGER NOT NULL DEFAULT 0,
+	synced_at DATETIME,
+	error TEXT NOT NULL DEFAULT '',
+	FOREIGN KEY(profile_id) REFERENCES profiles(id)
+);
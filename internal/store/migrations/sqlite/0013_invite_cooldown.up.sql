@@ -0,0 +1,7 @@
+-- Pause of invites after LinkedIn showed its weekly invitation limit. Other
+-- actions go on; send-connections and connect jobs wait until it passes.
+CREATE TABLE invite_cooldown (
+	id INTEGER PRIMARY KEY CHECK (id = 1),
+	detected_at DATETIME NOT NULL,
+	until DATETIME NOT NULL
+);
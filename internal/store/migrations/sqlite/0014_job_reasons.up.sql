@@ -0,0 +1,5 @@
+-- Why a job last failed (selector_not_found, navigation_timeout,
+-- profile_unavailable, rate_limited, other), and how often retry-failed
+-- has put it back in the queue
+ALTER TABLE jobs ADD COLUMN reason TEXT NOT NULL DEFAULT '';
+ALTER TABLE jobs ADD COLUMN retries INTEGER NOT NULL DEFAULT 0;
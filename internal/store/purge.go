@@ -0,0 +1,187 @@
+package store
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/example/linkedbot/internal/models"
+)
+
+// anonymizedPrefix replaces the URL of anonymized profiles, keeping the
+// column unique.
+const anonymizedPrefix = "anonymized:"
+
+// PurgeFilter selects the profiles Purge removes: those in one of Statuses
+// that were last updated before UpdatedBefore.
+type PurgeFilter struct {
+	UpdatedBefore time.Time
+	Statuses      []models.ProfileStatus
+}
+
+// PurgeResult counts what a purge removed, or would remove on a dry run.
+type PurgeResult struct {
+	Profiles    int
+	MessageLogs int
+	ActionLogs  int
+	// Screenshots are the audit screenshot files of the purged action logs;
+	// deleting them is up to the caller
+	Screenshots []string
+}
+
+// profileTables hold rows about a single profile, keyed by profile_id.
+var profileTables = []string{
+	"message_logs", "message_sequences", "profile_details", "profile_experience", "profile_education",
+	"engagements", "nurture_events", "status_transitions", "profile_tags", "profile_notes", "action_logs", "jobs", "approvals",
+	"generated_texts", "replies", "crm_sync", "events", "profile_aliases",
+}
+
+// Purge deletes the profiles matching f together with every row recorded
+// about them. With anonymize the profile, message and status history rows
+// are kept for the stats, but names, URLs, message texts, enrichment, notes
+// and tags are erased and the profile is closed. dryRun only counts.
+func (s *Store) Purge(ctx context.Context, f PurgeFilter, anonymize, dryRun bool) (PurgeResult, error) {
+	var res PurgeResult
+	if len(f.Statuses) == 0 {
+		return res, nil
+	}
+	args := []any{f.UpdatedBefore, anonymizedPrefix + "%"}
+	for _, st := range f.Statuses {
+		args = append(args, string(st))
+	}
+	rows, err := s.db.QueryContext(ctx, `SELECT id FROM profiles
+	WHERE updated_at < ? AND linkedin_url NOT LIKE ? AND status IN (?`+strings.Repeat(", ?", len(f.Statuses)-1)+`)
+	ORDER BY id`, args...)
+	if err != nil {
+		return res, err
+	}
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return res, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return res, err
+	}
+
+	for _, id := range ids {
+		var n int
+		if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM message_logs WHERE profile_id = ?`, id).Scan(&n); err != nil {
+			return res, err
+		}
+		res.MessageLogs += n
+		shots, logs, err := s.actionScreenshots(ctx, `profile_id = ?`, id)
+		if err != nil {
+			return res, err
+		}
+		res.ActionLogs += logs
+		res.Screenshots = append(res.Screenshots, shots...)
+	}
+	res.Profiles = len(ids)
+	if dryRun || len(ids) == 0 {
+		return res, nil
+	}
+
+	now := time.Now()
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return res, err
+	}
+	defer func() { _ = tx.Rollback() }()
+	for _, id := range ids {
+		if anonymize {
+			err = anonymizeProfile(ctx, tx, id, now)
+		} else {
+			err = deleteProfile(ctx, tx, id)
+		}
+		if err != nil {
+			return res, err
+		}
+	}
+	return res, tx.Commit()
+}
+
+func deleteProfile(ctx context.Context, tx *txn, id int64) error {
+	for _, table := range profileTables {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM `+table+` WHERE profile_id = ?`, id); err != nil {
+			return err
+		}
+	}
+	_, err := tx.ExecContext(ctx, `DELETE FROM profiles WHERE id = ?`, id)
+	return err
+}
+
+func anonymizeProfile(ctx context.Context, tx *txn, id int64, now time.Time) error {
+	for _, table := range []string{"profile_details", "profile_experience", "profile_education", "profile_tags", "profile_notes", "jobs", "approvals", "generated_texts", "events", "profile_aliases"} {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM `+table+` WHERE profile_id = ?`, id); err != nil {
+			return err
+		}
+	}
+	for _, q := range []string{
+		`UPDATE message_logs SET content = '' WHERE profile_id = ?`,
+		`UPDATE engagements SET post_url = '', post_text = '', comment = '' WHERE profile_id = ?`,
+		`UPDATE replies SET content = '' WHERE profile_id = ?`,
+		`UPDATE nurture_events SET detail = '', message = '' WHERE profile_id = ?`,
+		`UPDATE action_logs SET profile_url = '', target = '', detail = '', before_screenshot = '', after_screenshot = '' WHERE profile_id = ?`,
+	} {
+		if _, err := tx.ExecContext(ctx, q, id); err != nil {
+			return err
+		}
+	}
+	var status models.ProfileStatus
+	if err := tx.QueryRowContext(ctx, `SELECT status FROM profiles WHERE id = ?`, id).Scan(&status); err != nil {
+		return err
+	}
+	if status != models.StatusDoNotContact {
+		if err := transition(ctx, tx, id, models.StatusClosed, "anonymized by purge", now); err != nil {
+			return err
+		}
+	}
+	_, err := tx.ExecContext(ctx, `UPDATE profiles SET linkedin_url = ?, name = '', headline = '', company = '', location = '',
+		member_urn = '', email = '', endorsed_skills = '' WHERE id = ?`, anonymizedPrefix+strconv.FormatInt(id, 10), id)
+	return err
+}
+
+// ClearScreenshots drops the screenshot paths of action logs older than
+// before and returns the files, which the caller deletes. dryRun only
+// lists them.
+func (s *Store) ClearScreenshots(ctx context.Context, before time.Time, dryRun bool) ([]string, error) {
+	shots, _, err := s.actionScreenshots(ctx, `created_at < ?`, before)
+	if err != nil || dryRun || len(shots) == 0 {
+		return shots, err
+	}
+	_, err = s.db.ExecContext(ctx, `UPDATE action_logs SET before_screenshot = '', after_screenshot = ''
+	WHERE created_at < ? AND (before_screenshot <> '' OR after_screenshot <> '')`, before)
+	return shots, err
+}
+
+// actionScreenshots returns the screenshot files of the action logs matching
+// where, and how many logs matched.
+func (s *Store) actionScreenshots(ctx context.Context, where string, arg any) ([]string, int, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT before_screenshot, after_screenshot FROM action_logs WHERE `+where, arg)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+	var shots []string
+	n := 0
+	for rows.Next() {
+		var before, after string
+		if err := rows.Scan(&before, &after); err != nil {
+			return nil, 0, err
+		}
+		n++
+		for _, path := range []string{before, after} {
+			if path != "" {
+				shots = append(shots, path)
+			}
+		}
+	}
+	return shots, n, rows.Err()
+}
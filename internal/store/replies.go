@@ -0,0 +1,62 @@
+package store
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+)
+
+// Reply is a classified reply from a messaged profile.
+type Reply struct {
+	ID         int64
+	ProfileID  int64
+	ProfileURL string
+	Name       string
+	Content    string
+	Label      string
+	Classifier string
+	CreatedAt  time.Time
+}
+
+// SaveReply records r. It reports false when the profile's same text was
+// recorded before, so the caller can leave it alone.
+func (s *Store) SaveReply(ctx context.Context, r *Reply) (bool, error) {
+	sum := sha256.Sum256([]byte(r.Content))
+	r.CreatedAt = time.Now()
+	res, err := s.db.ExecContext(ctx, `INSERT INTO replies (profile_id, content, content_hash, label, classifier, created_at) VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT DO NOTHING`, r.ProfileID, r.Content, hex.EncodeToString(sum[:]), r.Label, r.Classifier, r.CreatedAt)
+	if err != nil {
+		return false, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return false, nil
+	}
+	return true, addEvent(ctx, s.db, EventReplied, r.ProfileID, map[string]any{"label": r.Label, "text": r.Content}, r.CreatedAt)
+}
+
+// GetReplies returns the replies recorded since since, newest first; label
+// filters them when not empty.
+func (s *Store) GetReplies(ctx context.Context, since time.Time, label string) ([]Reply, error) {
+	q := `SELECT r.id, r.profile_id, p.linkedin_url, COALESCE(p.name, ''), r.content, r.label, r.classifier, r.created_at
+		FROM replies r JOIN profiles p ON p.id = r.profile_id WHERE r.created_at >= ?`
+	args := []any{since}
+	if label != "" {
+		q += ` AND r.label = ?`
+		args = append(args, label)
+	}
+	rows, err := s.db.QueryContext(ctx, q+` ORDER BY r.created_at DESC, r.id DESC`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Reply
+	for rows.Next() {
+		var r Reply
+		if err := rows.Scan(&r.ID, &r.ProfileID, &r.ProfileURL, &r.Name, &r.Content, &r.Label, &r.Classifier, &r.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
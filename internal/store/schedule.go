@@ -0,0 +1,70 @@
+package store
+
+import (
+	"context"
+	"time"
+)
+
+// ScheduleSlot is a burst in the day's plan for one kind of action: Size
+// actions due from DueAt on, Used of them done.
+type ScheduleSlot struct {
+	ID    int64
+	Kind  string
+	Day   string
+	DueAt time.Time
+	Size  int
+	Used  int
+}
+
+// GetSchedule returns the slots planned for kind on day (YYYY-MM-DD),
+// earliest first.
+func (s *Store) GetSchedule(ctx context.Context, kind, day string) ([]ScheduleSlot, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT id, kind, day, due_at, size, used FROM schedule_slots
+		WHERE kind = ? AND day = ? ORDER BY due_at, id`, kind, day)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []ScheduleSlot
+	for rows.Next() {
+		var sl ScheduleSlot
+		if err := rows.Scan(&sl.ID, &sl.Kind, &sl.Day, &sl.DueAt, &sl.Size, &sl.Used); err != nil {
+			return nil, err
+		}
+		out = append(out, sl)
+	}
+	return out, rows.Err()
+}
+
+// SaveSchedule stores slots as the plan for kind on day, replacing every
+// earlier plan of kind.
+func (s *Store) SaveSchedule(ctx context.Context, kind, day string, slots []ScheduleSlot) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+	if _, err := tx.ExecContext(ctx, `DELETE FROM schedule_slots WHERE kind = ?`, kind); err != nil {
+		return err
+	}
+	for _, sl := range slots {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO schedule_slots (kind, day, due_at, size, used) VALUES (?, ?, ?, ?, 0)`,
+			kind, day, sl.DueAt, sl.Size); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// UseScheduleSlot counts one action against the earliest slot of kind on day
+// that is due at now and not used up. It reports whether there was one.
+func (s *Store) UseScheduleSlot(ctx context.Context, kind, day string, now time.Time) (bool, error) {
+	res, err := s.db.ExecContext(ctx, `UPDATE schedule_slots SET used = used + 1 WHERE id = (
+		SELECT id FROM schedule_slots WHERE kind = ? AND day = ? AND due_at <= ? AND used < size ORDER BY due_at, id LIMIT 1)`,
+		kind, day, now)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
@@ -0,0 +1,57 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/example/linkedbot/internal/models"
+)
+
+// SheetRow is a profile as written to the Google sheet.
+type SheetRow struct {
+	Profile models.Profile
+	// Note is the newest note on the profile
+	Note       string
+	ReplyLabel string
+}
+
+// GetSheetRows returns the profiles in statuses, or every profile when
+// statuses is empty, oldest first so rows keep their place in the sheet.
+func (s *Store) GetSheetRows(ctx context.Context, statuses []string) ([]SheetRow, error) {
+	q := `SELECT p.id, p.linkedin_url, p.name, p.headline, p.company, p.location, p.source, p.status,
+		p.connection_sent_at, p.connection_checked_at, p.message_sent_at, p.replied_at, p.updated_at,
+		COALESCE((SELECT body FROM profile_notes WHERE profile_id = p.id ORDER BY created_at DESC, id DESC LIMIT 1), ''),
+		COALESCE((SELECT label FROM replies WHERE profile_id = p.id ORDER BY created_at DESC, id DESC LIMIT 1), '')
+	FROM profiles p`
+	var args []any
+	if len(statuses) > 0 {
+		q += ` WHERE p.status IN (?` + strings.Repeat(", ?", len(statuses)-1) + `)`
+		for _, st := range statuses {
+			args = append(args, st)
+		}
+	}
+	rows, err := s.db.QueryContext(ctx, q+` ORDER BY p.id`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []SheetRow
+	for rows.Next() {
+		var r SheetRow
+		p := &r.Profile
+		var name, headline, company, location sql.NullString
+		var sentAt, checkedAt, messagedAt, repliedAt sql.NullTime
+		if err := rows.Scan(&p.ID, &p.LinkedInURL, &name, &headline, &company, &location, &p.Source, &p.Status,
+			&sentAt, &checkedAt, &messagedAt, &repliedAt, &p.UpdatedAt, &r.Note, &r.ReplyLabel); err != nil {
+			return nil, err
+		}
+		p.Name, p.Headline, p.Company, p.Location = name.String, headline.String, company.String, location.String
+		p.ConnectionSentAt = timePtr(sentAt)
+		p.ConnectionCheckedAt = timePtr(checkedAt)
+		p.MessageSentAt = timePtr(messagedAt)
+		p.RepliedAt = timePtr(repliedAt)
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
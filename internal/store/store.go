@@ -4,103 +4,444 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
 	"time"
 
-	_ "modernc.org/sqlite"
-
 	"github.com/example/linkedbot/internal/models"
 )
 
-type Store struct{ db *sql.DB }
+type Store struct{ db *conn }
 
-func Open(path string) (*Store, error) {
-	db, err := sql.Open("sqlite", path)
+// Open opens the database of driver ("sqlite" or "postgres") at source: a
+// file path for sqlite, a connection URL for postgres.
+func Open(driver, source string) (*Store, error) {
+	d, err := dialectFor(driver)
+	if err != nil {
+		return nil, err
+	}
+	db, err := sql.Open(d.driverName(), source)
 	if err != nil {
 		return nil, err
 	}
-	return &Store{db: db}, nil
+	return &Store{db: &conn{DB: db, d: d}}, nil
 }
 
 func (s *Store) Close() { _ = s.db.Close() }
 
-func (s *Store) Migrate(ctx context.Context) error {
-	stmt := `
-CREATE TABLE IF NOT EXISTS profiles (
-	id INTEGER PRIMARY KEY AUTOINCREMENT,
-	linkedin_url TEXT NOT NULL UNIQUE,
-	name TEXT,
-	headline TEXT,
-	company TEXT,
-	location TEXT,
-	connection_sent INTEGER DEFAULT 0,
-	connection_sent_at DATETIME,
-	connection_accepted INTEGER DEFAULT 0,
-	connection_checked_at DATETIME,
-	message_sent INTEGER DEFAULT 0,
-	message_sent_at DATETIME,
-	created_at DATETIME NOT NULL,
-	updated_at DATETIME NOT NULL
-);
-CREATE TABLE IF NOT EXISTS message_logs (
-	id INTEGER PRIMARY KEY AUTOINCREMENT,
-	profile_id INTEGER NOT NULL,
-	type TEXT NOT NULL,
-	content TEXT NOT NULL,
-	created_at DATETIME NOT NULL,
-	FOREIGN KEY(profile_id) REFERENCES profiles(id)
-);
-CREATE TABLE IF NOT EXISTS run_logs (
-	id INTEGER PRIMARY KEY AUTOINCREMENT,
-	run_type TEXT NOT NULL,
-	started_at DATETIME NOT NULL,
-	ended_at DATETIME NOT NULL,
-	summary TEXT
-);
-`
-	_, err := s.db.ExecContext(ctx, stmt)
+// addColumnIfMissing adds a column to an existing table and reports whether it
+// had to. CREATE TABLE IF NOT EXISTS never alters tables from older versions.
+func (s *Store) addColumnIfMissing(ctx context.Context, table, column, decl string) (bool, error) {
+	rows, err := s.db.QueryContext(ctx, fmt.Sprintf(`PRAGMA table_info(%s)`, table))
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			cid     int
+			name    string
+			typ     string
+			notnull int
+			dflt    sql.NullString
+			pk      int
+		)
+		if err := rows.Scan(&cid, &name, &typ, &notnull, &dflt, &pk); err != nil {
+			return false, err
+		}
+		if name == column {
+			return false, nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return false, err
+	}
+	rows.Close()
+	if _, err := s.db.ExecContext(ctx, fmt.Sprintf(`ALTER TABLE %s ADD COLUMN %s %s`, table, column, decl)); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// ErrIllegalTransition is returned when a profile can't move to the
+// requested status.
+var ErrIllegalTransition = errors.New("illegal status transition")
+
+// Status conditions shared by the queue queries.
+const (
+	// inQueue are profiles that may still be invited
+	inQueue = `status IN ('discovered', 'queued')`
+	// contactable leaves out profiles outreach is done with
+	contactable = `status NOT IN ('closed', 'do_not_contact', 'invalid')`
+)
+
+// transition moves profile id to status to inside tx and logs the move.
+// Moving to the current status is a no-op.
+func transition(ctx context.Context, tx *txn, id int64, to models.ProfileStatus, reason string, now time.Time) error {
+	var from models.ProfileStatus
+	if err := tx.QueryRowContext(ctx, `SELECT status FROM profiles WHERE id = ?`, id).Scan(&from); err != nil {
+		return err
+	}
+	if from == to {
+		return nil
+	}
+	if !models.CanTransition(from, to) {
+		return fmt.Errorf("%w: profile %d from %s to %s", ErrIllegalTransition, id, from, to)
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE profiles SET status = ?, updated_at = ? WHERE id = ?`, string(to), now, id); err != nil {
+		return err
+	}
+	_, err := tx.ExecContext(ctx, `INSERT INTO status_transitions (profile_id, from_status, to_status, reason, created_at) VALUES (?, ?, ?, ?, ?)`,
+		id, string(from), string(to), reason, now)
 	return err
 }
 
+// SetStatus moves a profile to status, e.g. to close it by hand.
+func (s *Store) SetStatus(ctx context.Context, id int64, status models.ProfileStatus, reason string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+	if err := transition(ctx, tx, id, status, reason, time.Now()); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// MarkUnavailable moves a profile LinkedIn no longer shows to invalid, which
+// keeps it out of every queue.
+func (s *Store) MarkUnavailable(ctx context.Context, id int64) error {
+	return s.SetStatus(ctx, id, models.StatusInvalid, "profile not available")
+}
+
+// StatusTransition is one logged status change of a profile. From is empty
+// for statuses derived when the log was introduced.
+type StatusTransition struct {
+	ID        int64
+	ProfileID int64
+	From      models.ProfileStatus
+	To        models.ProfileStatus
+	Reason    string
+	CreatedAt time.Time
+}
+
+// GetStatusTransitions returns the status history of a profile, oldest
+// first.
+func (s *Store) GetStatusTransitions(ctx context.Context, profileID int64) ([]StatusTransition, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT id, profile_id, from_status, to_status, reason, created_at FROM status_transitions
+	WHERE profile_id = ? ORDER BY created_at, id`, profileID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []StatusTransition
+	for rows.Next() {
+		var t StatusTransition
+		if err := rows.Scan(&t.ID, &t.ProfileID, &t.From, &t.To, &t.Reason, &t.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, t)
+	}
+	return out, rows.Err()
+}
+
+// GetProfileByURL returns the stored profile with the given URL, or one of
+// its former URLs, or nil.
+func (s *Store) GetProfileByURL(ctx context.Context, profileURL string) (*models.Profile, error) {
+	profileURL, err := s.resolveAlias(ctx, profileURL)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := s.db.QueryContext(ctx, `SELECT id, linkedin_url, name, headline, company, location, source, status, created_at, updated_at
+	FROM profiles WHERE linkedin_url = ?`, profileURL)
+	if err != nil {
+		return nil, err
+	}
+	out, err := scanListed(rows)
+	if err != nil || len(out) == 0 {
+		return nil, err
+	}
+	return &out[0], nil
+}
+
+// GetProfilesByStatus returns up to limit profiles, most recently updated
+// first. An empty status returns every status.
+func (s *Store) GetProfilesByStatus(ctx context.Context, status models.ProfileStatus, limit int) ([]models.Profile, error) {
+	q := `SELECT id, linkedin_url, name, headline, company, location, source, status, created_at, updated_at FROM profiles`
+	var args []any
+	if status != "" {
+		q += ` WHERE status = ?`
+		args = append(args, string(status))
+	}
+	rows, err := s.db.QueryContext(ctx, q+` ORDER BY updated_at DESC, id DESC LIMIT ?`, append(args, limit)...)
+	if err != nil {
+		return nil, err
+	}
+	return scanListed(rows)
+}
+
+func scanListed(rows *sql.Rows) ([]models.Profile, error) {
+	defer rows.Close()
+	var out []models.Profile
+	for rows.Next() {
+		var p models.Profile
+		var name, headline, company, location sql.NullString
+		if err := rows.Scan(&p.ID, &p.LinkedInURL, &name, &headline, &company, &location, &p.Source, &p.Status, &p.CreatedAt, &p.UpdatedAt); err != nil {
+			return nil, err
+		}
+		p.Name, p.Headline, p.Company, p.Location = name.String, headline.String, company.String, location.String
+		out = append(out, p)
+	}
+	return out, rows.Err()
+}
+
+// UpsertProfile stores p, or refreshes the stored copy, and returns its id.
+// Profiles on the do-not-contact list are refused with ErrDoNotContact.
 func (s *Store) UpsertProfile(ctx context.Context, p *models.Profile) (int64, error) {
+	var err error
+	if p.LinkedInURL, err = s.resolveAlias(ctx, p.LinkedInURL); err != nil {
+		return 0, err
+	}
+	if err := s.CheckDoNotContact(ctx, p.LinkedInURL, "store"); err != nil {
+		return 0, err
+	}
 	now := time.Now()
 	p.CreatedAt = now
 	p.UpdatedAt = now
-	res, err := s.db.ExecContext(ctx, `INSERT INTO profiles (linkedin_url, name, headline, company, location, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?)
+	// The first source to discover a profile keeps ownership of it
+	// Details, degree and open profile are only seen on some pages, so an
+	// unknown value never overwrites a known one
+	// created_at only equals updated_at on the row just inserted
+	var id int64
+	var inserted bool
+	err = s.db.QueryRowContext(ctx, `INSERT INTO profiles (linkedin_url, name, headline, company, location, source, member_urn, degree, open_profile, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 		ON CONFLICT(linkedin_url) DO UPDATE SET
-		name=excluded.name,
-		headline=excluded.headline,
-		company=excluded.company,
-		location=excluded.location,
+		name=COALESCE(NULLIF(excluded.name, ''), profiles.name),
+		headline=COALESCE(NULLIF(excluded.headline, ''), profiles.headline),
+		company=COALESCE(NULLIF(excluded.company, ''), profiles.company),
+		location=COALESCE(NULLIF(excluded.location, ''), profiles.location),
+		source=COALESCE(NULLIF(profiles.source, ''), excluded.source),
+		member_urn=COALESCE(NULLIF(excluded.member_urn, ''), profiles.member_urn),
+		degree=COALESCE(NULLIF(excluded.degree, ''), profiles.degree),
+		open_profile=profiles.open_profile | excluded.open_profile,
 		updated_at=excluded.updated_at
-	`, p.LinkedInURL, p.Name, p.Headline, p.Company, p.Location, p.CreatedAt, p.UpdatedAt)
+	RETURNING id, created_at = updated_at`, p.LinkedInURL, p.Name, p.Headline, p.Company, p.Location, p.Source, p.MemberURN, p.Degree, p.OpenProfile, p.CreatedAt, p.UpdatedAt).Scan(&id, &inserted)
+	if err != nil || !inserted {
+		return id, err
+	}
+	return id, addEvent(ctx, s.db, EventProfileDiscovered, id, map[string]any{"source": p.Source}, now)
+}
+
+// ImportProfile adds a profile unless its canonical URL is already stored,
+// in which case only empty fields are filled in. It reports whether a new row
+// was created. Profiles on the do-not-contact list are refused with
+// ErrDoNotContact.
+func (s *Store) ImportProfile(ctx context.Context, p *models.Profile) (bool, error) {
+	var err error
+	if p.LinkedInURL, err = s.resolveAlias(ctx, p.LinkedInURL); err != nil {
+		return false, err
+	}
+	if err := s.CheckDoNotContact(ctx, p.LinkedInURL, "store"); err != nil {
+		return false, err
+	}
+	now := time.Now()
+	var id int64
+	err = s.db.QueryRowContext(ctx, `INSERT INTO profiles (linkedin_url, name, headline, company, location, source, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?) ON CONFLICT(linkedin_url) DO NOTHING RETURNING id`,
+		p.LinkedInURL, p.Name, p.Headline, p.Company, p.Location, p.Source, now, now).Scan(&id)
+	switch {
+	case err == nil:
+		return true, addEvent(ctx, s.db, EventProfileDiscovered, id, map[string]any{"source": p.Source}, now)
+	case !errors.Is(err, sql.ErrNoRows):
+		return false, err
+	}
+	_, err = s.db.ExecContext(ctx, `UPDATE profiles SET
+		name = COALESCE(NULLIF(name, ''), ?),
+		headline = COALESCE(NULLIF(headline, ''), ?),
+		company = COALESCE(NULLIF(company, ''), ?),
+		location = COALESCE(NULLIF(location, ''), ?),
+		updated_at = ?
+	WHERE linkedin_url = ?`, p.Name, p.Headline, p.Company, p.Location, now, p.LinkedInURL)
+	return false, err
+}
+
+// GetProfilesNeedingConnection returns discovered and queued profiles in
+// discovery order, skipping any in excludeSources. Profiles whose
+// invite asked for their email are only returned with withEmailRequired.
+func (s *Store) GetProfilesNeedingConnection(ctx context.Context, limit int, f QueueFilter) ([]models.Profile, error) {
+	where, args := f.where()
+	rows, err := s.db.QueryContext(ctx, `SELECT id, linkedin_url, name, headline, company, location, source, member_urn, degree, open_profile FROM profiles WHERE `+inQueue+where+` ORDER BY id LIMIT ?`, append(args, limit)...)
 	if err != nil {
-		return 0, err
+		return nil, err
+	}
+	return scanQueue(rows)
+}
+
+// GetProfilesNeedingConnectionRoundRobin interleaves profiles from each
+// source (1st of every source, then 2nd of every source, ...) so one search
+// can't starve the others.
+func (s *Store) GetProfilesNeedingConnectionRoundRobin(ctx context.Context, limit int, f QueueFilter) ([]models.Profile, error) {
+	where, args := f.where()
+	rows, err := s.db.QueryContext(ctx, `SELECT id, linkedin_url, name, headline, company, location, source, member_urn, degree, open_profile FROM (
+		SELECT id, linkedin_url, name, headline, company, location, source, member_urn, degree, open_profile,
+			ROW_NUMBER() OVER (PARTITION BY source ORDER BY id) AS rn
+		FROM profiles WHERE `+inQueue+where+`
+	) AS queue ORDER BY rn, id LIMIT ?`, append(args, limit)...)
+	if err != nil {
+		return nil, err
+	}
+	return scanQueue(rows)
+}
+
+// CountProfilesByStatus tallies the stored profiles by status.
+func (s *Store) CountProfilesByStatus(ctx context.Context) (map[models.ProfileStatus]int, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT status, COUNT(*) FROM profiles GROUP BY status`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := map[models.ProfileStatus]int{}
+	for rows.Next() {
+		var st string
+		var n int
+		if err := rows.Scan(&st, &n); err != nil {
+			return nil, err
+		}
+		out[models.ProfileStatus(st)] = n
+	}
+	return out, rows.Err()
+}
+
+// QueueFilter narrows the connection queue.
+type QueueFilter struct {
+	ExcludeSources    []string
+	WithEmailRequired bool      // keep profiles marked requires_email
+	ViewedBefore      time.Time // if set, only profiles warm-viewed before it
+	Unviewed          bool      // only profiles not warm-viewed yet
+	Tags              []string  // if set, only profiles with any of these tags
+	WithoutJob        string    // leave out profiles with an open or failed job of this kind
+	WithoutApproval   bool      // leave out profiles with a staged approval, whatever its state
+}
+
+func (f QueueFilter) where() (string, []any) {
+	var where string
+	var args []any
+	if !f.WithEmailRequired {
+		where += ` AND requires_email = 0`
+	}
+	if !f.ViewedBefore.IsZero() {
+		where += ` AND viewed_at < ?`
+		args = append(args, f.ViewedBefore)
+	}
+	if f.Unviewed {
+		where += ` AND viewed_at IS NULL`
+	}
+	if tagged, tagArgs := taggedWith("profiles.id", f.Tags); tagged != "" {
+		where += tagged
+		args = append(args, tagArgs...)
+	}
+	if queued, jobArgs := withoutJob("profiles.id", f.WithoutJob); queued != "" {
+		where += queued
+		args = append(args, jobArgs...)
 	}
-	id, _ := res.LastInsertId()
-	if id == 0 {
-		// fetch existing id
-		row := s.db.QueryRowContext(ctx, `SELECT id FROM profiles WHERE linkedin_url = ?`, p.LinkedInURL)
-		_ = row.Scan(&id)
+	if f.WithoutApproval {
+		where += ` AND NOT EXISTS (SELECT 1 FROM approvals WHERE approvals.profile_id = profiles.id)`
+	}
+	if len(f.ExcludeSources) == 0 {
+		return where, args
+	}
+	for _, src := range f.ExcludeSources {
+		args = append(args, src)
+	}
+	return where + ` AND source NOT IN (?` + strings.Repeat(", ?", len(f.ExcludeSources)-1) + `)`, args
+}
+
+// SourceStats summarizes invites and acceptances for one discovery source.
+type SourceStats struct {
+	Source   string
+	Sent     int
+	Accepted int
+}
+
+// Rate is the share of sent invites that were accepted.
+func (s SourceStats) Rate() float64 {
+	if s.Sent == 0 {
+		return 0
 	}
-	return id, nil
+	return float64(s.Accepted) / float64(s.Sent)
 }
 
-func (s *Store) GetProfilesNeedingConnection(ctx context.Context, limit int) ([]models.Profile, error) {
-	rows, err := s.db.QueryContext(ctx, `SELECT id, linkedin_url, name, headline, company, location FROM profiles WHERE connection_sent = 0 ORDER BY id LIMIT ?`, limit)
+// AcceptanceRateBySource counts sent and accepted invites per source.
+func (s *Store) AcceptanceRateBySource(ctx context.Context) ([]SourceStats, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT source, COUNT(*), COUNT(connection_checked_at) FROM profiles WHERE connection_sent_at IS NOT NULL GROUP BY source ORDER BY source`)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
+	var out []SourceStats
+	for rows.Next() {
+		var st SourceStats
+		if err := rows.Scan(&st.Source, &st.Sent, &st.Accepted); err != nil {
+			return nil, err
+		}
+		out = append(out, st)
+	}
+	return out, rows.Err()
+}
+
+func scanQueue(rows *sql.Rows) ([]models.Profile, error) {
 	defer rows.Close()
 	var out []models.Profile
 	for rows.Next() {
 		var p models.Profile
-		if err := rows.Scan(&p.ID, &p.LinkedInURL, &p.Name, &p.Headline, &p.Company, &p.Location); err != nil {
+		var name, headline, company, location sql.NullString
+		if err := rows.Scan(&p.ID, &p.LinkedInURL, &name, &headline, &company, &location, &p.Source, &p.MemberURN, &p.Degree, &p.OpenProfile); err != nil {
 			return nil, err
 		}
+		p.Name, p.Headline, p.Company, p.Location = name.String, headline.String, company.String, location.String
 		out = append(out, p)
 	}
-	return out, nil
+	return out, rows.Err()
+}
+
+// MarkRequiresEmail records that LinkedIn asked for the member's email to
+// invite p, so the queue leaves it out until an email lookup is configured.
+func (s *Store) MarkRequiresEmail(ctx context.Context, id int64) error {
+	now := time.Now()
+	_, err := s.db.ExecContext(ctx, `UPDATE profiles SET requires_email = 1, requires_email_at = ?, updated_at = ? WHERE id = ?`, now, now, id)
+	return err
+}
+
+// MarkViewed records a warm-up visit to a profile, which queues it for an
+// invite.
+func (s *Store) MarkViewed(ctx context.Context, id int64) error {
+	now := time.Now()
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+	if _, err := tx.ExecContext(ctx, `UPDATE profiles SET viewed_at = ?, updated_at = ? WHERE id = ?`, now, now, id); err != nil {
+		return err
+	}
+	if err := transition(ctx, tx, id, models.StatusQueued, "warm-viewed", now); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// MarkQueued records that a send-connections batch picked up a profile.
+func (s *Store) MarkQueued(ctx context.Context, id int64) error {
+	return s.SetStatus(ctx, id, models.StatusQueued, "picked for invite")
+}
+
+// SaveEmail stores the email address found for a profile.
+func (s *Store) SaveEmail(ctx context.Context, id int64, email string) error {
+	_, err := s.db.ExecContext(ctx, `UPDATE profiles SET email = ?, updated_at = ? WHERE id = ?`, email, time.Now(), id)
+	return err
 }
 
 func (s *Store) MarkConnectionSent(ctx context.Context, id int64, note string) error {
@@ -110,17 +451,130 @@ func (s *Store) MarkConnectionSent(ctx context.Context, id int64, note string) e
 		return err
 	}
 	defer func() { _ = tx.Rollback() }()
-	if _, err := tx.ExecContext(ctx, `UPDATE profiles SET connection_sent = 1, connection_sent_at = ?, updated_at = ? WHERE id = ?`, now, now, id); err != nil {
+	if err := transition(ctx, tx, id, models.StatusInvited, "invite sent", now); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE profiles SET connection_sent_at = ? WHERE id = ?`, now, id); err != nil {
 		return err
 	}
 	if _, err := tx.ExecContext(ctx, `INSERT INTO message_logs (profile_id, type, content, created_at) VALUES (?, ?, ?, ?)`, id, string(models.MessageTypeConnectionNote), note, now); err != nil {
 		return err
 	}
+	if err := addEvent(ctx, tx, EventInviteSent, id, map[string]any{"note": note}, now); err != nil {
+		return err
+	}
 	return tx.Commit()
 }
 
-func (s *Store) GetProfilesNeedingFollowUp(ctx context.Context, limit int) ([]models.Profile, error) {
-	rows, err := s.db.QueryContext(ctx, `SELECT id, linkedin_url, name, headline, company, location FROM profiles WHERE connection_sent = 1 AND connection_accepted = 1 AND message_sent = 0 ORDER BY id LIMIT ?`, limit)
+// FollowUpDue is an accepted profile whose next sequence step is due. Step
+// is the 0-based index into the configured sequence.
+type FollowUpDue struct {
+	Profile models.Profile
+	Step    int
+}
+
+// AcceptanceDelay holds the first follow-up back after acceptance: at least
+// Min, plus a share of Jitter that is fixed per profile so every run agrees
+// on when it is due.
+type AcceptanceDelay struct {
+	Min    time.Duration
+	Jitter time.Duration
+}
+
+// For returns the wait after acceptance for profile id.
+func (d AcceptanceDelay) For(id int64) time.Duration {
+	if d.Jitter <= 0 {
+		return d.Min
+	}
+	return d.Min + time.Duration(rand.New(rand.NewSource(id)).Int63n(int64(d.Jitter)))
+}
+
+// GetFollowUpsDue returns up to limit accepted profiles whose next step is
+// due. delays[i] is the wait before step i, counted from acceptance for the
+// first step and from the previous message after that; len(delays) is the
+// sequence length, so finished profiles are never returned. The first step
+// also waits accepted.For the profile after acceptance; profiles accepted
+// less than accepted.Min ago aren't even read. Profiles that replied or
+// were closed are dropped from the sequence, and those with an open or
+// failed message job are left to it.
+func (s *Store) GetFollowUpsDue(ctx context.Context, limit int, delays []time.Duration, accepted AcceptanceDelay, tags []string) ([]FollowUpDue, error) {
+	now := time.Now()
+	args := []any{len(delays), now.Add(-accepted.Min)}
+	tagged, tagArgs := taggedWith("p.id", tags)
+	queued, jobArgs := withoutJob("p.id", JobMessage)
+	args = append(append(args, tagArgs...), jobArgs...)
+	rows, err := s.db.QueryContext(ctx, `SELECT p.id, p.linkedin_url, p.name, p.headline, p.company, p.location, p.source, p.member_urn,
+		COALESCE(ms.steps_sent, 0), p.connection_checked_at, ms.last_sent_at
+	FROM profiles p LEFT JOIN message_sequences ms ON ms.profile_id = p.id
+	WHERE p.status IN ('accepted', 'messaged') AND COALESCE(ms.steps_sent, 0) < ?
+		AND (COALESCE(ms.steps_sent, 0) > 0 OR p.connection_checked_at IS NULL OR p.connection_checked_at <= ?)`+tagged+queued+`
+	ORDER BY p.id`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []FollowUpDue
+	for rows.Next() && len(out) < limit {
+		var d FollowUpDue
+		var name, headline, company, location sql.NullString
+		var acceptedAt, lastSentAt sql.NullTime
+		if err := rows.Scan(&d.Profile.ID, &d.Profile.LinkedInURL, &name, &headline, &company, &location, &d.Profile.Source, &d.Profile.MemberURN,
+			&d.Step, &acceptedAt, &lastSentAt); err != nil {
+			return nil, err
+		}
+		d.Profile.Name, d.Profile.Headline, d.Profile.Company, d.Profile.Location = name.String, headline.String, company.String, location.String
+		since, wait := lastSentAt, delays[d.Step]
+		if d.Step == 0 {
+			since, wait = acceptedAt, max(wait, accepted.For(d.Profile.ID))
+		}
+		if since.Valid && now.Before(since.Time.Add(wait)) {
+			continue
+		}
+		out = append(out, d)
+	}
+	return out, rows.Err()
+}
+
+// MarkMessageSent records that sequence step (0-based) was sent. The first
+// step also flips the profile to messaged.
+func (s *Store) MarkMessageSent(ctx context.Context, id int64, step int, content string) error {
+	now := time.Now()
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+	if step == 0 {
+		if err := transition(ctx, tx, id, models.StatusMessaged, "first follow-up sent", now); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `UPDATE profiles SET message_sent_at = ? WHERE id = ?`, now, id); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.ExecContext(ctx, `INSERT INTO message_sequences (profile_id, steps_sent, last_sent_at) VALUES (?, ?, ?)
+		ON CONFLICT(profile_id) DO UPDATE SET steps_sent = excluded.steps_sent, last_sent_at = excluded.last_sent_at`, id, step+1, now); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `INSERT INTO message_logs (profile_id, type, content, created_at) VALUES (?, ?, ?, ?)`, id, string(models.MessageTypeFollowUp), content, now); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetPendingAcceptanceChecks returns invited profiles not yet accepted. When
+// sentBefore is non-zero only invites sent before that instant are returned,
+// so a run can skip the connections it just sent.
+func (s *Store) GetPendingAcceptanceChecks(ctx context.Context, limit int, sentBefore time.Time) ([]models.Profile, error) {
+	q := `SELECT id, linkedin_url FROM profiles WHERE status = 'invited'`
+	args := []any{}
+	if !sentBefore.IsZero() {
+		q += ` AND connection_sent_at < ?`
+		args = append(args, sentBefore)
+	}
+	q += ` ORDER BY connection_sent_at ASC LIMIT ?`
+	args = append(args, limit)
+	rows, err := s.db.QueryContext(ctx, q, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -128,7 +582,7 @@ func (s *Store) GetProfilesNeedingFollowUp(ctx context.Context, limit int) ([]mo
 	var out []models.Profile
 	for rows.Next() {
 		var p models.Profile
-		if err := rows.Scan(&p.ID, &p.LinkedInURL, &p.Name, &p.Headline, &p.Company, &p.Location); err != nil {
+		if err := rows.Scan(&p.ID, &p.LinkedInURL); err != nil {
 			return nil, err
 		}
 		out = append(out, p)
@@ -136,24 +590,92 @@ func (s *Store) GetProfilesNeedingFollowUp(ctx context.Context, limit int) ([]mo
 	return out, nil
 }
 
-func (s *Store) MarkMessageSent(ctx context.Context, id int64, content string) error {
+// GetProfilesAwaitingReply returns messaged profiles that have not replied and
+// still have steps left in a sequence of the given length, least recently
+// messaged first.
+func (s *Store) GetProfilesAwaitingReply(ctx context.Context, limit, steps int) ([]models.Profile, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT p.id, p.linkedin_url, p.name, p.headline, p.company, p.location, p.source, p.member_urn, p.degree, p.open_profile
+	FROM profiles p LEFT JOIN message_sequences ms ON ms.profile_id = p.id
+	WHERE p.status = 'messaged' AND COALESCE(ms.steps_sent, 0) < ?
+	ORDER BY COALESCE(ms.last_sent_at, p.message_sent_at) ASC LIMIT ?`, steps, limit)
+	if err != nil {
+		return nil, err
+	}
+	return scanQueue(rows)
+}
+
+// MarkReplied moves a profile to replied, which ends its follow-up
+// sequence.
+func (s *Store) MarkReplied(ctx context.Context, id int64) error {
+	return s.stamp(ctx, id, "replied_at", models.StatusReplied, "reply detected")
+}
+
+// stamp moves a profile to status and sets the timestamp column that
+// records when it got there.
+func (s *Store) stamp(ctx context.Context, id int64, column string, status models.ProfileStatus, reason string) error {
 	now := time.Now()
 	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
 	defer func() { _ = tx.Rollback() }()
-	if _, err := tx.ExecContext(ctx, `UPDATE profiles SET message_sent = 1, message_sent_at = ?, updated_at = ? WHERE id = ?`, now, now, id); err != nil {
+	var from models.ProfileStatus
+	if err := tx.QueryRowContext(ctx, `SELECT status FROM profiles WHERE id = ?`, id).Scan(&from); err != nil {
 		return err
 	}
-	if _, err := tx.ExecContext(ctx, `INSERT INTO message_logs (profile_id, type, content, created_at) VALUES (?, ?, ?, ?)`, id, string(models.MessageTypeFollowUp), content, now); err != nil {
+	if err := transition(ctx, tx, id, status, reason, now); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE profiles SET `+column+` = ? WHERE id = ?`, now, id); err != nil {
 		return err
 	}
+	if status == models.StatusAccepted && from != status {
+		if err := addEvent(ctx, tx, EventAccepted, id, nil, now); err != nil {
+			return err
+		}
+	}
 	return tx.Commit()
 }
 
-func (s *Store) GetPendingAcceptanceChecks(ctx context.Context, limit int) ([]models.Profile, error) {
-	rows, err := s.db.QueryContext(ctx, `SELECT id, linkedin_url FROM profiles WHERE connection_sent = 1 AND connection_accepted = 0 ORDER BY connection_sent_at ASC LIMIT ?`, limit)
+// ExpireInvites moves invites sent before sentBefore and still unanswered
+// to expired, so acceptance checks stop visiting them. It returns how many
+// expired.
+func (s *Store) ExpireInvites(ctx context.Context, sentBefore time.Time) (int, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT id FROM profiles WHERE status = 'invited' AND connection_sent_at < ?`, sentBefore)
+	if err != nil {
+		return 0, err
+	}
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil || len(ids) == 0 {
+		return 0, err
+	}
+	now := time.Now()
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = tx.Rollback() }()
+	for _, id := range ids {
+		if err := transition(ctx, tx, id, models.StatusExpired, "invite unanswered", now); err != nil {
+			return 0, err
+		}
+	}
+	return len(ids), tx.Commit()
+}
+
+// GetPendingInvites returns every invite still awaiting an answer, expired
+// ones included, with the time it was sent.
+func (s *Store) GetPendingInvites(ctx context.Context) ([]models.Profile, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT id, linkedin_url, connection_sent_at, status FROM profiles WHERE status IN ('invited', 'expired')`)
 	if err != nil {
 		return nil, err
 	}
@@ -161,28 +683,63 @@ func (s *Store) GetPendingAcceptanceChecks(ctx context.Context, limit int) ([]mo
 	var out []models.Profile
 	for rows.Next() {
 		var p models.Profile
-		if err := rows.Scan(&p.ID, &p.LinkedInURL); err != nil {
+		var sentAt sql.NullTime
+		if err := rows.Scan(&p.ID, &p.LinkedInURL, &sentAt, &p.Status); err != nil {
 			return nil, err
 		}
+		if sentAt.Valid {
+			p.ConnectionSentAt = &sentAt.Time
+		}
 		out = append(out, p)
 	}
-	return out, nil
+	return out, rows.Err()
+}
+
+// MarkWithdrawn records that a pending invite was withdrawn. Withdrawn
+// profiles are not invited again.
+func (s *Store) MarkWithdrawn(ctx context.Context, id int64) error {
+	return s.stamp(ctx, id, "withdrawn_at", models.StatusWithdrawn, "invite withdrawn")
 }
 
 func (s *Store) MarkAccepted(ctx context.Context, id int64) error {
-	now := time.Now()
-	_, err := s.db.ExecContext(ctx, `UPDATE profiles SET connection_accepted = 1, connection_checked_at = ?, updated_at = ? WHERE id = ?`, now, now, id)
-	return err
+	return s.stamp(ctx, id, "connection_checked_at", models.StatusAccepted, "invite accepted")
+}
+
+// MarkAlreadyConnected records a 1st-degree connection found on LinkedIn,
+// adding the profile if it is new. A pending invite is marked accepted; a
+// profile still in the queue becomes an existing contact and is never
+// invited. It reports whether anything changed.
+func (s *Store) MarkAlreadyConnected(ctx context.Context, p *models.Profile) (bool, error) {
+	added, err := s.ImportProfile(ctx, p)
+	if err != nil {
+		return false, err
+	}
+	var id int64
+	var status models.ProfileStatus
+	if err := s.db.QueryRowContext(ctx, `SELECT id, status FROM profiles WHERE linkedin_url = ?`, p.LinkedInURL).Scan(&id, &status); err != nil {
+		return false, err
+	}
+	switch status {
+	case models.StatusInvited, models.StatusExpired:
+		err = s.stamp(ctx, id, "connection_checked_at", models.StatusAccepted, "found among connections")
+	case models.StatusDiscovered, models.StatusQueued:
+		err = s.stamp(ctx, id, "already_connected_at", models.StatusConnected, "found among connections")
+	default:
+		return added, nil
+	}
+	return err == nil, err
 }
 
 func (s *Store) CountActionsToday(ctx context.Context, table, typeFilter string) (int, error) {
+	now := time.Now()
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
 	var row *sql.Row
 	if table == "message_logs" && typeFilter != "" {
-		row = s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM message_logs WHERE type = ? AND DATE(created_at) = DATE('now', 'localtime')`, typeFilter)
+		row = s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM message_logs WHERE type = ? AND created_at >= ?`, typeFilter, midnight)
 	} else if table == "message_logs" {
-		row = s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM message_logs WHERE DATE(created_at) = DATE('now', 'localtime')`)
+		row = s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM message_logs WHERE created_at >= ?`, midnight)
 	} else if table == "profiles" {
-		row = s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM profiles WHERE connection_sent = 1 AND DATE(connection_sent_at) = DATE('now', 'localtime')`)
+		row = s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM profiles WHERE connection_sent_at >= ?`, midnight)
 	} else {
 		return 0, errors.New("unsupported table for CountActionsToday")
 	}
@@ -192,3 +749,641 @@ func (s *Store) CountActionsToday(ctx context.Context, table, typeFilter string)
 	}
 	return c, nil
 }
+
+// ActionLog is one browser action taken on behalf of a profile. ProfileID is
+// 0 for profiles not in the database (e.g. invites sent by hand).
+type ActionLog struct {
+	ID         int64
+	ProfileID  int64
+	ProfileURL string
+	Action     string
+	Target     string
+	Detail     string
+	BeforePath string
+	AfterPath  string
+	Error      string
+	CreatedAt  time.Time
+}
+
+func (s *Store) InsertActionLog(ctx context.Context, a *ActionLog) (int64, error) {
+	var profileID any
+	if a.ProfileID != 0 {
+		profileID = a.ProfileID
+	}
+	var id int64
+	err := s.db.QueryRowContext(ctx, `INSERT INTO action_logs (profile_id, profile_url, action, target, detail, before_screenshot, after_screenshot, error, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING id`, profileID, a.ProfileURL, a.Action, a.Target, a.Detail, a.BeforePath, a.AfterPath, a.Error, a.CreatedAt).Scan(&id)
+	return id, err
+}
+
+// GetActionLogs returns the latest actions, oldest first so they read as a
+// timeline. A non-empty profileURL keeps only that profile's actions.
+func (s *Store) GetActionLogs(ctx context.Context, profileURL string, limit int) ([]ActionLog, error) {
+	q := `SELECT id, COALESCE(profile_id, 0), profile_url, action, target, detail, before_screenshot, after_screenshot, error, created_at FROM action_logs`
+	var args []any
+	if profileURL != "" {
+		q += ` WHERE profile_url = ?`
+		args = append(args, profileURL)
+	}
+	q = `SELECT * FROM (` + q + ` ORDER BY created_at DESC, id DESC LIMIT ?) AS recent ORDER BY created_at, id`
+	args = append(args, limit)
+	rows, err := s.db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []ActionLog
+	for rows.Next() {
+		var a ActionLog
+		if err := rows.Scan(&a.ID, &a.ProfileID, &a.ProfileURL, &a.Action, &a.Target, &a.Detail, &a.BeforePath, &a.AfterPath, &a.Error, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, a)
+	}
+	return out, rows.Err()
+}
+
+// RunLog is one recorded command execution or daemon job. Summary is the
+// command's JSON result.
+type RunLog struct {
+	ID        int64
+	RunType   string
+	StartedAt time.Time
+	EndedAt   time.Time
+	OK        bool
+	Processed int
+	Succeeded int
+	Failed    int
+	Summary   string
+}
+
+func (s *Store) InsertRunLog(ctx context.Context, r *RunLog) (int64, error) {
+	var id int64
+	err := s.db.QueryRowContext(ctx, `INSERT INTO run_logs (run_type, started_at, ended_at, ok, processed, succeeded, failed, summary) VALUES (?, ?, ?, ?, ?, ?, ?, ?) RETURNING id`,
+		r.RunType, r.StartedAt, r.EndedAt, r.OK, r.Processed, r.Succeeded, r.Failed, r.Summary).Scan(&id)
+	return id, err
+}
+
+// GetRecentRuns returns the latest runs, newest first. A non-empty runType
+// keeps only runs of that command.
+func (s *Store) GetRecentRuns(ctx context.Context, limit int, runType string) ([]RunLog, error) {
+	q := `SELECT id, run_type, started_at, ended_at, ok, processed, succeeded, failed, COALESCE(summary, '') FROM run_logs`
+	var args []any
+	if runType != "" {
+		q += ` WHERE run_type = ?`
+		args = append(args, runType)
+	}
+	q += ` ORDER BY started_at DESC, id DESC LIMIT ?`
+	args = append(args, limit)
+	rows, err := s.db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []RunLog
+	for rows.Next() {
+		var r RunLog
+		if err := rows.Scan(&r.ID, &r.RunType, &r.StartedAt, &r.EndedAt, &r.OK, &r.Processed, &r.Succeeded, &r.Failed, &r.Summary); err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
+// RunProgress is the position of a batch command. It is saved after every
+// profile and cleared when the batch completes, so a row left behind means
+// the last run was interrupted.
+type RunProgress struct {
+	Command       string
+	Batch         int
+	Processed     int
+	Sent          int
+	Failed        int
+	LastProfileID int64
+	Cursor        string
+}
+
+// Step records one processed profile.
+func (p *RunProgress) Step(profileID int64, ok bool) {
+	p.Processed++
+	p.LastProfileID = profileID
+	if ok {
+		p.Sent++
+	} else {
+		p.Failed++
+	}
+}
+
+// Remaining is how much of the batch is left.
+func (p *RunProgress) Remaining() int { return p.Batch - p.Processed }
+
+// ResumeBatch returns the progress of an interrupted run of command, with
+// resumed set, or a fresh record for a batch of the given size.
+func (s *Store) ResumeBatch(ctx context.Context, command string, batch int) (*RunProgress, bool, error) {
+	p := &RunProgress{Command: command}
+	err := s.db.QueryRowContext(ctx, `SELECT batch, processed, sent, failed, last_profile_id, cursor FROM run_progress WHERE command = ?`, command).
+		Scan(&p.Batch, &p.Processed, &p.Sent, &p.Failed, &p.LastProfileID, &p.Cursor)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && p.Remaining() <= 0) {
+		return &RunProgress{Command: command, Batch: batch}, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return p, true, nil
+}
+
+// SaveRunProgress stores the current position of a batch.
+func (s *Store) SaveRunProgress(ctx context.Context, p *RunProgress) error {
+	_, err := s.db.ExecContext(ctx, `INSERT INTO run_progress (command, batch, processed, sent, failed, last_profile_id, cursor, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(command) DO UPDATE SET batch = excluded.batch, processed = excluded.processed, sent = excluded.sent, failed = excluded.failed,
+		last_profile_id = excluded.last_profile_id, cursor = excluded.cursor, updated_at = excluded.updated_at`,
+		p.Command, p.Batch, p.Processed, p.Sent, p.Failed, p.LastProfileID, p.Cursor, time.Now())
+	return err
+}
+
+// FinishBatch keeps the progress of an interrupted batch for the next run and
+// clears it otherwise.
+func (s *Store) FinishBatch(ctx context.Context, p *RunProgress, interrupted bool) error {
+	if interrupted {
+		return s.SaveRunProgress(ctx, p)
+	}
+	_, err := s.db.ExecContext(ctx, `DELETE FROM run_progress WHERE command = ?`, p.Command)
+	return err
+}
+
+// GetMessageTimes returns when messages of type typ were sent since the
+// given time, oldest first.
+func (s *Store) GetMessageTimes(ctx context.Context, typ models.MessageType, since time.Time) ([]time.Time, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT created_at FROM message_logs WHERE type = ? AND created_at >= ? ORDER BY created_at`, string(typ), since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []time.Time
+	for rows.Next() {
+		var t time.Time
+		if err := rows.Scan(&t); err != nil {
+			return nil, err
+		}
+		out = append(out, t)
+	}
+	return out, rows.Err()
+}
+
+// Exclusion is a company, name pattern or profile URL added with
+// `blacklist add`.
+type Exclusion struct {
+	ID        int64
+	Kind      string
+	Value     string
+	CreatedAt time.Time
+}
+
+// AddExclusion stores an exclusion and reports whether it was new.
+func (s *Store) AddExclusion(ctx context.Context, kind, value string) (bool, error) {
+	res, err := s.db.ExecContext(ctx, `INSERT INTO exclusions (kind, value, created_at) VALUES (?, ?, ?) ON CONFLICT DO NOTHING`, kind, value, time.Now())
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// RemoveExclusion deletes an exclusion and reports whether it existed.
+func (s *Store) RemoveExclusion(ctx context.Context, kind, value string) (bool, error) {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM exclusions WHERE kind = ? AND value = ?`, kind, value)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+func (s *Store) GetExclusions(ctx context.Context) ([]Exclusion, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT id, kind, value, created_at FROM exclusions ORDER BY kind, value`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Exclusion
+	for rows.Next() {
+		var e Exclusion
+		if err := rows.Scan(&e.ID, &e.Kind, &e.Value, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
+
+// AccountStartedAt returns when the bot first ran for this account's
+// database, which warm-up counts the account's age from.
+func (s *Store) AccountStartedAt(ctx context.Context) (time.Time, error) {
+	var t time.Time
+	err := s.db.QueryRowContext(ctx, `SELECT started_at FROM account WHERE id = 1`).Scan(&t)
+	return t, err
+}
+
+// Engagement is a like and/or comment left on a profile's post before
+// inviting it. An empty PostURL records a profile with no recent post, which
+// is not visited again.
+type Engagement struct {
+	ID        int64
+	ProfileID int64
+	PostURL   string
+	// PostText is the start of the post, for llm prompts
+	PostText  string
+	Liked     bool
+	Comment   string
+	CreatedAt time.Time
+}
+
+// Engaged reports whether anything was left on the post.
+func (e *Engagement) Engaged() bool { return e.Liked || e.Comment != "" }
+
+// InsertEngagement records e and, when something was left on the post, the
+// profile's first engagement time.
+func (s *Store) InsertEngagement(ctx context.Context, e *Engagement) error {
+	e.CreatedAt = time.Now()
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+	if err := tx.QueryRowContext(ctx, `INSERT INTO engagements (profile_id, post_url, post_text, liked, comment, created_at) VALUES (?, ?, ?, ?, ?, ?) RETURNING id`,
+		e.ProfileID, e.PostURL, e.PostText, e.Liked, e.Comment, e.CreatedAt).Scan(&e.ID); err != nil {
+		return err
+	}
+	if e.Engaged() {
+		if _, err := tx.ExecContext(ctx, `UPDATE profiles SET engaged_at = COALESCE(engaged_at, ?), updated_at = ? WHERE id = ?`,
+			e.CreatedAt, e.CreatedAt, e.ProfileID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetProfilesToEngage returns uninvited profiles never visited by engage, in
+// discovery order.
+func (s *Store) GetProfilesToEngage(ctx context.Context, limit int) ([]models.Profile, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT id, linkedin_url, name, headline, company, location, source, member_urn, degree, open_profile
+	FROM profiles p WHERE `+inQueue+`
+		AND NOT EXISTS (SELECT 1 FROM engagements e WHERE e.profile_id = p.id)
+	ORDER BY id LIMIT ?`, limit)
+	if err != nil {
+		return nil, err
+	}
+	return scanQueue(rows)
+}
+
+// GetProfilesToEndorse returns accepted connections whose skills were not
+// visited by endorse yet, oldest acceptance first.
+func (s *Store) GetProfilesToEndorse(ctx context.Context) ([]models.Profile, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT id, linkedin_url, name, headline, company, location, source, member_urn, degree, open_profile
+	FROM profiles WHERE connection_checked_at IS NOT NULL AND `+contactable+` AND endorsed_at IS NULL
+	ORDER BY connection_checked_at, id`)
+	if err != nil {
+		return nil, err
+	}
+	return scanQueue(rows)
+}
+
+// MarkEndorsed records the endorse visit to a profile and the skills
+// endorsed, so it is not visited again.
+func (s *Store) MarkEndorsed(ctx context.Context, id int64, skills []string) error {
+	now := time.Now()
+	_, err := s.db.ExecContext(ctx, `UPDATE profiles SET endorsed_at = ?, endorsed_skills = ?, updated_at = ? WHERE id = ?`,
+		now, strings.Join(skills, ", "), now, id)
+	return err
+}
+
+// GetConnections returns every 1st-degree connection still open to
+// outreach: accepted invites and existing contacts.
+func (s *Store) GetConnections(ctx context.Context) ([]models.Profile, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT id, linkedin_url, name, headline, company, location, source, member_urn, degree, open_profile
+	FROM profiles WHERE (connection_checked_at IS NOT NULL OR already_connected_at IS NOT NULL) AND `+contactable+` ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	return scanQueue(rows)
+}
+
+// NurtureEvent is a job change, work anniversary or birthday of a connection
+// queued for a congratulation message.
+type NurtureEvent struct {
+	ID        int64
+	Profile   models.Profile
+	Kind      string
+	Detail    string // notification text
+	CreatedAt time.Time
+}
+
+// QueueNurture queues an event for a profile. Each kind is queued at most
+// once a year per profile, so rescanning the same notification is a no-op;
+// ok is false then.
+func (s *Store) QueueNurture(ctx context.Context, profileID int64, kind, detail string) (bool, error) {
+	now := time.Now()
+	res, err := s.db.ExecContext(ctx, `INSERT INTO nurture_events (profile_id, kind, detail, year, created_at) VALUES (?, ?, ?, ?, ?) ON CONFLICT DO NOTHING`,
+		profileID, kind, detail, now.Year(), now)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// GetNurtureDue returns up to limit unsent events queued after since, oldest
+// first.
+func (s *Store) GetNurtureDue(ctx context.Context, limit int, since time.Time) ([]NurtureEvent, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT n.id, n.kind, n.detail, n.created_at,
+		p.id, p.linkedin_url, COALESCE(p.name, ''), COALESCE(p.headline, ''), COALESCE(p.company, ''), COALESCE(p.location, ''), p.source, p.member_urn
+	FROM nurture_events n JOIN profiles p ON p.id = n.profile_id
+	WHERE n.sent_at IS NULL AND n.created_at >= ? AND `+contactable+`
+	ORDER BY n.created_at, n.id LIMIT ?`, since, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []NurtureEvent
+	for rows.Next() {
+		var e NurtureEvent
+		p := &e.Profile
+		if err := rows.Scan(&e.ID, &e.Kind, &e.Detail, &e.CreatedAt,
+			&p.ID, &p.LinkedInURL, &p.Name, &p.Headline, &p.Company, &p.Location, &p.Source, &p.MemberURN); err != nil {
+			return nil, err
+		}
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
+
+// MarkNurtureSent records the congratulation sent for an event.
+func (s *Store) MarkNurtureSent(ctx context.Context, e *NurtureEvent, content string) error {
+	now := time.Now()
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+	if _, err := tx.ExecContext(ctx, `UPDATE nurture_events SET message = ?, sent_at = ? WHERE id = ?`, content, now, e.ID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `INSERT INTO message_logs (profile_id, type, content, created_at) VALUES (?, ?, ?, ?)`, e.Profile.ID, string(models.MessageTypeNurture), content, now); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Cooldown blocks automated actions after LinkedIn showed a checkpoint. A
+// nil Until lasts until it is cleared by hand.
+type Cooldown struct {
+	Kind       string
+	URL        string
+	DetectedAt time.Time
+	Until      *time.Time
+}
+
+// Active reports whether the cooldown still blocks actions at now.
+func (c *Cooldown) Active(now time.Time) bool {
+	return c != nil && (c.Until == nil || now.Before(*c.Until))
+}
+
+// SetCooldown replaces the current cooldown.
+func (s *Store) SetCooldown(ctx context.Context, c *Cooldown) error {
+	_, err := s.db.ExecContext(ctx, `INSERT INTO cooldown (id, kind, url, detected_at, until) VALUES (1, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET kind = excluded.kind, url = excluded.url, detected_at = excluded.detected_at, until = excluded.until`,
+		c.Kind, c.URL, c.DetectedAt, c.Until)
+	return err
+}
+
+// GetCooldown returns the last cooldown, expired or not, or nil if none was
+// set.
+func (s *Store) GetCooldown(ctx context.Context) (*Cooldown, error) {
+	var c Cooldown
+	var until sql.NullTime
+	err := s.db.QueryRowContext(ctx, `SELECT kind, url, detected_at, until FROM cooldown WHERE id = 1`).Scan(&c.Kind, &c.URL, &c.DetectedAt, &until)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	c.Until = timePtr(until)
+	return &c, nil
+}
+
+// ClearCooldown removes the cooldown and reports whether there was one.
+func (s *Store) ClearCooldown(ctx context.Context) (bool, error) {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM cooldown WHERE id = 1`)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// InviteLimitKind is the Kind of the cooldown GetInviteCooldown returns.
+const InviteLimitKind = "weekly invitation limit"
+
+// SetInviteCooldown holds invites until until, after LinkedIn showed its
+// weekly invitation limit at detectedAt. Other actions are not affected.
+func (s *Store) SetInviteCooldown(ctx context.Context, detectedAt, until time.Time) error {
+	_, err := s.db.ExecContext(ctx, `INSERT INTO invite_cooldown (id, detected_at, until) VALUES (1, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET detected_at = excluded.detected_at, until = excluded.until`, detectedAt, until)
+	return err
+}
+
+// GetInviteCooldown returns the last invite cooldown, expired or not, or nil
+// if none was set.
+func (s *Store) GetInviteCooldown(ctx context.Context) (*Cooldown, error) {
+	c := Cooldown{Kind: InviteLimitKind}
+	var until time.Time
+	err := s.db.QueryRowContext(ctx, `SELECT detected_at, until FROM invite_cooldown WHERE id = 1`).Scan(&c.DetectedAt, &until)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	c.Until = &until
+	return &c, nil
+}
+
+// ClearInviteCooldown removes the invite cooldown and reports whether there
+// was one.
+func (s *Store) ClearInviteCooldown(ctx context.Context) (bool, error) {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM invite_cooldown WHERE id = 1`)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// RecordRateEvent counts one action of kind against the rate limiter budgets.
+func (s *Store) RecordRateEvent(ctx context.Context, kind string) error {
+	_, err := s.db.ExecContext(ctx, `INSERT INTO rate_events (kind, created_at) VALUES (?, ?)`, kind, time.Now())
+	return err
+}
+
+// GetRateEvents returns when actions of kind were done since the given time,
+// oldest first.
+func (s *Store) GetRateEvents(ctx context.Context, kind string, since time.Time) ([]time.Time, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT created_at FROM rate_events WHERE kind = ? AND created_at >= ? ORDER BY created_at`, kind, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []time.Time
+	for rows.Next() {
+		var t time.Time
+		if err := rows.Scan(&t); err != nil {
+			return nil, err
+		}
+		out = append(out, t)
+	}
+	return out, rows.Err()
+}
+
+// ExportProfiles returns full profile rows for export. filter is "accepted",
+// "pending" (invited, no answer yet), "messaged" or "" for every profile.
+func (s *Store) ExportProfiles(ctx context.Context, filter string) ([]models.Profile, error) {
+	var where string
+	switch filter {
+	case "":
+	case "accepted":
+		where = ` WHERE connection_checked_at IS NOT NULL`
+	case "pending":
+		where = ` WHERE status = 'invited'`
+	case "messaged":
+		where = ` WHERE message_sent_at IS NOT NULL`
+	default:
+		return nil, fmt.Errorf("unknown export filter: %s", filter)
+	}
+	rows, err := s.db.QueryContext(ctx, `SELECT id, linkedin_url, name, headline, company, location, source, degree, open_profile, requires_email, email, status,
+		connection_sent_at, connection_checked_at, message_sent_at, replied_at, withdrawn_at, created_at, engaged_at, viewed_at, endorsed_skills
+	FROM profiles`+where+` ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []models.Profile
+	for rows.Next() {
+		var p models.Profile
+		var name, headline, company, location sql.NullString
+		var sentAt, checkedAt, messagedAt, repliedAt, withdrawnAt, engagedAt, viewedAt sql.NullTime
+		if err := rows.Scan(&p.ID, &p.LinkedInURL, &name, &headline, &company, &location, &p.Source, &p.Degree, &p.OpenProfile, &p.RequiresEmail, &p.Email, &p.Status,
+			&sentAt, &checkedAt, &messagedAt, &repliedAt, &withdrawnAt, &p.CreatedAt, &engagedAt, &viewedAt, &p.EndorsedSkills); err != nil {
+			return nil, err
+		}
+		p.Name, p.Headline, p.Company, p.Location = name.String, headline.String, company.String, location.String
+		p.ConnectionSentAt = timePtr(sentAt)
+		p.ConnectionCheckedAt = timePtr(checkedAt)
+		p.MessageSentAt = timePtr(messagedAt)
+		p.RepliedAt = timePtr(repliedAt)
+		p.WithdrawnAt = timePtr(withdrawnAt)
+		p.EngagedAt = timePtr(engagedAt)
+		p.ViewedAt = timePtr(viewedAt)
+		out = append(out, p)
+	}
+	return out, rows.Err()
+}
+
+func timePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+// GetProfilesNeedingEnrichment returns profiles never enriched or enriched
+// before staleBefore, never-enriched first.
+func (s *Store) GetProfilesNeedingEnrichment(ctx context.Context, limit int, staleBefore time.Time) ([]models.Profile, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT p.id, p.linkedin_url, p.name, p.headline, p.company, p.location, p.source, p.member_urn, p.degree, p.open_profile
+	FROM profiles p LEFT JOIN profile_details d ON d.profile_id = p.id
+	WHERE d.profile_id IS NULL OR d.enriched_at < ?
+	ORDER BY d.enriched_at IS NOT NULL, d.enriched_at, p.id LIMIT ?`, staleBefore, limit)
+	if err != nil {
+		return nil, err
+	}
+	return scanQueue(rows)
+}
+
+// SaveProfileDetails replaces the stored details of a profile. Skills are
+// kept as one comma-separated column so they can be matched with LIKE.
+func (s *Store) SaveProfileDetails(ctx context.Context, d *models.ProfileDetails) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+	if _, err := tx.ExecContext(ctx, `INSERT INTO profile_details (profile_id, about, skills, mutual_connections, enriched_at) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(profile_id) DO UPDATE SET about = excluded.about, skills = excluded.skills,
+		mutual_connections = excluded.mutual_connections, enriched_at = excluded.enriched_at`,
+		d.ProfileID, d.About, strings.Join(d.Skills, ", "), d.MutualConnections, d.EnrichedAt); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM profile_experience WHERE profile_id = ?`, d.ProfileID); err != nil {
+		return err
+	}
+	for i, pos := range d.Experience {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO profile_experience (profile_id, position, title, company, dates) VALUES (?, ?, ?, ?, ?)`,
+			d.ProfileID, i, pos.Title, pos.Company, pos.Dates); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM profile_education WHERE profile_id = ?`, d.ProfileID); err != nil {
+		return err
+	}
+	for i, sch := range d.Education {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO profile_education (profile_id, position, school, degree, dates) VALUES (?, ?, ?, ?, ?)`,
+			d.ProfileID, i, sch.School, sch.Degree, sch.Dates); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// AcceptedRow is an accepted and messaged profile together with the note and
+// follow-up that were sent to it.
+type AcceptedRow struct {
+	LinkedInURL      string
+	Name             string
+	Headline         string
+	Company          string
+	Location         string
+	Source           string
+	ConnectionSentAt sql.NullTime
+	AcceptedAt       sql.NullTime
+	MessageSentAt    sql.NullTime
+	Note             string
+	FollowUp         string
+}
+
+// GetAcceptedAndMessaged joins profiles with their latest connection note and
+// follow-up from message_logs.
+func (s *Store) GetAcceptedAndMessaged(ctx context.Context) ([]AcceptedRow, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT p.linkedin_url, COALESCE(p.name, ''), COALESCE(p.headline, ''), COALESCE(p.company, ''), COALESCE(p.location, ''), p.source,
+		p.connection_sent_at, p.connection_checked_at, p.message_sent_at,
+		COALESCE(n.content, ''), m.content
+	FROM profiles p
+	JOIN message_logs m ON m.id = (SELECT id FROM message_logs WHERE profile_id = p.id AND type = ? ORDER BY created_at DESC LIMIT 1)
+	LEFT JOIN message_logs n ON n.id = (SELECT id FROM message_logs WHERE profile_id = p.id AND type = ? ORDER BY created_at DESC LIMIT 1)
+	WHERE p.connection_checked_at IS NOT NULL AND p.message_sent_at IS NOT NULL
+	ORDER BY p.message_sent_at`, string(models.MessageTypeFollowUp), string(models.MessageTypeConnectionNote))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []AcceptedRow
+	for rows.Next() {
+		var r AcceptedRow
+		if err := rows.Scan(&r.LinkedInURL, &r.Name, &r.Headline, &r.Company, &r.Location, &r.Source,
+			&r.ConnectionSentAt, &r.AcceptedAt, &r.MessageSentAt, &r.Note, &r.FollowUp); err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
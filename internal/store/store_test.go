@@ -0,0 +1,289 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/example/linkedbot/internal/models"
+)
+
+// newTestStore opens a migrated SQLite store in a temporary directory.
+func newTestStore(t *testing.T) *Store {
+	t.Helper()
+	st, err := Open("sqlite", filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(st.Close)
+	if err := st.Migrate(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return st
+}
+
+// storedURLs returns the linkedin_url of every stored profile.
+func storedURLs(t *testing.T, st *Store) []string {
+	t.Helper()
+	rows, err := st.db.QueryContext(context.Background(), `SELECT linkedin_url FROM profiles ORDER BY id`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var out []string
+	for rows.Next() {
+		var u string
+		if err := rows.Scan(&u); err != nil {
+			t.Fatal(err)
+		}
+		out = append(out, u)
+	}
+	return out
+}
+
+func TestWritePathsStoreCanonicalURL(t *testing.T) {
+	const raw = "https://in.linkedin.com/in/jane-doe/?miniProfileUrn=urn%3Ali%3Afs_miniProfile%3AACoAA&trk=people-guest"
+	const want = "https://www.linkedin.com/in/jane-doe"
+	ctx := context.Background()
+	// UpsertProfile backs search and the profile visits, ImportProfile the
+	// import command and MarkAlreadyConnected sync-connections
+	paths := map[string]func(*Store, *models.Profile) error{
+		"upsert": func(st *Store, p *models.Profile) error {
+			_, err := st.UpsertProfile(ctx, p)
+			return err
+		},
+		"import": func(st *Store, p *models.Profile) error {
+			_, err := st.ImportProfile(ctx, p)
+			return err
+		},
+		"already connected": func(st *Store, p *models.Profile) error {
+			_, err := st.MarkAlreadyConnected(ctx, p)
+			return err
+		},
+	}
+	for name, write := range paths {
+		t.Run(name, func(t *testing.T) {
+			st := newTestStore(t)
+			for _, u := range []string{raw, want, want + "/"} {
+				if err := write(st, &models.Profile{LinkedInURL: u, Name: "Jane Doe", Source: "test"}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			got := storedURLs(t, st)
+			if len(got) != 1 || got[0] != want {
+				t.Errorf("stored %q, want only %q", got, want)
+			}
+		})
+	}
+}
+
+func TestPendingAcceptanceChecksSkipInvitesSentThisRun(t *testing.T) {
+	ctx := context.Background()
+	st := newTestStore(t)
+	invite := func(url string) int64 {
+		t.Helper()
+		id, err := st.UpsertProfile(ctx, &models.Profile{LinkedInURL: url, Name: url})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := st.MarkQueued(ctx, id); err != nil {
+			t.Fatal(err)
+		}
+		if err := st.MarkConnectionSent(ctx, id, "hi"); err != nil {
+			t.Fatal(err)
+		}
+		return id
+	}
+
+	// An invite from an earlier run
+	earlier := invite("https://www.linkedin.com/in/earlier")
+	if _, err := st.db.ExecContext(ctx, `UPDATE profiles SET connection_sent_at = ? WHERE id = ?`, time.Now().Add(-time.Hour), earlier); err != nil {
+		t.Fatal(err)
+	}
+	// run-all records its start before connecting, then hands it to
+	// send-messages as sentBefore
+	runStart := time.Now()
+	invite("https://www.linkedin.com/in/this-run")
+
+	got, err := st.GetPendingAcceptanceChecks(ctx, 10, runStart)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].ID != earlier {
+		t.Fatalf("sentBefore run start: got %+v, want only profile %d", got, earlier)
+	}
+
+	got, err = st.GetPendingAcceptanceChecks(ctx, 10, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("zero sentBefore: got %d profiles, want 2", len(got))
+	}
+}
+
+func TestRoundRobinQueueBalancesSources(t *testing.T) {
+	ctx := context.Background()
+	st := newTestStore(t)
+	// The first search inserted all its results before the others ran
+	for _, src := range []struct {
+		name string
+		n    int
+	}{{"search:a", 5}, {"search:b", 2}, {"search:c", 3}} {
+		for i := 0; i < src.n; i++ {
+			url := fmt.Sprintf("https://www.linkedin.com/in/%s-%d", strings.TrimPrefix(src.name, "search:"), i)
+			if _, err := st.UpsertProfile(ctx, &models.Profile{LinkedInURL: url, Name: url, Source: src.name}); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	byID, err := st.GetProfilesNeedingConnection(ctx, 6, QueueFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := countSources(byID)["search:a"]; n != 5 {
+		t.Fatalf("id order picked %d from search:a, want 5 (the case round robin fixes)", n)
+	}
+
+	got, err := st.GetProfilesNeedingConnectionRoundRobin(ctx, 6, QueueFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]int{"search:a": 2, "search:b": 2, "search:c": 2}
+	if counts := countSources(got); !reflect.DeepEqual(counts, want) {
+		t.Errorf("round robin picked %v, want %v", counts, want)
+	}
+	// Each round takes one profile per source before any source gets a second
+	if first := countSources(got[:3]); len(first) != 3 {
+		t.Errorf("first round covered %v, want one profile from each source", first)
+	}
+	// A source that runs out leaves the rest to the others
+	got, err = st.GetProfilesNeedingConnectionRoundRobin(ctx, 10, QueueFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if counts := countSources(got); counts["search:a"] != 5 || counts["search:b"] != 2 || counts["search:c"] != 3 {
+		t.Errorf("round robin with room for everyone picked %v", counts)
+	}
+}
+
+func countSources(ps []models.Profile) map[string]int {
+	out := map[string]int{}
+	for _, p := range ps {
+		out[p.Source]++
+	}
+	return out
+}
+
+func TestGetAcceptedAndMessaged(t *testing.T) {
+	ctx := context.Background()
+	st := newTestStore(t)
+	// add walks a profile through the pipeline: an invite with note,
+	// accepted when followUps is not nil, then each follow-up sent
+	add := func(slug, note string, followUps []string) {
+		t.Helper()
+		id, err := st.UpsertProfile(ctx, &models.Profile{LinkedInURL: "https://www.linkedin.com/in/" + slug, Name: slug, Headline: "Engineer", Company: "Acme " + slug, Source: "search:go"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := st.MarkQueued(ctx, id); err != nil {
+			t.Fatal(err)
+		}
+		if err := st.MarkConnectionSent(ctx, id, note); err != nil {
+			t.Fatal(err)
+		}
+		if followUps == nil {
+			return
+		}
+		if err := st.MarkAccepted(ctx, id); err != nil {
+			t.Fatal(err)
+		}
+		for step, msg := range followUps {
+			if err := st.MarkMessageSent(ctx, id, step, msg); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	add("messaged", "Hi, let's connect", []string{"Thanks for connecting", "Following up"})
+	add("accepted-only", "Hi", []string{})
+	add("pending", "Hi", nil)
+	add("no-note", "", []string{"Thanks"})
+
+	rows, err := st.GetAcceptedAndMessaged(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("got %d rows, want the 2 accepted and messaged profiles: %+v", len(rows), rows)
+	}
+	got := rows[0]
+	if got.LinkedInURL != "https://www.linkedin.com/in/messaged" || got.Company != "Acme messaged" || got.Source != "search:go" {
+		t.Errorf("first row is %+v", got)
+	}
+	if got.Note != "Hi, let's connect" || got.FollowUp != "Following up" {
+		t.Errorf("note %q, follow-up %q; want the note and the latest follow-up", got.Note, got.FollowUp)
+	}
+	if !got.ConnectionSentAt.Valid || !got.AcceptedAt.Valid || !got.MessageSentAt.Valid {
+		t.Errorf("missing timestamps: %+v", got)
+	}
+	if rows[1].LinkedInURL != "https://www.linkedin.com/in/no-note" || rows[1].Note != "" || rows[1].FollowUp != "Thanks" {
+		t.Errorf("second row is %+v", rows[1])
+	}
+}
+
+func TestPostgresRebind(t *testing.T) {
+	got := postgresDialect{}.rebind(`SELECT id FROM profiles WHERE status = ? AND source IN (?, ?) AND name <> '?'`)
+	want := `SELECT id FROM profiles WHERE status = $1 AND source IN ($2, $3) AND name <> '?'`
+	if got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+}
+
+func TestTxnQueryRebinds(t *testing.T) {
+	ctx := context.Background()
+	st := newTestStore(t)
+	if _, err := st.UpsertProfile(ctx, &models.Profile{LinkedInURL: "https://www.linkedin.com/in/a", Source: "search:a"}); err != nil {
+		t.Fatal(err)
+	}
+	tx, err := st.db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = tx.Rollback() }()
+	rows, err := tx.QueryContext(ctx, `SELECT linkedin_url FROM profiles WHERE source = ? AND open_profile = ?`, "search:a", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var n int
+	for rows.Next() {
+		n++
+	}
+	if n != 1 {
+		t.Errorf("got %d rows, want 1", n)
+	}
+}
+
+func TestUpsertDedupesSlugSpellings(t *testing.T) {
+	ctx := context.Background()
+	st := newTestStore(t)
+	var ids []int64
+	for _, u := range []string{"https://www.linkedin.com/in/Jürgen-M/", "https://www.linkedin.com/in/j%C3%BCrgen-m", "https://de.linkedin.com/in/JÜRGEN-M?trk=x"} {
+		id, err := st.UpsertProfile(ctx, &models.Profile{LinkedInURL: u, Name: "Jürgen M"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+	}
+	if ids[0] != ids[1] || ids[1] != ids[2] {
+		t.Errorf("spellings of one slug got ids %v", ids)
+	}
+	if got := storedURLs(t, st); !reflect.DeepEqual(got, []string{"https://www.linkedin.com/in/j%C3%BCrgen-m"}) {
+		t.Errorf("stored %v", got)
+	}
+}
@@ -0,0 +1,136 @@
+package store
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// AddTag attaches tag to a profile, creating the tag on first use. It
+// reports whether the profile did not have the tag yet.
+func (s *Store) AddTag(ctx context.Context, profileID int64, tag string) (bool, error) {
+	now := time.Now()
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = tx.Rollback() }()
+	if _, err := tx.ExecContext(ctx, `INSERT INTO tags (name, created_at) VALUES (?, ?) ON CONFLICT (name) DO NOTHING`, tag, now); err != nil {
+		return false, err
+	}
+	var tagID int64
+	if err := tx.QueryRowContext(ctx, `SELECT id FROM tags WHERE name = ?`, tag).Scan(&tagID); err != nil {
+		return false, err
+	}
+	res, err := tx.ExecContext(ctx, `INSERT INTO profile_tags (profile_id, tag_id, created_at) VALUES (?, ?, ?) ON CONFLICT DO NOTHING`,
+		profileID, tagID, now)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, tx.Commit()
+}
+
+// RemoveTag detaches tag from a profile and reports whether it was attached.
+// The tag itself is kept so it still shows up in ListTags.
+func (s *Store) RemoveTag(ctx context.Context, profileID int64, tag string) (bool, error) {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM profile_tags
+	WHERE profile_id = ? AND tag_id IN (SELECT id FROM tags WHERE name = ?)`, profileID, tag)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// GetProfileTags returns the tags of a profile in name order.
+func (s *Store) GetProfileTags(ctx context.Context, profileID int64) ([]string, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT t.name FROM profile_tags pt JOIN tags t ON t.id = pt.tag_id
+	WHERE pt.profile_id = ? ORDER BY t.name`, profileID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		out = append(out, name)
+	}
+	return out, rows.Err()
+}
+
+// TagCount is a tag and how many profiles carry it.
+type TagCount struct {
+	Name     string
+	Profiles int
+}
+
+// ListTags returns every known tag with its profile count, in name order.
+func (s *Store) ListTags(ctx context.Context) ([]TagCount, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT t.name, COUNT(pt.profile_id) FROM tags t
+	LEFT JOIN profile_tags pt ON pt.tag_id = t.id GROUP BY t.id, t.name ORDER BY t.name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []TagCount
+	for rows.Next() {
+		var tc TagCount
+		if err := rows.Scan(&tc.Name, &tc.Profiles); err != nil {
+			return nil, err
+		}
+		out = append(out, tc)
+	}
+	return out, rows.Err()
+}
+
+// Note is a free-text note on a profile.
+type Note struct {
+	ID        int64
+	ProfileID int64
+	Body      string
+	CreatedAt time.Time
+}
+
+// AddNote appends a note to a profile.
+func (s *Store) AddNote(ctx context.Context, profileID int64, body string) error {
+	_, err := s.db.ExecContext(ctx, `INSERT INTO profile_notes (profile_id, body, created_at) VALUES (?, ?, ?)`,
+		profileID, body, time.Now())
+	return err
+}
+
+// GetNotes returns the notes of a profile, oldest first.
+func (s *Store) GetNotes(ctx context.Context, profileID int64) ([]Note, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT id, profile_id, body, created_at FROM profile_notes
+	WHERE profile_id = ? ORDER BY created_at, id`, profileID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Note
+	for rows.Next() {
+		var n Note
+		if err := rows.Scan(&n.ID, &n.ProfileID, &n.Body, &n.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, n)
+	}
+	return out, rows.Err()
+}
+
+// taggedWith restricts a profile query to rows carrying any of tags; idCol
+// names the profile id column of the outer query.
+func taggedWith(idCol string, tags []string) (string, []any) {
+	if len(tags) == 0 {
+		return "", nil
+	}
+	args := make([]any, len(tags))
+	for i, t := range tags {
+		args[i] = t
+	}
+	return ` AND EXISTS (SELECT 1 FROM profile_tags pt JOIN tags t ON t.id = pt.tag_id
+		WHERE pt.profile_id = ` + idCol + ` AND t.name IN (?` + strings.Repeat(", ?", len(tags)-1) + `))`, args
+}
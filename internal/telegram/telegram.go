@@ -0,0 +1,193 @@
+package telegram
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/example/linkedbot/internal/config"
+	"github.com/example/linkedbot/internal/logging"
+)
+
+const apiURL = "https://api.telegram.org/bot"
+
+// pollTimeout is how long one getUpdates call waits for a message.
+const pollTimeout = 30 * time.Second
+
+// Command handles "/name args" sent to the bot and returns the reply.
+type Command struct {
+	Help string
+	Run  func(ctx context.Context, args string) (string, error)
+}
+
+// Bot talks to one Telegram chat through the Bot API: Send pushes messages
+// to it and Listen answers the commands registered with Handle. Messages
+// from any other chat are ignored.
+type Bot struct {
+	cfg      *config.Config
+	token    string
+	client   *http.Client
+	commands map[string]Command
+	log      *logging.Logger
+}
+
+func New(cfg *config.Config) *Bot {
+	return &Bot{
+		cfg:      cfg,
+		token:    cfg.TelegramToken(),
+		client:   &http.Client{Timeout: pollTimeout + time.Duration(cfg.Notifications.TimeoutSec)*time.Second},
+		commands: map[string]Command{},
+		log:      logging.New(cfg.Logging.Level).With("module", "telegram"),
+	}
+}
+
+// Handle registers cmd for /name.
+func (b *Bot) Handle(name string, cmd Command) { b.commands[name] = cmd }
+
+// Send posts text to the configured chat.
+func (b *Bot) Send(ctx context.Context, text string) error {
+	return b.call(ctx, "sendMessage", map[string]any{"chat_id": b.cfg.Notifications.Telegram.ChatID, "text": text, "disable_web_page_preview": true}, nil)
+}
+
+type update struct {
+	UpdateID int64 `json:"update_id"`
+	Message  *struct {
+		Chat struct {
+			ID int64 `json:"id"`
+		} `json:"chat"`
+		Text string `json:"text"`
+	} `json:"message"`
+}
+
+// Listen long-polls for messages until ctx is cancelled and answers the
+// commands among them. Messages sent while it was not running are dropped
+// on start, so a stale /resume is never acted on.
+func (b *Bot) Listen(ctx context.Context) error {
+	offset, err := b.skipPending(ctx)
+	if err != nil {
+		return err
+	}
+	b.log.Info("listening for commands", "chat_id", b.cfg.Notifications.Telegram.ChatID)
+	for ctx.Err() == nil {
+		var updates []update
+		if err := b.call(ctx, "getUpdates", map[string]any{"offset": offset, "timeout": int(pollTimeout.Seconds()), "allowed_updates": []string{"message"}}, &updates); err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			b.log.Warn("polling failed", "err", err)
+			select {
+			case <-ctx.Done():
+			case <-time.After(10 * time.Second):
+			}
+			continue
+		}
+		for _, u := range updates {
+			offset = u.UpdateID + 1
+			if u.Message == nil || u.Message.Chat.ID != b.cfg.Notifications.Telegram.ChatID {
+				continue
+			}
+			if reply := b.dispatch(ctx, u.Message.Text); reply != "" {
+				if err := b.Send(ctx, reply); err != nil {
+					b.log.Warn("reply failed", "err", err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// skipPending confirms the updates queued before start and returns the
+// offset after them.
+func (b *Bot) skipPending(ctx context.Context) (int64, error) {
+	var updates []update
+	if err := b.call(ctx, "getUpdates", map[string]any{"offset": -1, "timeout": 0}, &updates); err != nil {
+		return 0, err
+	}
+	if len(updates) == 0 {
+		return 0, nil
+	}
+	return updates[len(updates)-1].UpdateID + 1, nil
+}
+
+// dispatch runs the command in text and returns the reply; anything that
+// isn't a command gets none.
+func (b *Bot) dispatch(ctx context.Context, text string) string {
+	if !strings.HasPrefix(text, "/") {
+		return ""
+	}
+	name, args, _ := strings.Cut(strings.TrimPrefix(text, "/"), " ")
+	// Commands may be addressed as /stats@my_bot in groups
+	name, _, _ = strings.Cut(name, "@")
+	cmd, ok := b.commands[name]
+	if !ok {
+		return b.help()
+	}
+	b.log.Info("command received", "command", name)
+	reply, err := cmd.Run(ctx, strings.TrimSpace(args))
+	if err != nil {
+		return fmt.Sprintf("/%s failed: %v", name, err)
+	}
+	return reply
+}
+
+func (b *Bot) help() string {
+	names := make([]string, 0, len(b.commands))
+	for n := range b.commands {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	var sb strings.Builder
+	sb.WriteString("Commands:")
+	for _, n := range names {
+		fmt.Fprintf(&sb, "\n/%s - %s", n, b.commands[n].Help)
+	}
+	return sb.String()
+}
+
+// call posts params to the Bot API method and decodes its result into out.
+func (b *Bot) call(ctx context.Context, method string, params map[string]any, out any) error {
+	if b.token == "" {
+		return fmt.Errorf("%s is not set", b.cfg.Notifications.Telegram.TokenEnv)
+	}
+	body, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, apiURL+b.token+"/"+method, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := b.client.Do(req)
+	if err != nil {
+		// The URL holds the token; keep it out of logs
+		var uerr *url.Error
+		if errors.As(err, &uerr) {
+			err = uerr.Err
+		}
+		return fmt.Errorf("%s: %w", method, err)
+	}
+	defer resp.Body.Close()
+	var res struct {
+		OK          bool            `json:"ok"`
+		Description string          `json:"description"`
+		Result      json.RawMessage `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return fmt.Errorf("%s: %s", method, resp.Status)
+	}
+	if !res.OK {
+		return fmt.Errorf("%s: %s", method, res.Description)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(res.Result, out)
+}
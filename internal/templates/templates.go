@@ -0,0 +1,265 @@
+package templates
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"text/template"
+	"unicode/utf8"
+
+	"github.com/example/linkedbot/internal/models"
+)
+
+type Severity string
+
+const (
+	SeverityWarning Severity = "warning"
+	SeverityError   Severity = "error"
+)
+
+type Issue struct {
+	Severity Severity
+	Message  string
+}
+
+func (i Issue) String() string { return fmt.Sprintf("%s: %s", i.Severity, i.Message) }
+
+// Title cleanup modes for templates.title_cleanup.
+const (
+	TitleCleanupAggressive = "aggressive"
+	TitleCleanupMinimal    = "minimal"
+	TitleCleanupNone       = "none"
+)
+
+// NoteLimit is what connection notes are cut to, in characters, leaving a
+// margin inside LinkedIn's 300.
+const NoteLimit = 280
+
+// Data is what templates see. Name is the first name, kept under that name
+// so older templates keep working; FullName is the name as extracted.
+type Data struct {
+	Name     string
+	FullName string
+	Company  string
+	Title    string
+	Headline string
+	Location string
+	Keywords string
+}
+
+// Funcs are available to every template:
+//   - firstName: first word of a name, {{firstName .FullName}}
+//   - truncate: shorten to n characters at a word boundary, {{.Headline | truncate 40}}
+var Funcs = template.FuncMap{
+	"firstName": firstName,
+	"truncate":  truncate,
+}
+
+// legacyRe matches the pre-text/template placeholders ({{Name}}, {{ Company }})
+// which Parse rewrites to field references.
+var legacyRe = regexp.MustCompile(`\{\{\s*(Name|Company|Title|Keywords)\s*\}\}`)
+
+// Parse compiles a message template. Besides text/template syntax
+// ({{.Company}}, {{if .Company}}...{{end}}) the old {{Name}} style is accepted.
+func Parse(name, text string) (*template.Template, error) {
+	return template.New(name).Funcs(Funcs).Parse(legacyRe.ReplaceAllString(text, "{{.$1}}"))
+}
+
+// NewData builds the template data for a profile. The headline is shortened
+// to a job title according to cleanup; "at Company" is only cut off when the
+// template also uses the company, otherwise that context would be lost.
+func NewData(text string, p *models.Profile, cleanup string) Data {
+	mentionsCompany := strings.Contains(legacyRe.ReplaceAllString(text, "{{.$1}}"), ".Company")
+	return Data{
+		Name:     firstName(p.Name),
+		FullName: p.Name,
+		Company:  p.Company,
+		Title:    CleanTitle(p.Headline, cleanup, mentionsCompany),
+		Headline: p.Headline,
+		Location: p.Location,
+	}
+}
+
+// Render fills a template with profile data.
+func Render(text string, p *models.Profile, cleanup string) (string, error) {
+	t, err := Parse("message", text)
+	if err != nil {
+		return "", err
+	}
+	return execute(t, NewData(text, p, cleanup))
+}
+
+func execute(t *template.Template, d Data) (string, error) {
+	var b strings.Builder
+	if err := t.Execute(&b, d); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+func firstName(name string) string {
+	name = strings.TrimSpace(name)
+	if idx := strings.Index(name, " "); idx > 0 {
+		return name[:idx]
+	}
+	return name
+}
+
+// Truncate cuts s to at most n characters like the truncate template func.
+func Truncate(s string, n int) string { return truncate(n, s) }
+
+// truncate cuts s to at most n characters, preferring a word boundary in the
+// second half.
+func truncate(n int, s string) string {
+	r := []rune(s)
+	if n < 0 || len(r) <= n {
+		return s
+	}
+	cut := string(r[:n])
+	if idx := strings.LastIndex(cut, " "); idx >= len(cut)/2 {
+		cut = cut[:idx]
+	}
+	return strings.TrimRight(cut, " ,;:-")
+}
+
+// Fit cuts s to at most n characters like Truncate and reports whether
+// anything was cut. Characters are runes, as LinkedIn counts them.
+func Fit(s string, n int) (string, bool) {
+	if utf8.RuneCountInString(s) <= n {
+		return s, false
+	}
+	return truncate(n, s), true
+}
+
+// CleanTitle shortens a headline for use as {{Title}}.
+//   - aggressive: cut at "@", "|" and, when stripCompany is set, " at "
+//   - minimal: cut at "|" only
+//   - none: headline as is
+func CleanTitle(title, cleanup string, stripCompany bool) string {
+	switch cleanup {
+	case TitleCleanupNone:
+		return title
+	case TitleCleanupMinimal:
+		if idx := strings.Index(title, "|"); idx > 0 {
+			title = strings.TrimSpace(title[:idx])
+		}
+	default:
+		// Remove everything after @ or | symbols
+		if idx := strings.Index(title, "@"); idx > 0 {
+			title = strings.TrimSpace(title[:idx])
+		} else if idx := strings.Index(title, "|"); idx > 0 {
+			title = strings.TrimSpace(title[:idx])
+		} else if idx := strings.Index(title, " at "); idx > 0 && stripCompany {
+			// Handle "Software Engineer at Company" format
+			title = strings.TrimSpace(title[:idx])
+		}
+	}
+
+	// Limit title length to avoid exceeding message limits, counting
+	// characters so non-Latin headlines aren't cut mid-character
+	title, _ = Fit(title, 50)
+	return title
+}
+
+// Tokens are the fields templates can reference.
+var Tokens = []string{"Name", "FullName", "Company", "Title", "Headline", "Location", "Keywords"}
+
+// Sample is a named profile used to preview templates.
+type Sample struct {
+	Name    string
+	Profile models.Profile
+}
+
+// Samples are the profiles `templates validate` renders every template
+// against: a typical one, one where only the name is known, and one with
+// worst-case lengths.
+var Samples = []Sample{
+	{"typical", models.Profile{Name: "Priya Sharma", Headline: "Senior Software Engineer at Acme | Go, Kubernetes", Company: "Acme", Location: "Bengaluru, India"}},
+	{"name-only", models.Profile{Name: "Alex"}},
+	{"long", models.Profile{Name: strings.Repeat("N", 30), Headline: strings.Repeat("T", 120), Company: strings.Repeat("C", 60), Location: strings.Repeat("L", 60)}},
+}
+
+// longData holds worst-case values used to estimate rendered length.
+var longData = Data{
+	Name:     strings.Repeat("N", 30),
+	FullName: strings.Repeat("N", 60),
+	Company:  strings.Repeat("C", 60),
+	Title:    strings.Repeat("T", 50), // renderers cap titles at 50 chars
+	Headline: strings.Repeat("H", 120),
+	Location: strings.Repeat("L", 60),
+	Keywords: strings.Repeat("K", 60),
+}
+
+var (
+	actionRe = regexp.MustCompile(`\{\{[^{}]*\}\}`)
+	orphanRe = regexp.MustCompile(`\s[,.;:!?]|\(\s*\)|\s{2,}|\b(?i:at|as|around|in|for|of|with)\s*([,.;:!?]|$)`)
+)
+
+// MaxLength returns how many characters text renders to with long field
+// values, the length it risks reaching for some profile.
+func MaxLength(text string) (int, error) {
+	t, err := Parse("template", text)
+	if err != nil {
+		return 0, err
+	}
+	long, err := execute(t, longData)
+	if err != nil {
+		return 0, err
+	}
+	return utf8.RuneCountInString(long), nil
+}
+
+// Lint checks a message template and reports problems that would make the
+// rendered message look broken. maxLen is the rendered length budget in
+// characters; 0 disables the length check.
+func Lint(text string, maxLen int) []Issue {
+	var issues []Issue
+	if strings.TrimSpace(text) == "" {
+		return []Issue{{SeverityError, "template is empty"}}
+	}
+
+	// Stray braces are plain text to text/template and would be sent as is
+	rest := actionRe.ReplaceAllString(text, "")
+	if strings.Contains(rest, "{") {
+		issues = append(issues, Issue{SeverityError, "opening brace without matching closing braces"})
+	}
+	if strings.Contains(rest, "}") {
+		issues = append(issues, Issue{SeverityError, "closing brace without matching opening braces"})
+	}
+
+	t, err := Parse("template", text)
+	if err != nil {
+		return append(issues, Issue{SeverityError, err.Error()})
+	}
+	long, err := execute(t, longData)
+	if err != nil {
+		return append(issues, Issue{SeverityError, fmt.Sprintf("%v (known fields: %s)", err, strings.Join(Tokens, ", "))})
+	}
+	if maxLen > 0 {
+		if n := utf8.RuneCountInString(long); n > maxLen {
+			issues = append(issues, Issue{SeverityWarning, fmt.Sprintf("may render to %d characters with long values (limit %d)", n, maxLen)})
+		}
+	}
+
+	if rendered, err := execute(t, Data{Name: "Alex", FullName: "Alex"}); err == nil && orphanRe.MatchString(rendered) {
+		issues = append(issues, Issue{SeverityWarning, fmt.Sprintf("leaves orphaned punctuation or spacing when optional fields are empty: %q", rendered)})
+	}
+
+	for i, r := range text {
+		if r > 127 {
+			issues = append(issues, Issue{SeverityWarning, fmt.Sprintf("non-ASCII character %q at offset %d may be rejected or mangled", r, i)})
+			break
+		}
+	}
+	return issues
+}
+
+// HasErrors reports whether any issue is an error rather than a warning.
+func HasErrors(issues []Issue) bool {
+	for _, i := range issues {
+		if i.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
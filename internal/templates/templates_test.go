@@ -0,0 +1,88 @@
+package templates
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/example/linkedbot/internal/models"
+)
+
+func TestLintRules(t *testing.T) {
+	cases := []struct {
+		name     string
+		text     string
+		maxLen   int
+		severity Severity
+		message  string
+	}{
+		{"empty", "  \n", 0, SeverityError, "template is empty"},
+		{"unclosed action", "Hi {{.Name}, great to meet you", 0, SeverityError, "opening brace without matching closing braces"},
+		{"stray closing", "Hi {{.Name}} }} great to meet you", 0, SeverityError, "closing brace without matching opening braces"},
+		{"unknown token", "Hi {{.Nmae}}, great to meet you", 0, SeverityError, "known fields:"},
+		{"over length", "Hi {{.Name}}, I saw {{.Headline}} and {{.Company}}", 100, SeverityWarning, "(limit 100)"},
+		{"orphaned punctuation", "Hi {{.Name}}, fellow {{.Title}} at {{.Company}}.", 0, SeverityWarning, "orphaned punctuation"},
+		{"non-ASCII", "Hi {{.Name}} 👋 great to meet you", 0, SeverityWarning, "non-ASCII character"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			issues := Lint(c.text, c.maxLen)
+			for _, i := range issues {
+				if i.Severity == c.severity && strings.Contains(i.Message, c.message) {
+					return
+				}
+			}
+			t.Errorf("Lint(%q) = %v, want %s containing %q", c.text, issues, c.severity, c.message)
+		})
+	}
+}
+
+func TestLintCleanTemplate(t *testing.T) {
+	text := "Hi {{.Name}}{{if .Company}}, I see you're at {{.Company}}{{end}}. Would be great to connect!"
+	if issues := Lint(text, NoteLimit); len(issues) != 0 {
+		t.Errorf("Lint(%q) = %v, want no issues", text, issues)
+	}
+}
+
+func TestCleanTitleModes(t *testing.T) {
+	long := strings.Repeat("Principal ", 8) + "Engineer"
+	cases := []struct {
+		title, cleanup string
+		stripCompany   bool
+		want           string
+	}{
+		{"Engineer at Acme", TitleCleanupAggressive, true, "Engineer"},
+		{"Engineer at a cool startup", TitleCleanupAggressive, false, "Engineer at a cool startup"},
+		{"Engineer @ Acme | Go, Kubernetes", TitleCleanupAggressive, false, "Engineer"},
+		{"Engineer at Acme | Go, Kubernetes", TitleCleanupAggressive, true, "Engineer at Acme"},
+		{long, TitleCleanupAggressive, true, "Principal Principal Principal Principal Principal"},
+		{"Engineer at Acme | Go, Kubernetes", TitleCleanupMinimal, true, "Engineer at Acme"},
+		{"Engineer @ Acme", TitleCleanupMinimal, true, "Engineer @ Acme"},
+		{long, TitleCleanupMinimal, false, "Principal Principal Principal Principal Principal"},
+		{"Engineer at Acme | Go, Kubernetes", TitleCleanupNone, true, "Engineer at Acme | Go, Kubernetes"},
+		{long, TitleCleanupNone, false, long},
+	}
+	for _, c := range cases {
+		if got := CleanTitle(c.title, c.cleanup, c.stripCompany); got != c.want {
+			t.Errorf("CleanTitle(%q, %s, %v) = %q, want %q", c.title, c.cleanup, c.stripCompany, got, c.want)
+		}
+	}
+}
+
+func TestRenderStripsCompanyOnlyWhenUsed(t *testing.T) {
+	p := &models.Profile{Name: "Jane Doe", Headline: "Engineer at a cool startup", Company: "a cool startup"}
+	cases := map[string]string{
+		"I liked your work as {{.Title}}":                 "I liked your work as Engineer at a cool startup",
+		"I liked your work as {{.Title}} at {{.Company}}": "I liked your work as Engineer at a cool startup",
+		"I liked your work as {{Title}} at {{ Company }}": "I liked your work as Engineer at a cool startup",
+		"{{.Title}}, {{.Company}}":                        "Engineer, a cool startup",
+	}
+	for text, want := range cases {
+		got, err := Render(text, p, TitleCleanupAggressive)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("Render(%q) = %q, want %q", text, got, want)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/example/linkedbot/internal/config"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/example/linkedbot"
+
+// Setup installs the OTLP exporter from cfg.Tracing as the global tracer
+// provider and returns the function that flushes and stops it. With no
+// endpoint configured nothing is installed and spans cost next to nothing.
+func Setup(ctx context.Context, cfg *config.Config) (func(context.Context) error, error) {
+	if cfg.Tracing.Endpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+	exp, err := otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(cfg.Tracing.Endpoint), otlptracehttp.WithHeaders(cfg.Tracing.Headers))
+	if err != nil {
+		return nil, fmt.Errorf("otlp exporter: %w", err)
+	}
+	res := resource.NewSchemaless(
+		attribute.String("service.name", cfg.Tracing.ServiceName),
+		attribute.String("linkedbot.account", cfg.AccountKey()),
+	)
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exp),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.Tracing.SampleRatio))),
+	)
+	otel.SetTracerProvider(tp)
+	return tp.Shutdown, nil
+}
+
+// Start opens a span named name as a child of any span in ctx.
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End closes span, marking it failed with err when there is one.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Profile is the attribute set identifying the profile a span works on.
+func Profile(id int64, url string) []attribute.KeyValue {
+	return []attribute.KeyValue{attribute.Int64("profile.id", id), attribute.String("profile.url", url)}
+}
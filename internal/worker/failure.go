@@ -0,0 +1,67 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"github.com/example/linkedbot/internal/browser"
+	"github.com/example/linkedbot/internal/driver"
+)
+
+// Reasons a job failed, recorded with it for `jobs` and retry-failed.
+const (
+	ReasonSelectorNotFound   = "selector_not_found"
+	ReasonNavigationTimeout  = "navigation_timeout"
+	ReasonProfileUnavailable = "profile_unavailable"
+	ReasonRateLimited        = "rate_limited"
+	ReasonOther              = "other"
+)
+
+// Reasons lists every failure reason.
+var Reasons = []string{ReasonSelectorNotFound, ReasonNavigationTimeout, ReasonProfileUnavailable, ReasonRateLimited, ReasonOther}
+
+// RetryableReasons are the failures retry-failed puts back in the queue
+// unless told otherwise: the page or LinkedIn may behave on a later try.
+var RetryableReasons = []string{ReasonSelectorNotFound, ReasonNavigationTimeout, ReasonRateLimited}
+
+// Failure is an error a handler has already classified.
+type Failure struct {
+	Reason string
+	Err    error
+}
+
+func (f *Failure) Error() string { return f.Err.Error() }
+func (f *Failure) Unwrap() error { return f.Err }
+
+// Fail tags err with reason, for failures Classify can't tell from the
+// error alone.
+func Fail(reason string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &Failure{Reason: reason, Err: err}
+}
+
+// Classify returns the reason a job failed with err: the one given to Fail,
+// else profile_unavailable for browser.ErrProfileUnavailable,
+// navigation_timeout for pages that didn't load, selector_not_found for
+// elements that never showed, and other for the rest.
+func Classify(err error) string {
+	var f *Failure
+	switch {
+	case errors.As(err, &f):
+		return f.Reason
+	case errors.Is(err, browser.ErrProfileUnavailable):
+		return ReasonProfileUnavailable
+	case errors.Is(err, browser.ErrNavigation):
+		return ReasonNavigationTimeout
+	case errors.Is(err, driver.ErrNotFound), errors.Is(err, context.DeadlineExceeded):
+		return ReasonSelectorNotFound
+	}
+	return ReasonOther
+}
+
+// Retryable reports whether retry-failed puts failures of reason back by
+// default.
+func Retryable(reason string) bool { return slices.Contains(RetryableReasons, reason) }
@@ -0,0 +1,225 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/example/linkedbot/internal/browser"
+	"github.com/example/linkedbot/internal/config"
+	"github.com/example/linkedbot/internal/driver"
+	"github.com/example/linkedbot/internal/logging"
+	"github.com/example/linkedbot/internal/models"
+	"github.com/example/linkedbot/internal/ratelimit"
+	"github.com/example/linkedbot/internal/retry"
+	"github.com/example/linkedbot/internal/session"
+	"github.com/example/linkedbot/internal/stealth"
+	"github.com/example/linkedbot/internal/store"
+	"github.com/example/linkedbot/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// ErrSkip ends a job without retrying it, for profiles that turned out not
+// to need the action (excluded, already connected, ...). Handlers wrap it
+// with the reason.
+var ErrSkip = errors.New("skipped")
+
+// ErrStop ends the job as failed, to be retried later, and the run with
+// it: LinkedIn refused the action in a way the remaining jobs would run
+// into as well, such as the weekly invitation limit. Handlers wrap it with
+// the reason, which Run returns.
+var ErrStop = errors.New("run stopped")
+
+// maxBackoff caps the doubling retry delay.
+const maxBackoff = 24 * time.Hour
+
+// Handler performs one job on the worker's page. Errors wrapping ErrSkip
+// end the job as skipped, retry.Permanent ones fail it at once and anything
+// else is retried later with backoff; ErrStop also ends the run.
+type Handler struct {
+	// Limit is the rate limit a job waits for and is counted against
+	Limit ratelimit.Kind
+	Run   func(ctx context.Context, p driver.Page, job *store.Job, prof *models.Profile) error
+}
+
+// Worker runs due jobs from the jobs table with the handler of their kind.
+type Worker struct {
+	br       *browser.Browser
+	cfg      *config.Config
+	st       *store.Store
+	rl       *ratelimit.Limiter
+	ss       *session.Session
+	handlers map[string]Handler
+	log      *logging.Logger
+}
+
+func New(br *browser.Browser, cfg *config.Config, st *store.Store) *Worker {
+	return &Worker{br: br, cfg: cfg, st: st, rl: ratelimit.New(cfg, st), ss: session.New(cfg), handlers: map[string]Handler{}, log: logging.New(cfg.Logging.Level).With("module", "worker")}
+}
+
+// Handle registers the handler for jobs of kind.
+func (w *Worker) Handle(kind string, h Handler) { w.handlers[kind] = h }
+
+// Run works through due jobs of kinds (every registered kind when none are
+// given), oldest first, so different kinds interleave. It stops after limit
+// attempts, when nothing is due, or when ctx is cancelled; the job in flight
+// is finished first. A kind whose rate limit is used up is left for later.
+func (w *Worker) Run(ctx context.Context, limit int, kinds ...string) (models.RunStats, error) {
+	var stats models.RunStats
+	if len(kinds) == 0 {
+		for k := range w.handlers {
+			kinds = append(kinds, k)
+		}
+	}
+	kinds = slices.Clone(kinds)
+	if n, err := w.st.RecoverJobs(ctx, time.Now().Add(-time.Duration(w.cfg.Jobs.StaleMinutes)*time.Minute)); err != nil {
+		return stats, err
+	} else if n > 0 {
+		w.log.Info("re-queued interrupted jobs", "count", n)
+	}
+
+	var p driver.Page
+	defer func() {
+		if p != nil {
+			w.br.ClosePage(p)
+		}
+	}()
+	work := context.WithoutCancel(ctx)
+	for attempted := 0; attempted < limit && len(kinds) > 0 && ctx.Err() == nil; {
+		kind, err := w.st.NextJobKind(ctx, kinds, time.Now())
+		if err != nil {
+			return stats, err
+		}
+		if kind == "" {
+			break
+		}
+		h, ok := w.handlers[kind]
+		if !ok {
+			return stats, fmt.Errorf("no handler for %s jobs", kind)
+		}
+		// Wait before claiming, so a job is never left running through a
+		// long pacing or schedule wait
+		if err := w.rl.Wait(ctx, h.Limit); err != nil {
+			if !errors.Is(err, ratelimit.ErrExhausted) {
+				break
+			}
+			w.log.Info("leaving jobs for later", "kind", kind, "reason", err)
+			kinds = slices.DeleteFunc(kinds, func(k string) bool { return k == kind })
+			continue
+		}
+		// The session browsing happens before claiming too, for the same
+		// reason
+		if p == nil {
+			if p, err = w.br.NewPage(ctx); err != nil {
+				return stats, err
+			}
+			w.ss.Open(ctx, p)
+		} else {
+			w.ss.Between(ctx, p)
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		job, err := w.st.ClaimJob(ctx, []string{kind}, time.Now())
+		if err != nil {
+			return stats, err
+		}
+		if job == nil {
+			// Another worker took it meanwhile
+			continue
+		}
+		attempted++
+		done, err := w.runOne(work, p, h, job, &stats)
+		if err != nil {
+			w.log.Warn("stopping run", "kind", kind, "reason", err)
+			return stats, err
+		}
+		if done {
+			stealth.SleepRandom(w.cfg.Stealth.MinDelayMs+300, w.cfg.Stealth.MaxDelayMs+900)
+		}
+	}
+	if ctx.Err() != nil {
+		w.log.Info("interrupted, remaining jobs stay queued")
+	}
+	return stats, nil
+}
+
+// runOne runs job and records the outcome. It reports whether the action
+// was done, and the handler's error when it wraps ErrStop. The job is a
+// trace span the handler's steps nest under.
+func (w *Worker) runOne(ctx context.Context, p driver.Page, h Handler, job *store.Job, stats *models.RunStats) (bool, error) {
+	ctx, span := tracing.Start(ctx, job.Kind+" job", attribute.Int64("job.id", job.ID), attribute.Int("job.attempt", job.Attempts), attribute.Int64("profile.id", job.ProfileID))
+	prof, err := w.st.GetProfile(ctx, job.ProfileID)
+	defer func() {
+		if errors.Is(err, ErrSkip) {
+			span.SetAttributes(attribute.String("job.skipped", err.Error()))
+			err = nil
+		}
+		tracing.End(span, err)
+	}()
+	if err == nil && prof == nil {
+		err = fmt.Errorf("%w: profile %d is gone", ErrSkip, job.ProfileID)
+	}
+	if err == nil {
+		if dnc := w.st.CheckDoNotContact(ctx, prof.LinkedInURL, job.Kind); dnc != nil {
+			err = fmt.Errorf("%w: %w", ErrSkip, dnc)
+		}
+	}
+	if err == nil {
+		w.log.Info("running job", "job", job.ID, "kind", job.Kind, "url", prof.LinkedInURL, "attempt", job.Attempts)
+		err = h.Run(ctx, p, job, prof)
+	}
+
+	switch {
+	case err == nil:
+		if err := w.st.CompleteJob(ctx, job.ID); err != nil {
+			w.log.Warn("failed to complete job", "job", job.ID, "err", err)
+		}
+		w.rl.Record(ctx, h.Limit)
+		stats.Sent++
+		return true, nil
+	case errors.Is(err, ErrSkip):
+		w.log.Info("job skipped", "job", job.ID, "kind", job.Kind, "reason", err)
+		if err := w.st.SkipJob(ctx, job.ID, err.Error()); err != nil {
+			w.log.Warn("failed to record skipped job", "job", job.ID, "err", err)
+		}
+		stats.Skipped++
+		return false, nil
+	}
+	reason := Classify(err)
+	var next time.Time
+	// A profile that is gone stays gone
+	if !retry.IsPermanent(err) && reason != ReasonProfileUnavailable {
+		next = time.Now().Add(w.backoff(job.Attempts))
+	}
+	again, rerr := w.st.RetryJob(ctx, job, err, reason, next)
+	if rerr != nil {
+		w.log.Warn("failed to record job failure", "job", job.ID, "err", rerr)
+	}
+	url := fmt.Sprintf("profile %d", job.ProfileID)
+	if prof != nil {
+		url = prof.LinkedInURL
+	}
+	if again {
+		w.log.Warn("job failed, will retry", "job", job.ID, "kind", job.Kind, "url", url, "attempt", job.Attempts, "next_run_at", next.Format(time.RFC3339), "reason", reason, "err", err)
+	} else {
+		w.log.Warn("job failed for good", "job", job.ID, "kind", job.Kind, "url", url, "attempts", job.Attempts, "reason", reason, "err", err)
+	}
+	stats.Fail(url, err)
+	if errors.Is(err, ErrStop) {
+		return false, err
+	}
+	return false, nil
+}
+
+// backoff is the wait after the given failed attempt: jobs.backoff_minutes,
+// doubled for every earlier failure.
+func (w *Worker) backoff(attempt int) time.Duration {
+	d := time.Duration(w.cfg.Jobs.BackoffMinutes) * time.Minute
+	for i := 1; i < attempt && d < maxBackoff; i++ {
+		d *= 2
+	}
+	return min(d, maxBackoff)
+}